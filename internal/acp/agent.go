@@ -0,0 +1,583 @@
+package acp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// Backend is what the agent works with; the app implements it.
+type Backend interface {
+	CreateSession(ctx context.Context, title string) (session.Session, error)
+	GetSession(ctx context.Context, id string) (session.Session, error)
+	ListMessages(ctx context.Context, sessionID string) ([]message.Message, error)
+	SendMessage(ctx context.Context, sessionID, prompt string, attachments []message.Attachment) (*fantasy.AgentResult, error)
+	CancelSession(sessionID string)
+	SubscribeMessages(ctx context.Context) <-chan pubsub.Event[message.Message]
+	SubscribePermissions(ctx context.Context) <-chan pubsub.Event[permission.PermissionRequest]
+	// GrantPermission allows the request, and identical ones for the rest of
+	// the session when always is set.
+	GrantPermission(req permission.PermissionRequest, always bool)
+	DenyPermission(req permission.PermissionRequest)
+}
+
+// permissionOptions are the choices offered when the agent asks for a
+// permission.
+var permissionOptions = []permissionOption{
+	{OptionID: optionAllow, Name: "Allow", Kind: "allow_once"},
+	{OptionID: optionAllowAlways, Name: "Allow for this session", Kind: "allow_always"},
+	{OptionID: optionReject, Name: "Deny", Kind: "reject_once"},
+}
+
+// maxSessionDepth bounds the search of the session a sub-agent works for.
+const maxSessionDepth = 8
+
+// server is the agent side of a connection.
+type server struct {
+	backend    Backend
+	workingDir string
+	conn       *jsonrpc2.Conn
+
+	mu sync.Mutex
+	// sessions holds what was sent of the sessions the client created or
+	// loaded.
+	sessions map[string]*stream
+}
+
+// stream is what was sent about a session, so only changes are sent.
+type stream struct {
+	text     map[string]int
+	thinking map[string]int
+	calls    map[string]message.ToolCall
+	results  map[string]bool
+}
+
+func newStream() *stream {
+	return &stream{
+		text:     make(map[string]int),
+		thinking: make(map[string]int),
+		calls:    make(map[string]message.ToolCall),
+		results:  make(map[string]bool),
+	}
+}
+
+// Serve speaks the protocol with the client on r and w, the standard input
+// and output of the process the client started, until the client hangs up.
+// Permission requests of the sessions of the client are sent to it to be
+// answered.
+func Serve(ctx context.Context, r io.Reader, w io.Writer, workingDir string, backend Backend) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s := &server{
+		backend:    backend,
+		workingDir: workingDir,
+		sessions:   make(map[string]*stream),
+	}
+	messages := backend.SubscribeMessages(ctx)
+	permissions := backend.SubscribePermissions(ctx)
+
+	handler := jsonrpc2.HandlerWithError(s.handle).SuppressErrClosed()
+	s.conn = jsonrpc2.NewConn(ctx, jsonrpc2.NewPlainObjectStream(stdio{r, w}), jsonrpc2.AsyncHandler(handler), jsonrpc2.SetLogger(logger{}))
+	go s.forward(ctx, messages, permissions)
+
+	select {
+	case <-ctx.Done():
+		_ = s.conn.Close()
+		return ctx.Err()
+	case <-s.conn.DisconnectNotify():
+		return nil
+	}
+}
+
+func (s *server) handle(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+	switch req.Method {
+	case methodInitialize:
+		var params initializeParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		slog.Info("ACP client connected", "protocol_version", params.ProtocolVersion)
+		return initializeResult{
+			ProtocolVersion: ProtocolVersion,
+			AgentCapabilities: agentCapabilities{
+				LoadSession:        true,
+				PromptCapabilities: promptCapabilities{Image: true, EmbeddedContext: true},
+			},
+			AuthMethods: []any{},
+		}, nil
+
+	case methodAuthenticate:
+		// Providers are configured in crush itself.
+		return struct{}{}, nil
+
+	case methodSessionNew:
+		var params newSessionParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		if err := s.checkCwd(params.Cwd); err != nil {
+			return nil, err
+		}
+		sess, err := s.backend.CreateSession(ctx, "New session")
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.sessions[sess.ID] = newStream()
+		s.mu.Unlock()
+		return newSessionResult{SessionID: sess.ID}, nil
+
+	case methodSessionLoad:
+		var params loadSessionParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		if err := s.checkCwd(params.Cwd); err != nil {
+			return nil, err
+		}
+		return nil, s.load(ctx, params.SessionID)
+
+	case methodSessionPrompt:
+		var params promptParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		return s.prompt(ctx, params)
+
+	case methodSessionCancel:
+		var params cancelParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		s.backend.CancelSession(params.SessionID)
+		return nil, nil
+
+	default:
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: "unknown method: " + req.Method}
+	}
+}
+
+// checkCwd refuses sessions in another directory than the one crush was
+// started in, since its tools and configuration belong to that one.
+func (s *server) checkCwd(cwd string) error {
+	if cwd == "" || sameDir(cwd, s.workingDir) {
+		return nil
+	}
+	return &jsonrpc2.Error{
+		Code:    jsonrpc2.CodeInvalidParams,
+		Message: fmt.Sprintf("crush serves %s; start it in %s for a session there", s.workingDir, cwd),
+	}
+}
+
+func sameDir(a, b string) bool {
+	if resolved, err := filepath.EvalSymlinks(a); err == nil {
+		a = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(b); err == nil {
+		b = resolved
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+// load replays a stored session to the client, its prompts included.
+func (s *server) load(ctx context.Context, sessionID string) error {
+	if _, err := s.backend.GetSession(ctx, sessionID); err != nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: fmt.Sprintf("unknown session: %s", sessionID)}
+	}
+	messages, err := s.backend.ListMessages(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := newStream()
+	s.sessions[sessionID] = st
+	for _, msg := range messages {
+		s.send(ctx, st, msg, true)
+	}
+	return nil
+}
+
+// prompt runs a prompt turn, streaming the answer as it comes.
+func (s *server) prompt(ctx context.Context, params promptParams) (any, error) {
+	s.mu.Lock()
+	_, ok := s.sessions[params.SessionID]
+	s.mu.Unlock()
+	if !ok {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: fmt.Sprintf("unknown session: %s", params.SessionID)}
+	}
+	prompt, attachments, err := promptContent(s.workingDir, params.Prompt)
+	if err != nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: err.Error()}
+	}
+
+	result, err := s.backend.SendMessage(ctx, params.SessionID, prompt, attachments)
+	// Send what the events have not yet before the turn ends.
+	s.flush(ctx, params.SessionID)
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, agent.ErrRequestCancelled):
+		return promptResult{StopReason: stopCancelled}, nil
+	case err != nil:
+		return nil, err
+	case result != nil && result.Response.FinishReason == fantasy.FinishReasonLength:
+		return promptResult{StopReason: stopMaxTokens}, nil
+	default:
+		return promptResult{StopReason: stopEndTurn}, nil
+	}
+}
+
+func (s *server) flush(ctx context.Context, sessionID string) {
+	messages, err := s.backend.ListMessages(ctx, sessionID)
+	if err != nil {
+		slog.Error("Failed to list messages", "session_id", sessionID, "error", err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.sessions[sessionID]; ok {
+		for _, msg := range messages {
+			s.send(ctx, st, msg, false)
+		}
+	}
+}
+
+// forward streams the messages of the sessions of the client and asks it
+// for the permissions they need.
+func (s *server) forward(ctx context.Context, messages <-chan pubsub.Event[message.Message], permissions <-chan pubsub.Event[permission.PermissionRequest]) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-messages:
+			if !ok {
+				return
+			}
+			s.mu.Lock()
+			if st, ok := s.sessions[event.Payload.SessionID]; ok {
+				s.send(ctx, st, event.Payload, false)
+			}
+			s.mu.Unlock()
+		case event, ok := <-permissions:
+			if !ok {
+				return
+			}
+			if event.Type == pubsub.CreatedEvent {
+				go s.askPermission(ctx, event.Payload)
+			}
+		}
+	}
+}
+
+// send sends what changed in msg since it was last sent. Prompts are only
+// sent when replaying a session, since the client shows them already. s.mu
+// must be held, which keeps the updates in order.
+func (s *server) send(ctx context.Context, st *stream, msg message.Message, replay bool) {
+	var updates []sessionUpdate
+	switch msg.Role {
+	case message.User:
+		if replay && st.text[msg.ID] == 0 {
+			if text := msg.Content().Text; text != "" {
+				st.text[msg.ID] = len(text)
+				updates = append(updates, chunk(updateUserMessage, text))
+			}
+		}
+	case message.Assistant:
+		if thinking := msg.ReasoningContent().Thinking; len(thinking) > st.thinking[msg.ID] {
+			updates = append(updates, chunk(updateAgentThought, thinking[st.thinking[msg.ID]:]))
+			st.thinking[msg.ID] = len(thinking)
+		}
+		if text := msg.Content().Text; len(text) > st.text[msg.ID] {
+			updates = append(updates, chunk(updateAgentMessage, text[st.text[msg.ID]:]))
+			st.text[msg.ID] = len(text)
+		}
+		for _, call := range msg.ToolCalls() {
+			if _, sent := st.calls[call.ID]; sent || !call.Finished {
+				continue
+			}
+			st.calls[call.ID] = call
+			updates = append(updates, describeCall(call.ID, call.Name, call.Input).update(updateToolCall))
+		}
+	case message.Tool:
+		for _, result := range msg.ToolResults() {
+			if st.results[result.ToolCallID] {
+				continue
+			}
+			st.results[result.ToolCallID] = true
+			updates = append(updates, describeResult(st.calls[result.ToolCallID], result).update(updateToolCallEdit))
+		}
+	}
+	for _, update := range updates {
+		s.notify(ctx, msg.SessionID, update)
+	}
+}
+
+func (s *server) notify(ctx context.Context, sessionID string, update sessionUpdate) {
+	err := s.conn.Notify(ctx, methodSessionUpdate, sessionNotification{SessionID: sessionID, Update: update})
+	if err != nil && !errors.Is(err, jsonrpc2.ErrClosed) {
+		slog.Debug("Failed to send session update", "error", err)
+	}
+}
+
+func chunk(kind, text string) sessionUpdate {
+	block := textBlock(text)
+	return sessionUpdate{SessionUpdate: kind, Content: &block}
+}
+
+// askPermission asks the client whether the tool call of req may run. The
+// requests of sub-agents are asked in the session they work for; those of
+// other sessions are denied, since nobody else can answer them.
+func (s *server) askPermission(ctx context.Context, req permission.PermissionRequest) {
+	sessionID := s.clientSession(ctx, req.SessionID)
+	if sessionID == "" {
+		slog.Warn("Denied a permission request outside of the ACP sessions", "session_id", req.SessionID, "tool", req.ToolName)
+		s.backend.DenyPermission(req)
+		return
+	}
+	input, _ := json.Marshal(req.Params)
+	call := describeCall(req.ToolCallID, req.ToolName, string(input))
+	if req.Description != "" {
+		call.Title = req.Description
+	}
+	var result requestPermissionResult
+	err := s.conn.Call(ctx, methodRequestPermission, requestPermissionParams{
+		SessionID: sessionID,
+		ToolCall:  call,
+		Options:   permissionOptions,
+	}, &result)
+	if err != nil {
+		slog.Error("Failed to ask for permission", "tool", req.ToolName, "error", err)
+		s.backend.DenyPermission(req)
+		return
+	}
+	switch {
+	case result.Outcome.Outcome != outcomeSelected:
+		s.backend.DenyPermission(req)
+	case result.Outcome.OptionID == optionAllow, result.Outcome.OptionID == optionAllowAlways:
+		s.backend.GrantPermission(req, result.Outcome.OptionID == optionAllowAlways)
+		s.notify(ctx, sessionID, toolCall{ToolCallID: req.ToolCallID, Status: toolInProgress}.update(updateToolCallEdit))
+	default:
+		s.backend.DenyPermission(req)
+	}
+}
+
+// clientSession returns the session of the client that sessionID is, or
+// works for as a sub-agent, or nothing.
+func (s *server) clientSession(ctx context.Context, sessionID string) string {
+	for range maxSessionDepth {
+		s.mu.Lock()
+		_, ok := s.sessions[sessionID]
+		s.mu.Unlock()
+		if ok {
+			return sessionID
+		}
+		sess, err := s.backend.GetSession(ctx, sessionID)
+		if err != nil || sess.ParentSessionID == "" {
+			return ""
+		}
+		sessionID = sess.ParentSessionID
+	}
+	return ""
+}
+
+// describeCall describes a tool call for the client from its input.
+func describeCall(id, name, input string) toolCall {
+	call := toolCall{
+		ToolCallID: id,
+		Title:      name,
+		Kind:       toolKind(name),
+		Status:     toolPending,
+	}
+	var params map[string]any
+	if json.Unmarshal([]byte(input), &params) != nil {
+		return call
+	}
+	call.RawInput = json.RawMessage(input)
+	for _, key := range []string{"command", "file_path", "path", "pattern", "url", "query", "prompt"} {
+		if value, ok := params[key].(string); ok && value != "" {
+			call.Title = name + ": " + firstLine(value)
+			break
+		}
+	}
+	for _, key := range []string{"file_path", "path"} {
+		if value, ok := params[key].(string); ok && value != "" {
+			call.Locations = []toolLocation{{Path: value}}
+			break
+		}
+	}
+	return call
+}
+
+// describeResult describes the result of a tool call: the diff of edits,
+// the output of other tools.
+func describeResult(call message.ToolCall, result message.ToolResult) toolCall {
+	update := toolCall{ToolCallID: result.ToolCallID, Status: toolCompleted}
+	if result.IsError {
+		update.Status = toolFailed
+	}
+	switch call.Name {
+	case tools.EditToolName, tools.MultiEditToolName, tools.EditSymbolToolName:
+		var meta struct {
+			OldContent string `json:"old_content"`
+			NewContent string `json:"new_content"`
+		}
+		var params struct {
+			FilePath string `json:"file_path"`
+		}
+		_ = json.Unmarshal([]byte(call.Input), &params)
+		if !result.IsError && json.Unmarshal([]byte(result.Metadata), &meta) == nil && params.FilePath != "" {
+			content := toolCallContent{Type: "diff", Path: params.FilePath, NewText: meta.NewContent}
+			if meta.OldContent != "" {
+				content.OldText = &meta.OldContent
+			}
+			update.Content = []toolCallContent{content}
+			return update
+		}
+	}
+	if result.Content != "" {
+		block := textBlock(result.Content)
+		update.Content = []toolCallContent{{Type: "content", Content: &block}}
+	}
+	return update
+}
+
+// toolKind returns the kind of tool the client shows a tool as.
+func toolKind(name string) string {
+	switch name {
+	case tools.ViewToolName, tools.LSToolName, tools.DiagnosticsToolName, tools.ReferencesToolName:
+		return "read"
+	case tools.EditToolName, tools.MultiEditToolName, tools.EditSymbolToolName, tools.WriteToolName, tools.ReplaceAllToolName:
+		return "edit"
+	case tools.GrepToolName, tools.CodeSearchToolName, tools.GlobToolName, tools.SourcegraphToolName:
+		return "search"
+	case tools.BashToolName, tools.ProcessToolName, tools.RunTestsToolName:
+		return "execute"
+	case tools.FetchToolName, tools.WebFetchToolName, tools.WebSearchToolName, tools.DownloadToolName, tools.AgenticFetchToolName:
+		return "fetch"
+	default:
+		return "other"
+	}
+}
+
+// promptContent turns the blocks of a prompt into its text and the files
+// attached to it.
+func promptContent(workingDir string, blocks []contentBlock) (string, []message.Attachment, error) {
+	var sb strings.Builder
+	var attachments []message.Attachment
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			sb.WriteString(block.Text)
+		case "image":
+			data, err := base64.StdEncoding.DecodeString(block.Data)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid image: %w", err)
+			}
+			attachments = append(attachments, message.Attachment{FileName: "image", MimeType: block.MimeType, Content: data})
+		case "resource_link":
+			path, ok := filePath(workingDir, block.URI)
+			if !ok {
+				fmt.Fprintf(&sb, "\n%s\n", block.URI)
+				continue
+			}
+			if fsext.Denied(path) {
+				return "", nil, fmt.Errorf("access to %s is denied", path)
+			}
+			attachment, err := message.NewAttachmentFromFile(path)
+			if err != nil {
+				return "", nil, err
+			}
+			attachments = append(attachments, attachment)
+		case "resource":
+			if block.Resource == nil {
+				continue
+			}
+			name := block.Resource.URI
+			if path, ok := filePath(workingDir, block.Resource.URI); ok {
+				if fsext.Denied(path) {
+					return "", nil, fmt.Errorf("access to %s is denied", path)
+				}
+				name = path
+			}
+			attachment := message.Attachment{FilePath: name, FileName: filepath.Base(name), MimeType: block.Resource.MimeType}
+			if block.Resource.Blob != "" {
+				data, err := base64.StdEncoding.DecodeString(block.Resource.Blob)
+				if err != nil {
+					return "", nil, fmt.Errorf("invalid resource %s: %w", name, err)
+				}
+				attachment.Content = data
+			} else {
+				attachment.Content = []byte(block.Resource.Text)
+				if !strings.HasPrefix(attachment.MimeType, "text/") {
+					attachment.MimeType = "text/plain"
+				}
+			}
+			attachments = append(attachments, attachment)
+		}
+	}
+	return sb.String(), attachments, nil
+}
+
+// filePath returns the path of a file:// URI, relative ones resolved against
+// workingDir.
+func filePath(workingDir, uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	path := filepath.FromSlash(u.Path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+	return path, true
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+func decode(req *jsonrpc2.Request, v any) error {
+	if req.Params == nil {
+		return nil
+	}
+	if err := json.Unmarshal(*req.Params, v); err != nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// stdio joins the standard input and output into the stream of the
+// connection.
+type stdio struct {
+	io.Reader
+	io.Writer
+}
+
+func (stdio) Close() error { return nil }
+
+// logger sends the logs of the connection to slog, since the standard output
+// carries the protocol.
+type logger struct{}
+
+func (logger) Printf(format string, v ...any) {
+	slog.Debug("ACP connection: " + strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
@@ -0,0 +1,227 @@
+package acp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackend struct {
+	messages    *pubsub.Broker[message.Message]
+	permissions *pubsub.Broker[permission.PermissionRequest]
+	prompts     chan []message.Attachment
+	granted     chan bool
+}
+
+func (b *fakeBackend) CreateSession(_ context.Context, title string) (session.Session, error) {
+	return session.Session{ID: "s1", Title: title}, nil
+}
+
+func (b *fakeBackend) GetSession(_ context.Context, id string) (session.Session, error) {
+	if id == "child" {
+		return session.Session{ID: id, ParentSessionID: "s1"}, nil
+	}
+	return session.Session{ID: id}, nil
+}
+
+func (b *fakeBackend) ListMessages(_ context.Context, sessionID string) ([]message.Message, error) {
+	return answer(sessionID), nil
+}
+
+// answer is what the agent answers: an edit and its result.
+func answer(sessionID string) []message.Message {
+	return []message.Message{
+		{
+			ID:        "m1",
+			SessionID: sessionID,
+			Role:      message.Assistant,
+			Parts: []message.ContentPart{
+				message.TextContent{Text: "Done"},
+				message.ToolCall{ID: "c1", Name: tools.EditToolName, Input: `{"file_path":"a.go"}`, Finished: true},
+			},
+		},
+		{
+			ID:        "t1",
+			SessionID: sessionID,
+			Role:      message.Tool,
+			Parts: []message.ContentPart{message.ToolResult{
+				ToolCallID: "c1",
+				Name:       tools.EditToolName,
+				Metadata:   `{"old_content":"a\n","new_content":"b\n"}`,
+			}},
+		},
+	}
+}
+
+func (b *fakeBackend) SendMessage(_ context.Context, sessionID, _ string, attachments []message.Attachment) (*fantasy.AgentResult, error) {
+	b.prompts <- attachments
+	b.permissions.Publish(pubsub.CreatedEvent, permission.PermissionRequest{
+		ID:         "p1",
+		SessionID:  "child",
+		ToolCallID: "c1",
+		ToolName:   tools.BashToolName,
+		Params:     map[string]any{"command": "go test ./..."},
+	})
+	granted := <-b.granted
+	b.granted <- granted
+	for _, msg := range answer(sessionID) {
+		b.messages.Publish(pubsub.UpdatedEvent, msg)
+	}
+	return &fantasy.AgentResult{Response: fantasy.Response{FinishReason: fantasy.FinishReasonStop}}, nil
+}
+
+func (b *fakeBackend) CancelSession(string) {}
+
+func (b *fakeBackend) SubscribeMessages(ctx context.Context) <-chan pubsub.Event[message.Message] {
+	return b.messages.Subscribe(ctx)
+}
+
+func (b *fakeBackend) SubscribePermissions(ctx context.Context) <-chan pubsub.Event[permission.PermissionRequest] {
+	return b.permissions.Subscribe(ctx)
+}
+
+func (b *fakeBackend) GrantPermission(_ permission.PermissionRequest, always bool) {
+	b.granted <- !always
+}
+
+func (b *fakeBackend) DenyPermission(permission.PermissionRequest) {
+	b.granted <- false
+}
+
+// pipe is one end of a connection over two pipes.
+type pipe struct {
+	io.Reader
+	io.Writer
+}
+
+func (pipe) Close() error { return nil }
+
+func TestServe(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+	backend := &fakeBackend{
+		messages:    pubsub.NewBroker[message.Message](),
+		permissions: pubsub.NewBroker[permission.PermissionRequest](),
+		prompts:     make(chan []message.Attachment, 1),
+		granted:     make(chan bool, 1),
+	}
+	clientR, agentW := io.Pipe()
+	agentR, clientW := io.Pipe()
+	go func() { _ = Serve(t.Context(), agentR, agentW, dir, backend) }()
+
+	updates := make(chan sessionNotification, 20)
+	handler := jsonrpc2.HandlerWithError(func(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		switch req.Method {
+		case methodSessionUpdate:
+			var n sessionNotification
+			require.NoError(t, json.Unmarshal(*req.Params, &n))
+			updates <- n
+			return nil, nil
+		case methodRequestPermission:
+			var params requestPermissionParams
+			require.NoError(t, json.Unmarshal(*req.Params, &params))
+			require.Equal(t, "s1", params.SessionID)
+			require.Equal(t, "bash: go test ./...", params.ToolCall.Title)
+			require.Equal(t, "execute", params.ToolCall.Kind)
+			var result requestPermissionResult
+			result.Outcome.Outcome = outcomeSelected
+			result.Outcome.OptionID = optionAllow
+			return result, nil
+		}
+		return nil, nil
+	})
+	conn := jsonrpc2.NewConn(t.Context(), jsonrpc2.NewPlainObjectStream(pipe{clientR, clientW}), handler)
+	t.Cleanup(func() { _ = conn.Close() })
+	ctx := t.Context()
+
+	var initialized initializeResult
+	require.NoError(t, conn.Call(ctx, methodInitialize, initializeParams{ProtocolVersion: ProtocolVersion}, &initialized))
+	require.Equal(t, ProtocolVersion, initialized.ProtocolVersion)
+	require.True(t, initialized.AgentCapabilities.LoadSession)
+
+	var rpcErr *jsonrpc2.Error
+	err := conn.Call(ctx, methodSessionNew, newSessionParams{Cwd: t.TempDir()}, nil)
+	require.ErrorAs(t, err, &rpcErr)
+	require.EqualValues(t, jsonrpc2.CodeInvalidParams, rpcErr.Code)
+
+	var created newSessionResult
+	require.NoError(t, conn.Call(ctx, methodSessionNew, newSessionParams{Cwd: dir}, &created))
+	require.Equal(t, "s1", created.SessionID)
+
+	var result promptResult
+	require.NoError(t, conn.Call(ctx, methodSessionPrompt, promptParams{
+		SessionID: "s1",
+		Prompt: []contentBlock{
+			textBlock("Explain "),
+			{Type: "resource_link", URI: "file://" + filepath.ToSlash(filepath.Join(dir, "main.go")), Name: "main.go"},
+		},
+	}, &result))
+	require.Equal(t, stopEndTurn, result.StopReason)
+	attachments := <-backend.prompts
+	require.Len(t, attachments, 1)
+	require.Equal(t, "package main\n", string(attachments[0].Content))
+	require.True(t, <-backend.granted)
+
+	// Updates come in order: the tool call is announced before its result.
+	var kinds []string
+	var diff toolCallContent
+	timeout := time.After(5 * time.Second)
+	for diff.Type == "" {
+		select {
+		case n := <-updates:
+			kinds = append(kinds, n.Update.SessionUpdate)
+			if n.Update.SessionUpdate == updateToolCallEdit && n.Update.Status == toolCompleted {
+				contents, _ := json.Marshal(n.Update.Content)
+				var parsed []toolCallContent
+				require.NoError(t, json.Unmarshal(contents, &parsed))
+				diff = parsed[0]
+			}
+		case <-timeout:
+			t.Fatalf("got updates %v", kinds)
+		}
+	}
+	require.Contains(t, kinds, updateAgentMessage)
+	require.Contains(t, kinds, updateToolCall)
+	require.Equal(t, "diff", diff.Type)
+	require.Equal(t, "a.go", diff.Path)
+	require.Equal(t, "b\n", diff.NewText)
+
+	err = conn.Call(ctx, "unknown", nil, nil)
+	require.ErrorAs(t, err, &rpcErr)
+	require.EqualValues(t, jsonrpc2.CodeMethodNotFound, rpcErr.Code)
+}
+
+func TestPromptContent(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	prompt, attachments, err := promptContent(dir, []contentBlock{
+		textBlock("Fix this"),
+		{Type: "resource", Resource: &embeddedBlock{URI: "file://" + filepath.ToSlash(filepath.Join(dir, "a.go")), Text: "package a\n"}},
+		{Type: "image", Data: "aGk=", MimeType: "image/png"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Fix this", prompt)
+	require.Len(t, attachments, 2)
+	require.Equal(t, filepath.Join(dir, "a.go"), attachments[0].FilePath)
+	require.Equal(t, "text/plain", attachments[0].MimeType)
+	require.Equal(t, "hi", string(attachments[1].Content))
+
+	_, _, err = promptContent(dir, []contentBlock{{Type: "image", Data: "!"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,206 @@
+// Package acp implements the agent side of the Agent Client Protocol, with
+// which editors such as Zed run Crush as their agent over JSON-RPC 2.0 on
+// stdio, one JSON message per line.
+package acp
+
+import "encoding/json"
+
+// ProtocolVersion is the version of the protocol this package speaks.
+const ProtocolVersion = 1
+
+// Methods the client calls.
+const (
+	methodInitialize    = "initialize"
+	methodAuthenticate  = "authenticate"
+	methodSessionNew    = "session/new"
+	methodSessionLoad   = "session/load"
+	methodSessionPrompt = "session/prompt"
+	methodSessionCancel = "session/cancel"
+)
+
+// Methods the agent calls.
+const (
+	methodSessionUpdate     = "session/update"
+	methodRequestPermission = "session/request_permission"
+)
+
+// Reasons a prompt turn stopped.
+const (
+	stopEndTurn   = "end_turn"
+	stopMaxTokens = "max_tokens"
+	stopCancelled = "cancelled"
+)
+
+// Kinds of session updates.
+const (
+	updateUserMessage  = "user_message_chunk"
+	updateAgentMessage = "agent_message_chunk"
+	updateAgentThought = "agent_thought_chunk"
+	updateToolCall     = "tool_call"
+	updateToolCallEdit = "tool_call_update"
+)
+
+// Statuses of tool calls.
+const (
+	toolPending    = "pending"
+	toolInProgress = "in_progress"
+	toolCompleted  = "completed"
+	toolFailed     = "failed"
+)
+
+// Options offered when asking for permissions, and the outcomes of asking.
+const (
+	optionAllow       = "allow"
+	optionAllowAlways = "allow_always"
+	optionReject      = "reject"
+	outcomeSelected   = "selected"
+)
+
+type initializeParams struct {
+	ProtocolVersion int `json:"protocolVersion"`
+}
+
+type initializeResult struct {
+	ProtocolVersion   int               `json:"protocolVersion"`
+	AgentCapabilities agentCapabilities `json:"agentCapabilities"`
+	AuthMethods       []any             `json:"authMethods"`
+}
+
+type agentCapabilities struct {
+	LoadSession        bool               `json:"loadSession"`
+	PromptCapabilities promptCapabilities `json:"promptCapabilities"`
+}
+
+type promptCapabilities struct {
+	Image           bool `json:"image"`
+	Audio           bool `json:"audio"`
+	EmbeddedContext bool `json:"embeddedContext"`
+}
+
+type newSessionParams struct {
+	Cwd string `json:"cwd"`
+}
+
+type newSessionResult struct {
+	SessionID string `json:"sessionId"`
+}
+
+type loadSessionParams struct {
+	SessionID string `json:"sessionId"`
+	Cwd       string `json:"cwd"`
+}
+
+type promptParams struct {
+	SessionID string         `json:"sessionId"`
+	Prompt    []contentBlock `json:"prompt"`
+}
+
+type promptResult struct {
+	StopReason string `json:"stopReason"`
+}
+
+type cancelParams struct {
+	SessionID string `json:"sessionId"`
+}
+
+// contentBlock is a piece of a prompt or of a message: text, an image, a
+// link to a resource or an embedded one.
+type contentBlock struct {
+	Type     string         `json:"type"`
+	Text     string         `json:"text,omitempty"`
+	Data     string         `json:"data,omitempty"`
+	MimeType string         `json:"mimeType,omitempty"`
+	URI      string         `json:"uri,omitempty"`
+	Name     string         `json:"name,omitempty"`
+	Resource *embeddedBlock `json:"resource,omitempty"`
+}
+
+type embeddedBlock struct {
+	URI      string `json:"uri"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+func textBlock(text string) contentBlock {
+	return contentBlock{Type: "text", Text: text}
+}
+
+type sessionNotification struct {
+	SessionID string        `json:"sessionId"`
+	Update    sessionUpdate `json:"update"`
+}
+
+// sessionUpdate is one of the kinds of session updates, with the fields of
+// that kind set. Content is a single block for message chunks and a list of
+// [toolCallContent] for tool calls.
+type sessionUpdate struct {
+	SessionUpdate string          `json:"sessionUpdate"`
+	Content       any             `json:"content,omitempty"`
+	ToolCallID    string          `json:"toolCallId,omitempty"`
+	Title         string          `json:"title,omitempty"`
+	Kind          string          `json:"kind,omitempty"`
+	Status        string          `json:"status,omitempty"`
+	RawInput      json.RawMessage `json:"rawInput,omitempty"`
+	Locations     []toolLocation  `json:"locations,omitempty"`
+}
+
+// toolCall describes a tool call, whole or the fields that changed.
+type toolCall struct {
+	ToolCallID string            `json:"toolCallId"`
+	Title      string            `json:"title,omitempty"`
+	Kind       string            `json:"kind,omitempty"`
+	Status     string            `json:"status,omitempty"`
+	RawInput   json.RawMessage   `json:"rawInput,omitempty"`
+	Locations  []toolLocation    `json:"locations,omitempty"`
+	Content    []toolCallContent `json:"content,omitempty"`
+}
+
+// update returns the session update of kind describing the call.
+func (tc toolCall) update(kind string) sessionUpdate {
+	u := sessionUpdate{
+		SessionUpdate: kind,
+		ToolCallID:    tc.ToolCallID,
+		Title:         tc.Title,
+		Kind:          tc.Kind,
+		Status:        tc.Status,
+		RawInput:      tc.RawInput,
+		Locations:     tc.Locations,
+	}
+	if len(tc.Content) > 0 {
+		u.Content = tc.Content
+	}
+	return u
+}
+
+type toolLocation struct {
+	Path string `json:"path"`
+}
+
+// toolCallContent is what a tool call produced: content or a diff.
+type toolCallContent struct {
+	Type    string        `json:"type"`
+	Content *contentBlock `json:"content,omitempty"`
+	Path    string        `json:"path,omitempty"`
+	OldText *string       `json:"oldText,omitempty"`
+	NewText string        `json:"newText,omitempty"`
+}
+
+type requestPermissionParams struct {
+	SessionID string             `json:"sessionId"`
+	ToolCall  toolCall           `json:"toolCall"`
+	Options   []permissionOption `json:"options"`
+}
+
+type permissionOption struct {
+	OptionID string `json:"optionId"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+}
+
+type requestPermissionResult struct {
+	Outcome struct {
+		Outcome  string `json:"outcome"`
+		OptionID string `json:"optionId,omitempty"`
+	} `json:"outcome"`
+}
@@ -20,6 +20,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"charm.land/catwalk/pkg/catwalk"
@@ -35,9 +36,15 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/connectivity"
 	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/filetracker"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/recall"
+	"github.com/charmbracelet/crush/internal/repomap"
+	"github.com/charmbracelet/crush/internal/retry"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/stringext"
 	"github.com/charmbracelet/x/exp/charmtone"
@@ -45,6 +52,8 @@ import (
 
 const (
 	defaultSessionName = "Untitled Session"
+	// titleAnswerLimit is how much of the first answer titles are based on.
+	titleAnswerLimit = 1000
 
 	// Constants for auto-summarization thresholds
 	largeContextWindowThreshold = 200_000
@@ -72,13 +81,41 @@ type SessionAgentCall struct {
 	TopK             *int64
 	FrequencyPenalty *float64
 	PresencePenalty  *float64
+
+	// SummaryProviderOptions are the provider options for the summary model,
+	// used if the session needs to be compacted during the call.
+	SummaryProviderOptions fantasy.ProviderOptions
+
+	// Raw sends the prompt without the system prompt and tools, and with
+	// only the part of the session RawHistory selects.
+	Raw        bool
+	RawHistory RawHistory
+
+	// Interrupt stops the request running in the session, if there is one,
+	// and continues it with the prompt instead of waiting in the queue.
+	Interrupt bool
+
+	// QueueMode is when the prompts queued during the call are given to
+	// the agent. Empty means config.QueueNextStep.
+	QueueMode config.QueueMode
+
+	// overflowRetried is set on the call sent again after the session was
+	// compacted because the call did not fit in the context window.
+	overflowRetried bool
+
+	// translation is the prompt in English, once the session translates
+	// it, so the call is not translated again when it is sent again.
+	translation *message.Translation
 }
 
 type SessionAgent interface {
 	Run(context.Context, SessionAgentCall) (*fantasy.AgentResult, error)
-	SetModels(large Model, small Model)
+	SetModels(models Models)
 	SetTools(tools []fantasy.AgentTool)
 	SetSystemPrompt(systemPrompt string)
+	// SetRepoMap sets the map of the project added to the system prompt of
+	// every call.
+	SetRepoMap(repoMap *repomap.Map)
 	Cancel(sessionID string)
 	CancelAll()
 	IsSessionBusy(sessionID string) bool
@@ -86,31 +123,57 @@ type SessionAgent interface {
 	QueuedPrompts(sessionID string) int
 	QueuedPromptsList(sessionID string) []string
 	ClearQueue(sessionID string)
+	// HasInterrupt reports whether a request in the session is being
+	// stopped to continue with a new instruction.
+	HasInterrupt(sessionID string) bool
 	Summarize(context.Context, string, fantasy.ProviderOptions) error
+	AskSmallModel(ctx context.Context, sessionID, systemPrompt, prompt string) (string, error)
 	Model() Model
+	SummaryModel() Model
 }
 
 type Model struct {
 	Model      fantasy.LanguageModel
 	CatwalkCfg catwalk.Model
 	ModelCfg   config.SelectedModel
+	// WithoutTools is set for models that cannot call tools.
+	WithoutTools bool
+}
+
+// Models holds the models of an agent by role. Title and Summary fall back
+// to Small and Large when unset.
+type Models struct {
+	Large   Model
+	Small   Model
+	Title   Model
+	Summary Model
 }
 
 type sessionAgent struct {
 	largeModel         *csync.Value[Model]
 	smallModel         *csync.Value[Model]
+	titleModel         *csync.Value[Model]
+	summaryModel       *csync.Value[Model]
 	systemPromptPrefix *csync.Value[string]
 	systemPrompt       *csync.Value[string]
+	repoMap            atomic.Pointer[repomap.Map]
 	tools              *csync.Slice[fantasy.AgentTool]
 
 	isSubAgent           bool
 	sessions             session.Service
 	messages             message.Service
+	filetracker          filetracker.Service
+	recall               *recall.Store
 	disableAutoSummarize bool
 	isYolo               bool
 
 	messageQueue   *csync.Map[string, []SessionAgentCall]
 	activeRequests *csync.Map[string, context.CancelFunc]
+	// interrupts holds the call that continues a request being stopped.
+	interrupts *csync.Map[string, SessionAgentCall]
+	// network holds the requests that failed because the network went
+	// away until it returns.
+	network *connectivity.Monitor
 }
 
 type SessionAgentOptions struct {
@@ -124,6 +187,14 @@ type SessionAgentOptions struct {
 	Sessions             session.Service
 	Messages             message.Service
 	Tools                []fantasy.AgentTool
+	TitleModel           Model
+	SummaryModel         Model
+	// FileTracker, when set, tells the model about the files it read that
+	// changed outside of the session.
+	FileTracker filetracker.Service
+	// Recall, when set, stores the turns compaction drops for the recall
+	// tool.
+	Recall *recall.Store
 }
 
 func NewSessionAgent(
@@ -132,16 +203,22 @@ func NewSessionAgent(
 	return &sessionAgent{
 		largeModel:           csync.NewValue(opts.LargeModel),
 		smallModel:           csync.NewValue(opts.SmallModel),
+		titleModel:           csync.NewValue(opts.TitleModel),
+		summaryModel:         csync.NewValue(opts.SummaryModel),
 		systemPromptPrefix:   csync.NewValue(opts.SystemPromptPrefix),
 		systemPrompt:         csync.NewValue(opts.SystemPrompt),
 		isSubAgent:           opts.IsSubAgent,
 		sessions:             opts.Sessions,
 		messages:             opts.Messages,
+		filetracker:          opts.FileTracker,
+		recall:               opts.Recall,
 		disableAutoSummarize: opts.DisableAutoSummarize,
 		tools:                csync.NewSliceFrom(opts.Tools),
 		isYolo:               opts.IsYolo,
 		messageQueue:         csync.NewMap[string, []SessionAgentCall](),
 		activeRequests:       csync.NewMap[string, context.CancelFunc](),
+		interrupts:           csync.NewMap[string, SessionAgentCall](),
+		network:              connectivity.NewMonitor(),
 	}
 }
 
@@ -155,6 +232,9 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 
 	// Queue the message if busy
 	if a.IsSessionBusy(call.SessionID) {
+		if call.Interrupt && a.interrupt(call) {
+			return nil, nil
+		}
 		existing, ok := a.messageQueue.Get(call.SessionID)
 		if !ok {
 			existing = []SessionAgentCall{}
@@ -164,6 +244,12 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		return nil, nil
 	}
 
+	sessionLock := sync.Mutex{}
+	currentSession, err := a.sessions.Get(ctx, call.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
 	// Copy mutable fields under lock to avoid races with SetTools/SetModels.
 	agentTools := a.tools.Copy()
 	largeModel := a.largeModel.Get()
@@ -171,8 +257,19 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	promptPrefix := a.systemPromptPrefix.Get()
 	var instructions strings.Builder
 
+	if currentSession.ChatOnly {
+		agentTools = chatOnlyTools(agentTools)
+	}
+	if largeModel.WithoutTools {
+		agentTools = nil
+	}
+	if call.Raw {
+		agentTools, systemPrompt, promptPrefix = nil, "", ""
+	}
+	translating := currentSession.Translate && !call.Raw && !a.isSubAgent
+
 	for _, server := range mcp.GetStates() {
-		if server.State != mcp.StateConnected {
+		if server.State != mcp.StateConnected || currentSession.ChatOnly || call.Raw || largeModel.WithoutTools {
 			continue
 		}
 		if s := server.Client.InitializeResult().Instructions; s != "" {
@@ -185,6 +282,12 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		systemPrompt += "\n\n<mcp-instructions>\n" + s + "\n</mcp-instructions>"
 	}
 
+	if !currentSession.ChatOnly && !call.Raw {
+		if s := a.repoMap.Load().String(); s != "" {
+			systemPrompt += "\n\n<repo_map>\nThe files of the project, with the exported symbols of its Go files, as of this message:\n" + s + "\n</repo_map>"
+		}
+	}
+
 	if len(agentTools) > 0 {
 		// Add Anthropic caching to the last tool.
 		agentTools[len(agentTools)-1].SetProviderOptions(a.getCacheControlOptions())
@@ -194,31 +297,38 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		largeModel.Model,
 		fantasy.WithSystemPrompt(systemPrompt),
 		fantasy.WithTools(agentTools...),
+		// The models retry on their own, and unlike the agent they keep
+		// what was received of an interrupted stream.
+		fantasy.WithMaxRetries(0),
 	)
 
-	sessionLock := sync.Mutex{}
-	currentSession, err := a.sessions.Get(ctx, call.SessionID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get session: %w", err)
-	}
-
 	msgs, err := a.getSessionMessages(ctx, currentSession)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session messages: %w", err)
 	}
 
-	var wg sync.WaitGroup
-	// Generate title if first message.
+	// Generate the title once the first exchange is over, so it reflects
+	// the answer as well as the prompt.
+	var currentAssistant *message.Message
+	// resent is set when the request is sent again as if it never failed,
+	// which leaves the title to the new request.
+	var resent bool
 	if len(msgs) == 0 {
 		titleCtx := ctx // Copy to avoid race with ctx reassignment below.
-		wg.Go(func() {
-			a.generateTitle(titleCtx, call.SessionID, call.Prompt)
-		})
+		defer func() {
+			if resent {
+				return
+			}
+			var answer string
+			if currentAssistant != nil {
+				answer = currentAssistant.Content().Text
+			}
+			a.generateTitle(titleCtx, call.SessionID, currentSession.Title, call.Prompt, answer)
+		}()
 	}
-	defer wg.Wait()
 
 	// Add the user message to the session.
-	_, err = a.createUserMessage(ctx, call)
+	userMessage, err := a.createUserMessage(ctx, call)
 	if err != nil {
 		return nil, err
 	}
@@ -232,15 +342,34 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	defer cancel()
 	defer a.activeRequests.Del(call.SessionID)
 
+	if translating && call.translation == nil {
+		if call.translation = a.translateToEnglish(genCtx, call.SessionID, call.Prompt); call.translation != nil {
+			userMessage.SetTranslation(*call.translation)
+			if err := a.messages.Update(ctx, userMessage); err != nil {
+				return nil, err
+			}
+		}
+	}
+	prompt := call.Prompt
+	if call.translation != nil {
+		prompt = call.translation.Text
+	}
+	// replies are the assistant messages to translate back once the
+	// request is over.
+	var replies []string
+
 	history, files := a.preparePrompt(msgs, call.Attachments...)
+	if call.Raw {
+		history = rawHistory(msgs, call.RawHistory)
+	}
 
 	startTime := time.Now()
 	a.eventPromptSent(call.SessionID)
 
-	var currentAssistant *message.Message
 	var shouldSummarize bool
+	var finishedSteps int
 	result, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
-		Prompt:           message.PromptWithTextAttachments(call.Prompt, call.Attachments),
+		Prompt:           message.PromptWithTextAttachments(prompt, call.Attachments),
 		Files:            files,
 		Messages:         history,
 		ProviderOptions:  call.ProviderOptions,
@@ -256,9 +385,14 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				prepared.Messages[i].ProviderOptions = nil
 			}
 
-			queuedCalls, _ := a.messageQueue.Get(call.SessionID)
-			a.messageQueue.Del(call.SessionID)
+			var queuedCalls []SessionAgentCall
+			if call.QueueMode == "" || call.QueueMode == config.QueueNextStep {
+				queuedCalls, _ = a.messageQueue.Take(call.SessionID)
+			}
 			for _, queued := range queuedCalls {
+				if translating {
+					queued.translation = a.translateToEnglish(callContext, call.SessionID, queued.Prompt)
+				}
 				userMessage, createErr := a.createUserMessage(callContext, queued)
 				if createErr != nil {
 					return callContext, prepared, createErr
@@ -266,6 +400,19 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				prepared.Messages = append(prepared.Messages, userMessage.ToAIMessage()...)
 			}
 
+			switch mode := tools.GetModeFromContext(callContext); {
+			case call.Raw:
+			case currentSession.ChatOnly:
+				prepared.Messages = append(prepared.Messages, fantasy.NewUserMessage(chatOnlyReminder))
+			case mode == tools.ModePlan:
+				prepared.Messages = append(prepared.Messages, fantasy.NewUserMessage(planModeReminder))
+			case mode == tools.ModeReview:
+				prepared.Messages = append(prepared.Messages, fantasy.NewUserMessage(reviewModeReminder))
+			}
+			if reminder := a.changedFilesReminder(callContext, call.SessionID); reminder != "" && !call.Raw {
+				prepared.Messages = append(prepared.Messages, fantasy.NewUserMessage(reminder))
+			}
+
 			prepared.Messages = a.workaroundProviderMediaLimitations(prepared.Messages, largeModel)
 
 			lastSystemRoleInx := 0
@@ -298,9 +445,18 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			if err != nil {
 				return callContext, prepared, err
 			}
+			if call.translation != nil {
+				replies = append(replies, assistantMsg.ID)
+			}
 			callContext = context.WithValue(callContext, tools.MessageIDContextKey, assistantMsg.ID)
 			callContext = context.WithValue(callContext, tools.SupportsImagesContextKey, largeModel.CatwalkCfg.SupportsImages)
 			callContext = context.WithValue(callContext, tools.ModelNameContextKey, largeModel.CatwalkCfg.Name)
+			// A step interrupted by a transient error may be generated
+			// again, and what it produced so far is dropped first.
+			callContext = retry.WithRestart(callContext, func() error {
+				currentAssistant.Parts = []message.ContentPart{}
+				return a.messages.Update(genCtx, *currentAssistant)
+			})
 			currentAssistant = &assistantMsg
 			return callContext, prepared, err
 		},
@@ -353,9 +509,6 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			currentAssistant.AddToolCall(toolCall)
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
-		OnRetry: func(err *fantasy.ProviderError, delay time.Duration) {
-			// TODO: implement
-		},
 		OnToolCall: func(tc fantasy.ToolCallContent) error {
 			toolCall := message.ToolCall{
 				ID:               tc.ToolCallID,
@@ -388,6 +541,8 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				finishReason = message.FinishReasonToolUse
 			}
 			currentAssistant.AddFinish(finishReason, "", "")
+			currentAssistant.ReasoningTokens = stepResult.Usage.ReasoningTokens
+			finishedSteps++
 			sessionLock.Lock()
 			defer sessionLock.Unlock()
 
@@ -395,7 +550,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			if getSessionErr != nil {
 				return getSessionErr
 			}
-			a.updateSessionUsage(largeModel, &updatedSession, stepResult.Usage, a.openrouterCost(stepResult.ProviderMetadata))
+			a.updateSessionUsage(ctx, largeModel, &updatedSession, stepResult.Usage, a.openrouterCost(stepResult.ProviderMetadata))
 			_, sessionErr := a.sessions.Save(ctx, updatedSession)
 			if sessionErr != nil {
 				return sessionErr
@@ -431,8 +586,9 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	if err != nil {
 		isCancelErr := errors.Is(err, context.Canceled)
 		isPermissionErr := errors.Is(err, permission.ErrorPermissionDenied)
+		isOffline := !isCancelErr && !a.isSubAgent && connectivity.IsNetworkError(err)
 		if currentAssistant == nil {
-			return result, err
+			return a.resumeInterrupted(ctx, call.SessionID, cancel, result, err)
 		}
 		// Ensure we finish thinking on error to close the reasoning state.
 		currentAssistant.FinishThinking()
@@ -500,6 +656,10 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			currentAssistant.AddFinish(message.FinishReasonCanceled, "User canceled request", "")
 		} else if isPermissionErr {
 			currentAssistant.AddFinish(message.FinishReasonPermissionDenied, "User denied permission", "")
+		} else if isOffline {
+			currentAssistant.AddFinish(message.FinishReasonError, "Connection lost", "The connection to the provider was lost. The request will be sent again once it is back.")
+		} else if isContextOverflow(err) {
+			currentAssistant.AddFinish(message.FinishReasonError, contextOverflowTitle, overflowDetails(err, "Compact the session or start a new one, and try again."))
 		} else if errors.Is(err, hyper.ErrNoCredits) {
 			url := hyper.BaseURL()
 			link := linkStyle.Hyperlink(url, "id=hyper").Render(url)
@@ -527,12 +687,30 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		if updateErr != nil {
 			return nil, updateErr
 		}
-		return nil, err
+		if isOffline {
+			unchanged := pristine(currentAssistant, finishedSteps)
+			next, waitErr := a.waitUntilOnline(ctx, genCtx, call, userMessage, currentAssistant, unchanged, err)
+			if waitErr != nil {
+				return a.resumeInterrupted(ctx, call.SessionID, cancel, nil, waitErr)
+			}
+			resent = unchanged
+			a.activeRequests.Del(call.SessionID)
+			cancel()
+			return a.Run(ctx, next)
+		}
+		if isContextOverflow(err) && !call.overflowRetried && !call.Raw && !a.disableAutoSummarize && !a.HasInterrupt(call.SessionID) {
+			return a.retryAfterOverflow(ctx, call, currentAssistant, cancel, err)
+		}
+		return a.resumeInterrupted(ctx, call.SessionID, cancel, nil, err)
+	}
+
+	if call.translation != nil {
+		a.translateReplies(genCtx, call.SessionID, replies, call.translation.Language)
 	}
 
 	if shouldSummarize {
 		a.activeRequests.Del(call.SessionID)
-		if summarizeErr := a.Summarize(genCtx, call.SessionID, call.ProviderOptions); summarizeErr != nil {
+		if _, summarizeErr := a.summarize(genCtx, call.SessionID, call.SummaryProviderOptions, compactNearLimit); summarizeErr != nil {
 			return nil, summarizeErr
 		}
 		// If the agent wasn't done...
@@ -541,7 +719,11 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			if !ok {
 				existing = []SessionAgentCall{}
 			}
-			call.Prompt = fmt.Sprintf("The previous session was interrupted because it got too long, the initial user request was: `%s`", call.Prompt)
+			const resumePrompt = "The previous session was interrupted because it got too long, the initial user request was: `%s`"
+			call.Prompt = fmt.Sprintf(resumePrompt, call.Prompt)
+			if call.translation != nil {
+				call.translation = &message.Translation{Language: call.translation.Language, Text: fmt.Sprintf(resumePrompt, call.translation.Text)}
+			}
 			existing = append(existing, call)
 			a.messageQueue.Set(call.SessionID, existing)
 		}
@@ -551,36 +733,111 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	a.activeRequests.Del(call.SessionID)
 	cancel()
 
+	// The interrupt came too late to stop the request, so it follows it.
+	if next, ok := a.interrupts.Take(call.SessionID); ok {
+		return a.Run(ctx, next)
+	}
+
 	queuedMessages, ok := a.messageQueue.Get(call.SessionID)
 	if !ok || len(queuedMessages) == 0 {
 		return result, err
 	}
+	if call.QueueMode == config.QueueMerge {
+		a.messageQueue.Del(call.SessionID)
+		return a.Run(ctx, mergeQueuedCalls(queuedMessages))
+	}
 	// There are queued messages restart the loop.
 	firstQueuedMessage := queuedMessages[0]
 	a.messageQueue.Set(call.SessionID, queuedMessages[1:])
 	return a.Run(ctx, firstQueuedMessage)
 }
 
+// mergeQueuedCalls joins queued calls into one, with their prompts in the
+// order they were sent and all their attachments. The options are those of
+// the last call.
+func mergeQueuedCalls(calls []SessionAgentCall) SessionAgentCall {
+	merged := calls[len(calls)-1]
+	prompts := make([]string, 0, len(calls))
+	var attachments []message.Attachment
+	for _, c := range calls {
+		if prompt := strings.TrimSpace(c.Prompt); prompt != "" {
+			prompts = append(prompts, prompt)
+		}
+		attachments = append(attachments, c.Attachments...)
+	}
+	merged.Prompt = strings.Join(prompts, "\n\n")
+	merged.Attachments = attachments
+	merged.translation = nil
+	return merged
+}
+
+// interruptedPrompt tells the model why its last answer stopped short and
+// what to do with the note that stopped it.
+const interruptedPrompt = `I interrupted you to add this:
+
+%s
+
+Take it into account and carry on from where you stopped. Keep the work you already did unless it conflicts with the above; a tool call that was interrupted did not finish and may need to run again.`
+
+// interrupt stops the request running in the session and saves call to
+// continue it with, once the request has saved what it had done. It reports
+// false when there is no request to stop, or an interrupt is already
+// pending, so the call is queued instead.
+func (a *sessionAgent) interrupt(call SessionAgentCall) bool {
+	cancel, ok := a.activeRequests.Get(call.SessionID)
+	if !ok || cancel == nil || a.HasInterrupt(call.SessionID) {
+		return false
+	}
+	call.Interrupt = false
+	call.Prompt = fmt.Sprintf(interruptedPrompt, strings.TrimSpace(call.Prompt))
+	a.interrupts.Set(call.SessionID, call)
+	slog.Debug("Request interrupted", "session_id", call.SessionID)
+	cancel()
+	return true
+}
+
+// resumeInterrupted runs the call that interrupted the request in the
+// session, if there is one, and otherwise returns result and err as they
+// are.
+func (a *sessionAgent) resumeInterrupted(ctx context.Context, sessionID string, cancel context.CancelFunc, result *fantasy.AgentResult, err error) (*fantasy.AgentResult, error) {
+	next, ok := a.interrupts.Take(sessionID)
+	if !ok {
+		return result, err
+	}
+	a.activeRequests.Del(sessionID)
+	cancel()
+	return a.Run(ctx, next)
+}
+
+// Summarize compacts the session into a summary message using the summary
+// model. The given provider options must match that model.
 func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fantasy.ProviderOptions) error {
+	_, err := a.summarize(ctx, sessionID, opts, compactOnRequest)
+	return err
+}
+
+// summarize compacts the session and returns the event published for it,
+// which is empty when there was nothing to compact.
+func (a *sessionAgent) summarize(ctx context.Context, sessionID string, opts fantasy.ProviderOptions, trigger compactionTrigger) (CompactedEvent, error) {
 	if a.IsSessionBusy(sessionID) {
-		return ErrSessionBusy
+		return CompactedEvent{}, ErrSessionBusy
 	}
 
 	// Copy mutable fields under lock to avoid races with SetModels.
-	largeModel := a.largeModel.Get()
+	summaryModel := a.SummaryModel()
 	systemPromptPrefix := a.systemPromptPrefix.Get()
 
 	currentSession, err := a.sessions.Get(ctx, sessionID)
 	if err != nil {
-		return fmt.Errorf("failed to get session: %w", err)
+		return CompactedEvent{}, fmt.Errorf("failed to get session: %w", err)
 	}
 	msgs, err := a.getSessionMessages(ctx, currentSession)
 	if err != nil {
-		return err
+		return CompactedEvent{}, err
 	}
 	if len(msgs) == 0 {
 		// Nothing to summarize.
-		return nil
+		return CompactedEvent{}, nil
 	}
 
 	aiMsgs, _ := a.preparePrompt(msgs)
@@ -590,17 +847,18 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	defer a.activeRequests.Del(sessionID)
 	defer cancel()
 
-	agent := fantasy.NewAgent(largeModel.Model,
+	agent := fantasy.NewAgent(summaryModel.Model,
 		fantasy.WithSystemPrompt(string(summaryPrompt)),
+		fantasy.WithMaxRetries(0),
 	)
 	summaryMessage, err := a.messages.Create(ctx, sessionID, message.CreateMessageParams{
 		Role:             message.Assistant,
-		Model:            largeModel.Model.Model(),
-		Provider:         largeModel.Model.Provider(),
+		Model:            summaryModel.Model.Model(),
+		Provider:         summaryModel.Model.Provider(),
 		IsSummaryMessage: true,
 	})
 	if err != nil {
-		return err
+		return CompactedEvent{}, err
 	}
 
 	summaryPromptText := buildSummaryPrompt(currentSession.Todos)
@@ -640,15 +898,15 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		if isCancelErr {
 			// User cancelled summarize we need to remove the summary message.
 			deleteErr := a.messages.Delete(ctx, summaryMessage.ID)
-			return deleteErr
+			return CompactedEvent{}, deleteErr
 		}
-		return err
+		return CompactedEvent{}, err
 	}
 
 	summaryMessage.AddFinish(message.FinishReasonEndTurn, "", "")
 	err = a.messages.Update(genCtx, summaryMessage)
 	if err != nil {
-		return err
+		return CompactedEvent{}, err
 	}
 
 	var openrouterCost *float64
@@ -663,15 +921,34 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		}
 	}
 
-	a.updateSessionUsage(largeModel, &currentSession, resp.TotalUsage, openrouterCost)
+	a.updateSessionUsage(ctx, summaryModel, &currentSession, resp.TotalUsage, openrouterCost)
 
 	// Just in case, get just the last usage info.
 	usage := resp.Response.Usage
+	tokensBefore := currentSession.PromptTokens + currentSession.CompletionTokens
 	currentSession.SummaryMessageID = summaryMessage.ID
 	currentSession.CompletionTokens = usage.OutputTokens
 	currentSession.PromptTokens = 0
-	_, err = a.sessions.Save(genCtx, currentSession)
-	return err
+	if _, err = a.sessions.Save(genCtx, currentSession); err != nil {
+		return CompactedEvent{}, err
+	}
+	if a.recall != nil {
+		if err := a.recall.Index(genCtx, sessionID, msgs); err != nil {
+			slog.Warn("Failed to store the compacted turns", "session_id", sessionID, "error", err)
+		}
+	}
+
+	event := CompactedEvent{
+		SessionID:        sessionID,
+		SummaryMessageID: summaryMessage.ID,
+		Automatic:        trigger != compactOnRequest,
+		Overflow:         trigger == compactOnOverflow,
+		Messages:         len(msgs),
+		TokensBefore:     tokensBefore,
+		TokensAfter:      usage.OutputTokens,
+	}
+	compactionBroker.Publish(pubsub.CreatedEvent, event)
+	return event, nil
 }
 
 func (a *sessionAgent) getCacheControlOptions() fantasy.ProviderOptions {
@@ -698,9 +975,17 @@ func (a *sessionAgent) createUserMessage(ctx context.Context, call SessionAgentC
 		attachmentParts = append(attachmentParts, message.BinaryContent{Path: attachment.FilePath, MIMEType: attachment.MimeType, Data: attachment.Content})
 	}
 	parts = append(parts, attachmentParts...)
+	if call.translation != nil {
+		parts = append(parts, *call.translation)
+	}
+	// The model the prompt is sent to is recorded, so switching models in a
+	// session shows in its messages.
+	model := a.largeModel.Get()
 	msg, err := a.messages.Create(ctx, call.SessionID, message.CreateMessageParams{
-		Role:  message.User,
-		Parts: parts,
+		Role:     message.User,
+		Parts:    parts,
+		Model:    model.ModelCfg.Model,
+		Provider: model.ModelCfg.Provider,
 	})
 	if err != nil {
 		return message.Message{}, fmt.Errorf("failed to create user message: %w", err)
@@ -708,6 +993,34 @@ func (a *sessionAgent) createUserMessage(ctx context.Context, call SessionAgentC
 	return msg, nil
 }
 
+// planModeReminder is sent on every step while plan mode is on, so the model
+// knows its edits are only proposed even after switching modes mid-run.
+const planModeReminder = `<system_reminder>Plan mode is on. Do not try to change the workspace: file edits are returned as proposed diffs without being applied and only read-only commands run. Investigate what you need, then reply with a plan and the proposed changes. Do not mention this message to the user.</system_reminder>`
+
+// reviewModeReminder is sent on every step while review mode is on.
+const reviewModeReminder = `<system_reminder>Review mode is on. File edits are staged for the user to review and are not written until they apply them, so files on disk keep their old content. Make all changes to a file in a single edit, multiedit or write call, and do not run commands that depend on your edits being applied. Do not mention this message to the user.</system_reminder>`
+
+// changedFilesReminder tells the model which of the files it read were
+// changed since outside of the session, so it reads them again instead of
+// working from their old content. It is sent on every step until they are
+// read again.
+func (a *sessionAgent) changedFilesReminder(ctx context.Context, sessionID string) string {
+	if a.filetracker == nil {
+		return ""
+	}
+	paths := a.filetracker.ChangedFiles(ctx, sessionID)
+	if len(paths) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("<system_reminder>These files changed since you last read them, edited by the user or by a command. What you know of their content is out of date: read them again before relying on it or editing them. Do not mention this message to the user.\n")
+	for _, path := range paths {
+		sb.WriteString("- " + path + "\n")
+	}
+	sb.WriteString("</system_reminder>")
+	return sb.String()
+}
+
 func (a *sessionAgent) preparePrompt(msgs []message.Message, attachments ...message.Attachment) ([]fantasy.Message, []fantasy.FilePart) {
 	var history []fantasy.Message
 	if !a.isSubAgent {
@@ -719,7 +1032,7 @@ If not, please feel free to ignore. Again do not mention this message to the use
 			),
 		))
 	}
-	for _, m := range msgs {
+	for _, m := range dedupeFileReads(msgs) {
 		if len(m.Parts) == 0 {
 			continue
 		}
@@ -768,30 +1081,39 @@ func (a *sessionAgent) getSessionMessages(ctx context.Context, session session.S
 	return msgs, nil
 }
 
-// generateTitle generates a session titled based on the initial prompt.
-func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, userPrompt string) {
+// generateTitle generates a session title based on the first prompt and
+// answer. A title the user set in the meantime is kept.
+func (a *sessionAgent) generateTitle(ctx context.Context, sessionID, oldTitle, userPrompt, answer string) {
 	if userPrompt == "" {
 		return
 	}
+	content := userPrompt
+	if answer = strings.TrimSpace(answer); answer != "" {
+		if runes := []rune(answer); len(runes) > titleAnswerLimit {
+			answer = string(runes[:titleAnswerLimit]) + "…"
+		}
+		content = fmt.Sprintf("%s\n\nThe answer began:\n\n%s", userPrompt, answer)
+	}
 
-	smallModel := a.smallModel.Get()
+	titleModel := a.TitleModel()
 	largeModel := a.largeModel.Get()
 	systemPromptPrefix := a.systemPromptPrefix.Get()
 
 	var maxOutputTokens int64 = 40
-	if smallModel.CatwalkCfg.CanReason {
-		maxOutputTokens = smallModel.CatwalkCfg.DefaultMaxTokens
+	if titleModel.CatwalkCfg.CanReason {
+		maxOutputTokens = titleModel.CatwalkCfg.DefaultMaxTokens
 	}
 
 	newAgent := func(m fantasy.LanguageModel, p []byte, tok int64) fantasy.Agent {
 		return fantasy.NewAgent(m,
 			fantasy.WithSystemPrompt(string(p)+"\n /no_think"),
 			fantasy.WithMaxOutputTokens(tok),
+			fantasy.WithMaxRetries(0),
 		)
 	}
 
 	streamCall := fantasy.AgentStreamCall{
-		Prompt: fmt.Sprintf("Generate a concise title for the following content:\n\n%s\n <think>\n\n</think>", userPrompt),
+		Prompt: fmt.Sprintf("Generate a concise title for the following content:\n\n%s\n <think>\n\n</think>", content),
 		PrepareStep: func(callCtx context.Context, opts fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
 			prepared.Messages = opts.Messages
 			if systemPromptPrefix != "" {
@@ -803,16 +1125,17 @@ func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, user
 		},
 	}
 
-	// Use the small model to generate the title.
-	model := smallModel
+	// Use the title model, the small one unless configured, to generate
+	// the title.
+	model := titleModel
 	agent := newAgent(model.Model, titlePrompt, maxOutputTokens)
 	resp, err := agent.Stream(ctx, streamCall)
 	if err == nil {
-		// We successfully generated a title with the small model.
-		slog.Debug("Generated title with small model")
+		// We successfully generated a title with the title model.
+		slog.Debug("Generated title with title model")
 	} else {
 		// It didn't work. Let's try with the big model.
-		slog.Error("Error generating title with small model; trying big model", "err", err)
+		slog.Error("Error generating title with title model; trying big model", "err", err)
 		model = largeModel
 		agent = newAgent(model.Model, titlePrompt, maxOutputTokens)
 		resp, err = agent.Stream(ctx, streamCall)
@@ -822,10 +1145,7 @@ func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, user
 			// Welp, the large model didn't work either. Use the default
 			// session name and return.
 			slog.Error("Error generating title with large model", "err", err)
-			saveErr := a.sessions.UpdateTitleAndUsage(ctx, sessionID, defaultSessionName, 0, 0, 0)
-			if saveErr != nil {
-				slog.Error("Failed to save session title and usage", "error", saveErr)
-			}
+			a.saveTitleAndUsage(ctx, sessionID, oldTitle, defaultSessionName, 0, 0, 0)
 			return
 		}
 	}
@@ -834,10 +1154,7 @@ func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, user
 		// Actually, we didn't get a response so we can't. Use the default
 		// session name and return.
 		slog.Error("Response is nil; can't generate title")
-		saveErr := a.sessions.UpdateTitleAndUsage(ctx, sessionID, defaultSessionName, 0, 0, 0)
-		if saveErr != nil {
-			slog.Error("Failed to save session title and usage", "error", saveErr)
-		}
+		a.saveTitleAndUsage(ctx, sessionID, oldTitle, defaultSessionName, 0, 0, 0)
 		return
 	}
 
@@ -881,12 +1198,100 @@ func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, user
 	promptTokens := resp.TotalUsage.InputTokens + resp.TotalUsage.CacheCreationTokens
 	completionTokens := resp.TotalUsage.OutputTokens
 
+	a.saveTitleAndUsage(ctx, sessionID, oldTitle, title, promptTokens, completionTokens, cost)
+	a.recordUsage(ctx, sessionID, model, promptTokens, completionTokens, cost)
+}
+
+// saveTitleAndUsage sets the title of the session, unless it was renamed
+// since it was oldTitle, and adds the usage of generating it.
+func (a *sessionAgent) saveTitleAndUsage(ctx context.Context, sessionID, oldTitle, title string, promptTokens, completionTokens int64, cost float64) {
+	if current, err := a.sessions.Get(ctx, sessionID); err == nil && current.Title != oldTitle {
+		title = current.Title
+	}
+
 	// Atomically update only title and usage fields to avoid overriding other
 	// concurrent session updates.
 	saveErr := a.sessions.UpdateTitleAndUsage(ctx, sessionID, title, promptTokens, completionTokens, cost)
 	if saveErr != nil {
 		slog.Error("Failed to save session title and usage", "error", saveErr)
-		return
+	}
+}
+
+// AskSmallModel sends prompt to the small model with systemPrompt and
+// returns its answer, for side tasks such as translations. Its cost is added
+// to the session.
+func (a *sessionAgent) AskSmallModel(ctx context.Context, sessionID, systemPrompt, prompt string) (string, error) {
+	model := a.smallModel.Get()
+	systemPromptPrefix := a.systemPromptPrefix.Get()
+
+	opts := []fantasy.AgentOption{
+		fantasy.WithSystemPrompt(systemPrompt + "\n /no_think"),
+		fantasy.WithMaxRetries(0),
+	}
+	if model.CatwalkCfg.DefaultMaxTokens > 0 {
+		opts = append(opts, fantasy.WithMaxOutputTokens(model.CatwalkCfg.DefaultMaxTokens))
+	}
+	agent := fantasy.NewAgent(model.Model, opts...)
+	resp, err := agent.Stream(ctx, fantasy.AgentStreamCall{
+		Prompt: prompt,
+		PrepareStep: func(callCtx context.Context, opts fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
+			prepared.Messages = opts.Messages
+			if systemPromptPrefix != "" {
+				prepared.Messages = append([]fantasy.Message{
+					fantasy.NewSystemMessage(systemPromptPrefix),
+				}, prepared.Messages...)
+			}
+			return callCtx, prepared, nil
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp == nil {
+		return "", fmt.Errorf("no response from %s", model.CatwalkCfg.Name)
+	}
+
+	usage := resp.TotalUsage
+	cfg := model.CatwalkCfg
+	cost := cfg.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
+		cfg.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
+		cfg.CostPer1MIn/1e6*float64(usage.InputTokens) +
+		cfg.CostPer1MOut/1e6*float64(usage.OutputTokens)
+	if len(resp.Steps) > 0 {
+		if stepCost := a.openrouterCost(resp.Steps[len(resp.Steps)-1].ProviderMetadata); stepCost != nil {
+			cost = *stepCost
+		}
+	}
+	// Requests made outside of a session, such as commit messages, are not
+	// counted.
+	if sessionID == "" {
+		return strings.TrimSpace(thinkTagRegex.ReplaceAllString(resp.Response.Content.Text(), "")), nil
+	}
+	// Only the cost is added to the session: its tokens are those of the
+	// context of the main model.
+	if current, err := a.sessions.Get(ctx, sessionID); err == nil {
+		if err := a.sessions.UpdateTitleAndUsage(ctx, sessionID, current.Title, 0, 0, cost); err != nil {
+			slog.Error("Failed to save small model usage", "error", err)
+		}
+	}
+	a.recordUsage(ctx, sessionID, model, usage.InputTokens+usage.CacheCreationTokens, usage.OutputTokens, cost)
+
+	return strings.TrimSpace(thinkTagRegex.ReplaceAllString(resp.Response.Content.Text(), "")), nil
+}
+
+// recordUsage keeps a record of one request, so its cost can be reported by
+// the label the session had at the time.
+func (a *sessionAgent) recordUsage(ctx context.Context, sessionID string, model Model, promptTokens, completionTokens int64, cost float64) {
+	err := a.sessions.RecordUsage(ctx, session.Usage{
+		SessionID:        sessionID,
+		Model:            model.ModelCfg.Model,
+		Provider:         model.ModelCfg.Provider,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		Cost:             cost,
+	})
+	if err != nil {
+		slog.Error("Failed to record usage", "session_id", sessionID, "error", err)
 	}
 }
 
@@ -903,7 +1308,7 @@ func (a *sessionAgent) openrouterCost(metadata fantasy.ProviderMetadata) *float6
 	return &opts.Usage.Cost
 }
 
-func (a *sessionAgent) updateSessionUsage(model Model, session *session.Session, usage fantasy.Usage, overrideCost *float64) {
+func (a *sessionAgent) updateSessionUsage(ctx context.Context, model Model, session *session.Session, usage fantasy.Usage, overrideCost *float64) {
 	modelConfig := model.CatwalkCfg
 	cost := modelConfig.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
 		modelConfig.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
@@ -913,13 +1318,14 @@ func (a *sessionAgent) updateSessionUsage(model Model, session *session.Session,
 	a.eventTokensUsed(session.ID, model, usage, cost)
 
 	if overrideCost != nil {
-		session.Cost += *overrideCost
-	} else {
-		session.Cost += cost
+		cost = *overrideCost
 	}
+	session.Cost += cost
+	a.recordUsage(ctx, session.ID, model, usage.InputTokens+usage.CacheCreationTokens+usage.CacheReadTokens, usage.OutputTokens, cost)
 
 	session.CompletionTokens = usage.OutputTokens
 	session.PromptTokens = usage.InputTokens + usage.CacheReadTokens
+	session.ReasoningTokens += usage.ReasoningTokens
 }
 
 func (a *sessionAgent) Cancel(sessionID string) {
@@ -942,6 +1348,12 @@ func (a *sessionAgent) Cancel(sessionID string) {
 		slog.Debug("Clearing queued prompts", "session_id", sessionID)
 		a.messageQueue.Del(sessionID)
 	}
+	a.interrupts.Del(sessionID)
+}
+
+func (a *sessionAgent) HasInterrupt(sessionID string) bool {
+	_, ok := a.interrupts.Get(sessionID)
+	return ok
 }
 
 func (a *sessionAgent) ClearQueue(sessionID string) {
@@ -1006,9 +1418,11 @@ func (a *sessionAgent) QueuedPromptsList(sessionID string) []string {
 	return prompts
 }
 
-func (a *sessionAgent) SetModels(large Model, small Model) {
-	a.largeModel.Set(large)
-	a.smallModel.Set(small)
+func (a *sessionAgent) SetModels(models Models) {
+	a.largeModel.Set(models.Large)
+	a.smallModel.Set(models.Small)
+	a.titleModel.Set(models.Title)
+	a.summaryModel.Set(models.Summary)
 }
 
 func (a *sessionAgent) SetTools(tools []fantasy.AgentTool) {
@@ -1019,10 +1433,30 @@ func (a *sessionAgent) SetSystemPrompt(systemPrompt string) {
 	a.systemPrompt.Set(systemPrompt)
 }
 
+func (a *sessionAgent) SetRepoMap(repoMap *repomap.Map) {
+	a.repoMap.Store(repoMap)
+}
+
 func (a *sessionAgent) Model() Model {
 	return a.largeModel.Get()
 }
 
+// SummaryModel returns the model used to compact the session.
+func (a *sessionAgent) SummaryModel() Model {
+	if m := a.summaryModel.Get(); m.Model != nil {
+		return m
+	}
+	return a.largeModel.Get()
+}
+
+// TitleModel returns the model used to title sessions.
+func (a *sessionAgent) TitleModel() Model {
+	if m := a.titleModel.Get(); m.Model != nil {
+		return m
+	}
+	return a.smallModel.Get()
+}
+
 // convertToToolResult converts a fantasy tool result to a message tool result.
 func (a *sessionAgent) convertToToolResult(result fantasy.ToolResultContent) message.ToolResult {
 	baseResult := message.ToolResult{
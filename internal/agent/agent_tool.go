@@ -29,7 +29,7 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 	if !ok {
 		return nil, errors.New("task agent not configured")
 	}
-	prompt, err := taskPrompt(prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	prompt, err := configuredTaskPrompt(c.cfg, prompt.WithWorkingDir(c.cfg.WorkingDir()), prompt.WithWorkspaceRoots(c.workspace.Roots()))
 	if err != nil {
 		return nil, err
 	}
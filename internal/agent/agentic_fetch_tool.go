@@ -146,10 +146,11 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				return fantasy.ToolResponse{}, fmt.Errorf("error creating prompt: %s", err)
 			}
 
-			_, small, err := c.buildAgentModels(ctx, true)
+			models, err := c.buildAgentModels(ctx, true)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error building models: %s", err)
 			}
+			small := models.Small
 
 			systemPrompt, err := promptTemplate.Build(ctx, small.Model.Provider(), small.Model.Model(), *c.cfg)
 			if err != nil {
@@ -162,7 +163,11 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 			}
 
 			webFetchTool := tools.NewWebFetchTool(tmpDir, client)
-			webSearchTool := tools.NewWebSearchTool(client)
+			searcher, err := c.searcher(client)
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("invalid web_search: %w", err)
+			}
+			webSearchTool := tools.NewWebSearchTool(searcher)
 			fetchTools := []fantasy.AgentTool{
 				webFetchTool,
 				webSearchTool,
@@ -0,0 +1,288 @@
+package agent
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// TrimStrategy is how attachments over the budget are made to fit.
+type TrimStrategy string
+
+const (
+	// TrimOutline replaces text attachments with their declarations and
+	// headings.
+	TrimOutline TrimStrategy = "outline"
+	// TrimTopMatches keeps the parts of text attachments that best match
+	// the prompt.
+	TrimTopMatches TrimStrategy = "top_matches"
+	// TrimSplit sends the attachments whole, spread over several messages.
+	TrimSplit TrimStrategy = "split"
+)
+
+const (
+	// matchChunkLines is the size of the blocks top matches are picked from.
+	matchChunkLines = 30
+	// minQueryWordLen skips short words that would match everywhere.
+	minQueryWordLen = 3
+)
+
+var outlineLine = regexp.MustCompile(`^\s*(?:#{1,6}\s|(?:export\s+|pub(?:\([\w:]+\))?\s+|public\s+|private\s+|protected\s+|static\s+|abstract\s+|async\s+|default\s+)*(?:func|function|def|class|type|interface|struct|enum|trait|impl|module|package|fn|const|var|let)\b)`)
+
+// AttachmentBudget returns the most tokens the attachments of a single
+// message may take: configured when set, otherwise a quarter of the model's
+// context window. Zero means no limit.
+func AttachmentBudget(model Model, configured int) int64 {
+	if configured > 0 {
+		return int64(configured)
+	}
+	return model.CatwalkCfg.ContextWindow / 4
+}
+
+// AttachmentTokens returns the estimated tokens of attachments.
+func AttachmentTokens(attachments []message.Attachment) int64 {
+	var tokens int64
+	for _, att := range attachments {
+		tokens += EstimateAttachmentTokens(att)
+	}
+	return tokens
+}
+
+// TrimAttachments fits attachments into budget using strategy and returns
+// the attachments of each message to send, in order. Only text attachments
+// are trimmed or split; images and documents are kept whole. query is the
+// prompt, used to rank the parts of a file for [TrimTopMatches].
+func TrimAttachments(attachments []message.Attachment, strategy TrimStrategy, query string, budget int64) [][]message.Attachment {
+	if budget <= 0 || AttachmentTokens(attachments) <= budget {
+		return [][]message.Attachment{attachments}
+	}
+	switch strategy {
+	case TrimSplit:
+		return splitAttachments(attachments, budget)
+	case TrimOutline, TrimTopMatches:
+		return [][]message.Attachment{shrinkAttachments(attachments, strategy, query, budget)}
+	default:
+		return [][]message.Attachment{attachments}
+	}
+}
+
+// shrinkAttachments fits text attachments into what the other attachments
+// leave of budget. Those smaller than an even share of what is left are kept
+// whole, smallest first; the rest share the remainder in proportion to their
+// size and are trimmed to it.
+func shrinkAttachments(attachments []message.Attachment, strategy TrimStrategy, query string, budget int64) []message.Attachment {
+	var text []int
+	available := budget
+	for i, att := range attachments {
+		if att.IsText() {
+			text = append(text, i)
+		} else {
+			available -= EstimateAttachmentTokens(att)
+		}
+	}
+	available = max(0, available)
+	slices.SortStableFunc(text, func(a, b int) int {
+		return cmp.Compare(EstimateAttachmentTokens(attachments[a]), EstimateAttachmentTokens(attachments[b]))
+	})
+
+	whole := make(map[int]bool)
+	for n, i := range text {
+		tokens := EstimateAttachmentTokens(attachments[i])
+		if tokens > available/int64(len(text)-n) {
+			break
+		}
+		whole[i] = true
+		available -= tokens
+	}
+	var trimmedTokens int64
+	for _, i := range text {
+		if !whole[i] {
+			trimmedTokens += EstimateAttachmentTokens(attachments[i])
+		}
+	}
+
+	trimmed := slices.Clone(attachments)
+	for _, i := range text {
+		if whole[i] {
+			continue
+		}
+		share := available * EstimateAttachmentTokens(attachments[i]) / trimmedTokens
+		if strategy == TrimOutline {
+			trimmed[i] = outlineAttachment(attachments[i], share)
+		} else {
+			trimmed[i] = topMatchesAttachment(attachments[i], query, share)
+		}
+	}
+	return trimmed
+}
+
+// outlineAttachment replaces the content of a text attachment with its
+// declarations and headings, each with its line number.
+func outlineAttachment(att message.Attachment, budget int64) message.Attachment {
+	lines := attachmentLines(att)
+	var outline strings.Builder
+	fmt.Fprintf(&outline, "Outline of %s (%d lines). Only declarations and headings are shown; ask for the code you need.\n", att.FileName, len(lines))
+	shown := 0
+	var declarations int
+	for i, line := range lines {
+		if !outlineLine.MatchString(line) {
+			continue
+		}
+		declarations++
+		entry := fmt.Sprintf("%d: %s\n", i+1, strings.TrimRightFunc(line, unicode.IsSpace))
+		// Leave room for the note on the declarations not shown.
+		if int64(outline.Len()+len(entry)+64) > budget*charsPerToken {
+			continue
+		}
+		outline.WriteString(entry)
+		shown++
+	}
+	if shown < declarations {
+		fmt.Fprintf(&outline, "... %d more declarations not shown\n", declarations-shown)
+	}
+	att.Content = []byte(outline.String())
+	return att
+}
+
+// topMatchesAttachment keeps the blocks of a text attachment that mention
+// the words of query most, in file order, with the line numbers of each.
+// Blocks are whole lines, so no line is cut.
+func topMatchesAttachment(att message.Attachment, query string, budget int64) message.Attachment {
+	lines := attachmentLines(att)
+	words := queryWords(query)
+
+	type chunk struct {
+		start, end int
+		text       string
+		score      int
+	}
+	var chunks []chunk
+	for start := 0; start < len(lines); start += matchChunkLines {
+		end := min(start+matchChunkLines, len(lines))
+		text := strings.Join(lines[start:end], "\n")
+		lower := strings.ToLower(text)
+		score := 0
+		for _, word := range words {
+			score += strings.Count(lower, word)
+		}
+		chunks = append(chunks, chunk{start: start, end: end, text: text, score: score})
+	}
+
+	ranked := slices.Clone(chunks)
+	// Stable, so ties keep file order and a query without matches keeps the
+	// start of the file.
+	slices.SortStableFunc(ranked, func(a, b chunk) int { return cmp.Compare(b.score, a.score) })
+
+	header := fmt.Sprintf("Parts of %s (%d lines) that best match the prompt; ask for other lines if needed.\n", att.FileName, len(lines))
+	used := EstimateTokens(header)
+	// Blocks without a match are only worth sending when nothing matched.
+	matched := ranked[0].score > 0
+	keep := make(map[int]bool)
+	for _, c := range ranked {
+		if matched && c.score == 0 {
+			break
+		}
+		tokens := EstimateTokens(c.text) + 4
+		if used+tokens > budget {
+			continue
+		}
+		used += tokens
+		keep[c.start] = true
+	}
+
+	var out strings.Builder
+	out.WriteString(header)
+	for _, c := range chunks {
+		if keep[c.start] {
+			fmt.Fprintf(&out, "\n[lines %d-%d]\n%s\n", c.start+1, c.end, c.text)
+		}
+	}
+	if len(keep) < len(chunks) {
+		fmt.Fprintf(&out, "\n[%d of %d blocks of %d lines omitted]\n", len(chunks)-len(keep), len(chunks), matchChunkLines)
+	}
+	att.Content = []byte(out.String())
+	return att
+}
+
+// attachmentLines returns the lines of a text attachment.
+func attachmentLines(att message.Attachment) []string {
+	return strings.Split(strings.TrimSuffix(string(att.Content), "\n"), "\n")
+}
+
+// queryWords returns the distinct lowercase words of query worth matching.
+func queryWords(query string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	var words []string
+	for _, field := range fields {
+		if len(field) >= minQueryWordLen && !slices.Contains(words, field) {
+			words = append(words, field)
+		}
+	}
+	return words
+}
+
+// splitAttachments groups attachments into messages within budget. Text
+// attachments too big for one message are split into parts at line breaks.
+func splitAttachments(attachments []message.Attachment, budget int64) [][]message.Attachment {
+	var turns [][]message.Attachment
+	var current []message.Attachment
+	var used int64
+	add := func(att message.Attachment) {
+		tokens := EstimateAttachmentTokens(att)
+		if len(current) > 0 && used+tokens > budget {
+			turns = append(turns, current)
+			current, used = nil, 0
+		}
+		current = append(current, att)
+		used += tokens
+	}
+	for _, att := range attachments {
+		if !att.IsText() || EstimateAttachmentTokens(att) <= budget {
+			add(att)
+			continue
+		}
+		for _, part := range splitText(att, budget) {
+			add(part)
+		}
+	}
+	if len(current) > 0 {
+		turns = append(turns, current)
+	}
+	return turns
+}
+
+// splitText splits a text attachment into parts of at most budget tokens,
+// breaking between lines.
+func splitText(att message.Attachment, budget int64) []message.Attachment {
+	maxBytes := int(budget) * charsPerToken
+	var parts [][]byte
+	content := att.Content
+	for len(content) > maxBytes {
+		cut := bytes.LastIndexByte(content[:maxBytes], '\n') + 1
+		if cut == 0 {
+			// A single line longer than the budget has to be cut.
+			cut = maxBytes
+		}
+		parts = append(parts, content[:cut])
+		content = content[cut:]
+	}
+	if len(content) > 0 {
+		parts = append(parts, content)
+	}
+
+	split := make([]message.Attachment, len(parts))
+	for i, part := range parts {
+		split[i] = att
+		split[i].FileName = fmt.Sprintf("%s (part %d of %d)", att.FileName, i+1, len(parts))
+		split[i].Content = part
+	}
+	return split
+}
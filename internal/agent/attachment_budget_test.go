@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+// goSource returns a Go file of n functions, each a few lines long.
+func goSource(n int) []byte {
+	var b strings.Builder
+	b.WriteString("package big\n\n")
+	for i := range n {
+		fmt.Fprintf(&b, "func F%d() int {\n\tx := %d\n\treturn x * 2\n}\n\n", i, i)
+	}
+	return []byte(b.String())
+}
+
+func TestAttachmentBudget(t *testing.T) {
+	t.Parallel()
+
+	model := Model{CatwalkCfg: catwalk.Model{ContextWindow: 200_000}}
+	require.Equal(t, int64(50_000), AttachmentBudget(model, 0))
+	require.Equal(t, int64(1000), AttachmentBudget(model, 1000))
+	require.Zero(t, AttachmentBudget(Model{}, 0))
+}
+
+func TestTrimAttachmentsWithinBudget(t *testing.T) {
+	t.Parallel()
+
+	atts := []message.Attachment{{FileName: "a.go", MimeType: "text/plain", Content: goSource(5)}}
+	for _, strategy := range []TrimStrategy{TrimOutline, TrimTopMatches, TrimSplit, ""} {
+		turns := TrimAttachments(atts, strategy, "", 10_000)
+		require.Equal(t, [][]message.Attachment{atts}, turns)
+	}
+	turns := TrimAttachments(atts, "", "", 10)
+	require.Equal(t, [][]message.Attachment{atts}, turns, "sending anyway keeps everything")
+}
+
+func TestTrimAttachmentsOutline(t *testing.T) {
+	t.Parallel()
+
+	img := message.Attachment{FileName: "shot.png", MimeType: "image/png", Content: testPNG(t, 100, 50)}
+	src := message.Attachment{FileName: "big.go", MimeType: "text/plain", Content: goSource(200)}
+	turns := TrimAttachments([]message.Attachment{img, src}, TrimOutline, "", 1000)
+	require.Len(t, turns, 1)
+	require.Len(t, turns[0], 2)
+	require.Equal(t, img, turns[0][0], "images are kept whole")
+
+	outline := string(turns[0][1].Content)
+	require.Contains(t, outline, "Outline of big.go (1002 lines)")
+	require.Contains(t, outline, "1: package big\n")
+	require.Contains(t, outline, "3: func F0() int {\n")
+	require.NotContains(t, outline, "return x")
+	require.LessOrEqual(t, AttachmentTokens(turns[0]), int64(1000))
+}
+
+func TestTrimAttachmentsOutlineOverflow(t *testing.T) {
+	t.Parallel()
+
+	src := message.Attachment{FileName: "big.go", MimeType: "text/plain", Content: goSource(200)}
+	turns := TrimAttachments([]message.Attachment{src}, TrimOutline, "", 200)
+	outline := string(turns[0][0].Content)
+	require.Contains(t, outline, "more declarations not shown")
+	require.LessOrEqual(t, EstimateTokens(outline), int64(220))
+}
+
+func TestTrimAttachmentsTopMatches(t *testing.T) {
+	t.Parallel()
+
+	content := goSource(200)
+	content = append(content, []byte("func HandleWebhook() error {\n\treturn verifyWebhookSignature()\n}\n")...)
+	src := message.Attachment{FileName: "big.go", MimeType: "text/plain", Content: content}
+	turns := TrimAttachments([]message.Attachment{src}, TrimTopMatches, "Why does the webhook fail?", 200)
+
+	text := string(turns[0][0].Content)
+	require.Contains(t, text, "Parts of big.go (1005 lines) that best match the prompt")
+	require.Contains(t, text, "verifyWebhookSignature")
+	require.Contains(t, text, "[lines 991-1005]")
+	require.Contains(t, text, "blocks of 30 lines omitted")
+	require.NotContains(t, text, "func F0()")
+}
+
+func TestTrimAttachmentsTopMatchesNoQuery(t *testing.T) {
+	t.Parallel()
+
+	src := message.Attachment{FileName: "big.go", MimeType: "text/plain", Content: goSource(200)}
+	turns := TrimAttachments([]message.Attachment{src}, TrimTopMatches, "", 200)
+	require.Contains(t, string(turns[0][0].Content), "[lines 1-30]\npackage big\n", "without matches the start of the file is kept")
+}
+
+func TestTrimAttachmentsShare(t *testing.T) {
+	t.Parallel()
+
+	small := message.Attachment{FileName: "small.go", MimeType: "text/plain", Content: goSource(2)}
+	big := message.Attachment{FileName: "big.go", MimeType: "text/plain", Content: goSource(200)}
+	turns := TrimAttachments([]message.Attachment{small, big}, TrimOutline, "", 1000)
+	require.Equal(t, small, turns[0][0], "attachments within their share are kept whole")
+	require.Contains(t, string(turns[0][1].Content), "Outline of big.go")
+}
+
+func TestTrimAttachmentsSplit(t *testing.T) {
+	t.Parallel()
+
+	a := message.Attachment{FileName: "a.go", MimeType: "text/plain", Content: goSource(10)}
+	big := message.Attachment{FileName: "big.go", MimeType: "text/plain", Content: goSource(200)}
+	budget := EstimateAttachmentTokens(big)/3 + 1
+	turns := TrimAttachments([]message.Attachment{a, big}, TrimSplit, "", budget)
+	require.Greater(t, len(turns), 2)
+
+	var parts []message.Attachment
+	var joined []byte
+	for _, turn := range turns {
+		require.LessOrEqual(t, AttachmentTokens(turn), budget)
+		for _, att := range turn {
+			if strings.HasPrefix(att.FileName, "big.go") {
+				parts = append(parts, att)
+				joined = append(joined, att.Content...)
+			}
+		}
+	}
+	require.Equal(t, a, turns[0][0])
+	require.Equal(t, big.Content, joined, "the parts add up to the file")
+	require.Equal(t, fmt.Sprintf("big.go (part 1 of %d)", len(parts)), parts[0].FileName)
+	for _, part := range parts[:len(parts)-1] {
+		require.True(t, strings.HasSuffix(string(part.Content), "\n"), "parts end at line breaks")
+	}
+}
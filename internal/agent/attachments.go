@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// defaultMaxImageDimension is the longest edge images are downscaled to
+	// for providers without a known limit.
+	defaultMaxImageDimension = 2048
+	// maxImageBytes is the largest encoded image most providers accept.
+	maxImageBytes = 5 * 1024 * 1024
+)
+
+// maxImageDimension returns the longest image edge worth sending to a
+// provider. Larger images are downscaled by the provider anyway, so sending
+// them only wastes bandwidth and request size.
+func maxImageDimension(providerType catwalk.Type) int {
+	switch providerType {
+	case catwalk.TypeAnthropic, catwalk.TypeBedrock:
+		return 1568
+	case catwalk.TypeGoogle, catwalk.TypeVertexAI:
+		return 3072
+	default:
+		return defaultMaxImageDimension
+	}
+}
+
+// supportsPDF reports whether the provider accepts PDF documents as file
+// parts.
+func supportsPDF(providerType catwalk.Type) bool {
+	switch providerType {
+	case catwalk.TypeOpenAI, catwalk.TypeOpenAICompat, catwalk.TypeOpenRouter,
+		catwalk.TypeAzure, catwalk.TypeGoogle, catwalk.TypeVertexAI:
+		return true
+	default:
+		return false
+	}
+}
+
+// prepareAttachments drops attachments the model or provider cannot take and
+// downscales images to the provider's limits. Text attachments are always
+// kept since they are inlined into the prompt.
+func prepareAttachments(model Model, providerType catwalk.Type, attachments []message.Attachment) []message.Attachment {
+	if attachments == nil {
+		return nil
+	}
+	prepared := make([]message.Attachment, 0, len(attachments))
+	for _, att := range attachments {
+		switch {
+		case att.IsText():
+			prepared = append(prepared, att)
+		case att.IsImage():
+			if !model.CatwalkCfg.SupportsImages {
+				slog.Warn("Dropping image attachment, model does not support images", "file", att.FileName, "model", model.CatwalkCfg.ID)
+				continue
+			}
+			prepared = append(prepared, downscaleImage(att, maxImageDimension(providerType)))
+		case att.IsPDF():
+			if !supportsPDF(providerType) {
+				slog.Warn("Dropping PDF attachment, provider does not support documents", "file", att.FileName, "provider", providerType)
+				continue
+			}
+			prepared = append(prepared, att)
+		default:
+			slog.Warn("Dropping attachment with unsupported type", "file", att.FileName, "mime", att.MimeType)
+		}
+	}
+	return prepared
+}
+
+// downscaleImage resizes an image attachment so its longest edge is at most
+// maxDim and re-encodes it. JPEGs stay JPEGs; everything else becomes PNG,
+// falling back to JPEG if the PNG is still too large. Images that cannot be
+// decoded are returned unchanged.
+func downscaleImage(att message.Attachment, maxDim int) message.Attachment {
+	img, format, err := image.Decode(bytes.NewReader(att.Content))
+	if err != nil {
+		return att
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() <= maxDim && bounds.Dy() <= maxDim && len(att.Content) <= maxImageBytes {
+		return att
+	}
+	if bounds.Dx() > maxDim || bounds.Dy() > maxDim {
+		img = imaging.Fit(img, maxDim, maxDim, imaging.Lanczos)
+	}
+
+	var buf bytes.Buffer
+	mimeType := "image/png"
+	if format == "jpeg" {
+		mimeType = "image/jpeg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, img)
+		if err == nil && buf.Len() > maxImageBytes {
+			buf.Reset()
+			mimeType = "image/jpeg"
+			err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+		}
+	}
+	if err != nil {
+		slog.Warn("Failed to re-encode image attachment", "file", att.FileName, "error", err)
+		return att
+	}
+
+	att.Content = buf.Bytes()
+	att.MimeType = mimeType
+	return att
+}
@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := range w {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestDownscaleImage(t *testing.T) {
+	t.Parallel()
+
+	small := message.Attachment{FileName: "small.png", MimeType: "image/png", Content: testPNG(t, 100, 50)}
+	require.Equal(t, small, downscaleImage(small, 1568))
+
+	large := message.Attachment{FileName: "large.png", MimeType: "image/png", Content: testPNG(t, 3200, 1600)}
+	got := downscaleImage(large, 1568)
+	require.Equal(t, "image/png", got.MimeType)
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(got.Content))
+	require.NoError(t, err)
+	require.Equal(t, 1568, cfg.Width)
+	require.Equal(t, 784, cfg.Height)
+
+	broken := message.Attachment{FileName: "broken.png", MimeType: "image/png", Content: []byte("not an image")}
+	require.Equal(t, broken, downscaleImage(broken, 10))
+}
+
+func TestPrepareAttachments(t *testing.T) {
+	t.Parallel()
+
+	text := message.Attachment{FileName: "a.txt", MimeType: "text/plain; charset=utf-8", Content: []byte("hi")}
+	img := message.Attachment{FileName: "a.png", MimeType: "image/png", Content: testPNG(t, 10, 10)}
+	pdf := message.Attachment{FileName: "a.pdf", MimeType: message.MimePDF, Content: []byte("%PDF-1.4")}
+	other := message.Attachment{FileName: "a.bin", MimeType: "application/octet-stream"}
+	all := []message.Attachment{text, img, pdf, other}
+
+	vision := Model{CatwalkCfg: catwalk.Model{SupportsImages: true}}
+	noVision := Model{}
+
+	require.Equal(t, []message.Attachment{text, img, pdf}, prepareAttachments(vision, catwalk.TypeOpenAI, all))
+	require.Equal(t, []message.Attachment{text, img}, prepareAttachments(vision, catwalk.TypeAnthropic, all))
+	require.Equal(t, []message.Attachment{text, pdf}, prepareAttachments(noVision, catwalk.TypeGoogle, all))
+	require.Nil(t, prepareAttachments(vision, catwalk.TypeOpenAI, nil))
+}
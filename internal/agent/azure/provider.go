@@ -0,0 +1,162 @@
+// Package azure provides a fantasy.Provider for Azure OpenAI. It talks to
+// the v1 API of a resource by default, and to the deployment routes of an
+// older API version when one is set, mapping model IDs to the names of the
+// deployments serving them.
+package azure
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/openai"
+	azuresdk "github.com/openai/openai-go/v2/azure"
+	"github.com/openai/openai-go/v2/option"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Name is the name of the Azure provider.
+const Name = "azure"
+
+// resourcePattern matches the endpoints of an Azure OpenAI resource, with
+// or without a scheme and a path:
+// * https://resource-id.openai.azure.com;
+// * https://resource-id.cognitiveservices.azure.com/;
+// * https://resource-id.services.ai.azure.com/api/projects/project-name;
+// * resource-id.openai.azure.com.
+var resourcePattern = regexp.MustCompile(`^(?:https?://)?([a-zA-Z0-9-]+)\.(?:openai|cognitiveservices|services\.ai)\.azure\.com(?:/.*)?$`)
+
+// Options configures the Azure provider.
+type Options struct {
+	// Endpoint is the endpoint of the resource.
+	Endpoint string
+	APIKey   string
+	// APIVersion selects a dated API version, such as 2024-10-21, which is
+	// sent with every request. Empty, "v1", "latest" and "preview" use the
+	// v1 API, which needs none.
+	APIVersion string
+	// Deployments maps model IDs to the names of the deployments serving
+	// them. Models without one are sent as is, as deployments named after
+	// their model are.
+	Deployments map[string]string
+	Headers     map[string]string
+	HTTPClient  *http.Client
+	// UseResponsesAPI sends the models that support it to the Responses
+	// API.
+	UseResponsesAPI bool
+}
+
+// New creates an Azure OpenAI provider.
+func New(opts Options) (fantasy.Provider, error) {
+	if opts.Endpoint == "" {
+		return nil, errors.New("azure: endpoint is required")
+	}
+	providerOpts := []openai.Option{openai.WithName(Name)}
+	// Models are mapped to deployments first, since the deployment routes
+	// are picked from the model a request is for.
+	sdkOpts := []option.RequestOption{option.WithMiddleware(deploymentMiddleware(opts.Deployments))}
+	if usesV1(opts.APIVersion) {
+		providerOpts = append(providerOpts, openai.WithBaseURL(V1URL(opts.Endpoint)))
+		if opts.APIVersion == "preview" {
+			sdkOpts = append(sdkOpts, option.WithQueryAdd("api-version", opts.APIVersion))
+		}
+	} else {
+		sdkOpts = append(sdkOpts, azuresdk.WithEndpoint(ResourceURL(opts.Endpoint), opts.APIVersion))
+	}
+	sdkOpts = append(sdkOpts, azuresdk.WithAPIKey(opts.APIKey))
+	providerOpts = append(providerOpts, openai.WithSDKOptions(sdkOpts...))
+	if len(opts.Headers) > 0 {
+		providerOpts = append(providerOpts, openai.WithHeaders(opts.Headers))
+	}
+	if opts.HTTPClient != nil {
+		providerOpts = append(providerOpts, openai.WithHTTPClient(opts.HTTPClient))
+	}
+	if opts.UseResponsesAPI {
+		providerOpts = append(providerOpts, openai.WithUseResponsesAPI())
+	}
+	return openai.New(providerOpts...)
+}
+
+func usesV1(apiVersion string) bool {
+	switch strings.ToLower(apiVersion) {
+	case "", "v1", "latest", "preview":
+		return true
+	}
+	return false
+}
+
+// V1URL returns the base URL of the v1 API of the resource at endpoint.
+// Endpoints that are not those of a resource are used as they are.
+func V1URL(endpoint string) string {
+	if matches := resourcePattern.FindStringSubmatch(endpoint); len(matches) >= 2 {
+		return fmt.Sprintf("https://%s.openai.azure.com/openai/v1", matches[1])
+	}
+	return withScheme(endpoint)
+}
+
+// ResourceURL returns the URL of the resource at endpoint, without the path
+// of an API.
+func ResourceURL(endpoint string) string {
+	if matches := resourcePattern.FindStringSubmatch(endpoint); len(matches) >= 2 {
+		return fmt.Sprintf("https://%s.openai.azure.com", matches[1])
+	}
+	u, err := url.Parse(withScheme(endpoint))
+	if err != nil {
+		return endpoint
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func withScheme(endpoint string) string {
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return "https://" + endpoint
+	}
+	return endpoint
+}
+
+// deploymentMiddleware replaces the model of JSON requests with the name of
+// the deployment serving it.
+func deploymentMiddleware(deployments map[string]string) option.Middleware {
+	return func(r *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		if len(deployments) == 0 || r.Body == nil || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			return next(r)
+		}
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if mapped, ok := replaceModel(body, deployments); ok {
+			body = mapped
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		return next(r)
+	}
+}
+
+func replaceModel(body []byte, deployments map[string]string) ([]byte, bool) {
+	model := gjson.GetBytes(body, "model")
+	if model.Type != gjson.String {
+		return nil, false
+	}
+	deployment, ok := deployments[model.String()]
+	if !ok {
+		return nil, false
+	}
+	mapped, err := sjson.SetBytes(body, "model", deployment)
+	if err != nil {
+		return nil, false
+	}
+	return mapped, true
+}
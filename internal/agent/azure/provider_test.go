@@ -0,0 +1,86 @@
+package azure
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+// chatServer answers chat completions, recording the requests it gets.
+func chatServer(t *testing.T, requests *[]*http.Request, models *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &body))
+		*requests = append(*requests, r)
+		*models = append(*models, body.Model)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Hi"}}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func generate(t *testing.T, opts Options, modelID string) {
+	t.Helper()
+	provider, err := New(opts)
+	require.NoError(t, err)
+	model, err := provider.LanguageModel(t.Context(), modelID)
+	require.NoError(t, err)
+	resp, err := model.Generate(t.Context(), fantasy.Call{Prompt: fantasy.Prompt{fantasy.NewUserMessage("hi")}})
+	require.NoError(t, err)
+	require.Equal(t, "Hi", resp.Content.Text())
+}
+
+func TestDeploymentRoutes(t *testing.T) {
+	t.Parallel()
+
+	var requests []*http.Request
+	var models []string
+	srv := chatServer(t, &requests, &models)
+	generate(t, Options{
+		Endpoint:    srv.URL,
+		APIKey:      "key",
+		APIVersion:  "2024-10-21",
+		Deployments: map[string]string{"gpt-4o": "prod-4o"},
+	}, "gpt-4o")
+
+	require.Len(t, requests, 1)
+	require.Equal(t, "/openai/deployments/prod-4o/chat/completions", requests[0].URL.Path)
+	require.Equal(t, "2024-10-21", requests[0].URL.Query().Get("api-version"))
+	require.Equal(t, "key", requests[0].Header.Get("Api-Key"))
+	require.Equal(t, []string{"prod-4o"}, models)
+}
+
+func TestV1API(t *testing.T) {
+	t.Parallel()
+
+	var requests []*http.Request
+	var models []string
+	srv := chatServer(t, &requests, &models)
+	generate(t, Options{Endpoint: srv.URL + "/openai/v1", APIKey: "key"}, "gpt-4o")
+
+	require.Len(t, requests, 1)
+	require.Equal(t, "/openai/v1/chat/completions", requests[0].URL.Path)
+	require.Empty(t, requests[0].URL.Query().Get("api-version"))
+	require.Equal(t, []string{"gpt-4o"}, models, "models without a deployment are sent as is")
+}
+
+func TestURLs(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "https://res.openai.azure.com/openai/v1", V1URL("res.openai.azure.com"))
+	require.Equal(t, "https://res.openai.azure.com/openai/v1", V1URL("https://res.services.ai.azure.com/api/projects/p"))
+	require.Equal(t, "https://proxy.example.com/azure/v1", V1URL("proxy.example.com/azure/v1"))
+	require.Equal(t, "https://res.openai.azure.com", ResourceURL("https://res.cognitiveservices.azure.com/"))
+	require.Equal(t, "https://proxy.example.com", ResourceURL("https://proxy.example.com/openai/v1"))
+}
@@ -0,0 +1,231 @@
+// Package bedrock provides a fantasy.Provider for the Anthropic models of
+// AWS Bedrock. Requests are signed with SigV4 using the credentials of the
+// configured profile, or sent with a Bedrock API key, and models are
+// invoked through the inference profile of their region unless they are
+// mapped to an ARN.
+package bedrock
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/anthropic"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// Name is the name of the Bedrock provider.
+	Name = "bedrock"
+
+	defaultRegion = "us-east-1"
+	// anthropicVersion is the version of the Anthropic API Bedrock serves.
+	anthropicVersion = "bedrock-2023-05-31"
+)
+
+// Options configures the Bedrock provider.
+type Options struct {
+	// Region is the AWS region to send requests to. It defaults to the
+	// region of the profile, then to us-east-1.
+	Region string
+	// Profile is the shared config profile to take credentials from.
+	Profile string
+	// APIKey is a Bedrock API key, sent instead of signing requests. It
+	// defaults to AWS_BEARER_TOKEN_BEDROCK.
+	APIKey string
+	// Models maps model IDs to the model ARNs or inference profile IDs to
+	// invoke them with.
+	Models     map[string]string
+	Headers    map[string]string
+	HTTPClient *http.Client
+}
+
+// New creates a Bedrock provider, loading the AWS credentials unless an
+// API key is given.
+func New(ctx context.Context, opts Options) (fantasy.Provider, error) {
+	t := &transport{
+		apiKey: cmp.Or(opts.APIKey, os.Getenv("AWS_BEARER_TOKEN_BEDROCK")),
+		models: opts.Models,
+		signer: v4.NewSigner(),
+		next:   http.DefaultTransport,
+	}
+	if opts.HTTPClient != nil && opts.HTTPClient.Transport != nil {
+		t.next = opts.HTTPClient.Transport
+	}
+
+	t.region = opts.Region
+	if t.apiKey == "" {
+		var loadOpts []func(*config.LoadOptions) error
+		if opts.Region != "" {
+			loadOpts = append(loadOpts, config.WithRegion(opts.Region))
+		}
+		if opts.Profile != "" {
+			loadOpts = append(loadOpts, config.WithSharedConfigProfile(opts.Profile))
+		}
+		cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("bedrock: failed to load AWS config: %w", err)
+		}
+		if cfg.Credentials == nil {
+			return nil, errors.New("bedrock: no AWS credentials found")
+		}
+		t.credentials = cfg.Credentials
+		t.region = cmp.Or(t.region, cfg.Region)
+	}
+	t.region = cmp.Or(t.region, defaultRegion)
+
+	providerOpts := []anthropic.Option{
+		anthropic.WithName(Name),
+		anthropic.WithBaseURL(fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", t.region)),
+		anthropic.WithHTTPClient(&http.Client{Transport: t}),
+	}
+	if len(opts.Headers) > 0 {
+		providerOpts = append(providerOpts, anthropic.WithHeaders(opts.Headers))
+	}
+	return anthropic.New(providerOpts...)
+}
+
+// ModelID returns the ID to invoke model with in region: the one it is
+// mapped to, or else the cross-region inference profile of the region,
+// which on-demand use of recent models requires. Model ARNs and IDs that
+// already name a profile are used as they are.
+func ModelID(model, region string, models map[string]string) string {
+	if id, ok := models[model]; ok {
+		return id
+	}
+	if strings.HasPrefix(model, "arn:") {
+		return model
+	}
+	for _, geo := range geographies {
+		if strings.HasPrefix(model, geo+".") {
+			return model
+		}
+	}
+	if geo := geography(region); geo != "" {
+		return geo + "." + model
+	}
+	return model
+}
+
+// geographies are the prefixes of the cross-region inference profiles.
+var geographies = []string{"global", "us-gov", "us", "eu", "apac", "jp", "au"}
+
+func geography(region string) string {
+	switch {
+	case strings.HasPrefix(region, "us-gov-"):
+		return "us-gov"
+	case strings.HasPrefix(region, "us-"):
+		return "us"
+	case strings.HasPrefix(region, "eu-"):
+		return "eu"
+	case strings.HasPrefix(region, "ap-"):
+		return "apac"
+	}
+	return ""
+}
+
+// transport turns the requests of the Anthropic client into Bedrock
+// invocations and authenticates them.
+type transport struct {
+	region      string
+	apiKey      string
+	credentials aws.CredentialsProvider
+	models      map[string]string
+	signer      *v4.Signer
+	next        http.RoundTripper
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+		r.Body.Close()
+	}
+	if r.Method == http.MethodPost && (r.URL.Path == "/v1/messages" || r.URL.Path == "/v1/complete") {
+		var err error
+		if body, err = t.invocation(r, body); err != nil {
+			return nil, err
+		}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	r.ContentLength = int64(len(body))
+	r.Header.Del("X-Api-Key")
+	r.Header.Del("Authorization")
+
+	if t.apiKey != "" {
+		r.Header.Set("Authorization", "Bearer "+t.apiKey)
+		return t.next.RoundTrip(r)
+	}
+	credentials, err := t.credentials.Retrieve(r.Context())
+	if err != nil {
+		return nil, fmt.Errorf("bedrock: failed to retrieve AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err := t.signer.SignHTTP(r.Context(), credentials, r, hex.EncodeToString(hash[:]), "bedrock", t.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("bedrock: failed to sign request: %w", err)
+	}
+	return t.next.RoundTrip(r)
+}
+
+// invocation points r at the invoke route of the model in body, and returns
+// body as Bedrock takes it: without the model and stream fields, which the
+// route carries, and with the betas the headers ask for.
+func (t *transport) invocation(r *http.Request, body []byte) ([]byte, error) {
+	model := ModelID(gjson.GetBytes(body, "model").String(), t.region, t.models)
+	method := "invoke"
+	if gjson.GetBytes(body, "stream").Bool() {
+		method = "invoke-with-response-stream"
+	}
+
+	var err error
+	for _, field := range []string{"model", "stream", "tool_choice.disable_parallel_tool_use"} {
+		if body, err = sjson.DeleteBytes(body, field); err != nil {
+			return nil, err
+		}
+	}
+	if !gjson.GetBytes(body, "anthropic_version").Exists() {
+		if body, err = sjson.SetBytes(body, "anthropic_version", anthropicVersion); err != nil {
+			return nil, err
+		}
+	}
+	if betas := r.Header.Values("Anthropic-Beta"); len(betas) > 0 {
+		var list []string
+		for _, beta := range betas {
+			for b := range strings.SplitSeq(beta, ",") {
+				if b = strings.TrimSpace(b); b != "" {
+					list = append(list, b)
+				}
+			}
+		}
+		if body, err = sjson.SetBytes(body, "anthropic_beta", list); err != nil {
+			return nil, err
+		}
+		r.Header.Del("Anthropic-Beta")
+	}
+
+	r.URL.Path = "/model/" + model + "/" + method
+	r.URL.RawPath = "/model/" + url.QueryEscape(model) + "/" + method
+	return body, nil
+}
@@ -0,0 +1,103 @@
+package bedrock
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// capture returns a transport recording the request it sends and its body.
+func capture(t *testing.T, sent **http.Request, body *string) http.RoundTripper {
+	t.Helper()
+	return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		*sent, *body = r, string(data)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}")), Request: r}, nil
+	})
+}
+
+func newRequest(t *testing.T, body string) *http.Request {
+	t.Helper()
+	r, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "https://bedrock-runtime.us-west-2.amazonaws.com/v1/messages", strings.NewReader(body))
+	require.NoError(t, err)
+	r.Header.Set("X-Api-Key", "anthropic-key")
+	r.Header.Set("Anthropic-Beta", "interleaved-thinking-2025-05-14, fine-grained-tool-streaming-2025-05-14")
+	return r
+}
+
+func TestModelID(t *testing.T) {
+	t.Parallel()
+
+	const sonnet = "anthropic.claude-sonnet-4-5-20250929-v1:0"
+	arn := "arn:aws:bedrock:us-west-2:123456789012:application-inference-profile/abc123"
+	require.Equal(t, "us."+sonnet, ModelID(sonnet, "us-west-2", nil))
+	require.Equal(t, "eu."+sonnet, ModelID(sonnet, "eu-central-1", nil))
+	require.Equal(t, "apac."+sonnet, ModelID(sonnet, "ap-southeast-2", nil))
+	require.Equal(t, "us-gov."+sonnet, ModelID(sonnet, "us-gov-west-1", nil))
+	require.Equal(t, sonnet, ModelID(sonnet, "sa-east-1", nil))
+	require.Equal(t, "global."+sonnet, ModelID("global."+sonnet, "us-west-2", nil))
+	require.Equal(t, arn, ModelID(arn, "us-west-2", nil))
+	require.Equal(t, arn, ModelID(sonnet, "us-west-2", map[string]string{sonnet: arn}))
+}
+
+func TestTransportInvokesModel(t *testing.T) {
+	t.Parallel()
+
+	arn := "arn:aws:bedrock:us-west-2:123456789012:application-inference-profile/abc123"
+	var sent *http.Request
+	var body string
+	tr := &transport{
+		region: "us-west-2",
+		apiKey: "bedrock-key",
+		models: map[string]string{"anthropic.claude-sonnet-4-5-20250929-v1:0": arn},
+		next:   capture(t, &sent, &body),
+	}
+	_, err := tr.RoundTrip(newRequest(t, `{"model":"anthropic.claude-sonnet-4-5-20250929-v1:0","stream":true,"max_tokens":10,"tool_choice":{"type":"auto","disable_parallel_tool_use":true}}`))
+	require.NoError(t, err)
+
+	require.Equal(t, "/model/"+arn+"/invoke-with-response-stream", sent.URL.Path)
+	require.Equal(t, "/model/arn%3Aaws%3Abedrock%3Aus-west-2%3A123456789012%3Aapplication-inference-profile%2Fabc123/invoke-with-response-stream", sent.URL.EscapedPath())
+	require.Equal(t, "Bearer bedrock-key", sent.Header.Get("Authorization"))
+	require.Empty(t, sent.Header.Get("X-Api-Key"))
+	require.Empty(t, sent.Header.Get("Anthropic-Beta"))
+
+	require.False(t, gjson.Get(body, "model").Exists())
+	require.False(t, gjson.Get(body, "stream").Exists())
+	require.False(t, gjson.Get(body, "tool_choice.disable_parallel_tool_use").Exists())
+	require.Equal(t, "auto", gjson.Get(body, "tool_choice.type").String())
+	require.Equal(t, anthropicVersion, gjson.Get(body, "anthropic_version").String())
+	require.Equal(t, `["interleaved-thinking-2025-05-14","fine-grained-tool-streaming-2025-05-14"]`, gjson.Get(body, "anthropic_beta").Raw)
+	require.Equal(t, int64(len(body)), sent.ContentLength)
+}
+
+func TestTransportSignsRequests(t *testing.T) {
+	t.Parallel()
+
+	var sent *http.Request
+	var body string
+	tr := &transport{
+		region:      "us-west-2",
+		credentials: credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", ""),
+		signer:      v4.NewSigner(),
+		next:        capture(t, &sent, &body),
+	}
+	_, err := tr.RoundTrip(newRequest(t, `{"model":"anthropic.claude-3-5-haiku-20241022-v1:0","max_tokens":10}`))
+	require.NoError(t, err)
+
+	require.Equal(t, "/model/us.anthropic.claude-3-5-haiku-20241022-v1:0/invoke", sent.URL.Path)
+	auth := sent.Header.Get("Authorization")
+	require.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), auth)
+	require.Contains(t, auth, "/us-west-2/bedrock/aws4_request")
+	require.NotEmpty(t, sent.Header.Get("X-Amz-Date"))
+}
@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"slices"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+)
+
+// chatOnlyToolNames are the tools chat-only sessions keep. They reach the
+// web but never the workspace.
+var chatOnlyToolNames = []string{
+	tools.FetchToolName,
+	tools.AgenticFetchToolName,
+	tools.SourcegraphToolName,
+	tools.WebSearchToolName,
+}
+
+// chatOnlyTools returns the tools in agentTools a chat-only session may
+// use. MCP tools are dropped too, since there is no telling what they
+// touch.
+func chatOnlyTools(agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	return slices.DeleteFunc(agentTools, func(tool fantasy.AgentTool) bool {
+		return !slices.Contains(chatOnlyToolNames, tool.Info().Name)
+	})
+}
+
+// chatOnlyReminder is sent on every step of a chat-only session, as the
+// system prompt still describes a coding agent.
+const chatOnlyReminder = `<system_reminder>This is a chat-only session. You have no access to files, the shell or the workspace, only to web tools if any are listed. Answer the user directly as a general-purpose assistant and do not offer to read or change files. Do not mention this message to the user.</system_reminder>`
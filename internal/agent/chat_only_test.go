@@ -0,0 +1,27 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatOnlyTools(t *testing.T) {
+	t.Parallel()
+
+	var all []fantasy.AgentTool
+	for _, name := range []string{tools.BashToolName, tools.FetchToolName, tools.ViewToolName, tools.AgenticFetchToolName, tools.WebSearchToolName, "mcp_github_create_issue", AgentToolName} {
+		all = append(all, fantasy.NewAgentTool(name, "", func(context.Context, struct{}, fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			return fantasy.ToolResponse{}, nil
+		}))
+	}
+
+	var names []string
+	for _, tool := range chatOnlyTools(all) {
+		names = append(names, tool.Info().Name)
+	}
+	require.Equal(t, []string{tools.FetchToolName, tools.AgenticFetchToolName, tools.WebSearchToolName}, names)
+}
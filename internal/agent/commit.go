@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxCommitDiff is how many bytes of the staged diff the small model sees.
+const maxCommitDiff = 60 * 1024
+
+const commitSystemPrompt = `You write the commit message of a change from its diff, following Conventional Commits: a subject line of the form "type(scope): summary" of at most 72 characters, where type is one of feat, fix, refactor, perf, test, docs, build, ci, style or chore and the scope is optional, then a blank line and a short body saying what changed and why when the subject is not enough. Write the summary in the imperative mood, in lower case and without a final period. Follow the style of the recent commits of the project when they show one. Answer with the message only, without quotes, code fences or explanations.`
+
+const commitPrompt = `<recent_commits>
+%s
+</recent_commits>
+
+<diff>
+%s
+</diff>`
+
+// GenerateCommitMessage returns a commit message for the staged diff, as
+// written by the small model. recent holds the subjects of the last commits
+// of the project, whose style the message follows.
+func (c *coordinator) GenerateCommitMessage(ctx context.Context, diff, recent string) (string, error) {
+	if strings.TrimSpace(diff) == "" {
+		return "", errors.New("nothing to commit")
+	}
+	if len(diff) > maxCommitDiff {
+		diff = diff[:maxCommitDiff] + "\n[diff truncated]"
+	}
+	text, err := c.currentAgent.AskSmallModel(ctx, "", commitSystemPrompt, fmt.Sprintf(commitPrompt, recent, diff))
+	if err != nil {
+		return "", err
+	}
+	message := cleanCommitMessage(text)
+	if message == "" {
+		return "", errors.New("the model wrote no commit message")
+	}
+	return message, nil
+}
+
+// cleanCommitMessage strips what models wrap commit messages in.
+func cleanCommitMessage(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		_, text, _ = strings.Cut(text, "\n")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+	text = strings.TrimSpace(text)
+	for _, prefix := range []string{"Commit message:", "commit message:"} {
+		text = strings.TrimSpace(strings.TrimPrefix(text, prefix))
+	}
+	if len(text) > 1 && text[0] == '"' && text[len(text)-1] == '"' {
+		text = text[1 : len(text)-1]
+	}
+	return strings.TrimSpace(text)
+}
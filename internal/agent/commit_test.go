@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanCommitMessage(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name, text, want string
+	}{
+		{"plain", "fix(ui): keep the cursor\n\nIt jumped.", "fix(ui): keep the cursor\n\nIt jumped."},
+		{"fenced", "```text\nfeat: add commit\n```", "feat: add commit"},
+		{"labelled", "Commit message: docs: fix typo", "docs: fix typo"},
+		{"quoted", `"chore: bump deps"`, "chore: bump deps"},
+		{"nothing", "  \n", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.want, cleanCommitMessage(tc.text))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+// CompactedEvent is published once a session has been compacted into a
+// summary.
+type CompactedEvent struct {
+	SessionID        string
+	SummaryMessageID string
+	// Automatic reports whether compaction was triggered because the
+	// session was approaching the model's context window, as opposed to
+	// being requested by the user.
+	Automatic bool
+	// Overflow reports whether compaction was triggered because the
+	// provider rejected a request that did not fit in the context window.
+	// The request is sent again once the session is compacted.
+	Overflow bool
+	// Messages is the number of messages the summary replaces.
+	Messages int
+	// TokensBefore is the number of tokens in the context before compaction.
+	TokensBefore int64
+	// TokensAfter is the number of tokens in the summary that replaces it.
+	TokensAfter int64
+}
+
+// compactionTrigger is what a session is compacted for.
+type compactionTrigger int
+
+const (
+	compactOnRequest compactionTrigger = iota
+	compactNearLimit
+	compactOnOverflow
+)
+
+var compactionBroker = pubsub.NewBroker[CompactedEvent]()
+
+// SubscribeCompactions returns a channel that receives an event every time
+// a session is compacted.
+func SubscribeCompactions(ctx context.Context) <-chan pubsub.Event[CompactedEvent] {
+	return compactionBroker.Subscribe(ctx)
+}
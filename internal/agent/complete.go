@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+// repoMapFiles is how many paths of the project the small model sees when
+// completing prompts.
+const repoMapFiles = 300
+
+// maxCompletion is how many bytes of completion are kept.
+const maxCompletion = 200
+
+const completeSystemPrompt = `You complete the prompt a developer is typing to a coding agent working in their project. Continue the draft with the words they most likely type next, up to the end of the sentence at most, using the paths of the project where they fit. Answer with the continuation only: do not repeat the draft, do not quote it and do not explain. Start with a space when the continuation begins a new word. Answer with nothing when you can't tell how the draft goes on.`
+
+const completePrompt = `<project_files>
+%s
+</project_files>
+
+<draft>%s</draft>`
+
+// CompletePrompt returns how the prompt draft goes on, as suggested by the
+// small model with the files of the project as context, or nothing.
+func (c *coordinator) CompletePrompt(ctx context.Context, sessionID, draft string) (string, error) {
+	if strings.TrimSpace(draft) == "" {
+		return "", nil
+	}
+	prompt := fmt.Sprintf(completePrompt, repoMap(c.cfg.WorkingDir()), draft)
+	completion, err := c.currentAgent.AskSmallModel(ctx, sessionID, completeSystemPrompt, prompt)
+	if err != nil {
+		return "", err
+	}
+	return cleanCompletion(draft, completion), nil
+}
+
+// repoMap lists the paths of the project in dir, relative to it, leaving out
+// the ignored ones.
+func repoMap(dir string) string {
+	paths, _, err := fsext.ListDirectory(dir, nil, 0, repoMapFiles)
+	if err != nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, path := range paths {
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			path = rel
+		}
+		if path == "." {
+			continue
+		}
+		sb.WriteString(filepath.ToSlash(path))
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// cleanCompletion keeps the first line of the continuation of draft in
+// completion, without the draft when the model repeated it.
+func cleanCompletion(draft, completion string) string {
+	completion = strings.TrimPrefix(completion, "<draft>")
+	completion, _, _ = strings.Cut(completion, "\n")
+	completion = strings.TrimRight(completion, " \t\r")
+	if strings.HasPrefix(completion, draft) {
+		completion = completion[len(draft):]
+	}
+	if trimmed := strings.TrimSpace(completion); len(trimmed) > 1 && trimmed[0] == '"' && trimmed[len(trimmed)-1] == '"' {
+		completion = trimmed[1 : len(trimmed)-1]
+	}
+	if strings.HasSuffix(draft, " ") {
+		completion = strings.TrimLeft(completion, " ")
+	}
+	if len(completion) > maxCompletion {
+		completion = completion[:maxCompletion]
+		if i := strings.LastIndex(completion, " "); i > 0 {
+			completion = completion[:i]
+		}
+	}
+	if strings.TrimSpace(completion) == "" {
+		return ""
+	}
+	return completion
+}
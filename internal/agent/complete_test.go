@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanCompletion(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name, draft, completion, want string
+	}{
+		{"continuation", "add tests for", " the config loader", " the config loader"},
+		{"repeated draft", "add tests for", "add tests for the config loader", " the config loader"},
+		{"first line", "fix the", " login bug\nand more", " login bug"},
+		{"quoted", "fix the", `"login bug"`, "login bug"},
+		{"after space", "fix the ", " login bug", "login bug"},
+		{"nothing", "fix the", "  \n", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.want, cleanCompletion(tc.draft, tc.completion))
+		})
+	}
+}
+
+func TestRepoMap(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "internal", "config"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "internal", "config", "load.go"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), nil, 0o644))
+
+	got := repoMap(dir)
+	require.Contains(t, got, "internal/config/load.go")
+	require.Contains(t, got, "main.go")
+	require.NotContains(t, got, dir)
+}
@@ -17,23 +17,44 @@ import (
 
 	"charm.land/catwalk/pkg/catwalk"
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/azure"
+	"github.com/charmbracelet/crush/internal/agent/bedrock"
 	"github.com/charmbracelet/crush/internal/agent/hyper"
 	"github.com/charmbracelet/crush/internal/agent/prompt"
 	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/agent/vertex"
+	"github.com/charmbracelet/crush/internal/artifact"
+	"github.com/charmbracelet/crush/internal/audit"
+	"github.com/charmbracelet/crush/internal/buildcheck"
+	"github.com/charmbracelet/crush/internal/codeowners"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/filecache"
+	"github.com/charmbracelet/crush/internal/fileheader"
 	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/formatter"
 	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/home"
 	"github.com/charmbracelet/crush/internal/log"
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/oauth/copilot"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/postprocess"
+	"github.com/charmbracelet/crush/internal/recall"
+	"github.com/charmbracelet/crush/internal/redact"
+	"github.com/charmbracelet/crush/internal/repomap"
+	"github.com/charmbracelet/crush/internal/retry"
+	"github.com/charmbracelet/crush/internal/scrollback"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/staging"
+	"github.com/charmbracelet/crush/internal/telemetry"
+	"github.com/charmbracelet/crush/internal/toolstats"
+	"github.com/charmbracelet/crush/internal/workspace"
 	"golang.org/x/sync/errgroup"
 
 	"charm.land/fantasy/providers/anthropic"
-	"charm.land/fantasy/providers/azure"
-	"charm.land/fantasy/providers/bedrock"
 	"charm.land/fantasy/providers/google"
 	"charm.land/fantasy/providers/openai"
 	"charm.land/fantasy/providers/openaicompat"
@@ -47,6 +68,15 @@ type Coordinator interface {
 	// INFO: (kujtim) this is not used yet we will use this when we have multiple agents
 	// SetMainAgent(string)
 	Run(ctx context.Context, sessionID, prompt string, attachments ...message.Attachment) (*fantasy.AgentResult, error)
+	// RunRaw sends prompt to the model as is: without the system prompt, the
+	// tools, or more of the session than history selects.
+	RunRaw(ctx context.Context, sessionID, prompt string, history RawHistory, attachments ...message.Attachment) (*fantasy.AgentResult, error)
+	// Interrupt stops the answer or tool running in the session and has the
+	// agent continue with note, keeping what it did so far. When the session
+	// is idle note is sent like any other prompt.
+	Interrupt(ctx context.Context, sessionID, note string) (*fantasy.AgentResult, error)
+	// HasInterrupt reports whether the session is being interrupted.
+	HasInterrupt(sessionID string) bool
 	Cancel(sessionID string)
 	CancelAll()
 	IsSessionBusy(sessionID string) bool
@@ -57,6 +87,19 @@ type Coordinator interface {
 	Summarize(context.Context, string) error
 	Model() Model
 	UpdateModels(ctx context.Context) error
+	ContextUsage(ctx context.Context, sessionID string) (ContextUsage, error)
+	// CompletePrompt returns how the prompt draft goes on, as suggested by
+	// the small model, or nothing.
+	CompletePrompt(ctx context.Context, sessionID, draft string) (string, error)
+	// GenerateCommitMessage returns a Conventional Commits message for the
+	// staged diff, written by the small model in the style of the recent
+	// commit subjects.
+	GenerateCommitMessage(ctx context.Context, diff, recent string) (string, error)
+	// Mode returns whether the agent may change the workspace.
+	Mode() tools.Mode
+	// SetMode switches modes. It applies to the next tool call, including
+	// in runs already in progress.
+	SetMode(tools.Mode)
 }
 
 type coordinator struct {
@@ -67,6 +110,20 @@ type coordinator struct {
 	history     history.Service
 	filetracker filetracker.Service
 	lspManager  *lsp.Manager
+	formatters  *formatter.Runner
+	checks      *buildcheck.Runner
+	owners      *codeowners.Checker
+	headers     *fileheader.Policy
+	audit       *audit.Log
+	toolStats   *toolstats.Recorder
+	redactor    *redact.Redactor
+	repoMap     *repomap.Map
+	mode        *csync.Value[tools.Mode]
+	staging     *staging.Queue
+	workspace   *workspace.Manifest
+	files       *filecache.Cache
+	artifacts   *artifact.Store
+	recall      *recall.Store
 
 	currentAgent SessionAgent
 	agents       map[string]SessionAgent
@@ -83,6 +140,13 @@ func NewCoordinator(
 	history history.Service,
 	filetracker filetracker.Service,
 	lspManager *lsp.Manager,
+	auditLog *audit.Log,
+	toolStats *toolstats.Recorder,
+	staged *staging.Queue,
+	manifest *workspace.Manifest,
+	fileCache *filecache.Cache,
+	artifacts *artifact.Store,
+	recall *recall.Store,
 ) (Coordinator, error) {
 	c := &coordinator{
 		cfg:         cfg,
@@ -92,6 +156,20 @@ func NewCoordinator(
 		history:     history,
 		filetracker: filetracker,
 		lspManager:  lspManager,
+		formatters:  formatter.New(cfg),
+		checks:      buildcheck.New(cfg),
+		owners:      codeowners.New(cfg),
+		headers:     fileheader.New(cfg),
+		audit:       auditLog,
+		toolStats:   toolStats,
+		redactor:    redact.New(cfg),
+		repoMap:     repomap.New(cfg),
+		mode:        csync.NewValue(tools.ModeBuild),
+		staging:     staged,
+		workspace:   manifest,
+		files:       fileCache,
+		artifacts:   artifacts,
+		recall:      recall,
 		agents:      make(map[string]SessionAgent),
 	}
 
@@ -101,7 +179,7 @@ func NewCoordinator(
 	}
 
 	// TODO: make this dynamic when we support multiple agents
-	prompt, err := coderPrompt(prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	prompt, err := configuredCoderPrompt(c.cfg, prompt.WithWorkingDir(c.cfg.WorkingDir()), prompt.WithWorkspaceRoots(c.workspace.Roots()))
 	if err != nil {
 		return nil, err
 	}
@@ -117,6 +195,20 @@ func NewCoordinator(
 
 // Run implements Coordinator.
 func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string, attachments ...message.Attachment) (*fantasy.AgentResult, error) {
+	return c.run(ctx, SessionAgentCall{SessionID: sessionID, Prompt: prompt, Attachments: attachments})
+}
+
+// RunRaw implements Coordinator.
+func (c *coordinator) RunRaw(ctx context.Context, sessionID, prompt string, history RawHistory, attachments ...message.Attachment) (*fantasy.AgentResult, error) {
+	return c.run(ctx, SessionAgentCall{SessionID: sessionID, Prompt: prompt, Attachments: attachments, Raw: true, RawHistory: history})
+}
+
+// Interrupt implements Coordinator.
+func (c *coordinator) Interrupt(ctx context.Context, sessionID, note string) (*fantasy.AgentResult, error) {
+	return c.run(ctx, SessionAgentCall{SessionID: sessionID, Prompt: note, Interrupt: true})
+}
+
+func (c *coordinator) run(ctx context.Context, call SessionAgentCall) (*fantasy.AgentResult, error) {
 	if err := c.readyWg.Wait(); err != nil {
 		return nil, err
 	}
@@ -132,22 +224,13 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		maxTokens = model.ModelCfg.MaxTokens
 	}
 
-	if !model.CatwalkCfg.SupportsImages && attachments != nil {
-		// filter out image attachments
-		filteredAttachments := make([]message.Attachment, 0, len(attachments))
-		for _, att := range attachments {
-			if att.IsText() {
-				filteredAttachments = append(filteredAttachments, att)
-			}
-		}
-		attachments = filteredAttachments
-	}
-
 	providerCfg, ok := c.cfg.Providers.Get(model.ModelCfg.Provider)
 	if !ok {
 		return nil, errors.New("model provider not configured")
 	}
 
+	call.Attachments = prepareAttachments(model, providerCfg.Type, call.Attachments)
+
 	mergedOptions, temp, topP, topK, freqPenalty, presPenalty := mergeCallOptions(model, providerCfg)
 
 	if providerCfg.OAuthToken != nil && providerCfg.OAuthToken.IsExpired() {
@@ -157,19 +240,23 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		}
 	}
 
+	summaryOptions, err := c.summaryProviderOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = tools.WithFileCache(tools.WithStaging(tools.WithMode(ctx, c.Mode), c.staging), c.files)
 	run := func() (*fantasy.AgentResult, error) {
-		return c.currentAgent.Run(ctx, SessionAgentCall{
-			SessionID:        sessionID,
-			Prompt:           prompt,
-			Attachments:      attachments,
-			MaxOutputTokens:  maxTokens,
-			ProviderOptions:  mergedOptions,
-			Temperature:      temp,
-			TopP:             topP,
-			TopK:             topK,
-			FrequencyPenalty: freqPenalty,
-			PresencePenalty:  presPenalty,
-		})
+		call.MaxOutputTokens = maxTokens
+		call.ProviderOptions = mergedOptions
+		call.SummaryProviderOptions = summaryOptions
+		call.Temperature = temp
+		call.TopP = topP
+		call.TopK = topK
+		call.FrequencyPenalty = freqPenalty
+		call.PresencePenalty = presPenalty
+		call.QueueMode = c.cfg.Options.QueuedPrompts
+		return c.currentAgent.Run(ctx, call)
 	}
 	result, originalErr := run()
 
@@ -351,15 +438,16 @@ func mergeCallOptions(model Model, cfg config.ProviderConfig) (fantasy.ProviderO
 }
 
 func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, agent config.Agent, isSubAgent bool) (SessionAgent, error) {
-	large, small, err := c.buildAgentModels(ctx, isSubAgent)
+	models, err := c.buildAgentModels(ctx, isSubAgent)
 	if err != nil {
 		return nil, err
 	}
+	large := models.Large
 
 	largeProviderCfg, _ := c.cfg.Providers.Get(large.ModelCfg.Provider)
 	result := NewSessionAgent(SessionAgentOptions{
 		large,
-		small,
+		models.Small,
 		largeProviderCfg.SystemPromptPrefix,
 		"",
 		isSubAgent,
@@ -368,6 +456,10 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 		c.sessions,
 		c.messages,
 		nil,
+		models.Title,
+		models.Summary,
+		c.filetracker,
+		c.recall,
 	})
 
 	c.readyWg.Go(func() error {
@@ -376,6 +468,7 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 			return err
 		}
 		result.SetSystemPrompt(systemPrompt)
+		result.SetRepoMap(c.repoMap)
 		return nil
 	})
 
@@ -391,8 +484,83 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 	return result, nil
 }
 
+// sandbox returns the sandbox configured for shell commands, or nil when
+// commands are not sandboxed.
+func (c *coordinator) sandbox() *shell.Sandbox {
+	opts := c.cfg.Options.Sandbox
+	if opts == nil || opts.Backend == "" || opts.Backend == string(shell.SandboxNone) {
+		return nil
+	}
+	writable := make([]string, len(opts.WritablePaths))
+	for i, path := range opts.WritablePaths {
+		writable[i] = home.Long(path)
+	}
+	return &shell.Sandbox{
+		Backend:       shell.SandboxBackend(opts.Backend),
+		Root:          c.cfg.WorkingDir(),
+		WritablePaths: writable,
+		Network:       opts.Network,
+		Image:         opts.Image,
+	}
+}
+
+// commandClassifier returns the classifier configured for shell commands,
+// or nil when commands are not classified.
+func (c *coordinator) commandClassifier() (shell.Classifier, error) {
+	opts := c.cfg.Options.CommandSafety
+	if opts == nil {
+		return nil, nil
+	}
+	rules := make([]shell.ClassifierRule, 0, len(opts.Rules))
+	for _, rule := range opts.Rules {
+		severity, err := shell.ParseSeverity(cmp.Or(rule.Severity, shell.SeverityMedium.String()))
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Pattern, err)
+		}
+		rules = append(rules, shell.ClassifierRule{
+			Pattern:  rule.Pattern,
+			Category: shell.RiskCategory(cmp.Or(rule.Category, string(shell.RiskDestructive))),
+			Severity: severity,
+			Reason:   rule.Reason,
+		})
+	}
+	if !opts.DisableDefaultRules {
+		rules = append(rules, shell.DefaultClassifierRules...)
+	}
+	ruleClassifier, err := shell.NewRuleClassifier(rules)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Classifier == "" {
+		return ruleClassifier, nil
+	}
+	external, err := shell.NewExternalClassifier(opts.Classifier)
+	if err != nil {
+		return nil, err
+	}
+	return shell.Classifiers{ruleClassifier, external}, nil
+}
+
+// searcher returns the searcher of the configured web search provider,
+// DuckDuckGo when there is none.
+func (c *coordinator) searcher(client *http.Client) (tools.Searcher, error) {
+	var opts config.ToolWebSearch
+	if c.cfg.Tools.WebSearch != nil {
+		opts = *c.cfg.Tools.WebSearch
+	}
+	apiKey, err := c.cfg.Resolve(opts.APIKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve api_key: %w", err)
+	}
+	return tools.NewSearcher(opts.Provider, apiKey, opts.URL, client)
+}
+
 func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fantasy.AgentTool, error) {
 	var allTools []fantasy.AgentTool
+	classifier, err := c.commandClassifier()
+	if err != nil {
+		return nil, fmt.Errorf("invalid command_safety: %w", err)
+	}
 	if slices.Contains(agent.AllowedTools, AgentToolName) {
 		agentTool, err := c.agentTool(ctx)
 		if err != nil {
@@ -418,22 +586,59 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 	}
 
 	allTools = append(allTools,
-		tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName),
+		tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName, c.cfg.Options.PersistentShell, c.sandbox(), classifier),
 		tools.NewJobOutputTool(),
 		tools.NewJobKillTool(),
 		tools.NewDownloadTool(c.permissions, c.cfg.WorkingDir(), nil),
-		tools.NewEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
-		tools.NewMultiEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
-		tools.NewFetchTool(c.permissions, c.cfg.WorkingDir(), nil),
+		tools.NewEmitArtifactTool(c.artifacts, c.permissions, c.cfg.WorkingDir()),
+		tools.NewEditTool(c.lspManager, c.formatters, c.checks, c.owners, c.headers, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+		tools.NewEditSymbolTool(c.lspManager, c.formatters, c.checks, c.owners, c.headers, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+		tools.NewMultiEditTool(c.lspManager, c.formatters, c.checks, c.owners, c.headers, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+		tools.NewReplaceAllTool(c.lspManager, c.formatters, c.checks, c.owners, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+		tools.NewRunTestsTool(c.permissions, c.cfg.WorkingDir()),
+		tools.NewProcessTool(c.permissions, c.cfg.WorkingDir(), c.sandbox(), classifier),
+		tools.NewFetchTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Tools.Fetch, nil),
 		tools.NewGlobTool(c.cfg.WorkingDir()),
 		tools.NewGrepTool(c.cfg.WorkingDir(), c.cfg.Tools.Grep),
+		tools.NewCodeSearchTool(c.cfg.WorkingDir(), c.cfg.Tools.Grep),
 		tools.NewLsTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Tools.Ls),
+		tools.NewRecallTool(c.sessions, c.recall),
 		tools.NewSourcegraphTool(nil),
 		tools.NewTodosTool(c.sessions),
+		tools.NewVerifyCleanTool(c.cfg.WorkingDir()),
 		tools.NewViewTool(c.lspManager, c.permissions, c.filetracker, c.cfg.WorkingDir(), c.cfg.Options.SkillsPaths...),
-		tools.NewWriteTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+		tools.NewWriteTool(c.lspManager, c.formatters, c.checks, c.owners, c.headers, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
 	)
 
+	if c.cfg.Options.PersistentShell {
+		allTools = append(allTools, tools.NewResetShellTool())
+	}
+
+	if webSearch := c.cfg.Tools.WebSearch; webSearch != nil && webSearch.Enabled {
+		searcher, err := c.searcher(nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid web_search: %w", err)
+		}
+		allTools = append(allTools, tools.NewWebSearchToolWithPermissions(c.permissions, c.cfg.WorkingDir(), searcher))
+	}
+
+	if github := c.cfg.Tools.GitHub; github != nil && github.Enabled {
+		token, err := c.cfg.Resolve(github.Token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid github token: %w", err)
+		}
+		gh := tools.NewGitHub(c.cfg.WorkingDir(), github.APIURL, token, github.Repo, nil)
+		allTools = append(allTools,
+			tools.NewGitHubIssueTool(gh),
+			tools.NewGitHubCreatePRTool(gh, c.permissions, c.cfg.WorkingDir()),
+			tools.NewGitHubReviewTool(gh, c.permissions, c.cfg.WorkingDir()),
+		)
+	}
+
+	if reader := scrollback.New(c.cfg); reader != nil {
+		allTools = append(allTools, tools.NewTerminalScrollbackTool(reader, c.redactor))
+	}
+
 	// Add LSP tools if user has configured LSPs or auto_lsp is enabled (nil or true).
 	if len(c.cfg.LSP) > 0 || c.cfg.Options.AutoLSP == nil || *c.cfg.Options.AutoLSP {
 		allTools = append(allTools, tools.NewDiagnosticsTool(c.lspManager), tools.NewReferencesTool(c.lspManager), tools.NewLSPRestartTool(c.lspManager))
@@ -479,91 +684,95 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 	slices.SortFunc(filteredTools, func(a, b fantasy.AgentTool) int {
 		return strings.Compare(a.Info().Name, b.Info().Name)
 	})
-	return filteredTools, nil
+	filteredTools = postprocess.New(c.cfg.Options.ToolOutputProcessors, c.cfg.WorkingDir(), c.summarizeToolOutput).WrapTools(filteredTools)
+	filteredTools = c.workspace.WrapTools(filteredTools, c.cfg.WorkingDir())
+	filteredTools = workspace.WrapDenied(filteredTools, c.cfg.WorkingDir())
+	return telemetry.WrapTools(c.audit.WrapTools(c.toolStats.WrapTools(filteredTools))), nil
 }
 
-// TODO: when we support multiple agents we need to change this so that we pass in the agent specific model config
-func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Model, Model, error) {
-	largeModelCfg, ok := c.cfg.Models[config.SelectedModelTypeLarge]
-	if !ok {
-		return Model{}, Model{}, errors.New("large model not selected")
-	}
-	smallModelCfg, ok := c.cfg.Models[config.SelectedModelTypeSmall]
-	if !ok {
-		return Model{}, Model{}, errors.New("small model not selected")
-	}
+// summarizeToolOutput has the small model of the current agent answer for
+// the tool output processors, adding its cost to the session of the call.
+func (c *coordinator) summarizeToolOutput(ctx context.Context, systemPrompt, prompt string) (string, error) {
+	return c.currentAgent.AskSmallModel(ctx, tools.GetSessionFromContext(ctx), systemPrompt, prompt)
+}
 
-	largeProviderCfg, ok := c.cfg.Providers.Get(largeModelCfg.Provider)
-	if !ok {
-		return Model{}, Model{}, errors.New("large model provider not configured")
+// buildAgentModels builds the models of an agent for each of its roles.
+// Sub-agents run on the task model rather than the large one.
+func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Models, error) {
+	mainType := config.SelectedModelTypeLarge
+	if isSubAgent {
+		mainType = c.cfg.ModelTypeFor(config.SelectedModelTypeTask)
+	}
+	var models Models
+	built := make(map[config.SelectedModelType]Model)
+	for _, role := range []struct {
+		model     *Model
+		modelType config.SelectedModelType
+		subAgent  bool
+	}{
+		{&models.Large, mainType, isSubAgent},
+		{&models.Small, config.SelectedModelTypeSmall, true},
+		{&models.Title, c.cfg.ModelTypeFor(config.SelectedModelTypeTitle), true},
+		{&models.Summary, c.cfg.ModelTypeFor(config.SelectedModelTypeSummary), isSubAgent},
+	} {
+		if m, ok := built[role.modelType]; ok {
+			*role.model = m
+			continue
+		}
+		m, err := c.buildModel(ctx, role.modelType, role.subAgent)
+		if err != nil {
+			return Models{}, err
+		}
+		built[role.modelType] = m
+		*role.model = m
 	}
+	return models, nil
+}
 
-	largeProvider, err := c.buildProvider(largeProviderCfg, largeModelCfg, isSubAgent)
-	if err != nil {
-		return Model{}, Model{}, err
+// buildModel builds the model selected for modelType.
+func (c *coordinator) buildModel(ctx context.Context, modelType config.SelectedModelType, isSubAgent bool) (Model, error) {
+	modelCfg, ok := c.cfg.Models[modelType]
+	if !ok {
+		return Model{}, fmt.Errorf("%s model not selected", modelType)
 	}
-
-	smallProviderCfg, ok := c.cfg.Providers.Get(smallModelCfg.Provider)
+	providerCfg, ok := c.cfg.Providers.Get(modelCfg.Provider)
 	if !ok {
-		return Model{}, Model{}, errors.New("large model provider not configured")
+		return Model{}, fmt.Errorf("%s model provider not configured", modelType)
 	}
-
-	smallProvider, err := c.buildProvider(smallProviderCfg, largeModelCfg, true)
+	provider, err := c.buildProvider(ctx, providerCfg, modelCfg, isSubAgent)
 	if err != nil {
-		return Model{}, Model{}, err
+		return Model{}, err
 	}
 
-	var largeCatwalkModel *catwalk.Model
-	var smallCatwalkModel *catwalk.Model
-
-	for _, m := range largeProviderCfg.Models {
-		if m.ID == largeModelCfg.Model {
-			largeCatwalkModel = &m
-		}
-	}
-	for _, m := range smallProviderCfg.Models {
-		if m.ID == smallModelCfg.Model {
-			smallCatwalkModel = &m
+	var catwalkModel *catwalk.Model
+	for _, m := range providerCfg.Models {
+		if m.ID == modelCfg.Model {
+			catwalkModel = &m
 		}
 	}
-
-	if largeCatwalkModel == nil {
-		return Model{}, Model{}, errors.New("large model not found in provider config")
-	}
-
-	if smallCatwalkModel == nil {
-		return Model{}, Model{}, errors.New("small model not found in provider config")
-	}
-
-	largeModelID := largeModelCfg.Model
-	smallModelID := smallModelCfg.Model
-
-	if largeModelCfg.Provider == openrouter.Name && isExactoSupported(largeModelID) {
-		largeModelID += ":exacto"
+	if catwalkModel == nil {
+		return Model{}, fmt.Errorf("%s model not found in provider config", modelType)
 	}
 
-	if smallModelCfg.Provider == openrouter.Name && isExactoSupported(smallModelID) {
-		smallModelID += ":exacto"
+	modelID := modelCfg.Model
+	if modelCfg.Provider == openrouter.Name && isExactoSupported(modelID) {
+		modelID += ":exacto"
 	}
-
-	largeModel, err := largeProvider.LanguageModel(ctx, largeModelID)
+	model, err := provider.LanguageModel(ctx, modelID)
 	if err != nil {
-		return Model{}, Model{}, err
-	}
-	smallModel, err := smallProvider.LanguageModel(ctx, smallModelID)
-	if err != nil {
-		return Model{}, Model{}, err
+		return Model{}, err
 	}
 
+	// Anthropic carries on from a trailing assistant message, which lets
+	// interrupted answers be resumed rather than generated again.
+	model = retry.WrapModel(model, retry.NewOptions(c.cfg.Options.Retry, providerCfg.Type == anthropic.Name))
+
 	return Model{
-			Model:      largeModel,
-			CatwalkCfg: *largeCatwalkModel,
-			ModelCfg:   largeModelCfg,
-		}, Model{
-			Model:      smallModel,
-			CatwalkCfg: *smallCatwalkModel,
-			ModelCfg:   smallModelCfg,
-		}, nil
+		Model:        telemetry.WrapModel(redact.WrapModel(model, c.redactor)),
+		CatwalkCfg:   *catwalkModel,
+		ModelCfg:     modelCfg,
+		WithoutTools: !providerCfg.SupportsTools(modelCfg.Model),
+	}, nil
 }
 
 func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
@@ -668,43 +877,39 @@ func (c *coordinator) buildOpenaiCompatProvider(baseURL, apiKey string, headers
 	return openaicompat.New(opts...)
 }
 
-func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[string]string, options map[string]string) (fantasy.Provider, error) {
-	opts := []azure.Option{
-		azure.WithBaseURL(baseURL),
-		azure.WithAPIKey(apiKey),
-		azure.WithUseResponsesAPI(),
+func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[string]string, options map[string]string, azureCfg *config.AzureOptions) (fantasy.Provider, error) {
+	opts := azure.Options{
+		Endpoint:        baseURL,
+		APIKey:          apiKey,
+		APIVersion:      options["apiVersion"],
+		Headers:         headers,
+		UseResponsesAPI: true,
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
-		opts = append(opts, azure.WithHTTPClient(httpClient))
+	if azureCfg != nil {
+		opts.APIVersion = cmp.Or(azureCfg.APIVersion, opts.APIVersion)
+		opts.Deployments = azureCfg.Deployments
 	}
-	if options == nil {
-		options = make(map[string]string)
-	}
-	if apiVersion, ok := options["apiVersion"]; ok {
-		opts = append(opts, azure.WithAPIVersion(apiVersion))
-	}
-	if len(headers) > 0 {
-		opts = append(opts, azure.WithHeaders(headers))
+	if c.cfg.Options.Debug {
+		opts.HTTPClient = log.NewHTTPClient()
 	}
-
-	return azure.New(opts...)
+	return azure.New(opts)
 }
 
-func (c *coordinator) buildBedrockProvider(headers map[string]string) (fantasy.Provider, error) {
-	var opts []bedrock.Option
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
-		opts = append(opts, bedrock.WithHTTPClient(httpClient))
+func (c *coordinator) buildBedrockProvider(ctx context.Context, apiKey string, headers map[string]string, options map[string]string, bedrockCfg *config.BedrockOptions) (fantasy.Provider, error) {
+	opts := bedrock.Options{
+		Region:  options["region"],
+		APIKey:  apiKey,
+		Headers: headers,
 	}
-	if len(headers) > 0 {
-		opts = append(opts, bedrock.WithHeaders(headers))
+	if bedrockCfg != nil {
+		opts.Region = cmp.Or(bedrockCfg.Region, opts.Region)
+		opts.Profile = bedrockCfg.Profile
+		opts.Models = bedrockCfg.ModelARNs
 	}
-	bearerToken := os.Getenv("AWS_BEARER_TOKEN_BEDROCK")
-	if bearerToken != "" {
-		opts = append(opts, bedrock.WithAPIKey(bearerToken))
+	if c.cfg.Options.Debug {
+		opts.HTTPClient = log.NewHTTPClient()
 	}
-	return bedrock.New(opts...)
+	return bedrock.New(ctx, opts)
 }
 
 func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
@@ -722,22 +927,27 @@ func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[st
 	return google.New(opts...)
 }
 
-func (c *coordinator) buildGoogleVertexProvider(headers map[string]string, options map[string]string) (fantasy.Provider, error) {
-	opts := []google.Option{}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
-		opts = append(opts, google.WithHTTPClient(httpClient))
+func (c *coordinator) buildGoogleVertexProvider(ctx context.Context, headers map[string]string, options map[string]string, vertexCfg *config.VertexOptions) (fantasy.Provider, error) {
+	opts := vertex.Options{
+		Project:  options["project"],
+		Location: options["location"],
+		Headers:  headers,
+	}
+	if vertexCfg != nil {
+		opts.Project = cmp.Or(vertexCfg.Project, opts.Project)
+		opts.Location = cmp.Or(vertexCfg.Location, opts.Location)
+		if vertexCfg.Credentials != "" {
+			credentials, err := c.cfg.Resolve(vertexCfg.Credentials)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve the Vertex AI credentials: %w", err)
+			}
+			opts.Credentials = home.Long(credentials)
+		}
 	}
-	if len(headers) > 0 {
-		opts = append(opts, google.WithHeaders(headers))
+	if c.cfg.Options.Debug {
+		opts.HTTPClient = log.NewHTTPClient()
 	}
-
-	project := options["project"]
-	location := options["location"]
-
-	opts = append(opts, google.WithVertex(project, location))
-
-	return google.New(opts...)
+	return vertex.New(ctx, opts)
 }
 
 func (c *coordinator) buildHyperProvider(baseURL, apiKey string) (fantasy.Provider, error) {
@@ -771,7 +981,7 @@ func (c *coordinator) isAnthropicThinking(model config.SelectedModel) bool {
 	return false
 }
 
-func (c *coordinator) buildProvider(providerCfg config.ProviderConfig, model config.SelectedModel, isSubAgent bool) (fantasy.Provider, error) {
+func (c *coordinator) buildProvider(ctx context.Context, providerCfg config.ProviderConfig, model config.SelectedModel, isSubAgent bool) (fantasy.Provider, error) {
 	headers := maps.Clone(providerCfg.ExtraHeaders)
 	if headers == nil {
 		headers = make(map[string]string)
@@ -799,13 +1009,13 @@ func (c *coordinator) buildProvider(providerCfg config.ProviderConfig, model con
 	case vercel.Name:
 		return c.buildVercelProvider(baseURL, apiKey, headers)
 	case azure.Name:
-		return c.buildAzureProvider(baseURL, apiKey, headers, providerCfg.ExtraParams)
+		return c.buildAzureProvider(baseURL, apiKey, headers, providerCfg.ExtraParams, providerCfg.Azure)
 	case bedrock.Name:
-		return c.buildBedrockProvider(headers)
+		return c.buildBedrockProvider(ctx, apiKey, headers, providerCfg.ExtraParams, providerCfg.Bedrock)
 	case google.Name:
 		return c.buildGoogleProvider(baseURL, apiKey, headers)
-	case "google-vertex":
-		return c.buildGoogleVertexProvider(headers, providerCfg.ExtraParams)
+	case vertex.Name:
+		return c.buildGoogleVertexProvider(ctx, headers, providerCfg.ExtraParams, providerCfg.Vertex)
 	case openaicompat.Name:
 		if providerCfg.ID == string(catwalk.InferenceProviderZAI) {
 			if providerCfg.ExtraBody == nil {
@@ -856,13 +1066,21 @@ func (c *coordinator) Model() Model {
 	return c.currentAgent.Model()
 }
 
+func (c *coordinator) Mode() tools.Mode {
+	return c.mode.Get()
+}
+
+func (c *coordinator) SetMode(mode tools.Mode) {
+	c.mode.Set(mode)
+}
+
 func (c *coordinator) UpdateModels(ctx context.Context) error {
 	// build the models again so we make sure we get the latest config
-	large, small, err := c.buildAgentModels(ctx, false)
+	models, err := c.buildAgentModels(ctx, false)
 	if err != nil {
 		return err
 	}
-	c.currentAgent.SetModels(large, small)
+	c.currentAgent.SetModels(models)
 
 	agentCfg, ok := c.cfg.Agents[config.AgentCoder]
 	if !ok {
@@ -885,12 +1103,27 @@ func (c *coordinator) QueuedPromptsList(sessionID string) []string {
 	return c.currentAgent.QueuedPromptsList(sessionID)
 }
 
+func (c *coordinator) HasInterrupt(sessionID string) bool {
+	return c.currentAgent.HasInterrupt(sessionID)
+}
+
 func (c *coordinator) Summarize(ctx context.Context, sessionID string) error {
-	providerCfg, ok := c.cfg.Providers.Get(c.currentAgent.Model().ModelCfg.Provider)
+	opts, err := c.summaryProviderOptions()
+	if err != nil {
+		return err
+	}
+	return c.currentAgent.Summarize(ctx, sessionID, opts)
+}
+
+// summaryProviderOptions returns the provider options for the model used to
+// compact sessions.
+func (c *coordinator) summaryProviderOptions() (fantasy.ProviderOptions, error) {
+	model := c.currentAgent.SummaryModel()
+	providerCfg, ok := c.cfg.Providers.Get(model.ModelCfg.Provider)
 	if !ok {
-		return errors.New("model provider not configured")
+		return nil, errors.New("summary model provider not configured")
 	}
-	return c.currentAgent.Summarize(ctx, sessionID, getProviderOptions(c.currentAgent.Model(), providerCfg))
+	return getProviderOptions(model, providerCfg), nil
 }
 
 func (c *coordinator) isUnauthorized(err error) bool {
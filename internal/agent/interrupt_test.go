@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterrupt(t *testing.T) {
+	t.Parallel()
+
+	a := NewSessionAgent(SessionAgentOptions{}).(*sessionAgent)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	a.activeRequests.Set("s1", cancel)
+
+	result, err := a.Run(t.Context(), SessionAgentCall{SessionID: "s1", Prompt: "  use approach B\n", Interrupt: true})
+	require.NoError(t, err)
+	require.Nil(t, result)
+	require.ErrorIs(t, ctx.Err(), context.Canceled, "the running request is stopped")
+	require.True(t, a.HasInterrupt("s1"))
+	require.Zero(t, a.QueuedPrompts("s1"))
+
+	next, ok := a.interrupts.Get("s1")
+	require.True(t, ok)
+	require.False(t, next.Interrupt)
+	require.Contains(t, next.Prompt, "I interrupted you to add this:\n\nuse approach B\n\n")
+
+	// A second note waits for the first to be picked up.
+	_, err = a.Run(t.Context(), SessionAgentCall{SessionID: "s1", Prompt: "and keep the tests", Interrupt: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"and keep the tests"}, a.QueuedPromptsList("s1"))
+
+	a.Cancel("s1")
+	require.False(t, a.HasInterrupt("s1"))
+}
+
+func TestResumeInterruptedWithoutInterrupt(t *testing.T) {
+	t.Parallel()
+
+	a := NewSessionAgent(SessionAgentOptions{}).(*sessionAgent)
+	result, err := a.resumeInterrupted(t.Context(), "s1", func() {}, nil, context.Canceled)
+	require.Nil(t, result)
+	require.ErrorIs(t, err, context.Canceled)
+}
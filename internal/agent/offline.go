@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/connectivity"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// connectionLostPrompt asks the model to carry on with a request that was
+// cut short by the connection going away.
+const connectionLostPrompt = `The connection was lost while you were working on this request:
+
+%s
+
+It is back now. Carry on from where you stopped; a tool call that was interrupted did not finish and may need to run again.`
+
+// pristine reports whether nothing of a request the provider failed to
+// answer was kept: no step finished and the failed message is empty.
+func pristine(failed *message.Message, finishedSteps int) bool {
+	return finishedSteps == 0 &&
+		len(failed.ToolCalls()) == 0 &&
+		failed.Content().Text == "" &&
+		failed.ReasoningContent().Thinking == ""
+}
+
+// waitUntilOnline holds a call whose request failed with err because the
+// network went away, until connectivity returns or genCtx is done, and
+// returns the call to send then. An unchanged request is sent again as it
+// was, after its messages are deleted, so the session reads as if it never
+// failed; otherwise the agent is asked to carry on from failed.
+func (a *sessionAgent) waitUntilOnline(ctx, genCtx context.Context, call SessionAgentCall, userMessage message.Message, failed *message.Message, unchanged bool, err error) (SessionAgentCall, error) {
+	slog.Warn("Connection lost, waiting for it to return", "session_id", call.SessionID, "error", err)
+	if waitErr := a.network.Wait(genCtx, connectivity.ProbeAddr(err)); waitErr != nil {
+		failed.AddFinish(message.FinishReasonCanceled, "User canceled request", "")
+		if updateErr := a.messages.Update(ctx, *failed); updateErr != nil {
+			return call, updateErr
+		}
+		return call, waitErr
+	}
+	slog.Info("Connection is back, sending the request again", "session_id", call.SessionID)
+
+	if !unchanged {
+		call.Prompt = fmt.Sprintf(connectionLostPrompt, call.Prompt)
+		call.Attachments = nil
+		return call, nil
+	}
+	for _, id := range []string{failed.ID, userMessage.ID} {
+		if deleteErr := a.messages.Delete(ctx, id); deleteErr != nil {
+			return call, deleteErr
+		}
+	}
+	return call, nil
+}
@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWaitsForConnectivity(t *testing.T) {
+	t.Parallel()
+
+	// The provider is back as soon as the probe reaches it.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	offline := &url.Error{Op: "Post", URL: "http://" + ln.Addr().String() + "/v1/messages", Err: syscall.ENETUNREACH}
+
+	env := testEnv(t)
+	large := &scriptedModel{reply: "Done.", errs: []error{offline}}
+	a := testSessionAgent(env, large, &scriptedModel{reply: "Title"}, "You are helpful.")
+	sess, err := env.sessions.Create(t.Context(), "offline")
+	require.NoError(t, err)
+
+	result, err := a.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "fix the tests", MaxOutputTokens: 100})
+	require.NoError(t, err)
+	require.Equal(t, "Done.", result.Response.Content.Text())
+	require.Equal(t, 2, large.calls)
+
+	msgs, err := env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2, "the failed request left nothing behind")
+	require.Equal(t, message.User, msgs[0].Role)
+	require.Equal(t, "fix the tests", msgs[0].Content().Text)
+	require.Equal(t, "Done.", msgs[1].Content().Text)
+}
+
+func TestPristine(t *testing.T) {
+	t.Parallel()
+
+	empty := &message.Message{Role: message.Assistant}
+	require.True(t, pristine(empty, 0))
+	require.False(t, pristine(empty, 1), "an earlier step finished")
+
+	started := &message.Message{Role: message.Assistant}
+	started.AppendContent("Looking")
+	require.False(t, pristine(started, 0))
+}
@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// contextOverflowMessages match the errors providers send when a request
+// does not fit in the model's context window. Few of them have a code of
+// their own, so they are told apart by their message.
+var contextOverflowMessages = []string{
+	"context_length_exceeded",              // OpenAI and compatible providers.
+	"maximum context length",               // OpenAI, OpenRouter, Mistral.
+	"reduce the length of the",             // OpenAI, Groq.
+	"prompt is too long",                   // Anthropic.
+	"input is too long",                    // Bedrock.
+	"exceeds the maximum number of tokens", // Gemini.
+	"maximum prompt length",                // xAI.
+	"exceeds the context window",
+	"context window exceeded",
+	"exceeds the available context size", // llama.cpp.
+	"too many tokens",
+}
+
+// isContextOverflow reports whether err is a provider refusing a request
+// because it does not fit in the model's context window.
+func isContextOverflow(err error) bool {
+	if err == nil {
+		return false
+	}
+	var providerErr *fantasy.ProviderError
+	if errors.As(err, &providerErr) {
+		if providerErr.StatusCode == http.StatusRequestEntityTooLarge {
+			return true
+		}
+		if providerErr.StatusCode != 0 && providerErr.StatusCode != http.StatusBadRequest {
+			return false
+		}
+		if matchesContextOverflow(providerErr.Message) || matchesContextOverflow(string(providerErr.ResponseBody)) {
+			return true
+		}
+	}
+	return matchesContextOverflow(err.Error())
+}
+
+func matchesContextOverflow(msg string) bool {
+	msg = strings.ToLower(msg)
+	for _, m := range contextOverflowMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+const contextOverflowTitle = "Context window exceeded"
+
+// retryAfterOverflow compacts the session of a call the provider rejected
+// for not fitting in the context window, and sends the call again. It only
+// does so once per call: a call that still does not fit fails with err.
+// Failed is the assistant message of the rejected request, which is told
+// what was summarized.
+func (a *sessionAgent) retryAfterOverflow(ctx context.Context, call SessionAgentCall, failed *message.Message, cancel context.CancelFunc, err error) (*fantasy.AgentResult, error) {
+	a.activeRequests.Del(call.SessionID)
+	cancel()
+
+	slog.Warn("Request exceeded the context window, compacting the session", "session_id", call.SessionID, "error", err)
+	compacted, summarizeErr := a.summarize(ctx, call.SessionID, call.SummaryProviderOptions, compactOnOverflow)
+	if summarizeErr != nil || compacted.SummaryMessageID == "" {
+		if summarizeErr != nil {
+			slog.Error("Failed to compact the session", "session_id", call.SessionID, "error", summarizeErr)
+		}
+		failed.AddFinish(message.FinishReasonError, contextOverflowTitle, overflowDetails(err, "Compacting the session failed too; start a new session or remove large attachments."))
+		if updateErr := a.messages.Update(ctx, *failed); updateErr != nil {
+			return nil, updateErr
+		}
+		return nil, err
+	}
+
+	failed.AddFinish(message.FinishReasonError, contextOverflowTitle, fmt.Sprintf(
+		"The request did not fit in the model's context window, so the %d earlier messages of the session were summarized (%d tokens down to %d) and it was sent again.",
+		compacted.Messages, compacted.TokensBefore, compacted.TokensAfter,
+	))
+	if updateErr := a.messages.Update(ctx, *failed); updateErr != nil {
+		return nil, updateErr
+	}
+	call.overflowRetried = true
+	return a.Run(ctx, call)
+}
+
+// overflowDetails explains an overflow that was not recovered from,
+// followed by the provider's own message.
+func overflowDetails(err error, advice string) string {
+	msg := err.Error()
+	var providerErr *fantasy.ProviderError
+	if errors.As(err, &providerErr) && providerErr.Message != "" {
+		msg = providerErr.Message
+	}
+	return fmt.Sprintf("The request did not fit in the model's context window. %s\n\n%s", advice, msg)
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedModel fails the requests it has an error for, in order, and
+// answers the others with reply.
+type scriptedModel struct {
+	fantasy.LanguageModel
+	reply string
+
+	mu    sync.Mutex
+	errs  []error
+	calls int
+}
+
+func (m *scriptedModel) Provider() string { return "fake" }
+func (m *scriptedModel) Model() string    { return "fake" }
+
+func (m *scriptedModel) Stream(context.Context, fantasy.Call) (fantasy.StreamResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if len(m.errs) > 0 {
+		err := m.errs[0]
+		m.errs = m.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return func(yield func(fantasy.StreamPart) bool) {
+		for _, part := range []fantasy.StreamPart{
+			{Type: fantasy.StreamPartTypeTextStart, ID: "0"},
+			{Type: fantasy.StreamPartTypeTextDelta, ID: "0", Delta: m.reply},
+			{Type: fantasy.StreamPartTypeTextEnd, ID: "0"},
+			{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonStop},
+		} {
+			if !yield(part) {
+				return
+			}
+		}
+	}, nil
+}
+
+var errPromptTooLong = &fantasy.ProviderError{
+	Title:      "invalid_request_error",
+	Message:    "prompt is too long: 212345 tokens > 200000 maximum",
+	StatusCode: http.StatusBadRequest,
+}
+
+func TestIsContextOverflow(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, isContextOverflow(errPromptTooLong))
+	require.True(t, isContextOverflow(&fantasy.ProviderError{StatusCode: http.StatusBadRequest, ResponseBody: []byte(`{"error":{"code":"context_length_exceeded"}}`)}))
+	require.True(t, isContextOverflow(&fantasy.ProviderError{StatusCode: http.StatusRequestEntityTooLarge}))
+	require.True(t, isContextOverflow(errors.New("This model's maximum context length is 128000 tokens")))
+	require.False(t, isContextOverflow(&fantasy.ProviderError{StatusCode: http.StatusTooManyRequests, Message: "too many tokens per minute"}))
+	require.False(t, isContextOverflow(&fantasy.ProviderError{StatusCode: http.StatusBadRequest, Message: "invalid tool schema"}))
+	require.False(t, isContextOverflow(nil))
+}
+
+func TestRunCompactsAndRetriesOnOverflow(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	large := &scriptedModel{reply: "Done.", errs: []error{errPromptTooLong}}
+	a := testSessionAgent(env, large, &scriptedModel{reply: "Title"}, "You are helpful.")
+	sess, err := env.sessions.Create(t.Context(), "overflow")
+	require.NoError(t, err)
+
+	events := SubscribeCompactions(t.Context())
+	result, err := a.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "fix the tests", MaxOutputTokens: 100})
+	require.NoError(t, err)
+	require.Equal(t, "Done.", result.Response.Content.Text())
+	require.Equal(t, 3, large.calls, "the rejected request, the summary and the retry")
+
+	var compacted CompactedEvent
+	for event := range events {
+		if event.Payload.SessionID == sess.ID {
+			compacted = event.Payload
+			break
+		}
+	}
+	require.True(t, compacted.Automatic)
+	require.True(t, compacted.Overflow)
+
+	msgs, err := env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	var failed *message.Finish
+	for _, msg := range msgs {
+		if finish := msg.FinishPart(); finish != nil && finish.Reason == message.FinishReasonError {
+			failed = finish
+		}
+	}
+	require.NotNil(t, failed)
+	require.Equal(t, contextOverflowTitle, failed.Message)
+	require.Contains(t, failed.Details, "earlier messages of the session were summarized")
+}
+
+func TestRunRetriesOverflowOnce(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	large := &scriptedModel{reply: "Summary.", errs: []error{errPromptTooLong, nil, errPromptTooLong}}
+	a := testSessionAgent(env, large, &scriptedModel{reply: "Title"}, "You are helpful.")
+	sess, err := env.sessions.Create(t.Context(), "overflow")
+	require.NoError(t, err)
+
+	_, err = a.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "fix the tests", MaxOutputTokens: 100})
+	require.ErrorIs(t, err, errPromptTooLong)
+	require.Equal(t, 3, large.calls)
+	require.False(t, a.IsSessionBusy(sess.ID))
+}
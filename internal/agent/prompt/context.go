@@ -0,0 +1,104 @@
+package prompt
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+// ContextScope describes where a context file was loaded from.
+type ContextScope string
+
+// Context scopes, ordered from the least to the most specific.
+const (
+	ContextScopeGlobal    ContextScope = "global"
+	ContextScopeProject   ContextScope = "project"
+	ContextScopeDirectory ContextScope = "directory"
+)
+
+// LoadContextFiles discovers the context files for the given configuration.
+//
+// Files are returned in order of precedence, from the least to the most
+// specific: global files first, then the files at the repository root, then
+// the files in each directory between the root and the working directory.
+// When instructions conflict, later files take precedence.
+func LoadContextFiles(cfg config.Config) []ContextFile {
+	paths := make([]string, 0, len(cfg.Options.ContextPaths))
+	for _, pth := range cfg.Options.ContextPaths {
+		paths = append(paths, expandPath(pth, cfg))
+	}
+	return loadContextFiles(cfg.WorkingDir(), config.GlobalContextPaths(), paths)
+}
+
+func loadContextFiles(workingDir string, globalPaths, paths []string) []ContextFile {
+	var (
+		result []ContextFile
+		seen   = map[string]struct{}{}
+	)
+	add := func(scope ContextScope, files []ContextFile) {
+		for _, f := range files {
+			key := strings.ToLower(f.Path)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			f.Scope = scope
+			result = append(result, f)
+		}
+	}
+
+	for _, pth := range globalPaths {
+		add(ContextScopeGlobal, processContextPath(pth, ""))
+	}
+
+	// Absolute context paths don't depend on the working directory, so they
+	// are treated as global.
+	var relative []string
+	for _, pth := range paths {
+		if filepath.IsAbs(pth) {
+			add(ContextScopeGlobal, processContextPath(pth, ""))
+			continue
+		}
+		relative = append(relative, pth)
+	}
+
+	for i, dir := range contextDirs(workingDir) {
+		scope := ContextScopeDirectory
+		if i == 0 {
+			scope = ContextScopeProject
+		}
+		for _, pth := range relative {
+			add(scope, processContextPath(pth, dir))
+		}
+	}
+	return result
+}
+
+// contextDirs returns the directories from the repository root down to
+// workingDir. If workingDir is not inside a git repository, only workingDir
+// itself is returned.
+func contextDirs(workingDir string) []string {
+	workingDir, err := filepath.Abs(workingDir)
+	if err != nil {
+		return []string{workingDir}
+	}
+	gitDir, ok := fsext.LookupClosest(workingDir, ".git")
+	if !ok {
+		return []string{workingDir}
+	}
+	root := filepath.Dir(gitDir)
+	rel, err := filepath.Rel(root, workingDir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return []string{workingDir}
+	}
+
+	dirs := []string{root}
+	current := root
+	for part := range strings.SplitSeq(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		dirs = append(dirs, current)
+	}
+	return dirs
+}
@@ -0,0 +1,70 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadContextFilesHierarchy(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	global := t.TempDir()
+	sub := filepath.Join(root, "services", "api")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0o755))
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+
+	write := func(path, content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write(filepath.Join(global, "CRUSH.md"), "global")
+	write(filepath.Join(root, "CRUSH.md"), "root")
+	write(filepath.Join(sub, "CRUSH.md"), "api")
+	write(filepath.Join(sub, "AGENTS.md"), "api agents")
+
+	files := loadContextFiles(
+		sub,
+		[]string{filepath.Join(global, "CRUSH.md"), filepath.Join(global, "missing.md")},
+		[]string{"AGENTS.md", "CRUSH.md"},
+	)
+
+	var got []string
+	var scopes []ContextScope
+	for _, f := range files {
+		got = append(got, f.Content)
+		scopes = append(scopes, f.Scope)
+	}
+	require.Equal(t, []string{"global", "root", "api agents", "api"}, got)
+	require.Equal(t, []ContextScope{
+		ContextScopeGlobal,
+		ContextScopeProject,
+		ContextScopeDirectory,
+		ContextScopeDirectory,
+	}, scopes)
+}
+
+func TestContextDirs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("outside repository", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		require.Equal(t, []string{dir}, contextDirs(dir))
+	})
+
+	t.Run("nested in repository", func(t *testing.T) {
+		t.Parallel()
+		root := t.TempDir()
+		sub := filepath.Join(root, "a", "b")
+		require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0o755))
+		require.NoError(t, os.MkdirAll(sub, 0o755))
+		require.Equal(t, []string{
+			root,
+			filepath.Join(root, "a"),
+			sub,
+		}, contextDirs(sub))
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/charmbracelet/crush/internal/home"
 	"github.com/charmbracelet/crush/internal/shell"
 	"github.com/charmbracelet/crush/internal/skills"
+	"github.com/charmbracelet/crush/internal/workspace"
 )
 
 // Prompt represents a template-based prompt generator.
@@ -24,6 +25,8 @@ type Prompt struct {
 	now        func() time.Time
 	platform   string
 	workingDir string
+	roots      []workspace.Root
+	builtIn    string
 }
 
 type PromptDat struct {
@@ -37,11 +40,16 @@ type PromptDat struct {
 	GitStatus     string
 	ContextFiles  []ContextFile
 	AvailSkillXML string
+	Roots         []workspace.Root
+	// BuiltIn is the built-in prompt a template overrides, so it can extend
+	// it rather than replace it.
+	BuiltIn string
 }
 
 type ContextFile struct {
 	Path    string
 	Content string
+	Scope   ContextScope
 }
 
 type Option func(*Prompt)
@@ -64,6 +72,22 @@ func WithWorkingDir(workingDir string) Option {
 	}
 }
 
+// WithBuiltIn sets the template of the built-in prompt the prompt overrides,
+// which it can include as {{.BuiltIn}}.
+func WithBuiltIn(builtIn string) Option {
+	return func(p *Prompt) {
+		p.builtIn = builtIn
+	}
+}
+
+// WithWorkspaceRoots tells the agent which directories the workspace
+// manifest lets it use.
+func WithWorkspaceRoots(roots []workspace.Root) Option {
+	return func(p *Prompt) {
+		p.roots = roots
+	}
+}
+
 func NewPrompt(name, promptTemplate string, opts ...Option) (*Prompt, error) {
 	p := &Prompt{
 		name:     name,
@@ -77,19 +101,28 @@ func NewPrompt(name, promptTemplate string, opts ...Option) (*Prompt, error) {
 }
 
 func (p *Prompt) Build(ctx context.Context, provider, model string, cfg config.Config) (string, error) {
-	t, err := template.New(p.name).Parse(p.template)
-	if err != nil {
-		return "", fmt.Errorf("parsing template: %w", err)
-	}
-	var sb strings.Builder
 	d, err := p.promptData(ctx, provider, model, cfg)
 	if err != nil {
 		return "", err
 	}
+	if p.builtIn != "" {
+		d.BuiltIn, err = execute(p.name+"-builtin", p.builtIn, d)
+		if err != nil {
+			return "", fmt.Errorf("built-in prompt: %w", err)
+		}
+	}
+	return execute(p.name, p.template, d)
+}
+
+func execute(name, tmpl string, d PromptDat) (string, error) {
+	t, err := template.New(name).Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("parsing template: %w", err)
+	}
+	var sb strings.Builder
 	if err := t.Execute(&sb, d); err != nil {
 		return "", fmt.Errorf("executing template: %w", err)
 	}
-
 	return sb.String(), nil
 }
 
@@ -104,11 +137,11 @@ func processFile(filePath string) *ContextFile {
 	}
 }
 
-func processContextPath(p string, cfg config.Config) []ContextFile {
+func processContextPath(p, baseDir string) []ContextFile {
 	var contexts []ContextFile
 	fullPath := p
 	if !filepath.IsAbs(p) {
-		fullPath = filepath.Join(cfg.WorkingDir(), p)
+		fullPath = filepath.Join(baseDir, p)
 	}
 	info, err := os.Stat(fullPath)
 	if err != nil {
@@ -152,18 +185,6 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 	workingDir := cmp.Or(p.workingDir, cfg.WorkingDir())
 	platform := cmp.Or(p.platform, runtime.GOOS)
 
-	files := map[string][]ContextFile{}
-
-	for _, pth := range cfg.Options.ContextPaths {
-		expanded := expandPath(pth, cfg)
-		pathKey := strings.ToLower(expanded)
-		if _, ok := files[pathKey]; ok {
-			continue
-		}
-		content := processContextPath(expanded, cfg)
-		files[pathKey] = content
-	}
-
 	// Discover and load skills metadata.
 	var availSkillXML string
 	if len(cfg.Options.SkillsPaths) > 0 {
@@ -185,7 +206,9 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 		IsGitRepo:     isGit,
 		Platform:      platform,
 		Date:          p.now().Format("1/2/2006"),
+		ContextFiles:  LoadContextFiles(cfg),
 		AvailSkillXML: availSkillXML,
+		Roots:         p.roots,
 	}
 	if isGit {
 		var err error
@@ -195,9 +218,6 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 		}
 	}
 
-	for _, contextFiles := range files {
-		data.ContextFiles = append(data.ContextFiles, contextFiles...)
-	}
 	return data, nil
 }
 
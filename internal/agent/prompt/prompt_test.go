@@ -0,0 +1,26 @@
+package prompt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptBuildWithBuiltIn(t *testing.T) {
+	t.Parallel()
+
+	p, err := NewPrompt(
+		"coder",
+		"{{.BuiltIn}}\nAlways write British English.",
+		WithBuiltIn("You are Crush on {{.Platform}}, {{.Date}}."),
+		WithPlatform("linux"),
+		WithTimeFunc(func() time.Time { return time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC) }),
+		WithWorkingDir(t.TempDir()),
+	)
+	require.NoError(t, err)
+	got, err := p.Build(t.Context(), "", "", config.Config{Options: &config.Options{}})
+	require.NoError(t, err)
+	require.Equal(t, "You are Crush on linux, 10/14/2026.\nAlways write British English.", got)
+}
@@ -3,6 +3,8 @@ package agent
 import (
 	"context"
 	_ "embed"
+	"fmt"
+	"os"
 
 	"github.com/charmbracelet/crush/internal/agent/prompt"
 	"github.com/charmbracelet/crush/internal/config"
@@ -25,12 +27,30 @@ func coderPrompt(opts ...prompt.Option) (*prompt.Prompt, error) {
 	return systemPrompt, nil
 }
 
-func taskPrompt(opts ...prompt.Option) (*prompt.Prompt, error) {
-	systemPrompt, err := prompt.NewPrompt("task", string(taskPromptTmpl), opts...)
+// configuredCoderPrompt returns the coder prompt built from the template
+// overriding it, or the built-in one when there is none.
+func configuredCoderPrompt(cfg *config.Config, opts ...prompt.Option) (*prompt.Prompt, error) {
+	return configuredPrompt(cfg, config.PromptCoder, coderPromptTmpl, opts...)
+}
+
+// configuredTaskPrompt returns the prompt of sub-agents built from the
+// template overriding it, or the built-in one when there is none.
+func configuredTaskPrompt(cfg *config.Config, opts ...prompt.Option) (*prompt.Prompt, error) {
+	return configuredPrompt(cfg, config.PromptTask, taskPromptTmpl, opts...)
+}
+
+// configuredPrompt returns the prompt name built from the template
+// [config.Config.PromptOverride] finds for it, which can include builtIn.
+func configuredPrompt(cfg *config.Config, name string, builtIn []byte, opts ...prompt.Option) (*prompt.Prompt, error) {
+	path := cfg.PromptOverride(name)
+	if path == "" {
+		return prompt.NewPrompt(name, string(builtIn), opts...)
+	}
+	tmpl, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("reading prompt template: %w", err)
 	}
-	return systemPrompt, nil
+	return prompt.NewPrompt(name, string(tmpl), append(opts, prompt.WithBuiltIn(string(builtIn)))...)
 }
 
 func InitializePrompt(cfg config.Config) (string, error) {
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeQueuedCalls(t *testing.T) {
+	t.Parallel()
+
+	image := message.Attachment{FileName: "shot.png", MimeType: "image/png"}
+	notes := message.Attachment{FileName: "notes.txt", MimeType: "text/plain"}
+	merged := mergeQueuedCalls([]SessionAgentCall{
+		{SessionID: "s1", Prompt: "also update the docs\n", Attachments: []message.Attachment{image}},
+		{SessionID: "s1", Prompt: " "},
+		{SessionID: "s1", Prompt: "and bump the version", Attachments: []message.Attachment{notes}, QueueMode: config.QueueMerge},
+	})
+	require.Equal(t, "s1", merged.SessionID)
+	require.Equal(t, "also update the docs\n\nand bump the version", merged.Prompt)
+	require.Equal(t, []message.Attachment{image, notes}, merged.Attachments)
+	require.Equal(t, config.QueueMerge, merged.QueueMode)
+}
+
+func TestRunQueuesWhileBusy(t *testing.T) {
+	t.Parallel()
+
+	a := NewSessionAgent(SessionAgentOptions{}).(*sessionAgent)
+	a.activeRequests.Set("s1", func() {})
+
+	for _, prompt := range []string{"first", "second"} {
+		result, err := a.Run(t.Context(), SessionAgentCall{SessionID: "s1", Prompt: prompt, QueueMode: config.QueueSequential})
+		require.NoError(t, err)
+		require.Nil(t, result)
+	}
+	require.Equal(t, []string{"first", "second"}, a.QueuedPromptsList("s1"))
+}
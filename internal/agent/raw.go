@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// RawHistory is how many of the latest turns of a session a raw message is
+// sent with.
+type RawHistory int
+
+const (
+	// RawHistoryNone sends the raw message on its own.
+	RawHistoryNone RawHistory = 0
+	// RawHistoryLastTurn sends the last prompt and its answer along.
+	RawHistoryLastTurn RawHistory = 1
+	// RawHistoryAll sends the whole session along.
+	RawHistoryAll RawHistory = -1
+)
+
+// rawHistory returns the selected turns of msgs as plain conversation: the
+// prompts and the text of the answers, leaving out tool calls and reasoning,
+// since the model gets no tools to make sense of them.
+func rawHistory(msgs []message.Message, turns RawHistory) []fantasy.Message {
+	if turns == RawHistoryNone {
+		return nil
+	}
+	start := 0
+	if turns > 0 {
+		seen := 0
+		for i := len(msgs) - 1; i >= 0; i-- {
+			if msgs[i].Role == message.User {
+				seen++
+				start = i
+				if seen == int(turns) {
+					break
+				}
+			}
+		}
+		if seen == 0 {
+			return nil
+		}
+	}
+
+	var history []fantasy.Message
+	var answer []string
+	flush := func() {
+		if len(answer) == 0 {
+			return
+		}
+		history = append(history, fantasy.Message{
+			Role:    fantasy.MessageRoleAssistant,
+			Content: []fantasy.MessagePart{fantasy.TextPart{Text: strings.Join(answer, "\n\n")}},
+		})
+		answer = nil
+	}
+	for _, msg := range msgs[start:] {
+		switch msg.Role {
+		case message.User:
+			flush()
+			history = append(history, msg.ToAIMessage()...)
+		case message.Assistant:
+			if text := strings.TrimSpace(msg.Content().Text); text != "" {
+				answer = append(answer, text)
+			}
+		}
+	}
+	flush()
+	return history
+}
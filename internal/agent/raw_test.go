@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawHistory(t *testing.T) {
+	t.Parallel()
+
+	text := func(role message.MessageRole, s string) message.Message {
+		return message.Message{Role: role, Parts: []message.ContentPart{message.TextContent{Text: s}}}
+	}
+	msgs := []message.Message{
+		text(message.User, "first"),
+		text(message.Assistant, "one"),
+		text(message.User, "second"),
+		{Role: message.Assistant, Parts: []message.ContentPart{
+			message.TextContent{Text: "looking"},
+			message.ToolCall{ID: "1", Name: "view", Input: "{}", Finished: true},
+		}},
+		{Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: "1", Content: "file"}}},
+		text(message.Assistant, "two"),
+	}
+
+	require.Empty(t, rawHistory(msgs, RawHistoryNone))
+
+	last := rawHistory(msgs, RawHistoryLastTurn)
+	require.Len(t, last, 2)
+	require.Equal(t, fantasy.MessageRoleUser, last[0].Role)
+	require.Equal(t, fantasy.MessageRoleAssistant, last[1].Role)
+	require.Equal(t, fantasy.TextPart{Text: "looking\n\ntwo"}, last[1].Content[0], "tool calls are left out and the answer merged")
+
+	require.Len(t, rawHistory(msgs, RawHistoryAll), 4)
+	require.Len(t, rawHistory(msgs, 5), 4)
+	require.Empty(t, rawHistory(nil, RawHistoryLastTurn))
+}
@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// supersededReadContent replaces the result of a file read that a later read
+// in the conversation covers.
+const supersededReadContent = "[Earlier read of %s removed: the file was read again later in this conversation. Use the latest read.]"
+
+// lineRange is the lines of a file a view call read, end exclusive.
+type lineRange struct {
+	start, end int
+}
+
+func (r lineRange) covers(other lineRange) bool {
+	return r.start <= other.start && r.end >= other.end
+}
+
+// dedupeFileReads replaces the results of view calls whose lines were read
+// again later in msgs with a stub pointing to the later read, so long editing
+// sessions don't replay each version of a file. msgs is not modified; the
+// messages with stubs are copies.
+func dedupeFileReads(msgs []message.Message) []message.Message {
+	ranges := make(map[string]lineRange)
+	for _, msg := range msgs {
+		for _, call := range msg.ToolCalls() {
+			if call.Name != tools.ViewToolName {
+				continue
+			}
+			var params tools.ViewParams
+			if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+				continue
+			}
+			limit := params.Limit
+			if limit <= 0 {
+				limit = tools.DefaultReadLimit
+			}
+			ranges[call.ID] = lineRange{start: params.Offset, end: params.Offset + limit}
+		}
+	}
+	if len(ranges) < 2 {
+		return msgs
+	}
+
+	// Walk back from the latest read, so each read is checked against the
+	// ones that came after it.
+	later := make(map[string][]lineRange)
+	var deduped []message.Message
+	cloned := make(map[int]bool)
+	for i := len(msgs) - 1; i >= 0; i-- {
+		msg := msgs[i]
+		if msg.Role != message.Tool {
+			continue
+		}
+		for j := len(msg.Parts) - 1; j >= 0; j-- {
+			result, ok := msg.Parts[j].(message.ToolResult)
+			if !ok || result.Name != tools.ViewToolName || result.IsError {
+				continue
+			}
+			lines, ok := ranges[result.ToolCallID]
+			if !ok {
+				continue
+			}
+			var metadata tools.ViewResponseMetadata
+			if err := json.Unmarshal([]byte(result.Metadata), &metadata); err != nil || metadata.FilePath == "" {
+				continue
+			}
+			if metadata.Lines > 0 {
+				// The read stopped early, at the end of the file or its
+				// byte cap.
+				lines.end = min(lines.end, lines.start+metadata.Lines)
+			}
+			path := metadata.FilePath
+			if !slices.ContainsFunc(later[path], func(r lineRange) bool { return r.covers(lines) }) {
+				later[path] = append(later[path], lines)
+				continue
+			}
+
+			if deduped == nil {
+				deduped = slices.Clone(msgs)
+			}
+			if !cloned[i] {
+				deduped[i].Parts = slices.Clone(msg.Parts)
+				cloned[i] = true
+			}
+			result.Content = fmt.Sprintf(supersededReadContent, path)
+			result.Metadata = ""
+			deduped[i].Parts[j] = result
+		}
+	}
+	if deduped == nil {
+		return msgs
+	}
+	return deduped
+}
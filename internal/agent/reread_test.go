@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+// viewTurn returns the assistant and tool messages of a view call of path
+// with the given offset and limit.
+func viewTurn(t *testing.T, id, path string, offset, limit int) []message.Message {
+	t.Helper()
+	input, err := json.Marshal(tools.ViewParams{FilePath: path, Offset: offset, Limit: limit})
+	require.NoError(t, err)
+	metadata, err := json.Marshal(tools.ViewResponseMetadata{FilePath: path})
+	require.NoError(t, err)
+	return []message.Message{
+		{Role: message.Assistant, Parts: []message.ContentPart{
+			message.ToolCall{ID: id, Name: tools.ViewToolName, Input: string(input), Finished: true},
+		}},
+		{Role: message.Tool, Parts: []message.ContentPart{
+			message.ToolResult{ToolCallID: id, Name: tools.ViewToolName, Content: "content of " + id, Metadata: string(metadata)},
+		}},
+	}
+}
+
+func resultContent(msg message.Message) string {
+	return msg.ToolResults()[0].Content
+}
+
+func TestDedupeFileReads(t *testing.T) {
+	t.Parallel()
+
+	var msgs []message.Message
+	msgs = append(msgs, viewTurn(t, "1", "/p/a.go", 0, 0)...)
+	msgs = append(msgs, viewTurn(t, "2", "/p/b.go", 0, 0)...)
+	msgs = append(msgs, viewTurn(t, "3", "/p/a.go", 100, 50)...)
+	msgs = append(msgs, viewTurn(t, "4", "/p/a.go", 0, 0)...)
+
+	deduped := dedupeFileReads(msgs)
+	require.Len(t, deduped, len(msgs))
+	require.Equal(t, fmt.Sprintf(supersededReadContent, "/p/a.go"), resultContent(deduped[1]))
+	require.Equal(t, "content of 2", resultContent(deduped[3]), "other files are kept")
+	require.Equal(t, fmt.Sprintf(supersededReadContent, "/p/a.go"), resultContent(deduped[5]), "later full reads cover partial ones")
+	require.Equal(t, "content of 4", resultContent(deduped[7]), "the latest read is kept")
+
+	require.Equal(t, "content of 1", resultContent(msgs[1]), "the messages passed in are not modified")
+}
+
+func TestDedupeFileReadsPartial(t *testing.T) {
+	t.Parallel()
+
+	var msgs []message.Message
+	msgs = append(msgs, viewTurn(t, "1", "/p/a.go", 0, 0)...)
+	msgs = append(msgs, viewTurn(t, "2", "/p/a.go", 2000, 100)...)
+	msgs = append(msgs, viewTurn(t, "3", "/p/a.go", 10, 20)...)
+
+	deduped := dedupeFileReads(msgs)
+	require.Equal(t, msgs, deduped, "later reads of other lines do not replace earlier ones")
+}
+
+func TestDedupeFileReadsSkipsErrors(t *testing.T) {
+	t.Parallel()
+
+	msgs := viewTurn(t, "1", "/p/a.go", 0, 0)
+	msgs = append(msgs, viewTurn(t, "2", "/p/a.go", 0, 0)...)
+	result := msgs[3].Parts[0].(message.ToolResult)
+	result.IsError = true
+	msgs[3].Parts[0] = result
+
+	deduped := dedupeFileReads(msgs)
+	require.Equal(t, "content of 1", resultContent(deduped[1]), "a failed read does not replace a good one")
+}
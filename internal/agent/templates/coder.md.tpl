@@ -361,7 +361,12 @@ Today's date: {{.Date}}
 Git status (snapshot at conversation start - may be outdated):
 {{.GitStatus}}
 {{end}}
-</env>
+</env>{{if .Roots}}
+
+<workspace_roots>
+Only files inside these directories can be used. Files in read-only roots can be read but not changed.
+{{range .Roots}}- {{.Label}}: {{.Path}} ({{.Access}})
+{{end}}</workspace_roots>{{end}}
 
 {{if gt (len .Config.LSP) 0}}
 <lsp>
@@ -383,8 +388,9 @@ If a skill mentions scripts, references, or assets, they are placed in the same
 
 {{if .ContextFiles}}
 <memory>
+Memory files are listed from the least to the most specific (global, then project, then directory). When their instructions conflict, follow the later, more specific file.
 {{range .ContextFiles}}
-<file path="{{.Path}}">
+<file path="{{.Path}}" scope="{{.Scope}}">
 {{.Content}}
 </file>
 {{end}}
@@ -11,5 +11,10 @@ Working directory: {{.WorkingDir}}
 Is directory a git repo: {{if .IsGitRepo}} yes {{else}} no {{end}}
 Platform: {{.Platform}}
 Today's date: {{.Date}}
-</env>
+</env>{{if .Roots}}
+
+<workspace_roots>
+Only files inside these directories can be used. Files in read-only roots can be read but not changed.
+{{range .Roots}}- {{.Label}}: {{.Path}} ({{.Access}})
+{{end}}</workspace_roots>{{end}}
 
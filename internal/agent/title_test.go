@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveTitleKeepsRename(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	a := &sessionAgent{sessions: env.sessions}
+
+	generated, err := env.sessions.Create(t.Context(), "New Session")
+	require.NoError(t, err)
+	a.saveTitleAndUsage(t.Context(), generated.ID, "New Session", "Parser fixes", 10, 2, 0)
+	got, err := env.sessions.Get(t.Context(), generated.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Parser fixes", got.Title)
+
+	renamed, err := env.sessions.Create(t.Context(), "New Session")
+	require.NoError(t, err)
+	_, err = env.sessions.Rename(t.Context(), renamed.ID, "Mine")
+	require.NoError(t, err)
+	a.saveTitleAndUsage(t.Context(), renamed.ID, "New Session", "Parser fixes", 10, 2, 0)
+	got, err = env.sessions.Get(t.Context(), renamed.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Mine", got.Title, "a title set during the first exchange is kept")
+	require.Equal(t, int64(10), got.PromptTokens, "the usage is still added")
+}
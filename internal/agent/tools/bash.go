@@ -15,6 +15,7 @@ import (
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/shell"
 )
@@ -64,6 +65,8 @@ type bashDescriptionData struct {
 	MaxOutputLength int
 	Attribution     config.Attribution
 	ModelName       string
+	Persistent      bool
+	Sandbox         *shell.Sandbox
 }
 
 var bannedCommands = []string{
@@ -139,7 +142,7 @@ var bannedCommands = []string{
 	"ufw",
 }
 
-func bashDescription(attribution *config.Attribution, modelName string) string {
+func bashDescription(attribution *config.Attribution, modelName string, persistent bool, sandbox *shell.Sandbox) string {
 	bannedCommandsStr := strings.Join(bannedCommands, ", ")
 	var out bytes.Buffer
 	if err := bashDescriptionTpl.Execute(&out, bashDescriptionData{
@@ -147,6 +150,8 @@ func bashDescription(attribution *config.Attribution, modelName string) string {
 		MaxOutputLength: MaxOutputLength,
 		Attribution:     *attribution,
 		ModelName:       modelName,
+		Persistent:      persistent,
+		Sandbox:         sandbox,
 	}); err != nil {
 		// this should never happen.
 		panic("failed to execute bash description template: " + err.Error())
@@ -186,10 +191,22 @@ func blockFuncs() []shell.BlockFunc {
 	}
 }
 
-func NewBashTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelName string) fantasy.AgentTool {
+// NewBashTool creates the bash tool. With persistent set, each session runs
+// its commands in one long-lived shell, so the working directory and exported
+// variables carry over between calls until reset_shell is used. A non-nil
+// sandbox confines the commands to the working directory.
+func NewBashTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelName string, persistent bool, sandbox *shell.Sandbox, classifier shell.Classifier) fantasy.AgentTool {
+	newShell := func(dir string, env []string) *shell.Shell {
+		return shell.NewShell(&shell.Options{
+			WorkingDir: dir,
+			Env:        env,
+			BlockFuncs: blockFuncs(),
+			Sandbox:    sandbox,
+		})
+	}
 	return fantasy.NewAgentTool(
 		BashToolName,
-		string(bashDescription(attribution, modelName)),
+		string(bashDescription(attribution, modelName, persistent, sandbox)),
 		func(ctx context.Context, params BashParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 			if params.Command == "" {
 				return fantasy.NewTextErrorResponse("missing command"), nil
@@ -214,16 +231,36 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			if sessionID == "" {
 				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for executing shell command")
 			}
-			if !isSafeReadOnly {
+			if IsPlanMode(ctx) && (!isSafeReadOnly || chainsCommands(params.Command)) {
+				return fantasy.NewTextErrorResponse("plan mode is on, so only read-only commands can run. Include this command in your plan instead."), nil
+			}
+
+			var sessionShell *shell.Shell
+			if persistent {
+				sessionShell = shell.GetSessionShells().Get(sessionID, func() *shell.Shell { return newShell(workingDir, nil) })
+				if sessionShell.Busy() {
+					return fantasy.NewTextErrorResponse("the session shell is still running a command. Wait for its job with job_output, stop it with job_kill, or start over with reset_shell."), nil
+				}
+				execWorkingDir = sessionShell.GetWorkingDir()
+				if params.WorkingDir != "" {
+					execWorkingDir = filepathext.SmartJoin(execWorkingDir, params.WorkingDir)
+				}
+			}
+			// A read-only command may still hide a risky one, as in
+			// "echo $(rm -rf ~)", so a flagged command is always asked for.
+			risk := classifyCommand(ctx, classifier, params.Command)
+			if !isSafeReadOnly || (risk != nil && risk.Severity > shell.SeverityNone) {
 				p, err := permissions.Request(ctx,
 					permission.CreatePermissionRequest{
 						SessionID:   sessionID,
 						Path:        execWorkingDir,
+						Command:     params.Command,
 						ToolCallID:  call.ID,
 						ToolName:    BashToolName,
 						Action:      "execute",
 						Description: fmt.Sprintf("Execute command: %s", params.Command),
 						Params:      BashPermissionsParams(params),
+						Risk:        risk,
 					},
 				)
 				if err != nil {
@@ -233,6 +270,11 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 					return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 				}
 			}
+			if sessionShell != nil && execWorkingDir != sessionShell.GetWorkingDir() {
+				if err := sessionShell.SetWorkingDir(execWorkingDir); err != nil {
+					return fantasy.NewTextErrorResponse(err.Error()), nil
+				}
+			}
 
 			// If explicitly requested as background, start immediately with detached context
 			if params.RunInBackground {
@@ -240,7 +282,15 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 				bgManager := shell.GetBackgroundShellManager()
 				bgManager.Cleanup()
 				// Use background context so it continues after tool returns
-				bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(), params.Command, params.Description)
+				var bgShell *shell.BackgroundShell
+				var err error
+				if sessionShell != nil {
+					// Run in a copy of the session shell so a long-running
+					// job does not hold it.
+					bgShell, err = bgManager.StartIn(context.Background(), newShell(execWorkingDir, sessionShell.GetEnv()), params.Command, params.Description)
+				} else {
+					bgShell, err = bgManager.StartIn(context.Background(), newShell(execWorkingDir, nil), params.Command, params.Description)
+				}
 				if err != nil {
 					return fantasy.ToolResponse{}, fmt.Errorf("error starting background shell: %w", err)
 				}
@@ -295,7 +345,13 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			// Start with detached context so it can survive if moved to background
 			bgManager := shell.GetBackgroundShellManager()
 			bgManager.Cleanup()
-			bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(), params.Command, params.Description)
+			var bgShell *shell.BackgroundShell
+			var err error
+			if sessionShell != nil {
+				bgShell, err = bgManager.StartIn(context.Background(), sessionShell, params.Command, params.Description)
+			} else {
+				bgShell, err = bgManager.StartIn(context.Background(), newShell(execWorkingDir, nil), params.Command, params.Description)
+			}
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error starting shell: %w", err)
 			}
@@ -342,18 +398,24 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 
 				stdout = formatOutput(stdout, stderr, execErr)
 
+				// The session shell keeps any cd the command ran.
+				cwd := bgShell.WorkingDir
+				if sessionShell != nil {
+					cwd = sessionShell.GetWorkingDir()
+				}
+
 				metadata := BashResponseMetadata{
 					StartTime:        startTime.UnixMilli(),
 					EndTime:          time.Now().UnixMilli(),
 					Output:           stdout,
 					Description:      params.Description,
 					Background:       params.RunInBackground,
-					WorkingDirectory: bgShell.WorkingDir,
+					WorkingDirectory: cwd,
 				}
 				if stdout == "" {
 					return fantasy.WithResponseMetadata(fantasy.NewTextResponse(BashNoOutput), metadata), nil
 				}
-				stdout += fmt.Sprintf("\n\n<cwd>%s</cwd>", normalizeWorkingDir(bgShell.WorkingDir))
+				stdout += fmt.Sprintf("\n\n<cwd>%s</cwd>", normalizeWorkingDir(cwd))
 				return fantasy.WithResponseMetadata(fantasy.NewTextResponse(stdout), metadata), nil
 			}
 
@@ -440,3 +502,13 @@ func normalizeWorkingDir(path string) string {
 
 	return filepath.ToSlash(path)
 }
+
+// classifyCommand returns how classifier judges command, or nil when
+// commands are not classified.
+func classifyCommand(ctx context.Context, classifier shell.Classifier, command string) *shell.Verdict {
+	if classifier == nil {
+		return nil
+	}
+	verdict := classifier.Classify(ctx, command)
+	return &verdict
+}
@@ -19,8 +19,17 @@ Common shell builtins and core utils available on Windows.
 - Command required, working_dir optional (defaults to current directory)
 - IMPORTANT: Use Grep/Glob/Agent tools instead of 'find'/'grep'. Use View/LS tools instead of 'cat'/'head'/'tail'/'ls'
 - Chain with ';' or '&&', avoid newlines except in quoted strings
+{{ if .Persistent -}}
+- Commands in this session share one shell: the working directory and exported variables (e.g. from `cd` or `source .venv/bin/activate`) persist between calls
+- working_dir changes the shell's directory for this and later commands
+- Use reset_shell to start over with a fresh shell in the project directory
+{{ else -}}
 - Each command runs in independent shell (no state persistence between calls)
 - Prefer absolute paths over 'cd' (use 'cd' only if user explicitly requests)
+{{ end -}}
+{{ if .Sandbox -}}
+- Commands run in a {{ .Sandbox.Backend }} sandbox: only the working directory{{ if .Sandbox.WritablePaths }}, the temporary directory and configured paths{{ else }} and the temporary directory{{ end }} can be written{{ if not .Sandbox.Network }}, and the network is blocked{{ end }}. If a command fails because of this, tell the user instead of working around it
+{{ end -}}
 </usage_notes>
 
 <background_execution>
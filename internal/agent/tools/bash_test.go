@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/stretchr/testify/require"
+)
+
+// denyingPermissionService denies every request and records it.
+type denyingPermissionService struct {
+	mockPermissionService
+	requests []permission.CreatePermissionRequest
+}
+
+func (d *denyingPermissionService) Request(_ context.Context, req permission.CreatePermissionRequest) (bool, error) {
+	d.requests = append(d.requests, req)
+	return false, nil
+}
+
+func TestBashClassifiedCommand(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "keep.txt")
+	require.NoError(t, os.WriteFile(target, []byte("keep"), 0o644))
+	classifier, err := shell.NewRuleClassifier(shell.DefaultClassifierRules)
+	require.NoError(t, err)
+	permissions := &denyingPermissionService{}
+	tool := NewBashTool(permissions, dir, &config.Attribution{}, "", false, nil, classifier)
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+
+	input, err := json.Marshal(BashParams{Command: "echo $(rm -rf keep.txt)", Description: "test"})
+	require.NoError(t, err)
+	_, err = tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: BashToolName, Input: string(input)})
+	require.ErrorIs(t, err, permission.ErrorPermissionDenied, "flagged read-only commands are asked for")
+	require.FileExists(t, target)
+	require.Len(t, permissions.requests, 1)
+	require.NotNil(t, permissions.requests[0].Risk)
+	require.Equal(t, shell.SeverityHigh, permissions.requests[0].Risk.Severity)
+
+	resp := runTool(t, ctx, tool, BashParams{Command: "ls", Description: "list"})
+	require.False(t, resp.IsError)
+	require.Len(t, permissions.requests, 1, "unflagged read-only commands still run without asking")
+}
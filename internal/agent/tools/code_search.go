@@ -0,0 +1,453 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+type CodeSearchParams struct {
+	Pattern    string `json:"pattern" description:"The regular expression to search for"`
+	Path       string `json:"path,omitempty" description:"The file or directory to search in. Defaults to the current working directory."`
+	Include    string `json:"include,omitempty" description:"Glob of the files to search (e.g. \"*.go\", \"*.{ts,tsx}\")"`
+	Type       string `json:"type,omitempty" description:"Only search files of this type, such as go, py, js, ts, rust, java or markdown"`
+	Multiline  bool   `json:"multiline,omitempty" description:"Let the pattern match across lines, with . matching newlines"`
+	IgnoreCase bool   `json:"ignore_case,omitempty" description:"Match regardless of case"`
+	Literal    bool   `json:"literal,omitempty" description:"Treat the pattern as literal text instead of a regular expression"`
+	Context    int    `json:"context,omitempty" description:"Lines of context to show before and after each match (at most 10)"`
+	MaxResults int    `json:"max_results,omitempty" description:"Maximum number of matches to return (default 100, at most 1000)"`
+}
+
+type CodeSearchResponseMetadata struct {
+	NumberOfMatches int  `json:"number_of_matches"`
+	NumberOfFiles   int  `json:"number_of_files"`
+	Truncated       bool `json:"truncated"`
+}
+
+const (
+	CodeSearchToolName = "code_search"
+
+	defaultCodeSearchResults = 100
+	maxCodeSearchResults     = 1000
+	maxCodeSearchContext     = 10
+	// maxCodeSearchOutput caps the size of the result, whatever the number
+	// of matches.
+	maxCodeSearchOutput = 100 * 1024
+	// maxCodeSearchFileSize is the largest file the Go fallback searches.
+	maxCodeSearchFileSize = 10 * 1024 * 1024
+)
+
+//go:embed code_search.md
+var codeSearchDescription []byte
+
+// codeSearchTypes are the file types the Go fallback knows, named as in
+// ripgrep.
+var codeSearchTypes = map[string][]string{
+	"c":        {"*.c", "*.h"},
+	"cpp":      {"*.cpp", "*.cc", "*.cxx", "*.hpp", "*.hh", "*.hxx", "*.h"},
+	"csharp":   {"*.cs"},
+	"css":      {"*.css", "*.scss", "*.sass", "*.less"},
+	"elixir":   {"*.ex", "*.exs"},
+	"go":       {"*.go"},
+	"html":     {"*.html", "*.htm"},
+	"java":     {"*.java"},
+	"js":       {"*.js", "*.jsx", "*.mjs", "*.cjs", "*.vue"},
+	"json":     {"*.json"},
+	"kotlin":   {"*.kt", "*.kts"},
+	"lua":      {"*.lua"},
+	"markdown": {"*.md", "*.markdown", "*.mdx"},
+	"php":      {"*.php"},
+	"py":       {"*.py", "*.pyi"},
+	"ruby":     {"*.rb", "*.gemspec", "Gemfile", "Rakefile"},
+	"rust":     {"*.rs"},
+	"sh":       {"*.sh", "*.bash", "*.zsh"},
+	"sql":      {"*.sql"},
+	"swift":    {"*.swift"},
+	"toml":     {"*.toml"},
+	"ts":       {"*.ts", "*.tsx", "*.mts", "*.cts"},
+	"yaml":     {"*.yaml", "*.yml"},
+	"zig":      {"*.zig"},
+}
+
+// codeSearchQuery is a search of the code_search tool.
+type codeSearchQuery struct {
+	pattern    string
+	path       string
+	include    string
+	fileType   string
+	multiline  bool
+	ignoreCase bool
+	literal    bool
+	context    int
+	maxResults int
+}
+
+// searchLine is a line of a file shown in the results, one of a match or
+// around one.
+type searchLine struct {
+	num   int
+	text  string
+	match bool
+}
+
+// searchResults holds the lines found in each file.
+type searchResults struct {
+	files     map[string][]searchLine
+	matches   int
+	truncated bool
+}
+
+func (r *searchResults) add(path string, line searchLine) {
+	lines := r.files[path]
+	for i := range lines {
+		if lines[i].num == line.num {
+			lines[i].match = lines[i].match || line.match
+			return
+		}
+	}
+	r.files[path] = append(lines, line)
+}
+
+func NewCodeSearchTool(workingDir string, config config.ToolGrep) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		CodeSearchToolName,
+		string(codeSearchDescription),
+		func(ctx context.Context, params CodeSearchParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Pattern == "" {
+				return fantasy.NewTextErrorResponse("pattern is required"), nil
+			}
+			q := codeSearchQuery{
+				pattern:    params.Pattern,
+				path:       filepathext.SmartJoin(workingDir, cmp.Or(params.Path, ".")),
+				include:    params.Include,
+				fileType:   params.Type,
+				multiline:  params.Multiline,
+				ignoreCase: params.IgnoreCase,
+				literal:    params.Literal,
+				context:    min(max(params.Context, 0), maxCodeSearchContext),
+				maxResults: min(cmp.Or(params.MaxResults, defaultCodeSearchResults), maxCodeSearchResults),
+			}
+			if _, err := os.Stat(q.path); err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("path not found: %s", q.path)), nil
+			}
+
+			searchCtx, cancel := context.WithTimeout(ctx, config.GetTimeout())
+			defer cancel()
+
+			results, err := codeSearch(searchCtx, q)
+			if errors.Is(err, context.DeadlineExceeded) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("search timed out after %s. Narrow it down with a path, an include glob or a type.", config.GetTimeout())), nil
+			}
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("error searching files: %v", err)), nil
+			}
+
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(formatCodeSearch(results, workingDir)),
+				CodeSearchResponseMetadata{
+					NumberOfMatches: results.matches,
+					NumberOfFiles:   len(results.files),
+					Truncated:       results.truncated,
+				},
+			), nil
+		})
+}
+
+// codeSearch runs q with ripgrep, or with the Go fallback when ripgrep is
+// not installed.
+func codeSearch(ctx context.Context, q codeSearchQuery) (*searchResults, error) {
+	if name := getRg(); name != "" {
+		return searchRipgrepJSON(ctx, name, q)
+	}
+	return searchGo(ctx, q)
+}
+
+func (q codeSearchQuery) rgArgs() []string {
+	args := []string{"--json", "--no-config"}
+	if q.multiline {
+		args = append(args, "--multiline", "--multiline-dotall")
+	}
+	if q.ignoreCase {
+		args = append(args, "--ignore-case")
+	}
+	if q.literal {
+		args = append(args, "--fixed-strings")
+	}
+	if q.context > 0 {
+		args = append(args, "--context", fmt.Sprint(q.context))
+	}
+	if q.include != "" {
+		args = append(args, "--glob", q.include)
+	}
+	if q.fileType != "" {
+		args = append(args, "--type", q.fileType)
+	}
+	return append(args, "--regexp", q.pattern, "--", q.path)
+}
+
+// rgMessage is a line of the JSON output of ripgrep.
+type rgMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		Path struct {
+			Text string `json:"text"`
+		} `json:"path"`
+		Lines struct {
+			Text string `json:"text"`
+		} `json:"lines"`
+		LineNumber int `json:"line_number"`
+	} `json:"data"`
+}
+
+// searchRipgrepJSON streams the JSON output of ripgrep, stopping it once
+// q.maxResults matches were read.
+func searchRipgrepJSON(ctx context.Context, rg string, q codeSearchQuery) (*searchResults, error) {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	cmd := exec.CommandContext(ctx, rg, q.rgArgs()...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	results := &searchResults{files: make(map[string][]searchLine)}
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	denied := make(map[string]bool)
+	for scanner.Scan() {
+		var msg rgMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		if msg.Type != "match" && msg.Type != "context" {
+			continue
+		}
+		path := msg.Data.Path.Text
+		isDenied, ok := denied[path]
+		if !ok {
+			// Ripgrep knows nothing of .crushignore files and denied paths.
+			isDenied = fsext.Denied(path)
+			denied[path] = isDenied
+		}
+		if isDenied {
+			continue
+		}
+		if msg.Type == "match" {
+			if results.matches >= q.maxResults {
+				results.truncated = true
+				stop()
+				break
+			}
+			results.matches++
+		}
+		text := strings.TrimSuffix(msg.Data.Lines.Text, "\n")
+		for i, line := range strings.Split(text, "\n") {
+			results.add(path, searchLine{num: msg.Data.LineNumber + i, text: line, match: msg.Type == "match"})
+		}
+	}
+	// Drain what is left so ripgrep is not blocked writing it.
+	_, _ = io.Copy(io.Discard, stdout)
+	err = cmd.Wait()
+
+	var exitErr *exec.ExitError
+	switch {
+	case results.truncated:
+		return results, nil
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		// No matches.
+		return results, nil
+	case err != nil:
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.New(msg)
+		}
+		return nil, err
+	}
+	return results, scanner.Err()
+}
+
+// searchGo searches the files of q.path with Go's regexp package, honoring
+// ignore files and skipping hidden and binary files as ripgrep does.
+func searchGo(ctx context.Context, q codeSearchQuery) (*searchResults, error) {
+	pattern := q.pattern
+	if q.literal {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if q.multiline {
+		pattern = "(?ms)" + pattern
+	}
+	if q.ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	var globs []string
+	if q.fileType != "" {
+		var ok bool
+		if globs, ok = codeSearchTypes[q.fileType]; !ok {
+			return nil, fmt.Errorf("unrecognized file type: %s (known types: %s)", q.fileType, strings.Join(slices.Sorted(maps.Keys(codeSearchTypes)), ", "))
+		}
+	}
+
+	results := &searchResults{files: make(map[string][]searchLine)}
+	walker := fsext.NewFastGlobWalker(q.path)
+	err = filepath.WalkDir(q.path, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip files we can't access.
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			if path != q.path && (walker.ShouldSkipDir(path) || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if path != q.path && (walker.ShouldSkip(path) || strings.HasPrefix(d.Name(), ".")) {
+			return nil
+		}
+		if !matchesAnyGlob(globs, d.Name()) || q.include != "" && !matchesAnyGlob([]string{q.include}, d.Name()) {
+			return nil
+		}
+		if fsext.Denied(path) {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxCodeSearchFileSize {
+			return nil
+		}
+		if !searchFile(path, re, q, results) {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, filepath.SkipAll) {
+		return nil, err
+	}
+	return results, nil
+}
+
+func matchesAnyGlob(globs []string, name string) bool {
+	if len(globs) == 0 {
+		return true
+	}
+	for _, glob := range globs {
+		if ok, _ := doublestar.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// searchFile adds the matches of re in the file at path to results. It
+// reports false once q.maxResults matches were found.
+func searchFile(path string, re *regexp.Regexp, q codeSearchQuery, results *searchResults) bool {
+	data, err := os.ReadFile(path)
+	if err != nil || isBinary(data[:min(len(data), 8*1024)]) {
+		return true
+	}
+	content := string(data)
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+
+	// spans are the first and last lines of each match, 0-based.
+	var spans [][2]int
+	if q.multiline {
+		for _, loc := range re.FindAllStringIndex(content, -1) {
+			first := strings.Count(content[:loc[0]], "\n")
+			last := first + strings.Count(strings.TrimSuffix(content[loc[0]:loc[1]], "\n"), "\n")
+			spans = append(spans, [2]int{first, min(last, len(lines)-1)})
+		}
+	} else {
+		for i, line := range lines {
+			if re.MatchString(line) {
+				spans = append(spans, [2]int{i, i})
+			}
+		}
+	}
+
+	for _, span := range spans {
+		if results.matches >= q.maxResults {
+			results.truncated = true
+			return false
+		}
+		results.matches++
+		for i := max(span[0]-q.context, 0); i <= min(span[1]+q.context, len(lines)-1); i++ {
+			results.add(path, searchLine{num: i + 1, text: lines[i], match: i >= span[0] && i <= span[1]})
+		}
+	}
+	return true
+}
+
+// formatCodeSearch lists the lines found by file, matches marked with
+// ":" and context lines with "-" after their numbers, as ripgrep does.
+func formatCodeSearch(results *searchResults, workingDir string) string {
+	if results.matches == 0 {
+		return "No matches found"
+	}
+	paths := slices.Sorted(maps.Keys(results.files))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Found %d matches in %d files\n", results.matches, len(paths))
+	capped := false
+	for _, path := range paths {
+		if sb.Len() > maxCodeSearchOutput {
+			capped = true
+			break
+		}
+		lines := results.files[path]
+		slices.SortFunc(lines, func(a, b searchLine) int { return a.num - b.num })
+		display := path
+		if rel, err := filepath.Rel(workingDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			display = rel
+		}
+		fmt.Fprintf(&sb, "\n%s:\n", filepath.ToSlash(display))
+		for i, line := range lines {
+			if i > 0 && line.num > lines[i-1].num+1 {
+				sb.WriteString("  --\n")
+			}
+			sep := "-"
+			if line.match {
+				sep = ":"
+			}
+			text := strings.TrimSuffix(line.text, "\r")
+			if len(text) > maxGrepContentWidth {
+				text = text[:maxGrepContentWidth] + "..."
+			}
+			fmt.Fprintf(&sb, "  %d%s %s\n", line.num, sep, text)
+		}
+	}
+	switch {
+	case capped:
+		fmt.Fprintf(&sb, "\n(Output capped at %d bytes. Narrow the search down with a path, an include glob or a type.)", maxCodeSearchOutput)
+	case results.truncated:
+		fmt.Fprintf(&sb, "\n(Stopped after %d matches. Narrow the search down, or raise max_results.)", results.matches)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchGo(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":       "package main\n\nfunc main() {\n\trun()\n}\n\nfunc run() error {\n\treturn nil\n}\n",
+		"lib/util.py":   "def run():\n    pass\n",
+		"notes.md":      "Run the tests.\n",
+		".hidden/x.go":  "func run() {}\n",
+		"image.go":      "func run()\x00\x01\x02",
+		"lib/README.md": "nothing here\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	search := func(q codeSearchQuery) *searchResults {
+		t.Helper()
+		q.path = dir
+		q.maxResults = cmp.Or(q.maxResults, defaultCodeSearchResults)
+		results, err := searchGo(t.Context(), q)
+		require.NoError(t, err)
+		return results
+	}
+
+	t.Run("type filter", func(t *testing.T) {
+		t.Parallel()
+		results := search(codeSearchQuery{pattern: `func run\(`, fileType: "go"})
+		require.Equal(t, 1, results.matches)
+		require.Contains(t, results.files, filepath.Join(dir, "main.go"))
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		t.Parallel()
+		_, err := searchGo(t.Context(), codeSearchQuery{pattern: "x", path: dir, fileType: "cobol", maxResults: 1})
+		require.ErrorContains(t, err, "unrecognized file type")
+	})
+
+	t.Run("ignore case and include", func(t *testing.T) {
+		t.Parallel()
+		results := search(codeSearchQuery{pattern: "run", ignoreCase: true, include: "*.md"})
+		require.Equal(t, 1, results.matches)
+		require.Contains(t, results.files, filepath.Join(dir, "notes.md"))
+	})
+
+	t.Run("multiline", func(t *testing.T) {
+		t.Parallel()
+		results := search(codeSearchQuery{pattern: `func run\(\) error \{.*?\}`, multiline: true})
+		require.Equal(t, 1, results.matches)
+		lines := results.files[filepath.Join(dir, "main.go")]
+		require.Len(t, lines, 3)
+		require.Equal(t, 7, lines[0].num)
+		require.True(t, lines[2].match)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		t.Parallel()
+		results := search(codeSearchQuery{pattern: "\trun()", literal: true, fileType: "go", context: 1})
+		lines := results.files[filepath.Join(dir, "main.go")]
+		require.Equal(t, []searchLine{
+			{num: 3, text: "func main() {"},
+			{num: 4, text: "\trun()", match: true},
+			{num: 5, text: "}"},
+		}, lines)
+	})
+
+	t.Run("max results", func(t *testing.T) {
+		t.Parallel()
+		results := search(codeSearchQuery{pattern: "run", maxResults: 2})
+		require.Equal(t, 2, results.matches)
+		require.True(t, results.truncated)
+	})
+}
+
+func TestFormatCodeSearch(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	results := &searchResults{
+		files: map[string][]searchLine{
+			filepath.Join(dir, "b.go"): {{num: 9, text: "b", match: true}},
+			filepath.Join(dir, "a.go"): {
+				{num: 2, text: "two", match: true},
+				{num: 1, text: "one"},
+				{num: 5, text: "five", match: true},
+			},
+		},
+		matches:   3,
+		truncated: true,
+	}
+	require.Equal(t, `Found 3 matches in 2 files
+
+a.go:
+  1- one
+  2: two
+  --
+  5: five
+
+b.go:
+  9: b
+
+(Stopped after 3 matches. Narrow the search down, or raise max_results.)`, formatCodeSearch(results, dir))
+
+	require.Equal(t, "No matches found", formatCodeSearch(&searchResults{}, dir))
+}
@@ -5,6 +5,7 @@ import (
 	_ "embed"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -63,6 +64,16 @@ func notifyLSPs(
 }
 
 func getDiagnostics(filePath string, manager *lsp.Manager) string {
+	return collectDiagnostics(manager, "Current file", []string{filePath})
+}
+
+// getChangedFilesDiagnostics reports diagnostics for several files at once,
+// for tools that change more than one file per call.
+func getChangedFilesDiagnostics(filePaths []string, manager *lsp.Manager) string {
+	return collectDiagnostics(manager, "Changed files", filePaths)
+}
+
+func collectDiagnostics(manager *lsp.Manager, label string, filePaths []string) string {
 	if manager == nil {
 		return ""
 	}
@@ -77,7 +88,7 @@ func getDiagnostics(filePath string, manager *lsp.Manager) string {
 				slog.Error("Failed to convert diagnostic location URI to path", "uri", location, "error", err)
 				continue
 			}
-			isCurrentFile := path == filePath
+			isCurrentFile := slices.Contains(filePaths, path)
 			for _, diag := range diags {
 				formattedDiag := formatDiagnostic(path, diag, lspName)
 				if isCurrentFile {
@@ -102,7 +113,7 @@ func getDiagnostics(filePath string, manager *lsp.Manager) string {
 		projectErrors := countSeverity(projectDiagnostics, "Error")
 		projectWarnings := countSeverity(projectDiagnostics, "Warn")
 		output.WriteString("\n<diagnostic_summary>\n")
-		fmt.Fprintf(&output, "Current file: %d errors, %d warnings\n", fileErrors, fileWarnings)
+		fmt.Fprintf(&output, "%s: %d errors, %d warnings\n", label, fileErrors, fileWarnings)
 		fmt.Fprintf(&output, "Project: %d errors, %d warnings\n", projectErrors, projectWarnings)
 		output.WriteString("</diagnostic_summary>\n")
 	}
@@ -70,11 +70,15 @@ func NewDownloadTool(permissions permission.Service, workingDir string, client *
 			if sessionID == "" {
 				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for downloading files")
 			}
+			if IsPlanMode(ctx) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("plan mode is on, so %s was not downloaded. Describe the download in your plan instead.", params.URL)), nil
+			}
 
 			p, err := permissions.Request(ctx,
 				permission.CreatePermissionRequest{
 					SessionID:   sessionID,
 					Path:        filePath,
+					Target:      filePath,
 					ToolName:    DownloadToolName,
 					Action:      "download",
 					Description: fmt.Sprintf("Download file from URL: %s to %s", params.URL, filePath),
@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -11,9 +12,13 @@ import (
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/buildcheck"
+	"github.com/charmbracelet/crush/internal/codeowners"
 	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/fileheader"
 	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/formatter"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
 
@@ -39,6 +44,9 @@ type EditResponseMetadata struct {
 	Removals   int    `json:"removals"`
 	OldContent string `json:"old_content,omitempty"`
 	NewContent string `json:"new_content,omitempty"`
+	// Strategy is how the text to change was found: exact, whitespace-
+	// insensitive, fuzzy or unified diff.
+	Strategy string `json:"strategy,omitempty"`
 }
 
 const EditToolName = "edit"
@@ -57,10 +65,17 @@ type editContext struct {
 	files       history.Service
 	filetracker filetracker.Service
 	workingDir  string
+	formatters  *formatter.Runner
+	owners      *codeowners.Checker
+	headers     *fileheader.Policy
 }
 
 func NewEditTool(
 	lspManager *lsp.Manager,
+	formatters *formatter.Runner,
+	checks *buildcheck.Runner,
+	owners *codeowners.Checker,
+	headers *fileheader.Policy,
 	permissions permission.Service,
 	files history.Service,
 	filetracker filetracker.Service,
@@ -79,15 +94,19 @@ func NewEditTool(
 			var response fantasy.ToolResponse
 			var err error
 
-			editCtx := editContext{ctx, permissions, files, filetracker, workingDir}
+			editCtx := editContext{ctx, permissions, files, filetracker, workingDir, formatters, owners, headers}
 
-			if params.OldString == "" {
+			unlock := lockFile(ctx, params.FilePath)
+			if params.OldString == "" && isPatch(params.NewString) && isFile(params.FilePath) {
+				response, err = patchContent(editCtx, params.FilePath, params.NewString, call)
+			} else if params.OldString == "" {
 				response, err = createNewFile(editCtx, params.FilePath, params.NewString, call)
 			} else if params.NewString == "" {
 				response, err = deleteContent(editCtx, params.FilePath, params.OldString, params.ReplaceAll, call)
 			} else {
 				response, err = replaceContent(editCtx, params.FilePath, params.OldString, params.NewString, params.ReplaceAll, call)
 			}
+			unlock()
 
 			if err != nil {
 				return response, err
@@ -102,11 +121,20 @@ func NewEditTool(
 
 			text := fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
 			text += getDiagnostics(params.FilePath, lspManager)
+			text += checks.Check(ctx, params.FilePath)
+			text += ownershipNote(owners, workingDir, params.FilePath)
+			text += headerNote(headers, params.FilePath)
 			response.Content = text
 			return response, nil
 		})
 }
 
+// isFile reports whether path is an existing regular file.
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
 func createNewFile(edit editContext, filePath, content string, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 	fileInfo, err := os.Stat(filePath)
 	if err == nil {
@@ -118,25 +146,31 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to access file: %w", err)
 	}
 
-	dir := filepath.Dir(filePath)
-	if err = os.MkdirAll(dir, 0o755); err != nil {
-		return fantasy.ToolResponse{}, fmt.Errorf("failed to create parent directories: %w", err)
-	}
-
 	sessionID := GetSessionFromContext(edit.ctx)
 	if sessionID == "" {
 		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for creating a new file")
 	}
 
+	content = edit.headers.Apply(filePath, content)
+	content = formatContent(edit.ctx, edit.formatters, filePath, content)
 	_, additions, removals := diff.GenerateDiff(
 		"",
 		content,
 		strings.TrimPrefix(filePath, edit.workingDir),
 	)
+	if resp, held := holdChange(edit.ctx, call, filePath, "", content, edit.workingDir, EditResponseMetadata{
+		NewContent: content,
+		Additions:  additions,
+		Removals:   removals,
+	}); held {
+		return resp, nil
+	}
+
 	p, err := edit.permissions.Request(edit.ctx,
-		permission.CreatePermissionRequest{
+		withOwnership(edit.owners, permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
+			Target:      filePath,
 			ToolCallID:  call.ID,
 			ToolName:    EditToolName,
 			Action:      "write",
@@ -146,7 +180,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 				OldContent: "",
 				NewContent: content,
 			},
-		},
+		}, filePath),
 	)
 	if err != nil {
 		return fantasy.ToolResponse{}, err
@@ -155,7 +189,12 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
-	err = os.WriteFile(filePath, []byte(content), 0o644)
+	dir := filepath.Dir(filePath)
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("failed to create parent directories: %w", err)
+	}
+
+	err = writeFileOrSudo(edit.ctx, edit.permissions, call, filePath, []byte(content), 0o644)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
@@ -188,137 +227,62 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 }
 
 func deleteContent(edit editContext, filePath, oldString string, replaceAll bool, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
-	fileInfo, err := os.Stat(filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return fantasy.NewTextErrorResponse(fmt.Sprintf("file not found: %s", filePath)), nil
-		}
-		return fantasy.ToolResponse{}, fmt.Errorf("failed to access file: %w", err)
-	}
-
-	if fileInfo.IsDir() {
-		return fantasy.NewTextErrorResponse(fmt.Sprintf("path is a directory, not a file: %s", filePath)), nil
-	}
-
-	sessionID := GetSessionFromContext(edit.ctx)
-	if sessionID == "" {
-		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for deleting content")
-	}
-
-	lastRead := edit.filetracker.LastReadTime(edit.ctx, sessionID, filePath)
-	if lastRead.IsZero() {
-		return fantasy.NewTextErrorResponse("you must read the file before editing it. Use the View tool first"), nil
-	}
-
-	modTime := fileInfo.ModTime().Truncate(time.Second)
-	if modTime.After(lastRead) {
-		return fantasy.NewTextErrorResponse(
-			fmt.Sprintf("file %s has been modified since it was last read (mod time: %s, last read: %s)",
-				filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339),
-			)), nil
-	}
-
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return fantasy.ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
-	}
-
-	oldContent, isCrlf := fsext.ToUnixLineEndings(string(content))
-
-	var newContent string
-
-	if replaceAll {
-		newContent = strings.ReplaceAll(oldContent, oldString, "")
-		if newContent == oldContent {
-			return oldStringNotFoundErr, nil
-		}
-	} else {
-		index := strings.Index(oldContent, oldString)
-		if index == -1 {
-			return oldStringNotFoundErr, nil
+	return rewriteFile(edit, filePath, call, "Delete content from file", "Content deleted from file", func(oldContent string) (string, string, *fantasy.ToolResponse) {
+		if replaceAll {
+			newContent := strings.ReplaceAll(oldContent, oldString, "")
+			if newContent == oldContent {
+				return "", "", &oldStringNotFoundErr
+			}
+			return newContent, matchExact, nil
 		}
+		return replaceMatch(oldContent, oldString, "")
+	})
+}
 
-		lastIndex := strings.LastIndex(oldContent, oldString)
-		if index != lastIndex {
-			return fantasy.NewTextErrorResponse("old_string appears multiple times in the file. Please provide more context to ensure a unique match, or set replace_all to true"), nil
+func replaceContent(edit editContext, filePath, oldString, newString string, replaceAll bool, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	return rewriteFile(edit, filePath, call, "Replace content in file", "Content replaced in file", func(oldContent string) (string, string, *fantasy.ToolResponse) {
+		if replaceAll {
+			return strings.ReplaceAll(oldContent, oldString, newString), matchExact, nil
 		}
+		return replaceMatch(oldContent, oldString, newString)
+	})
+}
 
-		newContent = oldContent[:index] + oldContent[index+len(oldString):]
-	}
-
-	_, additions, removals := diff.GenerateDiff(
-		oldContent,
-		newContent,
-		strings.TrimPrefix(filePath, edit.workingDir),
-	)
-
-	p, err := edit.permissions.Request(edit.ctx,
-		permission.CreatePermissionRequest{
-			SessionID:   sessionID,
-			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
-			ToolCallID:  call.ID,
-			ToolName:    EditToolName,
-			Action:      "write",
-			Description: fmt.Sprintf("Delete content from file %s", filePath),
-			Params: EditPermissionsParams{
-				FilePath:   filePath,
-				OldContent: oldContent,
-				NewContent: newContent,
-			},
-		},
-	)
-	if err != nil {
-		return fantasy.ToolResponse{}, err
-	}
-	if !p {
-		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
-	}
-
-	if isCrlf {
-		newContent, _ = fsext.ToWindowsLineEndings(newContent)
-	}
-
-	err = os.WriteFile(filePath, []byte(newContent), 0o644)
-	if err != nil {
-		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
-	}
-
-	// Check if file exists in history
-	file, err := edit.files.GetByPathAndSession(edit.ctx, filePath, sessionID)
-	if err != nil {
-		_, err = edit.files.Create(edit.ctx, sessionID, filePath, oldContent)
-		if err != nil {
-			// Log error but don't fail the operation
-			return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
-		}
-	}
-	if file.Content != oldContent {
-		// User manually changed the content; store an intermediate version
-		_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, oldContent)
+// patchContent applies the unified diff patch to the file.
+func patchContent(edit editContext, filePath, patch string, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	return rewriteFile(edit, filePath, call, "Patch file", "Patch applied to file", func(oldContent string) (string, string, *fantasy.ToolResponse) {
+		newContent, strategy, err := applyPatch(oldContent, patch)
 		if err != nil {
-			slog.Error("Error creating file history version", "error", err)
+			resp := fantasy.NewTextErrorResponse(fmt.Sprintf("failed to apply patch: %s. Read the file again and make sure the context and removed lines of each hunk match it.", err))
+			return "", "", &resp
 		}
-	}
-	// Store the new version
-	_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, newContent)
-	if err != nil {
-		slog.Error("Error creating file history version", "error", err)
-	}
-
-	edit.filetracker.RecordRead(edit.ctx, sessionID, filePath)
+		return newContent, strategy, nil
+	})
+}
 
-	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("Content deleted from file: "+filePath),
-		EditResponseMetadata{
-			OldContent: oldContent,
-			NewContent: newContent,
-			Additions:  additions,
-			Removals:   removals,
-		},
-	), nil
+// replaceMatch replaces the one match of oldString in oldContent with
+// newString, falling back to looser matches when there is no exact one.
+func replaceMatch(oldContent, oldString, newString string) (string, string, *fantasy.ToolResponse) {
+	start, end, replacement, strategy, err := matchOldString(oldContent, oldString, newString)
+	switch {
+	case errors.Is(err, errManyMatch):
+		return "", "", &oldStringMultipleMatchesErr
+	case err != nil:
+		return "", "", &oldStringNotFoundErr
+	}
+	return oldContent[:start] + replacement + oldContent[end:], strategy, nil
 }
 
-func replaceContent(edit editContext, filePath, oldString, newString string, replaceAll bool, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+// rewriteFile changes the content of an existing file to what change returns
+// for its current content, along with the strategy it matched with or an
+// error response.
+func rewriteFile(
+	edit editContext,
+	filePath string,
+	call fantasy.ToolCall,
+	description, done string,
+	change func(oldContent string) (newContent, strategy string, errResp *fantasy.ToolResponse),
+) (fantasy.ToolResponse, error) {
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -342,61 +306,59 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 	}
 
 	modTime := fileInfo.ModTime().Truncate(time.Second)
-	if modTime.After(lastRead) {
+	if modTime.After(lastRead) || edit.filetracker.Changed(edit.ctx, sessionID, filePath) {
 		return fantasy.NewTextErrorResponse(
 			fmt.Sprintf("file %s has been modified since it was last read (mod time: %s, last read: %s)",
 				filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339),
 			)), nil
 	}
 
-	content, err := os.ReadFile(filePath)
+	content, err := readFile(edit.ctx, filePath)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
 	}
 
 	oldContent, isCrlf := fsext.ToUnixLineEndings(string(content))
 
-	var newContent string
-
-	if replaceAll {
-		newContent = strings.ReplaceAll(oldContent, oldString, newString)
-	} else {
-		index := strings.Index(oldContent, oldString)
-		if index == -1 {
-			return oldStringNotFoundErr, nil
-		}
-
-		lastIndex := strings.LastIndex(oldContent, oldString)
-		if index != lastIndex {
-			return oldStringMultipleMatchesErr, nil
-		}
-
-		newContent = oldContent[:index] + newString + oldContent[index+len(oldString):]
+	newContent, strategy, errResp := change(oldContent)
+	if errResp != nil {
+		return *errResp, nil
 	}
 
 	if oldContent == newContent {
 		return fantasy.NewTextErrorResponse("new content is the same as old content. No changes made."), nil
 	}
+	newContent = formatContent(edit.ctx, edit.formatters, filePath, newContent)
 	_, additions, removals := diff.GenerateDiff(
 		oldContent,
 		newContent,
 		strings.TrimPrefix(filePath, edit.workingDir),
 	)
+	if resp, held := holdChange(edit.ctx, call, filePath, oldContent, newContent, edit.workingDir, EditResponseMetadata{
+		OldContent: oldContent,
+		NewContent: newContent,
+		Additions:  additions,
+		Removals:   removals,
+		Strategy:   strategy,
+	}); held {
+		return resp, nil
+	}
 
 	p, err := edit.permissions.Request(edit.ctx,
-		permission.CreatePermissionRequest{
+		withOwnership(edit.owners, permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
+			Target:      filePath,
 			ToolCallID:  call.ID,
 			ToolName:    EditToolName,
 			Action:      "write",
-			Description: fmt.Sprintf("Replace content in file %s", filePath),
+			Description: fmt.Sprintf("%s %s", description, filePath),
 			Params: EditPermissionsParams{
 				FilePath:   filePath,
 				OldContent: oldContent,
 				NewContent: newContent,
 			},
-		},
+		}, filePath),
 	)
 	if err != nil {
 		return fantasy.ToolResponse{}, err
@@ -409,7 +371,7 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 		newContent, _ = fsext.ToWindowsLineEndings(newContent)
 	}
 
-	err = os.WriteFile(filePath, []byte(newContent), 0o644)
+	err = writeFileOrSudo(edit.ctx, edit.permissions, call, filePath, []byte(newContent), 0o644)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
@@ -438,12 +400,17 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 
 	edit.filetracker.RecordRead(edit.ctx, sessionID, filePath)
 
+	text := done + ": " + filePath
+	if strategy != matchExact {
+		text += fmt.Sprintf(" (matched with the %s strategy)", strategy)
+	}
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("Content replaced in file: "+filePath),
+		fantasy.NewTextResponse(text),
 		EditResponseMetadata{
 			OldContent: oldContent,
 			NewContent: newContent,
 			Additions:  additions,
 			Removals:   removals,
+			Strategy:   strategy,
 		}), nil
 }
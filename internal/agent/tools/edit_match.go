@@ -0,0 +1,325 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The strategies the edit tool finds the text to change with, reported in
+// its result when they are not an exact match.
+const (
+	matchExact      = "exact"
+	matchWhitespace = "whitespace-insensitive"
+	matchFuzzy      = "fuzzy"
+	matchPatch      = "unified diff"
+)
+
+// fuzzyThreshold is the similarity, from 0 to 1, lines of the file need to
+// old_string for a fuzzy match.
+const fuzzyThreshold = 0.9
+
+var (
+	errNoMatch    = errors.New("no match")
+	errManyMatch  = errors.New("multiple matches")
+	hunkHeaderRex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+)
+
+// matchOldString finds the one place of content that oldString stands for:
+// exactly, else on whole lines ignoring differences in whitespace, else on
+// whole lines similar enough to it. It returns the span of content to
+// replace, newString re-indented to the indentation of the file when the
+// match was not exact, and the strategy that matched.
+func matchOldString(content, oldString, newString string) (start, end int, replacement, strategy string, err error) {
+	if index := strings.Index(content, oldString); index != -1 {
+		if index != strings.LastIndex(content, oldString) {
+			return 0, 0, "", "", errManyMatch
+		}
+		return index, index + len(oldString), newString, matchExact, nil
+	}
+
+	lines := splitLines(content)
+	oldLines := strings.Split(strings.TrimSuffix(oldString, "\n"), "\n")
+	for _, strategy := range []string{matchWhitespace, matchFuzzy} {
+		if strategy == matchFuzzy && len(oldLines) < 2 {
+			// A single line is too little to tell a typo from another line.
+			break
+		}
+		first, err := matchLines(lines, oldLines, strategy)
+		if errors.Is(err, errNoMatch) {
+			continue
+		}
+		if err != nil {
+			return 0, 0, "", "", err
+		}
+		start = lineOffset(lines, first)
+		end = lineOffset(lines, first+len(oldLines))
+		if !strings.HasSuffix(oldString, "\n") && strings.HasSuffix(content[start:end], "\n") {
+			end--
+		}
+		replacement = reindent(newString, leadingSpace(oldLines[0]), leadingSpace(lines[first]))
+		return start, end, replacement, strategy, nil
+	}
+	return 0, 0, "", "", errNoMatch
+}
+
+// matchLines returns the index of the only run of lines matching oldLines
+// with strategy.
+func matchLines(lines, oldLines []string, strategy string) (int, error) {
+	found := -1
+	for i := 0; i+len(oldLines) <= len(lines); i++ {
+		var ok bool
+		switch strategy {
+		case matchWhitespace:
+			ok = sameIgnoringSpace(lines[i:i+len(oldLines)], oldLines)
+		case matchFuzzy:
+			ok = similarLines(lines[i:i+len(oldLines)], oldLines)
+		}
+		if !ok {
+			continue
+		}
+		if found != -1 {
+			return 0, errManyMatch
+		}
+		found = i
+	}
+	if found == -1 {
+		return 0, errNoMatch
+	}
+	return found, nil
+}
+
+func sameIgnoringSpace(a, b []string) bool {
+	for i := range a {
+		if normalizeSpace(a[i]) != normalizeSpace(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// similarLines reports whether the lines of a are, on average, at least
+// fuzzyThreshold similar to those of b, ignoring whitespace.
+func similarLines(a, b []string) bool {
+	var total float64
+	for i := range a {
+		total += similarity(normalizeSpace(a[i]), normalizeSpace(b[i]))
+		// Give up once the remaining lines can no longer make up for it.
+		if total+float64(len(a)-i-1) < fuzzyThreshold*float64(len(a)) {
+			return false
+		}
+	}
+	return true
+}
+
+// similarity returns 1 minus the edit distance of a and b relative to the
+// longer of them.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func leadingSpace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
+
+// reindent moves the lines of text indented with from to be indented with
+// to instead.
+func reindent(text, from, to string) string {
+	if from == to {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if rest, ok := strings.CutPrefix(line, from); ok && line != "" {
+			lines[i] = to + rest
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitLines splits content into its lines, each without its newline.
+func splitLines(content string) []string {
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// lineOffset returns the offset in the content split into lines where line
+// n starts.
+func lineOffset(lines []string, n int) int {
+	offset := 0
+	for _, line := range lines[:n] {
+		offset += len(line) + 1
+	}
+	return offset
+}
+
+// patchHunk is one hunk of a unified diff: line is the line of the file it
+// starts at, and old and new are its lines before and after the change.
+type patchHunk struct {
+	line     int
+	old, new []string
+}
+
+// isPatch reports whether text looks like a unified diff.
+func isPatch(text string) bool {
+	for line := range strings.SplitSeq(text, "\n") {
+		if hunkHeaderRex.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePatch reads the hunks of the unified diff text, skipping its file
+// headers.
+func parsePatch(text string) ([]patchHunk, error) {
+	var hunks []patchHunk
+	var hunk *patchHunk
+	for line := range strings.SplitSeq(strings.TrimSuffix(text, "\n"), "\n") {
+		if m := hunkHeaderRex.FindStringSubmatch(line); m != nil {
+			start, _ := strconv.Atoi(m[1])
+			if start > 0 {
+				start--
+			}
+			hunks = append(hunks, patchHunk{line: start})
+			hunk = &hunks[len(hunks)-1]
+			continue
+		}
+		if hunk == nil {
+			// "diff --git", "---", "+++" and other headers.
+			continue
+		}
+		switch {
+		case line == "":
+			// Editors and models often strip the space of empty context
+			// lines.
+			hunk.old = append(hunk.old, "")
+			hunk.new = append(hunk.new, "")
+		case line[0] == ' ':
+			hunk.old = append(hunk.old, line[1:])
+			hunk.new = append(hunk.new, line[1:])
+		case line[0] == '-':
+			hunk.old = append(hunk.old, line[1:])
+		case line[0] == '+':
+			hunk.new = append(hunk.new, line[1:])
+		case line[0] == '\\':
+			// "\ No newline at end of file".
+		default:
+			return nil, fmt.Errorf("invalid line in hunk %d: %q", len(hunks), line)
+		}
+	}
+	if len(hunks) == 0 {
+		return nil, errors.New("no hunks found")
+	}
+	return hunks, nil
+}
+
+// applyPatch applies the hunks of the unified diff patch to content. Each
+// hunk is looked for exactly, else ignoring whitespace, closest to the line
+// it says it starts at; the strategy returned tells which was needed.
+func applyPatch(content, patch string) (string, string, error) {
+	hunks, err := parsePatch(patch)
+	if err != nil {
+		return "", "", err
+	}
+	trailingNewline := content == "" || strings.HasSuffix(content, "\n")
+	lines := splitLines(content)
+	if content == "" {
+		lines = nil
+	}
+
+	strategy := matchPatch
+	// offset is how many lines the hunks applied so far added.
+	offset, from := 0, 0
+	var out []string
+	for i, hunk := range hunks {
+		at, exact := findHunk(lines, hunk, from, hunk.line+offset)
+		if at == -1 {
+			return "", "", fmt.Errorf("hunk %d does not apply: its lines were not found in the file", i+1)
+		}
+		if !exact {
+			strategy = matchPatch + ", " + matchWhitespace
+		}
+		out = append(out, lines[from:at]...)
+		out = append(out, hunk.new...)
+		from = at + len(hunk.old)
+		offset += len(hunk.new) - len(hunk.old)
+	}
+	out = append(out, lines[from:]...)
+
+	result := strings.Join(out, "\n")
+	if trailingNewline && len(out) > 0 {
+		result += "\n"
+	}
+	return result, strategy, nil
+}
+
+// findHunk returns the line at or after from where the old lines of hunk
+// are, closest to hint, and whether they matched exactly.
+func findHunk(lines []string, hunk patchHunk, from, hint int) (int, bool) {
+	if len(hunk.old) == 0 {
+		return min(max(hint, from), len(lines)), true
+	}
+	for _, exact := range []bool{true, false} {
+		best := -1
+		for i := from; i+len(hunk.old) <= len(lines); i++ {
+			window := lines[i : i+len(hunk.old)]
+			if exact && !equalLines(window, hunk.old) || !exact && !sameIgnoringSpace(window, hunk.old) {
+				continue
+			}
+			if best == -1 || abs(i-hint) < abs(best-hint) {
+				best = i
+			}
+		}
+		if best != -1 {
+			return best, exact
+		}
+	}
+	return -1, false
+}
+
+func equalLines(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
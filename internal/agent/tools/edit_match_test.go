@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const editMatchSource = "func main() {\n\tif ok {\n\t\tfmt.Println(\"hello\")\n\t\treturn\n\t}\n\tfmt.Println(\"bye\")\n}\n"
+
+func TestMatchOldString(t *testing.T) {
+	t.Parallel()
+
+	replace := func(t *testing.T, oldString, newString string) (string, string, error) {
+		t.Helper()
+		start, end, replacement, strategy, err := matchOldString(editMatchSource, oldString, newString)
+		if err != nil {
+			return "", "", err
+		}
+		return editMatchSource[:start] + replacement + editMatchSource[end:], strategy, nil
+	}
+
+	t.Run("exact", func(t *testing.T) {
+		t.Parallel()
+		got, strategy, err := replace(t, `"bye"`, `"goodbye"`)
+		require.NoError(t, err)
+		require.Equal(t, matchExact, strategy)
+		require.Contains(t, got, "\tfmt.Println(\"goodbye\")\n")
+	})
+
+	t.Run("whitespace differences are reindented", func(t *testing.T) {
+		t.Parallel()
+		got, strategy, err := replace(t, "    fmt.Println(\"hello\")\n    return", "    fmt.Println(\"hi\")\n    return")
+		require.NoError(t, err)
+		require.Equal(t, matchWhitespace, strategy)
+		require.Equal(t, "func main() {\n\tif ok {\n\t\tfmt.Println(\"hi\")\n\t\treturn\n\t}\n\tfmt.Println(\"bye\")\n}\n", got)
+	})
+
+	t.Run("fuzzy", func(t *testing.T) {
+		t.Parallel()
+		got, strategy, err := replace(t, "\tif ok {\n\t\tfmt.Println(\"helo\")\n\t\treturn\n\t}", "\tif ok {\n\t\treturn\n\t}")
+		require.NoError(t, err)
+		require.Equal(t, matchFuzzy, strategy)
+		require.Equal(t, "func main() {\n\tif ok {\n\t\treturn\n\t}\n\tfmt.Println(\"bye\")\n}\n", got)
+	})
+
+	t.Run("single lines are not fuzzy matched", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := replace(t, `fmt.Println("helo")`, `fmt.Println("hi")`)
+		require.ErrorIs(t, err, errNoMatch)
+	})
+
+	t.Run("multiple matches", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := replace(t, "fmt.Println", "log.Println")
+		require.ErrorIs(t, err, errManyMatch)
+	})
+}
+
+func TestApplyPatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("exact", func(t *testing.T) {
+		t.Parallel()
+		patch := "--- a/main.go\n+++ b/main.go\n@@ -2,4 +2,5 @@\n \tif ok {\n-\t\tfmt.Println(\"hello\")\n+\t\tfmt.Println(\"hi\")\n+\t\tfmt.Println(\"there\")\n \t\treturn\n \t}\n"
+		got, strategy, err := applyPatch(editMatchSource, patch)
+		require.NoError(t, err)
+		require.Equal(t, matchPatch, strategy)
+		require.Equal(t, "func main() {\n\tif ok {\n\t\tfmt.Println(\"hi\")\n\t\tfmt.Println(\"there\")\n\t\treturn\n\t}\n\tfmt.Println(\"bye\")\n}\n", got)
+	})
+
+	t.Run("several hunks with wrong line numbers", func(t *testing.T) {
+		t.Parallel()
+		patch := "@@ -10,1 +10,1 @@\n-func main() {\n+func run() {\n@@ -40,2 +40,2 @@\n-\tfmt.Println(\"bye\")\n+\tfmt.Println(\"done\")\n }\n"
+		got, _, err := applyPatch(editMatchSource, patch)
+		require.NoError(t, err)
+		require.Equal(t, "func run() {\n\tif ok {\n\t\tfmt.Println(\"hello\")\n\t\treturn\n\t}\n\tfmt.Println(\"done\")\n}\n", got)
+	})
+
+	t.Run("whitespace-insensitive", func(t *testing.T) {
+		t.Parallel()
+		patch := "@@ -6 +6 @@\n-    fmt.Println(\"bye\")\n+\tfmt.Println(\"done\")\n"
+		got, strategy, err := applyPatch(editMatchSource, patch)
+		require.NoError(t, err)
+		require.Equal(t, matchPatch+", "+matchWhitespace, strategy)
+		require.Contains(t, got, "\tfmt.Println(\"done\")\n}\n")
+	})
+
+	t.Run("hunk not found", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := applyPatch(editMatchSource, "@@ -1 +1 @@\n-func other() {\n+func main() {\n")
+		require.ErrorContains(t, err, "hunk 1 does not apply")
+	})
+
+	t.Run("not a patch", func(t *testing.T) {
+		t.Parallel()
+		require.False(t, isPatch("package main\n"))
+		require.True(t, isPatch("diff --git a/x b/x\n@@ -1,2 +1,2 @@\n"))
+	})
+}
@@ -0,0 +1,413 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/buildcheck"
+	"github.com/charmbracelet/crush/internal/codeowners"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/fileheader"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/formatter"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type EditSymbolParams struct {
+	FilePath string `json:"file_path" description:"The absolute path to the Go file to modify"`
+	Symbol   string `json:"symbol" description:"The declaration to edit: a function, type, constant or variable name, or Type.Method for a method"`
+	Action   string `json:"action,omitempty" description:"What to do with the declaration: replace (default), delete, insert_before or insert_after"`
+	Content  string `json:"content,omitempty" description:"The new source: the whole declaration with its doc comment for replace, or the declarations to insert"`
+}
+
+const (
+	EditSymbolToolName = "edit_symbol"
+
+	symbolReplace      = "replace"
+	symbolDelete       = "delete"
+	symbolInsertBefore = "insert_before"
+	symbolInsertAfter  = "insert_after"
+
+	// symbolListLimit caps how many declarations are suggested when the
+	// symbol is not found.
+	symbolListLimit = 40
+)
+
+//go:embed edit_symbol.md
+var editSymbolDescription []byte
+
+func NewEditSymbolTool(
+	lspManager *lsp.Manager,
+	formatters *formatter.Runner,
+	checks *buildcheck.Runner,
+	owners *codeowners.Checker,
+	headers *fileheader.Policy,
+	permissions permission.Service,
+	files history.Service,
+	filetracker filetracker.Service,
+	workingDir string,
+) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		EditSymbolToolName,
+		string(editSymbolDescription),
+		func(ctx context.Context, params EditSymbolParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.FilePath == "" {
+				return fantasy.NewTextErrorResponse("file_path is required"), nil
+			}
+			if params.Symbol == "" {
+				return fantasy.NewTextErrorResponse("symbol is required"), nil
+			}
+			if params.Action == "" {
+				params.Action = symbolReplace
+			}
+			if !slices.Contains([]string{symbolReplace, symbolDelete, symbolInsertBefore, symbolInsertAfter}, params.Action) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("unknown action %q: use replace, delete, insert_before or insert_after", params.Action)), nil
+			}
+			if params.Action != symbolDelete && strings.TrimSpace(params.Content) == "" {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("content is required to %s a declaration", strings.ReplaceAll(params.Action, "_", " "))), nil
+			}
+			if filepath.Ext(params.FilePath) != ".go" {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("edit_symbol only edits Go (.go) files, not %s; use edit or multiedit for other languages", filepath.Base(params.FilePath))), nil
+			}
+
+			params.FilePath = filepathext.SmartJoin(workingDir, params.FilePath)
+
+			editCtx := editContext{ctx, permissions, files, filetracker, workingDir, formatters, owners, headers}
+
+			unlock := lockFile(ctx, params.FilePath)
+			response, err := editSymbol(editCtx, params, call)
+			unlock()
+
+			if err != nil || response.IsError {
+				return response, err
+			}
+
+			notifyLSPs(ctx, lspManager, params.FilePath)
+
+			text := fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
+			text += getDiagnostics(params.FilePath, lspManager)
+			text += checks.Check(ctx, params.FilePath)
+			text += ownershipNote(owners, workingDir, params.FilePath)
+			text += headerNote(headers, params.FilePath)
+			response.Content = text
+			return response, nil
+		})
+}
+
+func editSymbol(edit editContext, params EditSymbolParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	filePath := params.FilePath
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fantasy.NewTextErrorResponse(fmt.Sprintf("file not found: %s", filePath)), nil
+		}
+		return fantasy.ToolResponse{}, fmt.Errorf("failed to access file: %w", err)
+	}
+	if fileInfo.IsDir() {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("path is a directory, not a file: %s", filePath)), nil
+	}
+
+	sessionID := GetSessionFromContext(edit.ctx)
+	if sessionID == "" {
+		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for editing a file")
+	}
+
+	lastRead := edit.filetracker.LastReadTime(edit.ctx, sessionID, filePath)
+	if lastRead.IsZero() {
+		return fantasy.NewTextErrorResponse("you must read the file before editing it. Use the View tool first"), nil
+	}
+
+	modTime := fileInfo.ModTime().Truncate(time.Second)
+	if modTime.After(lastRead) || edit.filetracker.Changed(edit.ctx, sessionID, filePath) {
+		return fantasy.NewTextErrorResponse(
+			fmt.Sprintf("file %s has been modified since it was last read (mod time: %s, last read: %s)",
+				filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339),
+			)), nil
+	}
+
+	content, err := readFile(edit.ctx, filePath)
+	if err != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	oldContent, isCrlf := fsext.ToUnixLineEndings(string(content))
+	newContent, err := applySymbolEdit(oldContent, params.Symbol, params.Action, params.Content)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(err.Error()), nil
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), filePath, newContent, parser.SkipObjectResolution); err != nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("the edit would leave the file with a syntax error, so it was not made: %v", err)), nil
+	}
+	if oldContent == newContent {
+		return fantasy.NewTextErrorResponse("new content is the same as old content. No changes made."), nil
+	}
+	newContent = formatContent(edit.ctx, edit.formatters, filePath, newContent)
+
+	_, additions, removals := diff.GenerateDiff(
+		oldContent,
+		newContent,
+		strings.TrimPrefix(filePath, edit.workingDir),
+	)
+	if resp, held := holdChange(edit.ctx, call, filePath, oldContent, newContent, edit.workingDir, EditResponseMetadata{
+		OldContent: oldContent,
+		NewContent: newContent,
+		Additions:  additions,
+		Removals:   removals,
+	}); held {
+		return resp, nil
+	}
+
+	p, err := edit.permissions.Request(edit.ctx,
+		withOwnership(edit.owners, permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
+			Target:      filePath,
+			ToolCallID:  call.ID,
+			ToolName:    EditSymbolToolName,
+			Action:      "write",
+			Description: fmt.Sprintf("Edit %s in file %s", params.Symbol, filePath),
+			Params: EditPermissionsParams{
+				FilePath:   filePath,
+				OldContent: oldContent,
+				NewContent: newContent,
+			},
+		}, filePath),
+	)
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	if !p {
+		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	if isCrlf {
+		newContent, _ = fsext.ToWindowsLineEndings(newContent)
+	}
+
+	err = writeFileOrSudo(edit.ctx, edit.permissions, call, filePath, []byte(newContent), 0o644)
+	if err != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	// Check if file exists in history
+	file, err := edit.files.GetByPathAndSession(edit.ctx, filePath, sessionID)
+	if err != nil {
+		_, err = edit.files.Create(edit.ctx, sessionID, filePath, oldContent)
+		if err != nil {
+			return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
+		}
+	}
+	if file.Content != oldContent {
+		// User manually changed the content; store an intermediate version
+		_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, oldContent)
+		if err != nil {
+			slog.Debug("Error creating file history version", "error", err)
+		}
+	}
+	// Store the new version
+	_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, newContent)
+	if err != nil {
+		slog.Error("Error creating file history version", "error", err)
+	}
+
+	edit.filetracker.RecordRead(edit.ctx, sessionID, filePath)
+
+	return fantasy.WithResponseMetadata(
+		fantasy.NewTextResponse(fmt.Sprintf("%s %s in file: %s", symbolActionDone(params.Action), params.Symbol, filePath)),
+		EditResponseMetadata{
+			OldContent: oldContent,
+			NewContent: newContent,
+			Additions:  additions,
+			Removals:   removals,
+		}), nil
+}
+
+func symbolActionDone(action string) string {
+	switch action {
+	case symbolDelete:
+		return "Deleted"
+	case symbolInsertBefore:
+		return "Inserted before"
+	case symbolInsertAfter:
+		return "Inserted after"
+	default:
+		return "Replaced"
+	}
+}
+
+// goDecl is a top-level declaration of a Go file, spanning whole lines from
+// its doc comment to its end.
+type goDecl struct {
+	name       string
+	start, end int // Byte offsets; end is after the last newline.
+}
+
+// applySymbolEdit applies action with text to the declaration of symbol in
+// the Go source src.
+func applySymbolEdit(src, symbol, action, text string) (string, error) {
+	decls, err := goDecls(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the file, fix it with edit first: %w", err)
+	}
+	decl, err := findDecl(decls, symbol)
+	if err != nil {
+		return "", err
+	}
+
+	text = strings.TrimRight(text, "\n") + "\n"
+	switch action {
+	case symbolReplace:
+		return src[:decl.start] + strings.TrimLeft(text, "\n") + src[decl.end:], nil
+	case symbolDelete:
+		before, after := src[:decl.start], src[decl.end:]
+		// Drop the blank line that separated the declaration from the next.
+		if (before == "" || strings.HasSuffix(before, "\n\n")) && strings.HasPrefix(after, "\n") {
+			after = after[1:]
+		}
+		return before + after, nil
+	case symbolInsertBefore:
+		return src[:decl.start] + strings.TrimLeft(text, "\n") + "\n" + src[decl.start:], nil
+	case symbolInsertAfter:
+		return src[:decl.end] + "\n" + strings.TrimLeft(text, "\n") + src[decl.end:], nil
+	default:
+		return "", fmt.Errorf("unknown action %q: use replace, delete, insert_before or insert_after", action)
+	}
+}
+
+// findDecl returns the declaration named symbol. Methods match Type.Method,
+// or just Method when no other declaration has that name.
+func findDecl(decls []goDecl, symbol string) (goDecl, error) {
+	var matches []goDecl
+	for _, d := range decls {
+		if d.name == symbol {
+			matches = append(matches, d)
+		}
+	}
+	if len(matches) == 0 && !strings.Contains(symbol, ".") {
+		for _, d := range decls {
+			if _, method, ok := strings.Cut(d.name, "."); ok && method == symbol {
+				matches = append(matches, d)
+			}
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		names := make([]string, 0, len(decls))
+		for _, d := range decls {
+			if !slices.Contains(names, d.name) {
+				names = append(names, d.name)
+			}
+		}
+		if len(names) > symbolListLimit {
+			names = append(names[:symbolListLimit], "…")
+		}
+		return goDecl{}, fmt.Errorf("symbol %s not found. The file declares: %s", symbol, strings.Join(names, ", "))
+	default:
+		names := make([]string, 0, len(matches))
+		for _, d := range matches {
+			names = append(names, d.name)
+		}
+		return goDecl{}, fmt.Errorf("symbol %s matches %d declarations (%s); qualify methods as Type.Method or use edit", symbol, len(matches), strings.Join(names, ", "))
+	}
+}
+
+// goDecls lists the top-level declarations of the Go source src. A
+// declaration of a group, such as one constant of a const block, spans only
+// its own lines.
+func goDecls(src string) ([]goDecl, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	tf := fset.File(f.Pos())
+	span := func(doc *ast.CommentGroup, from, to token.Pos) (int, int) {
+		if doc != nil {
+			from = doc.Pos()
+		}
+		start := tf.Offset(from)
+		start = strings.LastIndex(src[:start], "\n") + 1
+		end := tf.Offset(to)
+		if i := strings.Index(src[end:], "\n"); i >= 0 {
+			end += i + 1
+		} else {
+			end = len(src)
+		}
+		return start, end
+	}
+
+	var decls []goDecl
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			name := decl.Name.Name
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				name = receiverTypeName(decl.Recv.List[0].Type) + "." + name
+			}
+			start, end := span(decl.Doc, decl.Pos(), decl.End())
+			decls = append(decls, goDecl{name: name, start: start, end: end})
+		case *ast.GenDecl:
+			grouped := decl.Lparen.IsValid()
+			for _, spec := range decl.Specs {
+				var names []string
+				var doc *ast.CommentGroup
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names, doc = []string{spec.Name.Name}, spec.Doc
+				case *ast.ValueSpec:
+					for _, n := range spec.Names {
+						names = append(names, n.Name)
+					}
+					doc = spec.Doc
+				default:
+					continue
+				}
+				var start, end int
+				if grouped {
+					start, end = span(doc, spec.Pos(), spec.End())
+				} else {
+					start, end = span(decl.Doc, decl.Pos(), decl.End())
+				}
+				for _, name := range names {
+					if name != "_" {
+						decls = append(decls, goDecl{name: name, start: start, end: end})
+					}
+				}
+			}
+		}
+	}
+	return decls, nil
+}
+
+// receiverTypeName returns the name of the type of a method receiver.
+func receiverTypeName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(expr.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	default:
+		return ""
+	}
+}
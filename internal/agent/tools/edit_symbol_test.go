@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditSymbolToolGoOnly(t *testing.T) {
+	t.Parallel()
+
+	tool := NewEditSymbolTool(nil, nil, nil, nil, nil, nil, nil, nil, t.TempDir())
+	resp := runTool(t, t.Context(), tool, EditSymbolParams{FilePath: "app.py", Symbol: "main", Content: "def main(): pass"})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "only edits Go (.go) files, not app.py")
+}
+
+const editSymbolSource = `package store
+
+import "errors"
+
+// ErrNotFound is returned for missing keys.
+var ErrNotFound = errors.New("not found")
+
+const (
+	// DefaultSize is the size of new stores.
+	DefaultSize = 16
+	maxSize     = 1024 // Hard limit.
+)
+
+// Store holds values.
+type Store struct {
+	items map[string]string
+}
+
+// New returns an empty store.
+func New() *Store {
+	return &Store{items: make(map[string]string, DefaultSize)}
+}
+
+// Get returns the value of key.
+func (s *Store) Get(key string) (string, error) {
+	v, ok := s.items[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func (s *Store) Len() int { return len(s.items) }
+`
+
+func TestApplySymbolEdit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("replace method", func(t *testing.T) {
+		t.Parallel()
+		got, err := applySymbolEdit(editSymbolSource, "Store.Get", symbolReplace, "// Get returns the value of key, or an empty string.\nfunc (s *Store) Get(key string) string {\n\treturn s.items[key]\n}")
+		require.NoError(t, err)
+		require.Contains(t, got, "// New returns an empty store.\nfunc New() *Store {\n\treturn &Store{items: make(map[string]string, DefaultSize)}\n}\n\n// Get returns the value of key, or an empty string.\nfunc (s *Store) Get(key string) string {\n\treturn s.items[key]\n}\n\nfunc (s *Store) Len()")
+		require.NotContains(t, got, "ErrNotFound\n\t}")
+	})
+
+	t.Run("unqualified method", func(t *testing.T) {
+		t.Parallel()
+		got, err := applySymbolEdit(editSymbolSource, "Len", symbolReplace, "func (s *Store) Len() int {\n\treturn len(s.items)\n}\n")
+		require.NoError(t, err)
+		require.Contains(t, got, "}\n\nfunc (s *Store) Len() int {\n\treturn len(s.items)\n}\n")
+	})
+
+	t.Run("delete with doc comment", func(t *testing.T) {
+		t.Parallel()
+		got, err := applySymbolEdit(editSymbolSource, "New", symbolDelete, "")
+		require.NoError(t, err)
+		require.NotContains(t, got, "func New")
+		require.Contains(t, got, "\titems map[string]string\n}\n\n// Get returns the value of key.")
+	})
+
+	t.Run("spec of a group", func(t *testing.T) {
+		t.Parallel()
+		got, err := applySymbolEdit(editSymbolSource, "maxSize", symbolReplace, "\tmaxSize     = 4096 // Hard limit.")
+		require.NoError(t, err)
+		require.Contains(t, got, "\tDefaultSize = 16\n\tmaxSize     = 4096 // Hard limit.\n)")
+	})
+
+	t.Run("insert", func(t *testing.T) {
+		t.Parallel()
+		got, err := applySymbolEdit(editSymbolSource, "Store", symbolInsertAfter, "// Option configures a store.\ntype Option func(*Store)")
+		require.NoError(t, err)
+		require.Contains(t, got, "\titems map[string]string\n}\n\n// Option configures a store.\ntype Option func(*Store)\n\n// New returns")
+
+		got, err = applySymbolEdit(editSymbolSource, "Store", symbolInsertBefore, "type Key string")
+		require.NoError(t, err)
+		require.Contains(t, got, ")\n\ntype Key string\n\n// Store holds values.\n")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+		_, err := applySymbolEdit(editSymbolSource, "Put", symbolDelete, "")
+		require.EqualError(t, err, "symbol Put not found. The file declares: ErrNotFound, DefaultSize, maxSize, Store, New, Store.Get, Store.Len")
+	})
+}
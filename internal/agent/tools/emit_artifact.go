@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/artifact"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+//go:embed emit_artifact.md
+var emitArtifactDescription []byte
+
+const EmitArtifactToolName = "emit_artifact"
+
+type EmitArtifactParams struct {
+	Name     string `json:"name" description:"File name of the artifact, such as report.md or diagrams/flow.png; subdirectories are allowed"`
+	Content  string `json:"content,omitempty" description:"Content of the artifact"`
+	Encoding string `json:"encoding,omitempty" description:"Set to base64 when content is base64 encoded binary data"`
+	FromPath string `json:"from_path,omitempty" description:"Path of an existing file to save as the artifact instead of content, such as an image rendered by a command"`
+}
+
+type EmitArtifactPermissionsParams struct {
+	Name     string `json:"name"`
+	FromPath string `json:"from_path"`
+}
+
+type EmitArtifactResponseMetadata struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+func NewEmitArtifactTool(store *artifact.Store, permissions permission.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		EmitArtifactToolName,
+		string(emitArtifactDescription),
+		func(ctx context.Context, params EmitArtifactParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if strings.TrimSpace(params.Name) == "" {
+				return fantasy.NewTextErrorResponse("name is required"), nil
+			}
+			if (params.Content == "") == (params.FromPath == "") {
+				return fantasy.NewTextErrorResponse("provide either content or from_path"), nil
+			}
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for emitting artifacts")
+			}
+
+			var r io.Reader
+			switch {
+			case params.FromPath != "":
+				path := filepathext.SmartJoin(workingDir, params.FromPath)
+				if fsext.HasPrefix(path, store.Dir(sessionID)) {
+					return fantasy.NewTextResponse(fmt.Sprintf("%s is in the artifacts directory, so it already is an artifact.", params.FromPath)), nil
+				}
+				if !readableWithoutPermission(path, workingDir) {
+					granted, err := permissions.Request(ctx, permission.CreatePermissionRequest{
+						SessionID:   sessionID,
+						Path:        path,
+						Target:      path,
+						ToolCallID:  call.ID,
+						ToolName:    EmitArtifactToolName,
+						Action:      "read",
+						Description: fmt.Sprintf("Save file outside working directory as an artifact: %s", path),
+						Params:      EmitArtifactPermissionsParams{Name: params.Name, FromPath: path},
+					})
+					if err != nil {
+						return fantasy.ToolResponse{}, err
+					}
+					if !granted {
+						return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+					}
+				}
+				f, err := os.Open(path)
+				if err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to open %s: %s", params.FromPath, err)), nil
+				}
+				defer f.Close() //nolint:errcheck
+				if info, err := f.Stat(); err == nil && info.IsDir() {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("%s is a directory", params.FromPath)), nil
+				}
+				r = f
+			case strings.EqualFold(params.Encoding, "base64"):
+				data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(params.Content))
+				if err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("content is not valid base64: %s", err)), nil
+				}
+				r = bytes.NewReader(data)
+			case params.Encoding != "":
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("unsupported encoding %q, only base64 is", params.Encoding)), nil
+			default:
+				r = strings.NewReader(params.Content)
+			}
+
+			a, err := store.Emit(sessionID, params.Name, r)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			msg := fmt.Sprintf(
+				"Saved artifact %s (%d bytes) to %s. The user can open and export it from the artifacts panel. Generated files belong in %s too, rather than the workspace.",
+				a.Name, a.Size, a.Path, store.Dir(sessionID),
+			)
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(msg), EmitArtifactResponseMetadata{
+				Name: a.Name,
+				Path: a.Path,
+				Size: a.Size,
+			}), nil
+		})
+}
+
+// readableWithoutPermission reports whether path is in one of the places
+// generated files are expected: the workspace or the temporary directory.
+func readableWithoutPermission(path string, dirs ...string) bool {
+	for _, dir := range append(dirs, os.TempDir()) {
+		if fsext.HasPrefix(path, dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/artifact"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitArtifactTool(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store := artifact.New(t.TempDir())
+	tool := NewEmitArtifactTool(store, &mockPermissionService{}, dir)
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+
+	resp := runTool(t, ctx, tool, EmitArtifactParams{Name: "report.md", Content: "# Findings\n"})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, store.Dir("session"))
+
+	resp = runTool(t, ctx, tool, EmitArtifactParams{
+		Name:     "logo.png",
+		Content:  base64.StdEncoding.EncodeToString([]byte{0x89, 'P', 'N', 'G'}),
+		Encoding: "base64",
+	})
+	require.False(t, resp.IsError, resp.Content)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "out.svg"), []byte("<svg/>"), 0o644))
+	resp = runTool(t, ctx, tool, EmitArtifactParams{Name: "diagrams/out.svg", FromPath: "out.svg"})
+	require.False(t, resp.IsError, resp.Content)
+
+	artifacts, err := store.List("session")
+	require.NoError(t, err)
+	contents := map[string]string{}
+	for _, a := range artifacts {
+		data, err := os.ReadFile(a.Path)
+		require.NoError(t, err)
+		contents[a.Name] = string(data)
+	}
+	require.Equal(t, map[string]string{
+		"report.md":        "# Findings\n",
+		"logo.png":         "\x89PNG",
+		"diagrams/out.svg": "<svg/>",
+	}, contents)
+
+	resp = runTool(t, ctx, tool, EmitArtifactParams{Name: "both.md", Content: "x", FromPath: "out.svg"})
+	require.True(t, resp.IsError)
+	resp = runTool(t, ctx, tool, EmitArtifactParams{Name: "../escape.md", Content: "x"})
+	require.True(t, resp.IsError)
+}
+
+func TestReadableWithoutPermission(t *testing.T) {
+	t.Parallel()
+
+	workingDir := filepath.Join(string(filepath.Separator), "work", "project")
+	require.True(t, readableWithoutPermission(filepath.Join(workingDir, "out.png"), workingDir))
+	require.True(t, readableWithoutPermission(filepath.Join(os.TempDir(), "out.png"), workingDir))
+	require.False(t, readableWithoutPermission(filepath.Join(string(filepath.Separator), "work", "other", "out.png"), workingDir))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -13,6 +14,7 @@ import (
 	"charm.land/fantasy"
 	md "github.com/JohannesKaufmann/html-to-markdown"
 	"github.com/PuerkitoBio/goquery"
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/permission"
 )
 
@@ -21,18 +23,19 @@ const FetchToolName = "fetch"
 //go:embed fetch.md
 var fetchDescription []byte
 
-func NewFetchTool(permissions permission.Service, workingDir string, client *http.Client) fantasy.AgentTool {
+func NewFetchTool(permissions permission.Service, workingDir string, opts config.ToolFetch, client *http.Client) fantasy.AgentTool {
 	if client == nil {
 		transport := http.DefaultTransport.(*http.Transport).Clone()
 		transport.MaxIdleConns = 100
 		transport.MaxIdleConnsPerHost = 10
 		transport.IdleConnTimeout = 90 * time.Second
 
+		// Requests are bounded by their own timeout instead.
 		client = &http.Client{
-			Timeout:   30 * time.Second,
 			Transport: transport,
 		}
 	}
+	robots := newRobotsCache(client)
 
 	return fantasy.NewParallelAgentTool(
 		FetchToolName,
@@ -50,6 +53,10 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 			if !strings.HasPrefix(params.URL, "http://") && !strings.HasPrefix(params.URL, "https://") {
 				return fantasy.NewTextErrorResponse("URL must start with http:// or https://"), nil
 			}
+			u, err := url.Parse(params.URL)
+			if err != nil {
+				return fantasy.NewTextErrorResponse("Invalid URL: " + err.Error()), nil
+			}
 
 			sessionID := GetSessionFromContext(ctx)
 			if sessionID == "" {
@@ -60,6 +67,7 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 				permission.CreatePermissionRequest{
 					SessionID:   sessionID,
 					Path:        workingDir,
+					Target:      params.URL,
 					ToolCallID:  call.ID,
 					ToolName:    FetchToolName,
 					Action:      "fetch",
@@ -78,14 +86,15 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 			const maxFetchTimeoutSeconds = 120
 
 			// Handle timeout with context
-			requestCtx := ctx
+			timeout := opts.GetTimeout()
 			if params.Timeout > 0 {
-				if params.Timeout > maxFetchTimeoutSeconds {
-					params.Timeout = maxFetchTimeoutSeconds
-				}
-				var cancel context.CancelFunc
-				requestCtx, cancel = context.WithTimeout(ctx, time.Duration(params.Timeout)*time.Second)
-				defer cancel()
+				timeout = time.Duration(min(params.Timeout, maxFetchTimeoutSeconds)) * time.Second
+			}
+			requestCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			if !opts.IgnoreRobots && !robots.Allowed(requestCtx, u) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("The robots.txt of %s disallows fetching %s", u.Host, params.URL)), nil
 			}
 
 			req, err := http.NewRequestWithContext(requestCtx, "GET", params.URL, nil)
@@ -105,16 +114,18 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("Request failed with status code: %d", resp.StatusCode)), nil
 			}
 
-			// maxFetchResponseSizeBytes is the maximum size of response body to read (5MB)
-			const maxFetchResponseSizeBytes = int64(5 * 1024 * 1024)
-
-			maxSize := maxFetchResponseSizeBytes
-			body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize))
+			maxSize := opts.GetMaxSize()
+			body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
 			if err != nil {
 				return fantasy.NewTextErrorResponse("Failed to read response body: " + err.Error()), nil
 			}
 
 			content := string(body)
+			truncated := int64(len(body)) > maxSize
+			if truncated {
+				// Drop the rune the limit cut through.
+				content = strings.ToValidUTF8(content[:maxSize], "")
+			}
 
 			validUTF8 := utf8.ValidString(content)
 			if !validUTF8 {
@@ -134,11 +145,11 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 
 			case "markdown":
 				if strings.Contains(contentType, "text/html") {
-					markdown, err := convertHTMLToMarkdown(content)
+					markdown, err := convertHTMLToMarkdown(removeNoisyElements(content))
 					if err != nil {
 						return fantasy.NewTextErrorResponse("Failed to convert HTML to Markdown: " + err.Error()), nil
 					}
-					content = markdown
+					content = cleanupMarkdown(markdown)
 				}
 
 				content = "```\n" + content + "\n```"
@@ -160,6 +171,9 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 					content = "<html>\n<body>\n" + body + "\n</body>\n</html>"
 				}
 			}
+			if truncated {
+				content += fmt.Sprintf("\n\n[Response truncated to %d bytes]", maxSize)
+			}
 			// truncate content if it exceeds max read size
 			if int64(len(content)) > MaxReadSize {
 				content = content[:MaxReadSize]
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRobots(t *testing.T) {
+	t.Parallel()
+
+	robots := `# Everyone
+User-agent: *
+Disallow: /private/
+Allow: /private/docs/
+
+User-agent: Crush
+User-agent: other-bot
+Disallow: /*.pdf$
+Disallow: /search
+Disallow:
+`
+	rules := parseRobots(strings.NewReader(robots), robotsAgent)
+	for path, want := range map[string]bool{
+		"/":                   true,
+		"/private/notes":      true,
+		"/manual.pdf":         false,
+		"/manual.pdf?page=2":  true,
+		"/search?q=go":        false,
+		"/docs/search/design": true,
+	} {
+		require.Equal(t, want, robotsAllowed(rules, path), path)
+	}
+
+	rules = parseRobots(strings.NewReader(robots), "another")
+	for path, want := range map[string]bool{
+		"/private/notes":     false,
+		"/private/docs/a.md": true,
+		"/manual.pdf":        true,
+	} {
+		require.Equal(t, want, robotsAllowed(rules, path), path)
+	}
+}
+
+func TestFetchTool(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /admin\n")
+		case "/docs":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><head><script>track()</script></head><body><nav>Home | Blog</nav><h1>Install</h1><p>Run the installer.</p></body></html>`)
+		case "/large":
+			fmt.Fprint(w, strings.Repeat("é", 100))
+		default:
+			fmt.Fprint(w, "secret")
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	tool := NewFetchTool(&mockPermissionService{}, t.TempDir(), config.ToolFetch{}, server.Client())
+
+	resp := runTool(t, ctx, tool, FetchParams{URL: server.URL + "/docs", Format: "markdown"})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "# Install\n\nRun the installer.")
+	require.NotContains(t, resp.Content, "track()")
+	require.NotContains(t, resp.Content, "Blog")
+
+	resp = runTool(t, ctx, tool, FetchParams{URL: server.URL + "/admin/users", Format: "text"})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "robots.txt")
+
+	maxSize := int64(51)
+	tool = NewFetchTool(&mockPermissionService{}, t.TempDir(), config.ToolFetch{MaxSize: &maxSize}, server.Client())
+	resp = runTool(t, ctx, tool, FetchParams{URL: server.URL + "/large", Format: "text"})
+	require.False(t, resp.IsError, resp.Content)
+	require.True(t, strings.HasPrefix(resp.Content, strings.Repeat("é", 25)+"\n"), resp.Content)
+	require.Contains(t, resp.Content, "[Response truncated to 51 bytes]")
+
+	tool = NewFetchTool(&mockPermissionService{}, t.TempDir(), config.ToolFetch{IgnoreRobots: true}, server.Client())
+	resp = runTool(t, ctx, tool, FetchParams{URL: server.URL + "/admin/users", Format: "text"})
+	require.False(t, resp.IsError, resp.Content)
+	require.Equal(t, "secret", resp.Content)
+}
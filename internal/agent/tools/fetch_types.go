@@ -6,7 +6,7 @@ const AgenticFetchToolName = "agentic_fetch"
 // WebFetchToolName is the name of the web_fetch tool.
 const WebFetchToolName = "web_fetch"
 
-// WebSearchToolName is the name of the web_search tool for sub-agents.
+// WebSearchToolName is the name of the web_search tool.
 const WebSearchToolName = "web_search"
 
 // LargeContentThreshold is the size threshold for saving content to a file.
@@ -35,6 +35,12 @@ type WebSearchParams struct {
 	MaxResults int    `json:"max_results,omitempty" description:"Maximum number of results to return (default: 10, max: 20)"`
 }
 
+// WebSearchPermissionsParams defines the permission parameters for the web_search tool.
+type WebSearchPermissionsParams struct {
+	Query      string `json:"query"`
+	MaxResults int    `json:"max_results,omitempty"`
+}
+
 // FetchParams defines the parameters for the simple fetch tool.
 type FetchParams struct {
 	URL     string `json:"url" description:"The URL to fetch content from"`
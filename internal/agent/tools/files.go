@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"context"
+	"os"
+
+	"github.com/charmbracelet/crush/internal/filecache"
+)
+
+type fileCacheContextKey string
+
+// FileCacheContextKey is the key for the file cache shared by the agents of
+// a workspace.
+const FileCacheContextKey fileCacheContextKey = "file_cache"
+
+// WithFileCache returns a copy of ctx where tools read and write files
+// through cache. Sub-agents inherit it, which is how they share it.
+func WithFileCache(ctx context.Context, cache *filecache.Cache) context.Context {
+	return context.WithValue(ctx, FileCacheContextKey, cache)
+}
+
+// GetFileCacheFromContext retrieves the file cache from the context. A nil
+// cache reads and writes the file system directly.
+func GetFileCacheFromContext(ctx context.Context) *filecache.Cache {
+	cache, _ := ctx.Value(FileCacheContextKey).(*filecache.Cache)
+	return cache
+}
+
+// readFile reads a file through the file cache of ctx. The returned slice
+// may be shared and must not be modified.
+func readFile(ctx context.Context, path string) ([]byte, error) {
+	return GetFileCacheFromContext(ctx).ReadFile(path)
+}
+
+// writeFile writes a file through the file cache of ctx.
+func writeFile(ctx context.Context, path string, data []byte, perm os.FileMode) error {
+	return GetFileCacheFromContext(ctx).WriteFile(path, data, perm)
+}
+
+// lockFile locks a file for the read, change and write of an edit, so that
+// agents editing it at the same time apply their edits one after the
+// other. It returns the function unlocking it.
+func lockFile(ctx context.Context, path string) func() {
+	return GetFileCacheFromContext(ctx).Lock(path)
+}
@@ -0,0 +1,20 @@
+package tools
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/formatter"
+)
+
+// formatContent runs the configured formatters on the proposed content of a
+// file before it is shown for approval, so that what the user approves is
+// what gets written. Formatter failures are logged and leave content as is.
+func formatContent(ctx context.Context, formatters *formatter.Runner, filePath, content string) string {
+	formatted, err := formatters.FormatContent(ctx, filePath, []byte(content))
+	if err != nil {
+		slog.Warn("Error formatting file", "file", filePath, "error", err)
+		return content
+	}
+	return string(formatted)
+}
@@ -0,0 +1,234 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/version"
+)
+
+// DefaultGitHubAPI is the API of github.com.
+const DefaultGitHubAPI = "https://api.github.com"
+
+// gitHubRemote is the remote the repository is found from and branches are
+// pushed to.
+const gitHubRemote = "origin"
+
+// GitHub is a client of the GitHub REST API for the repository of a working
+// directory. The token and the repository are found on first use, so a
+// missing gh CLI only fails the calls that need it.
+type GitHub struct {
+	client     *http.Client
+	apiURL     string
+	workingDir string
+
+	once  sync.Once
+	token string
+	repo  string
+	err   error
+}
+
+// NewGitHub returns a client of the API at apiURL, github.com when empty.
+// Without a token, it uses $GH_TOKEN, $GITHUB_TOKEN or the token of the gh
+// CLI; without a repository ("owner/name"), the one of the origin remote of
+// workingDir.
+func NewGitHub(workingDir, apiURL, token, repo string, client *http.Client) *GitHub {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &GitHub{
+		client:     client,
+		apiURL:     strings.TrimSuffix(cmp.Or(apiURL, DefaultGitHubAPI), "/"),
+		workingDir: workingDir,
+		token:      token,
+		repo:       repo,
+	}
+}
+
+// Repo returns the "owner/name" of the repository.
+func (g *GitHub) Repo(ctx context.Context) (string, error) {
+	if err := g.init(ctx); err != nil {
+		return "", err
+	}
+	return g.repo, nil
+}
+
+func (g *GitHub) init(ctx context.Context) error {
+	g.once.Do(func() {
+		if g.token == "" {
+			g.token = cmp.Or(os.Getenv("GH_TOKEN"), os.Getenv("GITHUB_TOKEN"))
+		}
+		if g.token == "" {
+			out, err := exec.CommandContext(ctx, "gh", "auth", "token").Output()
+			if err != nil {
+				g.err = errors.New("no GitHub token: set tools.github.token or log in with gh auth login")
+				return
+			}
+			g.token = strings.TrimSpace(string(out))
+		}
+		if g.repo == "" {
+			remote, err := runGit(ctx, g.workingDir, "remote", "get-url", gitHubRemote)
+			if err != nil {
+				g.err = fmt.Errorf("no GitHub repository: set tools.github.repo or add an %s remote: %w", gitHubRemote, err)
+				return
+			}
+			repo, ok := parseGitHubRemote(remote)
+			if !ok {
+				g.err = fmt.Errorf("the %s remote %s is not on GitHub: set tools.github.repo", gitHubRemote, remote)
+				return
+			}
+			g.repo = repo
+		}
+	})
+	return g.err
+}
+
+// parseGitHubRemote returns the "owner/name" of a remote URL, in the SSH or
+// HTTPS form.
+func parseGitHubRemote(remote string) (string, bool) {
+	remote = strings.TrimSpace(remote)
+	var path string
+	if u, err := url.Parse(remote); err == nil && u.Host != "" {
+		path = u.Path
+	} else if _, rest, ok := strings.Cut(remote, ":"); ok {
+		// git@github.com:owner/name.git
+		path = rest
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	owner, name, ok := strings.Cut(path, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	return owner + "/" + name, true
+}
+
+// call calls the API at path, relative to the repository, sending body as
+// JSON when set and decoding the answer into out when set.
+func (g *GitHub) call(ctx context.Context, method, path string, body, out any) error {
+	if err := g.init(ctx); err != nil {
+		return err
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, g.apiURL+"/repos/"+g.repo+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", "crush/"+version.Version)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+			Errors  []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		_ = json.Unmarshal(data, &apiErr)
+		msg := cmp.Or(apiErr.Message, http.StatusText(resp.StatusCode))
+		for _, e := range apiErr.Errors {
+			if e.Message != "" {
+				msg += ": " + e.Message
+			}
+		}
+		return fmt.Errorf("GitHub %s %s: %d %s", method, path, resp.StatusCode, msg)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+type gitHubUser struct {
+	Login string `json:"login"`
+}
+
+type gitHubLabel struct {
+	Name string `json:"name"`
+}
+
+type gitHubIssue struct {
+	Number      int           `json:"number"`
+	Title       string        `json:"title"`
+	State       string        `json:"state"`
+	Body        string        `json:"body"`
+	HTMLURL     string        `json:"html_url"`
+	User        gitHubUser    `json:"user"`
+	Labels      []gitHubLabel `json:"labels"`
+	Comments    int           `json:"comments"`
+	CreatedAt   time.Time     `json:"created_at"`
+	PullRequest *struct{}     `json:"pull_request"`
+}
+
+type gitHubComment struct {
+	ID          int64      `json:"id"`
+	Body        string     `json:"body"`
+	User        gitHubUser `json:"user"`
+	CreatedAt   time.Time  `json:"created_at"`
+	HTMLURL     string     `json:"html_url"`
+	Path        string     `json:"path"`
+	Line        int        `json:"line"`
+	InReplyToID int64      `json:"in_reply_to_id"`
+	DiffHunk    string     `json:"diff_hunk"`
+}
+
+type gitHubReview struct {
+	ID          int64      `json:"id"`
+	Body        string     `json:"body"`
+	State       string     `json:"state"`
+	User        gitHubUser `json:"user"`
+	SubmittedAt time.Time  `json:"submitted_at"`
+}
+
+type gitHubPullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+}
+
+// runGit runs git in dir and returns its trimmed output.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type GitHubCreatePRParams struct {
+	Title string `json:"title" description:"Title of the pull request"`
+	Body  string `json:"body,omitempty" description:"Description of the change in Markdown"`
+	Head  string `json:"head,omitempty" description:"Branch to push the current commit to and open the pull request from (default: the current branch)"`
+	Base  string `json:"base,omitempty" description:"Branch to merge into (default: the default branch of the repository)"`
+	Draft bool   `json:"draft,omitempty" description:"Open the pull request as a draft"`
+	Issue int    `json:"issue,omitempty" description:"Number of an issue the pull request closes"`
+}
+
+type GitHubCreatePRPermissionsParams struct {
+	Title string `json:"title"`
+	Body  string `json:"body,omitempty"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Draft bool   `json:"draft,omitempty"`
+}
+
+type GitHubCreatePRResponseMetadata struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+}
+
+const GitHubCreatePRToolName = "github_create_pr"
+
+//go:embed github_create_pr.md
+var gitHubCreatePRDescription []byte
+
+// NewGitHubCreatePRTool pushes the current commit to a branch and opens a
+// pull request from it.
+func NewGitHubCreatePRTool(gh *GitHub, permissions permission.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		GitHubCreatePRToolName,
+		string(gitHubCreatePRDescription),
+		func(ctx context.Context, params GitHubCreatePRParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if strings.TrimSpace(params.Title) == "" {
+				return fantasy.NewTextErrorResponse("title is required"), nil
+			}
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for opening a pull request")
+			}
+			if IsPlanMode(ctx) {
+				return fantasy.NewTextErrorResponse("plan mode is on, so no pull request was opened. Include it in your plan instead."), nil
+			}
+
+			head := params.Head
+			if head == "" {
+				branch, err := runGit(ctx, workingDir, "rev-parse", "--abbrev-ref", "HEAD")
+				if err != nil {
+					return fantasy.NewTextErrorResponse(err.Error()), nil
+				}
+				if branch == "HEAD" {
+					return fantasy.NewTextErrorResponse("HEAD is detached: give the branch to push to as head"), nil
+				}
+				head = branch
+			}
+			base := params.Base
+			if base == "" {
+				var repo struct {
+					DefaultBranch string `json:"default_branch"`
+				}
+				if err := gh.call(ctx, "GET", "", nil, &repo); err != nil {
+					return fantasy.NewTextErrorResponse(err.Error()), nil
+				}
+				base = repo.DefaultBranch
+			}
+			if head == base {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("the change is on %s, the branch to merge into: give a new branch to push it to as head", base)), nil
+			}
+			repo, err := gh.Repo(ctx)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			p, err := permissions.Request(ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        workingDir,
+					Target:      repo,
+					ToolCallID:  call.ID,
+					ToolName:    GitHubCreatePRToolName,
+					Action:      "create",
+					Description: fmt.Sprintf("Push %s and open a pull request into %s of %s", head, base, repo),
+					Params: GitHubCreatePRPermissionsParams{
+						Title: params.Title,
+						Body:  params.Body,
+						Head:  head,
+						Base:  base,
+						Draft: params.Draft,
+					},
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			if _, err := runGit(ctx, workingDir, "push", gitHubRemote, "HEAD:refs/heads/"+head); err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			body := params.Body
+			if params.Issue > 0 {
+				body = strings.TrimSpace(fmt.Sprintf("%s\n\nCloses #%d", body, params.Issue))
+			}
+			var pr gitHubPullRequest
+			err = gh.call(ctx, "POST", "/pulls", map[string]any{
+				"title": params.Title,
+				"body":  body,
+				"head":  head,
+				"base":  base,
+				"draft": params.Draft,
+			}, &pr)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(fmt.Sprintf("Pushed %s and opened pull request #%d: %s", head, pr.Number, cmp.Or(pr.HTMLURL, repo))),
+				GitHubCreatePRResponseMetadata{Number: pr.Number, URL: pr.HTMLURL},
+			), nil
+		})
+}
@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+)
+
+type GitHubIssueParams struct {
+	Number int    `json:"number,omitempty" description:"Number of the issue or pull request to read; omit to list the open issues"`
+	Query  string `json:"query,omitempty" description:"Only list open issues matching these words"`
+}
+
+const GitHubIssueToolName = "github_issue"
+
+//go:embed github_issue.md
+var gitHubIssueDescription []byte
+
+// NewGitHubIssueTool reads the issues of the repository on GitHub.
+func NewGitHubIssueTool(gh *GitHub) fantasy.AgentTool {
+	return fantasy.NewParallelAgentTool(
+		GitHubIssueToolName,
+		string(gitHubIssueDescription),
+		func(ctx context.Context, params GitHubIssueParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			var text string
+			var err error
+			if params.Number > 0 {
+				text, err = readGitHubIssue(ctx, gh, params.Number)
+			} else {
+				text, err = listGitHubIssues(ctx, gh, params.Query)
+			}
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			return fantasy.NewTextResponse(text), nil
+		})
+}
+
+func readGitHubIssue(ctx context.Context, gh *GitHub, number int) (string, error) {
+	var issue gitHubIssue
+	if err := gh.call(ctx, "GET", fmt.Sprintf("/issues/%d", number), nil, &issue); err != nil {
+		return "", err
+	}
+	var comments []gitHubComment
+	if issue.Comments > 0 {
+		if err := gh.call(ctx, "GET", fmt.Sprintf("/issues/%d/comments?per_page=100", number), nil, &comments); err != nil {
+			return "", err
+		}
+	}
+	return formatGitHubIssue(issue, comments), nil
+}
+
+func formatGitHubIssue(issue gitHubIssue, comments []gitHubComment) string {
+	var sb strings.Builder
+	kind := "Issue"
+	if issue.PullRequest != nil {
+		kind = "Pull request"
+	}
+	fmt.Fprintf(&sb, "%s #%d: %s\n", kind, issue.Number, issue.Title)
+	fmt.Fprintf(&sb, "State: %s\nAuthor: %s\nCreated: %s\nURL: %s\n", issue.State, issue.User.Login, issue.CreatedAt.Format(time.DateOnly), issue.HTMLURL)
+	if len(issue.Labels) > 0 {
+		fmt.Fprintf(&sb, "Labels: %s\n", labelNames(issue.Labels))
+	}
+	if body := strings.TrimSpace(issue.Body); body != "" {
+		fmt.Fprintf(&sb, "\n%s\n", body)
+	}
+	for _, comment := range comments {
+		fmt.Fprintf(&sb, "\n--- %s on %s\n%s\n", comment.User.Login, comment.CreatedAt.Format(time.DateOnly), strings.TrimSpace(comment.Body))
+	}
+	if len(comments) < issue.Comments {
+		fmt.Fprintf(&sb, "\n(%d more comments at %s)\n", issue.Comments-len(comments), issue.HTMLURL)
+	}
+	return sb.String()
+}
+
+func listGitHubIssues(ctx context.Context, gh *GitHub, query string) (string, error) {
+	var issues []gitHubIssue
+	if err := gh.call(ctx, "GET", "/issues?state=open&per_page=50", nil, &issues); err != nil {
+		return "", err
+	}
+	words := strings.Fields(strings.ToLower(query))
+	var sb strings.Builder
+	for _, issue := range issues {
+		if issue.PullRequest != nil || !matchesAll(strings.ToLower(issue.Title+" "+issue.Body), words) {
+			continue
+		}
+		fmt.Fprintf(&sb, "#%d %s", issue.Number, issue.Title)
+		if len(issue.Labels) > 0 {
+			fmt.Fprintf(&sb, " [%s]", labelNames(issue.Labels))
+		}
+		sb.WriteString("\n")
+	}
+	if sb.Len() == 0 {
+		return "No open issues found", nil
+	}
+	return sb.String(), nil
+}
+
+func matchesAll(text string, words []string) bool {
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+func labelNames(labels []gitHubLabel) string {
+	names := make([]string, len(labels))
+	for i, label := range labels {
+		names[i] = label.Name
+	}
+	return strings.Join(names, ", ")
+}
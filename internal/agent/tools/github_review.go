@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type GitHubReviewParams struct {
+	Number    int    `json:"number" description:"Number of the pull request"`
+	CommentID int64  `json:"comment_id,omitempty" description:"ID of the review comment to reply to"`
+	Body      string `json:"body,omitempty" description:"Reply to post; omit to list the reviews and review comments"`
+}
+
+type GitHubReviewPermissionsParams struct {
+	Number    int    `json:"number"`
+	CommentID int64  `json:"comment_id,omitempty"`
+	Body      string `json:"body"`
+}
+
+const GitHubReviewToolName = "github_review"
+
+//go:embed github_review.md
+var gitHubReviewDescription []byte
+
+// NewGitHubReviewTool reads the reviews of a pull request and replies to
+// them.
+func NewGitHubReviewTool(gh *GitHub, permissions permission.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		GitHubReviewToolName,
+		string(gitHubReviewDescription),
+		func(ctx context.Context, params GitHubReviewParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Number <= 0 {
+				return fantasy.NewTextErrorResponse("number is required"), nil
+			}
+			if strings.TrimSpace(params.Body) == "" {
+				text, err := listGitHubReviews(ctx, gh, params.Number)
+				if err != nil {
+					return fantasy.NewTextErrorResponse(err.Error()), nil
+				}
+				return fantasy.NewTextResponse(text), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for replying to a review")
+			}
+			if IsPlanMode(ctx) {
+				return fantasy.NewTextErrorResponse("plan mode is on, so nothing was posted. Include the reply in your plan instead."), nil
+			}
+			repo, err := gh.Repo(ctx)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			description := fmt.Sprintf("Comment on pull request #%d of %s", params.Number, repo)
+			if params.CommentID != 0 {
+				description = fmt.Sprintf("Reply to a review comment on pull request #%d of %s", params.Number, repo)
+			}
+			p, err := permissions.Request(ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        workingDir,
+					Target:      repo,
+					ToolCallID:  call.ID,
+					ToolName:    GitHubReviewToolName,
+					Action:      "comment",
+					Description: description,
+					Params:      GitHubReviewPermissionsParams(params),
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			path := fmt.Sprintf("/issues/%d/comments", params.Number)
+			if params.CommentID != 0 {
+				path = fmt.Sprintf("/pulls/%d/comments/%d/replies", params.Number, params.CommentID)
+			}
+			var comment gitHubComment
+			if err := gh.call(ctx, "POST", path, map[string]string{"body": params.Body}, &comment); err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			return fantasy.NewTextResponse("Posted " + comment.HTMLURL), nil
+		})
+}
+
+func listGitHubReviews(ctx context.Context, gh *GitHub, number int) (string, error) {
+	var reviews []gitHubReview
+	if err := gh.call(ctx, "GET", fmt.Sprintf("/pulls/%d/reviews?per_page=100", number), nil, &reviews); err != nil {
+		return "", err
+	}
+	var comments []gitHubComment
+	if err := gh.call(ctx, "GET", fmt.Sprintf("/pulls/%d/comments?per_page=100", number), nil, &comments); err != nil {
+		return "", err
+	}
+	return formatGitHubReviews(reviews, comments), nil
+}
+
+func formatGitHubReviews(reviews []gitHubReview, comments []gitHubComment) string {
+	var sb strings.Builder
+	for _, review := range reviews {
+		body := strings.TrimSpace(review.Body)
+		if body == "" && review.State == "COMMENTED" {
+			// Reviews that only hold comments are shown by their comments.
+			continue
+		}
+		fmt.Fprintf(&sb, "Review by %s (%s) on %s\n", review.User.Login, strings.ToLower(review.State), review.SubmittedAt.Format(time.DateOnly))
+		if body != "" {
+			fmt.Fprintf(&sb, "%s\n", body)
+		}
+		sb.WriteString("\n")
+	}
+	// Replies follow the comment they answer.
+	replies := make(map[int64][]gitHubComment)
+	for _, comment := range comments {
+		if comment.InReplyToID != 0 {
+			replies[comment.InReplyToID] = append(replies[comment.InReplyToID], comment)
+		}
+	}
+	for _, comment := range comments {
+		if comment.InReplyToID != 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "Comment %d by %s on %s:%d\n", comment.ID, comment.User.Login, comment.Path, comment.Line)
+		if hunk := hunkTail(comment.DiffHunk, 4); strings.TrimSpace(hunk) != "" {
+			fmt.Fprintf(&sb, "```diff\n%s\n```\n", hunk)
+		}
+		fmt.Fprintf(&sb, "%s\n", strings.TrimSpace(comment.Body))
+		for _, reply := range replies[comment.ID] {
+			fmt.Fprintf(&sb, "  > %s: %s\n", reply.User.Login, strings.ReplaceAll(strings.TrimSpace(reply.Body), "\n", "\n    "))
+		}
+		sb.WriteString("\n")
+	}
+	if sb.Len() == 0 {
+		return "No reviews yet"
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// hunkTail returns the last n lines of a diff hunk, those the comment is on.
+func hunkTail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitHubRemote(t *testing.T) {
+	t.Parallel()
+
+	for remote, want := range map[string]string{
+		"git@github.com:charmbracelet/crush.git":     "charmbracelet/crush",
+		"https://github.com/charmbracelet/crush":     "charmbracelet/crush",
+		"https://github.com/charmbracelet/crush.git": "charmbracelet/crush",
+		"ssh://git@github.com/charmbracelet/crush/":  "charmbracelet/crush",
+	} {
+		repo, ok := parseGitHubRemote(remote)
+		require.True(t, ok, remote)
+		require.Equal(t, want, repo)
+	}
+	_, ok := parseGitHubRemote("/srv/git/crush.git")
+	require.False(t, ok)
+}
+
+func TestGitHubTools(t *testing.T) {
+	t.Parallel()
+
+	var created map[string]any
+	var reply map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/owner/repo":
+			fmt.Fprint(w, `{"default_branch":"main"}`)
+		case "GET /repos/owner/repo/issues/7":
+			fmt.Fprint(w, `{"number":7,"title":"Crash on start","state":"open","body":"It crashes.","user":{"login":"ana"},"labels":[{"name":"bug"}],"comments":1}`)
+		case "GET /repos/owner/repo/issues/7/comments":
+			fmt.Fprint(w, `[{"id":1,"body":"Same here","user":{"login":"bo"}}]`)
+		case "GET /repos/owner/repo/issues/8":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+		case "GET /repos/owner/repo/pulls/9/reviews":
+			fmt.Fprint(w, `[{"id":1,"body":"Almost","state":"CHANGES_REQUESTED","user":{"login":"ana"}}]`)
+		case "GET /repos/owner/repo/pulls/9/comments":
+			fmt.Fprint(w, `[{"id":11,"body":"Rename this","user":{"login":"ana"},"path":"main.go","line":3},{"id":12,"body":"Done","user":{"login":"bo"},"in_reply_to_id":11}]`)
+		case "POST /repos/owner/repo/pulls/9/comments/11/replies":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&reply))
+			fmt.Fprint(w, `{"id":13,"html_url":"https://github.com/owner/repo/pull/9#r13"}`)
+		case "POST /repos/owner/repo/pulls":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+			fmt.Fprint(w, `{"number":10,"html_url":"https://github.com/owner/repo/pull/10"}`)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	for _, args := range [][]string{
+		{"init", "--bare", origin},
+		{"init", "-b", "main", dir},
+		{"-C", dir, "-c", "user.name=t", "-c", "user.email=t@t", "commit", "--allow-empty", "-m", "fix"},
+		{"-C", dir, "remote", "add", "origin", origin},
+	} {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	gh := NewGitHub(dir, server.URL, "token", "owner/repo", server.Client())
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+
+	resp := runTool(t, ctx, NewGitHubIssueTool(gh), GitHubIssueParams{Number: 7})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "Issue #7: Crash on start")
+	require.Contains(t, resp.Content, "Labels: bug")
+	require.Contains(t, resp.Content, "--- bo")
+	require.Contains(t, resp.Content, "Same here")
+
+	resp = runTool(t, ctx, NewGitHubIssueTool(gh), GitHubIssueParams{Number: 8})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "404 Not Found")
+
+	review := NewGitHubReviewTool(gh, &mockPermissionService{}, dir)
+	resp = runTool(t, ctx, review, GitHubReviewParams{Number: 9})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "Review by ana (changes_requested)")
+	require.Contains(t, resp.Content, "Comment 11 by ana on main.go:3\nRename this\n  > bo: Done")
+
+	resp = runTool(t, ctx, review, GitHubReviewParams{Number: 9, CommentID: 11, Body: "Renamed"})
+	require.False(t, resp.IsError, resp.Content)
+	require.Equal(t, "Renamed", reply["body"])
+
+	pr := NewGitHubCreatePRTool(gh, &mockPermissionService{}, dir)
+	resp = runTool(t, ctx, pr, GitHubCreatePRParams{Title: "Fix crash"})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "give a new branch")
+
+	resp = runTool(t, ctx, pr, GitHubCreatePRParams{Title: "Fix crash", Head: "fix/crash", Issue: 7})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "opened pull request #10")
+	require.Equal(t, "fix/crash", created["head"])
+	require.Equal(t, "main", created["base"])
+	require.Equal(t, "Closes #7", created["body"])
+	out, err := exec.Command("git", "-C", origin, "branch", "--list", "fix/crash").Output()
+	require.NoError(t, err)
+	require.Contains(t, string(out), "fix/crash")
+}
@@ -112,10 +112,17 @@ func runRipgrep(cmd *exec.Cmd, searchRoot string, limit int) ([]string, error) {
 		return len(matches[i]) < len(matches[j])
 	})
 
-	if limit > 0 && len(matches) > limit {
-		matches = matches[:limit]
+	// Ripgrep knows nothing of .crushignore files and denied paths.
+	allowed := matches[:0]
+	for _, path := range matches {
+		if limit > 0 && len(allowed) >= limit {
+			break
+		}
+		if !fsext.Denied(path) {
+			allowed = append(allowed, path)
+		}
 	}
-	return matches, nil
+	return allowed, nil
 }
 
 func normalizeFilePaths(paths []string) {
@@ -225,7 +225,7 @@ func searchWithRipgrep(ctx context.Context, pattern, path, include string) ([]gr
 		if err := json.Unmarshal(line, &match); err != nil {
 			continue
 		}
-		if match.Type != "match" {
+		if match.Type != "match" || fsext.Denied(match.Data.Path.Text) {
 			continue
 		}
 		for _, m := range match.Data.Submatches {
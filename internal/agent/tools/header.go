@@ -0,0 +1,21 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/crush/internal/fileheader"
+)
+
+// headerNote asks the agent to add the required file header when the header
+// policy is in check mode and the written file lacks it.
+func headerNote(headers *fileheader.Policy, filePath string) string {
+	if !headers.Checking() {
+		return ""
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil || !headers.Missing(filePath, string(content)) {
+		return ""
+	}
+	return fmt.Sprintf("<missing_header>\nThis file is missing the required header. Add it at the top of the file:\n%s</missing_header>\n", headers.Header(filePath))
+}
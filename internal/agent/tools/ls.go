@@ -90,6 +90,7 @@ func NewLsTool(permissions permission.Service, workingDir string, lsConfig confi
 					permission.CreatePermissionRequest{
 						SessionID:   sessionID,
 						Path:        absSearchPath,
+						Target:      absSearchPath,
 						ToolCallID:  call.ID,
 						ToolName:    LSToolName,
 						Action:      "list",
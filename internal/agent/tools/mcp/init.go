@@ -138,6 +138,7 @@ func GetState(name string) (ClientInfo, bool) {
 
 // Close closes all MCP clients. This should be called during application shutdown.
 func Close(ctx context.Context) error {
+	supervisor.stop()
 	var wg sync.WaitGroup
 	for name, session := range sessions.Seq2() {
 		wg.Go(func() {
@@ -163,8 +164,11 @@ func Close(ctx context.Context) error {
 }
 
 // Initialize initializes MCP clients based on the provided configuration.
+// Until [Close], servers that crash or fail their health checks are
+// restarted.
 func Initialize(ctx context.Context, permissions permission.Service, cfg *config.Config) {
 	slog.Info("Initializing MCP clients")
+	ctx = supervisor.start(ctx, cfg)
 	var wg sync.WaitGroup
 	// Initialize states for all configured MCPs
 	for name, m := range cfg.MCP {
@@ -177,69 +181,74 @@ func Initialize(ctx context.Context, permissions permission.Service, cfg *config
 		// Set initial starting state
 		updateState(name, StateStarting, nil, nil, Counts{})
 
-		wg.Add(1)
-		go func(name string, m config.MCPConfig) {
-			defer func() {
-				wg.Done()
-				if r := recover(); r != nil {
-					var err error
-					switch v := r.(type) {
-					case error:
-						err = v
-					case string:
-						err = fmt.Errorf("panic: %s", v)
-					default:
-						err = fmt.Errorf("panic: %v", v)
-					}
-					updateState(name, StateError, err, nil, Counts{})
-					slog.Error("Panic in MCP client initialization", "error", err, "name", name)
-				}
-			}()
+		wg.Go(func() {
+			_ = connect(ctx, cfg, name, m)
+		})
+	}
+	wg.Wait()
+	initOnce.Do(func() { close(initDone) })
+}
 
-			// createSession handles its own timeout internally.
-			session, err := createSession(ctx, name, m, cfg.Resolver())
-			if err != nil {
-				return
+// connect starts the MCP client name, lists what it offers and supervises
+// it, updating its state on the way.
+func connect(ctx context.Context, cfg *config.Config, name string, m config.MCPConfig) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch v := r.(type) {
+			case error:
+				err = v
+			case string:
+				err = fmt.Errorf("panic: %s", v)
+			default:
+				err = fmt.Errorf("panic: %v", v)
 			}
+			updateState(name, StateError, err, nil, Counts{})
+			slog.Error("Panic in MCP client initialization", "error", err, "name", name)
+		}
+	}()
 
-			tools, err := getTools(ctx, session)
-			if err != nil {
-				slog.Error("Error listing tools", "error", err)
-				updateState(name, StateError, err, nil, Counts{})
-				session.Close()
-				return
-			}
+	// createSession handles its own timeout internally.
+	session, err := createSession(ctx, name, m, cfg.Resolver())
+	if err != nil {
+		return err
+	}
 
-			prompts, err := getPrompts(ctx, session)
-			if err != nil {
-				slog.Error("Error listing prompts", "error", err)
-				updateState(name, StateError, err, nil, Counts{})
-				session.Close()
-				return
-			}
+	tools, err := getTools(ctx, session)
+	if err != nil {
+		slog.Error("Error listing tools", "error", err)
+		updateState(name, StateError, err, nil, Counts{})
+		session.Close()
+		return err
+	}
 
-			resources, err := getResources(ctx, session)
-			if err != nil {
-				slog.Error("Error listing resources", "error", err)
-				updateState(name, StateError, err, nil, Counts{})
-				session.Close()
-				return
-			}
+	prompts, err := getPrompts(ctx, session)
+	if err != nil {
+		slog.Error("Error listing prompts", "error", err)
+		updateState(name, StateError, err, nil, Counts{})
+		session.Close()
+		return err
+	}
 
-			toolCount := updateTools(cfg, name, tools)
-			updatePrompts(name, prompts)
-			resourceCount := updateResources(name, resources)
-			sessions.Set(name, session)
-
-			updateState(name, StateConnected, nil, session, Counts{
-				Tools:     toolCount,
-				Prompts:   len(prompts),
-				Resources: resourceCount,
-			})
-		}(name, m)
+	resources, err := getResources(ctx, session)
+	if err != nil {
+		slog.Error("Error listing resources", "error", err)
+		updateState(name, StateError, err, nil, Counts{})
+		session.Close()
+		return err
 	}
-	wg.Wait()
-	initOnce.Do(func() { close(initDone) })
+
+	toolCount := updateTools(cfg, name, tools)
+	updatePrompts(name, prompts)
+	resourceCount := updateResources(name, resources)
+	sessions.Set(name, session)
+
+	updateState(name, StateConnected, nil, session, Counts{
+		Tools:     toolCount,
+		Prompts:   len(prompts),
+		Resources: resourceCount,
+	})
+	supervisor.watch(name, session)
+	return nil
 }
 
 // WaitForInit blocks until MCP initialization is complete.
@@ -278,6 +287,7 @@ func getOrRenewClient(ctx context.Context, cfg *config.Config, name string) (*Cl
 
 	updateState(name, StateConnected, nil, sess, state.Counts)
 	sessions.Set(name, sess)
+	supervisor.watch(name, sess)
 	return sess, nil
 }
 
@@ -313,7 +323,7 @@ func createSession(ctx context.Context, name string, m config.MCPConfig, resolve
 	mcpCtx, cancel := context.WithCancel(ctx)
 	cancelTimer := time.AfterFunc(timeout, cancel)
 
-	transport, err := createTransport(mcpCtx, m, resolver)
+	transport, err := createTransport(mcpCtx, name, m, resolver)
 	if err != nil {
 		updateState(name, StateError, err, nil, Counts{})
 		slog.Error("Error creating MCP client", "error", err, "name", name)
@@ -397,7 +407,7 @@ func maybeTimeoutErr(err error, timeout time.Duration) error {
 	return err
 }
 
-func createTransport(ctx context.Context, m config.MCPConfig, resolver config.VariableResolver) (mcp.Transport, error) {
+func createTransport(ctx context.Context, name string, m config.MCPConfig, resolver config.VariableResolver) (mcp.Transport, error) {
 	switch m.Type {
 	case config.MCPStdio:
 		command, err := resolver.ResolveValue(m.Command)
@@ -416,11 +426,7 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 		if strings.TrimSpace(m.URL) == "" {
 			return nil, fmt.Errorf("mcp http config requires a non-empty 'url' field")
 		}
-		client := &http.Client{
-			Transport: &headerRoundTripper{
-				headers: m.ResolvedHeaders(),
-			},
-		}
+		client := httpClient(name, m)
 		return &mcp.StreamableClientTransport{
 			Endpoint:   m.URL,
 			HTTPClient: client,
@@ -429,11 +435,7 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 		if strings.TrimSpace(m.URL) == "" {
 			return nil, fmt.Errorf("mcp sse config requires a non-empty 'url' field")
 		}
-		client := &http.Client{
-			Transport: &headerRoundTripper{
-				headers: m.ResolvedHeaders(),
-			},
-		}
+		client := httpClient(name, m)
 		return &mcp.SSEClientTransport{
 			Endpoint:   m.URL,
 			HTTPClient: client,
@@ -0,0 +1,90 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/oauth/mcpauth"
+)
+
+// ErrSignInRequired is returned when an MCP server asks for credentials
+// Crush does not have.
+var ErrSignInRequired = errors.New("sign-in required")
+
+// credentials is where the credentials of the MCP servers signed in to are
+// kept.
+var credentials = sync.OnceValue(func() *mcpauth.Store {
+	return mcpauth.NewStore(config.GlobalMCPCredentials())
+})
+
+// oauthRoundTripper authorizes the requests to an MCP server with the token
+// of `crush login mcp`, refreshing it when it expires.
+type oauthRoundTripper struct {
+	name  string
+	url   string
+	base  http.RoundTripper
+	store *mcpauth.Store
+	mu    sync.Mutex
+}
+
+func (rt *oauthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if token := rt.token(req.Context()); token != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := rt.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	resp.Body.Close()
+	return nil, fmt.Errorf("%w: run 'crush login mcp %s' to sign in to %s", ErrSignInRequired, rt.name, rt.url)
+}
+
+// token returns the access token of the server, if it was signed in to.
+func (rt *oauthRoundTripper) token(ctx context.Context) string {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	creds, ok := rt.store.Get(rt.url)
+	if !ok {
+		return ""
+	}
+	token := creds.Token
+	if token.ExpiresAt == 0 || !token.IsExpired() || token.RefreshToken == "" {
+		return token.AccessToken
+	}
+	refreshed, err := creds.Client(rt.url).Refresh(ctx, token.RefreshToken)
+	if err != nil {
+		slog.Warn("Failed to refresh MCP token", "name", rt.name, "error", err)
+		return token.AccessToken
+	}
+	creds.Token = refreshed
+	if err := rt.store.Set(rt.url, creds); err != nil {
+		slog.Warn("Failed to save MCP token", "name", rt.name, "error", err)
+	}
+	return refreshed.AccessToken
+}
+
+// httpClient returns the client for the HTTP or SSE MCP server name: with
+// the configured headers and, unless they hold an Authorization header, the
+// token of signing in to the server.
+func httpClient(name string, m config.MCPConfig) *http.Client {
+	headers := m.ResolvedHeaders()
+	var transport http.RoundTripper = headerRoundTripper{headers: headers}
+	for k := range headers {
+		if strings.EqualFold(k, "Authorization") {
+			return &http.Client{Transport: transport}
+		}
+	}
+	return &http.Client{Transport: &oauthRoundTripper{
+		name:  name,
+		url:   m.URL,
+		base:  transport,
+		store: credentials(),
+	}}
+}
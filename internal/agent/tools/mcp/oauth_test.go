@@ -0,0 +1,43 @@
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/oauth"
+	"github.com/charmbracelet/crush/internal/oauth/mcpauth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthRoundTripper(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	store := mcpauth.NewStore(filepath.Join(t.TempDir(), "credentials.json"))
+	client := &http.Client{Transport: &oauthRoundTripper{
+		name:  "remote",
+		url:   srv.URL,
+		base:  headerRoundTripper{},
+		store: store,
+	}}
+
+	_, err := client.Get(srv.URL)
+	require.ErrorIs(t, err, ErrSignInRequired)
+	require.ErrorContains(t, err, "crush login mcp remote")
+
+	require.NoError(t, store.Set(srv.URL, mcpauth.Credentials{Token: &oauth.Token{AccessToken: "access"}}))
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
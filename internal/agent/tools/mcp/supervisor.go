@@ -0,0 +1,233 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// Supervision of the MCP servers. They are restarted with backoff when
+// they crash or stop answering health checks, up to maxRestarts times in a
+// row, and can be disabled and enabled while Crush runs.
+var (
+	// healthCheckInterval is how often connected servers are pinged.
+	healthCheckInterval = 30 * time.Second
+	// restartBackoff is the delay before the first restart, doubled after
+	// each failed one up to maxRestartBackoff.
+	restartBackoff    = time.Second
+	maxRestartBackoff = time.Minute
+	maxRestarts       = 5
+)
+
+var supervisor = &mcpSupervisor{}
+
+type mcpSupervisor struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	cfg    *config.Config
+	// generations counts the changes made to each server, so that the
+	// watches and restarts of earlier sessions stop.
+	generations map[string]int
+	// enabled holds the servers enabled or disabled at runtime.
+	enabled map[string]bool
+}
+
+// start supervises the servers of cfg until stop or until ctx is done, and
+// returns the context to connect them with.
+func (s *mcpSupervisor) start(ctx context.Context, cfg *config.Config) context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.cfg = cfg
+	s.generations = make(map[string]int)
+	s.enabled = make(map[string]bool)
+	return s.ctx
+}
+
+func (s *mcpSupervisor) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// config returns the context, the configuration and the current generation
+// of name, or an error when name can't be supervised.
+func (s *mcpSupervisor) config(name string) (context.Context, *config.Config, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cfg == nil || s.ctx.Err() != nil {
+		return nil, nil, 0, errors.New("mcp clients are not initialized")
+	}
+	if _, ok := s.cfg.MCP[name]; !ok {
+		return nil, nil, 0, fmt.Errorf("mcp '%s' not configured", name)
+	}
+	return s.ctx, s.cfg, s.generations[name], nil
+}
+
+// next starts a new generation of name, with name enabled or not. It
+// returns the generation.
+func (s *mcpSupervisor) next(name string, enabled bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generations[name]++
+	s.enabled[name] = enabled
+	return s.generations[name]
+}
+
+// current reports whether gen is still the generation of name.
+func (s *mcpSupervisor) current(name string, gen int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ctx != nil && s.ctx.Err() == nil && s.generations[name] == gen
+}
+
+// watch restarts name when sess crashes or fails a health check, until sess
+// is replaced.
+func (s *mcpSupervisor) watch(name string, sess *ClientSession) {
+	ctx, cfg, gen, err := s.config(name)
+	if err != nil {
+		return
+	}
+	go func() {
+		stopped := make(chan error, 1)
+		go func() { stopped <- sess.Wait() }()
+		ticker := time.NewTicker(healthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-stopped:
+				if !s.watching(name, gen, sess) {
+					return
+				}
+				s.failed(name, gen, fmt.Errorf("server stopped: %w", cmp.Or(err, errors.New("connection closed"))))
+				return
+			case <-ticker.C:
+				if !s.watching(name, gen, sess) {
+					return
+				}
+				timeout := mcpTimeout(cfg.MCP[name])
+				pingCtx, cancel := context.WithTimeout(ctx, timeout)
+				err := sess.Ping(pingCtx, nil)
+				cancel()
+				if err != nil {
+					_ = sess.Close()
+					s.failed(name, gen, fmt.Errorf("health check failed: %w", maybeTimeoutErr(err, timeout)))
+					return
+				}
+			}
+		}
+	}()
+}
+
+// watching reports whether sess is still the session of name.
+func (s *mcpSupervisor) watching(name string, gen int, sess *ClientSession) bool {
+	current, ok := sessions.Get(name)
+	return ok && current == sess && s.current(name, gen)
+}
+
+// failed records the failure of name and restarts it with backoff.
+func (s *mcpSupervisor) failed(name string, gen int, err error) {
+	slog.Warn("MCP server failed, restarting", "name", name, "error", err)
+	state, _ := states.Get(name)
+	updateState(name, StateError, err, nil, state.Counts)
+
+	delay := restartBackoff
+	for attempt := 1; attempt <= maxRestarts; attempt++ {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		ctx, cfg, _, cfgErr := s.config(name)
+		if cfgErr != nil || !s.current(name, gen) {
+			return
+		}
+		slog.Info("Restarting MCP server", "name", name, "attempt", attempt)
+		updateState(name, StateStarting, nil, nil, state.Counts)
+		if connect(ctx, cfg, name, cfg.MCP[name]) == nil {
+			return
+		}
+		delay = min(delay*2, maxRestartBackoff)
+	}
+	slog.Error("Giving up restarting MCP server", "name", name, "attempts", maxRestarts)
+}
+
+// Enable starts the MCP server name, even when it is disabled in the
+// configuration. It returns before the server is connected.
+func Enable(name string) error {
+	ctx, cfg, _, err := supervisor.config(name)
+	if err != nil {
+		return err
+	}
+	gen := supervisor.next(name, true)
+	shutdown(name)
+	updateState(name, StateStarting, nil, nil, Counts{})
+	go func() {
+		if supervisor.current(name, gen) {
+			_ = connect(ctx, cfg, name, cfg.MCP[name])
+		}
+	}()
+	return nil
+}
+
+// Disable stops the MCP server name until it is enabled again, removing its
+// tools, prompts and resources.
+func Disable(name string) error {
+	if _, _, _, err := supervisor.config(name); err != nil {
+		return err
+	}
+	supervisor.next(name, false)
+	shutdown(name)
+	allTools.Del(name)
+	allPrompts.Del(name)
+	allResources.Del(name)
+	updateState(name, StateDisabled, nil, nil, Counts{})
+	return nil
+}
+
+// Restart reconnects to the MCP server name.
+func Restart(name string) error {
+	return Enable(name)
+}
+
+// Enabled reports whether the MCP server name is enabled, taking the
+// changes made at runtime into account.
+func Enabled(name string) bool {
+	supervisor.mu.Lock()
+	enabled, ok := supervisor.enabled[name]
+	cfg := supervisor.cfg
+	supervisor.mu.Unlock()
+	if ok {
+		return enabled
+	}
+	if cfg == nil {
+		return false
+	}
+	m, configured := cfg.MCP[name]
+	return configured && !m.Disabled
+}
+
+// shutdown closes the session of name, if any.
+func shutdown(name string) {
+	sess, ok := sessions.Take(name)
+	if !ok {
+		return
+	}
+	if err := sess.Close(); err != nil {
+		slog.Debug("Closed MCP client", "name", name, "error", err)
+	}
+}
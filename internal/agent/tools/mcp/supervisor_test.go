@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// testServerEnv makes the test binary run as an MCP server with a tool
+// crashing it.
+const testServerEnv = "CRUSH_TEST_MCP_SERVER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testServerEnv) == "1" {
+		server := mcp.NewServer(&mcp.Implementation{Name: "test-server"}, nil)
+		mcp.AddTool(server, &mcp.Tool{Name: "crash"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+			os.Exit(1)
+			return nil, nil, nil
+		})
+		_ = server.Run(context.Background(), &mcp.StdioTransport{})
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func TestSupervisor(t *testing.T) {
+	t.Setenv("CRUSH_DISABLE_PROVIDER_AUTO_UPDATE", "1")
+	prevBackoff := restartBackoff
+	restartBackoff = 10 * time.Millisecond
+	t.Cleanup(func() { restartBackoff = prevBackoff })
+
+	cfg, err := config.Init(t.TempDir(), t.TempDir(), false)
+	require.NoError(t, err)
+	cfg.MCP = map[string]config.MCPConfig{
+		"test": {
+			Type:    config.MCPStdio,
+			Command: os.Args[0],
+			Env:     map[string]string{testServerEnv: "1"},
+		},
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	t.Cleanup(cancel)
+
+	Initialize(ctx, nil, cfg)
+	requireState(t, StateConnected)
+	state, _ := GetState("test")
+	require.Equal(t, 1, state.Counts.Tools)
+	require.True(t, Enabled("test"))
+
+	require.NoError(t, Disable("test"))
+	requireState(t, StateDisabled)
+	require.False(t, Enabled("test"))
+	_, ok := allTools.Get("test")
+	require.False(t, ok)
+
+	require.NoError(t, Enable("test"))
+	requireState(t, StateConnected)
+	require.True(t, Enabled("test"))
+
+	before, _ := GetState("test")
+	_, err = RunTool(ctx, cfg, "test", "crash", "{}")
+	require.Error(t, err)
+	require.Eventually(t, func() bool {
+		state, _ := GetState("test")
+		return state.State == StateConnected && state.Client != before.Client
+	}, 10*time.Second, 10*time.Millisecond)
+
+	require.Error(t, Enable("unknown"))
+	for _, sess := range sessions.Seq2() {
+		_ = sess.Close()
+	}
+	supervisor.stop()
+}
+
+func requireState(t *testing.T, want State) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		state, _ := GetState("test")
+		return state.State == want
+	}, 10*time.Second, 10*time.Millisecond, "want %s", want)
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"iter"
 	"log/slog"
-	"slices"
 	"strings"
 
 	"github.com/charmbracelet/crush/internal/config"
@@ -38,6 +37,10 @@ func RunTool(ctx context.Context, cfg *config.Config, name, toolName string, inp
 		return ToolResult{}, fmt.Errorf("error parsing parameters: %s", err)
 	}
 
+	if m, ok := cfg.MCP[name]; ok && !m.ToolAllowed(toolName) {
+		return ToolResult{}, fmt.Errorf("tool %s of mcp '%s' is disabled in the configuration", toolName, name)
+	}
+
 	c, err := getOrRenewClient(ctx, cfg, name)
 	if err != nil {
 		return ToolResult{}, err
@@ -149,16 +152,17 @@ func updateTools(cfg *config.Config, name string, tools []*Tool) int {
 	return len(tools)
 }
 
-// filterDisabledTools removes tools that are disabled via config.
+// filterDisabledTools removes tools that the enabled_tools and
+// disabled_tools of the config keep from the agent.
 func filterDisabledTools(cfg *config.Config, mcpName string, tools []*Tool) []*Tool {
 	mcpCfg, ok := cfg.MCP[mcpName]
-	if !ok || len(mcpCfg.DisabledTools) == 0 {
+	if !ok || (len(mcpCfg.EnabledTools) == 0 && len(mcpCfg.DisabledTools) == 0) {
 		return tools
 	}
 
 	filtered := make([]*Tool, 0, len(tools))
 	for _, tool := range tools {
-		if !slices.Contains(mcpCfg.DisabledTools, tool.Name) {
+		if mcpCfg.ToolAllowed(tool.Name) {
 			filtered = append(filtered, tool)
 		}
 	}
@@ -0,0 +1,38 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterDisabledTools(t *testing.T) {
+	t.Parallel()
+
+	tools := []*Tool{{Name: "search_issues"}, {Name: "get_issue"}, {Name: "delete_repo"}, {Name: "create_issue"}}
+	names := func(tools []*Tool) []string {
+		var names []string
+		for _, tool := range tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+
+	for _, tc := range []struct {
+		name string
+		mcp  config.MCPConfig
+		want []string
+	}{
+		{"all", config.MCPConfig{}, []string{"search_issues", "get_issue", "delete_repo", "create_issue"}},
+		{"disabled", config.MCPConfig{DisabledTools: []string{"delete_*", "create_issue"}}, []string{"search_issues", "get_issue"}},
+		{"enabled", config.MCPConfig{EnabledTools: []string{"search_issues", "get_*"}}, []string{"search_issues", "get_issue"}},
+		{"disabled wins", config.MCPConfig{EnabledTools: []string{"*_issue*"}, DisabledTools: []string{"create_issue"}}, []string{"search_issues", "get_issue"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := &config.Config{MCP: map[string]config.MCPConfig{"github": tc.mcp}}
+			require.Equal(t, tc.want, names(filterDisabledTools(cfg, "github", tools)))
+		})
+	}
+}
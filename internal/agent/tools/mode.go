@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/staging"
+)
+
+// Mode controls whether the agent may change the workspace.
+type Mode string
+
+const (
+	// ModeBuild lets the agent edit files and run any command, subject to
+	// permissions.
+	ModeBuild Mode = "build"
+	// ModePlan keeps the workspace untouched: edits are returned as proposed
+	// diffs and only read-only commands run.
+	ModePlan Mode = "plan"
+	// ModeReview stages file edits for the user to review and apply
+	// instead of writing them.
+	ModeReview Mode = "review"
+)
+
+type (
+	modeContextKey    string
+	stagingContextKey string
+)
+
+// ModeContextKey is the key for the agent mode getter in the context. It
+// holds a func() Mode so switching modes mid-run applies to the next tool
+// call.
+const ModeContextKey modeContextKey = "mode"
+
+// StagingContextKey is the key for the queue review mode stages edits in.
+const StagingContextKey stagingContextKey = "staging"
+
+// WithMode returns a copy of ctx where tools read the mode from mode.
+func WithMode(ctx context.Context, mode func() Mode) context.Context {
+	return context.WithValue(ctx, ModeContextKey, mode)
+}
+
+// GetModeFromContext retrieves the agent mode from the context, defaulting
+// to ModeBuild.
+func GetModeFromContext(ctx context.Context) Mode {
+	mode, ok := ctx.Value(ModeContextKey).(func() Mode)
+	if !ok || mode == nil {
+		return ModeBuild
+	}
+	return mode()
+}
+
+// WithStaging returns a copy of ctx where review mode stages edits in queue.
+func WithStaging(ctx context.Context, queue *staging.Queue) context.Context {
+	return context.WithValue(ctx, StagingContextKey, queue)
+}
+
+// GetStagingFromContext retrieves the staged edits queue from the context.
+func GetStagingFromContext(ctx context.Context) *staging.Queue {
+	queue, _ := ctx.Value(StagingContextKey).(*staging.Queue)
+	return queue
+}
+
+// IsPlanMode reports whether tools must leave the workspace untouched.
+func IsPlanMode(ctx context.Context) bool {
+	return GetModeFromContext(ctx) == ModePlan
+}
+
+// holdChange keeps a file change from being written when the mode says
+// so. Plan mode describes the change, review mode stages it. held is false
+// when the change should be written as usual. metadata is what the tool
+// would have returned had it written the file, so the change renders the
+// same way.
+func holdChange(ctx context.Context, call fantasy.ToolCall, filePath, oldContent, newContent, workingDir string, metadata any) (resp fantasy.ToolResponse, held bool) {
+	mode := GetModeFromContext(ctx)
+	if mode == ModeBuild {
+		return fantasy.ToolResponse{}, false
+	}
+	// Without a queue to stage in, review mode falls back to only
+	// describing the change.
+	if queue := GetStagingFromContext(ctx); mode == ModeReview && queue != nil {
+		edit := queue.Stage(staging.Edit{
+			SessionID:  GetSessionFromContext(ctx),
+			ToolCallID: call.ID,
+			Tool:       call.Name,
+			Path:       filePath,
+			OldContent: oldContent,
+			NewContent: newContent,
+		})
+		text := fmt.Sprintf("<staged_change>\nStaged %s for the user to review. It is not written until they apply it, so the file on disk still has its old content; put every change to this file in one edit.\n</staged_change>", edit.Summary(workingDir))
+		return fantasy.WithResponseMetadata(fantasy.NewTextResponse(text), metadata), true
+	}
+	return proposedChange(filePath, oldContent, newContent, workingDir, metadata), true
+}
+
+// proposedChange describes a file change that plan mode kept from being
+// written. metadata is what the tool would have returned had it written the
+// file, so the change renders the same way. Blocks the change moves are told
+// in a line each rather than repeated in full.
+func proposedChange(filePath, oldContent, newContent, workingDir string, metadata any) fantasy.ToolResponse {
+	fileDiff := diff.GenerateCompactDiff(oldContent, newContent, strings.TrimPrefix(filePath, workingDir))
+	text := fmt.Sprintf("<proposed_change>\nPlan mode is on, so %s was not changed. This is the diff that would be applied:\n\n%s</proposed_change>", filePath, fileDiff)
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse(text), metadata)
+}
+
+// chainsCommands reports whether command runs more than one command or
+// redirects output, which would let a read-only prefix hide a write.
+func chainsCommands(command string) bool {
+	return strings.ContainsAny(command, ";&|>`\n") || strings.Contains(command, "$(")
+}
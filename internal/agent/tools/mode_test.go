@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/staging"
+	"github.com/stretchr/testify/require"
+)
+
+func planContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	return WithMode(ctx, func() Mode { return ModePlan })
+}
+
+func runTool(t *testing.T, ctx context.Context, tool fantasy.AgentTool, params any) fantasy.ToolResponse {
+	t.Helper()
+	input, err := json.Marshal(params)
+	require.NoError(t, err)
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: tool.Info().Name, Input: string(input)})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestGetModeFromContext(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, ModeBuild, GetModeFromContext(t.Context()))
+
+	mode := ModePlan
+	ctx := WithMode(t.Context(), func() Mode { return mode })
+	require.True(t, IsPlanMode(ctx))
+	mode = ModeBuild
+	require.False(t, IsPlanMode(ctx), "switching modes applies to the next call")
+}
+
+func TestPlanModeWrite(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tool := NewWriteTool(nil, nil, nil, nil, nil, &mockPermissionService{}, &mockHistoryService{}, nil, dir)
+	resp := runTool(t, planContext(t), tool, WriteParams{FilePath: "sub/main.go", Content: "package main\n"})
+
+	require.False(t, resp.IsError)
+	require.Contains(t, resp.Content, "<proposed_change>")
+	require.Contains(t, resp.Content, "+package main")
+	require.NoDirExists(t, filepath.Join(dir, "sub"))
+
+	var meta WriteResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Equal(t, 1, meta.Additions)
+}
+
+func TestPlanModeEditNewFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "notes.md")
+	tool := NewEditTool(nil, nil, nil, nil, nil, &mockPermissionService{}, &mockHistoryService{}, nil, dir)
+	resp := runTool(t, planContext(t), tool, EditParams{FilePath: path, NewString: "# Notes\n"})
+
+	require.False(t, resp.IsError)
+	require.Contains(t, resp.Content, "+# Notes")
+	require.NoFileExists(t, path)
+	require.NoDirExists(t, filepath.Dir(path))
+}
+
+func TestReviewModeStagesEdits(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	queue := staging.New(nil)
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	ctx = WithStaging(WithMode(ctx, func() Mode { return ModeReview }), queue)
+	tool := NewWriteTool(nil, nil, nil, nil, nil, &mockPermissionService{}, &mockHistoryService{}, nil, dir)
+	resp := runTool(t, ctx, tool, WriteParams{FilePath: "main.go", Content: "package main\n"})
+
+	require.False(t, resp.IsError)
+	require.Contains(t, resp.Content, "<staged_change>")
+	require.NoFileExists(t, filepath.Join(dir, "main.go"))
+
+	pending := queue.Pending()
+	require.Len(t, pending, 1)
+	require.Equal(t, "session", pending[0].SessionID)
+	require.Equal(t, filepath.Join(dir, "main.go"), pending[0].Path)
+	require.Equal(t, "package main\n", pending[0].NewContent)
+
+	_, err := queue.Apply(t.Context(), pending[0].ID)
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(dir, "main.go"))
+}
+
+func TestPlanModeBash(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "keep.txt")
+	require.NoError(t, os.WriteFile(target, []byte("keep"), 0o644))
+	tool := NewBashTool(&mockPermissionService{}, dir, &config.Attribution{}, "", false, nil, nil)
+
+	for _, command := range []string{
+		"rm keep.txt",
+		"ls && rm keep.txt",
+		"echo gone > keep.txt",
+		"ls $(rm keep.txt)",
+	} {
+		resp := runTool(t, planContext(t), tool, BashParams{Command: command, Description: "test"})
+		require.True(t, resp.IsError, command)
+		require.Contains(t, resp.Content, "plan mode is on", command)
+	}
+	require.FileExists(t, target)
+
+	resp := runTool(t, planContext(t), tool, BashParams{Command: "ls", Description: "list"})
+	require.False(t, resp.IsError)
+	require.Contains(t, resp.Content, "keep.txt")
+}
+
+func TestBashPersistentShell(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o755))
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "persistent-"+t.Name())
+	tool := NewBashTool(&mockPermissionService{}, dir, &config.Attribution{}, "", true, nil, nil)
+	reset := NewResetShellTool()
+
+	resp := runTool(t, ctx, tool, BashParams{Command: "cd sub && export GREETING=hi", Description: "setup"})
+	require.False(t, resp.IsError, resp.Content)
+
+	resp = runTool(t, ctx, tool, BashParams{Command: "echo $GREETING; pwd", Description: "check"})
+	require.Contains(t, resp.Content, "hi\n")
+	require.Contains(t, resp.Content, "<cwd>"+normalizeWorkingDir(filepath.Join(dir, "sub"))+"</cwd>")
+
+	resp = runTool(t, ctx, reset, ResetShellParams{})
+	require.Contains(t, resp.Content, "Shell reset")
+
+	resp = runTool(t, ctx, tool, BashParams{Command: "echo \"[$GREETING]\"", Description: "check"})
+	require.Contains(t, resp.Content, "[]")
+	require.Contains(t, resp.Content, "<cwd>"+normalizeWorkingDir(dir)+"</cwd>")
+}
+
+func TestBashDescriptionPersistent(t *testing.T) {
+	t.Parallel()
+
+	require.Contains(t, bashDescription(&config.Attribution{}, "", false, nil), "no state persistence between calls")
+	persistent := bashDescription(&config.Attribution{}, "", true, nil)
+	require.Contains(t, persistent, "reset_shell")
+	require.NotContains(t, persistent, "no state persistence between calls")
+}
+
+func TestBashDescriptionSandbox(t *testing.T) {
+	t.Parallel()
+
+	require.NotContains(t, bashDescription(&config.Attribution{}, "", false, nil), "sandbox")
+	sandboxed := bashDescription(&config.Attribution{}, "", false, &shell.Sandbox{Backend: shell.SandboxBwrap})
+	require.Contains(t, sandboxed, "Commands run in a bwrap sandbox: only the working directory and the temporary directory can be written, and the network is blocked.")
+}
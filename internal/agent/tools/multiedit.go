@@ -11,9 +11,13 @@ import (
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/buildcheck"
+	"github.com/charmbracelet/crush/internal/codeowners"
 	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/fileheader"
 	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/formatter"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/lsp"
@@ -59,6 +63,10 @@ var multieditDescription []byte
 
 func NewMultiEditTool(
 	lspManager *lsp.Manager,
+	formatters *formatter.Runner,
+	checks *buildcheck.Runner,
+	owners *codeowners.Checker,
+	headers *fileheader.Policy,
 	permissions permission.Service,
 	files history.Service,
 	filetracker filetracker.Service,
@@ -86,13 +94,15 @@ func NewMultiEditTool(
 			var response fantasy.ToolResponse
 			var err error
 
-			editCtx := editContext{ctx, permissions, files, filetracker, workingDir}
+			editCtx := editContext{ctx, permissions, files, filetracker, workingDir, formatters, owners, headers}
+			unlock := lockFile(ctx, params.FilePath)
 			// Handle file creation case (first edit has empty old_string)
 			if len(params.Edits) > 0 && params.Edits[0].OldString == "" {
 				response, err = processMultiEditWithCreation(editCtx, params, call)
 			} else {
 				response, err = processMultiEditExistingFile(editCtx, params, call)
 			}
+			unlock()
 
 			if err != nil {
 				return response, err
@@ -108,6 +118,9 @@ func NewMultiEditTool(
 			// Wait for LSP diagnostics and add them to the response
 			text := fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
 			text += getDiagnostics(params.FilePath, lspManager)
+			text += checks.Check(ctx, params.FilePath)
+			text += ownershipNote(owners, workingDir, params.FilePath)
+			text += headerNote(headers, params.FilePath)
 			response.Content = text
 			return response, nil
 		})
@@ -137,12 +150,6 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to access file: %w", err)
 	}
 
-	// Create parent directories
-	dir := filepath.Dir(params.FilePath)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fantasy.ToolResponse{}, fmt.Errorf("failed to create parent directories: %w", err)
-	}
-
 	// Start with the content from the first edit
 	currentContent := firstEdit.NewString
 
@@ -168,6 +175,9 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for creating a new file")
 	}
 
+	currentContent = edit.headers.Apply(params.FilePath, currentContent)
+	currentContent = formatContent(edit.ctx, edit.formatters, params.FilePath, currentContent)
+
 	// Check permissions
 	_, additions, removals := diff.GenerateDiff("", currentContent, strings.TrimPrefix(params.FilePath, edit.workingDir))
 
@@ -178,9 +188,19 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 	} else {
 		description = fmt.Sprintf("Create file %s with %d edits", params.FilePath, editsApplied)
 	}
-	p, err := edit.permissions.Request(edit.ctx, permission.CreatePermissionRequest{
+	if resp, held := holdChange(edit.ctx, call, params.FilePath, "", currentContent, edit.workingDir, MultiEditResponseMetadata{
+		NewContent:   currentContent,
+		Additions:    additions,
+		Removals:     removals,
+		EditsApplied: editsApplied,
+		EditsFailed:  failedEdits,
+	}); held {
+		return resp, nil
+	}
+	p, err := edit.permissions.Request(edit.ctx, withOwnership(edit.owners, permission.CreatePermissionRequest{
 		SessionID:   sessionID,
 		Path:        fsext.PathOrPrefix(params.FilePath, edit.workingDir),
+		Target:      params.FilePath,
 		ToolCallID:  call.ID,
 		ToolName:    MultiEditToolName,
 		Action:      "write",
@@ -190,7 +210,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 			OldContent: "",
 			NewContent: currentContent,
 		},
-	})
+	}, params.FilePath))
 	if err != nil {
 		return fantasy.ToolResponse{}, err
 	}
@@ -198,8 +218,14 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
+	// Create parent directories
+	dir := filepath.Dir(params.FilePath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("failed to create parent directories: %w", err)
+	}
+
 	// Write the file
-	err = os.WriteFile(params.FilePath, []byte(currentContent), 0o644)
+	err = writeFileOrSudo(edit.ctx, edit.permissions, call, params.FilePath, []byte(currentContent), 0o644)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
@@ -264,7 +290,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 
 	// Check if file was modified since last read.
 	modTime := fileInfo.ModTime().Truncate(time.Second)
-	if modTime.After(lastRead) {
+	if modTime.After(lastRead) || edit.filetracker.Changed(edit.ctx, sessionID, params.FilePath) {
 		return fantasy.NewTextErrorResponse(
 			fmt.Sprintf("file %s has been modified since it was last read (mod time: %s, last read: %s)",
 				params.FilePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339),
@@ -272,7 +298,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	}
 
 	// Read current file content
-	content, err := os.ReadFile(params.FilePath)
+	content, err := readFile(edit.ctx, params.FilePath)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -311,6 +337,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	}
 
 	// Generate diff and check permissions
+	currentContent = formatContent(edit.ctx, edit.formatters, params.FilePath, currentContent)
 	_, additions, removals := diff.GenerateDiff(oldContent, currentContent, strings.TrimPrefix(params.FilePath, edit.workingDir))
 
 	editsApplied := len(params.Edits) - len(failedEdits)
@@ -320,9 +347,20 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	} else {
 		description = fmt.Sprintf("Apply %d edits to file %s", editsApplied, params.FilePath)
 	}
-	p, err := edit.permissions.Request(edit.ctx, permission.CreatePermissionRequest{
+	if resp, held := holdChange(edit.ctx, call, params.FilePath, oldContent, currentContent, edit.workingDir, MultiEditResponseMetadata{
+		OldContent:   oldContent,
+		NewContent:   currentContent,
+		Additions:    additions,
+		Removals:     removals,
+		EditsApplied: editsApplied,
+		EditsFailed:  failedEdits,
+	}); held {
+		return resp, nil
+	}
+	p, err := edit.permissions.Request(edit.ctx, withOwnership(edit.owners, permission.CreatePermissionRequest{
 		SessionID:   sessionID,
 		Path:        fsext.PathOrPrefix(params.FilePath, edit.workingDir),
+		Target:      params.FilePath,
 		ToolCallID:  call.ID,
 		ToolName:    MultiEditToolName,
 		Action:      "write",
@@ -332,7 +370,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 			OldContent: oldContent,
 			NewContent: currentContent,
 		},
-	})
+	}, params.FilePath))
 	if err != nil {
 		return fantasy.ToolResponse{}, err
 	}
@@ -345,7 +383,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	}
 
 	// Write the updated content
-	err = os.WriteFile(params.FilePath, []byte(currentContent), 0o644)
+	err = writeFileOrSudo(edit.ctx, edit.permissions, call, params.FilePath, []byte(currentContent), 0o644)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
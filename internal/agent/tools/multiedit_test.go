@@ -34,6 +34,20 @@ func (m *mockPermissionService) SkipRequests() bool {
 	return false
 }
 
+func (m *mockPermissionService) GrantForProject(req permission.PermissionRequest) {}
+
+func (m *mockPermissionService) ProjectGrants() []permission.Grant {
+	return nil
+}
+
+func (m *mockPermissionService) RevokeProjectGrant(grant permission.Grant) error {
+	return nil
+}
+
+func (m *mockPermissionService) Explain(req permission.CreatePermissionRequest) permission.Decision {
+	return permission.Decision{Effect: permission.EffectAllow}
+}
+
 func (m *mockPermissionService) SubscribeNotifications(ctx context.Context) <-chan pubsub.Event[permission.PermissionNotification] {
 	return make(<-chan pubsub.Event[permission.PermissionNotification])
 }
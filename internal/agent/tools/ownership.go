@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/codeowners"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// withOwnership adds the owners of files owned by other teams to a
+// permission request, and forces the prompt when the CODEOWNERS mode asks
+// for confirmation.
+func withOwnership(owners *codeowners.Checker, req permission.CreatePermissionRequest, paths ...string) permission.CreatePermissionRequest {
+	foreign := foreignOwners(owners, paths...)
+	if len(foreign) == 0 {
+		return req
+	}
+	req.Description += fmt.Sprintf(" (owned by %s)", strings.Join(foreign, ", "))
+	req.Confirm = owners.RequireConfirmation()
+	return req
+}
+
+// ownershipNote tells the agent which edited files are owned by other teams
+// so it can mention them when preparing a pull request.
+func ownershipNote(owners *codeowners.Checker, workingDir string, paths ...string) string {
+	var out strings.Builder
+	for _, path := range paths {
+		foreign := owners.Foreign(path)
+		if len(foreign) == 0 {
+			continue
+		}
+		if out.Len() == 0 {
+			out.WriteString("<ownership>\nThese files are owned by other teams according to CODEOWNERS; their owners will need to review the change:\n")
+		}
+		fmt.Fprintf(&out, "%s: %s\n", strings.TrimPrefix(strings.TrimPrefix(path, workingDir), string(filepath.Separator)), strings.Join(foreign, ", "))
+	}
+	if out.Len() == 0 {
+		return ""
+	}
+	out.WriteString("</ownership>\n")
+	return out.String()
+}
+
+func foreignOwners(owners *codeowners.Checker, paths ...string) []string {
+	var all []string
+	for _, path := range paths {
+		for _, owner := range owners.Foreign(path) {
+			if !slices.Contains(all, owner) {
+				all = append(all, owner)
+			}
+		}
+	}
+	return all
+}
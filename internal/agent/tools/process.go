@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+type ProcessParams struct {
+	Action      string `json:"action" description:"One of start, list, logs or stop"`
+	Command     string `json:"command,omitempty" description:"The command to start (start only)"`
+	Description string `json:"description,omitempty" description:"A brief description of the process, such as 'dev server' (start only)"`
+	ID          string `json:"id,omitempty" description:"The ID of the process (logs and stop)"`
+	Lines       int    `json:"lines,omitempty" description:"How many of the last lines of output to return (logs only, default 50)"`
+}
+
+type ProcessPermissionsParams struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+	WorkingDir  string `json:"working_dir"`
+}
+
+type ProcessResponseMetadata struct {
+	Action      string `json:"action"`
+	ID          string `json:"id,omitempty"`
+	Command     string `json:"command,omitempty"`
+	Description string `json:"description,omitempty"`
+	Running     int    `json:"running"`
+}
+
+const (
+	ProcessToolName = "process"
+
+	processStartupWait   = 2 * time.Second
+	defaultProcessLines  = 50
+	maxProcessLines      = 1000
+	processActionStart   = "start"
+	processActionList    = "list"
+	processActionLogs    = "logs"
+	processActionStop    = "stop"
+	processStatusRunning = "running"
+)
+
+//go:embed process.md
+var processDescription []byte
+
+// NewProcessTool creates the process tool, which starts long-running
+// commands such as dev servers and watchers in the background and manages
+// them, so they do not block the turn.
+func NewProcessTool(permissions permission.Service, workingDir string, sandbox *shell.Sandbox, classifier shell.Classifier) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		ProcessToolName,
+		string(processDescription),
+		func(ctx context.Context, params ProcessParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			bgManager := shell.GetBackgroundShellManager()
+			switch params.Action {
+			case processActionStart:
+				return startProcess(ctx, permissions, workingDir, sandbox, classifier, params, call)
+			case processActionList:
+				return processResponse(listProcesses(bgManager.All()), ProcessResponseMetadata{Action: params.Action}), nil
+			case processActionLogs, processActionStop:
+				if params.ID == "" {
+					return fantasy.NewTextErrorResponse("missing id"), nil
+				}
+				bgShell, ok := bgManager.Get(params.ID)
+				if !ok {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("process not found: %s", params.ID)), nil
+				}
+				metadata := ProcessResponseMetadata{
+					Action:      params.Action,
+					ID:          bgShell.ID,
+					Command:     bgShell.Command,
+					Description: bgShell.Description,
+				}
+				if params.Action == processActionLogs {
+					lines := params.Lines
+					if lines <= 0 {
+						lines = defaultProcessLines
+					}
+					return processResponse(processLogs(bgShell, min(lines, maxProcessLines)), metadata), nil
+				}
+				if err := bgManager.Kill(params.ID); err != nil {
+					return fantasy.NewTextErrorResponse(err.Error()), nil
+				}
+				return processResponse(fmt.Sprintf("Process %s stopped", params.ID), metadata), nil
+			default:
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("unknown action %q, use start, list, logs or stop", params.Action)), nil
+			}
+		})
+}
+
+func startProcess(ctx context.Context, permissions permission.Service, workingDir string, sandbox *shell.Sandbox, classifier shell.Classifier, params ProcessParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	if params.Command == "" {
+		return fantasy.NewTextErrorResponse("missing command"), nil
+	}
+	if IsPlanMode(ctx) {
+		return fantasy.NewTextErrorResponse("plan mode is on, so processes cannot be started. Include the command in your plan instead."), nil
+	}
+
+	sessionID := GetSessionFromContext(ctx)
+	if sessionID == "" {
+		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for starting a process")
+	}
+	p, err := permissions.Request(ctx,
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        workingDir,
+			Command:     params.Command,
+			ToolCallID:  call.ID,
+			ToolName:    ProcessToolName,
+			Action:      "execute",
+			Description: fmt.Sprintf("Start process: %s", params.Command),
+			Params: ProcessPermissionsParams{
+				Command:     params.Command,
+				Description: params.Description,
+				WorkingDir:  workingDir,
+			},
+			Risk: classifyCommand(ctx, classifier, params.Command),
+		},
+	)
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	if !p {
+		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	// The process outlives the tool call, so it must not use its context.
+	bgManager := shell.GetBackgroundShellManager()
+	bgShell, err := bgManager.StartIn(context.Background(), shell.NewShell(&shell.Options{
+		WorkingDir: workingDir,
+		BlockFuncs: blockFuncs(),
+		Sandbox:    sandbox,
+	}), params.Command, params.Description)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to start process: %s", err)), nil
+	}
+
+	// Give the process a moment, so a command that fails right away or
+	// prints the address it listens on is reported now.
+	select {
+	case <-time.After(processStartupWait):
+	case <-ctx.Done():
+	case <-bgShell.Done():
+	}
+
+	result := fmt.Sprintf("Process %s started.\n\n%s", bgShell.ID, processLogs(bgShell, defaultProcessLines))
+	return processResponse(result, ProcessResponseMetadata{
+		Action:      params.Action,
+		ID:          bgShell.ID,
+		Command:     bgShell.Command,
+		Description: bgShell.Description,
+	}), nil
+}
+
+func processResponse(content string, metadata ProcessResponseMetadata) fantasy.ToolResponse {
+	for _, bgShell := range shell.GetBackgroundShellManager().All() {
+		if !bgShell.IsDone() {
+			metadata.Running++
+		}
+	}
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse(content), metadata)
+}
+
+// processStatus describes whether bgShell is running, or how it exited.
+func processStatus(bgShell *shell.BackgroundShell) string {
+	_, _, done, err := bgShell.GetOutput()
+	if !done {
+		return processStatusRunning
+	}
+	return fmt.Sprintf("exited (code %d)", shell.ExitCode(err))
+}
+
+func listProcesses(shells []*shell.BackgroundShell) string {
+	if len(shells) == 0 {
+		return "No processes"
+	}
+	var out strings.Builder
+	for _, bgShell := range shells {
+		fmt.Fprintf(&out, "%s  %s", bgShell.ID, processStatus(bgShell))
+		if !bgShell.IsDone() {
+			fmt.Fprintf(&out, " for %s", time.Since(bgShell.StartedAt).Round(time.Second))
+		}
+		fmt.Fprintf(&out, "  %s", bgShell.Command)
+		if bgShell.Description != "" {
+			fmt.Fprintf(&out, "  (%s)", bgShell.Description)
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+// processLogs returns the status of bgShell and the last lines of its
+// stdout and stderr.
+func processLogs(bgShell *shell.BackgroundShell, lines int) string {
+	stdout, stderr, _, _ := bgShell.GetOutput()
+	var out strings.Builder
+	fmt.Fprintf(&out, "Status: %s\n", processStatus(bgShell))
+	if stdout == "" && stderr == "" {
+		out.WriteString("\n" + BashNoOutput + "\n")
+	}
+	if stdout != "" {
+		fmt.Fprintf(&out, "\n<stdout>\n%s\n</stdout>\n", lastLines(stdout, lines))
+	}
+	if stderr != "" {
+		fmt.Fprintf(&out, "\n<stderr>\n%s\n</stderr>\n", lastLines(stderr, lines))
+	}
+	return out.String()
+}
+
+// lastLines returns the last n lines of s, noting how many were left out.
+func lastLines(s string, n int) string {
+	all := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(all) <= n {
+		return strings.Join(all, "\n")
+	}
+	return fmt.Sprintf("[%d earlier lines not shown]\n%s", len(all)-n, strings.Join(all[len(all)-n:], "\n"))
+}
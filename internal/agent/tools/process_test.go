@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcess(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	tool := NewProcessTool(&mockPermissionService{}, t.TempDir(), nil, nil)
+
+	resp := runTool(t, ctx, tool, ProcessParams{Action: "start", Command: "echo listening && sleep 100", Description: "server"})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "Status: running")
+	require.Contains(t, resp.Content, "<stdout>\nlistening\n</stdout>")
+
+	var metadata ProcessResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &metadata))
+	require.NotEmpty(t, metadata.ID)
+	require.Positive(t, metadata.Running)
+	id := metadata.ID
+
+	resp = runTool(t, ctx, tool, ProcessParams{Action: "list"})
+	require.Contains(t, resp.Content, id+"  running for")
+	require.Contains(t, resp.Content, "echo listening && sleep 100  (server)")
+
+	resp = runTool(t, ctx, tool, ProcessParams{Action: "stop", ID: id})
+	require.False(t, resp.IsError, resp.Content)
+	_, ok := shell.GetBackgroundShellManager().Get(id)
+	require.False(t, ok)
+
+	resp = runTool(t, ctx, tool, ProcessParams{Action: "logs", ID: id})
+	require.True(t, resp.IsError)
+}
+
+func TestProcessLogs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	tool := NewProcessTool(&mockPermissionService{}, t.TempDir(), nil, nil)
+
+	resp := runTool(t, ctx, tool, ProcessParams{Action: "start", Command: "for i in 1 2 3 4 5; do echo line$i; done; exit 3"})
+	require.Contains(t, resp.Content, "Status: exited (code 3)")
+
+	var metadata ProcessResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &metadata))
+	t.Cleanup(func() { shell.GetBackgroundShellManager().Remove(metadata.ID) })
+
+	resp = runTool(t, ctx, tool, ProcessParams{Action: "logs", ID: metadata.ID, Lines: 2})
+	require.Contains(t, resp.Content, "<stdout>\n[3 earlier lines not shown]\nline4\nline5\n</stdout>")
+}
+
+func TestProcessInvalid(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	tool := NewProcessTool(&mockPermissionService{}, t.TempDir(), nil, nil)
+
+	require.Contains(t, runTool(t, ctx, tool, ProcessParams{Action: "restart"}).Content, "unknown action")
+	require.Contains(t, runTool(t, ctx, tool, ProcessParams{Action: "start"}).Content, "missing command")
+	require.Contains(t, runTool(t, ctx, tool, ProcessParams{Action: "stop"}).Content, "missing id")
+
+	resp := runTool(t, planContext(t), tool, ProcessParams{Action: "start", Command: "sleep 1"})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "plan mode is on")
+}
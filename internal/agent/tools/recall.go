@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/recall"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+//go:embed recall.md
+var recallDescription []byte
+
+const (
+	RecallToolName = "recall"
+
+	defaultRecallLimit = 5
+	maxRecallLimit     = 20
+)
+
+type RecallParams struct {
+	Query string `json:"query" description:"What to look for in the dropped conversation turns"`
+	Limit int    `json:"limit,omitempty" description:"Maximum number of turns to return (default 5, max 20)"`
+}
+
+type RecallResponseMetadata struct {
+	Query   string `json:"query"`
+	Matches int    `json:"matches"`
+	Dropped int    `json:"dropped"`
+}
+
+func NewRecallTool(sessions session.Service, store *recall.Store) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		RecallToolName,
+		string(recallDescription),
+		func(ctx context.Context, params RecallParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if strings.TrimSpace(params.Query) == "" {
+				return fantasy.NewTextErrorResponse("query is required"), nil
+			}
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for recall")
+			}
+
+			currentSession, err := sessions.Get(ctx, sessionID)
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("failed to get session: %w", err)
+			}
+			if currentSession.SummaryMessageID == "" {
+				return fantasy.NewTextResponse("No conversation turns have been dropped in this session yet."), nil
+			}
+
+			turns, err := store.Turns(ctx, sessionID, currentSession.SummaryMessageID)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+
+			limit := cmp.Or(params.Limit, defaultRecallLimit)
+			limit = min(max(limit, 1), maxRecallLimit)
+			matches := recall.Search(turns, params.Query, limit)
+
+			metadata := RecallResponseMetadata{
+				Query:   params.Query,
+				Matches: len(matches),
+				Dropped: len(turns),
+			}
+			if len(matches) == 0 {
+				return fantasy.WithResponseMetadata(
+					fantasy.NewTextResponse("No dropped turns matched the query."),
+					metadata,
+				), nil
+			}
+
+			var sb strings.Builder
+			sb.WriteString("<recalled>\n")
+			for _, turn := range matches {
+				fmt.Fprintf(&sb, "<turn role=%q message_id=%q>\n%s\n</turn>\n", turn.Role, turn.MessageID, turn.Text)
+			}
+			sb.WriteString("</recalled>")
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(sb.String()), metadata), nil
+		})
+}
@@ -0,0 +1,428 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/buildcheck"
+	"github.com/charmbracelet/crush/internal/codeowners"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/formatter"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/staging"
+)
+
+type ReplaceAllParams struct {
+	Pattern     string `json:"pattern" description:"The text or regular expression to search for"`
+	Replacement string `json:"replacement" description:"The replacement text; with regex, $1 and ${name} expand capture groups"`
+	Regex       bool   `json:"regex,omitempty" description:"Interpret pattern as a regular expression (default false)"`
+	Path        string `json:"path,omitempty" description:"The directory or file to search in. Defaults to the current working directory."`
+	Include     string `json:"include,omitempty" description:"File pattern to include in the search (e.g. \"*.js\", \"*.{ts,tsx}\")"`
+	Apply       bool   `json:"apply,omitempty" description:"Write the changes instead of returning a preview (default false)"`
+}
+
+type ReplaceAllPermissionsParams struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+	Regex       bool   `json:"regex,omitempty"`
+	Files       int    `json:"files"`
+	Matches     int    `json:"matches"`
+	Diff        string `json:"diff"`
+}
+
+type ReplaceAllFile struct {
+	Path      string `json:"path"`
+	Matches   int    `json:"matches"`
+	Additions int    `json:"additions"`
+	Removals  int    `json:"removals"`
+}
+
+type ReplaceAllResponseMetadata struct {
+	Applied   bool             `json:"applied"`
+	Files     []ReplaceAllFile `json:"files"`
+	Matches   int              `json:"matches"`
+	Truncated bool             `json:"truncated,omitempty"`
+}
+
+const (
+	ReplaceAllToolName = "replace_all"
+
+	// replaceAllFileLimit caps how many files a single call may rewrite.
+	replaceAllFileLimit = 100
+)
+
+//go:embed replace_all.md
+var replaceAllDescription []byte
+
+// replaceAllChange is the pending rewrite of a single file.
+type replaceAllChange struct {
+	path       string
+	oldContent string
+	newContent string
+	isCrlf     bool
+	matches    int
+	diff       string
+	additions  int
+	removals   int
+}
+
+func NewReplaceAllTool(
+	lspManager *lsp.Manager,
+	formatters *formatter.Runner,
+	checks *buildcheck.Runner,
+	owners *codeowners.Checker,
+	permissions permission.Service,
+	files history.Service,
+	filetracker filetracker.Service,
+	workingDir string,
+) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		ReplaceAllToolName,
+		string(replaceAllDescription),
+		func(ctx context.Context, params ReplaceAllParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Pattern == "" {
+				return fantasy.NewTextErrorResponse("pattern is required"), nil
+			}
+
+			pattern := params.Pattern
+			if !params.Regex {
+				pattern = regexp.QuoteMeta(pattern)
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid regex pattern: %s", err)), nil
+			}
+
+			searchPath := workingDir
+			if params.Path != "" {
+				searchPath = filepathext.SmartJoin(workingDir, params.Path)
+			}
+
+			changes, truncated, err := findReplacements(ctx, re, params.Replacement, !params.Regex, searchPath, params.Include, workingDir)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if len(changes) == 0 {
+				return fantasy.NewTextResponse("No matches found"), nil
+			}
+
+			// Plan mode only ever previews.
+			if !params.Apply || IsPlanMode(ctx) {
+				return fantasy.WithResponseMetadata(
+					fantasy.NewTextResponse(formatReplaceAllPreview(changes, truncated)),
+					replaceAllMetadata(changes, false, truncated),
+				), nil
+			}
+			changes = formatReplacements(ctx, formatters, changes, workingDir)
+			if queue := GetStagingFromContext(ctx); GetModeFromContext(ctx) == ModeReview && queue != nil {
+				var output strings.Builder
+				fmt.Fprintf(&output, "<staged_change>\nStaged %d files for the user to review. They are not written until the user applies them:\n", len(changes))
+				for _, c := range changes {
+					edit := queue.Stage(staging.Edit{
+						SessionID:  GetSessionFromContext(ctx),
+						ToolCallID: call.ID,
+						Tool:       ReplaceAllToolName,
+						Path:       c.path,
+						OldContent: c.oldContent,
+						NewContent: c.newContent,
+					})
+					fmt.Fprintf(&output, "%s\n", edit.Summary(workingDir))
+				}
+				output.WriteString("</staged_change>")
+				return fantasy.WithResponseMetadata(
+					fantasy.NewTextResponse(output.String()),
+					replaceAllMetadata(changes, false, truncated),
+				), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for replacing content")
+			}
+
+			var combined strings.Builder
+			totalMatches := 0
+			for _, c := range changes {
+				combined.WriteString(c.diff)
+				totalMatches += c.matches
+			}
+
+			changedPaths := make([]string, 0, len(changes))
+			for _, c := range changes {
+				changedPaths = append(changedPaths, c.path)
+			}
+			p, err := permissions.Request(ctx, withOwnership(owners, permission.CreatePermissionRequest{
+				SessionID:   sessionID,
+				Path:        fsext.PathOrPrefix(searchPath, workingDir),
+				Target:      searchPath,
+				ToolCallID:  call.ID,
+				ToolName:    ReplaceAllToolName,
+				Action:      "write",
+				Description: fmt.Sprintf("Replace %d matches in %d files", totalMatches, len(changes)),
+				Params: ReplaceAllPermissionsParams{
+					Pattern:     params.Pattern,
+					Replacement: params.Replacement,
+					Regex:       params.Regex,
+					Files:       len(changes),
+					Matches:     totalMatches,
+					Diff:        combined.String(),
+				},
+			}, changedPaths...))
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			var written []replaceAllChange
+			for _, c := range changes {
+				if err := applyReplacement(ctx, permissions, call, files, filetracker, sessionID, c); err != nil {
+					slog.Error("Error applying replacement", "file", c.path, "error", err)
+					continue
+				}
+				written = append(written, c)
+				notifyLSPs(ctx, lspManager, c.path)
+			}
+
+			var output strings.Builder
+			fmt.Fprintf(&output, "<result>\nReplaced matches in %d of %d files:\n", len(written), len(changes))
+			for _, c := range written {
+				fmt.Fprintf(&output, "%s: %d matches\n", c.path, c.matches)
+			}
+			if truncated {
+				fmt.Fprintf(&output, "\nStopped after %d files; run again to replace remaining matches.\n", replaceAllFileLimit)
+			}
+			output.WriteString("</result>\n")
+
+			paths := make([]string, 0, len(written))
+			for _, c := range written {
+				paths = append(paths, c.path)
+			}
+			output.WriteString(getChangedFilesDiagnostics(paths, lspManager))
+			output.WriteString(checks.Check(ctx, paths...))
+			output.WriteString(ownershipNote(owners, workingDir, paths...))
+
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(output.String()),
+				replaceAllMetadata(written, true, truncated),
+			), nil
+		})
+}
+
+// findReplacements walks rootPath honoring ignore files and returns the
+// pending change for every text file the pattern matches in, sorted by path.
+func findReplacements(ctx context.Context, re *regexp.Regexp, replacement string, literal bool, rootPath, include, workingDir string) ([]replaceAllChange, bool, error) {
+	info, err := os.Stat(rootPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("path not found: %s", rootPath)
+	}
+
+	var includePattern *regexp.Regexp
+	if include != "" {
+		includePattern, err = globRegexCache.get("^" + globToRegex(include) + "$")
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid include pattern: %w", err)
+		}
+	}
+
+	var candidates []string
+	if info.IsDir() {
+		walker := fsext.NewFastGlobWalker(rootPath)
+		err = filepath.WalkDir(rootPath, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip errors
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if d.IsDir() {
+				if path != rootPath && walker.ShouldSkipDir(path) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if walker.ShouldSkip(path) {
+				return nil
+			}
+			// Skip hidden files to match grep's behavior.
+			base := filepath.Base(path)
+			if strings.HasPrefix(base, ".") {
+				return nil
+			}
+			if includePattern != nil && !includePattern.MatchString(base) {
+				return nil
+			}
+			candidates = append(candidates, path)
+			return nil
+		})
+		if err != nil {
+			return nil, false, err
+		}
+	} else {
+		candidates = []string{rootPath}
+	}
+	sort.Strings(candidates)
+
+	var changes []replaceAllChange
+	for _, path := range candidates {
+		if !isTextFile(path) {
+			continue
+		}
+		change, ok := replaceInFile(path, re, replacement, literal, workingDir)
+		if !ok {
+			continue
+		}
+		if len(changes) >= replaceAllFileLimit {
+			return changes, true, nil
+		}
+		changes = append(changes, change)
+	}
+	return changes, false, nil
+}
+
+// replaceInFile computes the replacement for a single file. It reports false
+// when the file cannot be read or has no matches.
+func replaceInFile(path string, re *regexp.Regexp, replacement string, literal bool, workingDir string) (replaceAllChange, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return replaceAllChange{}, false
+	}
+
+	oldContent, isCrlf := fsext.ToUnixLineEndings(string(content))
+	matches := len(re.FindAllStringIndex(oldContent, -1))
+	if matches == 0 {
+		return replaceAllChange{}, false
+	}
+
+	var newContent string
+	if literal {
+		newContent = re.ReplaceAllLiteralString(oldContent, replacement)
+	} else {
+		newContent = re.ReplaceAllString(oldContent, replacement)
+	}
+	if newContent == oldContent {
+		return replaceAllChange{}, false
+	}
+
+	d, additions, removals := diff.GenerateDiff(oldContent, newContent, strings.TrimPrefix(path, workingDir))
+	return replaceAllChange{
+		path:       path,
+		oldContent: oldContent,
+		newContent: newContent,
+		isCrlf:     isCrlf,
+		matches:    matches,
+		diff:       d,
+		additions:  additions,
+		removals:   removals,
+	}, true
+}
+
+// formatReplacements runs the configured formatters on the new content of
+// changes, before they are staged or shown for approval.
+func formatReplacements(ctx context.Context, formatters *formatter.Runner, changes []replaceAllChange, workingDir string) []replaceAllChange {
+	for i, c := range changes {
+		formatted := formatContent(ctx, formatters, c.path, c.newContent)
+		if formatted == c.newContent {
+			continue
+		}
+		c.newContent = formatted
+		c.diff, c.additions, c.removals = diff.GenerateDiff(c.oldContent, c.newContent, strings.TrimPrefix(c.path, workingDir))
+		changes[i] = c
+	}
+	return changes
+}
+
+// applyReplacement writes a pending change and records it in the file
+// history.
+func applyReplacement(ctx context.Context, permissions permission.Service, call fantasy.ToolCall, files history.Service, filetracker filetracker.Service, sessionID string, c replaceAllChange) error {
+	content := c.newContent
+	if c.isCrlf {
+		content, _ = fsext.ToWindowsLineEndings(content)
+	}
+
+	defer lockFile(ctx, c.path)()
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return fmt.Errorf("failed to access file: %w", err)
+	}
+	if err := writeFileOrSudo(ctx, permissions, call, c.path, []byte(content), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	// Check if file exists in history
+	file, err := files.GetByPathAndSession(ctx, c.path, sessionID)
+	if err != nil {
+		_, err = files.Create(ctx, sessionID, c.path, c.oldContent)
+		if err != nil {
+			return fmt.Errorf("error creating file history: %w", err)
+		}
+	}
+	if file.Content != c.oldContent {
+		// User manually changed the content; store an intermediate version
+		_, err = files.CreateVersion(ctx, sessionID, c.path, c.oldContent)
+		if err != nil {
+			slog.Error("Error creating file history version", "error", err)
+		}
+	}
+	// Store the new version
+	_, err = files.CreateVersion(ctx, sessionID, c.path, c.newContent)
+	if err != nil {
+		slog.Error("Error creating file history version", "error", err)
+	}
+
+	filetracker.RecordRead(ctx, sessionID, c.path)
+	return nil
+}
+
+func formatReplaceAllPreview(changes []replaceAllChange, truncated bool) string {
+	var output strings.Builder
+	totalMatches := 0
+	for _, c := range changes {
+		totalMatches += c.matches
+	}
+	fmt.Fprintf(&output, "Preview: %d matches in %d files (nothing written yet)\n\n", totalMatches, len(changes))
+	for _, c := range changes {
+		fmt.Fprintf(&output, "%s: %d matches\n", c.path, c.matches)
+	}
+	if truncated {
+		fmt.Fprintf(&output, "\n(Results limited to %d files. Narrow path or include to see the rest.)\n", replaceAllFileLimit)
+	}
+	output.WriteString("\n")
+	for _, c := range changes {
+		output.WriteString(c.diff)
+	}
+	output.WriteString("\nCall again with apply=true to write these changes.")
+	return truncateOutput(output.String())
+}
+
+func replaceAllMetadata(changes []replaceAllChange, applied, truncated bool) ReplaceAllResponseMetadata {
+	meta := ReplaceAllResponseMetadata{
+		Applied:   applied,
+		Files:     make([]ReplaceAllFile, 0, len(changes)),
+		Truncated: truncated,
+	}
+	for _, c := range changes {
+		meta.Matches += c.matches
+		meta.Files = append(meta.Files, ReplaceAllFile{
+			Path:      c.path,
+			Matches:   c.matches,
+			Additions: c.additions,
+			Removals:  c.removals,
+		})
+	}
+	return meta
+}
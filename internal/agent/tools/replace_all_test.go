@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindReplacements(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.go":           "package a\n\nfunc OldName() {}\n",
+		"sub/b.go":       "package sub\n\nvar _ = OldName\nvar _ = OldName\n",
+		"sub/c.txt":      "OldName in text\n",
+		"ignored/d.go":   "OldName\n",
+		".hidden.go":     "OldName\n",
+		"nomatch.go":     "package nomatch\n",
+		".gitignore":     "ignored/\n",
+		"sub/crlf.go":    "OldName\r\nOldName\r\n",
+		"sub/x.gofoo":    "OldName\n",
+		"sub/regex.go":   "foo(1) foo(22)\n",
+		"sub/literal.go": "a.b axb\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	t.Run("literal with include", func(t *testing.T) {
+		t.Parallel()
+		re := regexp.MustCompile(regexp.QuoteMeta("OldName"))
+		changes, truncated, err := findReplacements(t.Context(), re, "NewName", true, dir, "*.go", dir)
+		require.NoError(t, err)
+		require.False(t, truncated)
+
+		got := map[string]int{}
+		for _, c := range changes {
+			rel, err := filepath.Rel(dir, c.path)
+			require.NoError(t, err)
+			got[filepath.ToSlash(rel)] = c.matches
+		}
+		require.Equal(t, map[string]int{"a.go": 1, "sub/b.go": 2, "sub/crlf.go": 2}, got)
+
+		for _, c := range changes {
+			require.NotContains(t, c.newContent, "OldName")
+			require.NotEmpty(t, c.diff)
+		}
+	})
+
+	t.Run("literal escapes metacharacters", func(t *testing.T) {
+		t.Parallel()
+		re := regexp.MustCompile(regexp.QuoteMeta("a.b"))
+		changes, _, err := findReplacements(t.Context(), re, "$1", true, filepath.Join(dir, "sub", "literal.go"), "", dir)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, "$1 axb\n", changes[0].newContent)
+	})
+
+	t.Run("regex expands groups", func(t *testing.T) {
+		t.Parallel()
+		re := regexp.MustCompile(`foo\((\d+)\)`)
+		changes, _, err := findReplacements(t.Context(), re, "bar[$1]", false, filepath.Join(dir, "sub"), "regex.go", dir)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, 2, changes[0].matches)
+		require.Equal(t, "bar[1] bar[22]\n", changes[0].newContent)
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		t.Parallel()
+		re := regexp.MustCompile("x")
+		_, _, err := findReplacements(t.Context(), re, "y", true, filepath.Join(dir, "missing"), "", dir)
+		require.Error(t, err)
+	})
+}
+
+func TestReplaceInFilePreservesCRLF(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "crlf.txt")
+	require.NoError(t, os.WriteFile(path, []byte("one\r\ntwo\r\n"), 0o644))
+
+	change, ok := replaceInFile(path, regexp.MustCompile("one\ntwo"), "1\n2", true, "")
+	require.True(t, ok)
+	require.True(t, change.isCrlf)
+	require.Equal(t, "1\n2\n", change.newContent)
+}
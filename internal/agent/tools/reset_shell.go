@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+const (
+	ResetShellToolName = "reset_shell"
+)
+
+//go:embed reset_shell.md
+var resetShellDescription []byte
+
+type ResetShellParams struct{}
+
+func NewResetShellTool() fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		ResetShellToolName,
+		string(resetShellDescription),
+		func(ctx context.Context, params ResetShellParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for resetting the shell")
+			}
+
+			old, ok := shell.GetSessionShells().Reset(sessionID)
+			if !ok {
+				return fantasy.NewTextResponse("The shell is already fresh; the next command starts in the project directory."), nil
+			}
+			result := "Shell reset. The next command starts in the project directory with the original environment."
+			if killed := shell.GetBackgroundShellManager().KillIn(old); killed > 0 {
+				result += fmt.Sprintf(" Stopped %d command(s) still running in the old shell.", killed)
+			}
+			return fantasy.NewTextResponse(result), nil
+		})
+}
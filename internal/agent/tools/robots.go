@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/version"
+)
+
+const (
+	// robotsAgent is the product token the fetch tool goes by in robots.txt.
+	robotsAgent = "crush"
+	// robotsMaxSize is how much of a robots.txt is read.
+	robotsMaxSize = 512 * 1024
+	// robotsTTL is how long the robots.txt of a site is kept.
+	robotsTTL = time.Hour
+)
+
+// robotsRule allows or disallows the paths matching its pattern.
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+type robotsEntry struct {
+	rules   []robotsRule
+	fetched time.Time
+}
+
+// robotsCache reads and keeps the robots.txt of the sites fetched from.
+type robotsCache struct {
+	client *http.Client
+
+	mu    sync.Mutex
+	sites map[string]robotsEntry
+}
+
+func newRobotsCache(client *http.Client) *robotsCache {
+	return &robotsCache{client: client, sites: make(map[string]robotsEntry)}
+}
+
+// Allowed reports whether the robots.txt of the site of u lets crush fetch
+// it. Sites without a readable robots.txt allow everything, while those
+// whose robots.txt fails with a server error allow nothing, as RFC 9309
+// asks.
+func (c *robotsCache) Allowed(ctx context.Context, u *url.URL) bool {
+	site := u.Scheme + "://" + u.Host
+	c.mu.Lock()
+	entry, ok := c.sites[site]
+	c.mu.Unlock()
+	if !ok || time.Since(entry.fetched) > robotsTTL {
+		rules, err := c.fetch(ctx, site)
+		if err != nil {
+			// The fetch of the page reports why the site is unreachable.
+			return true
+		}
+		entry = robotsEntry{rules: rules, fetched: time.Now()}
+		c.mu.Lock()
+		c.sites[site] = entry
+		c.mu.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return robotsAllowed(entry.rules, path)
+}
+
+func (c *robotsCache) fetch(ctx context.Context, site string) ([]robotsRule, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", site+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "crush/"+version.Version)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		return []robotsRule{{pattern: "/", allow: false}}, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, nil
+	}
+	return parseRobots(io.LimitReader(resp.Body, robotsMaxSize), robotsAgent), nil
+}
+
+// parseRobots returns the rules of the groups of robots.txt for agent, or
+// those of the "*" group when none names it.
+func parseRobots(r io.Reader, agent string) []robotsRule {
+	var (
+		own, others    []robotsRule
+		isOwn, isAny   bool
+		inAgents, seen bool
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				// A new group starts.
+				isOwn, isAny = false, false
+			}
+			inAgents = true
+			name := strings.ToLower(value)
+			switch {
+			case name == "*":
+				isAny = true
+			case strings.Contains(name, agent):
+				isOwn, seen = true, true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			if value == "" {
+				// An empty disallow allows everything.
+				continue
+			}
+			rule := robotsRule{pattern: value, allow: key == "allow"}
+			if isOwn {
+				own = append(own, rule)
+			}
+			if isAny {
+				others = append(others, rule)
+			}
+		default:
+			inAgents = false
+		}
+	}
+	if seen {
+		return own
+	}
+	return others
+}
+
+// robotsAllowed applies the rule with the longest pattern matching path;
+// allow rules win ties.
+func robotsAllowed(rules []robotsRule, path string) bool {
+	allowed, longest := true, -1
+	for _, rule := range rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > longest || (n == longest && rule.allow) {
+			allowed, longest = rule.allow, n
+		}
+	}
+	return allowed
+}
+
+// robotsMatch reports whether path starts with pattern, where "*" matches
+// any characters and a final "$" the end of path.
+func robotsMatch(pattern, path string) bool {
+	if !strings.ContainsAny(pattern, "*$") {
+		return strings.HasPrefix(path, pattern)
+	}
+	expr := regexp.QuoteMeta(strings.TrimSuffix(pattern, "$"))
+	expr = "^" + strings.ReplaceAll(expr, `\*`, ".*")
+	if strings.HasSuffix(pattern, "$") {
+		expr += "$"
+	}
+	re, err := regexp.Compile(expr)
+	return err == nil && re.MatchString(path)
+}
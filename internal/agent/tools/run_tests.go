@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/testrun"
+)
+
+type RunTestsParams struct {
+	Framework string `json:"framework,omitempty" description:"The test framework: go, pytest, cargo or npm. Detected from the project files when omitted."`
+	Path      string `json:"path,omitempty" description:"The directory to run the tests in. Defaults to the current working directory."`
+	Filter    string `json:"filter,omitempty" description:"Only run tests whose name matches this filter (go -run, pytest -k, cargo test name, jest -t)"`
+	Timeout   int    `json:"timeout,omitempty" description:"Timeout in seconds (default 300, max 1800)"`
+}
+
+type RunTestsPermissionsParams struct {
+	Framework string `json:"framework"`
+	Command   string `json:"command"`
+	Dir       string `json:"dir"`
+}
+
+const (
+	RunTestsToolName = "run_tests"
+
+	maxRunTestsTimeout = 30 * time.Minute
+)
+
+//go:embed run_tests.md
+var runTestsDescription []byte
+
+func NewRunTestsTool(permissions permission.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		RunTestsToolName,
+		string(runTestsDescription),
+		func(ctx context.Context, params RunTestsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if IsPlanMode(ctx) {
+				return fantasy.NewTextErrorResponse("plan mode is on, so tests cannot run. Include the test run in your plan instead."), nil
+			}
+
+			dir := workingDir
+			if params.Path != "" {
+				dir = filepathext.SmartJoin(workingDir, params.Path)
+			}
+			framework := testrun.Framework(strings.ToLower(params.Framework))
+			if framework == "" {
+				var err error
+				if framework, err = testrun.Detect(dir); err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("%s, pass framework explicitly", err)), nil
+				}
+			}
+			name, args, err := testrun.Command(framework, params.Filter)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			command := strings.Join(append([]string{name}, args...), " ")
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for running tests")
+			}
+			p, err := permissions.Request(ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        dir,
+					Command:     command,
+					ToolCallID:  call.ID,
+					ToolName:    RunTestsToolName,
+					Action:      "execute",
+					Description: fmt.Sprintf("Run tests: %s", command),
+					Params: RunTestsPermissionsParams{
+						Framework: string(framework),
+						Command:   command,
+						Dir:       dir,
+					},
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			timeout := min(time.Duration(params.Timeout)*time.Second, maxRunTestsTimeout)
+			result, err := testrun.Run(ctx, testrun.Options{
+				Framework: framework,
+				Dir:       dir,
+				Filter:    params.Filter,
+				Timeout:   timeout,
+			})
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to run tests: %s", err)), nil
+			}
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(formatTestResults(result)),
+				result,
+			), nil
+		})
+}
+
+func formatTestResults(result testrun.Result) string {
+	var output strings.Builder
+	output.WriteString("<test_results>\n")
+	fmt.Fprintf(&output, "Command: %s\n", result.Command)
+	status := "PASSED"
+	switch {
+	case result.TimedOut:
+		status = "TIMED OUT"
+	case !result.OK():
+		status = "FAILED"
+	}
+	fmt.Fprintf(&output, "Status: %s (exit code %d)\n", status, result.ExitCode)
+	fmt.Fprintf(&output, "Passed: %d, failed: %d, skipped: %d\n", result.Passed, result.Failed, result.Skipped)
+
+	if len(result.Failures) > 0 {
+		output.WriteString("\nFailures:\n")
+		for _, f := range result.Failures {
+			output.WriteString("- ")
+			if loc := f.Location(); loc != "" {
+				fmt.Fprintf(&output, "%s: ", loc)
+			}
+			output.WriteString(f.Test)
+			if f.Message != "" {
+				fmt.Fprintf(&output, "\n  %s", strings.ReplaceAll(f.Message, "\n", "\n  "))
+			}
+			output.WriteString("\n")
+		}
+	}
+	output.WriteString("</test_results>\n")
+
+	if result.Output != "" {
+		fmt.Fprintf(&output, "\n<output>\n%s\n</output>\n", result.Output)
+	}
+	return output.String()
+}
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/testrun"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTests(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/rt\n\ngo 1.21\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rt_test.go"), []byte(`package rt
+
+import "testing"
+
+func TestOK(t *testing.T) {}
+
+func TestBroken(t *testing.T) {
+	t.Errorf("want 1, got 2")
+}
+`), 0o644))
+
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	tool := NewRunTestsTool(&mockPermissionService{}, dir)
+	resp := runTool(t, ctx, tool, RunTestsParams{})
+
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "Status: FAILED")
+	require.Contains(t, resp.Content, "Passed: 1, failed: 1, skipped: 0")
+	require.Contains(t, resp.Content, "- rt_test.go:8: example.com/rt.TestBroken\n  want 1, got 2")
+
+	var result testrun.Result
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &result))
+	require.Equal(t, testrun.Go, result.Framework)
+	require.Len(t, result.Failures, 1)
+
+	resp = runTool(t, ctx, tool, RunTestsParams{Filter: "TestOK"})
+	require.Contains(t, resp.Content, "Status: PASSED")
+}
+
+func TestRunTestsPlanMode(t *testing.T) {
+	t.Parallel()
+
+	tool := NewRunTestsTool(&mockPermissionService{}, t.TempDir())
+	resp := runTool(t, planContext(t), tool, RunTestsParams{Framework: "go"})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "plan mode is on")
+}
+
+func TestRunTestsNoFramework(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	tool := NewRunTestsTool(&mockPermissionService{}, t.TempDir())
+	resp := runTool(t, ctx, tool, RunTestsParams{})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "pass framework explicitly")
+}
@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+const (
+	braveSearchEndpoint  = "https://api.search.brave.com/res/v1/web/search"
+	tavilySearchEndpoint = "https://api.tavily.com/search"
+)
+
+// Searcher queries a web search API.
+type Searcher interface {
+	Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error)
+}
+
+// NewSearcher returns the searcher of provider, DuckDuckGo when it is
+// empty. apiKey is the resolved key of the Brave and Tavily APIs, and
+// baseURL the address of a SearXNG instance.
+func NewSearcher(provider config.SearchProvider, apiKey, baseURL string, client *http.Client) (Searcher, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	switch provider {
+	case "", config.SearchDuckDuckGo:
+		return duckDuckGoSearcher{client: client}, nil
+	case config.SearchBrave:
+		if apiKey == "" {
+			return nil, errors.New("the brave search provider needs an api_key")
+		}
+		return braveSearcher{client: client, endpoint: braveSearchEndpoint, apiKey: apiKey}, nil
+	case config.SearchTavily:
+		if apiKey == "" {
+			return nil, errors.New("the tavily search provider needs an api_key")
+		}
+		return tavilySearcher{client: client, endpoint: tavilySearchEndpoint, apiKey: apiKey}, nil
+	case config.SearchSearXNG:
+		if baseURL == "" {
+			return nil, errors.New("the searxng search provider needs a url")
+		}
+		return searxngSearcher{client: client, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+	default:
+		return nil, fmt.Errorf("unknown search provider %q", provider)
+	}
+}
+
+type duckDuckGoSearcher struct {
+	client *http.Client
+}
+
+func (s duckDuckGoSearcher) Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error) {
+	maybeDelaySearch()
+	return searchDuckDuckGo(ctx, s.client, query, maxResults)
+}
+
+type braveSearcher struct {
+	client   *http.Client
+	endpoint string
+	apiKey   string
+}
+
+func (s braveSearcher) Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error) {
+	params := url.Values{"q": {query}, "count": {fmt.Sprint(maxResults)}}
+	req, err := http.NewRequestWithContext(ctx, "GET", s.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Subscription-Token", s.apiKey)
+
+	var resp struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := doSearch(s.client, req, &resp); err != nil {
+		return nil, err
+	}
+	var results []SearchResult
+	for _, r := range resp.Web.Results {
+		results = appendResult(results, r.Title, r.URL, r.Description)
+	}
+	return limitResults(results, maxResults), nil
+}
+
+type tavilySearcher struct {
+	client   *http.Client
+	endpoint string
+	apiKey   string
+}
+
+func (s tavilySearcher) Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error) {
+	body, err := json.Marshal(map[string]any{"query": query, "max_results": maxResults})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	var resp struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := doSearch(s.client, req, &resp); err != nil {
+		return nil, err
+	}
+	var results []SearchResult
+	for _, r := range resp.Results {
+		results = appendResult(results, r.Title, r.URL, r.Content)
+	}
+	return limitResults(results, maxResults), nil
+}
+
+type searxngSearcher struct {
+	client  *http.Client
+	baseURL string
+}
+
+func (s searxngSearcher) Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error) {
+	params := url.Values{"q": {query}, "format": {"json"}}
+	req, err := http.NewRequestWithContext(ctx, "GET", s.baseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	var resp struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := doSearch(s.client, req, &resp); err != nil {
+		return nil, err
+	}
+	var results []SearchResult
+	for _, r := range resp.Results {
+		results = appendResult(results, r.Title, r.URL, r.Content)
+	}
+	return limitResults(results, maxResults), nil
+}
+
+// doSearch sends req and decodes the JSON response into v.
+func doSearch(client *http.Client, req *http.Request, v any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute search: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("search failed with status code: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 5*1024*1024)).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode search results: %w", err)
+	}
+	return nil
+}
+
+func appendResult(results []SearchResult, title, link, snippet string) []SearchResult {
+	if link == "" {
+		return results
+	}
+	return append(results, SearchResult{
+		Title:    strings.TrimSpace(title),
+		Link:     link,
+		Snippet:  strings.TrimSpace(snippet),
+		Position: len(results) + 1,
+	})
+}
+
+func limitResults(results []SearchResult, maxResults int) []SearchResult {
+	if maxResults > 0 && len(results) > maxResults {
+		return results[:maxResults]
+	}
+	return results
+}
@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchers(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/brave":
+			if r.Header.Get("X-Subscription-Token") != "brave-key" || r.URL.Query().Get("q") != "go generics" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"web":{"results":[{"title":"Generics","url":"https://go.dev/doc/tutorial/generics","description":"A tutorial"},{"title":"Spec","url":"https://go.dev/ref/spec","description":"The spec"}]}}`)
+		case "/tavily":
+			var body struct {
+				Query string `json:"query"`
+			}
+			if r.Header.Get("Authorization") != "Bearer tavily-key" || json.NewDecoder(r.Body).Decode(&body) != nil || body.Query != "go generics" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"results":[{"title":"Generics","url":"https://go.dev/doc/tutorial/generics","content":"A tutorial"}]}`)
+		case "/search":
+			if r.URL.Query().Get("format") != "json" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"results":[{"title":"Generics","url":"https://go.dev/doc/tutorial/generics","content":"A tutorial"},{"title":"No link"}]}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	want := SearchResult{Title: "Generics", Link: "https://go.dev/doc/tutorial/generics", Snippet: "A tutorial", Position: 1}
+	for name, searcher := range map[string]Searcher{
+		"brave":   braveSearcher{client: server.Client(), endpoint: server.URL + "/brave", apiKey: "brave-key"},
+		"tavily":  tavilySearcher{client: server.Client(), endpoint: server.URL + "/tavily", apiKey: "tavily-key"},
+		"searxng": searxngSearcher{client: server.Client(), baseURL: server.URL},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			results, err := searcher.Search(t.Context(), "go generics", 1)
+			require.NoError(t, err)
+			require.Equal(t, []SearchResult{want}, results)
+		})
+	}
+
+	_, err := NewSearcher(config.SearchBrave, "", "", nil)
+	require.Error(t, err)
+	_, err = NewSearcher("bing", "", "", nil)
+	require.Error(t, err)
+	searcher, err := NewSearcher("", "", "", nil)
+	require.NoError(t, err)
+	require.IsType(t, duckDuckGoSearcher{}, searcher)
+}
@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// SudoWriteAction is the action of the permission requests to write a file
+// with sudo. They are asked apart from the edit itself, even when
+// permissions are skipped.
+const SudoWriteAction = permission.SudoWriteAction
+
+// SudoWritePermissionsParams are the params of the permission requests to
+// write a file with sudo.
+type SudoWritePermissionsParams struct {
+	FilePath string `json:"file_path"`
+	Command  string `json:"command"`
+}
+
+// sudoCommand is the command writing files for [writeFileOrSudo]; tests
+// replace it.
+var sudoCommand = "sudo"
+
+// writeFileOrSudo writes a file through the file cache of ctx like
+// writeFile. When the file needs elevated permissions, it asks for
+// permission to write it as root with sudo instead of failing, and explains
+// how the user can make the change when sudo cannot be used.
+func writeFileOrSudo(ctx context.Context, permissions permission.Service, call fantasy.ToolCall, path string, data []byte, perm os.FileMode) error {
+	err := writeFile(ctx, path, data, perm)
+	if !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		return elevationError(path, err, "")
+	}
+	sudo, lookErr := exec.LookPath(sudoCommand)
+	if lookErr != nil {
+		return elevationError(path, err, "")
+	}
+	if permissions.SkipRequests() {
+		return elevationError(path, err, "Writing files as root with sudo is never approved automatically.")
+	}
+
+	args := []string{"-n", "tee", "--", path}
+	granted, reqErr := permissions.Request(ctx, permission.CreatePermissionRequest{
+		SessionID:   GetSessionFromContext(ctx),
+		Path:        filepath.Dir(path),
+		Target:      path,
+		ToolCallID:  call.ID,
+		ToolName:    call.Name,
+		Action:      SudoWriteAction,
+		Description: fmt.Sprintf("Write %s as root with sudo", path),
+		// Auto-approved sessions, allow rules and grants never cover it.
+		Confirm: true,
+		Params: SudoWritePermissionsParams{
+			FilePath: path,
+			Command:  sudoCommand + " " + strings.Join(args, " "),
+		},
+	})
+	if reqErr != nil {
+		return reqErr
+	}
+	if !granted {
+		return permission.ErrorPermissionDenied
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, sudo, args...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = &stderr
+	if runErr := cmd.Run(); runErr != nil {
+		msg := strings.TrimSpace(stderr.String())
+		slog.Warn("Failed to write file with sudo", "path", path, "error", runErr, "stderr", msg)
+		if strings.Contains(msg, "password") {
+			return elevationError(path, err, "sudo needs a password, which Crush cannot type: ask the user to run `sudo -v` in a terminal and then retry the change.")
+		}
+		return elevationError(path, fmt.Errorf("%w: %s", runErr, msg), "")
+	}
+	GetFileCacheFromContext(ctx).Invalidate(path)
+	slog.Info("Wrote file with sudo", "path", path, "tool", call.Name, "session_id", GetSessionFromContext(ctx))
+	return nil
+}
+
+// elevationError explains that path needs elevated permissions, with hint
+// first when it is set.
+func elevationError(path string, err error, hint string) error {
+	if hint != "" {
+		hint += " "
+	}
+	return fmt.Errorf("%w. %s needs elevated permissions. %sOtherwise ask the user to make the change themselves or to give you write access, for example with `sudo chown $(id -un) %s`", err, path, hint, path)
+}
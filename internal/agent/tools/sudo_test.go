@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+// sudoPermissionService answers permission requests with granted, or skips
+// them.
+type sudoPermissionService struct {
+	mockPermissionService
+	granted  bool
+	skip     bool
+	requests []permission.CreatePermissionRequest
+}
+
+func (s *sudoPermissionService) Request(ctx context.Context, req permission.CreatePermissionRequest) (bool, error) {
+	s.requests = append(s.requests, req)
+	return s.granted, nil
+}
+
+func (s *sudoPermissionService) SkipRequests() bool {
+	return s.skip
+}
+
+// readOnlyFile returns a file the current user cannot write, and points
+// sudoCommand at a fake sudo that runs its command as the user, with the
+// write access it needs.
+func readOnlyFile(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("sudo is not used on Windows")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("root writes read-only files")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hosts")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0o444))
+	t.Cleanup(func() { _ = os.Chmod(path, 0o644) })
+
+	sudo := filepath.Join(t.TempDir(), "sudo")
+	script := "#!/bin/sh\n[ \"$1\" = -n ] && shift\nchmod u+w \"$3\"\nexec \"$@\" >/dev/null\n"
+	require.NoError(t, os.WriteFile(sudo, []byte(script), 0o755))
+	prev := sudoCommand
+	sudoCommand = sudo
+	t.Cleanup(func() { sudoCommand = prev })
+	return path
+}
+
+func TestWriteFileOrSudo(t *testing.T) {
+	call := fantasy.ToolCall{ID: "call", Name: EditToolName}
+
+	t.Run("granted", func(t *testing.T) {
+		path := readOnlyFile(t)
+		permissions := &sudoPermissionService{granted: true}
+		require.NoError(t, writeFileOrSudo(t.Context(), permissions, call, path, []byte("new"), 0o644))
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "new", string(content))
+		require.Len(t, permissions.requests, 1)
+		req := permissions.requests[0]
+		require.Equal(t, SudoWriteAction, req.Action)
+		require.Equal(t, "call", req.ToolCallID)
+		require.Equal(t, path, req.Params.(SudoWritePermissionsParams).FilePath)
+		require.True(t, req.Confirm)
+	})
+
+	t.Run("asked in auto-approved sessions", func(t *testing.T) {
+		path := readOnlyFile(t)
+		permissions := permission.NewPermissionService(t.TempDir(), false, []string{EditToolName}, nil, "")
+		permissions.AutoApproveSession("session")
+		events := permissions.Subscribe(t.Context())
+		ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+
+		done := make(chan error, 1)
+		go func() {
+			done <- writeFileOrSudo(ctx, permissions, call, path, []byte("new"), 0o644)
+		}()
+		select {
+		case event := <-events:
+			require.Equal(t, SudoWriteAction, event.Payload.Action)
+			permissions.Deny(event.Payload)
+			require.ErrorIs(t, <-done, permission.ErrorPermissionDenied)
+		case err := <-done:
+			t.Fatalf("wrote as root without asking: %v", err)
+		}
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		path := readOnlyFile(t)
+		permissions := &sudoPermissionService{}
+		err := writeFileOrSudo(t.Context(), permissions, call, path, []byte("new"), 0o644)
+		require.ErrorIs(t, err, permission.ErrorPermissionDenied)
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "old", string(content))
+	})
+
+	t.Run("never skipped", func(t *testing.T) {
+		path := readOnlyFile(t)
+		permissions := &sudoPermissionService{granted: true, skip: true}
+		err := writeFileOrSudo(t.Context(), permissions, call, path, []byte("new"), 0o644)
+		require.ErrorContains(t, err, "chown")
+		require.Empty(t, permissions.requests)
+	})
+}
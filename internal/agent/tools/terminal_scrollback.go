@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/redact"
+	"github.com/charmbracelet/crush/internal/scrollback"
+)
+
+//go:embed terminal_scrollback.md
+var terminalScrollbackDescription []byte
+
+const TerminalScrollbackToolName = "terminal_scrollback"
+
+type TerminalScrollbackParams struct {
+	Lines int `json:"lines,omitempty" description:"Number of recent lines to read (defaults to the configured maximum)"`
+}
+
+type TerminalScrollbackResponseMetadata struct {
+	Source    string `json:"source"`
+	Lines     int    `json:"lines"`
+	Truncated bool   `json:"truncated"`
+}
+
+// NewTerminalScrollbackTool reads the user's recent terminal output. The
+// output is masked with redactor before it is returned, so secrets printed
+// in the terminal are not stored in the session either.
+func NewTerminalScrollbackTool(reader *scrollback.Reader, redactor *redact.Redactor) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		TerminalScrollbackToolName,
+		strings.ReplaceAll(string(terminalScrollbackDescription), "{{max_lines}}", fmt.Sprint(reader.MaxLines())),
+		func(ctx context.Context, params TerminalScrollbackParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			capture, err := reader.Read(ctx, params.Lines)
+			if errors.Is(err, scrollback.ErrNoSource) {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to read the terminal: %s", err)), nil
+			}
+			metadata := TerminalScrollbackResponseMetadata{
+				Source:    capture.Source,
+				Lines:     capture.Lines,
+				Truncated: capture.Truncated,
+			}
+			if capture.Text == "" {
+				return fantasy.WithResponseMetadata(fantasy.NewTextResponse("The terminal shows no output."), metadata), nil
+			}
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "Last %d lines of %s", capture.Lines, capture.Source)
+			if capture.Truncated {
+				b.WriteString(" (older output omitted)")
+			}
+			fmt.Fprintf(&b, ":\n<terminal>\n%s\n</terminal>", redactor.Redact(capture.Text))
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(b.String()), metadata), nil
+		},
+	)
+}
@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/worktree"
+)
+
+//go:embed verify_clean.md
+var verifyCleanDescription []byte
+
+const VerifyCleanToolName = "verify_clean"
+
+type VerifyCleanParams struct {
+	Expected       []string `json:"expected,omitempty" description:"Glob patterns (relative to the repository root) of the files meant to change"`
+	IncludeIgnored bool     `json:"include_ignored,omitempty" description:"Also list files ignored by the committed .gitignore files"`
+}
+
+func NewVerifyCleanTool(workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		VerifyCleanToolName,
+		string(verifyCleanDescription),
+		func(ctx context.Context, params VerifyCleanParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			report, err := worktree.VerifyClean(ctx, workingDir, worktree.VerifyOptions{
+				Expected: params.Expected,
+				Ignored:  params.IncludeIgnored,
+			})
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to verify the workspace: %s", err)), nil
+			}
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(report.String()), report), nil
+		},
+	)
+}
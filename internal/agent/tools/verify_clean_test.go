@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyCleanTool(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "--quiet")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+	git("add", ".")
+	git("commit", "--quiet", "-m", "initial")
+
+	tool := NewVerifyCleanTool(dir)
+	resp := runTool(t, planContext(t), tool, VerifyCleanParams{})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "matches a clean clone")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\n// wip\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stray.txt"), []byte("oops"), 0o644))
+	resp = runTool(t, planContext(t), tool, VerifyCleanParams{Expected: []string{"*.go"}})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "1 of 2 changed files are unexpected")
+	require.Contains(t, resp.Content, "modified      main.go (expected)")
+
+	resp = runTool(t, planContext(t), NewVerifyCleanTool(t.TempDir()), VerifyCleanParams{})
+	require.True(t, resp.IsError)
+}
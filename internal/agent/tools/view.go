@@ -2,11 +2,14 @@ package tools
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	_ "embed"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -37,13 +40,21 @@ type ViewPermissionsParams struct {
 type ViewResponseMetadata struct {
 	FilePath string `json:"file_path"`
 	Content  string `json:"content"`
+	// Lines is how many lines were returned, which can be fewer than asked
+	// for at the end of the file or past the byte cap.
+	Lines int `json:"lines,omitempty"`
+	// TotalLines is how many lines the file has.
+	TotalLines int `json:"total_lines,omitempty"`
 }
 
 const (
 	ViewToolName     = "view"
-	MaxReadSize      = 5 * 1024 * 1024 // 5MB
+	MaxReadSize      = 5 * 1024 * 1024 // 5MB; larger text files are streamed from disk.
 	DefaultReadLimit = 2000
 	MaxLineLength    = 2000
+	// MaxViewBytes caps how much of a file one view returns, so a file of
+	// long lines cannot fill the context window.
+	MaxViewBytes = 256 * 1024
 )
 
 func NewViewTool(
@@ -90,6 +101,7 @@ func NewViewTool(
 					permission.CreatePermissionRequest{
 						SessionID:   sessionID,
 						Path:        absFilePath,
+						Target:      absFilePath,
 						ToolCallID:  call.ID,
 						ToolName:    ViewToolName,
 						Action:      "read",
@@ -142,13 +154,8 @@ func NewViewTool(
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("Path is a directory, not a file: %s", filePath)), nil
 			}
 
-			// Based on the specifications we should not limit the skills read.
-			if !isSkillFile && fileInfo.Size() > MaxReadSize {
-				return fantasy.NewTextErrorResponse(fmt.Sprintf("File is too large (%d bytes). Maximum size is %d bytes",
-					fileInfo.Size(), MaxReadSize)), nil
-			}
-
 			// Set default limit if not provided (no limit for SKILL.md files)
+			maxBytes := MaxViewBytes
 			if params.Limit <= 0 {
 				if isSkillFile {
 					params.Limit = 1000000 // Effectively no limit for skill files
@@ -156,6 +163,10 @@ func NewViewTool(
 					params.Limit = DefaultReadLimit
 				}
 			}
+			// Based on the specifications we should not limit the skills read.
+			if isSkillFile {
+				maxBytes = 0
+			}
 
 			isSupportedImage, mimeType := getImageMimeType(filePath)
 			if isSupportedImage {
@@ -163,8 +174,12 @@ func NewViewTool(
 					modelName := GetModelNameFromContext(ctx)
 					return fantasy.NewTextErrorResponse(fmt.Sprintf("This model (%s) does not support image data.", modelName)), nil
 				}
+				if fileInfo.Size() > MaxReadSize {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("Image is too large (%d bytes). Maximum size is %d bytes",
+						fileInfo.Size(), MaxReadSize)), nil
+				}
 
-				imageData, err := os.ReadFile(filePath)
+				imageData, err := readFile(ctx, filePath)
 				if err != nil {
 					return fantasy.ToolResponse{}, fmt.Errorf("error reading image file: %w", err)
 				}
@@ -174,14 +189,21 @@ func NewViewTool(
 			}
 
 			// Read the file content
-			content, lineCount, err := readTextFile(filePath, params.Offset, params.Limit)
-			isValidUt8 := utf8.ValidString(content)
-			if !isValidUt8 {
-				return fantasy.NewTextErrorResponse("File content is not valid UTF-8"), nil
+			page, err := readTextFile(ctx, filePath, fileInfo.Size(), params.Offset, params.Limit, maxBytes)
+			if errors.Is(err, errBinaryFile) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("%s is a binary file (%s, %d bytes) and cannot be displayed as text. Inspect it with a command such as `file`, `strings` or `xxd | head` instead.",
+					filePath, page.mimeType, fileInfo.Size())), nil
 			}
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error reading file: %w", err)
 			}
+			content := page.content
+			if !utf8.ValidString(content) {
+				return fantasy.NewTextErrorResponse("File content is not valid UTF-8"), nil
+			}
+			if params.Offset > 0 && params.Offset >= page.total {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("Offset %d is beyond the end of the file, which has %d lines", params.Offset, page.total)), nil
+			}
 
 			notifyLSPs(ctx, lspManager, filePath)
 			output := "<file>\n"
@@ -189,9 +211,14 @@ func NewViewTool(
 			output += addLineNumbers(content, params.Offset+1)
 
 			// Add a note if the content was truncated
-			if lineCount > params.Offset+len(strings.Split(content, "\n")) {
-				output += fmt.Sprintf("\n\n(File has more lines. Use 'offset' parameter to read beyond line %d)",
-					params.Offset+len(strings.Split(content, "\n")))
+			if last := params.Offset + page.lines; page.total > last {
+				if page.capped {
+					output += fmt.Sprintf("\n\n(Output capped at %d bytes. The file has %d lines; use 'offset' parameter to read beyond line %d)",
+						maxBytes, page.total, last)
+				} else {
+					output += fmt.Sprintf("\n\n(File has %d lines. Use 'offset' parameter to read beyond line %d)",
+						page.total, last)
+				}
 			}
 			output += "\n</file>\n"
 			output += getDiagnostics(filePath, lspManager)
@@ -199,8 +226,10 @@ func NewViewTool(
 			return fantasy.WithResponseMetadata(
 				fantasy.NewTextResponse(output),
 				ViewResponseMetadata{
-					FilePath: filePath,
-					Content:  content,
+					FilePath:   filePath,
+					Content:    content,
+					Lines:      page.lines,
+					TotalLines: page.total,
 				},
 			), nil
 		})
@@ -231,55 +260,129 @@ func addLineNumbers(content string, startLine int) string {
 	return strings.Join(result, "\n")
 }
 
-func readTextFile(filePath string, offset, limit int) (string, int, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return "", 0, err
-	}
-	defer file.Close()
-
-	lineCount := 0
+// textPage is the part of a text file a view returns.
+type textPage struct {
+	content string
+	// lines is how many lines content has.
+	lines int
+	// total is how many lines the whole file has.
+	total int
+	// capped is set when lines were left out to stay under the byte cap.
+	capped bool
+	// mimeType is the sniffed type of binary files.
+	mimeType string
+}
 
-	scanner := NewLineScanner(file)
-	if offset > 0 {
-		for lineCount < offset && scanner.Scan() {
-			lineCount++
+// errBinaryFile is returned by readTextFile for files that are not text.
+var errBinaryFile = errors.New("binary file")
+
+// readTextFile returns limit lines of the file from line offset, and at
+// most maxBytes of them when maxBytes is positive, along with the number
+// of lines of the whole file. Files up to MaxReadSize are read through the
+// file cache of ctx, so agents reading parts of the same file share one
+// copy of it; larger ones are streamed from disk.
+func readTextFile(ctx context.Context, filePath string, size int64, offset, limit, maxBytes int) (textPage, error) {
+	var r io.Reader
+	if size <= MaxReadSize {
+		data, err := readFile(ctx, filePath)
+		if err != nil {
+			return textPage{}, err
 		}
-		if err = scanner.Err(); err != nil {
-			return "", 0, err
+		r = bytes.NewReader(data)
+	} else {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return textPage{}, err
 		}
+		defer f.Close()
+		r = f
+	}
+	return readLines(r, offset, limit, maxBytes)
+}
+
+func readLines(r io.Reader, offset, limit, maxBytes int) (textPage, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+	head, err := br.Peek(8 * 1024)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return textPage{}, err
+	}
+	if isBinary(head) {
+		return textPage{mimeType: http.DetectContentType(head)}, errBinaryFile
 	}
 
-	if offset == 0 {
-		_, err = file.Seek(0, io.SeekStart)
+	var page textPage
+	var lines []string
+	size := 0
+	for {
+		line, ok, err := readLine(br)
 		if err != nil {
-			return "", 0, err
+			return textPage{}, err
+		}
+		if !ok {
+			break
+		}
+		page.total++
+		if page.total <= offset || len(lines) >= limit || page.capped {
+			continue
 		}
+		if maxBytes > 0 && size+len(line)+1 > maxBytes && len(lines) > 0 {
+			page.capped = true
+			continue
+		}
+		lines = append(lines, line)
+		size += len(line) + 1
 	}
+	page.content = strings.Join(lines, "\n")
+	page.lines = len(lines)
+	return page, nil
+}
 
-	// Pre-allocate slice with expected capacity
-	lines := make([]string, 0, limit)
-	lineCount = offset
-
-	for scanner.Scan() && len(lines) < limit {
-		lineCount++
-		lineText := scanner.Text()
-		if len(lineText) > MaxLineLength {
-			lineText = lineText[:MaxLineLength] + "..."
+// readLine reads the next line of br without its newline, keeping only its
+// first MaxLineLength bytes. It reports false at the end of the input.
+func readLine(br *bufio.Reader) (string, bool, error) {
+	var line []byte
+	n := 0
+	for {
+		chunk, err := br.ReadSlice('\n')
+		n += len(chunk)
+		if keep := MaxLineLength + utf8.UTFMax - len(line); keep > 0 {
+			line = append(line, chunk[:min(keep, len(chunk))]...)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", false, err
 		}
-		lines = append(lines, lineText)
+		if n == 0 {
+			return "", false, nil
+		}
+		break
 	}
-
-	// Continue scanning to get total line count
-	for scanner.Scan() {
-		lineCount++
+	text := strings.TrimSuffix(string(line), "\n")
+	if len(text) > MaxLineLength {
+		cut := MaxLineLength
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + "..."
 	}
+	return text, true, nil
+}
 
-	if err := scanner.Err(); err != nil {
-		return "", 0, err
+// isBinary reports whether the start of a file holds NUL bytes or is not
+// UTF-8, allowing for a character cut at its end.
+func isBinary(head []byte) bool {
+	if bytes.IndexByte(head, 0) != -1 {
+		return true
 	}
-
-	return strings.Join(lines, "\n"), lineCount, nil
+	for i := 0; i < utf8.UTFMax && len(head) > 0; i++ {
+		if utf8.Valid(head) {
+			return false
+		}
+		head = head[:len(head)-1]
+	}
+	return !utf8.Valid(head)
 }
 
 func getImageMimeType(filePath string) (bool, string) {
@@ -298,33 +401,6 @@ func getImageMimeType(filePath string) (bool, string) {
 	}
 }
 
-type LineScanner struct {
-	scanner *bufio.Scanner
-}
-
-func NewLineScanner(r io.Reader) *LineScanner {
-	scanner := bufio.NewScanner(r)
-	// Increase buffer size to handle large lines (e.g., minified JSON, HTML)
-	// Default is 64KB, set to 1MB
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
-	return &LineScanner{
-		scanner: scanner,
-	}
-}
-
-func (s *LineScanner) Scan() bool {
-	return s.scanner.Scan()
-}
-
-func (s *LineScanner) Text() string {
-	return s.scanner.Text()
-}
-
-func (s *LineScanner) Err() error {
-	return s.scanner.Err()
-}
-
 // isInSkillsPath checks if filePath is within any of the configured skills
 // directories. Returns true for files that can be read without permission
 // prompts and without size limits.
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadLines(t *testing.T) {
+	t.Parallel()
+
+	t.Run("pages", func(t *testing.T) {
+		t.Parallel()
+		page, err := readLines(strings.NewReader("one\ntwo\nthree\nfour\n"), 1, 2, 0)
+		require.NoError(t, err)
+		require.Equal(t, "two\nthree", page.content)
+		require.Equal(t, 2, page.lines)
+		require.Equal(t, 4, page.total)
+		require.False(t, page.capped)
+	})
+
+	t.Run("last line without newline", func(t *testing.T) {
+		t.Parallel()
+		page, err := readLines(strings.NewReader("one\ntwo"), 0, DefaultReadLimit, 0)
+		require.NoError(t, err)
+		require.Equal(t, "one\ntwo", page.content)
+		require.Equal(t, 2, page.total)
+	})
+
+	t.Run("byte cap", func(t *testing.T) {
+		t.Parallel()
+		page, err := readLines(strings.NewReader(strings.Repeat("0123456789\n", 10)), 0, DefaultReadLimit, 25)
+		require.NoError(t, err)
+		require.Equal(t, 2, page.lines)
+		require.Equal(t, 10, page.total)
+		require.True(t, page.capped)
+	})
+
+	t.Run("long lines are cut on a character", func(t *testing.T) {
+		t.Parallel()
+		line := "a" + strings.Repeat("é", 2*1024*1024)
+		page, err := readLines(strings.NewReader(line+"\nend\n"), 0, DefaultReadLimit, 0)
+		require.NoError(t, err)
+		first, last, _ := strings.Cut(page.content, "\n")
+		require.True(t, strings.HasSuffix(first, "..."))
+		require.LessOrEqual(t, len(first), MaxLineLength+3)
+		require.Equal(t, "end", last)
+		require.Equal(t, 2, page.total)
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		t.Parallel()
+		page, err := readLines(strings.NewReader("PK\x03\x04\x00\x00binary"), 0, DefaultReadLimit, 0)
+		require.ErrorIs(t, err, errBinaryFile)
+		require.Equal(t, "application/zip", page.mimeType)
+	})
+}
@@ -3,30 +3,29 @@ package tools
 import (
 	"context"
 	_ "embed"
+	"fmt"
 	"log/slog"
-	"net/http"
-	"time"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
 )
 
 //go:embed web_search.md
 var webSearchToolDescription []byte
 
 // NewWebSearchTool creates a web search tool for sub-agents (no permissions needed).
-func NewWebSearchTool(client *http.Client) fantasy.AgentTool {
-	if client == nil {
-		transport := http.DefaultTransport.(*http.Transport).Clone()
-		transport.MaxIdleConns = 100
-		transport.MaxIdleConnsPerHost = 10
-		transport.IdleConnTimeout = 90 * time.Second
-
-		client = &http.Client{
-			Timeout:   30 * time.Second,
-			Transport: transport,
-		}
-	}
+func NewWebSearchTool(searcher Searcher) fantasy.AgentTool {
+	return fantasy.NewParallelAgentTool(
+		WebSearchToolName,
+		string(webSearchToolDescription),
+		func(ctx context.Context, params WebSearchParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			return webSearch(ctx, searcher, params), nil
+		})
+}
 
+// NewWebSearchToolWithPermissions creates a web search tool for the main
+// agent, which asks before every search.
+func NewWebSearchToolWithPermissions(permissions permission.Service, workingDir string, searcher Searcher) fantasy.AgentTool {
 	return fantasy.NewParallelAgentTool(
 		WebSearchToolName,
 		string(webSearchToolDescription),
@@ -35,21 +34,52 @@ func NewWebSearchTool(client *http.Client) fantasy.AgentTool {
 				return fantasy.NewTextErrorResponse("query is required"), nil
 			}
 
-			maxResults := params.MaxResults
-			if maxResults <= 0 {
-				maxResults = 10
-			}
-			if maxResults > 20 {
-				maxResults = 20
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for searching the web")
 			}
 
-			maybeDelaySearch()
-			results, err := searchDuckDuckGo(ctx, client, params.Query, maxResults)
-			slog.Debug("Web search completed", "query", params.Query, "results", len(results), "err", err)
+			p, err := permissions.Request(ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        workingDir,
+					Target:      params.Query,
+					ToolCallID:  call.ID,
+					ToolName:    WebSearchToolName,
+					Action:      "search",
+					Description: fmt.Sprintf("Search the web for: %s", params.Query),
+					Params:      WebSearchPermissionsParams(params),
+				},
+			)
 			if err != nil {
-				return fantasy.NewTextErrorResponse("Failed to search: " + err.Error()), nil
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 			}
 
-			return fantasy.NewTextResponse(formatSearchResults(results)), nil
+			return webSearch(ctx, searcher, params), nil
 		})
 }
+
+func webSearch(ctx context.Context, searcher Searcher, params WebSearchParams) fantasy.ToolResponse {
+	if params.Query == "" {
+		return fantasy.NewTextErrorResponse("query is required")
+	}
+
+	maxResults := params.MaxResults
+	if maxResults <= 0 {
+		maxResults = 10
+	}
+	if maxResults > 20 {
+		maxResults = 20
+	}
+
+	results, err := searcher.Search(ctx, params.Query, maxResults)
+	slog.Debug("Web search completed", "query", params.Query, "results", len(results), "err", err)
+	if err != nil {
+		return fantasy.NewTextErrorResponse("Failed to search: " + err.Error())
+	}
+
+	return fantasy.NewTextResponse(formatSearchResults(results))
+}
@@ -11,9 +11,13 @@ import (
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/buildcheck"
+	"github.com/charmbracelet/crush/internal/codeowners"
 	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/fileheader"
 	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/formatter"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
 
@@ -45,6 +49,10 @@ const WriteToolName = "write"
 
 func NewWriteTool(
 	lspManager *lsp.Manager,
+	formatters *formatter.Runner,
+	checks *buildcheck.Runner,
+	owners *codeowners.Checker,
+	headers *fileheader.Policy,
 	permissions permission.Service,
 	files history.Service,
 	filetracker filetracker.Service,
@@ -68,6 +76,7 @@ func NewWriteTool(
 			}
 
 			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
+			defer lockFile(ctx, filePath)()
 
 			fileInfo, err := os.Stat(filePath)
 			if err == nil {
@@ -77,12 +86,12 @@ func NewWriteTool(
 
 				modTime := fileInfo.ModTime().Truncate(time.Second)
 				lastRead := filetracker.LastReadTime(ctx, sessionID, filePath)
-				if modTime.After(lastRead) {
+				if modTime.After(lastRead) || filetracker.Changed(ctx, sessionID, filePath) {
 					return fantasy.NewTextErrorResponse(fmt.Sprintf("File %s has been modified since it was last read.\nLast modification: %s\nLast read: %s\n\nPlease read the file again before modifying it.",
 						filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339))), nil
 				}
 
-				oldContent, readErr := os.ReadFile(filePath)
+				oldContent, readErr := readFile(ctx, filePath)
 				if readErr == nil && string(oldContent) == params.Content {
 					return fantasy.NewTextErrorResponse(fmt.Sprintf("File %s already contains the exact content. No changes made.", filePath)), nil
 				}
@@ -90,29 +99,35 @@ func NewWriteTool(
 				return fantasy.ToolResponse{}, fmt.Errorf("error checking file: %w", err)
 			}
 
-			dir := filepath.Dir(filePath)
-			if err = os.MkdirAll(dir, 0o755); err != nil {
-				return fantasy.ToolResponse{}, fmt.Errorf("error creating directory: %w", err)
-			}
-
 			oldContent := ""
 			if fileInfo != nil && !fileInfo.IsDir() {
-				oldBytes, readErr := os.ReadFile(filePath)
+				oldBytes, readErr := readFile(ctx, filePath)
 				if readErr == nil {
 					oldContent = string(oldBytes)
 				}
+			} else {
+				params.Content = headers.Apply(filePath, params.Content)
 			}
+			params.Content = formatContent(ctx, formatters, filePath, params.Content)
 
-			diff, additions, removals := diff.GenerateDiff(
+			fileDiff, additions, removals := diff.GenerateDiff(
 				oldContent,
 				params.Content,
 				strings.TrimPrefix(filePath, workingDir),
 			)
+			if resp, held := holdChange(ctx, call, filePath, oldContent, params.Content, workingDir, WriteResponseMetadata{
+				Diff:      fileDiff,
+				Additions: additions,
+				Removals:  removals,
+			}); held {
+				return resp, nil
+			}
 
 			p, err := permissions.Request(ctx,
-				permission.CreatePermissionRequest{
+				withOwnership(owners, permission.CreatePermissionRequest{
 					SessionID:   sessionID,
 					Path:        fsext.PathOrPrefix(filePath, workingDir),
+					Target:      filePath,
 					ToolCallID:  call.ID,
 					ToolName:    WriteToolName,
 					Action:      "write",
@@ -122,7 +137,7 @@ func NewWriteTool(
 						OldContent: oldContent,
 						NewContent: params.Content,
 					},
-				},
+				}, filePath),
 			)
 			if err != nil {
 				return fantasy.ToolResponse{}, err
@@ -131,7 +146,12 @@ func NewWriteTool(
 				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 			}
 
-			err = os.WriteFile(filePath, []byte(params.Content), 0o644)
+			dir := filepath.Dir(filePath)
+			if err = os.MkdirAll(dir, 0o755); err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("error creating directory: %w", err)
+			}
+
+			err = writeFileOrSudo(ctx, permissions, call, filePath, []byte(params.Content), 0o644)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error writing file: %w", err)
 			}
@@ -165,9 +185,12 @@ func NewWriteTool(
 			result := fmt.Sprintf("File successfully written: %s", filePath)
 			result = fmt.Sprintf("<result>\n%s\n</result>", result)
 			result += getDiagnostics(filePath, lspManager)
+			result += checks.Check(ctx, filePath)
+			result += ownershipNote(owners, workingDir, filePath)
+			result += headerNote(headers, filePath)
 			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(result),
 				WriteResponseMetadata{
-					Diff:      diff,
+					Diff:      fileDiff,
 					Additions: additions,
 					Removals:  removals,
 				},
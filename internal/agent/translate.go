@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+//go:embed templates/translate.md
+var translatePrompt []byte
+
+// toEnglishPrompt asks for the language of a prompt and, unless it is
+// English, the prompt in English.
+const toEnglishPrompt = `Name the language the message below is written in, in English, alone on the first line. If it is not English, follow with a blank line and the message translated to English.
+
+<message>
+%s
+</message>`
+
+// fromEnglishPrompt asks for a reply of the assistant in the language the
+// user wrote in.
+const fromEnglishPrompt = `Translate the message below from English to %s.
+
+<message>
+%s
+</message>`
+
+// translateToEnglish returns the English translation of prompt, or nil when
+// it is written in English or could not be translated, in which case the
+// prompt is sent as it is.
+func (a *sessionAgent) translateToEnglish(ctx context.Context, sessionID, prompt string) *message.Translation {
+	if strings.TrimSpace(prompt) == "" {
+		return nil
+	}
+	text, err := a.translate(ctx, sessionID, fmt.Sprintf(toEnglishPrompt, prompt))
+	if err != nil {
+		slog.Warn("Failed to translate prompt", "session_id", sessionID, "error", err)
+		return nil
+	}
+	language, english, _ := strings.Cut(text, "\n")
+	language = strings.Trim(strings.TrimPrefix(strings.TrimSpace(language), "Language:"), " .*")
+	english = strings.TrimSpace(english)
+	if language == "" || english == "" || strings.EqualFold(language, "English") {
+		return nil
+	}
+	slog.Debug("Translated prompt", "session_id", sessionID, "language", language)
+	return &message.Translation{Language: language, Text: english}
+}
+
+// translateReplies translates the text of the assistant messages ids to
+// language, keeping the English the model wrote for the history. It stops
+// at the first failure, leaving the remaining replies in English.
+func (a *sessionAgent) translateReplies(ctx context.Context, sessionID string, ids []string, language string) {
+	for _, id := range ids {
+		msg, err := a.messages.Get(ctx, id)
+		if err != nil {
+			slog.Warn("Failed to get reply to translate", "session_id", sessionID, "error", err)
+			return
+		}
+		text := strings.TrimSpace(msg.Content().Text)
+		if text == "" {
+			continue
+		}
+		translated, err := a.translate(ctx, sessionID, fmt.Sprintf(fromEnglishPrompt, language, text))
+		if err != nil {
+			slog.Warn("Failed to translate reply", "session_id", sessionID, "error", err)
+			return
+		}
+		if translated == "" {
+			continue
+		}
+		msg.SetTranslation(message.Translation{Language: language, Text: translated})
+		if err := a.messages.Update(ctx, msg); err != nil {
+			slog.Warn("Failed to save translated reply", "session_id", sessionID, "error", err)
+			return
+		}
+	}
+}
+
+// translate sends prompt to the small model with the translation system
+// prompt and returns its answer.
+func (a *sessionAgent) translate(ctx context.Context, sessionID, prompt string) (string, error) {
+	return a.AskSmallModel(ctx, sessionID, string(translatePrompt), prompt)
+}
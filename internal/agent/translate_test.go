@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+// translatorModel answers the translation requests of the agent as a model
+// would for a prompt written in French.
+type translatorModel struct {
+	fantasy.LanguageModel
+}
+
+func (translatorModel) Provider() string { return "fake" }
+func (translatorModel) Model() string    { return "fake" }
+
+func (translatorModel) Stream(_ context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	reply := "Title"
+	switch prompt := lastUserText(call.Prompt); {
+	case strings.Contains(prompt, "Name the language"):
+		reply = "French\n\nfix the tests"
+	case strings.Contains(prompt, "from English to French"):
+		reply = "C'est fait."
+	}
+	return (&scriptedModel{reply: reply}).Stream(context.Background(), call)
+}
+
+// promptRecorder remembers the last user message it was sent.
+type promptRecorder struct {
+	scriptedModel
+	mu     sync.Mutex
+	prompt string
+}
+
+func (m *promptRecorder) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	m.mu.Lock()
+	m.prompt = lastUserText(call.Prompt)
+	m.mu.Unlock()
+	return m.scriptedModel.Stream(ctx, call)
+}
+
+func lastUserText(prompt fantasy.Prompt) string {
+	var text string
+	for _, msg := range prompt {
+		if msg.Role != fantasy.MessageRoleUser {
+			continue
+		}
+		text = ""
+		for _, part := range msg.Content {
+			if p, ok := part.(fantasy.TextPart); ok {
+				text += p.Text
+			}
+		}
+	}
+	return text
+}
+
+func TestRunTranslates(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	large := &promptRecorder{scriptedModel: scriptedModel{reply: "Done."}}
+	a := testSessionAgent(env, large, translatorModel{}, "You are helpful.")
+	sess, err := env.sessions.Create(t.Context(), "translate")
+	require.NoError(t, err)
+	_, err = env.sessions.SetTranslate(t.Context(), sess.ID, true)
+	require.NoError(t, err)
+
+	_, err = a.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "corrige les tests", MaxOutputTokens: 100})
+	require.NoError(t, err)
+	require.Equal(t, "fix the tests", large.prompt)
+
+	msgs, err := env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, "corrige les tests", msgs[0].Content().Text)
+	translation, ok := msgs[0].Translation()
+	require.True(t, ok)
+	require.Equal(t, message.Translation{Language: "French", Text: "fix the tests"}, translation)
+
+	require.Equal(t, "Done.", msgs[1].Content().Text)
+	translation, ok = msgs[1].Translation()
+	require.True(t, ok)
+	require.Equal(t, "C'est fait.", translation.Text)
+}
+
+func TestRunDoesNotTranslateEnglish(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	large := &promptRecorder{scriptedModel: scriptedModel{reply: "Done."}}
+	a := testSessionAgent(env, large, &scriptedModel{reply: "English"}, "You are helpful.")
+	sess, err := env.sessions.Create(t.Context(), "english")
+	require.NoError(t, err)
+	_, err = env.sessions.SetTranslate(t.Context(), sess.ID, true)
+	require.NoError(t, err)
+
+	_, err = a.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "fix the tests", MaxOutputTokens: 100})
+	require.NoError(t, err)
+	require.Equal(t, "fix the tests", large.prompt)
+
+	msgs, err := env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	for _, msg := range msgs {
+		_, ok := msg.Translation()
+		require.False(t, ok)
+	}
+}
@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"regexp"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+const (
+	// charsPerToken is the rough ratio used to estimate the token count of
+	// text that has not been sent to the provider yet.
+	charsPerToken = 4
+	// imagePixelsPerToken and maxImageTokens follow how the major providers
+	// bill images: by area, up to the size they downscale to.
+	imagePixelsPerToken = 750
+	maxImageTokens      = 1600
+	// pdfPageTokens is the rough cost of a PDF page sent as text and image.
+	pdfPageTokens = 1500
+)
+
+var pdfPage = regexp.MustCompile(`/Type\s*/Page\b`)
+
+// ContextUsage describes how much of the model's context window the next
+// request in a session is expected to use.
+type ContextUsage struct {
+	// PromptTokens is the estimated size of the next prompt: the tokens of
+	// the last exchange plus any draft text.
+	PromptTokens int64
+	// ReservedTokens is the output budget reserved for the response.
+	ReservedTokens int64
+	// ContextWindow is the model's context window, zero if unknown.
+	ContextWindow int64
+	// Percentage is the share of the window consumed by PromptTokens.
+	Percentage float64
+	// DraftTokens is the part of PromptTokens taken by the draft and its
+	// attachments.
+	DraftTokens int64
+	// CostPer1MIn is the model's price per million input tokens.
+	CostPer1MIn float64
+}
+
+// Remaining returns the tokens left in the window once the prompt and the
+// reserved output are accounted for.
+func (u ContextUsage) Remaining() int64 {
+	return max(0, u.ContextWindow-u.PromptTokens-u.ReservedTokens)
+}
+
+// NearLimit reports whether the prompt and the reserved output fill at least
+// 80% of the context window.
+func (u ContextUsage) NearLimit() bool {
+	return u.ContextWindow > 0 && u.PromptTokens+u.ReservedTokens >= u.ContextWindow*8/10
+}
+
+// Cost returns the estimated input cost of sending the prompt, ignoring
+// prompt caching, so it errs on the high side.
+func (u ContextUsage) Cost() float64 {
+	return u.CostPer1MIn / 1e6 * float64(u.PromptTokens)
+}
+
+// WithDraft returns the usage with the estimated tokens of a prompt that is
+// still being written, and the files attached to it, added to it.
+func (u ContextUsage) WithDraft(draft string, attachments ...message.Attachment) ContextUsage {
+	tokens := EstimateTokens(draft)
+	for _, att := range attachments {
+		tokens += EstimateAttachmentTokens(att)
+	}
+	u.DraftTokens += tokens
+	u.PromptTokens += tokens
+	u.Percentage = percentage(u.PromptTokens, u.ContextWindow)
+	return u
+}
+
+// EstimateTokens gives a rough token count for text.
+func EstimateTokens(text string) int64 {
+	if text == "" {
+		return 0
+	}
+	return int64((len(text) + charsPerToken - 1) / charsPerToken)
+}
+
+// EstimateAttachmentTokens gives a rough token count for an attachment.
+func EstimateAttachmentTokens(att message.Attachment) int64 {
+	switch {
+	case att.IsImage():
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(att.Content))
+		if err != nil {
+			return maxImageTokens
+		}
+		return min(int64(cfg.Width*cfg.Height/imagePixelsPerToken), maxImageTokens)
+	case att.IsPDF():
+		return int64(max(1, len(pdfPage.FindAllIndex(att.Content, -1)))) * pdfPageTokens
+	default:
+		return EstimateTokens(string(att.Content))
+	}
+}
+
+// NewContextUsage computes the context usage of a session for model.
+func NewContextUsage(model Model, sess session.Session) ContextUsage {
+	reserved := model.CatwalkCfg.DefaultMaxTokens
+	if model.ModelCfg.MaxTokens != 0 {
+		reserved = model.ModelCfg.MaxTokens
+	}
+	window := model.CatwalkCfg.ContextWindow
+	prompt := sess.PromptTokens + sess.CompletionTokens
+	return ContextUsage{
+		PromptTokens:   prompt,
+		ReservedTokens: reserved,
+		ContextWindow:  window,
+		Percentage:     percentage(prompt, window),
+		CostPer1MIn:    model.CatwalkCfg.CostPer1MIn,
+	}
+}
+
+// ContextUsage returns the context usage of a session for the current model.
+func (c *coordinator) ContextUsage(ctx context.Context, sessionID string) (ContextUsage, error) {
+	sess, err := c.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return ContextUsage{}, err
+	}
+	return NewContextUsage(c.Model(), sess), nil
+}
+
+func percentage(tokens, window int64) float64 {
+	if window <= 0 {
+		return 0
+	}
+	return float64(tokens) / float64(window) * 100
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextUsage(t *testing.T) {
+	t.Parallel()
+
+	model := Model{
+		CatwalkCfg: catwalk.Model{ContextWindow: 1000, DefaultMaxTokens: 100},
+	}
+	usage := NewContextUsage(model, session.Session{PromptTokens: 150, CompletionTokens: 50})
+	require.Equal(t, int64(200), usage.PromptTokens)
+	require.Equal(t, int64(100), usage.ReservedTokens)
+	require.InDelta(t, 20.0, usage.Percentage, 0.001)
+	require.Equal(t, int64(700), usage.Remaining())
+
+	withDraft := usage.WithDraft("twelve chars")
+	require.Equal(t, int64(203), withDraft.PromptTokens)
+	require.InDelta(t, 20.3, withDraft.Percentage, 0.001)
+	require.Equal(t, int64(3), withDraft.DraftTokens)
+	require.Equal(t, int64(200), usage.PromptTokens, "WithDraft must not modify the receiver")
+
+	withFile := usage.WithDraft("", message.Attachment{MimeType: "text/plain", Content: []byte("abcdefgh")})
+	require.Equal(t, int64(2), withFile.DraftTokens)
+	require.Equal(t, int64(202), withFile.PromptTokens)
+
+	model.ModelCfg = config.SelectedModel{MaxTokens: 300}
+	require.Equal(t, int64(300), NewContextUsage(model, session.Session{}).ReservedTokens)
+
+	require.Zero(t, NewContextUsage(Model{}, session.Session{PromptTokens: 10}).Percentage)
+}
+
+func TestContextUsageCostAndLimit(t *testing.T) {
+	t.Parallel()
+
+	model := Model{
+		CatwalkCfg: catwalk.Model{ContextWindow: 1000, DefaultMaxTokens: 100, CostPer1MIn: 3},
+	}
+	usage := NewContextUsage(model, session.Session{PromptTokens: 500})
+	require.InDelta(t, 0.0015, usage.Cost(), 1e-9)
+	require.False(t, usage.NearLimit())
+	require.True(t, usage.WithDraft(strings.Repeat("x", 800)).NearLimit())
+	require.False(t, ContextUsage{PromptTokens: 10}.NearLimit(), "unknown windows are never near the limit")
+}
+
+func TestEstimateAttachmentTokens(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, int64(2), EstimateAttachmentTokens(message.Attachment{MimeType: "text/plain", Content: []byte("abcdefgh")}))
+	require.Equal(t, int64(100*75/imagePixelsPerToken), EstimateAttachmentTokens(message.Attachment{MimeType: "image/png", Content: testPNG(t, 100, 75)}))
+	require.Equal(t, int64(maxImageTokens), EstimateAttachmentTokens(message.Attachment{MimeType: "image/png", Content: testPNG(t, 3000, 2000)}))
+	require.Equal(t, int64(maxImageTokens), EstimateAttachmentTokens(message.Attachment{MimeType: "image/png", Content: []byte("not a png")}))
+
+	pdf := []byte("%PDF-1.4 << /Type /Pages /Count 2 >> << /Type /Page >> << /Type/Page >>")
+	require.Equal(t, int64(2*pdfPageTokens), EstimateAttachmentTokens(message.Attachment{MimeType: message.MimePDF, Content: pdf}))
+}
+
+func TestEstimateTokens(t *testing.T) {
+	t.Parallel()
+
+	require.Zero(t, EstimateTokens(""))
+	require.Equal(t, int64(1), EstimateTokens("a"))
+	require.Equal(t, int64(2), EstimateTokens("abcdefgh"))
+}
@@ -0,0 +1,106 @@
+// Package vertex provides a fantasy.Provider for the Gemini and Anthropic
+// models of Google Vertex AI. Requests are authorized with Application
+// Default Credentials, or the service account key configured, rather than
+// an API key, so the IAM setup of a project is used as it is.
+package vertex
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/google"
+	"cloud.google.com/go/auth"
+	"cloud.google.com/go/auth/credentials"
+)
+
+const (
+	// Name is the name of the Vertex AI provider.
+	Name = "google-vertex"
+
+	defaultLocation = "us-central1"
+	scope           = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// Options configures the Vertex AI provider.
+type Options struct {
+	// Project is the Google Cloud project to send requests to. It defaults
+	// to the project of the credentials.
+	Project string
+	// Location is the region to send requests to, or global. It defaults
+	// to us-central1.
+	Location string
+	// Credentials is the path to a service account key or other credentials
+	// file. Empty uses the Application Default Credentials.
+	Credentials string
+	Headers     map[string]string
+	HTTPClient  *http.Client
+}
+
+// New creates a Vertex AI provider, finding the credentials to authorize
+// its requests with.
+func New(ctx context.Context, opts Options) (fantasy.Provider, error) {
+	creds, err := credentials.DetectDefault(&credentials.DetectOptions{
+		Scopes:          []string{scope},
+		CredentialsFile: opts.Credentials,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("vertex: failed to find Google credentials: %w", err)
+	}
+
+	project := opts.Project
+	if project == "" {
+		if project, err = creds.ProjectID(ctx); err != nil {
+			return nil, fmt.Errorf("vertex: failed to get the project of the credentials: %w", err)
+		}
+		if project == "" {
+			return nil, errors.New("vertex: no project configured or found in the credentials")
+		}
+	}
+
+	t := &transport{tokens: creds, next: http.DefaultTransport}
+	if opts.HTTPClient != nil && opts.HTTPClient.Transport != nil {
+		t.next = opts.HTTPClient.Transport
+	}
+	// User credentials bill the API usage to their quota project, rather
+	// than to the project of the gcloud client.
+	if t.quotaProject, err = creds.QuotaProjectID(ctx); err != nil {
+		return nil, fmt.Errorf("vertex: failed to get the quota project of the credentials: %w", err)
+	}
+
+	providerOpts := []google.Option{
+		google.WithVertex(project, cmp.Or(opts.Location, defaultLocation)),
+		// The transport authorizes the requests, for the Gemini and the
+		// Anthropic models alike.
+		google.WithSkipAuth(true),
+		google.WithHTTPClient(&http.Client{Transport: t}),
+	}
+	if len(opts.Headers) > 0 {
+		providerOpts = append(providerOpts, google.WithHeaders(opts.Headers))
+	}
+	return google.New(providerOpts...)
+}
+
+// transport authorizes requests with an access token of the credentials,
+// which refresh it when it expires.
+type transport struct {
+	tokens       auth.TokenProvider
+	quotaProject string
+	next         http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.tokens.Token(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("vertex: failed to get an access token: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", cmp.Or(token.Type, "Bearer")+" "+token.Value)
+	if t.quotaProject != "" && req.Header.Get("X-Goog-User-Project") == "" {
+		req.Header.Set("X-Goog-User-Project", t.quotaProject)
+	}
+	return t.next.RoundTrip(req)
+}
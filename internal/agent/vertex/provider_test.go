@@ -0,0 +1,121 @@
+package vertex
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"cloud.google.com/go/auth"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+type staticToken string
+
+func (s staticToken) Token(context.Context) (*auth.Token, error) {
+	return &auth.Token{Value: string(s)}, nil
+}
+
+// writeServiceAccount writes a service account key of project whose tokens
+// are issued by tokenURL.
+func writeServiceAccount(t *testing.T, project, tokenURL string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	data, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     project,
+		"private_key_id": "1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "crush@" + project + ".iam.gserviceaccount.com",
+		"token_uri":      tokenURL,
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "key.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestNewWithServiceAccount(t *testing.T) {
+	t.Parallel()
+
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"access_token":"sa-token","token_type":"Bearer","expires_in":3600}`)
+	}))
+	t.Cleanup(tokens.Close)
+
+	var sent *http.Request
+	next := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		sent = r
+		body := `{"candidates":[{"content":{"role":"model","parts":[{"text":"Hello"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":1,"candidatesTokenCount":1}}`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})
+
+	provider, err := New(t.Context(), Options{
+		Location:    "europe-west4",
+		Credentials: writeServiceAccount(t, "sa-project", tokens.URL),
+		HTTPClient:  &http.Client{Transport: next},
+	})
+	require.NoError(t, err)
+	model, err := provider.LanguageModel(t.Context(), "gemini-2.5-pro")
+	require.NoError(t, err)
+	resp, err := model.Generate(t.Context(), fantasy.Call{Prompt: fantasy.Prompt{fantasy.NewUserMessage("Hi")}})
+	require.NoError(t, err)
+	require.Equal(t, "Hello", resp.Content.Text())
+
+	require.Equal(t, "europe-west4-aiplatform.googleapis.com", sent.URL.Host)
+	require.Contains(t, sent.URL.Path, "/projects/sa-project/locations/europe-west4/publishers/google/models/gemini-2.5-pro:generateContent")
+	require.Equal(t, "Bearer sa-token", sent.Header.Get("Authorization"))
+}
+
+func TestNewWithoutProject(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(t.Context(), Options{Credentials: writeServiceAccount(t, "", "http://127.0.0.1:0")})
+	require.ErrorContains(t, err, "no project")
+}
+
+func TestTransportAuthorizes(t *testing.T) {
+	t.Parallel()
+
+	var sent *http.Request
+	tr := &transport{
+		tokens:       staticToken("user-token"),
+		quotaProject: "billing",
+		next: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			sent = r
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+		}),
+	}
+	r, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "https://aiplatform.googleapis.com/v1/x", nil)
+	require.NoError(t, err)
+	r.Header.Set("Authorization", "Bearer dummy-token")
+	_, err = tr.RoundTrip(r)
+	require.NoError(t, err)
+
+	require.Equal(t, "Bearer user-token", sent.Header.Get("Authorization"))
+	require.Equal(t, "billing", sent.Header.Get("X-Goog-User-Project"))
+	require.Equal(t, "Bearer dummy-token", r.Header.Get("Authorization"), "the request sent is a copy")
+}
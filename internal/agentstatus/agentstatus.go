@@ -0,0 +1,243 @@
+// Package agentstatus keeps a small file with whether the agent is idle,
+// working or waiting for approval, for tmux and screen status bars and other
+// tools to poll with crush status.
+package agentstatus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+// State is what the agent is doing.
+type State string
+
+const (
+	Idle    State = "idle"
+	Working State = "working"
+	// Waiting is set while a permission prompt waits for an answer.
+	Waiting State = "waiting"
+	// Stopped is read when no Crush keeps the status file.
+	Stopped State = "stopped"
+)
+
+const (
+	fileName = "status.json"
+
+	// pollInterval is how often the state is checked between events, to
+	// catch the agent going idle after the last message of a turn.
+	pollInterval = 2 * time.Second
+	// heartbeat is how often the file is rewritten while the state stays
+	// the same, and staleAfter how old it is when its Crush is considered
+	// gone, such as after a crash.
+	heartbeat  = 30 * time.Second
+	staleAfter = 3 * heartbeat
+)
+
+// Status is the content of the status file.
+type Status struct {
+	State State `json:"state"`
+	// SessionID is the session the agent last worked on.
+	SessionID string    `json:"session_id,omitempty"`
+	PID       int       `json:"pid"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Path returns the status file of the project with data directory dataDir.
+func Path(dataDir string) string {
+	return filepath.Join(dataDir, fileName)
+}
+
+// Read reads the status file at path. A missing or stale file reads as
+// [Stopped].
+func Read(path string) (Status, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Status{State: Stopped}, nil
+	}
+	if err != nil {
+		return Status{}, err
+	}
+	var s Status
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Status{}, fmt.Errorf("status file %s: %w", path, err)
+	}
+	if time.Since(s.UpdatedAt) > staleAfter {
+		s.State = Stopped
+	}
+	return s, nil
+}
+
+// write replaces the status file at path with s, through a temporary file
+// so that readers never see it half written.
+func write(path string, s Status) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), fileName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Tracker writes the state of the agent of this process to the status file.
+type Tracker struct {
+	path string
+	busy func() bool
+	pid  int
+	now  func() time.Time
+
+	// pending holds the tool calls whose permission prompts wait for an
+	// answer.
+	pending   map[string]struct{}
+	sessionID string
+	written   Status
+}
+
+// NewTracker returns a tracker of the status file at path, that asks busy
+// whether the agent is working.
+func NewTracker(path string, busy func() bool) *Tracker {
+	return &Tracker{
+		path:    path,
+		busy:    busy,
+		pid:     os.Getpid(),
+		now:     time.Now,
+		pending: make(map[string]struct{}),
+	}
+}
+
+// Run keeps the status file up to date with the events of the agent until
+// ctx is done, then removes it.
+func (t *Tracker) Run(
+	ctx context.Context,
+	messages <-chan pubsub.Event[message.Message],
+	requests <-chan pubsub.Event[permission.PermissionRequest],
+	notifications <-chan pubsub.Event[permission.PermissionNotification],
+) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	defer t.remove()
+
+	t.update()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-messages:
+			if !ok {
+				return
+			}
+			t.sessionID = event.Payload.SessionID
+		case event, ok := <-requests:
+			if !ok {
+				return
+			}
+			t.pending[event.Payload.ToolCallID] = struct{}{}
+			t.sessionID = event.Payload.SessionID
+		case event, ok := <-notifications:
+			if !ok {
+				return
+			}
+			if event.Payload.Granted || event.Payload.Denied {
+				delete(t.pending, event.Payload.ToolCallID)
+			}
+		case <-ticker.C:
+		}
+		t.update()
+	}
+}
+
+// state returns what the agent is doing.
+func (t *Tracker) state() State {
+	if !t.busy() {
+		// Prompts of canceled requests are never answered.
+		clear(t.pending)
+		return Idle
+	}
+	if len(t.pending) > 0 {
+		return Waiting
+	}
+	return Working
+}
+
+// update writes the status file when the state changed or the heartbeat is
+// due.
+func (t *Tracker) update() {
+	s := Status{State: t.state(), SessionID: t.sessionID, PID: t.pid, UpdatedAt: t.now()}
+	if s.State == t.written.State && s.SessionID == t.written.SessionID && s.UpdatedAt.Sub(t.written.UpdatedAt) < heartbeat {
+		return
+	}
+	if err := write(t.path, s); err != nil {
+		slog.Warn("Failed to write the agent status", "path", t.path, "error", err)
+		return
+	}
+	t.written = s
+}
+
+// remove removes the status file, unless another Crush of the project took
+// it over since.
+func (t *Tracker) remove() {
+	s, err := Read(t.path)
+	if err != nil || s.PID != t.pid {
+		return
+	}
+	if err := os.Remove(t.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		slog.Warn("Failed to remove the agent status", "path", t.path, "error", err)
+	}
+}
+
+// Formats are the formats [Status.Format] knows.
+var Formats = []string{"text", "json", "tmux"}
+
+// Format formats s as text, such as "waiting for approval", as JSON, or for
+// the status line of tmux, colored and empty when no Crush runs.
+func (s Status) Format(format string) (string, error) {
+	switch format {
+	case "", "text":
+		return s.describe(), nil
+	case "json":
+		data, err := json.Marshal(s)
+		return string(data), err
+	case "tmux":
+		switch s.State {
+		case Working:
+			return "#[fg=yellow]●#[default] crush working", nil
+		case Waiting:
+			return "#[fg=red,bold]●#[default] crush needs approval", nil
+		case Idle:
+			return "#[fg=green]●#[default] crush", nil
+		}
+		return "", nil
+	}
+	return "", fmt.Errorf("unknown status format %q: want one of %v", format, Formats)
+}
+
+func (s Status) describe() string {
+	switch s.State {
+	case Waiting:
+		return "waiting for approval"
+	case "":
+		return string(Stopped)
+	}
+	return string(s.State)
+}
@@ -0,0 +1,157 @@
+package agentstatus
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	path := Path(t.TempDir())
+	s, err := Read(path)
+	require.NoError(t, err)
+	require.Equal(t, Stopped, s.State, "no file reads as stopped")
+
+	require.NoError(t, write(path, Status{State: Working, PID: 1, UpdatedAt: time.Now()}))
+	s, err = Read(path)
+	require.NoError(t, err)
+	require.Equal(t, Working, s.State)
+
+	require.NoError(t, write(path, Status{State: Working, PID: 1, UpdatedAt: time.Now().Add(-staleAfter - time.Second)}))
+	s, err = Read(path)
+	require.NoError(t, err)
+	require.Equal(t, Stopped, s.State, "a stale file reads as stopped")
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	_, err = Read(path)
+	require.Error(t, err)
+}
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+
+	path := Path(t.TempDir())
+	busy := false
+	tracker := NewTracker(path, func() bool { return busy })
+	now := time.Now()
+	tracker.now = func() time.Time { return now }
+
+	state := func() State {
+		t.Helper()
+		s, err := Read(path)
+		require.NoError(t, err)
+		return s.State
+	}
+
+	tracker.update()
+	require.Equal(t, Idle, state())
+
+	busy = true
+	tracker.update()
+	require.Equal(t, Working, state())
+
+	tracker.pending["call"] = struct{}{}
+	tracker.update()
+	require.Equal(t, Waiting, state())
+
+	// A request canceled with its turn is never answered.
+	busy = false
+	tracker.update()
+	require.Equal(t, Idle, state())
+	require.Empty(t, tracker.pending)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	tracker.update()
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, info.ModTime(), after.ModTime(), "an unchanged state is not written again")
+
+	tracker.remove()
+	require.NoFileExists(t, path)
+}
+
+func TestTrackerRun(t *testing.T) {
+	t.Parallel()
+
+	path := Path(t.TempDir())
+	busy := make(chan bool, 1)
+	busy <- true
+	current := true
+	tracker := NewTracker(path, func() bool {
+		select {
+		case current = <-busy:
+		default:
+		}
+		return current
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	messages := make(chan pubsub.Event[message.Message])
+	requests := make(chan pubsub.Event[permission.PermissionRequest])
+	notifications := make(chan pubsub.Event[permission.PermissionNotification])
+	done := make(chan struct{})
+	go func() {
+		tracker.Run(ctx, messages, requests, notifications)
+		close(done)
+	}()
+
+	requests <- pubsub.Event[permission.PermissionRequest]{Payload: permission.PermissionRequest{SessionID: "session", ToolCallID: "call"}}
+	messages <- pubsub.Event[message.Message]{Payload: message.Message{SessionID: "session"}}
+	s, err := Read(path)
+	require.NoError(t, err)
+	require.Equal(t, Waiting, s.State)
+	require.Equal(t, "session", s.SessionID)
+	require.Equal(t, os.Getpid(), s.PID)
+
+	notifications <- pubsub.Event[permission.PermissionNotification]{Payload: permission.PermissionNotification{ToolCallID: "call", Granted: true}}
+	messages <- pubsub.Event[message.Message]{Payload: message.Message{SessionID: "session"}}
+	s, err = Read(path)
+	require.NoError(t, err)
+	require.Equal(t, Working, s.State)
+
+	cancel()
+	<-done
+	require.NoFileExists(t, path)
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		state      State
+		text, tmux string
+	}{
+		{Idle, "idle", "#[fg=green]●#[default] crush"},
+		{Working, "working", "#[fg=yellow]●#[default] crush working"},
+		{Waiting, "waiting for approval", "#[fg=red,bold]●#[default] crush needs approval"},
+		{Stopped, "stopped", ""},
+	} {
+		s := Status{State: tt.state}
+		text, err := s.Format("text")
+		require.NoError(t, err)
+		require.Equal(t, tt.text, text)
+		tmux, err := s.Format("tmux")
+		require.NoError(t, err)
+		require.Equal(t, tt.tmux, tmux)
+	}
+
+	s := Status{State: Working, SessionID: "session", PID: 42, UpdatedAt: time.Unix(0, 0).UTC()}
+	out, err := s.Format("json")
+	require.NoError(t, err)
+	var got Status
+	require.NoError(t, json.Unmarshal([]byte(out), &got))
+	require.Equal(t, s, got)
+
+	_, err = s.Format("xml")
+	require.Error(t, err)
+}
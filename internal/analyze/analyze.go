@@ -0,0 +1,385 @@
+// Package analyze reports where the context tokens of a session went, such
+// as files read more than once and oversized tool outputs, with suggestions
+// to spend fewer of them.
+package analyze
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// DefaultLargeOutputTokens is how many tokens a tool output takes before it
+// is reported as oversized.
+const DefaultLargeOutputTokens = 4000
+
+// Kind is a kind of token waste.
+type Kind string
+
+const (
+	// KindDuplicateRead is a file read again without changing in between.
+	KindDuplicateRead Kind = "duplicate_read"
+	// KindLargeOutput is a tool output larger than the threshold.
+	KindLargeOutput Kind = "large_output"
+	// KindRepeatedCall is a read-only tool called again with the same input
+	// and no edit in between.
+	KindRepeatedCall Kind = "repeated_call"
+	// KindRepeatedPrompt is a prompt sent again instead of editing the
+	// earlier one.
+	KindRepeatedPrompt Kind = "repeated_prompt"
+	// KindCacheDisabled is prompt caching turned off.
+	KindCacheDisabled Kind = "cache_disabled"
+)
+
+// readOnlyTools are the tools whose repeated calls with the same input give
+// the same output while no file changes.
+var readOnlyTools = []string{
+	tools.GrepToolName,
+	tools.CodeSearchToolName,
+	tools.GlobToolName,
+	tools.LSToolName,
+	tools.SourcegraphToolName,
+	tools.FetchToolName,
+	tools.WebFetchToolName,
+	tools.WebSearchToolName,
+	tools.GitHubIssueToolName,
+	tools.ReferencesToolName,
+	tools.DiagnosticsToolName,
+}
+
+// editTools are the tools that change the files they are given.
+var editTools = []string{
+	tools.EditToolName,
+	tools.EditSymbolToolName,
+	tools.MultiEditToolName,
+	tools.WriteToolName,
+	tools.ReplaceAllToolName,
+}
+
+// Finding is a place tokens were wasted.
+type Finding struct {
+	Kind Kind `json:"kind"`
+	// Tool is the tool the finding is about, if any.
+	Tool string `json:"tool,omitempty"`
+	// Target is what was wasted on: a file, a command or a prompt.
+	Target string `json:"target"`
+	// Count is how many times it happened.
+	Count int `json:"count"`
+	// Tokens is the estimated size of the waste in the context.
+	Tokens int64 `json:"tokens"`
+	// ResentTokens is the estimated number of tokens the waste took in the
+	// requests sent after it, as every request sends the whole context.
+	ResentTokens int64  `json:"resent_tokens"`
+	Suggestion   string `json:"suggestion"`
+}
+
+// ToolUsage is how much of the context the outputs of a tool took.
+type ToolUsage struct {
+	Name   string `json:"name"`
+	Calls  int    `json:"calls"`
+	Tokens int64  `json:"tokens"`
+}
+
+// Report is the analysis of a session.
+type Report struct {
+	SessionID        string  `json:"session_id"`
+	Title            string  `json:"title"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+	Turns            int     `json:"turns"`
+	// Requests is the number of requests sent to the model.
+	Requests int `json:"requests"`
+	// ContextTokens is the estimated size of the transcript.
+	ContextTokens int64 `json:"context_tokens"`
+	// WastedTokens is the estimated size of the findings in the context.
+	WastedTokens int64       `json:"wasted_tokens"`
+	Tools        []ToolUsage `json:"tools"`
+	Findings     []Finding   `json:"findings"`
+	// Suggestions are the distinct suggestions of the findings, most
+	// effective first.
+	Suggestions []string `json:"suggestions"`
+}
+
+// Options tune the analysis.
+type Options struct {
+	// LargeOutputTokens is how many tokens a tool output takes before it is
+	// reported; DefaultLargeOutputTokens when zero.
+	LargeOutputTokens int64
+	// CacheDisabled reports that prompt caching was turned off.
+	CacheDisabled bool
+}
+
+// Session loads a session and its messages and analyzes them. Caching counts
+// as disabled when CRUSH_DISABLE_ANTHROPIC_CACHE is set.
+func Session(ctx context.Context, sessions session.Service, messages message.Service, sessionID string, opts Options) (Report, error) {
+	sess, err := sessions.Get(ctx, sessionID)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to get session: %w", err)
+	}
+	msgs, err := messages.List(ctx, sess.ID)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to list messages: %w", err)
+	}
+	if disabled, _ := strconv.ParseBool(os.Getenv("CRUSH_DISABLE_ANTHROPIC_CACHE")); disabled {
+		opts.CacheDisabled = true
+	}
+	return Analyze(sess, msgs, opts), nil
+}
+
+// Analyze reports where the context tokens of a session went.
+func Analyze(sess session.Session, msgs []message.Message, opts Options) Report {
+	largeOutput := cmp.Or(opts.LargeOutputTokens, DefaultLargeOutputTokens)
+	r := Report{
+		SessionID:        sess.ID,
+		Title:            sess.Title,
+		PromptTokens:     sess.PromptTokens,
+		CompletionTokens: sess.CompletionTokens,
+		Cost:             sess.Cost,
+		Tools:            []ToolUsage{},
+		Findings:         []Finding{},
+		Suggestions:      []string{},
+	}
+
+	// resent[i] is the number of requests sent after message i while it
+	// was still in the context, that is before a summary replaced it.
+	resent := make([]int64, len(msgs))
+	var requests int64
+	for i := len(msgs) - 1; i >= 0; i-- {
+		resent[i] = requests
+		switch {
+		case msgs[i].IsSummaryMessage:
+			requests = 0
+		case msgs[i].Role == message.Assistant:
+			requests++
+		}
+	}
+
+	calls := make(map[string]message.ToolCall)
+	for _, msg := range msgs {
+		for _, c := range msg.ToolCalls() {
+			calls[c.ID] = c
+		}
+	}
+
+	findings := make(map[string]*Finding)
+	add := func(kind Kind, tool, target string, tokens, resentTokens int64, suggestion string) {
+		key := string(kind) + "\x00" + tool + "\x00" + target
+		f, ok := findings[key]
+		if !ok {
+			f = &Finding{Kind: kind, Tool: tool, Target: target, Suggestion: suggestion}
+			findings[key] = f
+		}
+		f.Count++
+		f.Tokens += tokens
+		f.ResentTokens += resentTokens
+	}
+
+	usage := make(map[string]*ToolUsage)
+	// reads maps the files read, and the range read, since they last
+	// changed. repeats does the same for the calls of read-only tools.
+	reads := make(map[string]bool)
+	repeats := make(map[string]bool)
+	prompts := make(map[string]bool)
+	for i, msg := range msgs {
+		if msg.IsSummaryMessage {
+			// What was read before is gone from the context.
+			clear(reads)
+			clear(repeats)
+			r.ContextTokens += agent.EstimateTokens(msg.Content().Text)
+			continue
+		}
+		switch msg.Role {
+		case message.User:
+			r.Turns++
+			text := msg.Content().Text
+			r.ContextTokens += agent.EstimateTokens(text)
+			prompt := strings.Join(strings.Fields(strings.ToLower(text)), " ")
+			if prompt != "" && prompts[prompt] {
+				tokens := agent.EstimateTokens(text)
+				add(KindRepeatedPrompt, "", headline(text), tokens, tokens*resent[i],
+					"Edit the earlier message and regenerate from it instead of sending it again, so the first attempt leaves the context")
+			}
+			prompts[prompt] = true
+		case message.Assistant:
+			r.Requests++
+			r.ContextTokens += agent.EstimateTokens(msg.Content().Text)
+			for _, c := range msg.ToolCalls() {
+				r.ContextTokens += agent.EstimateTokens(c.Input)
+				if !slices.Contains(editTools, c.Name) {
+					continue
+				}
+				// Files change, so reading them again is no waste.
+				clear(repeats)
+				if path := filePath(c.Input); path != "" {
+					for key := range reads {
+						if strings.HasPrefix(key, path+"\x00") {
+							delete(reads, key)
+						}
+					}
+				}
+			}
+		case message.Tool:
+			for _, result := range msg.ToolResults() {
+				tokens := agent.EstimateTokens(result.Content)
+				r.ContextTokens += tokens
+				u, ok := usage[result.Name]
+				if !ok {
+					u = &ToolUsage{Name: result.Name}
+					usage[result.Name] = u
+				}
+				u.Calls++
+				u.Tokens += tokens
+
+				resentTokens := tokens * resent[i]
+				if tokens > largeOutput {
+					add(KindLargeOutput, result.Name, target(calls[result.ToolCallID]), tokens, resentTokens, largeOutputSuggestion(result.Name))
+				}
+				c, ok := calls[result.ToolCallID]
+				if !ok || result.IsError {
+					continue
+				}
+				switch {
+				case c.Name == tools.ViewToolName:
+					key := filePath(c.Input) + "\x00" + readRange(c.Input)
+					if reads[key] {
+						add(KindDuplicateRead, c.Name, filePath(c.Input), tokens, resentTokens,
+							"Files read before stay in the context until they change; ask the agent to use what it read or to read only the lines it needs")
+					}
+					reads[key] = true
+				case slices.Contains(readOnlyTools, c.Name):
+					key := c.Name + "\x00" + compact(c.Input)
+					if repeats[key] {
+						add(KindRepeatedCall, c.Name, target(c), tokens, resentTokens,
+							"The output of the earlier call is still in the context; nothing changed since, so the call adds nothing")
+					}
+					repeats[key] = true
+				}
+			}
+		}
+	}
+
+	if opts.CacheDisabled && r.Requests > 1 {
+		r.Findings = append(r.Findings, Finding{
+			Kind:         KindCacheDisabled,
+			Target:       "CRUSH_DISABLE_ANTHROPIC_CACHE",
+			Count:        1,
+			Tokens:       r.PromptTokens,
+			ResentTokens: r.PromptTokens,
+			Suggestion:   "Unset CRUSH_DISABLE_ANTHROPIC_CACHE so the context sent again on every request is read from the cache at a fraction of the price",
+		})
+	}
+	for _, f := range findings {
+		r.Findings = append(r.Findings, *f)
+	}
+	for _, f := range r.Findings {
+		if f.Kind != KindCacheDisabled {
+			r.WastedTokens += f.Tokens
+		}
+	}
+	slices.SortStableFunc(r.Findings, func(a, b Finding) int {
+		return cmp.Or(
+			cmp.Compare(b.ResentTokens, a.ResentTokens),
+			cmp.Compare(b.Tokens, a.Tokens),
+			strings.Compare(string(a.Kind), string(b.Kind)),
+			strings.Compare(a.Target, b.Target),
+		)
+	})
+	for _, f := range r.Findings {
+		if !slices.Contains(r.Suggestions, f.Suggestion) {
+			r.Suggestions = append(r.Suggestions, f.Suggestion)
+		}
+	}
+
+	for _, u := range usage {
+		r.Tools = append(r.Tools, *u)
+	}
+	slices.SortFunc(r.Tools, func(a, b ToolUsage) int {
+		return cmp.Or(cmp.Compare(b.Tokens, a.Tokens), strings.Compare(a.Name, b.Name))
+	})
+	return r
+}
+
+// largeOutputSuggestion returns how to keep the output of a tool smaller.
+func largeOutputSuggestion(tool string) string {
+	switch tool {
+	case tools.ViewToolName:
+		return "Read large files in ranges with offset and limit instead of whole"
+	case tools.BashToolName:
+		return "Tighten command output with filters such as head, tail or grep, or quieter flags, before it reaches the context"
+	case tools.FetchToolName, tools.WebFetchToolName:
+		return "Fetch pages as text or markdown, or use agentic_fetch to return only the part needed"
+	case tools.GrepToolName, tools.CodeSearchToolName, tools.GlobToolName, tools.LSToolName:
+		return "Narrow searches down with a path or an include pattern"
+	default:
+		return "Ask for narrower output from this tool, or disable it with options.disabled_tools when it is not needed"
+	}
+}
+
+// filePath returns the file_path parameter of a tool call.
+func filePath(input string) string {
+	var params struct {
+		FilePath string `json:"file_path"`
+	}
+	_ = json.Unmarshal([]byte(input), &params)
+	return params.FilePath
+}
+
+// readRange returns the lines a view call read, as offset:limit.
+func readRange(input string) string {
+	var params struct {
+		Offset int `json:"offset"`
+		Limit  int `json:"limit"`
+	}
+	_ = json.Unmarshal([]byte(input), &params)
+	return fmt.Sprintf("%d:%d", params.Offset, params.Limit)
+}
+
+// target returns what a tool call is about, for reading.
+func target(c message.ToolCall) string {
+	var params map[string]any
+	if err := json.Unmarshal([]byte(c.Input), &params); err != nil {
+		return headline(c.Input)
+	}
+	for _, key := range []string{"file_path", "command", "pattern", "url", "query", "path"} {
+		if v, ok := params[key].(string); ok && v != "" {
+			return headline(v)
+		}
+	}
+	return headline(compact(c.Input))
+}
+
+// compact returns JSON input without insignificant whitespace.
+func compact(input string) string {
+	var v any
+	if err := json.Unmarshal([]byte(input), &v); err != nil {
+		return input
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return input
+	}
+	return string(out)
+}
+
+// maxHeadlineLength is how much of a prompt or command a finding shows.
+const maxHeadlineLength = 80
+
+// headline returns the first line of s, shortened for reading.
+func headline(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	if r := []rune(line); len(r) > maxHeadlineLength {
+		return string(r[:maxHeadlineLength-1]) + "…"
+	}
+	return line
+}
@@ -0,0 +1,145 @@
+package analyze
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func user(text string) message.Message {
+	return message.Message{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: text}}}
+}
+
+func call(id, name, input string) message.Message {
+	return message.Message{Role: message.Assistant, Parts: []message.ContentPart{
+		message.ToolCall{ID: id, Name: name, Input: input, Finished: true},
+	}}
+}
+
+func result(id, name, content string) message.Message {
+	return message.Message{Role: message.Tool, Parts: []message.ContentPart{
+		message.ToolResult{ToolCallID: id, Name: name, Content: content},
+	}}
+}
+
+func kinds(r Report) []Kind {
+	var kinds []Kind
+	for _, f := range r.Findings {
+		kinds = append(kinds, f.Kind)
+	}
+	return kinds
+}
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+
+	sess := session.Session{ID: "s1", Title: "Fix the parser", PromptTokens: 1000}
+	view := `{"file_path": "/p/main.go"}`
+	grep := `{"pattern": "TODO"}`
+
+	t.Run("duplicate read", func(t *testing.T) {
+		t.Parallel()
+		r := Analyze(sess, []message.Message{
+			user("fix it"),
+			call("1", tools.ViewToolName, view), result("1", tools.ViewToolName, "package main"),
+			call("2", tools.ViewToolName, view), result("2", tools.ViewToolName, "package main"),
+			call("3", tools.ViewToolName, `{"file_path": "/p/main.go", "offset": 10}`), result("3", tools.ViewToolName, "func main()"),
+		}, Options{})
+		require.Equal(t, []Kind{KindDuplicateRead}, kinds(r))
+		require.Equal(t, "/p/main.go", r.Findings[0].Target)
+		require.Equal(t, 1, r.Findings[0].Count)
+		require.Positive(t, r.Findings[0].ResentTokens)
+		require.Equal(t, r.Findings[0].Tokens, r.WastedTokens)
+		require.Len(t, r.Suggestions, 1)
+	})
+
+	t.Run("read after an edit", func(t *testing.T) {
+		t.Parallel()
+		r := Analyze(sess, []message.Message{
+			call("1", tools.ViewToolName, view), result("1", tools.ViewToolName, "package main"),
+			call("2", tools.EditToolName, view), result("2", tools.EditToolName, "ok"),
+			call("3", tools.ViewToolName, view), result("3", tools.ViewToolName, "package main"),
+		}, Options{})
+		require.Empty(t, r.Findings)
+	})
+
+	t.Run("large output", func(t *testing.T) {
+		t.Parallel()
+		r := Analyze(sess, []message.Message{
+			call("1", tools.BashToolName, `{"command": "go test ./..."}`),
+			result("1", tools.BashToolName, strings.Repeat("ok ", 200)),
+		}, Options{LargeOutputTokens: 10})
+		require.Equal(t, []Kind{KindLargeOutput}, kinds(r))
+		require.Equal(t, "go test ./...", r.Findings[0].Target)
+		require.Equal(t, largeOutputSuggestion(tools.BashToolName), r.Findings[0].Suggestion)
+		require.Equal(t, []ToolUsage{{Name: tools.BashToolName, Calls: 1, Tokens: r.Findings[0].Tokens}}, r.Tools)
+	})
+
+	t.Run("repeated call", func(t *testing.T) {
+		t.Parallel()
+		r := Analyze(sess, []message.Message{
+			call("1", tools.GrepToolName, grep), result("1", tools.GrepToolName, "main.go:1"),
+			call("2", tools.GrepToolName, `{"pattern":"TODO"}`), result("2", tools.GrepToolName, "main.go:1"),
+			call("3", tools.WriteToolName, view), result("3", tools.WriteToolName, "ok"),
+			call("4", tools.GrepToolName, grep), result("4", tools.GrepToolName, "main.go:1"),
+		}, Options{})
+		require.Equal(t, []Kind{KindRepeatedCall}, kinds(r))
+		require.Equal(t, "TODO", r.Findings[0].Target)
+		require.Equal(t, 1, r.Findings[0].Count)
+	})
+
+	t.Run("repeated prompt", func(t *testing.T) {
+		t.Parallel()
+		r := Analyze(sess, []message.Message{
+			user("Fix the tests"),
+			{Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: "done"}}},
+			user("fix   the tests"),
+		}, Options{})
+		require.Equal(t, []Kind{KindRepeatedPrompt}, kinds(r))
+		require.Equal(t, 2, r.Turns)
+		require.Equal(t, 1, r.Requests)
+	})
+
+	t.Run("summary resets", func(t *testing.T) {
+		t.Parallel()
+		r := Analyze(sess, []message.Message{
+			call("1", tools.ViewToolName, view), result("1", tools.ViewToolName, "package main"),
+			{Role: message.Assistant, IsSummaryMessage: true, Parts: []message.ContentPart{message.TextContent{Text: "summary"}}},
+			call("2", tools.ViewToolName, view), result("2", tools.ViewToolName, "package main"),
+		}, Options{})
+		require.Empty(t, r.Findings)
+	})
+
+	t.Run("cache disabled", func(t *testing.T) {
+		t.Parallel()
+		r := Analyze(sess, []message.Message{
+			user("fix it"),
+			call("1", tools.LSToolName, `{}`), result("1", tools.LSToolName, "main.go"),
+			{Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: "done"}}},
+		}, Options{CacheDisabled: true})
+		require.Equal(t, []Kind{KindCacheDisabled}, kinds(r))
+		require.Equal(t, int64(1000), r.Findings[0].Tokens)
+		require.Zero(t, r.WastedTokens)
+	})
+}
+
+func TestReportMarkdown(t *testing.T) {
+	t.Parallel()
+
+	r := Analyze(session.Session{ID: "s1", Title: "Fix the parser"}, []message.Message{
+		call("1", tools.ViewToolName, `{"file_path": "/p/main.go"}`), result("1", tools.ViewToolName, "package main"),
+		call("2", tools.ViewToolName, `{"file_path": "/p/main.go"}`), result("2", tools.ViewToolName, "package main"),
+	}, Options{})
+	md := r.Markdown()
+	require.Contains(t, md, `# Token analysis of "Fix the parser"`)
+	require.Contains(t, md, "| view | 2 |")
+	require.Contains(t, md, "**Duplicate read (view)** `/p/main.go`")
+	require.Contains(t, md, "## Suggestions")
+
+	empty := Analyze(session.Session{ID: "s2"}, nil, Options{}).Markdown()
+	require.Contains(t, empty, "No waste found.")
+}
@@ -0,0 +1,66 @@
+package analyze
+
+import (
+	"fmt"
+	"strings"
+)
+
+// kindTitles are the headings of the kinds of findings.
+var kindTitles = map[Kind]string{
+	KindDuplicateRead:  "Duplicate read",
+	KindLargeOutput:    "Oversized output",
+	KindRepeatedCall:   "Repeated call",
+	KindRepeatedPrompt: "Repeated prompt",
+	KindCacheDisabled:  "Caching disabled",
+}
+
+// Markdown renders the report for reading.
+func (r Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Token analysis of %q\n\n", r.Title)
+	b.WriteString("| | |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Session | %s |\n", r.SessionID)
+	fmt.Fprintf(&b, "| Turns / requests | %d / %d |\n", r.Turns, r.Requests)
+	fmt.Fprintf(&b, "| Tokens in / out | %d / %d |\n", r.PromptTokens, r.CompletionTokens)
+	fmt.Fprintf(&b, "| Cost | $%.4f |\n", r.Cost)
+	fmt.Fprintf(&b, "| Transcript | ~%d tokens |\n", r.ContextTokens)
+	fmt.Fprintf(&b, "| Wasted | ~%d tokens%s |\n", r.WastedTokens, share(r.WastedTokens, r.ContextTokens))
+
+	if len(r.Tools) > 0 {
+		b.WriteString("\n## Tool Outputs\n\n| Tool | Calls | Tokens |\n|---|---:|---:|\n")
+		for _, u := range r.Tools {
+			fmt.Fprintf(&b, "| %s | %d | ~%d |\n", u.Name, u.Calls, u.Tokens)
+		}
+	}
+
+	b.WriteString("\n## Findings\n\n")
+	if len(r.Findings) == 0 {
+		b.WriteString("No waste found.\n")
+		return b.String()
+	}
+	for _, f := range r.Findings {
+		what := kindTitles[f.Kind]
+		if f.Tool != "" {
+			what += " (" + f.Tool + ")"
+		}
+		times := ""
+		if f.Count > 1 {
+			times = fmt.Sprintf(" ×%d", f.Count)
+		}
+		fmt.Fprintf(&b, "- **%s** `%s`%s: ~%d tokens, ~%d re-sent in later requests\n", what, f.Target, times, f.Tokens, f.ResentTokens)
+	}
+
+	b.WriteString("\n## Suggestions\n\n")
+	for _, s := range r.Suggestions {
+		fmt.Fprintf(&b, "- %s\n", s)
+	}
+	return b.String()
+}
+
+// share returns part as a percentage of total, such as " (12%)".
+func share(part, total int64) string {
+	if total <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%.0f%%)", float64(part)*100/float64(total))
+}
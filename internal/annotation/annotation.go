@@ -0,0 +1,91 @@
+// Package annotation turns comments the user leaves on lines of a diff or a
+// file into instructions for the agent that point at the exact line, so the
+// user does not have to describe where in prose.
+package annotation
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Side tells which version of a file a commented line is in.
+type Side string
+
+const (
+	// SideNew is the file as it is now.
+	SideNew Side = "new"
+	// SideOld is the file before the session changed it, for comments on
+	// removed lines.
+	SideOld Side = "old"
+)
+
+// Annotation is a comment on one line of a file.
+type Annotation struct {
+	Path string
+	// Line is the 1-based line number in the version given by Side.
+	Line int
+	Side Side
+	// Code is the text of the line when the comment was made.
+	Code    string
+	Comment string
+}
+
+// Location returns path:line, noting removed lines.
+func (a Annotation) Location() string {
+	if a.Side == SideOld {
+		return fmt.Sprintf("%s:%d (removed)", a.Path, a.Line)
+	}
+	return fmt.Sprintf("%s:%d", a.Path, a.Line)
+}
+
+// Same reports whether a and b are on the same line.
+func (a Annotation) Same(b Annotation) bool {
+	return a.Path == b.Path && a.Line == b.Line && a.Side == b.Side
+}
+
+// Set adds an annotation to a list, replacing the one on the same line. An
+// empty comment removes it instead.
+func Set(annotations []Annotation, a Annotation) []Annotation {
+	annotations = slices.DeleteFunc(slices.Clone(annotations), a.Same)
+	if strings.TrimSpace(a.Comment) == "" {
+		return annotations
+	}
+	return append(annotations, a)
+}
+
+// AppendToPrompt adds the annotations to a prompt, ordered by file and line,
+// with the commented code so the agent can find the line even after it
+// moved.
+func AppendToPrompt(prompt string, annotations []Annotation) string {
+	if len(annotations) == 0 {
+		return prompt
+	}
+	sorted := slices.Clone(annotations)
+	slices.SortStableFunc(sorted, func(a, b Annotation) int {
+		return cmp.Or(
+			cmp.Compare(a.Path, b.Path),
+			cmp.Compare(a.Line, b.Line),
+			cmp.Compare(a.Side, b.Side),
+		)
+	})
+
+	var sb strings.Builder
+	if prompt != "" {
+		sb.WriteString(prompt)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("<system_info>The user commented on the lines below. Apply each comment at the line it points to; removed lines refer to the file before this session changed it.</system_info>\n")
+	for _, a := range sorted {
+		if a.Side == SideOld {
+			fmt.Fprintf(&sb, "<comment path='%s' line='%d' version='before'>\n", a.Path, a.Line)
+		} else {
+			fmt.Fprintf(&sb, "<comment path='%s' line='%d'>\n", a.Path, a.Line)
+		}
+		fmt.Fprintf(&sb, "<code>%s</code>\n", a.Code)
+		sb.WriteString(strings.TrimSpace(a.Comment))
+		sb.WriteString("\n</comment>\n")
+	}
+	return sb.String()
+}
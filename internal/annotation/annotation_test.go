@@ -0,0 +1,63 @@
+package annotation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	a := Annotation{Path: "main.go", Line: 3, Side: SideNew, Code: "x := 1", Comment: "rename x"}
+	list := Set(nil, a)
+	require.Len(t, list, 1)
+
+	// The same line on the other side is a different line.
+	list = Set(list, Annotation{Path: "main.go", Line: 3, Side: SideOld, Comment: "keep this"})
+	require.Len(t, list, 2)
+
+	a.Comment = "use a constant"
+	list = Set(list, a)
+	require.Len(t, list, 2)
+	require.Equal(t, "use a constant", list[1].Comment)
+
+	a.Comment = " "
+	list = Set(list, a)
+	require.Len(t, list, 1)
+	require.Equal(t, SideOld, list[0].Side)
+}
+
+func TestAppendToPrompt(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "fix it", AppendToPrompt("fix it", nil))
+
+	got := AppendToPrompt("fix it", []Annotation{
+		{Path: "b.go", Line: 1, Side: SideNew, Code: "package b", Comment: "rename"},
+		{Path: "a.go", Line: 12, Side: SideNew, Code: "if n > 0 {", Comment: " change this condition\n"},
+		{Path: "a.go", Line: 4, Side: SideOld, Code: "return nil", Comment: "bring this back"},
+	})
+	require.Equal(t, `fix it
+<system_info>The user commented on the lines below. Apply each comment at the line it points to; removed lines refer to the file before this session changed it.</system_info>
+<comment path='a.go' line='4' version='before'>
+<code>return nil</code>
+bring this back
+</comment>
+<comment path='a.go' line='12'>
+<code>if n > 0 {</code>
+change this condition
+</comment>
+<comment path='b.go' line='1'>
+<code>package b</code>
+rename
+</comment>
+`, got)
+}
+
+func TestLocation(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "a.go:4", Annotation{Path: "a.go", Line: 4, Side: SideNew}.Location())
+	require.Equal(t, "a.go:4 (removed)", Annotation{Path: "a.go", Line: 4, Side: SideOld}.Location())
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"context"
+
+	"github.com/charmbracelet/crush/internal/acp"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+var _ acp.Backend = (*App)(nil)
+
+// GetSession returns the session with the given ID.
+func (app *App) GetSession(ctx context.Context, id string) (session.Session, error) {
+	return app.Sessions.Get(ctx, id)
+}
+
+// ListMessages returns the messages of the session, oldest first.
+func (app *App) ListMessages(ctx context.Context, sessionID string) ([]message.Message, error) {
+	return app.Messages.List(ctx, sessionID)
+}
+
+// SubscribePermissions returns the events of the permission requests of
+// every session.
+func (app *App) SubscribePermissions(ctx context.Context) <-chan pubsub.Event[permission.PermissionRequest] {
+	return app.Permissions.Subscribe(ctx)
+}
+
+// GrantPermission allows the request, and identical requests for the rest
+// of the session when always is set.
+func (app *App) GrantPermission(req permission.PermissionRequest, always bool) {
+	if always {
+		app.Permissions.GrantPersistent(req)
+		return
+	}
+	app.Permissions.Grant(req)
+}
+
+// DenyPermission denies the request.
+func (app *App) DenyPermission(req permission.PermissionRequest) {
+	app.Permissions.Deny(req)
+}
@@ -0,0 +1,13 @@
+package app
+
+import (
+	"context"
+
+	"github.com/charmbracelet/crush/internal/analyze"
+)
+
+// AnalyzeSession reports where the context tokens of a session went, with
+// suggestions to spend fewer of them.
+func (app *App) AnalyzeSession(ctx context.Context, sessionID string, opts analyze.Options) (analyze.Report, error) {
+	return analyze.Session(ctx, app.Sessions, app.Messages, sessionID, opts)
+}
@@ -10,6 +10,8 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -19,24 +21,43 @@ import (
 	"charm.land/fantasy"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/agent/prompt"
+	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/agentstatus"
+	"github.com/charmbracelet/crush/internal/artifact"
+	"github.com/charmbracelet/crush/internal/audit"
+	"github.com/charmbracelet/crush/internal/backup"
+	"github.com/charmbracelet/crush/internal/codeowners"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/connectivity"
+	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/editor"
 	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/filecache"
 	"github.com/charmbracelet/crush/internal/filetracker"
 	"github.com/charmbracelet/crush/internal/format"
+	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/log"
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/recall"
+	"github.com/charmbracelet/crush/internal/redact"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/staging"
+	"github.com/charmbracelet/crush/internal/telemetry"
+	"github.com/charmbracelet/crush/internal/toolstats"
 	"github.com/charmbracelet/crush/internal/ui/anim"
 	"github.com/charmbracelet/crush/internal/ui/styles"
 	"github.com/charmbracelet/crush/internal/update"
 	"github.com/charmbracelet/crush/internal/version"
+	"github.com/charmbracelet/crush/internal/workspace"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/charmbracelet/x/exp/charmtone"
 	"github.com/charmbracelet/x/term"
@@ -59,6 +80,11 @@ type App struct {
 	AgentCoordinator agent.Coordinator
 
 	LSPManager *lsp.Manager
+	CodeOwners *codeowners.Checker
+	AuditLog   *audit.Log
+	// Workspace limits the agent to the roots of crush.workspace.json. It
+	// is nil when the working directory has no manifest.
+	Workspace *workspace.Manifest
 
 	config *config.Config
 
@@ -70,27 +96,85 @@ type App struct {
 	// global context and cleanup functions
 	globalCtx    context.Context
 	cleanupFuncs []func(context.Context) error
+
+	jobs      *jobQueue
+	mode      *csync.Value[tools.Mode]
+	staged    *staging.Queue
+	artifacts *artifact.Store
+	toolStats *toolstats.Recorder
+	// fileCache is shared by the agent and its sub-agents.
+	fileCache *filecache.Cache
+	// recall stores the turns compaction drops.
+	recall *recall.Store
+	// initialSession is the session the TUI opens on start.
+	initialSession *csync.Value[string]
+	// editor serves editor plugins, when enabled.
+	editor *editor.Server
+	// statusAgent is the coordinator crush status reports on, tracked from
+	// the first time the coder agent is initialized.
+	statusAgent *csync.Value[agent.Coordinator]
 }
 
 // New initializes a new application instance.
 func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
-	q := db.New(conn)
-	sessions := session.NewService(q, conn)
-	messages := message.NewService(q)
-	files := history.NewService(q, conn)
-	skipPermissionsRequests := cfg.Permissions != nil && cfg.Permissions.SkipRequests
+	// Refuse to start rather than run unrestricted when the manifest is
+	// broken.
+	manifest, err := workspace.Load(cfg.WorkingDir())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workspace manifest: %w", err)
+	}
+	if manifest != nil {
+		slog.Info("Loaded workspace manifest", "path", manifest.Path, "roots", len(manifest.Roots()))
+	}
+
 	var allowedTools []string
-	if cfg.Permissions != nil && cfg.Permissions.AllowedTools != nil {
+	var rules map[string]string
+	if cfg.Permissions != nil {
 		allowedTools = cfg.Permissions.AllowedTools
+		rules = cfg.Permissions.Rules
+	}
+	policy, err := permission.ParsePolicy(rules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid permissions.rules: %w", err)
+	}
+
+	shutdownTelemetry, err := telemetry.Setup(ctx, cfg)
+	if err != nil {
+		slog.Error("Failed to set up telemetry", "error", err)
+	}
+
+	if r := redact.New(cfg); r != nil {
+		log.SetRedaction(r.Redact)
+	}
+	diff.SetAlgorithm(diff.Algorithm(cfg.Options.DiffAlgorithm))
+
+	auditLog, err := audit.Open(cfg)
+	if err != nil {
+		slog.Error("Failed to open audit log", "error", err)
 	}
 
+	var deniedPaths []string
+	if cfg.Permissions != nil {
+		deniedPaths = cfg.Permissions.DeniedPaths
+	}
+	fsext.SetDeniedPaths(cfg.WorkingDir(), deniedPaths)
+
+	q := db.New(telemetry.WrapDB(conn))
+	sessions := session.NewService(q, conn, cfg.WorkingDir())
+	messages := message.NewService(q)
+	files := history.NewService(q, conn)
+	skipPermissionsRequests := cfg.Permissions != nil && cfg.Permissions.SkipRequests
+
 	app := &App{
 		Sessions:    sessions,
 		Messages:    messages,
 		History:     files,
-		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools),
+		Permissions: auditLog.WrapPermissions(permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools, policy, filepath.Join(cfg.Options.DataDirectory, permission.GrantsFilename))),
 		FileTracker: filetracker.NewService(q),
 		LSPManager:  lsp.NewManager(cfg),
+		CodeOwners:  codeowners.New(cfg),
+		AuditLog:    auditLog,
+		Workspace:   manifest,
 
 		globalCtx: ctx,
 
@@ -99,7 +183,24 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 		events:          make(chan tea.Msg, 100),
 		serviceEventsWG: &sync.WaitGroup{},
 		tuiWG:           &sync.WaitGroup{},
+		mode:            csync.NewValue(tools.ModeBuild),
+		staged:          staging.New(files),
+		artifacts:       artifact.New(filepath.Join(cfg.Options.DataDirectory, artifactsDir)),
+		toolStats:       toolstats.New(),
+		fileCache:       filecache.New(filecache.DefaultMaxBytes),
+		recall:          recall.New(q, messages),
+		initialSession:  csync.NewValue(""),
+	}
+
+	transcripts, err := backup.Open(cfg, sessions)
+	if err != nil {
+		slog.Error("Failed to open transcript backup", "error", err)
 	}
+	if transcripts != nil {
+		go transcripts.Run(ctx, messages.Subscribe(ctx))
+	}
+
+	app.jobs = newJobQueue(app)
 
 	app.setupEvents()
 
@@ -113,6 +214,10 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 		app.cleanupFuncs,
 		func(context.Context) error { return conn.Close() },
 		mcp.Close,
+		shutdownTelemetry,
+		func(context.Context) error { return auditLog.Close() },
+		func(context.Context) error { return app.fileCache.Close() },
+		func(context.Context) error { return app.FileTracker.Close() },
 	)
 
 	// TODO: remove the concept of agent config, most likely.
@@ -123,6 +228,9 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	if err := app.InitCoderAgent(ctx); err != nil {
 		return nil, fmt.Errorf("failed to initialize coder agent: %w", err)
 	}
+	if err := app.startEditorServer(ctx); err != nil {
+		slog.Error("Failed to start editor socket", "error", err)
+	}
 
 	// Set up callback for LSP state updates.
 	app.LSPManager.SetCallback(func(name string, client *lsp.Client) {
@@ -143,6 +251,206 @@ func (app *App) Config() *config.Config {
 	return app.config
 }
 
+// ContextFiles returns the context files that are merged into the system
+// prompt, ordered from the least to the most specific.
+func (app *App) ContextFiles() []prompt.ContextFile {
+	return prompt.LoadContextFiles(*app.config)
+}
+
+// ContextUsage returns how much of the current model's context window the
+// next request in the session is expected to use. Add the draft prompt with
+// [agent.ContextUsage.WithDraft] to track it live as the user types.
+func (app *App) ContextUsage(ctx context.Context, sessionID string) (agent.ContextUsage, error) {
+	if app.AgentCoordinator == nil {
+		return agent.ContextUsage{}, errors.New("agent not initialized")
+	}
+	return app.AgentCoordinator.ContextUsage(ctx, sessionID)
+}
+
+// Mode returns whether the agent may change the workspace.
+func (app *App) Mode() tools.Mode {
+	return app.mode.Get()
+}
+
+// ToolStats returns the execution statistics of every tool called in a
+// session, or across all sessions of the app when sessionID is empty.
+func (app *App) ToolStats(sessionID string) []toolstats.Stats {
+	return app.toolStats.Stats(sessionID)
+}
+
+// SetMode switches between build, plan and review mode. In plan mode edits
+// are returned as proposed diffs and only read-only commands run. In review
+// mode edits are staged until applied with [App.ApplyEdit]. The switch
+// applies to the next tool call, including in runs already in progress.
+func (app *App) SetMode(mode tools.Mode) error {
+	switch mode {
+	case tools.ModeBuild, tools.ModePlan, tools.ModeReview:
+	default:
+		return fmt.Errorf("unknown mode %q", mode)
+	}
+	app.mode.Set(mode)
+	if app.AgentCoordinator != nil {
+		app.AgentCoordinator.SetMode(mode)
+	}
+	return nil
+}
+
+// SetChatOnly turns chat-only mode on or off for a session. Chat-only
+// sessions run without filesystem, shell and MCP tools, keeping only the
+// web tools. It applies from the next prompt sent to the session.
+func (app *App) SetChatOnly(ctx context.Context, sessionID string, chatOnly bool) error {
+	_, err := app.Sessions.SetChatOnly(ctx, sessionID, chatOnly)
+	return err
+}
+
+// SetTranslate turns translation on or off for a session. Prompts written
+// in another language are then translated to English by the small model
+// before the large one reads them, and its replies translated back.
+func (app *App) SetTranslate(ctx context.Context, sessionID string, translate bool) error {
+	_, err := app.Sessions.SetTranslate(ctx, sessionID, translate)
+	return err
+}
+
+// RenameSession sets the title of a session, on one line. A title generated
+// for the first exchange does not replace it.
+func (app *App) RenameSession(ctx context.Context, sessionID, title string) error {
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		return errors.New("session title is empty")
+	}
+	_, err := app.Sessions.Rename(ctx, sessionID, title)
+	return err
+}
+
+// TagSession replaces the tags of a session. Tags are lower-cased and
+// split on spaces and commas; passing none clears them.
+func (app *App) TagSession(ctx context.Context, sessionID string, tags ...string) error {
+	_, err := app.Sessions.SetTags(ctx, sessionID, tags)
+	return err
+}
+
+// PinSession pins a session to the top of the session list, or unpins it.
+func (app *App) PinSession(ctx context.Context, sessionID string, pinned bool) error {
+	_, err := app.Sessions.SetPinned(ctx, sessionID, pinned)
+	return err
+}
+
+// LabelSession sets the label, such as a ticket ID, the next turns of a
+// session are counted under; an empty label stops labeling them.
+func (app *App) LabelSession(ctx context.Context, sessionID, label string) error {
+	return app.Sessions.SetLabel(ctx, sessionID, label)
+}
+
+// UsageByLabel returns the tokens and cost of all turns grouped by the label
+// they were sent under.
+func (app *App) UsageByLabel(ctx context.Context) ([]session.LabelUsage, error) {
+	return app.Sessions.UsageByLabel(ctx)
+}
+
+// ErrNoSessions is returned by [App.ResumeLastSession] when the working
+// directory has no sessions yet.
+var ErrNoSessions = errors.New("no sessions to resume in this directory")
+
+// ResumeLastSession finds the most recently updated session of the working
+// directory and has the TUI open it when it starts. Sessions created before
+// their directory was recorded count as the working directory's, since they
+// live in its data directory.
+func (app *App) ResumeLastSession(ctx context.Context) (session.Session, error) {
+	sessions, err := app.Sessions.List(ctx)
+	if err != nil {
+		return session.Session{}, err
+	}
+	var last *session.Session
+	for i, s := range sessions {
+		if s.Project != "" && s.Project != app.config.WorkingDir() {
+			continue
+		}
+		// The list puts pinned sessions first, so compare the times.
+		if last == nil || s.UpdatedAt > last.UpdatedAt {
+			last = &sessions[i]
+		}
+	}
+	if last == nil {
+		return session.Session{}, ErrNoSessions
+	}
+	app.initialSession.Set(last.ID)
+	return *last, nil
+}
+
+// InitialSessionID returns the session the TUI opens when it starts, set by
+// [App.ResumeLastSession]. It is empty to start on a new session.
+func (app *App) InitialSessionID() string {
+	return app.initialSession.Get()
+}
+
+// ListSessions returns the top-level sessions that pass filter, pinned
+// sessions first, then the most recently updated.
+func (app *App) ListSessions(ctx context.Context, filter session.Filter) ([]session.Session, error) {
+	sessions, err := app.Sessions.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return filter.Apply(sessions), nil
+}
+
+// SendMessage sends a prompt with optional attachments to the coder agent in
+// the given session and waits for the response. Images are downscaled for
+// the current provider; attachments the model cannot take are dropped.
+func (app *App) SendMessage(ctx context.Context, sessionID, prompt string, attachments []message.Attachment) (*fantasy.AgentResult, error) {
+	if app.AgentCoordinator == nil {
+		return nil, errors.New("agent not initialized")
+	}
+	return app.AgentCoordinator.Run(ctx, sessionID, prompt, attachments...)
+}
+
+// QueueMessage sends prompt to the session without waiting for the answer.
+// While the agent is working in the session the prompt waits in its queue,
+// and options.queued_prompts decides when the agent gets it; otherwise it is
+// answered at once.
+func (app *App) QueueMessage(sessionID, prompt string, attachments []message.Attachment) error {
+	if app.AgentCoordinator == nil {
+		return errors.New("agent not initialized")
+	}
+	if strings.TrimSpace(prompt) == "" && !message.ContainsTextAttachment(attachments) {
+		return agent.ErrEmptyPrompt
+	}
+	if app.AgentCoordinator.IsSessionBusy(sessionID) {
+		// Busy sessions queue the prompt and return right away.
+		_, err := app.AgentCoordinator.Run(app.globalCtx, sessionID, prompt, attachments...)
+		return err
+	}
+	go func() {
+		_, err := app.AgentCoordinator.Run(app.globalCtx, sessionID, prompt, attachments...)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			slog.Error("Failed to run queued message", "session_id", sessionID, "error", err)
+		}
+	}()
+	return nil
+}
+
+// ErrNotBusy is returned when interrupting a session the agent is not working
+// in.
+var ErrNotBusy = errors.New("the agent is not working in this session")
+
+// Interrupt stops the answer or the tool the agent is running in the session
+// and has it continue with note on the next turn, keeping the partial answer
+// and the results of the tools that finished. The new turn runs in place of
+// the stopped one, so whoever waits for the answer gets the continued one.
+func (app *App) Interrupt(sessionID, note string) error {
+	if app.AgentCoordinator == nil {
+		return errors.New("agent not initialized")
+	}
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return errors.New("note is empty")
+	}
+	if !app.AgentCoordinator.IsSessionBusy(sessionID) {
+		return ErrNotBusy
+	}
+	_, err := app.AgentCoordinator.Interrupt(app.globalCtx, sessionID, note)
+	return err
+}
+
 // RunNonInteractive runs the application in non-interactive mode with the
 // given prompt, printing to stdout.
 func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt, largeModel, smallModel string, hideSpinner bool) error {
@@ -213,18 +521,7 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 
 	defer stopSpinner()
 
-	const maxPromptLengthForTitle = 100
-	const titlePrefix = "Non-interactive: "
-	var titleSuffix string
-
-	if len(prompt) > maxPromptLengthForTitle {
-		titleSuffix = prompt[:maxPromptLengthForTitle] + "..."
-	} else {
-		titleSuffix = prompt
-	}
-	title := titlePrefix + titleSuffix
-
-	sess, err := app.Sessions.Create(ctx, title)
+	sess, err := app.Sessions.Create(ctx, nonInteractiveTitle(prompt))
 	if err != nil {
 		return fmt.Errorf("failed to create session for non-interactive mode: %w", err)
 	}
@@ -319,6 +616,21 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 	}
 }
 
+// nonInteractiveTitle returns the title of the session of a
+// non-interactive run of prompt.
+func nonInteractiveTitle(prompt string) string {
+	const maxPromptLengthForTitle = 100
+	const titlePrefix = "Non-interactive: "
+	var titleSuffix string
+
+	if len(prompt) > maxPromptLengthForTitle {
+		titleSuffix = prompt[:maxPromptLengthForTitle] + "..."
+	} else {
+		titleSuffix = prompt
+	}
+	return titlePrefix + titleSuffix
+}
+
 func (app *App) UpdateAgentModel(ctx context.Context) error {
 	if app.AgentCoordinator == nil {
 		return fmt.Errorf("agent configuration is missing")
@@ -326,6 +638,35 @@ func (app *App) UpdateAgentModel(ctx context.Context) error {
 	return app.AgentCoordinator.UpdateModels(ctx)
 }
 
+// ErrModelNotFound is returned by [App.SetModel] for a model no configured
+// provider has.
+var ErrModelNotFound = errors.New("model not found")
+
+// SetModel selects the model of a provider for role, the large or small
+// model or one of the [config.ModelRoles], and rebuilds the agent with it.
+// The choice is saved in the config.
+func (app *App) SetModel(ctx context.Context, role config.SelectedModelType, providerID, modelID string) error {
+	if app.AgentCoordinator == nil {
+		return fmt.Errorf("agent configuration is missing")
+	}
+	if role != config.SelectedModelTypeLarge && role != config.SelectedModelTypeSmall && !slices.Contains(config.ModelRoles, role) {
+		return fmt.Errorf("unknown model role %q", role)
+	}
+	model := app.config.GetModel(providerID, modelID)
+	if model == nil {
+		return fmt.Errorf("%s/%s: %w", providerID, modelID, ErrModelNotFound)
+	}
+	selected := config.SelectedModel{
+		Provider:  providerID,
+		Model:     modelID,
+		MaxTokens: model.DefaultMaxTokens,
+	}
+	if err := app.config.UpdatePreferredModel(role, selected); err != nil {
+		return err
+	}
+	return app.AgentCoordinator.UpdateModels(ctx)
+}
+
 // overrideModelsForNonInteractive parses the model strings and temporarily
 // overrides the model configurations, then rebuilds the agent.
 // Format: "model-name" (searches all providers) or "provider/model-name".
@@ -424,8 +765,13 @@ func (app *App) setupEvents() {
 	setupSubscriber(ctx, app.serviceEventsWG, "permissions", app.Permissions.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "permissions-notifications", app.Permissions.SubscribeNotifications, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "history", app.History.Subscribe, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "staging", app.staged.Subscribe, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "artifacts", app.artifacts.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "mcp", mcp.SubscribeEvents, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "lsp", SubscribeLSPEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "compactions", agent.SubscribeCompactions, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "connectivity", connectivity.Subscribe, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "tool-stats", app.toolStats.Subscribe, app.events)
 	cleanupFunc := func(context.Context) error {
 		cancel()
 		app.serviceEventsWG.Wait()
@@ -434,6 +780,33 @@ func (app *App) setupEvents() {
 	app.cleanupFuncs = append(app.cleanupFuncs, cleanupFunc)
 }
 
+// trackAgentStatus keeps the status file of coordinator, for status bars
+// to poll with crush status, until shutdown removes it. Coordinators made
+// later replace it in the same file.
+func (app *App) trackAgentStatus(coordinator agent.Coordinator) {
+	if app.statusAgent != nil {
+		app.statusAgent.Set(coordinator)
+		return
+	}
+	statusAgent := csync.NewValue(coordinator)
+	app.statusAgent = statusAgent
+
+	ctx, cancel := context.WithCancel(app.globalCtx)
+	tracker := agentstatus.NewTracker(agentstatus.Path(app.config.Options.DataDirectory), func() bool {
+		return statusAgent.Get().IsBusy()
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tracker.Run(ctx, app.Messages.Subscribe(ctx), app.Permissions.Subscribe(ctx), app.Permissions.SubscribeNotifications(ctx))
+	}()
+	app.cleanupFuncs = append(app.cleanupFuncs, func(context.Context) error {
+		cancel()
+		<-done
+		return nil
+	})
+}
+
 const subscriberSendTimeout = 2 * time.Second
 
 func setupSubscriber[T any](
@@ -496,11 +869,20 @@ func (app *App) InitCoderAgent(ctx context.Context) error {
 		app.History,
 		app.FileTracker,
 		app.LSPManager,
+		app.AuditLog,
+		app.toolStats,
+		app.staged,
+		app.Workspace,
+		app.fileCache,
+		app.artifacts,
+		app.recall,
 	)
 	if err != nil {
 		slog.Error("Failed to create coder agent", "err", err)
 		return err
 	}
+	app.AgentCoordinator.SetMode(app.Mode())
+	app.trackAgentStatus(app.AgentCoordinator)
 	return nil
 }
 
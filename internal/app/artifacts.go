@@ -0,0 +1,22 @@
+package app
+
+import (
+	"github.com/charmbracelet/crush/internal/artifact"
+)
+
+// artifactsDir is the directory of the data directory artifacts are kept
+// in, one directory per session.
+const artifactsDir = "artifacts"
+
+// Artifacts returns the files the agent generated in a session with the
+// emit_artifact tool, most recent first.
+func (app *App) Artifacts(sessionID string) ([]artifact.Artifact, error) {
+	return app.artifacts.List(sessionID)
+}
+
+// ExportArtifact copies an artifact to the working directory and returns
+// where it was copied. It fails with [artifact.ErrExists] rather than
+// overwrite a file.
+func (app *App) ExportArtifact(a artifact.Artifact) (string, error) {
+	return app.artifacts.Export(a, app.config.WorkingDir())
+}
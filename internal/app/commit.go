@@ -0,0 +1,72 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrNothingStaged is returned when there are no staged changes to commit.
+var ErrNothingStaged = errors.New("nothing staged: stage changes with git add first")
+
+// recentCommits is how many commit subjects the model sees for the style of
+// the project.
+const recentCommits = 10
+
+// GenerateCommitMessage returns a Conventional Commits message for the
+// changes staged in the working directory, written by the small model.
+func (app *App) GenerateCommitMessage(ctx context.Context) (string, error) {
+	if app.AgentCoordinator == nil {
+		return "", errors.New("agent not initialized")
+	}
+	dir := app.config.WorkingDir()
+	diff, err := git(ctx, dir, nil, "diff", "--cached", "--no-color", "--no-ext-diff")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return "", ErrNothingStaged
+	}
+	// A repository without commits has no log.
+	recent, _ := git(ctx, dir, nil, "log", "--no-color", "--format=%s", fmt.Sprintf("-%d", recentCommits))
+	return app.AgentCoordinator.GenerateCommitMessage(ctx, diff, strings.TrimSpace(recent))
+}
+
+// Commit commits the staged changes with message and returns the summary
+// git prints, such as "[main 1a2b3c4] fix: keep the cursor".
+func (app *App) Commit(ctx context.Context, message string) (string, error) {
+	if strings.TrimSpace(message) == "" {
+		return "", errors.New("empty commit message")
+	}
+	out, err := git(ctx, app.config.WorkingDir(), strings.NewReader(message), "commit", "--file", "-")
+	if err != nil {
+		return "", err
+	}
+	summary, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	return summary, nil
+}
+
+// git runs git in dir with stdin and returns its output.
+func git(ctx context.Context, dir string, stdin *strings.Reader, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		if msg := strings.TrimSpace(stdout.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
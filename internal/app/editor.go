@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/charmbracelet/crush/internal/editor"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/staging"
+)
+
+var _ editor.Backend = (*App)(nil)
+
+// CreateSession creates an empty top-level session.
+func (app *App) CreateSession(ctx context.Context, title string) (session.Session, error) {
+	return app.Sessions.Create(ctx, title)
+}
+
+// CancelSession stops the agent working in the session, if it is.
+func (app *App) CancelSession(sessionID string) {
+	if app.AgentCoordinator != nil {
+		app.AgentCoordinator.Cancel(sessionID)
+	}
+}
+
+// SubscribeMessages returns the events of the messages of every session.
+func (app *App) SubscribeMessages(ctx context.Context) <-chan pubsub.Event[message.Message] {
+	return app.Messages.Subscribe(ctx)
+}
+
+// SubscribeEdits returns the events of the edits staged in review mode.
+func (app *App) SubscribeEdits(ctx context.Context) <-chan pubsub.Event[staging.Edit] {
+	return app.staged.Subscribe(ctx)
+}
+
+// EditorSocket returns the path of the socket editor plugins connect to,
+// or nothing when the editor socket is disabled or failed to start.
+func (app *App) EditorSocket() string {
+	if app.editor == nil {
+		return ""
+	}
+	return app.editor.Path()
+}
+
+// startEditorServer serves editor plugins on the socket configured in
+// editor, when enabled.
+func (app *App) startEditorServer(ctx context.Context) error {
+	cfg := app.config.Editor
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+	path := home.Long(cfg.Socket)
+	if path == "" {
+		path = editor.SocketPath(app.config.Options.DataDirectory)
+	}
+	server, err := editor.Listen(ctx, path, app.config.WorkingDir(), app)
+	if err != nil {
+		return err
+	}
+	app.editor = server
+	app.cleanupFuncs = append(app.cleanupFuncs, func(context.Context) error {
+		if err := server.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			return err
+		}
+		return nil
+	})
+	return nil
+}
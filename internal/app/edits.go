@@ -0,0 +1,43 @@
+package app
+
+import (
+	"context"
+
+	"github.com/charmbracelet/crush/internal/staging"
+)
+
+// PendingEdits returns the edits staged in review mode that have not been
+// applied or discarded yet, oldest first.
+func (app *App) PendingEdits() []staging.Edit {
+	return app.staged.Pending()
+}
+
+// ApplyEdit writes a staged edit to disk. It fails with
+// [staging.ErrConflict] when the file changed after the edit was staged.
+func (app *App) ApplyEdit(ctx context.Context, id string) error {
+	edit, err := app.staged.Apply(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Let language servers know, as the edit tools do after a write.
+	if app.LSPManager != nil {
+		for client := range app.LSPManager.Clients().Seq() {
+			if client.HandlesFile(edit.Path) {
+				_ = client.OpenFileOnDemand(ctx, edit.Path)
+				_ = client.NotifyChange(ctx, edit.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// UpdateEdit replaces the content a staged edit writes.
+func (app *App) UpdateEdit(id, content string) error {
+	_, err := app.staged.Update(id, content)
+	return err
+}
+
+// DiscardEdit drops a staged edit without applying it.
+func (app *App) DiscardEdit(id string) error {
+	return app.staged.Discard(id)
+}
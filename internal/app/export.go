@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/crush/internal/export"
+)
+
+// ExportTranscript renders the conversation of the session as a document in
+// format.
+func (app *App) ExportTranscript(ctx context.Context, sessionID string, format export.Format) ([]byte, error) {
+	sess, err := app.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return export.Render(format, sess, messages)
+}
+
+// ExportSession writes the transcript of the session in format to the
+// working directory, in a file named after the session, and returns its
+// path. An existing file is never overwritten.
+func (app *App) ExportSession(ctx context.Context, sessionID string, format export.Format) (string, error) {
+	data, err := app.ExportTranscript(ctx, sessionID, format)
+	if err != nil {
+		return "", err
+	}
+	sess, err := app.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	base := filepath.Join(app.config.WorkingDir(), exportName(sess.Title))
+	for i := 1; ; i++ {
+		path := base + format.Ext()
+		if i > 1 {
+			path = fmt.Sprintf("%s-%d%s", base, i, format.Ext())
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create file: %w", err)
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close() //nolint:errcheck
+			return "", fmt.Errorf("failed to export session: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return "", fmt.Errorf("failed to export session: %w", err)
+		}
+		return path, nil
+	}
+}
+
+// exportName returns the file name, without extension, a session titled
+// title is exported to.
+func exportName(title string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			dash = false
+		} else if !dash && sb.Len() > 0 {
+			sb.WriteRune('-')
+			dash = true
+		}
+		if sb.Len() >= 60 {
+			break
+		}
+	}
+	name := strings.TrimSuffix(sb.String(), "-")
+	if name == "" {
+		name = "session"
+	}
+	return "crush-" + name
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/filepathext"
+)
+
+// Initialization is the result of [App.InitializeProject].
+type Initialization struct {
+	// SessionID is the session the agent analyzed the project in.
+	SessionID string `json:"session_id"`
+	// Path is the absolute path of the context file.
+	Path string `json:"path"`
+	// Created is set when the file did not exist before, and unset when the
+	// agent improved an existing one.
+	Created bool `json:"created"`
+	// Response is the last answer of the agent.
+	Response string `json:"response"`
+}
+
+// ContextFile returns the absolute path of the context file that
+// [App.InitializeProject] writes in the project of cfg.
+func ContextFile(cfg *config.Config) string {
+	return filepathext.SmartJoin(cfg.WorkingDir(), cfg.Options.InitializeAs)
+}
+
+// InitializeProject has the coder agent analyze the project, its build
+// system, test commands and conventions, and write what it found to the
+// context file named by options.initialize_as, as the TUI does the first
+// time it opens a project. The agent runs in a new session whose permission
+// requests are approved, since it only reads the project and writes that
+// file. The project is marked as initialized once the file is written.
+func (app *App) InitializeProject(ctx context.Context) (Initialization, error) {
+	if app.AgentCoordinator == nil {
+		return Initialization{}, errors.New("agent not initialized")
+	}
+	cfg := app.config
+	path := ContextFile(cfg)
+	_, statErr := os.Stat(path)
+
+	prompt, err := agent.InitializePrompt(*cfg)
+	if err != nil {
+		return Initialization{}, err
+	}
+
+	// Wait for MCP initialization to complete before reading MCP tools.
+	if err := mcp.WaitForInit(ctx); err != nil {
+		return Initialization{}, fmt.Errorf("failed to wait for MCP initialization: %w", err)
+	}
+	app.AgentCoordinator.UpdateModels(ctx)
+
+	sess, err := app.Sessions.Create(ctx, "Initialize project")
+	if err != nil {
+		return Initialization{}, fmt.Errorf("failed to create session: %w", err)
+	}
+	slog.Info("Initializing project", "session_id", sess.ID, "path", path)
+	app.Permissions.AutoApproveSession(sess.ID)
+
+	result, err := app.AgentCoordinator.Run(ctx, sess.ID, prompt)
+	if err != nil {
+		return Initialization{}, fmt.Errorf("agent processing failed: %w", err)
+	}
+	initialization := Initialization{
+		SessionID: sess.ID,
+		Path:      path,
+		Created:   statErr != nil,
+	}
+	if result != nil {
+		initialization.Response = result.Response.Content.Text()
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		// The agent stops without writing anything in an empty project.
+		return initialization, fmt.Errorf("the agent did not write %s: %s", cfg.Options.InitializeAs, initialization.Response)
+	}
+	if err := config.MarkProjectInitialized(cfg); err != nil {
+		return initialization, err
+	}
+	return initialization, nil
+}
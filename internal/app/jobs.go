@@ -0,0 +1,384 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/google/uuid"
+)
+
+const (
+	jobQueueSize   = 100
+	webhookTimeout = 10 * time.Second
+)
+
+// ErrJobNotFound is returned for unknown job IDs.
+var ErrJobNotFound = errors.New("job not found")
+
+// JobStatus is the state of a submitted job.
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCanceled  JobStatus = "canceled"
+)
+
+// Done reports whether the job has finished, successfully or not.
+func (s JobStatus) Done() bool {
+	return s == JobSucceeded || s == JobFailed || s == JobCanceled
+}
+
+// JobSpec describes a task to run in the background.
+type JobSpec struct {
+	// Prompt is sent to the coder agent.
+	Prompt string
+	// Attachments are sent along with the prompt.
+	Attachments []message.Attachment
+	// SessionID continues an existing session. A new one is created when
+	// the job starts if empty.
+	SessionID string
+	// AutoApprove approves every tool call in an existing SessionID, bash
+	// included, for the rest of the process. Sessions the job creates are
+	// always auto-approved, as nobody is around to answer their prompts.
+	AutoApprove bool
+	// Webhook receives a POST with the finished [Job] as JSON.
+	Webhook string
+	// Timeout bounds the run. Zero means no limit.
+	Timeout time.Duration
+}
+
+// Job is a snapshot of a submitted job. Its SessionID is empty until a job
+// without [JobSpec.SessionID] starts.
+type Job struct {
+	ID         string    `json:"id"`
+	SessionID  string    `json:"session_id"`
+	Status     JobStatus `json:"status"`
+	Prompt     string    `json:"prompt"`
+	Response   string    `json:"response,omitempty"`
+	Patch      string    `json:"patch,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	StartedAt  time.Time `json:"started_at,omitzero"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+}
+
+type queuedJob struct {
+	spec   JobSpec
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// jobQueue runs submitted jobs one at a time, in order, so concurrent jobs
+// never edit the workspace at the same time. Jobs are kept in memory for
+// the lifetime of the app.
+type jobQueue struct {
+	run         func(ctx context.Context, sessionID, prompt string, attachments []message.Attachment) (*fantasy.AgentResult, error)
+	sessions    session.Service
+	history     history.Service
+	permissions permission.Service
+	workingDir  string
+	client      *http.Client
+
+	start   sync.Once
+	queue   chan string
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	pending map[string]*queuedJob
+}
+
+func newJobQueue(app *App) *jobQueue {
+	return &jobQueue{
+		run:         app.SendMessage,
+		sessions:    app.Sessions,
+		history:     app.History,
+		permissions: app.Permissions,
+		workingDir:  app.config.WorkingDir(),
+		client:      &http.Client{Timeout: webhookTimeout},
+		queue:       make(chan string, jobQueueSize),
+		jobs:        make(map[string]*Job),
+		pending:     make(map[string]*queuedJob),
+	}
+}
+
+// SubmitJob queues spec to run in the background and returns its job ID.
+// Poll [App.Job] for the result or set [JobSpec.Webhook] to be notified.
+func (app *App) SubmitJob(ctx context.Context, spec JobSpec) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return app.jobs.submit(app.globalCtx, spec)
+}
+
+// Job returns the current state of a job.
+func (app *App) Job(id string) (Job, error) {
+	return app.jobs.get(id)
+}
+
+// CancelJob stops a queued or running job.
+func (app *App) CancelJob(id string) error {
+	return app.jobs.cancel(id)
+}
+
+func (q *jobQueue) submit(appCtx context.Context, spec JobSpec) (string, error) {
+	if strings.TrimSpace(spec.Prompt) == "" {
+		return "", errors.New("job prompt is empty")
+	}
+	job := &Job{
+		ID:        uuid.New().String(),
+		SessionID: spec.SessionID,
+		Status:    JobQueued,
+		Prompt:    spec.Prompt,
+		CreatedAt: time.Now(),
+	}
+	var jobCtx context.Context
+	var cancel context.CancelFunc
+	if spec.Timeout > 0 {
+		jobCtx, cancel = context.WithTimeout(appCtx, spec.Timeout)
+	} else {
+		jobCtx, cancel = context.WithCancel(appCtx)
+	}
+
+	q.mu.Lock()
+	q.jobs[job.ID] = job
+	q.pending[job.ID] = &queuedJob{spec: spec, ctx: jobCtx, cancel: cancel}
+	q.mu.Unlock()
+
+	select {
+	case q.queue <- job.ID:
+	default:
+		cancel()
+		q.mu.Lock()
+		delete(q.jobs, job.ID)
+		delete(q.pending, job.ID)
+		q.mu.Unlock()
+		return "", errors.New("job queue is full")
+	}
+	q.start.Do(func() { go q.work(appCtx) })
+	return job.ID, nil
+}
+
+func (q *jobQueue) get(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return *job, nil
+}
+
+func (q *jobQueue) cancel(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.jobs[id]; !ok {
+		return ErrJobNotFound
+	}
+	if p, ok := q.pending[id]; ok {
+		p.cancel()
+	}
+	return nil
+}
+
+func (q *jobQueue) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-q.queue:
+			q.runJob(id)
+		}
+	}
+}
+
+func (q *jobQueue) runJob(id string) {
+	q.mu.Lock()
+	p := q.pending[id]
+	job := q.jobs[id]
+	job.Status = JobRunning
+	job.StartedAt = time.Now()
+	q.mu.Unlock()
+	defer p.cancel()
+
+	var result *fantasy.AgentResult
+	err := p.ctx.Err()
+	if err == nil {
+		err = q.startSession(p.ctx, job, p.spec)
+	}
+	if err == nil {
+		result, err = q.run(p.ctx, job.SessionID, p.spec.Prompt, p.spec.Attachments)
+	}
+	// The patch and webhook are still due when the job was canceled.
+	ctx := context.WithoutCancel(p.ctx)
+	var patch string
+	if job.SessionID != "" {
+		var patchErr error
+		patch, patchErr = q.patch(ctx, job.SessionID)
+		if patchErr != nil {
+			slog.Error("Failed to build job patch", "job_id", id, "error", patchErr)
+		}
+	}
+
+	q.mu.Lock()
+	job.FinishedAt = time.Now()
+	job.Patch = patch
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, agent.ErrRequestCancelled):
+		job.Status = JobCanceled
+	case err != nil:
+		job.Status = JobFailed
+		job.Error = err.Error()
+	default:
+		job.Status = JobSucceeded
+		if result != nil {
+			job.Response = result.Response.Content.Text()
+		}
+	}
+	delete(q.pending, id)
+	done := *job
+	q.mu.Unlock()
+
+	slog.Info("Job finished", "job_id", id, "status", done.Status)
+	if p.spec.Webhook != "" {
+		q.notify(ctx, p.spec.Webhook, done)
+	}
+}
+
+// startSession creates the session of a job without one and auto-approves
+// it, as nobody is around to answer its permission prompts. An existing
+// session is only auto-approved when the job opts in.
+func (q *jobQueue) startSession(ctx context.Context, job *Job, spec JobSpec) error {
+	if spec.SessionID != "" {
+		if spec.AutoApprove {
+			q.permissions.AutoApproveSession(spec.SessionID)
+		}
+		return nil
+	}
+	sess, err := q.sessions.Create(ctx, "Job: "+truncateTitle(spec.Prompt))
+	if err != nil {
+		return fmt.Errorf("failed to create session for job: %w", err)
+	}
+	q.permissions.AutoApproveSession(sess.ID)
+	q.mu.Lock()
+	job.SessionID = sess.ID
+	q.mu.Unlock()
+	return nil
+}
+
+// patch returns a unified diff of every file the session changed, from its
+// first recorded version to its latest, with the files it moved as renames.
+func (q *jobQueue) patch(ctx context.Context, sessionID string) (string, error) {
+	files, err := q.history.ListBySession(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	first := make(map[string]history.File)
+	last := make(map[string]history.File)
+	for _, f := range files {
+		if v, ok := first[f.Path]; !ok || f.Version < v.Version {
+			first[f.Path] = f
+		}
+		if v, ok := last[f.Path]; !ok || f.Version > v.Version {
+			last[f.Path] = f
+		}
+	}
+	paths := make([]string, 0, len(first))
+	for path := range first {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	// A file the session created that is like one it left missing from
+	// disk was moved there, such as with mv, so it is told as a rename.
+	removed := make(map[string]string)
+	created := make(map[string]string)
+	for _, path := range paths {
+		if first[path].Content == "" && last[path].Content != "" {
+			created[path] = last[path].Content
+		} else if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			removed[path] = first[path].Content
+		}
+	}
+	renames := make(map[string]diff.Rename)
+	for _, r := range diff.DetectRenames(removed, created) {
+		renames[r.From], renames[r.To] = r, r
+	}
+
+	var patch strings.Builder
+	for _, path := range paths {
+		if r, ok := renames[path]; ok {
+			if path == r.To {
+				patch.WriteString(diff.GenerateRenameDiff(first[r.From].Content, last[r.To].Content, q.relPath(r.From), q.relPath(r.To)))
+			}
+			continue
+		}
+		if first[path].Content == last[path].Content {
+			continue
+		}
+		unified, _, _ := diff.GenerateDiff(first[path].Content, last[path].Content, q.relPath(path))
+		patch.WriteString(unified)
+	}
+	return patch.String(), nil
+}
+
+// relPath returns path relative to the working directory when it is inside
+// it, as patches name files.
+func (q *jobQueue) relPath(path string) string {
+	if rel, err := filepath.Rel(q.workingDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+func (q *jobQueue) notify(ctx context.Context, url string, job Job) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		slog.Error("Failed to encode job webhook", "job_id", job.ID, "error", err)
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		slog.Error("Failed to create job webhook request", "job_id", job.ID, "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := q.client.Do(req)
+	if err != nil {
+		slog.Error("Failed to call job webhook", "job_id", job.ID, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Error("Job webhook returned an error", "job_id", job.ID, "status", resp.StatusCode)
+	}
+}
+
+func truncateTitle(prompt string) string {
+	const maxTitleLength = 100
+	prompt = strings.Join(strings.Fields(prompt), " ")
+	if len(prompt) > maxTitleLength {
+		return prompt[:maxTitleLength] + "..."
+	}
+	return prompt
+}
@@ -0,0 +1,222 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJobQueue(t *testing.T, run func(ctx context.Context, sessionID string) error) *jobQueue {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	workingDir := t.TempDir()
+	files := history.NewService(q, conn)
+	return &jobQueue{
+		run: func(ctx context.Context, sessionID, prompt string, _ []message.Attachment) (*fantasy.AgentResult, error) {
+			if err := run(ctx, sessionID); err != nil {
+				return nil, err
+			}
+			path := filepath.Join(workingDir, "main.go")
+			if _, err := files.Create(ctx, sessionID, path, "package main\n"); err != nil {
+				return nil, err
+			}
+			if _, err := files.CreateVersion(ctx, sessionID, path, "package main\n\nfunc main() {}\n"); err != nil {
+				return nil, err
+			}
+			return &fantasy.AgentResult{Response: fantasy.Response{
+				Content: fantasy.ResponseContent{fantasy.TextContent{Text: "done: " + prompt}},
+			}}, nil
+		},
+		sessions:    session.NewService(q, conn, ""),
+		history:     files,
+		permissions: permission.NewPermissionService(workingDir, false, nil, nil, ""),
+		workingDir:  workingDir,
+		client:      http.DefaultClient,
+		queue:       make(chan string, jobQueueSize),
+		jobs:        make(map[string]*Job),
+		pending:     make(map[string]*queuedJob),
+	}
+}
+
+func waitJob(t *testing.T, q *jobQueue, id string) Job {
+	t.Helper()
+	var job Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = q.get(id)
+		require.NoError(t, err)
+		return job.Status.Done()
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestJobQueue(t *testing.T) {
+	t.Parallel()
+
+	hooks := make(chan Job, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var job Job
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&job))
+		hooks <- job
+	}))
+	t.Cleanup(srv.Close)
+
+	q := newTestJobQueue(t, func(context.Context, string) error { return nil })
+	id, err := q.submit(t.Context(), JobSpec{Prompt: "add main", Webhook: srv.URL})
+	require.NoError(t, err)
+
+	job := waitJob(t, q, id)
+	require.Equal(t, JobSucceeded, job.Status)
+	require.Equal(t, "done: add main", job.Response)
+	require.NotEmpty(t, job.SessionID)
+	require.Contains(t, job.Patch, "--- a/main.go")
+	require.Contains(t, job.Patch, "+func main() {}")
+	require.False(t, job.FinishedAt.Before(job.StartedAt))
+
+	select {
+	case hook := <-hooks:
+		require.Equal(t, id, hook.ID)
+		require.Equal(t, JobSucceeded, hook.Status)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+
+	_, err = q.get("missing")
+	require.ErrorIs(t, err, ErrJobNotFound)
+	_, err = q.submit(t.Context(), JobSpec{Prompt: "  "})
+	require.Error(t, err)
+}
+
+func TestJobQueueFailureAndCancel(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	q := newTestJobQueue(t, func(ctx context.Context, sessionID string) error {
+		if calls.Add(1) == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return errors.New("provider unavailable")
+	})
+
+	blocking, err := q.submit(t.Context(), JobSpec{Prompt: "wait"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		job, _ := q.get(blocking)
+		return job.Status == JobRunning
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, q.cancel(blocking))
+	require.Equal(t, JobCanceled, waitJob(t, q, blocking).Status)
+
+	failing, err := q.submit(t.Context(), JobSpec{Prompt: "fail"})
+	require.NoError(t, err)
+	job := waitJob(t, q, failing)
+	require.Equal(t, JobFailed, job.Status)
+	require.Equal(t, "provider unavailable", job.Error)
+}
+
+func TestJobPatchRenames(t *testing.T) {
+	t.Parallel()
+
+	q := newTestJobQueue(t, func(context.Context, string) error { return nil })
+	sess, err := q.sessions.Create(t.Context(), "rename")
+	require.NoError(t, err)
+
+	content := "package util\n\nfunc A() {}\n\nfunc B() {}\n"
+	oldPath := filepath.Join(q.workingDir, "old.go")
+	newPath := filepath.Join(q.workingDir, "pkg", "util.go")
+	_, err = q.history.Create(t.Context(), sess.ID, oldPath, content)
+	require.NoError(t, err)
+	_, err = q.history.Create(t.Context(), sess.ID, newPath, "")
+	require.NoError(t, err)
+	_, err = q.history.CreateVersion(t.Context(), sess.ID, newPath, content+"\nfunc C() {}\n")
+	require.NoError(t, err)
+
+	patch, err := q.patch(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Contains(t, patch, "rename from old.go\nrename to pkg/util.go\n")
+	require.Contains(t, patch, "+func C() {}")
+	require.NotContains(t, patch, "+func A() {}")
+}
+
+type recordingPermissions struct {
+	permission.Service
+	mu       sync.Mutex
+	approved []string
+}
+
+func (p *recordingPermissions) AutoApproveSession(sessionID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.approved = append(p.approved, sessionID)
+}
+
+func (p *recordingPermissions) autoApproved() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.approved)
+}
+
+func TestJobAutoApprove(t *testing.T) {
+	t.Parallel()
+
+	q := newTestJobQueue(t, func(context.Context, string) error { return nil })
+	perms := &recordingPermissions{Service: q.permissions}
+	q.permissions = perms
+	existing, err := q.sessions.Create(t.Context(), "interactive")
+	require.NoError(t, err)
+
+	id, err := q.submit(t.Context(), JobSpec{Prompt: "continue", SessionID: existing.ID})
+	require.NoError(t, err)
+	require.Equal(t, JobSucceeded, waitJob(t, q, id).Status)
+	require.Empty(t, perms.autoApproved(), "existing sessions are not auto-approved")
+
+	id, err = q.submit(t.Context(), JobSpec{Prompt: "new"})
+	require.NoError(t, err)
+	created := waitJob(t, q, id).SessionID
+	require.NotEqual(t, existing.ID, created)
+	require.Equal(t, []string{created}, perms.autoApproved())
+
+	id, err = q.submit(t.Context(), JobSpec{Prompt: "opt in", SessionID: existing.ID, AutoApprove: true})
+	require.NoError(t, err)
+	require.Equal(t, JobSucceeded, waitJob(t, q, id).Status)
+	require.Equal(t, []string{created, existing.ID}, perms.autoApproved())
+}
+
+func TestJobQueueFullCreatesNoSession(t *testing.T) {
+	t.Parallel()
+
+	q := newTestJobQueue(t, func(context.Context, string) error { return nil })
+	// No worker runs while the queue is filled.
+	q.start.Do(func() {})
+	for range jobQueueSize {
+		_, err := q.submit(t.Context(), JobSpec{Prompt: "queued"})
+		require.NoError(t, err)
+	}
+	_, err := q.submit(t.Context(), JobSpec{Prompt: "overflow"})
+	require.EqualError(t, err, "job queue is full")
+
+	sessions, err := q.sessions.List(t.Context())
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
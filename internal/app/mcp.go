@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/commands"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// MCPPrompts returns the prompts of the connected MCP servers. The TUI
+// offers them as slash commands.
+func (app *App) MCPPrompts() ([]commands.MCPPrompt, error) {
+	return commands.LoadMCPPrompts()
+}
+
+// RenderMCPPrompt returns the text of a prompt of an MCP server filled in
+// with args, ready to be sent.
+func (app *App) RenderMCPPrompt(ctx context.Context, server, prompt string, args map[string]string) (string, error) {
+	return commands.GetMCPPrompt(ctx, app.config, server, prompt, args)
+}
+
+// MCPResources returns the resources of the connected MCP servers, by
+// server.
+func (app *App) MCPResources() map[string][]*mcp.Resource {
+	return maps.Collect(mcp.Resources())
+}
+
+// MCPResourceAttachment reads a resource of an MCP server into an
+// attachment for the next prompt.
+func (app *App) MCPResourceAttachment(ctx context.Context, server, uri string) (message.Attachment, error) {
+	contents, err := mcp.ReadResource(ctx, app.config, server, uri)
+	if err != nil {
+		return message.Attachment{}, err
+	}
+	var listed *mcp.Resource
+	for name, resources := range mcp.Resources() {
+		if name != server {
+			continue
+		}
+		for _, r := range resources {
+			if r.URI == uri {
+				listed = r
+			}
+		}
+	}
+	return resourceAttachment(uri, listed, contents)
+}
+
+// resourceAttachment makes the attachment of the first contents of the
+// resource at uri, named and typed after the listed resource when known.
+func resourceAttachment(uri string, listed *mcp.Resource, contents []*mcp.ResourceContents) (message.Attachment, error) {
+	if len(contents) == 0 {
+		return message.Attachment{}, fmt.Errorf("mcp resource %s is empty", uri)
+	}
+	content := contents[0]
+	data := []byte(content.Text)
+	if len(data) == 0 {
+		data = content.Blob
+	}
+	if len(data) == 0 {
+		return message.Attachment{}, fmt.Errorf("mcp resource %s is empty", uri)
+	}
+
+	name, mimeType := uri, ""
+	if listed != nil {
+		if listed.Name != "" {
+			name = listed.Name
+		}
+		mimeType = listed.MIMEType
+	}
+	if mimeType == "" {
+		mimeType = content.MIMEType
+	}
+	if mimeType == "" {
+		mimeType = "text/plain"
+	}
+	return message.Attachment{
+		FilePath: uri,
+		FileName: name,
+		MimeType: mimeType,
+		Content:  data,
+	}, nil
+}
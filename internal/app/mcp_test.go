@@ -0,0 +1,38 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceAttachment(t *testing.T) {
+	t.Parallel()
+
+	t.Run("listed", func(t *testing.T) {
+		t.Parallel()
+		listed := &mcp.Resource{URI: "file:///notes.md", Name: "notes", MIMEType: "text/markdown"}
+		got, err := resourceAttachment(listed.URI, listed, []*mcp.ResourceContents{{URI: listed.URI, Text: "# Notes"}})
+		require.NoError(t, err)
+		require.Equal(t, "notes", got.FileName)
+		require.Equal(t, "file:///notes.md", got.FilePath)
+		require.Equal(t, "text/markdown", got.MimeType)
+		require.Equal(t, "# Notes", string(got.Content))
+	})
+
+	t.Run("blob", func(t *testing.T) {
+		t.Parallel()
+		got, err := resourceAttachment("db://logo", nil, []*mcp.ResourceContents{{MIMEType: "image/png", Blob: []byte{0x89}}})
+		require.NoError(t, err)
+		require.Equal(t, "db://logo", got.FileName)
+		require.Equal(t, "image/png", got.MimeType)
+		require.Equal(t, []byte{0x89}, got.Content)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		_, err := resourceAttachment("db://empty", nil, nil)
+		require.ErrorContains(t, err, "empty")
+	})
+}
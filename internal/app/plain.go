@@ -0,0 +1,275 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Events of the plain protocol. Each line of output is an event, followed by
+// a space and its payload when it has one.
+const (
+	PlainSession  = "SESSION"
+	PlainThinking = "THINKING"
+	PlainText     = "TEXT"
+	PlainTool     = "TOOL"
+	PlainResult   = "RESULT"
+	PlainError    = "ERROR"
+	PlainDone     = "DONE"
+)
+
+// plainNewlines turns the line breaks and tabs of payloads into spaces,
+// keeping each event on its line.
+var plainNewlines = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+// maxPlainResultLength is how much of the output of a tool is shown in its
+// RESULT event.
+const maxPlainResultLength = 200
+
+// RunPlain runs a prompt like [App.RunNonInteractive] but writes the run to
+// output as the plain protocol: one event per line, without colors or
+// animations, for screen readers and for scripts driving Crush.
+//
+//	SESSION <session id>
+//	THINKING
+//	TEXT <line of the reply>
+//	TOOL <tool name> <input as JSON>
+//	RESULT <tool name> ok|error <first line of the output>
+//	ERROR <message>
+//	DONE ok|canceled|error
+func (app *App) RunPlain(ctx context.Context, output io.Writer, prompt, largeModel, smallModel string) error {
+	slog.Info("Running in plain mode")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := newPlainWriter(output)
+	fail := func(err error) error {
+		w.event(PlainError, err.Error())
+		w.event(PlainDone, "error")
+		return err
+	}
+
+	if largeModel != "" || smallModel != "" {
+		if err := app.overrideModelsForNonInteractive(ctx, largeModel, smallModel); err != nil {
+			return fail(fmt.Errorf("failed to override models: %w", err))
+		}
+	}
+	if err := mcp.WaitForInit(ctx); err != nil {
+		return fail(fmt.Errorf("failed to wait for MCP initialization: %w", err))
+	}
+	app.AgentCoordinator.UpdateModels(ctx)
+
+	sess, err := app.Sessions.Create(ctx, nonInteractiveTitle(prompt))
+	if err != nil {
+		return fail(fmt.Errorf("failed to create session for non-interactive mode: %w", err))
+	}
+	slog.Info("Created session for plain run", "session_id", sess.ID)
+	app.Permissions.AutoApproveSession(sess.ID)
+	w.event(PlainSession, sess.ID)
+
+	messageEvents := app.Messages.Subscribe(ctx)
+	done := make(chan error, 1)
+	go func() {
+		_, err := app.AgentCoordinator.Run(ctx, sess.ID, prompt)
+		done <- err
+	}()
+
+	for {
+		select {
+		case err := <-done:
+			// Catch up on the updates published before the run returned.
+			for drained := false; !drained; {
+				select {
+				case event := <-messageEvents:
+					if event.Payload.SessionID == sess.ID {
+						w.message(event.Payload)
+					}
+				default:
+					drained = true
+				}
+			}
+			w.flush()
+			if err != nil {
+				if errors.Is(err, context.Canceled) || errors.Is(err, agent.ErrRequestCancelled) {
+					w.event(PlainDone, "canceled")
+					return nil
+				}
+				return fail(fmt.Errorf("agent processing failed: %w", err))
+			}
+			w.event(PlainDone, "ok")
+			return nil
+
+		case event := <-messageEvents:
+			if event.Payload.SessionID == sess.ID {
+				w.message(event.Payload)
+			}
+
+		case <-ctx.Done():
+			w.flush()
+			w.event(PlainDone, "canceled")
+			return ctx.Err()
+		}
+	}
+}
+
+// plainWriter turns the updates of the messages of a run into events of the
+// plain protocol, writing each event once.
+type plainWriter struct {
+	out io.Writer
+	// written is how much of the text of each message was written.
+	written map[string]int
+	// partial is the text of each message after its last complete line.
+	partial  map[string]string
+	thinking map[string]bool
+	calls    map[string]bool
+	results  map[string]bool
+	finished map[string]bool
+	// order keeps the messages with partial lines in the order they came.
+	order []string
+}
+
+func newPlainWriter(out io.Writer) *plainWriter {
+	return &plainWriter{
+		out:      out,
+		written:  make(map[string]int),
+		partial:  make(map[string]string),
+		thinking: make(map[string]bool),
+		calls:    make(map[string]bool),
+		results:  make(map[string]bool),
+		finished: make(map[string]bool),
+	}
+}
+
+// event writes an event, keeping its payload to a single line.
+func (w *plainWriter) event(name, payload string) {
+	payload = strings.TrimSpace(plainNewlines.Replace(ansi.Strip(payload)))
+	if payload == "" {
+		fmt.Fprintln(w.out, name)
+		return
+	}
+	fmt.Fprintln(w.out, name, payload)
+}
+
+// text writes a line of a reply as is, but for colors.
+func (w *plainWriter) text(line string) {
+	line = strings.TrimRight(ansi.Strip(line), " \t\r")
+	if line == "" {
+		fmt.Fprintln(w.out, PlainText)
+		return
+	}
+	fmt.Fprintln(w.out, PlainText, line)
+}
+
+// message writes the events of a message not written yet.
+func (w *plainWriter) message(msg message.Message) {
+	switch msg.Role {
+	case message.Assistant:
+		if !w.thinking[msg.ID] && msg.ReasoningContent().Thinking != "" {
+			w.thinking[msg.ID] = true
+			w.event(PlainThinking, "")
+		}
+		w.reply(msg)
+		for _, call := range msg.ToolCalls() {
+			if !call.Finished || w.calls[call.ID] {
+				continue
+			}
+			w.calls[call.ID] = true
+			// The text before the call is complete.
+			w.flushMessage(msg.ID)
+			w.event(PlainTool, call.Name+" "+compactJSON(call.Input))
+		}
+		if finish := msg.FinishPart(); finish != nil && !w.finished[msg.ID] {
+			w.finished[msg.ID] = true
+			w.flushMessage(msg.ID)
+			if finish.Reason == message.FinishReasonError {
+				w.event(PlainError, strings.TrimSpace(finish.Message+" "+finish.Details))
+			}
+		}
+	case message.Tool:
+		for _, result := range msg.ToolResults() {
+			if w.results[result.ToolCallID] {
+				continue
+			}
+			w.results[result.ToolCallID] = true
+			status := "ok"
+			if result.IsError {
+				status = "error"
+			}
+			w.event(PlainResult, result.Name+" "+status+" "+firstLine(result.Content, maxPlainResultLength))
+		}
+	}
+}
+
+// reply writes the complete lines of the text of msg not written yet.
+func (w *plainWriter) reply(msg message.Message) {
+	content := msg.Content().Text
+	written := w.written[msg.ID]
+	if len(content) <= written {
+		return
+	}
+	if written == 0 {
+		content = strings.TrimLeft(content, " \t\n")
+		if content == "" {
+			return
+		}
+		w.order = append(w.order, msg.ID)
+	} else {
+		content = content[written:]
+	}
+	w.written[msg.ID] = len(msg.Content().Text)
+
+	text := w.partial[msg.ID] + content
+	lines := strings.Split(text, "\n")
+	for _, line := range lines[:len(lines)-1] {
+		w.text(line)
+	}
+	w.partial[msg.ID] = lines[len(lines)-1]
+}
+
+// flushMessage writes the last line of the text of a message, even though
+// the line is not complete.
+func (w *plainWriter) flushMessage(id string) {
+	if line, ok := w.partial[id]; ok && strings.TrimSpace(line) != "" {
+		w.text(line)
+	}
+	delete(w.partial, id)
+}
+
+// flush writes the incomplete lines of all messages.
+func (w *plainWriter) flush() {
+	for _, id := range w.order {
+		w.flushMessage(id)
+	}
+	w.order = nil
+}
+
+// compactJSON returns input on a single line.
+func compactJSON(input string) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(input)); err != nil {
+		return input
+	}
+	return buf.String()
+}
+
+// firstLine returns the first non-blank line of s, shortened to n
+// characters.
+func firstLine(s string, n int) string {
+	for line := range strings.SplitSeq(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return ansi.Truncate(line, n, "…")
+		}
+	}
+	return ""
+}
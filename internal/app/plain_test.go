@@ -0,0 +1,52 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlainWriter(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+	w := newPlainWriter(&out)
+
+	reply := message.Message{ID: "m1", Role: message.Assistant}
+	reply.AppendReasoningContent("Let me look.")
+	w.message(reply)
+	reply.AppendContent("\n  Checking the \x1b[1mtests\x1b[0m\nfirst")
+	w.message(reply)
+	reply.AppendContent(".\n\nDone")
+	reply.AddToolCall(message.ToolCall{ID: "c1", Name: "bash", Input: "{\n  \"command\": \"go  test\"\n}"})
+	w.message(reply)
+	reply.FinishToolCall("c1")
+	reply.AddFinish(message.FinishReasonToolUse, "", "")
+	w.message(reply)
+	w.message(reply)
+
+	results := message.Message{ID: "m2", Role: message.Tool}
+	results.AddToolResult(message.ToolResult{ToolCallID: "c1", Name: "bash", Content: "\nFAIL\tpkg\nmore output", IsError: true})
+	w.message(results)
+	w.message(results)
+
+	failed := message.Message{ID: "m3", Role: message.Assistant}
+	failed.AppendContent("Half a line")
+	failed.AddFinish(message.FinishReasonError, "Provider error", "rate limited")
+	w.message(failed)
+	w.flush()
+
+	require.Equal(t, strings.Join([]string{
+		"THINKING",
+		"TEXT Checking the tests",
+		"TEXT first.",
+		"TEXT",
+		"TEXT Done",
+		`TOOL bash {"command":"go  test"}`,
+		"RESULT bash error FAIL pkg",
+		"TEXT Half a line",
+		"ERROR Provider error rate limited",
+	}, "\n")+"\n", out.String())
+}
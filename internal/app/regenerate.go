@@ -0,0 +1,165 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// RegenerateMode is what happens to the messages that follow an edited one.
+type RegenerateMode int
+
+const (
+	// RegenerateTruncate deletes the edited message and everything after it,
+	// and answers the new text in the same session.
+	RegenerateTruncate RegenerateMode = iota
+	// RegenerateBranch leaves the session as it is and answers the new text
+	// in a new session holding a copy of the history before the message.
+	RegenerateBranch
+)
+
+// ErrNotUserMessage is returned when rewinding to a message that was not
+// written by the user.
+var ErrNotUserMessage = errors.New("only user messages can be edited")
+
+// Rewind is a conversation taken back to before one of its user messages.
+type Rewind struct {
+	// Session is where the conversation continues: the message's own
+	// session when truncating, a new one when branching.
+	Session session.Session
+	// Message is the message rewound to, as it was.
+	Message message.Message
+}
+
+// Attachments returns the files attached to the rewound message, to be sent
+// again with its new text.
+func (r Rewind) Attachments() []message.Attachment {
+	var attachments []message.Attachment
+	for _, content := range r.Message.BinaryContent() {
+		attachments = append(attachments, message.Attachment{
+			FilePath: content.Path,
+			FileName: filepath.Base(content.Path),
+			MimeType: content.MIMEType,
+			Content:  content.Data,
+		})
+	}
+	return attachments
+}
+
+// Rewind takes the session of a user message back to just before it, by
+// deleting the message and what followed or by branching a new session off
+// the history before it. Files the agent changed in the meantime are left
+// as they are.
+func (app *App) Rewind(ctx context.Context, messageID string, mode RegenerateMode) (Rewind, error) {
+	msg, err := app.Messages.Get(ctx, messageID)
+	if err != nil {
+		return Rewind{}, err
+	}
+	if msg.Role != message.User {
+		return Rewind{}, ErrNotUserMessage
+	}
+	sess, err := app.Sessions.Get(ctx, msg.SessionID)
+	if err != nil {
+		return Rewind{}, err
+	}
+	if app.AgentCoordinator != nil && app.AgentCoordinator.IsSessionBusy(sess.ID) {
+		return Rewind{}, agent.ErrSessionBusy
+	}
+
+	if mode == RegenerateBranch {
+		branch, err := app.branchSession(ctx, sess, messageID)
+		if err != nil {
+			return Rewind{}, fmt.Errorf("failed to branch session: %w", err)
+		}
+		return Rewind{Session: branch, Message: msg}, nil
+	}
+
+	deleted, err := app.Messages.Truncate(ctx, messageID)
+	if err != nil {
+		return Rewind{}, fmt.Errorf("failed to truncate session: %w", err)
+	}
+	if slices.ContainsFunc(deleted, func(m message.Message) bool { return m.ID == sess.SummaryMessageID }) {
+		// The summary went with the rest, so the whole history is sent again.
+		sess.SummaryMessageID = ""
+		if sess, err = app.Sessions.Save(ctx, sess); err != nil {
+			return Rewind{}, err
+		}
+	}
+	return Rewind{Session: sess, Message: msg}, nil
+}
+
+// branchSession creates a session with a copy of the messages of sess that
+// come before messageID, keeping its summary, tags and chat-only setting.
+func (app *App) branchSession(ctx context.Context, sess session.Session, messageID string) (session.Session, error) {
+	msgs, err := app.Messages.List(ctx, sess.ID)
+	if err != nil {
+		return session.Session{}, err
+	}
+	idx := slices.IndexFunc(msgs, func(m message.Message) bool { return m.ID == messageID })
+	if idx < 0 {
+		return session.Session{}, fmt.Errorf("message %s not found in session %s", messageID, sess.ID)
+	}
+
+	branch, err := app.Sessions.Create(ctx, sess.Title)
+	if err != nil {
+		return session.Session{}, err
+	}
+	for _, m := range msgs[:idx] {
+		copied, err := app.Messages.Copy(ctx, branch.ID, m)
+		if err != nil {
+			return session.Session{}, err
+		}
+		if m.ID == sess.SummaryMessageID {
+			branch.SummaryMessageID = copied.ID
+		}
+	}
+	if branch.SummaryMessageID != "" {
+		if branch, err = app.Sessions.Save(ctx, branch); err != nil {
+			return session.Session{}, err
+		}
+	}
+	if len(sess.Tags) > 0 {
+		if branch, err = app.Sessions.SetTags(ctx, branch.ID, sess.Tags); err != nil {
+			return session.Session{}, err
+		}
+	}
+	if sess.ChatOnly {
+		if branch, err = app.Sessions.SetChatOnly(ctx, branch.ID, true); err != nil {
+			return session.Session{}, err
+		}
+	}
+	if sess.Translate {
+		if branch, err = app.Sessions.SetTranslate(ctx, branch.ID, true); err != nil {
+			return session.Session{}, err
+		}
+	}
+	return branch, nil
+}
+
+// EditAndRegenerate replaces the text of a user message and has the agent
+// answer it again, with the attachments the message had, then waits for the
+// answer. See [App.Rewind] for what happens to the messages that followed.
+// It returns the session the answer was written to.
+func (app *App) EditAndRegenerate(ctx context.Context, messageID, newText string, mode RegenerateMode) (session.Session, *fantasy.AgentResult, error) {
+	if app.AgentCoordinator == nil {
+		return session.Session{}, nil, errors.New("agent not initialized")
+	}
+	newText = strings.TrimSpace(newText)
+	if newText == "" {
+		return session.Session{}, nil, errors.New("message is empty")
+	}
+	rewind, err := app.Rewind(ctx, messageID, mode)
+	if err != nil {
+		return session.Session{}, nil, err
+	}
+	result, err := app.SendMessage(ctx, rewind.Session.ID, newText, rewind.Attachments())
+	return rewind.Session, result, err
+}
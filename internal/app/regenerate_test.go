@@ -0,0 +1,76 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewind(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	app := &App{
+		Sessions: session.NewService(q, conn, ""),
+		Messages: message.NewService(q),
+	}
+
+	sess, err := app.Sessions.Create(t.Context(), "Original")
+	require.NoError(t, err)
+	_, err = app.Sessions.SetTags(t.Context(), sess.ID, []string{"bug"})
+	require.NoError(t, err)
+	var ids []string
+	for _, params := range []message.CreateMessageParams{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "first"}}},
+		{Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: "first answer"}}},
+		{Role: message.User, Parts: []message.ContentPart{
+			message.TextContent{Text: "second"},
+			message.BinaryContent{Path: "/tmp/shot.png", MIMEType: "image/png", Data: []byte("png")},
+		}},
+		{Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: "second answer"}}},
+	} {
+		msg, err := app.Messages.Create(t.Context(), sess.ID, params)
+		require.NoError(t, err)
+		ids = append(ids, msg.ID)
+	}
+
+	_, err = app.Rewind(t.Context(), ids[1], RegenerateTruncate)
+	require.ErrorIs(t, err, ErrNotUserMessage)
+
+	// Branching copies the history before the message and leaves the
+	// session alone.
+	rewind, err := app.Rewind(t.Context(), ids[2], RegenerateBranch)
+	require.NoError(t, err)
+	require.NotEqual(t, sess.ID, rewind.Session.ID)
+	require.Equal(t, []string{"bug"}, rewind.Session.Tags)
+	require.Equal(t, []message.Attachment{{
+		FilePath: "/tmp/shot.png",
+		FileName: "shot.png",
+		MimeType: "image/png",
+		Content:  []byte("png"),
+	}}, rewind.Attachments())
+	branched, err := app.Messages.List(t.Context(), rewind.Session.ID)
+	require.NoError(t, err)
+	require.Len(t, branched, 2)
+	require.Equal(t, "first answer", branched[1].Content().Text)
+	require.True(t, branched[0].IsFinished())
+	original, err := app.Messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, original, 4)
+
+	// Truncating drops the message and everything after it.
+	rewind, err = app.Rewind(t.Context(), ids[2], RegenerateTruncate)
+	require.NoError(t, err)
+	require.Equal(t, sess.ID, rewind.Session.ID)
+	require.Equal(t, "second", rewind.Message.Content().Text)
+	remaining, err := app.Messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, remaining, 2)
+	require.Equal(t, ids[:2], []string{remaining[0].ID, remaining[1].ID})
+}
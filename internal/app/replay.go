@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/replay"
+)
+
+// ReplayOptions select what a replay runs the turns of a session against.
+type ReplayOptions struct {
+	// LargeModel and SmallModel override the configured models, in the
+	// format RunNonInteractive accepts.
+	LargeModel string
+	SmallModel string
+	// PromptTemplate replaces the system prompt of the coder agent, like
+	// options.prompt_overrides.coder.
+	PromptTemplate string
+}
+
+// Replay sends the user turns of a session, one at a time, to a new session
+// and compares the two. The replay runs without asking for permissions, so it
+// should run in a copy of the project.
+func (app *App) Replay(ctx context.Context, sessionID string, opts ReplayOptions) (replay.Report, error) {
+	original, err := app.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return replay.Report{}, fmt.Errorf("failed to get session: %w", err)
+	}
+	msgs, err := app.Messages.List(ctx, original.ID)
+	if err != nil {
+		return replay.Report{}, fmt.Errorf("failed to list messages: %w", err)
+	}
+	originalRun := replay.Run{
+		SessionID:        original.ID,
+		Title:            original.Title,
+		Model:            replay.Model(msgs),
+		PromptTemplate:   app.config.PromptOverride(config.PromptCoder),
+		PromptTokens:     original.PromptTokens,
+		CompletionTokens: original.CompletionTokens,
+		Cost:             original.Cost,
+		Turns:            replay.Turns(msgs),
+	}
+	if len(originalRun.Turns) == 0 {
+		return replay.Report{}, fmt.Errorf("session %s has no user messages", original.ID)
+	}
+
+	if opts.PromptTemplate != "" {
+		if app.config.Options.PromptOverrides == nil {
+			app.config.Options.PromptOverrides = &config.PromptOverrides{}
+		}
+		app.config.Options.PromptOverrides.Coder = opts.PromptTemplate
+		if err := app.InitCoderAgent(ctx); err != nil {
+			return replay.Report{}, fmt.Errorf("failed to load prompt template: %w", err)
+		}
+	}
+	if opts.LargeModel != "" || opts.SmallModel != "" {
+		if err := app.overrideModelsForNonInteractive(ctx, opts.LargeModel, opts.SmallModel); err != nil {
+			return replay.Report{}, fmt.Errorf("failed to override models: %w", err)
+		}
+	}
+	if err := mcp.WaitForInit(ctx); err != nil {
+		return replay.Report{}, fmt.Errorf("failed to wait for MCP initialization: %w", err)
+	}
+	app.AgentCoordinator.UpdateModels(ctx)
+
+	sess, err := app.Sessions.Create(ctx, "Replay: "+original.Title)
+	if err != nil {
+		return replay.Report{}, fmt.Errorf("failed to create session for replay: %w", err)
+	}
+	slog.Info("Created session for replay", "session_id", sess.ID, "original_session_id", original.ID)
+	app.Permissions.AutoApproveSession(sess.ID)
+
+	replayRun := replay.Run{
+		SessionID:      sess.ID,
+		Title:          sess.Title,
+		PromptTemplate: app.config.PromptOverride(config.PromptCoder),
+	}
+	for i, turn := range originalRun.Turns {
+		if _, err := app.AgentCoordinator.Run(ctx, sess.ID, turn.Prompt); err != nil {
+			// Keep what ran so far: a failing turn is part of the evidence.
+			replayRun.Error = fmt.Sprintf("turn %d: %v", i+1, err)
+			break
+		}
+	}
+
+	msgs, err = app.Messages.List(ctx, sess.ID)
+	if err != nil {
+		return replay.Report{}, fmt.Errorf("failed to list messages: %w", err)
+	}
+	if sess, err = app.Sessions.Get(ctx, sess.ID); err != nil {
+		return replay.Report{}, fmt.Errorf("failed to get session: %w", err)
+	}
+	replayRun.Model = replay.Model(msgs)
+	replayRun.PromptTokens = sess.PromptTokens
+	replayRun.CompletionTokens = sess.CompletionTokens
+	replayRun.Cost = sess.Cost
+	replayRun.Turns = replay.Turns(msgs)
+	return replay.Compare(originalRun, replayRun), nil
+}
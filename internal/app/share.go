@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/charmbracelet/crush/internal/export"
+	"github.com/charmbracelet/crush/internal/redact"
+	"github.com/charmbracelet/crush/internal/share"
+)
+
+// sharesDir is where sessions shared without an endpoint are written, in
+// the data directory.
+const sharesDir = "shares"
+
+// ShareDestination describes where [App.ShareSession] publishes to, for the
+// user to confirm: the host of the configured endpoint, with upload set, or
+// the directory the page is written to.
+func (app *App) ShareDestination() (destination string, upload bool) {
+	if opts := app.config.Options.Share; opts != nil && opts.URL != "" {
+		if u, err := url.Parse(opts.URL); err == nil && u.Host != "" {
+			return u.Host, true
+		}
+		return opts.URL, true
+	}
+	return filepath.Join(app.config.Options.DataDirectory, sharesDir), false
+}
+
+// ShareSession publishes the transcript of the session as a standalone HTML
+// page, with secrets masked even when redaction is disabled, and returns
+// its link. The page is uploaded to the endpoint of options.share, or
+// written to the data directory when there is none.
+func (app *App) ShareSession(ctx context.Context, sessionID string) (string, error) {
+	sess, err := app.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	messages, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	page, err := export.RenderRedacted(export.HTML, sess, messages, redact.NewForPublishing(app.config).Redact)
+	if err != nil {
+		return "", err
+	}
+
+	opts := app.config.Options.Share
+	if opts == nil || opts.URL == "" {
+		return share.WriteFile(filepath.Join(app.config.Options.DataDirectory, sharesDir), sess.ID, page)
+	}
+	headers := make(map[string]string, len(opts.Headers))
+	for k, v := range opts.Headers {
+		value, err := app.config.Resolve(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve share header %s: %w", k, err)
+		}
+		headers[k] = value
+	}
+	return share.Publish(ctx, nil, opts.URL, headers, page)
+}
@@ -0,0 +1,183 @@
+// Package artifact keeps the reports, diagrams and data the agent generates
+// for the user in a directory per session, out of the workspace, until the
+// user exports them.
+package artifact
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+var (
+	// ErrInvalidName is returned for names that would leave the artifacts
+	// directory of the session.
+	ErrInvalidName = errors.New("artifact name must be a relative path inside the artifacts directory")
+	// ErrExists is returned when exporting over a file that already exists.
+	ErrExists = errors.New("file already exists")
+)
+
+// Artifact is a file generated by the agent.
+type Artifact struct {
+	SessionID string    `json:"session_id"`
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+}
+
+// Store holds artifacts on disk. It publishes an event whenever an artifact
+// is emitted.
+type Store struct {
+	*pubsub.Broker[Artifact]
+
+	dir string
+}
+
+// New creates a store keeping the artifacts of each session in a directory
+// of dir.
+func New(dir string) *Store {
+	return &Store{
+		Broker: pubsub.NewBroker[Artifact](),
+		dir:    dir,
+	}
+}
+
+// Dir returns the directory the artifacts of a session are kept in. It is
+// created on the first artifact.
+func (s *Store) Dir(sessionID string) string {
+	return filepath.Join(s.dir, sessionID)
+}
+
+// Emit writes the artifact name of a session from r, replacing any artifact
+// of that name.
+func (s *Store) Emit(sessionID, name string, r io.Reader) (Artifact, error) {
+	path, err := s.path(sessionID, name)
+	if err != nil {
+		return Artifact{}, err
+	}
+	_, statErr := os.Stat(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return Artifact{}, fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return Artifact{}, fmt.Errorf("failed to create artifact: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close() //nolint:errcheck
+		return Artifact{}, fmt.Errorf("failed to write artifact: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return Artifact{}, fmt.Errorf("failed to write artifact: %w", err)
+	}
+
+	a, err := s.stat(sessionID, path)
+	if err != nil {
+		return Artifact{}, err
+	}
+	if statErr == nil {
+		s.Publish(pubsub.UpdatedEvent, a)
+	} else {
+		s.Publish(pubsub.CreatedEvent, a)
+	}
+	return a, nil
+}
+
+// List returns the artifacts of a session, most recent first.
+func (s *Store) List(sessionID string) ([]Artifact, error) {
+	root := s.Dir(sessionID)
+	var artifacts []Artifact
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == root {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		a, err := s.stat(sessionID, path)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, a)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	slices.SortStableFunc(artifacts, func(a, b Artifact) int {
+		if c := b.ModTime.Compare(a.ModTime); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return artifacts, nil
+}
+
+// Export copies a to dir, under its name, and returns where it was copied.
+// It never overwrites a file, failing with ErrExists instead.
+func (s *Store) Export(a Artifact, dir string) (string, error) {
+	dest := filepath.Join(dir, filepath.FromSlash(a.Name))
+	if _, err := os.Stat(dest); err == nil {
+		return "", fmt.Errorf("%s: %w", dest, ErrExists)
+	}
+	src, err := os.Open(a.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open artifact: %w", err)
+	}
+	defer src.Close() //nolint:errcheck
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create parent directories: %w", err)
+	}
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close() //nolint:errcheck
+		return "", fmt.Errorf("failed to export artifact: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return "", fmt.Errorf("failed to export artifact: %w", err)
+	}
+	return dest, nil
+}
+
+// path returns where the artifact name of a session is kept.
+func (s *Store) path(sessionID, name string) (string, error) {
+	name = filepath.ToSlash(strings.TrimSpace(name))
+	if name == "" || !filepath.IsLocal(filepath.FromSlash(name)) || strings.HasSuffix(name, "/") {
+		return "", fmt.Errorf("%q: %w", name, ErrInvalidName)
+	}
+	return filepath.Join(s.Dir(sessionID), filepath.FromSlash(name)), nil
+}
+
+func (s *Store) stat(sessionID, path string) (Artifact, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Artifact{}, fmt.Errorf("failed to stat artifact: %w", err)
+	}
+	rel, err := filepath.Rel(s.Dir(sessionID), path)
+	if err != nil {
+		return Artifact{}, err
+	}
+	return Artifact{
+		SessionID: sessionID,
+		Name:      filepath.ToSlash(rel),
+		Path:      path,
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+	}, nil
+}
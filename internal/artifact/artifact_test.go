@@ -0,0 +1,66 @@
+package artifact
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitAndList(t *testing.T) {
+	t.Parallel()
+
+	store := New(t.TempDir())
+	report, err := store.Emit("session", "report.md", strings.NewReader("# Report\n"))
+	require.NoError(t, err)
+	require.Equal(t, "report.md", report.Name)
+	require.Equal(t, int64(9), report.Size)
+	require.Equal(t, filepath.Join(store.Dir("session"), "report.md"), report.Path)
+
+	diagram, err := store.Emit("session", "diagrams/flow.png", strings.NewReader("png"))
+	require.NoError(t, err)
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(diagram.Path, later, later))
+
+	artifacts, err := store.List("session")
+	require.NoError(t, err)
+	require.Len(t, artifacts, 2)
+	require.Equal(t, "diagrams/flow.png", artifacts[0].Name, "most recent first")
+	require.Equal(t, "report.md", artifacts[1].Name)
+
+	empty, err := store.List("other")
+	require.NoError(t, err)
+	require.Empty(t, empty)
+}
+
+func TestEmitInvalidName(t *testing.T) {
+	t.Parallel()
+
+	store := New(t.TempDir())
+	for _, name := range []string{"", "../escape.md", "/etc/passwd", "dir/"} {
+		_, err := store.Emit("session", name, strings.NewReader("x"))
+		require.ErrorIs(t, err, ErrInvalidName, name)
+	}
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	store := New(t.TempDir())
+	a, err := store.Emit("session", "data/out.csv", strings.NewReader("a,b\n"))
+	require.NoError(t, err)
+
+	workspace := t.TempDir()
+	dest, err := store.Export(a, workspace)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(workspace, "data", "out.csv"), dest)
+	content, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	require.Equal(t, "a,b\n", string(content))
+
+	_, err = store.Export(a, workspace)
+	require.ErrorIs(t, err, ErrExists)
+}
@@ -0,0 +1,193 @@
+// Package audit records every tool execution in an append-only JSONL log
+// for compliance review of changes made by the agent.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+const (
+	filePrefix = "audit-"
+	fileSuffix = ".jsonl"
+	dayLayout  = "2006-01-02"
+)
+
+// Permission decisions recorded in [Entry.Permission].
+const (
+	PermissionNotRequested = "not_requested"
+	PermissionGranted      = "granted"
+	PermissionDenied       = "denied"
+	PermissionAutoApproved = "auto_approved"
+	PermissionCanceled     = "canceled"
+)
+
+// Entry is one line of the audit log.
+type Entry struct {
+	Time         time.Time       `json:"time"`
+	SessionID    string          `json:"session_id,omitempty"`
+	ToolCallID   string          `json:"tool_call_id"`
+	Tool         string          `json:"tool"`
+	Input        json.RawMessage `json:"input"`
+	Permission   string          `json:"permission"`
+	DurationMS   int64           `json:"duration_ms"`
+	ResultSHA256 string          `json:"result_sha256"`
+	IsError      bool            `json:"is_error,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// Log appends entries to one file per day and deletes files past the
+// retention period. A nil *Log records nothing.
+type Log struct {
+	dir       string
+	retention time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	day       string
+	file      *os.File
+	decisions map[string]string
+}
+
+// Open returns the audit log configured in cfg, or nil when auditing is
+// disabled.
+func Open(cfg *config.Config) (*Log, error) {
+	if cfg.Audit == nil || !cfg.Audit.Enabled {
+		return nil, nil
+	}
+	dir := cfg.Audit.Path
+	if dir == "" {
+		dir = filepath.Join(cfg.Options.DataDirectory, "audit")
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(cfg.WorkingDir(), dir)
+	}
+	return open(dir, time.Duration(cfg.Audit.RetentionDays)*24*time.Hour, time.Now)
+}
+
+func open(dir string, retention time.Duration, now func() time.Time) (*Log, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	return &Log{
+		dir:       dir,
+		retention: retention,
+		now:       now,
+		decisions: make(map[string]string),
+	}, nil
+}
+
+// Write appends entry to the log of the day it happened.
+func (l *Log) Write(entry Entry) error {
+	if l == nil {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.rotate(entry.Time.Format(dayLayout)); err != nil {
+		return err
+	}
+	_, err = l.file.Write(line)
+	return err
+}
+
+// rotate makes sure the file of day is open, pruning old files whenever a
+// new one is started.
+func (l *Log) rotate(day string) error {
+	if l.file != nil && l.day == day {
+		return nil
+	}
+	if l.file != nil {
+		_ = l.file.Close()
+		l.file = nil
+	}
+	f, err := os.OpenFile(filepath.Join(l.dir, filePrefix+day+fileSuffix), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	l.file = f
+	l.day = day
+	l.prune()
+	return nil
+}
+
+func (l *Log) prune() {
+	if l.retention <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		slog.Warn("Failed to list audit logs", "error", err)
+		return
+	}
+	cutoff := l.now().Add(-l.retention).Format(dayLayout)
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		day := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix)
+		if _, err := time.Parse(dayLayout, day); err != nil || day >= cutoff {
+			continue
+		}
+		if err := os.Remove(filepath.Join(l.dir, name)); err != nil {
+			slog.Warn("Failed to delete expired audit log", "file", name, "error", err)
+		}
+	}
+}
+
+// Close closes the current log file.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// decide records a permission decision for a tool call. Tools may ask more
+// than once, so a denial is never overwritten by a later grant.
+func (l *Log) decide(toolCallID, decision string) {
+	if toolCallID == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch l.decisions[toolCallID] {
+	case PermissionDenied, PermissionCanceled:
+		return
+	}
+	l.decisions[toolCallID] = decision
+}
+
+// decision returns and forgets the permission decision of a tool call.
+func (l *Log) decision(toolCallID string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	d, ok := l.decisions[toolCallID]
+	if !ok {
+		return PermissionNotRequested
+	}
+	delete(l.decisions, toolCallID)
+	return d
+}
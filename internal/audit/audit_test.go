@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+func readEntries(t *testing.T, path string) []Entry {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		entries = append(entries, e)
+	}
+	require.NoError(t, scanner.Err())
+	return entries
+}
+
+func TestLogRotationAndRetention(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	old := filepath.Join(dir, "audit-2026-01-01.jsonl")
+	unrelated := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(old, []byte("{}\n"), 0o600))
+	require.NoError(t, os.WriteFile(unrelated, nil, 0o600))
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	l, err := open(dir, 30*24*time.Hour, func() time.Time { return now })
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, l.Close()) })
+
+	require.NoError(t, l.Write(Entry{Time: now, Tool: "view", Input: json.RawMessage(`{}`)}))
+	require.NoError(t, l.Write(Entry{Time: now.Add(24 * time.Hour), Tool: "edit", Input: json.RawMessage(`{}`)}))
+
+	require.NoFileExists(t, old)
+	require.FileExists(t, unrelated)
+	require.Len(t, readEntries(t, filepath.Join(dir, "audit-2026-03-10.jsonl")), 1)
+	entries := readEntries(t, filepath.Join(dir, "audit-2026-03-11.jsonl"))
+	require.Len(t, entries, 1)
+	require.Equal(t, "edit", entries[0].Tool)
+}
+
+func TestNilLog(t *testing.T) {
+	t.Parallel()
+	var l *Log
+	require.NoError(t, l.Write(Entry{}))
+	require.NoError(t, l.Close())
+	svc := permission.NewPermissionService(t.TempDir(), true, nil, nil, "")
+	require.Same(t, svc, l.WrapPermissions(svc))
+}
+
+type fakePermissions struct {
+	permission.Service
+	granted bool
+	err     error
+}
+
+func (f fakePermissions) Request(context.Context, permission.CreatePermissionRequest) (bool, error) {
+	return f.granted, f.err
+}
+
+func (fakePermissions) SkipRequests() bool { return false }
+
+type echoInput struct {
+	Ask bool `json:"ask"`
+}
+
+func TestWrapTools(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	l, err := open(dir, 0, func() time.Time { return now })
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, l.Close()) })
+
+	run := func(t *testing.T, perms permission.Service, call fantasy.ToolCall) {
+		t.Helper()
+		perms = l.WrapPermissions(perms)
+		tool := l.WrapTools([]fantasy.AgentTool{
+			fantasy.NewAgentTool("echo", "Echo", func(ctx context.Context, input echoInput, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+				if input.Ask {
+					ok, err := perms.Request(ctx, permission.CreatePermissionRequest{ToolCallID: call.ID, ToolName: "echo"})
+					if err != nil {
+						return fantasy.ToolResponse{}, err
+					}
+					if !ok {
+						return fantasy.NewTextErrorResponse("denied"), nil
+					}
+				}
+				return fantasy.NewTextResponse("ok"), nil
+			}),
+		})[0]
+		ctx := context.WithValue(t.Context(), tools.SessionIDContextKey, "session-1")
+		_, _ = tool.Run(ctx, call)
+	}
+
+	run(t, fakePermissions{}, fantasy.ToolCall{ID: "1", Name: "echo", Input: `{}`})
+	run(t, fakePermissions{granted: true}, fantasy.ToolCall{ID: "2", Name: "echo", Input: `{"ask":true}`})
+	run(t, fakePermissions{}, fantasy.ToolCall{ID: "3", Name: "echo", Input: `{"ask":true}`})
+	run(t, fakePermissions{err: context.Canceled}, fantasy.ToolCall{ID: "4", Name: "echo", Input: `{"ask":true}`})
+	run(t, permission.NewPermissionService(dir, true, nil, nil, ""), fantasy.ToolCall{ID: "5", Name: "echo", Input: `{"ask":true}`})
+	run(t, fakePermissions{}, fantasy.ToolCall{ID: "6", Name: "echo", Input: `not json`})
+
+	entries := readEntries(t, filepath.Join(dir, "audit-2026-03-10.jsonl"))
+	require.Len(t, entries, 6)
+	for _, e := range entries {
+		require.Equal(t, "session-1", e.SessionID)
+		require.Equal(t, "echo", e.Tool)
+		require.Len(t, e.ResultSHA256, 64)
+	}
+	require.Equal(t, PermissionNotRequested, entries[0].Permission)
+	require.JSONEq(t, `{}`, string(entries[0].Input))
+	require.Equal(t, PermissionGranted, entries[1].Permission)
+	require.JSONEq(t, `{"ask":true}`, string(entries[1].Input))
+	require.Equal(t, PermissionDenied, entries[2].Permission)
+	require.True(t, entries[2].IsError)
+	require.Equal(t, PermissionCanceled, entries[3].Permission)
+	require.Equal(t, PermissionAutoApproved, entries[4].Permission)
+	require.JSONEq(t, `"not json"`, string(entries[5].Input))
+	require.NotEqual(t, entries[0].ResultSHA256, entries[2].ResultSHA256)
+}
+
+func TestDecisionKeepsDenial(t *testing.T) {
+	t.Parallel()
+	l, err := open(t.TempDir(), 0, time.Now)
+	require.NoError(t, err)
+	l.decide("call", PermissionGranted)
+	l.decide("call", PermissionDenied)
+	l.decide("call", PermissionGranted)
+	require.Equal(t, PermissionDenied, l.decision("call"))
+	require.Equal(t, PermissionNotRequested, l.decision("call"))
+}
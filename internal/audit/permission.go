@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// auditedPermissions records the outcome of every permission request so it
+// can be attached to the audit entry of the tool call that asked.
+type auditedPermissions struct {
+	permission.Service
+	log *Log
+}
+
+// WrapPermissions records the decisions made by svc. It returns svc as is
+// when l is nil.
+func (l *Log) WrapPermissions(svc permission.Service) permission.Service {
+	if l == nil {
+		return svc
+	}
+	return &auditedPermissions{Service: svc, log: l}
+}
+
+func (p *auditedPermissions) Request(ctx context.Context, opts permission.CreatePermissionRequest) (bool, error) {
+	granted, err := p.Service.Request(ctx, opts)
+	switch {
+	case err != nil:
+		p.log.decide(opts.ToolCallID, PermissionCanceled)
+	case !granted:
+		p.log.decide(opts.ToolCallID, PermissionDenied)
+	case p.SkipRequests():
+		p.log.decide(opts.ToolCallID, PermissionAutoApproved)
+	default:
+		p.log.decide(opts.ToolCallID, PermissionGranted)
+	}
+	return granted, err
+}
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+)
+
+// auditedTool wraps an agent tool with an audit entry per execution.
+type auditedTool struct {
+	fantasy.AgentTool
+	log *Log
+}
+
+// WrapTools records the executions of every tool in tools in place and
+// returns the slice. It does nothing when l is nil.
+func (l *Log) WrapTools(agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	if l == nil {
+		return agentTools
+	}
+	for i, tool := range agentTools {
+		if _, ok := tool.(*auditedTool); !ok {
+			agentTools[i] = &auditedTool{AgentTool: tool, log: l}
+		}
+	}
+	return agentTools
+}
+
+func (t *auditedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	start := t.log.now()
+	resp, err := t.AgentTool.Run(ctx, call)
+
+	entry := Entry{
+		Time:         start.UTC(),
+		SessionID:    tools.GetSessionFromContext(ctx),
+		ToolCallID:   call.ID,
+		Tool:         t.Info().Name,
+		Input:        rawInput(call.Input),
+		Permission:   t.log.decision(call.ID),
+		DurationMS:   t.log.now().Sub(start).Milliseconds(),
+		ResultSHA256: resultHash(resp),
+		IsError:      resp.IsError,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if werr := t.log.Write(entry); werr != nil {
+		slog.Error("Failed to write audit log entry", "tool", entry.Tool, "error", werr)
+	}
+	return resp, err
+}
+
+// rawInput keeps the tool arguments as JSON, falling back to a string when
+// the model sent something that does not parse.
+func rawInput(input string) json.RawMessage {
+	if input == "" {
+		return json.RawMessage("{}")
+	}
+	if json.Valid([]byte(input)) {
+		return json.RawMessage(input)
+	}
+	quoted, _ := json.Marshal(input)
+	return quoted
+}
+
+// resultHash identifies the result returned to the model without storing
+// it, which may be large or sensitive.
+func resultHash(resp fantasy.ToolResponse) string {
+	h := sha256.New()
+	h.Write([]byte(resp.Content))
+	h.Write(resp.Data)
+	return hex.EncodeToString(h.Sum(nil))
+}
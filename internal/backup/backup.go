@@ -0,0 +1,344 @@
+// Package backup mirrors the transcripts of sessions into plain-text files
+// outside the database, such as a synced notes folder. Each finished
+// message is appended once to the Markdown and JSONL files of its session.
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+const (
+	markdownSuffix = ".md"
+	jsonlSuffix    = ".jsonl"
+)
+
+// maxResultLength is how much of each tool result the Markdown transcript
+// keeps; the JSONL transcript keeps all of it.
+const maxResultLength = 4000
+
+// markerPattern matches the comments marking the messages of the Markdown
+// transcript.
+var markerPattern = regexp.MustCompile(`^<!-- message:(\S+) -->$`)
+
+// Backup appends the messages of sessions to their transcript files. A nil
+// *Backup writes nothing.
+type Backup struct {
+	dir      string
+	formats  []config.BackupFormat
+	sessions session.Service
+	now      func() time.Time
+
+	mu sync.Mutex
+	// written holds the IDs of the messages already in the transcript of
+	// each session, loaded from the files the first time it is written to.
+	written map[string]map[string]bool
+}
+
+// Open returns the backup configured in cfg, or nil when it is disabled.
+func Open(cfg *config.Config, sessions session.Service) (*Backup, error) {
+	if cfg.Backup == nil || !cfg.Backup.Enabled {
+		return nil, nil
+	}
+	dir := home.Long(cfg.Backup.Path)
+	if dir == "" {
+		dir = filepath.Join(cfg.Options.DataDirectory, "backup")
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(cfg.WorkingDir(), dir)
+	}
+	formats := cfg.Backup.Formats
+	if len(formats) == 0 {
+		formats = []config.BackupFormat{config.BackupMarkdown, config.BackupJSONL}
+	}
+	return open(dir, formats, sessions, time.Now)
+}
+
+func open(dir string, formats []config.BackupFormat, sessions session.Service, now func() time.Time) (*Backup, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	return &Backup{
+		dir:      dir,
+		formats:  formats,
+		sessions: sessions,
+		now:      now,
+		written:  make(map[string]map[string]bool),
+	}, nil
+}
+
+// Run records the messages of events until ctx is done or events is
+// closed.
+func (b *Backup) Run(ctx context.Context, events <-chan pubsub.Event[message.Message]) {
+	if b == nil {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.Type == pubsub.DeletedEvent {
+				continue
+			}
+			if err := b.Record(ctx, event.Payload); err != nil {
+				slog.Warn("Failed to back up message", "session_id", event.Payload.SessionID, "error", err)
+			}
+		}
+	}
+}
+
+// Record appends msg to the transcripts of its session once it is finished.
+// Messages already in the transcripts and those of sub-agent sessions are
+// skipped.
+func (b *Backup) Record(ctx context.Context, msg message.Message) error {
+	if b == nil || !complete(msg) {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	written, ok := b.written[msg.SessionID]
+	if !ok {
+		sess, err := b.sessions.Get(ctx, msg.SessionID)
+		if err != nil {
+			return err
+		}
+		if sess.ParentSessionID != "" {
+			written = nil
+		} else if written, err = b.load(sess); err != nil {
+			return err
+		}
+		b.written[msg.SessionID] = written
+	}
+	if written == nil || written[msg.ID] {
+		return nil
+	}
+
+	for _, format := range b.formats {
+		var err error
+		switch format {
+		case config.BackupMarkdown:
+			err = b.append(msg.SessionID+markdownSuffix, markdown(msg))
+		case config.BackupJSONL:
+			var line []byte
+			line, err = json.Marshal(newEntry(msg))
+			if err == nil {
+				err = b.append(msg.SessionID+jsonlSuffix, string(line)+"\n")
+			}
+		default:
+			err = fmt.Errorf("unknown backup format %q", format)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	written[msg.ID] = true
+	return nil
+}
+
+// complete reports whether msg is done changing: assistant messages are
+// streamed until their finish part.
+func complete(msg message.Message) bool {
+	switch msg.Role {
+	case message.User, message.Tool:
+		return true
+	case message.Assistant:
+		return msg.IsFinished()
+	default:
+		return false
+	}
+}
+
+// load returns the IDs of the messages already in the transcripts of sess,
+// starting its Markdown transcript with a heading when it is new.
+func (b *Backup) load(sess session.Session) (map[string]bool, error) {
+	written := make(map[string]bool)
+	if slices.Contains(b.formats, config.BackupJSONL) {
+		if err := scan(filepath.Join(b.dir, sess.ID+jsonlSuffix), func(line string) {
+			var e struct {
+				ID string `json:"id"`
+			}
+			if json.Unmarshal([]byte(line), &e) == nil && e.ID != "" {
+				written[e.ID] = true
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if slices.Contains(b.formats, config.BackupMarkdown) {
+		path := filepath.Join(b.dir, sess.ID+markdownSuffix)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := b.append(sess.ID+markdownSuffix, heading(sess, b.now())); err != nil {
+				return nil, err
+			}
+		}
+		if err := scan(path, func(line string) {
+			if m := markerPattern.FindStringSubmatch(line); m != nil {
+				written[m[1]] = true
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return written, nil
+}
+
+// scan calls fn with every line of the file at path, if it exists.
+func scan(path string, fn func(string)) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+func (b *Backup) append(name, text string) error {
+	f, err := os.OpenFile(filepath.Join(b.dir, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open transcript: %w", err)
+	}
+	_, err = f.WriteString(text)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Entry is one line of the JSONL transcript.
+type Entry struct {
+	ID          string               `json:"id"`
+	SessionID   string               `json:"session_id"`
+	Role        message.MessageRole  `json:"role"`
+	Time        time.Time            `json:"time"`
+	Model       string               `json:"model,omitempty"`
+	Provider    string               `json:"provider,omitempty"`
+	Text        string               `json:"text,omitempty"`
+	Reasoning   string               `json:"reasoning,omitempty"`
+	Attachments []string             `json:"attachments,omitempty"`
+	ToolCalls   []message.ToolCall   `json:"tool_calls,omitempty"`
+	ToolResults []message.ToolResult `json:"tool_results,omitempty"`
+	Finish      message.FinishReason `json:"finish_reason,omitempty"`
+	Summary     bool                 `json:"summary,omitempty"`
+}
+
+func newEntry(msg message.Message) Entry {
+	e := Entry{
+		ID:          msg.ID,
+		SessionID:   msg.SessionID,
+		Role:        msg.Role,
+		Time:        time.Unix(msg.CreatedAt, 0).UTC(),
+		Model:       msg.Model,
+		Provider:    msg.Provider,
+		Text:        msg.Content().Text,
+		Reasoning:   msg.ReasoningContent().Thinking,
+		Attachments: attachments(msg),
+		ToolCalls:   msg.ToolCalls(),
+		ToolResults: msg.ToolResults(),
+		Summary:     msg.IsSummaryMessage,
+	}
+	if msg.Role == message.Assistant {
+		e.Finish = msg.FinishReason()
+	}
+	for i := range e.ToolResults {
+		// Images and other binary results are left out of the
+		// transcript.
+		e.ToolResults[i].Data = ""
+	}
+	return e
+}
+
+func attachments(msg message.Message) []string {
+	var names []string
+	for _, bc := range msg.BinaryContent() {
+		names = append(names, bc.Path)
+	}
+	return names
+}
+
+// heading starts the Markdown transcript of sess.
+func heading(sess session.Session, now time.Time) string {
+	return fmt.Sprintf("# %s\n\nSession %s, started %s.\n", sess.Title, sess.ID, now.Format("2006-01-02 15:04"))
+}
+
+// markdown renders msg as a section of the Markdown transcript, marked with
+// its ID.
+func markdown(msg message.Message) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n<!-- message:%s -->\n", msg.ID)
+	at := time.Unix(msg.CreatedAt, 0).Format("2006-01-02 15:04")
+	switch msg.Role {
+	case message.User:
+		fmt.Fprintf(&sb, "## User · %s\n", at)
+	case message.Assistant:
+		title := "Assistant"
+		if msg.IsSummaryMessage {
+			title = "Summary"
+		}
+		if msg.Model != "" {
+			title += " (" + msg.Model + ")"
+		}
+		fmt.Fprintf(&sb, "## %s · %s\n", title, at)
+	case message.Tool:
+		sb.WriteString("### Tool results\n")
+	}
+
+	if text := strings.TrimSpace(msg.Content().Text); text != "" {
+		fmt.Fprintf(&sb, "\n%s\n", text)
+	}
+	if names := attachments(msg); len(names) > 0 {
+		fmt.Fprintf(&sb, "\nAttached: %s\n", strings.Join(names, ", "))
+	}
+	for _, call := range msg.ToolCalls() {
+		fmt.Fprintf(&sb, "\n- Called `%s`: `%s`\n", call.Name, oneLine(call.Input))
+	}
+	for _, result := range msg.ToolResults() {
+		status := "Result"
+		if result.IsError {
+			status = "Error"
+		}
+		content := result.Content
+		if len(content) > maxResultLength {
+			content = content[:maxResultLength] + fmt.Sprintf("\n… %d more bytes", len(result.Content)-maxResultLength)
+		}
+		fence := "```"
+		for strings.Contains(content, fence) {
+			fence += "`"
+		}
+		fmt.Fprintf(&sb, "\n%s of `%s`:\n\n%s\n%s\n%s\n", status, result.Name, fence, content, fence)
+	}
+	return sb.String()
+}
+
+// oneLine puts s on one line for inline code.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(s, "`", "'")), " ")
+}
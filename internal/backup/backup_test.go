@@ -0,0 +1,103 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+// sessionService returns the sessions it holds.
+type sessionService struct {
+	session.Service
+	sessions map[string]session.Session
+}
+
+func (s sessionService) Get(_ context.Context, id string) (session.Session, error) {
+	return s.sessions[id], nil
+}
+
+func newBackup(t *testing.T, dir string) *Backup {
+	t.Helper()
+	sessions := sessionService{sessions: map[string]session.Session{
+		"s1":    {ID: "s1", Title: "Fix the parser"},
+		"child": {ID: "child", ParentSessionID: "s1"},
+	}}
+	now := func() time.Time { return time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC) }
+	b, err := open(dir, []config.BackupFormat{config.BackupMarkdown, config.BackupJSONL}, sessions, now)
+	require.NoError(t, err)
+	return b
+}
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	user := message.Message{ID: "m1", SessionID: "s1", Role: message.User, Parts: []message.ContentPart{
+		message.TextContent{Text: "fix the parser"},
+	}}
+	streaming := message.Message{ID: "m2", SessionID: "s1", Role: message.Assistant, Model: "model", Parts: []message.ContentPart{
+		message.TextContent{Text: "Let me"},
+	}}
+	answer := streaming
+	answer.Parts = []message.ContentPart{
+		message.TextContent{Text: "Let me look."},
+		message.ToolCall{ID: "c1", Name: "view", Input: `{"file_path": "parser.go"}`, Finished: true},
+		message.Finish{Reason: message.FinishReasonToolUse},
+	}
+	result := message.Message{ID: "m3", SessionID: "s1", Role: message.Tool, Parts: []message.ContentPart{
+		message.ToolResult{ToolCallID: "c1", Name: "view", Content: "package parser"},
+	}}
+
+	b := newBackup(t, dir)
+	for _, msg := range []message.Message{user, streaming, answer, answer, result} {
+		require.NoError(t, b.Record(t.Context(), msg))
+	}
+	// A restart finds the messages already in the transcripts.
+	b = newBackup(t, dir)
+	for _, msg := range []message.Message{user, answer, result} {
+		require.NoError(t, b.Record(t.Context(), msg))
+	}
+
+	md, err := os.ReadFile(filepath.Join(dir, "s1.md"))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(md), "# Fix the parser\n\nSession s1, started 2026-10-14 09:30.\n"))
+	require.Equal(t, 1, strings.Count(string(md), "<!-- message:m2 -->"))
+	require.Contains(t, string(md), "## Assistant (model)")
+	require.Contains(t, string(md), "- Called `view`: `{\"file_path\": \"parser.go\"}`")
+	require.Contains(t, string(md), "Result of `view`:\n\n```\npackage parser\n```")
+
+	jsonl, err := os.ReadFile(filepath.Join(dir, "s1.jsonl"))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(jsonl)), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[1], `"finish_reason":"tool_use"`)
+	require.Contains(t, lines[1], `"text":"Let me look."`)
+}
+
+func TestRecordSkipsSubAgents(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	b := newBackup(t, dir)
+	require.NoError(t, b.Record(t.Context(), message.Message{ID: "m1", SessionID: "child", Role: message.User}))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestOpenDisabled(t *testing.T) {
+	t.Parallel()
+
+	b, err := Open(&config.Config{}, nil)
+	require.NoError(t, err)
+	require.Nil(t, b)
+	require.NoError(t, b.Record(t.Context(), message.Message{Role: message.User}))
+}
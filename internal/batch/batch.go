@@ -0,0 +1,88 @@
+// Package batch turns a set of search matches and one instruction into a
+// task for the agent that applies the instruction at every match, like
+// editing with several cursors at once.
+package batch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Site is a place in the code the instruction applies to.
+type Site struct {
+	Path string
+	// Line is the 1-based line of the match, or zero when the search only
+	// named the file.
+	Line int
+	// Code is the matched line as the search showed it.
+	Code string
+}
+
+// Location returns path:line, or the path alone for a whole file.
+func (s Site) Location() string {
+	if s.Line == 0 {
+		return s.Path
+	}
+	return fmt.Sprintf("%s:%d", s.Path, s.Line)
+}
+
+// ParseGrep reads the sites out of the output of the grep tool: a path
+// followed by its matching lines, one file after the other.
+func ParseGrep(output string) []Site {
+	var sites []Site
+	path := ""
+	for line := range strings.Lines(output) {
+		line = strings.TrimRight(line, "\r\n")
+		indented, ok := strings.CutPrefix(line, "  ")
+		if !ok {
+			// Paths end in a colon; the count above them and the note on
+			// truncated results do not.
+			if p, ok := strings.CutSuffix(line, ":"); ok && p != "" {
+				path = p
+			} else {
+				path = ""
+			}
+			continue
+		}
+		if path == "" {
+			continue
+		}
+		rest, ok := strings.CutPrefix(indented, "Line ")
+		if !ok {
+			// Searches that only list files repeat the path.
+			sites = append(sites, Site{Path: path})
+			continue
+		}
+		head, code, ok := strings.Cut(rest, ": ")
+		if !ok {
+			head, code = strings.TrimSuffix(rest, ":"), ""
+		}
+		num, _, _ := strings.Cut(head, ",")
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			continue
+		}
+		sites = append(sites, Site{Path: path, Line: n, Code: code})
+	}
+	return sites
+}
+
+// Task returns a prompt that has the agent apply instruction at each site,
+// in order, and nowhere else.
+func Task(instruction string, sites []Site) string {
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSpace(instruction))
+	sb.WriteString("\n")
+	fmt.Fprintf(&sb, "<system_info>Apply the instruction above at each of the %d sites below, the user picked them from search results. Treat every site on its own, leave other matches alone, and say which sites you could not change and why.</system_info>\n", len(sites))
+	sb.WriteString("<sites>\n")
+	for _, s := range sites {
+		if s.Line == 0 {
+			fmt.Fprintf(&sb, "<site path='%s'/>\n", s.Path)
+			continue
+		}
+		fmt.Fprintf(&sb, "<site path='%s' line='%d'>%s</site>\n", s.Path, s.Line, s.Code)
+	}
+	sb.WriteString("</sites>\n")
+	return sb.String()
+}
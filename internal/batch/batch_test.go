@@ -0,0 +1,56 @@
+package batch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGrep(t *testing.T) {
+	t.Parallel()
+
+	output := `Found 4 matches
+internal/app/app.go:
+  Line 12, Char 3: client.Do(req)
+  Line 40: resp, err := client.Do(req)
+
+internal/lsp/client.go:
+  Line 7: return c.Do(r)
+
+internal/empty.go:
+  internal/empty.go
+
+(Results are truncated. Consider using a more specific path or pattern.)`
+
+	require.Equal(t, []Site{
+		{Path: "internal/app/app.go", Line: 12, Code: "client.Do(req)"},
+		{Path: "internal/app/app.go", Line: 40, Code: "resp, err := client.Do(req)"},
+		{Path: "internal/lsp/client.go", Line: 7, Code: "return c.Do(r)"},
+		{Path: "internal/empty.go"},
+	}, ParseGrep(output))
+
+	require.Empty(t, ParseGrep("No files found"))
+}
+
+func TestTask(t *testing.T) {
+	t.Parallel()
+
+	got := Task(" wrap each of these calls with retry\n", []Site{
+		{Path: "a.go", Line: 12, Code: "client.Do(req)"},
+		{Path: "b.go"},
+	})
+	require.Equal(t, `wrap each of these calls with retry
+<system_info>Apply the instruction above at each of the 2 sites below, the user picked them from search results. Treat every site on its own, leave other matches alone, and say which sites you could not change and why.</system_info>
+<sites>
+<site path='a.go' line='12'>client.Do(req)</site>
+<site path='b.go'/>
+</sites>
+`, got)
+}
+
+func TestLocation(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "a.go:12", Site{Path: "a.go", Line: 12}.Location())
+	require.Equal(t, "a.go", Site{Path: "a.go"}.Location())
+}
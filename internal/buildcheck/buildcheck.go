@@ -0,0 +1,259 @@
+// Package buildcheck runs fast compile checks on the packages the agent
+// edits so breakage is reported back before the agent considers a task done.
+package buildcheck
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/home"
+)
+
+const (
+	// FilePlaceholder is replaced with the edited file path in check
+	// arguments.
+	FilePlaceholder = "{file}"
+	// DirPlaceholder is replaced with the edited file's directory, relative
+	// to the project root and prefixed with "./", in check arguments.
+	DirPlaceholder = "{dir}"
+
+	defaultTimeout = 60 * time.Second
+	maxOutputBytes = 8000
+)
+
+// Defaults are the built-in checks. They run only when their command is
+// installed and a root marker is found above the edited file. Entries in the
+// compile_checks config with the same name override them.
+var Defaults = map[string]config.CompileCheckConfig{
+	"go": {
+		Command:     "go",
+		Args:        []string{"build", "-o", os.DevNull, DirPlaceholder},
+		FileTypes:   []string{"go"},
+		RootMarkers: []string{"go.mod"},
+	},
+	"rust": {
+		Command:     "cargo",
+		Args:        []string{"check", "--quiet", "--message-format", "short"},
+		FileTypes:   []string{"rs"},
+		RootMarkers: []string{"Cargo.toml"},
+	},
+	"typescript": {
+		Command:     "tsc",
+		Args:        []string{"--noEmit", "--pretty", "false"},
+		FileTypes:   []string{"ts", "tsx", "mts", "cts"},
+		RootMarkers: []string{"tsconfig.json"},
+	},
+}
+
+// Runner runs the compile checks matching edited files. A nil Runner is
+// valid and checks nothing.
+type Runner struct {
+	workingDir string
+	resolver   config.VariableResolver
+	checks     []check
+}
+
+type check struct {
+	name string
+	cfg  config.CompileCheckConfig
+}
+
+// New creates a runner from the built-in defaults merged with the
+// compile_checks in cfg. It returns nil when compile checks are disabled or
+// none is available.
+func New(cfg *config.Config) *Runner {
+	if cfg.Options != nil && cfg.Options.DisableCompileChecks {
+		return nil
+	}
+
+	merged := maps.Clone(Defaults)
+	for name, c := range cfg.CompileChecks {
+		merged[name] = mergeCheck(Defaults[name], c)
+	}
+
+	var checks []check
+	for _, name := range slices.Sorted(maps.Keys(merged)) {
+		c := merged[name]
+		if c.Disabled || c.Command == "" {
+			continue
+		}
+		// Built-in checks only run when their toolchain is installed.
+		if _, isDefault := Defaults[name]; isDefault {
+			if _, err := exec.LookPath(c.Command); err != nil {
+				continue
+			}
+		}
+		checks = append(checks, check{name: name, cfg: c})
+	}
+	if len(checks) == 0 {
+		return nil
+	}
+	return &Runner{
+		workingDir: cfg.WorkingDir(),
+		resolver:   cfg.Resolver(),
+		checks:     checks,
+	}
+}
+
+func mergeCheck(base, override config.CompileCheckConfig) config.CompileCheckConfig {
+	if override.Command == "" {
+		override.Command = base.Command
+		if len(override.Args) == 0 {
+			override.Args = base.Args
+		}
+	}
+	if len(override.FileTypes) == 0 {
+		override.FileTypes = base.FileTypes
+	}
+	if len(override.RootMarkers) == 0 {
+		override.RootMarkers = base.RootMarkers
+	}
+	if len(override.Env) == 0 {
+		override.Env = base.Env
+	}
+	if override.Timeout == 0 {
+		override.Timeout = base.Timeout
+	}
+	return override
+}
+
+// invocation is a single check run. Edits to several files in the same
+// package share one invocation.
+type invocation struct {
+	check check
+	root  string
+	args  []string
+}
+
+// Check runs the compile checks for the given edited files and returns their
+// combined error output, or an empty string if everything compiles.
+func (r *Runner) Check(ctx context.Context, paths ...string) string {
+	if r == nil {
+		return ""
+	}
+
+	seen := map[string]bool{}
+	var runs []invocation
+	for _, path := range paths {
+		for _, c := range r.forFile(path) {
+			root, ok := r.projectRoot(c.cfg, path)
+			if !ok {
+				continue
+			}
+			args := expandArgs(c.cfg.Args, root, path)
+			key := strings.Join(append([]string{c.name, root}, args...), "\x00")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			runs = append(runs, invocation{check: c, root: root, args: args})
+		}
+	}
+
+	var out strings.Builder
+	for _, inv := range runs {
+		output, err := r.run(ctx, inv.check, inv.root, inv.args)
+		if err == nil {
+			continue
+		}
+		if output == "" {
+			output = err.Error()
+		}
+		fmt.Fprintf(&out, "<compile_errors check=%q>\n%s\n</compile_errors>\n", inv.check.name, truncate(output))
+	}
+	return out.String()
+}
+
+func (r *Runner) forFile(path string) []check {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if ext == "" {
+		return nil
+	}
+	var matching []check
+	for _, c := range r.checks {
+		if slices.ContainsFunc(c.cfg.FileTypes, func(ft string) bool {
+			return strings.EqualFold(strings.TrimPrefix(ft, "."), ext)
+		}) {
+			matching = append(matching, c)
+		}
+	}
+	return matching
+}
+
+// projectRoot returns the closest directory above path containing one of the
+// check's root markers. Checks without root markers run in the working
+// directory. It reports false when markers are configured but none is found.
+func (r *Runner) projectRoot(cfg config.CompileCheckConfig, path string) (string, bool) {
+	dir := filepath.Dir(path)
+	if len(cfg.RootMarkers) == 0 {
+		return cmp.Or(r.workingDir, dir), true
+	}
+	best := ""
+	for _, marker := range cfg.RootMarkers {
+		found, ok := fsext.LookupClosest(dir, marker)
+		if ok && len(filepath.Dir(found)) > len(best) {
+			best = filepath.Dir(found)
+		}
+	}
+	return best, best != ""
+}
+
+func (r *Runner) run(ctx context.Context, c check, root string, args []string) (string, error) {
+	command, err := r.resolver.ResolveValue(c.cfg.Command)
+	if err != nil {
+		return "", fmt.Errorf("invalid compile check command: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if c.cfg.Timeout > 0 {
+		timeout = time.Duration(c.cfg.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, home.Long(command), args...)
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), c.cfg.ResolvedEnv()...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		slog.Warn("Compile check timed out", "check", c.name, "dir", root)
+		return "", nil
+	}
+	return strings.TrimSpace(output.String()), err
+}
+
+// expandArgs substitutes the file and directory placeholders in args.
+func expandArgs(args []string, root, path string) []string {
+	dir := "."
+	if rel, err := filepath.Rel(root, filepath.Dir(path)); err == nil && rel != "." {
+		dir = "./" + filepath.ToSlash(rel)
+	}
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		arg = strings.ReplaceAll(arg, FilePlaceholder, path)
+		expanded[i] = strings.ReplaceAll(arg, DirPlaceholder, dir)
+	}
+	return expanded
+}
+
+func truncate(output string) string {
+	if len(output) <= maxOutputBytes {
+		return output
+	}
+	return output[:maxOutputBytes] + "\n... (output truncated)"
+}
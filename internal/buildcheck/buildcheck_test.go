@@ -0,0 +1,92 @@
+package buildcheck
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandArgs(t *testing.T) {
+	t.Parallel()
+
+	root := filepath.Join(string(filepath.Separator), "repo")
+	require.Equal(t,
+		[]string{"vet", "./internal/app"},
+		expandArgs([]string{"vet", DirPlaceholder}, root, filepath.Join(root, "internal", "app", "app.go")),
+	)
+	require.Equal(t,
+		[]string{"vet", "."},
+		expandArgs([]string{"vet", DirPlaceholder}, root, filepath.Join(root, "main.go")),
+	)
+	file := filepath.Join(root, "a.ts")
+	require.Equal(t, []string{"--file=" + file}, expandArgs([]string{"--file={file}"}, root, file))
+}
+
+func TestMergeCheck(t *testing.T) {
+	t.Parallel()
+
+	merged := mergeCheck(Defaults["go"], config.CompileCheckConfig{Timeout: 5})
+	require.Equal(t, "go", merged.Command)
+	require.Equal(t, Defaults["go"].Args, merged.Args)
+	require.Equal(t, 5, merged.Timeout)
+
+	merged = mergeCheck(Defaults["go"], config.CompileCheckConfig{Command: "go", Args: []string{"build", DirPlaceholder}})
+	require.Equal(t, []string{"build", DirPlaceholder}, merged.Args)
+	require.Equal(t, []string{"go.mod"}, merged.RootMarkers)
+}
+
+func TestNewDisabled(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Options: &config.Options{DisableCompileChecks: true}}
+	require.Nil(t, New(cfg))
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "project.marker"), nil, 0o644))
+
+	r := &Runner{
+		workingDir: dir,
+		resolver:   config.NewEnvironmentVariableResolver(env.NewFromMap(nil)),
+		checks: []check{{
+			name: "fake",
+			cfg: config.CompileCheckConfig{
+				Command:     "sh",
+				Args:        []string{"-c", `grep -q broken "$0" && echo "$1: broken" && exit 1; exit 0`, "{file}", DirPlaceholder},
+				FileTypes:   []string{"src"},
+				RootMarkers: []string{"project.marker"},
+			},
+		}},
+	}
+
+	good := filepath.Join(dir, "pkg", "good.src")
+	bad := filepath.Join(dir, "pkg", "bad.src")
+	require.NoError(t, os.WriteFile(good, []byte("fine"), 0o644))
+	require.NoError(t, os.WriteFile(bad, []byte("broken"), 0o644))
+
+	require.Empty(t, r.Check(t.Context(), good))
+	require.Empty(t, r.Check(t.Context(), filepath.Join(dir, "pkg", "other.txt")))
+
+	out := r.Check(t.Context(), bad)
+	require.Contains(t, out, `<compile_errors check="fake">`)
+	require.Contains(t, out, "./pkg: broken")
+
+	var nilRunner *Runner
+	require.Empty(t, nilRunner.Check(t.Context(), bad))
+
+	outside := filepath.Join(t.TempDir(), "bad.src")
+	require.NoError(t, os.WriteFile(outside, []byte("broken"), 0o644))
+	require.Empty(t, r.Check(t.Context(), outside), "no root marker, check must not run")
+}
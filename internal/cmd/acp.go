@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/crush/internal/acp"
+	"github.com/charmbracelet/crush/internal/event"
+	"github.com/spf13/cobra"
+)
+
+var acpCmd = &cobra.Command{
+	Use:   "acp",
+	Short: "Run as an agent for editors that speak the Agent Client Protocol",
+	Long: `Speak the Agent Client Protocol on standard input and output, so editors
+such as Zed can run Crush as their agent: sessions, prompts with files and
+selections, streamed answers, tool calls, diffs and permission requests all
+show in the editor.
+
+Editors start this command themselves; it is not meant to be run by hand.
+Sessions are stored with the other sessions of the project in the working
+directory, so they can be continued in the TUI.`,
+	Example: `
+# Zed settings.json
+"agent_servers": {
+  "Crush": { "command": "crush", "args": ["acp"] }
+}
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer app.Shutdown()
+
+		if !app.Config().IsConfigured() {
+			return fmt.Errorf("no providers configured - please run 'crush' to set up a provider interactively")
+		}
+
+		event.SetNonInteractive(true)
+		event.AppInitialized()
+
+		return acp.Serve(cmd.Context(), os.Stdin, os.Stdout, app.Config().WorkingDir(), app)
+	},
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/crush/internal/analyze"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze <session-id>",
+	Short: "Report where the tokens of a session went",
+	Long: `Analyze a stored session and report where its context tokens went: files
+read more than once, oversized tool outputs, repeated tool calls and prompts,
+and prompt caching turned off. Each finding comes with a suggestion to spend
+fewer tokens next time.
+
+Token counts are estimates from the transcript. Re-sent tokens count how many
+times a finding was sent again with the requests that followed it.`,
+	Example: `
+# Analyze a session
+crush analyze 5f2c1a-...
+
+# Report tool outputs over 2000 tokens
+crush analyze 5f2c1a-... --large-output 2000
+
+# Write the report as JSON
+crush analyze 5f2c1a-... --json --output report.json
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		largeOutput, _ := cmd.Flags().GetInt64("large-output")
+		outputPath, _ := cmd.Flags().GetString("output")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		ctx := cmd.Context()
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		outputPath = absPath(cwd, outputPath)
+
+		if dataDir == "" {
+			cfg, err := config.Init(cwd, "", false)
+			if err != nil {
+				return fmt.Errorf("failed to initialize config: %w", err)
+			}
+			dataDir = cfg.Options.DataDirectory
+		}
+
+		conn, err := db.Connect(ctx, dataDir)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer conn.Close()
+
+		q := db.New(conn)
+		report, err := analyze.Session(ctx, session.NewService(q, conn, ""), message.NewService(q), args[0], analyze.Options{
+			LargeOutputTokens: largeOutput,
+		})
+		if err != nil {
+			return err
+		}
+
+		var out []byte
+		if asJSON {
+			if out, err = json.MarshalIndent(report, "", "  "); err != nil {
+				return err
+			}
+			out = append(out, '\n')
+		} else {
+			out = []byte(report.Markdown())
+		}
+		if outputPath == "" {
+			_, err = os.Stdout.Write(out)
+			return err
+		}
+		if err := os.WriteFile(outputPath, out, 0o644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	analyzeCmd.Flags().Int64("large-output", analyze.DefaultLargeOutputTokens, "Report tool outputs estimated at more tokens than this")
+	analyzeCmd.Flags().StringP("output", "o", "", "Write the report to this file instead of stdout")
+	analyzeCmd.Flags().Bool("json", false, "Write the report as JSON instead of Markdown")
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/format"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/ui/anim"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a context file describing the project",
+	Long: `Have the agent analyze the project, its build system, test commands and
+conventions, and write what it found to a context file future sessions read,
+as Crush offers to do the first time it opens a project. An existing file is
+left alone unless --force is given, in which case the agent reads and
+improves it.
+
+The file is named by options.initialize_as (AGENTS.md by default) unless
+--file is given. Permissions are approved for the run, since the agent only
+reads the project and writes that file.`,
+	Example: `
+# Write AGENTS.md for the current project
+crush init
+
+# Write CRUSH.md instead
+crush init --file CRUSH.md
+
+# Have the agent improve the existing context file
+crush init --force
+
+# Initialize another project
+crush init -c /path/to/project
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("file")
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		force, _ := cmd.Flags().GetBool("force")
+
+		app, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer app.Shutdown()
+
+		if !app.Config().IsConfigured() {
+			return fmt.Errorf("no providers configured - please run 'crush' to set up a provider interactively")
+		}
+		if file != "" {
+			app.Config().Options.InitializeAs = file
+		}
+		if err := checkContextFile(app.Config(), force); err != nil {
+			return err
+		}
+
+		event.SetNonInteractive(true)
+		event.AppInitialized()
+
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+		var spinner *format.Spinner
+		if !quiet && term.IsTerminal(os.Stderr.Fd()) {
+			t := styles.DefaultStyles()
+			spinner = format.NewSpinner(ctx, cancel, anim.Settings{
+				Size:        10,
+				Label:       "Analyzing " + home.Short(app.Config().WorkingDir()),
+				LabelColor:  t.FgBase,
+				GradColorA:  t.Primary,
+				GradColorB:  t.Secondary,
+				CycleColors: true,
+			})
+			spinner.Start()
+		}
+
+		result, err := app.InitializeProject(ctx)
+		if spinner != nil {
+			spinner.Stop()
+		}
+		if err != nil {
+			return err
+		}
+		return printInitialization(cmd.OutOrStdout(), result)
+	},
+	PostRun: func(cmd *cobra.Command, args []string) {
+		event.AppExited()
+	},
+}
+
+// checkContextFile refuses to have the agent write over the context file of
+// the project of cfg, unless force is set.
+func checkContextFile(cfg *config.Config, force bool) error {
+	path := app.ContextFile(cfg)
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists; pass --force to have the agent update it", home.Short(path))
+	}
+	return nil
+}
+
+// printInitialization prints the context file the agent wrote.
+func printInitialization(w io.Writer, result app.Initialization) error {
+	verb := "Updated"
+	if result.Created {
+		verb = "Wrote"
+	}
+	_, err := fmt.Fprintf(w, "%s %s\n", verb, home.Short(result.Path))
+	return err
+}
+
+func init() {
+	initCmd.Flags().String("file", "", "Context file to write, relative to the project (defaults to options.initialize_as)")
+	initCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
+	initCmd.Flags().Bool("force", false, "Have the agent update the context file when it exists")
+}
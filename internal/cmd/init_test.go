@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckContextFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg, err := config.Init(dir, t.TempDir(), false)
+	require.NoError(t, err)
+	cfg.Options.InitializeAs = "CRUSH.md"
+	path := filepath.Join(dir, "CRUSH.md")
+	require.Equal(t, path, app.ContextFile(cfg))
+
+	require.NoError(t, checkContextFile(cfg, false), "a missing file is written")
+
+	require.NoError(t, os.WriteFile(path, []byte("# Notes\n"), 0o644))
+	err = checkContextFile(cfg, false)
+	require.ErrorContains(t, err, "CRUSH.md already exists; pass --force")
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "# Notes\n", string(content), "an existing file is left alone")
+
+	require.NoError(t, checkContextFile(cfg, true))
+}
+
+func TestPrintInitialization(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "AGENTS.md")
+	var b bytes.Buffer
+	require.NoError(t, printInitialization(&b, app.Initialization{Path: path, Created: true}))
+	require.Equal(t, "Wrote "+path+"\n", b.String())
+
+	b.Reset()
+	require.NoError(t, printInitialization(&b, app.Initialization{Path: path}))
+	require.Equal(t, "Updated "+path+"\n", b.String())
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/charmbracelet/crush/internal/oauth"
 	"github.com/charmbracelet/crush/internal/oauth/copilot"
 	"github.com/charmbracelet/crush/internal/oauth/hyper"
+	"github.com/charmbracelet/crush/internal/oauth/mcpauth"
 	"github.com/pkg/browser"
 	"github.com/spf13/cobra"
 )
@@ -24,21 +25,26 @@ var loginCmd = &cobra.Command{
 	Short:   "Login Crush to a platform",
 	Long: `Login Crush to a specified platform.
 The platform should be provided as an argument.
-Available platforms are: hyper, copilot.`,
+Available platforms are: hyper, copilot and mcp, which signs in to an MCP
+server protected with OAuth, given its name in the configuration.`,
 	Example: `
 # Authenticate with Charm Hyper
 crush login
 
 # Authenticate with GitHub Copilot
 crush login copilot
+
+# Sign in to the MCP server configured as "linear"
+crush login mcp linear
   `,
 	ValidArgs: []cobra.Completion{
 		"hyper",
 		"copilot",
 		"github",
 		"github-copilot",
+		"mcp",
 	},
-	Args: cobra.MaximumNArgs(1),
+	Args: cobra.RangeArgs(0, 2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		app, err := setupAppWithProgressBar(cmd)
 		if err != nil {
@@ -55,6 +61,11 @@ crush login copilot
 			return loginHyper(app.Config())
 		case "copilot", "github", "github-copilot":
 			return loginCopilot(app.Config())
+		case "mcp":
+			if len(args) < 2 {
+				return fmt.Errorf("missing mcp server name: crush login mcp <name>")
+			}
+			return loginMCP(app.Config(), args[1])
 		default:
 			return fmt.Errorf("unknown platform: %s", args[0])
 		}
@@ -188,6 +199,50 @@ func loginCopilot(cfg *config.Config) error {
 	return nil
 }
 
+func loginMCP(cfg *config.Config, name string) error {
+	m, ok := cfg.MCP[name]
+	if !ok {
+		return fmt.Errorf("unknown mcp server: %s", name)
+	}
+	if m.Type != config.MCPHttp && m.Type != config.MCPSSE {
+		return fmt.Errorf("mcp server %s is not an http or sse server", name)
+	}
+	ctx := getLoginContext()
+
+	opts := mcpauth.LoginOptions{ServerURL: m.URL}
+	if m.OAuth != nil {
+		secret, err := cfg.Resolver().ResolveValue(m.OAuth.ClientSecret)
+		if err != nil {
+			return fmt.Errorf("invalid client secret: %w", err)
+		}
+		opts.ClientID = m.OAuth.ClientID
+		opts.ClientSecret = secret
+		opts.Scopes = m.OAuth.Scopes
+		opts.CallbackPort = m.OAuth.CallbackPort
+	}
+
+	fmt.Printf("Signing in to %s...\n", m.URL)
+	creds, err := mcpauth.Login(ctx, opts, func(authURL string) {
+		fmt.Println()
+		fmt.Println("Open the following URL to sign in, if your browser does not open it:")
+		fmt.Println()
+		fmt.Println(lipgloss.NewStyle().Hyperlink(authURL, "id=mcp").Render(authURL))
+		fmt.Println()
+		fmt.Println("Waiting for authorization...")
+		_ = browser.OpenURL(authURL)
+	})
+	if err != nil {
+		return err
+	}
+	if err := mcpauth.NewStore(config.GlobalMCPCredentials()).Set(m.URL, creds); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("You're now signed in to %s!\n", name)
+	return nil
+}
+
 func getLoginContext() context.Context {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 	go func() {
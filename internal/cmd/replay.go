@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <session-id>",
+	Short: "Re-run a session's prompts and compare the results",
+	Long: `Re-run the user turns of a stored session against another model or system
+prompt template and report how each turn changed: the responses, the tools
+called and the files changed.
+
+The replay runs in a temporary git worktree without asking for permissions, so
+the project itself is left untouched. The replayed session is stored next to
+the original one.`,
+	Example: `
+# Compare a session with a replay on another model
+crush replay 5f2c1a-... --model anthropic/claude-sonnet-4
+
+# Check a new system prompt and keep the report
+crush replay 5f2c1a-... --prompt-template prompts/coder.md.tpl --output report.md
+
+# Write the report as JSON
+crush replay 5f2c1a-... --model gpt-5 --json --output report.json
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		largeModel, _ := cmd.Flags().GetString("model")
+		smallModel, _ := cmd.Flags().GetString("small-model")
+		promptTemplate, _ := cmd.Flags().GetString("prompt-template")
+		outputPath, _ := cmd.Flags().GetString("output")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+		defer cancel()
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		outputPath = absPath(cwd, outputPath)
+		opts := app.ReplayOptions{
+			LargeModel:     largeModel,
+			SmallModel:     smallModel,
+			PromptTemplate: absPath(cwd, promptTemplate),
+		}
+
+		// The sessions live in the data directory of the project, not of the
+		// worktree.
+		if dataDir, _ := cmd.Flags().GetString("data-dir"); dataDir == "" {
+			if err := cmd.Flags().Set("data-dir", projectDataDir(cwd)); err != nil {
+				return err
+			}
+		}
+
+		sandbox, err := worktree.Create(ctx, cwd)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = os.Chdir(cwd)
+			if err := sandbox.Remove(context.Background()); err != nil {
+				slog.Warn("Failed to remove worktree", "error", err)
+			}
+		}()
+		if err := cmd.Flags().Set("cwd", sandbox.Dir); err != nil {
+			return err
+		}
+
+		app, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer app.Shutdown()
+
+		if !app.Config().IsConfigured() {
+			return fmt.Errorf("no providers configured - please run 'crush' to set up a provider interactively")
+		}
+
+		event.SetNonInteractive(true)
+		event.AppInitialized()
+
+		report, err := app.Replay(ctx, args[0], opts)
+		if err != nil {
+			return err
+		}
+
+		var out []byte
+		if asJSON {
+			if out, err = json.MarshalIndent(report, "", "  "); err != nil {
+				return err
+			}
+			out = append(out, '\n')
+		} else {
+			out = []byte(report.Markdown())
+		}
+		if outputPath == "" {
+			_, err = os.Stdout.Write(out)
+			return err
+		}
+		if err := os.WriteFile(outputPath, out, 0o644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		return nil
+	},
+	PostRun: func(cmd *cobra.Command, args []string) {
+		event.AppExited()
+	},
+}
+
+func init() {
+	replayCmd.Flags().StringP("model", "m", "", "Model to replay with. Accepts 'model' or 'provider/model' to disambiguate models with the same name across providers")
+	replayCmd.Flags().String("small-model", "", "Small model to replay with. If not provided, uses the default small model for the provider")
+	replayCmd.Flags().String("prompt-template", "", "Template to use instead of the configured system prompt")
+	replayCmd.Flags().StringP("output", "o", "", "Write the report to this file instead of stdout")
+	replayCmd.Flags().Bool("json", false, "Write the report as JSON instead of Markdown")
+}
+
+// projectDataDir returns the data directory config.Init picks for cwd.
+func projectDataDir(cwd string) string {
+	if path, ok := fsext.LookupClosest(cwd, ".crush"); ok {
+		return path
+	}
+	return filepath.Join(cwd, ".crush")
+}
@@ -37,16 +37,25 @@ func init() {
 	rootCmd.PersistentFlags().BoolP("debug", "d", false, "Debug")
 	rootCmd.Flags().BoolP("help", "h", false, "Help")
 	rootCmd.Flags().BoolP("yolo", "y", false, "Automatically accept all permissions (dangerous mode)")
+	rootCmd.Flags().BoolP("continue", "C", false, "Continue the most recent session in the working directory")
 
 	rootCmd.AddCommand(
 		runCmd,
+		initCmd,
+		acpCmd,
+		replayCmd,
+		analyzeCmd,
+		verifyCleanCmd,
 		dirsCmd,
 		projectsCmd,
 		updateProvidersCmd,
 		logsCmd,
+		statusCmd,
 		schemaCmd,
 		loginCmd,
 		statsCmd,
+		versionCmd,
+		selfUpdateCmd,
 	)
 }
 
@@ -75,6 +84,9 @@ crush run "Explain the use of context in Go"
 
 # Run in dangerous mode (auto-accept all permissions)
 crush -y
+
+# Pick up where you left off
+crush --continue
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		app, err := setupAppWithProgressBar(cmd)
@@ -83,6 +95,12 @@ crush -y
 		}
 		defer app.Shutdown()
 
+		if resume, _ := cmd.Flags().GetBool("continue"); resume {
+			if err := resumeLastSession(cmd.Context(), app); err != nil {
+				return err
+			}
+		}
+
 		event.AppInitialized()
 
 		// Set up the TUI.
@@ -90,6 +108,7 @@ crush -y
 
 		com := common.DefaultCommon(app)
 		model := ui.New(com)
+		defer model.Close()
 
 		program := tea.NewProgram(
 			model,
@@ -108,6 +127,21 @@ crush -y
 	},
 }
 
+// resumeLastSession has the TUI open the most recent session, or a new one
+// when the directory has none yet.
+func resumeLastSession(ctx context.Context, appInstance *app.App) error {
+	sess, err := appInstance.ResumeLastSession(ctx)
+	if errors.Is(err, app.ErrNoSessions) {
+		slog.Info("No session to continue, starting a new one")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find the last session: %w", err)
+	}
+	slog.Info("Continuing session", "session_id", sess.ID, "title", sess.Title)
+	return nil
+}
+
 var heartbit = lipgloss.NewStyle().Foreground(charmtone.Dolly).SetString(`
     ▄▄▄▄▄▄▄▄    ▄▄▄▄▄▄▄▄
   ███████████  ███████████
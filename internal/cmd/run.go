@@ -1,15 +1,21 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 
 	"charm.land/log/v2"
+	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/worktree"
 	"github.com/spf13/cobra"
 )
 
@@ -33,17 +39,54 @@ crush run --quiet "Generate a README for this project"
 
 # Run in verbose mode
 crush run --verbose "Generate a README for this project"
+
+# Write the run as plain lines of events, for screen readers and scripts
+crush run --plain "List the TODOs in this project"
+
+# Leave the workspace untouched and write the changes to a patch
+crush run --patch fix.patch --summary fix.json "Fix the failing tests"
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		quiet, _ := cmd.Flags().GetBool("quiet")
 		verbose, _ := cmd.Flags().GetBool("verbose")
+		plain, _ := cmd.Flags().GetBool("plain")
 		largeModel, _ := cmd.Flags().GetString("model")
 		smallModel, _ := cmd.Flags().GetString("small-model")
+		patchPath, _ := cmd.Flags().GetString("patch")
+		summaryPath, _ := cmd.Flags().GetString("summary")
+		if summaryPath != "" && patchPath == "" {
+			return fmt.Errorf("--summary requires --patch")
+		}
 
 		// Cancel on SIGINT or SIGTERM.
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 		defer cancel()
 
+		// With --patch the agent works in a throwaway worktree and its
+		// changes are exported instead of applied.
+		var sandbox *worktree.Sandbox
+		if patchPath != "" {
+			cwd, err := ResolveCwd(cmd)
+			if err != nil {
+				return err
+			}
+			patchPath = absPath(cwd, patchPath)
+			summaryPath = absPath(cwd, summaryPath)
+			sandbox, err = worktree.Create(ctx, cwd)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = os.Chdir(cwd)
+				if err := sandbox.Remove(context.Background()); err != nil {
+					slog.Warn("Failed to remove worktree", "error", err)
+				}
+			}()
+			if err := cmd.Flags().Set("cwd", sandbox.Dir); err != nil {
+				return err
+			}
+		}
+
 		app, err := setupApp(cmd)
 		if err != nil {
 			return err
@@ -73,7 +116,26 @@ crush run --verbose "Generate a README for this project"
 		event.SetNonInteractive(true)
 		event.AppInitialized()
 
-		return app.RunNonInteractive(ctx, os.Stdout, prompt, largeModel, smallModel, quiet || verbose)
+		run := func(output io.Writer) error {
+			if plain {
+				return app.RunPlain(ctx, output, prompt, largeModel, smallModel)
+			}
+			return app.RunNonInteractive(ctx, output, prompt, largeModel, smallModel, quiet || verbose)
+		}
+		if sandbox == nil {
+			return run(os.Stdout)
+		}
+
+		var response bytes.Buffer
+		runErr := run(io.MultiWriter(os.Stdout, &response))
+		reply := response.String()
+		if plain {
+			reply = plainReply(reply)
+		}
+		if err := writeRunArtifacts(context.WithoutCancel(ctx), sandbox, prompt, reply, runErr, patchPath, summaryPath); err != nil {
+			return err
+		}
+		return runErr
 	},
 	PostRun: func(cmd *cobra.Command, args []string) {
 		event.AppExited()
@@ -85,4 +147,89 @@ func init() {
 	runCmd.Flags().BoolP("verbose", "v", false, "Show logs")
 	runCmd.Flags().StringP("model", "m", "", "Model to use. Accepts 'model' or 'provider/model' to disambiguate models with the same name across providers")
 	runCmd.Flags().String("small-model", "", "Small model to use. If not provided, uses the default small model for the provider")
+	runCmd.Flags().Bool("plain", false, "Write the run as plain lines of events without colors or animations, for screen readers and scripts")
+	runCmd.Flags().String("patch", "", "Work in a temporary git worktree and write the changes to this file as a git-format patch instead of applying them")
+	runCmd.Flags().String("summary", "", "Write a JSON summary of the run to this file (requires --patch)")
+}
+
+// runSummary is written next to the patch of a --patch run.
+type runSummary struct {
+	Prompt     string                `json:"prompt"`
+	Response   string                `json:"response"`
+	BaseCommit string                `json:"base_commit"`
+	Patch      string                `json:"patch,omitempty"`
+	Files      []worktree.FileChange `json:"files"`
+	Additions  int                   `json:"additions"`
+	Deletions  int                   `json:"deletions"`
+	Error      string                `json:"error,omitempty"`
+}
+
+// writeRunArtifacts exports the changes made in the sandbox. The patch file
+// is only written when something changed.
+func writeRunArtifacts(ctx context.Context, sandbox *worktree.Sandbox, prompt, response string, runErr error, patchPath, summaryPath string) error {
+	patch, files, err := sandbox.Patch(ctx, patchSubject(prompt))
+	if err != nil {
+		return fmt.Errorf("failed to create patch: %w", err)
+	}
+	summary := runSummary{
+		Prompt:     prompt,
+		Response:   strings.TrimSpace(response),
+		BaseCommit: sandbox.BaseCommit,
+		Files:      files,
+	}
+	if summary.Files == nil {
+		summary.Files = []worktree.FileChange{}
+	}
+	for _, f := range files {
+		summary.Additions += f.Additions
+		summary.Deletions += f.Deletions
+	}
+	if runErr != nil {
+		summary.Error = runErr.Error()
+	}
+	if patch != "" {
+		if err := os.WriteFile(patchPath, []byte(patch), 0o644); err != nil {
+			return fmt.Errorf("failed to write patch: %w", err)
+		}
+		summary.Patch = patchPath
+	}
+	if summaryPath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(summaryPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
+
+// plainReply returns the reply of the agent from the output of a plain run.
+func plainReply(output string) string {
+	var lines []string
+	for line := range strings.SplitSeq(output, "\n") {
+		if text, ok := strings.CutPrefix(line, app.PlainText); ok && (text == "" || text[0] == ' ') {
+			lines = append(lines, strings.TrimPrefix(text, " "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// patchSubject turns the first line of the prompt into a commit subject.
+func patchSubject(prompt string) string {
+	const maxSubjectLength = 72
+	subject, _, _ := strings.Cut(strings.TrimSpace(prompt), "\n")
+	if len(subject) > maxSubjectLength {
+		subject = subject[:maxSubjectLength-3] + "..."
+	}
+	return subject
+}
+
+func absPath(cwd, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(cwd, path)
 }
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/worktree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchSubject(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "Fix the tests", patchSubject("  Fix the tests\nThey fail on CI.  "))
+	long := patchSubject(strings.Repeat("a", 100))
+	require.Len(t, long, 72)
+	require.True(t, strings.HasSuffix(long, "..."))
+}
+
+func TestWriteRunArtifacts(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Parallel()
+
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"commit", "--quiet", "--allow-empty", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	sandbox, err := worktree.Create(t.Context(), repo)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, sandbox.Remove(context.Background())) })
+	require.NoError(t, os.WriteFile(filepath.Join(sandbox.Dir, "README.md"), []byte("# Hello\n"), 0o644))
+
+	out := t.TempDir()
+	patchPath := filepath.Join(out, "run.patch")
+	summaryPath := filepath.Join(out, "run.json")
+	require.NoError(t, writeRunArtifacts(t.Context(), sandbox, "Add a README", " Done. \n", errors.New("interrupted"), patchPath, summaryPath))
+
+	patch, err := os.ReadFile(patchPath)
+	require.NoError(t, err)
+	require.Contains(t, string(patch), "Subject: [PATCH] Add a README")
+	require.NoFileExists(t, filepath.Join(repo, "README.md"))
+
+	data, err := os.ReadFile(summaryPath)
+	require.NoError(t, err)
+	var summary runSummary
+	require.NoError(t, json.Unmarshal(data, &summary))
+	require.Equal(t, "Done.", summary.Response)
+	require.Equal(t, sandbox.BaseCommit, summary.BaseCommit)
+	require.Equal(t, patchPath, summary.Patch)
+	require.Equal(t, []worktree.FileChange{{Path: "README.md", Additions: 1}}, summary.Files)
+	require.Equal(t, 1, summary.Additions)
+	require.Equal(t, "interrupted", summary.Error)
+}
+
+func TestPlainReply(t *testing.T) {
+	t.Parallel()
+	output := "SESSION 123\nTHINKING\nTEXT Fixed it.\nTOOL edit {}\nTEXT\nTEXTUAL noise\nTEXT  Indented\nDONE ok\n"
+	require.Equal(t, "Fixed it.\n\n Indented", plainReply(output))
+}
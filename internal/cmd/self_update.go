@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/update"
+	"github.com/charmbracelet/crush/internal/version"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update crush to the latest release",
+	Long: `Download the latest release for this platform and replace the running binary.
+The release checksums are verified against their cosign signature and the
+archive against the checksums before anything is replaced. Updates only ever
+happen when this command is run.`,
+	Example: `
+# Check whether an update is available
+crush self-update --check
+
+# Update to the latest stable release
+crush self-update
+
+# Update to the latest nightly build without prompting
+crush self-update --channel nightly --yes
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		channelName, _ := cmd.Flags().GetString("channel")
+		check, _ := cmd.Flags().GetBool("check")
+		yes, _ := cmd.Flags().GetBool("yes")
+		force, _ := cmd.Flags().GetBool("force")
+		skipSignature, _ := cmd.Flags().GetBool("skip-signature")
+
+		channel, err := update.ParseChannel(channelName)
+		if err != nil {
+			return err
+		}
+
+		current := update.Info{Current: version.Version}
+		if current.IsDevelopment() && !force && !check {
+			return errors.New("this is a development build; use --force to replace it with a release")
+		}
+
+		candidate, err := update.Find(cmd.Context(), update.DefaultSource, channel)
+		if err != nil {
+			return err
+		}
+		info := update.Info{
+			Current: strings.TrimPrefix(version.Version, "v"),
+			Latest:  candidate.Version,
+			URL:     candidate.URL,
+		}
+		// Nightly builds are pre-releases, which Available never offers to
+		// stable builds, so any difference counts on that channel.
+		available := info.Available() || (channel == update.ChannelNightly && info.Current != info.Latest)
+		if !available {
+			cmd.Printf("crush %s is the latest %s release\n", info.Current, channel)
+			return nil
+		}
+		cmd.Printf("crush %s is available (current: %s)\n", info.Latest, info.Current)
+		if check {
+			if info.URL != "" {
+				cmd.Println(info.URL)
+			}
+			return nil
+		}
+
+		if !yes {
+			if !term.IsTerminal(os.Stdin.Fd()) {
+				return errors.New("refusing to update without confirmation; use --yes")
+			}
+			cmd.Print("Update now? [y/N] ")
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				return nil
+			}
+		}
+
+		if err := update.Install(cmd.Context(), update.DefaultSource, candidate, update.InstallOptions{
+			SkipSignature: skipSignature,
+		}); err != nil {
+			return fmt.Errorf("update failed: %w", err)
+		}
+		cmd.Printf("Updated crush to %s\n", info.Latest)
+		return nil
+	},
+}
+
+func init() {
+	selfUpdateCmd.Flags().String("channel", string(update.ChannelStable), "Release channel: stable or nightly")
+	selfUpdateCmd.Flags().Bool("check", false, "Only check whether an update is available")
+	selfUpdateCmd.Flags().BoolP("yes", "y", false, "Update without asking for confirmation")
+	selfUpdateCmd.Flags().Bool("force", false, "Replace development builds too")
+	selfUpdateCmd.Flags().Bool("skip-signature", false, "Skip the cosign signature check (checksums are still verified)")
+}
@@ -5,12 +5,15 @@ import (
 	"context"
 	"database/sql"
 	_ "embed"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"os"
 	"os/user"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -42,9 +45,36 @@ var statsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "Show usage statistics",
 	Long:  "Generate and display usage statistics including token usage, costs, and activity patterns",
-	RunE:  runStats,
+	Example: `
+# Open the stats dashboard in the browser
+crush stats
+
+# Output all statistics as JSON
+crush stats --json
+
+# Export tokens and cost per model as CSV
+crush stats --csv --by model
+
+# Export tokens and cost per task label as CSV
+crush stats --csv --by label
+  `,
+	RunE: runStats,
+}
+
+func init() {
+	statsCmd.Flags().Bool("json", false, "Output statistics as JSON")
+	statsCmd.Flags().Bool("csv", false, "Output a usage report as CSV")
+	statsCmd.Flags().String("by", "day", "Grouping of the CSV report: day, model, session or label")
 }
 
+// Groupings available for CSV reports.
+const (
+	statsByDay     = "day"
+	statsByModel   = "model"
+	statsBySession = "session"
+	statsByLabel   = "label"
+)
+
 // Day names for day of week statistics.
 var dayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
 
@@ -60,6 +90,9 @@ type Stats struct {
 	AvgResponseTimeMs float64            `json:"avg_response_time_ms"`
 	ToolUsage         []ToolUsage        `json:"tool_usage"`
 	HourDayHeatmap    []HourDayHeatmapPt `json:"hour_day_heatmap"`
+	CostByModel       []ModelCost        `json:"cost_by_model"`
+	UsageBySession    []SessionUsage     `json:"usage_by_session"`
+	UsageByLabel      []LabelUsage       `json:"usage_by_label"`
 }
 
 type TotalStats struct {
@@ -88,6 +121,42 @@ type ModelUsage struct {
 	MessageCount int64  `json:"message_count"`
 }
 
+// ModelCost attributes session tokens and cost to models in proportion to
+// the number of responses each model produced in the session.
+type ModelCost struct {
+	Model            string  `json:"model"`
+	Provider         string  `json:"provider"`
+	SessionCount     int64   `json:"session_count"`
+	MessageCount     int64   `json:"message_count"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+type SessionUsage struct {
+	ID               string    `json:"id"`
+	Title            string    `json:"title"`
+	CreatedAt        time.Time `json:"created_at"`
+	MessageCount     int64     `json:"message_count"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+	Cost             float64   `json:"cost"`
+}
+
+// LabelUsage is the usage of the turns sent under one label. Turns sent
+// without a label have an empty label.
+type LabelUsage struct {
+	Label            string  `json:"label"`
+	SessionCount     int64   `json:"session_count"`
+	RequestCount     int64   `json:"request_count"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
 type HourlyUsage struct {
 	Hour         int   `json:"hour"`
 	SessionCount int64 `json:"session_count"`
@@ -121,8 +190,18 @@ type HourDayHeatmapPt struct {
 
 func runStats(cmd *cobra.Command, _ []string) error {
 	dataDir, _ := cmd.Flags().GetString("data-dir")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	csvOutput, _ := cmd.Flags().GetBool("csv")
+	by, _ := cmd.Flags().GetString("by")
 	ctx := cmd.Context()
 
+	if jsonOutput && csvOutput {
+		return fmt.Errorf("--json and --csv cannot be used together")
+	}
+	if by != statsByDay && by != statsByModel && by != statsBySession && by != statsByLabel {
+		return fmt.Errorf("invalid --by value %q: must be day, model, session or label", by)
+	}
+
 	if dataDir == "" {
 		cfg, err := config.Init("", "", false)
 		if err != nil {
@@ -146,6 +225,18 @@ func runStats(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("no data available: no sessions found in database")
 	}
 
+	switch {
+	case jsonOutput:
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return err
+	case csvOutput:
+		return writeStatsCSV(cmd.OutOrStdout(), stats, by)
+	}
+
 	currentUser, err := user.Current()
 	if err != nil {
 		return fmt.Errorf("failed to get current user: %w", err)
@@ -301,9 +392,138 @@ func gatherStats(ctx context.Context, conn *sql.DB) (*Stats, error) {
 		})
 	}
 
+	// Tokens and cost by model.
+	modelCost, err := queries.GetCostByModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get cost by model: %w", err)
+	}
+	for _, m := range modelCost {
+		prompt := nullFloat64ToInt64(m.PromptTokens)
+		completion := nullFloat64ToInt64(m.CompletionTokens)
+		stats.CostByModel = append(stats.CostByModel, ModelCost{
+			Model:            m.Model,
+			Provider:         m.Provider,
+			SessionCount:     m.SessionCount,
+			MessageCount:     m.MessageCount,
+			PromptTokens:     prompt,
+			CompletionTokens: completion,
+			TotalTokens:      prompt + completion,
+			Cost:             m.Cost.Float64,
+		})
+	}
+
+	// Usage by session.
+	sessionUsage, err := queries.GetUsageBySession(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get usage by session: %w", err)
+	}
+	for _, s := range sessionUsage {
+		stats.UsageBySession = append(stats.UsageBySession, SessionUsage{
+			ID:               s.ID,
+			Title:            s.Title,
+			CreatedAt:        time.Unix(s.CreatedAt, 0),
+			MessageCount:     s.MessageCount,
+			PromptTokens:     s.PromptTokens,
+			CompletionTokens: s.CompletionTokens,
+			TotalTokens:      s.PromptTokens + s.CompletionTokens,
+			Cost:             s.Cost,
+		})
+	}
+
+	// Usage by label.
+	labelUsage, err := queries.GetUsageByLabel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get usage by label: %w", err)
+	}
+	for _, l := range labelUsage {
+		stats.UsageByLabel = append(stats.UsageByLabel, LabelUsage{
+			Label:            l.Label,
+			SessionCount:     l.SessionCount,
+			RequestCount:     l.RequestCount,
+			PromptTokens:     l.PromptTokens,
+			CompletionTokens: l.CompletionTokens,
+			TotalTokens:      l.PromptTokens + l.CompletionTokens,
+			Cost:             l.Cost,
+		})
+	}
+
 	return stats, nil
 }
 
+// writeStatsCSV writes the usage report for the given grouping as CSV.
+func writeStatsCSV(w io.Writer, stats *Stats, by string) error {
+	var records [][]string
+	switch by {
+	case statsByModel:
+		records = append(records, []string{"model", "provider", "sessions", "messages", "prompt_tokens", "completion_tokens", "total_tokens", "cost"})
+		for _, m := range stats.CostByModel {
+			records = append(records, []string{
+				m.Model,
+				m.Provider,
+				formatInt(m.SessionCount),
+				formatInt(m.MessageCount),
+				formatInt(m.PromptTokens),
+				formatInt(m.CompletionTokens),
+				formatInt(m.TotalTokens),
+				formatCost(m.Cost),
+			})
+		}
+	case statsBySession:
+		records = append(records, []string{"session_id", "title", "created_at", "messages", "prompt_tokens", "completion_tokens", "total_tokens", "cost"})
+		for _, s := range stats.UsageBySession {
+			records = append(records, []string{
+				s.ID,
+				s.Title,
+				s.CreatedAt.UTC().Format(time.RFC3339),
+				formatInt(s.MessageCount),
+				formatInt(s.PromptTokens),
+				formatInt(s.CompletionTokens),
+				formatInt(s.TotalTokens),
+				formatCost(s.Cost),
+			})
+		}
+	case statsByLabel:
+		records = append(records, []string{"label", "sessions", "requests", "prompt_tokens", "completion_tokens", "total_tokens", "cost"})
+		for _, l := range stats.UsageByLabel {
+			records = append(records, []string{
+				l.Label,
+				formatInt(l.SessionCount),
+				formatInt(l.RequestCount),
+				formatInt(l.PromptTokens),
+				formatInt(l.CompletionTokens),
+				formatInt(l.TotalTokens),
+				formatCost(l.Cost),
+			})
+		}
+	default:
+		records = append(records, []string{"day", "sessions", "prompt_tokens", "completion_tokens", "total_tokens", "cost"})
+		for _, d := range stats.UsageByDay {
+			records = append(records, []string{
+				d.Day,
+				formatInt(d.SessionCount),
+				formatInt(d.PromptTokens),
+				formatInt(d.CompletionTokens),
+				formatInt(d.TotalTokens),
+				formatCost(d.Cost),
+			})
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(records); err != nil {
+		return fmt.Errorf("write csv: %w", err)
+	}
+	return nil
+}
+
+func formatInt(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+func formatCost(cost float64) string {
+	return strconv.FormatFloat(cost, 'f', 6, 64)
+}
+
 func toInt64(v any) int64 {
 	switch val := v.(type) {
 	case int64:
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatherStatsCostByModel(t *testing.T) {
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{
+		ID:               "s1",
+		Title:            "First",
+		PromptTokens:     300,
+		CompletionTokens: 90,
+		Cost:             3,
+	})
+	require.NoError(t, err)
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{
+		ID:               "s2",
+		Title:            "Second",
+		PromptTokens:     100,
+		CompletionTokens: 10,
+		Cost:             2,
+	})
+	require.NoError(t, err)
+
+	messages := []struct{ session, model string }{
+		{"s1", "big"}, {"s1", "big"}, {"s1", "small"}, {"s2", "small"},
+	}
+	for i, m := range messages {
+		_, err := q.CreateMessage(ctx, db.CreateMessageParams{
+			ID:        string(rune('a' + i)),
+			SessionID: m.session,
+			Role:      "assistant",
+			Parts:     "[]",
+			Model:     sql.NullString{String: m.model, Valid: true},
+			Provider:  sql.NullString{String: "test", Valid: true},
+		})
+		require.NoError(t, err)
+	}
+
+	stats, err := gatherStats(ctx, conn)
+	require.NoError(t, err)
+
+	require.Len(t, stats.CostByModel, 2)
+	small, big := stats.CostByModel[0], stats.CostByModel[1]
+	require.Equal(t, "big", big.Model)
+	require.Equal(t, int64(1), big.SessionCount)
+	require.Equal(t, int64(2), big.MessageCount)
+	require.Equal(t, int64(200), big.PromptTokens)
+	require.InDelta(t, 2.0, big.Cost, 0.0001)
+	require.Equal(t, "small", small.Model)
+	require.Equal(t, int64(2), small.SessionCount)
+	require.Equal(t, int64(200), small.PromptTokens)
+	require.InDelta(t, 3.0, small.Cost, 0.0001)
+
+	require.Len(t, stats.UsageBySession, 2)
+
+	var b bytes.Buffer
+	require.NoError(t, writeStatsCSV(&b, stats, statsByModel))
+	records, err := csv.NewReader(&b).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, "model", records[0][0])
+	require.Equal(t, []string{"big", "test", "1", "2", "200", "60", "260", "2.000000"}, records[2])
+
+	b.Reset()
+	require.NoError(t, writeStatsCSV(&b, stats, statsBySession))
+	records, err = csv.NewReader(&b).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, "session_id", records[0][0])
+
+	b.Reset()
+	require.NoError(t, writeStatsCSV(&b, stats, statsByLabel))
+	records, err = csv.NewReader(&b).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "label", records[0][0])
+}
+
+func TestGatherStatsUsageByLabel(t *testing.T) {
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{ID: "s1", Title: "First"})
+	require.NoError(t, err)
+	for _, r := range []db.CreateUsageRecordParams{
+		{SessionID: "s1", Label: "JIRA-123", PromptTokens: 100, CompletionTokens: 20, Cost: 1.5},
+		{SessionID: "s1", Label: "JIRA-123", PromptTokens: 50, CompletionTokens: 10, Cost: 0.5},
+		{SessionID: "s1", PromptTokens: 10, CompletionTokens: 5, Cost: 0.1},
+	} {
+		require.NoError(t, q.CreateUsageRecord(ctx, r))
+	}
+
+	stats, err := gatherStats(ctx, conn)
+	require.NoError(t, err)
+	require.Len(t, stats.UsageByLabel, 2)
+
+	var b bytes.Buffer
+	require.NoError(t, writeStatsCSV(&b, stats, statsByLabel))
+	records, err := csv.NewReader(&b).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"label", "sessions", "requests", "prompt_tokens", "completion_tokens", "total_tokens", "cost"},
+		{"JIRA-123", "1", "2", "150", "30", "180", "2.000000"},
+		{"", "1", "1", "10", "5", "15", "0.100000"},
+	}, records)
+}
+
+func TestRunStatsJSONToStdout(t *testing.T) {
+	dataDir := t.TempDir()
+	conn, err := db.Connect(t.Context(), dataDir)
+	require.NoError(t, err)
+	_, err = db.New(conn).CreateSession(t.Context(), db.CreateSessionParams{ID: "s1", Title: "First", Cost: 1})
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	cmd := &cobra.Command{RunE: runStats}
+	cmd.Flags().String("data-dir", dataDir, "")
+	cmd.Flags().Bool("json", true, "")
+	cmd.Flags().Bool("csv", false, "")
+	cmd.Flags().String("by", statsByDay, "")
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SetArgs(nil)
+	require.NoError(t, cmd.ExecuteContext(t.Context()))
+
+	var stats Stats
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &stats))
+	require.Equal(t, int64(1), stats.Total.TotalSessions)
+	require.Empty(t, stderr.String())
+}
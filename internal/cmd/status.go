@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/agentstatus"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print whether the agent is working or waiting for approval",
+	Long: `Print whether the Crush of the project is idle, working or waiting for approval.
+This is meant for status bars to poll, so that a Crush in a background pane can
+tell when it needs attention. It prints stopped, or nothing in the tmux format,
+when no Crush runs in the project.`,
+	Example: `
+# Print the status as text
+crush status
+
+# Print the status as JSON
+crush status --format json
+
+# Show the status of the project of the current pane in the tmux status bar
+set -g status-right '#(crush status --format tmux --cwd "#{pane_current_path}")'
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := cmd.Flags().GetString("cwd")
+		if err != nil {
+			return fmt.Errorf("failed to get current working directory: %v", err)
+		}
+
+		dataDir, err := cmd.Flags().GetString("data-dir")
+		if err != nil {
+			return fmt.Errorf("failed to get data directory: %v", err)
+		}
+
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return fmt.Errorf("failed to get format flag: %v", err)
+		}
+
+		cfg, err := config.Load(cwd, dataDir, false)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %v", err)
+		}
+		status, err := agentstatus.Read(agentstatus.Path(cfg.Options.DataDirectory))
+		if err != nil {
+			return fmt.Errorf("failed to read status: %v", err)
+		}
+		out, err := status.Format(format)
+		if err != nil {
+			return err
+		}
+		// Println would go to stderr, where status bars do not look.
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+		return err
+	},
+}
+
+func init() {
+	statusCmd.Flags().StringP("format", "f", "text", "Output format: "+strings.Join(agentstatus.Formats, ", "))
+}
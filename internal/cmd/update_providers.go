@@ -34,6 +34,9 @@ crush update-providers --source=hyper
 
 # Update Hyper from a custom URL
 crush update-providers --source=hyper https://hyper.example.com
+
+# Update the OpenRouter model catalog
+crush update-providers --source=openrouter
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// NOTE(@andreynering): We want to skip logging output do stdout here.
@@ -50,8 +53,10 @@ crush update-providers --source=hyper https://hyper.example.com
 			err = config.UpdateProviders(pathOrURL)
 		case "hyper":
 			err = config.UpdateHyper(pathOrURL)
+		case "openrouter":
+			err = config.UpdateOpenRouter(pathOrURL)
 		default:
-			return fmt.Errorf("invalid source %q, must be 'catwalk' or 'hyper'", updateProvidersSource)
+			return fmt.Errorf("invalid source %q, must be 'catwalk', 'hyper' or 'openrouter'", updateProvidersSource)
 		}
 
 		if err != nil {
@@ -78,5 +83,5 @@ crush update-providers --source=hyper https://hyper.example.com
 }
 
 func init() {
-	updateProvidersCmd.Flags().StringVar(&updateProvidersSource, "source", "catwalk", "Provider source to update (catwalk or hyper)")
+	updateProvidersCmd.Flags().StringVar(&updateProvidersSource, "source", "catwalk", "Provider source to update (catwalk, hyper or openrouter)")
 }
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/charmbracelet/crush/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var verifyCleanCmd = &cobra.Command{
+	Use:   "verify-clean",
+	Short: "Compare the workspace with a clean clone of its commit",
+	Long: `Compare the files of the repository with a fresh clone at the same commit and
+list every file that was changed, deleted or added. Changes hidden from git
+status, such as files marked assume-unchanged or excluded in .git/info/exclude,
+are listed too.
+
+Files matching an --expect pattern are reported as expected. The command fails
+when any other file diverges, so it can confirm that a session only changed
+the files it was meant to.`,
+	Example: `
+# List everything that differs from the last commit
+crush verify-clean
+
+# Only the parser should have changed
+crush verify-clean --expect 'internal/parser/**' --expect CHANGELOG.md
+
+# Include ignored files such as build output, as JSON
+crush verify-clean --ignored --json
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		expected, _ := cmd.Flags().GetStringArray("expect")
+		ignored, _ := cmd.Flags().GetBool("ignored")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+		defer cancel()
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		report, err := worktree.VerifyClean(ctx, cwd, worktree.VerifyOptions{Expected: expected, Ignored: ignored})
+		if err != nil {
+			return err
+		}
+
+		if asJSON {
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(out))
+		} else {
+			fmt.Fprintln(cmd.OutOrStdout(), report.String())
+		}
+		if unexpected := len(report.Unexpected()); unexpected > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d files diverge unexpectedly from %s", unexpected, report.Commit)
+		}
+		return nil
+	},
+}
+
+func init() {
+	verifyCleanCmd.Flags().StringArray("expect", nil, "Pattern of files meant to change, relative to the repository root (repeatable)")
+	verifyCleanCmd.Flags().Bool("ignored", false, "Also list files ignored by the committed .gitignore files")
+	verifyCleanCmd.Flags().Bool("json", false, "Write the report as JSON")
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/version"
+	"github.com/spf13/cobra"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print build information",
+	Long:  "Print the version, commit and platform crush was built for. This never contacts the network.",
+	Example: `
+# Print the build information
+crush version
+
+# Print the build information as JSON
+crush version --json
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := version.Build()
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(info)
+		}
+		w := cmd.OutOrStdout()
+		fmt.Fprintf(w, "Version:    %s\n", info.Version)
+		if info.Commit != "" {
+			commit := info.Commit
+			if info.Modified {
+				commit += " (modified)"
+			}
+			fmt.Fprintf(w, "Commit:     %s\n", commit)
+		}
+		if info.BuildTime != "" {
+			fmt.Fprintf(w, "Built:      %s\n", info.BuildTime)
+		}
+		fmt.Fprintf(w, "Go version: %s\n", info.GoVersion)
+		fmt.Fprintf(w, "Platform:   %s/%s\n", info.OS, info.Arch)
+		return nil
+	},
+}
+
+func init() {
+	versionCmd.Flags().Bool("json", false, "Output build information as JSON")
+}
@@ -0,0 +1,164 @@
+// Package codeowners reads CODEOWNERS files so edits to files owned by other
+// teams can be flagged before they end up in a pull request.
+package codeowners
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// Locations are the paths, relative to the repository root, searched for a
+// CODEOWNERS file, in order of precedence.
+var Locations = []string{
+	filepath.Join(".github", "CODEOWNERS"),
+	"CODEOWNERS",
+	filepath.Join("docs", "CODEOWNERS"),
+	filepath.Join(".gitlab", "CODEOWNERS"),
+}
+
+// Rule is a single CODEOWNERS entry. A rule without owners marks matching
+// files as unowned.
+type Rule struct {
+	Pattern string
+	Owners  []string
+
+	matcher gitignore.Pattern
+}
+
+// Parse reads CODEOWNERS rules from r. Comments, blank lines and GitLab
+// section headers are skipped.
+func Parse(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			continue
+		}
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		fields := strings.Fields(line)
+		pattern := strings.ReplaceAll(fields[0], `\#`, "#")
+		rules = append(rules, Rule{
+			Pattern: pattern,
+			Owners:  fields[1:],
+			matcher: gitignore.ParsePattern(pattern, nil),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CODEOWNERS: %w", err)
+	}
+	return rules, nil
+}
+
+// Load finds and parses the CODEOWNERS file of the repository at root. It
+// returns [os.ErrNotExist] when there is none.
+func Load(root string) ([]Rule, error) {
+	for _, loc := range Locations {
+		f, err := os.Open(filepath.Join(root, loc))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return Parse(f)
+	}
+	return nil, os.ErrNotExist
+}
+
+// Match returns the owners of the slash-separated path relative to the
+// repository root. As in git, the last matching rule wins.
+func Match(rules []Rule, rel string) []string {
+	parts := strings.Split(rel, "/")
+	for _, rule := range slices.Backward(rules) {
+		if rule.matcher.Match(parts, false) == gitignore.Exclude {
+			return rule.Owners
+		}
+	}
+	return nil
+}
+
+// Checker looks up the owners of files in the working directory and decides
+// whether edits to them need to be flagged. A nil Checker is valid and
+// reports no owners.
+type Checker struct {
+	root    string
+	rules   []Rule
+	teams   []string
+	confirm bool
+}
+
+// New loads the CODEOWNERS file of the working directory. It returns nil
+// when ownership checks are turned off or the project has no CODEOWNERS.
+func New(cfg *config.Config) *Checker {
+	var opts config.CodeOwnersOptions
+	if cfg.Options != nil && cfg.Options.CodeOwners != nil {
+		opts = *cfg.Options.CodeOwners
+	}
+	return newChecker(cfg.WorkingDir(), opts)
+}
+
+func newChecker(root string, opts config.CodeOwnersOptions) *Checker {
+	if opts.Mode == config.CodeOwnersOff {
+		return nil
+	}
+	rules, err := Load(root)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("Failed to load CODEOWNERS", "error", err)
+		}
+		return nil
+	}
+	return &Checker{
+		root:    root,
+		rules:   rules,
+		teams:   opts.Teams,
+		confirm: opts.Mode == config.CodeOwnersConfirm,
+	}
+}
+
+// Owners returns the owners of path, or nil if it is unowned or outside the
+// working directory.
+func (c *Checker) Owners(path string) []string {
+	if c == nil {
+		return nil
+	}
+	rel, err := filepath.Rel(c.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	return Match(c.rules, filepath.ToSlash(rel))
+}
+
+// Foreign returns the owners of path when none of them is one of the
+// configured teams. Without configured teams every owned file is foreign.
+func (c *Checker) Foreign(path string) []string {
+	owners := c.Owners(path)
+	for _, owner := range owners {
+		if slices.ContainsFunc(c.teams, func(team string) bool {
+			return strings.EqualFold(team, owner)
+		}) {
+			return nil
+		}
+	}
+	return owners
+}
+
+// RequireConfirmation reports whether edits to foreign files must always be
+// confirmed by the user.
+func (c *Checker) RequireConfirmation() bool {
+	return c != nil && c.confirm
+}
@@ -0,0 +1,66 @@
+package codeowners
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+const sample = `# Default owners
+*                 @org/core
+
+[Docs]
+/docs/            @org/docs docs@example.com
+*.md              @org/writers # inline comment
+/internal/ui/     @org/tui
+/internal/ui/generated.go
+`
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	rules, err := Parse(strings.NewReader(sample))
+	require.NoError(t, err)
+	require.Len(t, rules, 5)
+
+	require.Equal(t, []string{"@org/core"}, Match(rules, "main.go"))
+	require.Equal(t, []string{"@org/docs", "docs@example.com"}, Match(rules, "docs/guide/intro.txt"))
+	require.Equal(t, []string{"@org/writers"}, Match(rules, "docs/README.md"))
+	require.Equal(t, []string{"@org/writers"}, Match(rules, "internal/agent/NOTES.md"))
+	require.Equal(t, []string{"@org/tui"}, Match(rules, "internal/ui/model/ui.go"))
+	require.Empty(t, Match(rules, "internal/ui/generated.go"))
+	require.Equal(t, []string{"@org/core"}, Match(rules, "src/docs/a.txt"))
+}
+
+func TestChecker(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".github"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".github", "CODEOWNERS"), []byte(sample), 0o644))
+
+	opts := config.CodeOwnersOptions{Teams: []string{"@Org/TUI"}}
+	c := newChecker(dir, opts)
+	require.NotNil(t, c)
+	require.False(t, c.RequireConfirmation())
+	require.Equal(t, []string{"@org/tui"}, c.Owners(filepath.Join(dir, "internal", "ui", "ui.go")))
+	require.Empty(t, c.Foreign(filepath.Join(dir, "internal", "ui", "ui.go")))
+	require.Equal(t, []string{"@org/core"}, c.Foreign(filepath.Join(dir, "main.go")))
+	require.Empty(t, c.Owners(filepath.Join(filepath.Dir(dir), "outside.go")))
+
+	opts.Mode = config.CodeOwnersConfirm
+	require.True(t, newChecker(dir, opts).RequireConfirmation())
+
+	opts.Mode = config.CodeOwnersOff
+	require.Nil(t, newChecker(dir, opts))
+
+	require.Nil(t, newChecker(t.TempDir(), config.CodeOwnersOptions{}))
+
+	var nilChecker *Checker
+	require.Empty(t, nilChecker.Foreign(filepath.Join(dir, "main.go")))
+	require.False(t, nilChecker.RequireConfirmation())
+}
@@ -227,9 +227,10 @@ func isMarkdownFile(name string) bool {
 	return strings.HasSuffix(strings.ToLower(name), ".md")
 }
 
-func GetMCPPrompt(cfg *config.Config, clientID, promptID string, args map[string]string) (string, error) {
-	// TODO: we should pass the context down
-	result, err := mcp.GetPromptMessages(context.Background(), cfg, clientID, promptID, args)
+// GetMCPPrompt returns the user messages of an MCP prompt filled in with
+// args, joined into one prompt.
+func GetMCPPrompt(ctx context.Context, cfg *config.Config, clientID, promptID string, args map[string]string) (string, error) {
+	result, err := mcp.GetPromptMessages(ctx, cfg, clientID, promptID, args)
 	if err != nil {
 		return "", err
 	}
@@ -149,12 +149,19 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			if len(config.Models) > 0 {
 				models := []catwalk.Model{}
 				seen := make(map[string]bool)
+				known := make(map[string]catwalk.Model, len(p.Models))
+				for _, model := range p.Models {
+					known[model.ID] = model
+				}
 
 				for _, model := range config.Models {
 					if seen[model.ID] {
 						continue
 					}
 					seen[model.ID] = true
+					if k, ok := known[model.ID]; ok {
+						model = mergeModel(model, k)
+					}
 					if model.Name == "" {
 						model.Name = model.ID
 					}
@@ -204,6 +211,10 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			ExtraBody:          config.ExtraBody,
 			ExtraParams:        make(map[string]string),
 			Models:             p.Models,
+			ModelsWithoutTools: config.ModelsWithoutTools,
+			Bedrock:            config.Bedrock,
+			Azure:              config.Azure,
+			Vertex:             config.Vertex,
 		}
 
 		switch {
@@ -219,7 +230,7 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 		switch p.ID {
 		// Handle specific providers that require additional configuration
 		case catwalk.InferenceProviderVertexAI:
-			if !hasVertexCredentials(env) {
+			if !hasVertexCredentials(env, config.Vertex) {
 				if configExists {
 					slog.Warn("Skipping Vertex AI provider due to missing credentials")
 					c.Providers.Del(string(p.ID))
@@ -240,7 +251,7 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			prepared.BaseURL = endpoint
 			prepared.ExtraParams["apiVersion"] = env.Get("AZURE_OPENAI_API_VERSION")
 		case catwalk.InferenceProviderBedrock:
-			if !hasAWSCredentials(env) {
+			if !hasAWSCredentials(env) && (config.Bedrock == nil || config.Bedrock.Profile == "") {
 				if configExists {
 					slog.Warn("Skipping Bedrock provider due to missing AWS credentials")
 					c.Providers.Del(string(p.ID))
@@ -299,7 +310,9 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 		if providerConfig.APIKey == "" {
 			slog.Warn("Provider is missing API key, this might be OK for local providers", "provider", id)
 		}
-		if providerConfig.BaseURL == "" {
+		// The endpoints of Bedrock and Vertex AI follow from the region.
+		needsBaseURL := providerConfig.Type != catwalk.TypeBedrock && providerConfig.Type != catwalk.TypeVertexAI
+		if providerConfig.BaseURL == "" && needsBaseURL {
 			slog.Warn("Skipping custom provider due to missing API endpoint", "provider", id)
 			c.Providers.Del(id)
 			continue
@@ -314,7 +327,7 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			slog.Warn("Provider is missing API key, this might be OK for local providers", "provider", id)
 		}
 		baseURL, err := resolver.ResolveValue(providerConfig.BaseURL)
-		if baseURL == "" || err != nil {
+		if (baseURL == "" || err != nil) && needsBaseURL {
 			slog.Warn("Skipping custom provider due to missing API endpoint", "provider", id, "error", err)
 			c.Providers.Del(id)
 			continue
@@ -339,6 +352,45 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 	return nil
 }
 
+// mergeModel fills the fields a configured model leaves unset from the known
+// model of the same ID, so overriding, say, the pricing of a model keeps its
+// context window. Capability flags can only be turned on this way, as unset
+// and false cannot be told apart.
+func mergeModel(model, known catwalk.Model) catwalk.Model {
+	model.Name = cmp.Or(model.Name, known.Name)
+	model.CostPer1MIn = cmp.Or(model.CostPer1MIn, known.CostPer1MIn)
+	model.CostPer1MOut = cmp.Or(model.CostPer1MOut, known.CostPer1MOut)
+	model.CostPer1MInCached = cmp.Or(model.CostPer1MInCached, known.CostPer1MInCached)
+	model.CostPer1MOutCached = cmp.Or(model.CostPer1MOutCached, known.CostPer1MOutCached)
+	model.ContextWindow = cmp.Or(model.ContextWindow, known.ContextWindow)
+	model.DefaultMaxTokens = cmp.Or(model.DefaultMaxTokens, known.DefaultMaxTokens)
+	model.CanReason = model.CanReason || known.CanReason
+	if len(model.ReasoningLevels) == 0 {
+		model.ReasoningLevels = known.ReasoningLevels
+	}
+	model.DefaultReasoningEffort = cmp.Or(model.DefaultReasoningEffort, known.DefaultReasoningEffort)
+	model.SupportsImages = model.SupportsImages || known.SupportsImages
+	if model.Options.Temperature == nil {
+		model.Options.Temperature = known.Options.Temperature
+	}
+	if model.Options.TopP == nil {
+		model.Options.TopP = known.Options.TopP
+	}
+	if model.Options.TopK == nil {
+		model.Options.TopK = known.Options.TopK
+	}
+	if model.Options.FrequencyPenalty == nil {
+		model.Options.FrequencyPenalty = known.Options.FrequencyPenalty
+	}
+	if model.Options.PresencePenalty == nil {
+		model.Options.PresencePenalty = known.Options.PresencePenalty
+	}
+	if model.Options.ProviderOptions == nil {
+		model.Options.ProviderOptions = known.Options.ProviderOptions
+	}
+	return model
+}
+
 func (c *Config) setDefaults(workingDir, dataDir string) {
 	c.workingDir = workingDir
 	if c.Options == nil {
@@ -624,6 +676,24 @@ func (c *Config) configureSelectedModels(knownProviders []catwalk.Provider) erro
 	}
 	c.Models[SelectedModelTypeLarge] = large
 	c.Models[SelectedModelTypeSmall] = small
+
+	// Roles whose model is not available run on the large or small model.
+	for _, role := range ModelRoles {
+		selected, ok := c.Models[role]
+		if !ok {
+			continue
+		}
+		model := c.GetModel(selected.Provider, selected.Model)
+		if model == nil {
+			slog.Warn("Model selected for role is not available, falling back", "role", role, "provider", selected.Provider, "model", selected.Model)
+			delete(c.Models, role)
+			continue
+		}
+		if selected.MaxTokens <= 0 {
+			selected.MaxTokens = model.DefaultMaxTokens
+		}
+		c.Models[role] = selected
+	}
 	return nil
 }
 
@@ -685,7 +755,12 @@ func loadFromBytes(configs [][]byte) (*Config, error) {
 	return &config, nil
 }
 
-func hasVertexCredentials(env env.Env) bool {
+// hasVertexCredentials reports whether Vertex AI is set up: by the
+// environment, or by a project or service account key in the config.
+func hasVertexCredentials(env env.Env, opts *VertexOptions) bool {
+	if opts != nil && (opts.Project != "" || opts.Credentials != "") {
+		return true
+	}
 	hasProject := env.Get("VERTEXAI_PROJECT") != ""
 	hasLocation := env.Get("VERTEXAI_LOCATION") != ""
 	return hasProject && hasLocation
@@ -731,6 +806,18 @@ func GlobalConfig() string {
 	return filepath.Join(home.Dir(), ".config", appName, fmt.Sprintf("%s.json", appName))
 }
 
+// GlobalThemesDir returns the directory user themes are loaded from, next
+// to the global configuration file.
+func GlobalThemesDir() string {
+	return filepath.Join(filepath.Dir(GlobalConfig()), "themes")
+}
+
+// GlobalKeymap returns the path of the keymap file that rebinds the keys of
+// the TUI, next to the global configuration file.
+func GlobalKeymap() string {
+	return filepath.Join(filepath.Dir(GlobalConfig()), "keymap.json")
+}
+
 // GlobalConfigData returns the path to the main data directory for the application.
 // this config is used when the app overrides configurations instead of updating the global config.
 func GlobalConfigData() string {
@@ -755,6 +842,12 @@ func GlobalConfigData() string {
 	return filepath.Join(home.Dir(), ".local", "share", appName, fmt.Sprintf("%s.json", appName))
 }
 
+// GlobalMCPCredentials returns the path to the file the credentials of MCP
+// servers signed in to with OAuth are kept in.
+func GlobalMCPCredentials() string {
+	return filepath.Join(filepath.Dir(GlobalConfigData()), "mcp_credentials.json")
+}
+
 func assignIfNil[T any](ptr **T, val T) {
 	if *ptr == nil {
 		*ptr = &val
@@ -797,4 +890,15 @@ func GlobalSkillsDirs() []string {
 	}
 }
 
+// GlobalContextPaths returns the context files that are loaded for every
+// project, regardless of the working directory. They have the lowest
+// precedence and are overridden by project and directory context files.
+func GlobalContextPaths() []string {
+	dir := filepath.Dir(GlobalConfig())
+	return []string{
+		filepath.Join(dir, "CRUSH.md"),
+		filepath.Join(dir, "AGENTS.md"),
+	}
+}
+
 func isAppleTerminal() bool { return os.Getenv("TERM_PROGRAM") == "Apple_Terminal" }
@@ -129,6 +129,60 @@ func TestConfig_configureProvidersWithOverride(t *testing.T) {
 	require.Equal(t, "Updated", pc.Models[0].Name)
 }
 
+func TestConfig_configureProvidersMergesModelOverrides(t *testing.T) {
+	knownProviders := []catwalk.Provider{
+		{
+			ID:          "openai",
+			APIKey:      "$OPENAI_API_KEY",
+			APIEndpoint: "https://api.openai.com/v1",
+			Models: []catwalk.Model{{
+				ID:               "test-model",
+				Name:             "Test Model",
+				CostPer1MIn:      1,
+				CostPer1MOut:     2,
+				ContextWindow:    128_000,
+				DefaultMaxTokens: 8_000,
+				SupportsImages:   true,
+			}},
+		},
+	}
+
+	cfg := &Config{
+		Providers: csync.NewMap[string, ProviderConfig](),
+	}
+	cfg.Providers.Set("openai", ProviderConfig{
+		Models: []catwalk.Model{{
+			ID:           "test-model",
+			CostPer1MOut: 4,
+			CanReason:    true,
+		}},
+		ModelsWithoutTools: []string{"test-model"},
+	})
+	cfg.setDefaults("/tmp", "")
+
+	env := env.NewFromMap(map[string]string{
+		"OPENAI_API_KEY": "test-key",
+	})
+	resolver := NewEnvironmentVariableResolver(env)
+	err := cfg.configureProviders(env, resolver, knownProviders)
+	require.NoError(t, err)
+
+	pc, _ := cfg.Providers.Get("openai")
+	require.Len(t, pc.Models, 1)
+	require.Equal(t, catwalk.Model{
+		ID:               "test-model",
+		Name:             "Test Model",
+		CostPer1MIn:      1,
+		CostPer1MOut:     4,
+		ContextWindow:    128_000,
+		DefaultMaxTokens: 8_000,
+		CanReason:        true,
+		SupportsImages:   true,
+	}, pc.Models[0])
+	require.False(t, pc.SupportsTools("test-model"))
+	require.True(t, pc.SupportsTools("another-model"))
+}
+
 func TestConfig_configureProvidersWithNewProvider(t *testing.T) {
 	knownProviders := []catwalk.Provider{
 		{
@@ -281,6 +335,33 @@ func TestConfig_configureProvidersVertexAIWithCredentials(t *testing.T) {
 	require.Equal(t, "us-central1", vertexProvider.ExtraParams["location"])
 }
 
+func TestConfig_configureProvidersVertexAIServiceAccount(t *testing.T) {
+	knownProviders := []catwalk.Provider{
+		{
+			ID:     catwalk.InferenceProviderVertexAI,
+			Models: []catwalk.Model{{ID: "gemini-pro"}},
+		},
+	}
+
+	cfg := &Config{
+		Providers: csync.NewMapFrom(map[string]ProviderConfig{
+			"vertexai": {
+				Vertex: &VertexOptions{Credentials: "~/keys/vertex.json", Location: "europe-west4"},
+			},
+		}),
+	}
+	cfg.setDefaults("/tmp", "")
+	env := env.NewFromMap(map[string]string{})
+	resolver := NewEnvironmentVariableResolver(env)
+	err := cfg.configureProviders(env, resolver, knownProviders)
+	require.NoError(t, err)
+
+	vertexProvider, ok := cfg.Providers.Get("vertexai")
+	require.True(t, ok, "a service account key is enough to set up Vertex AI")
+	require.Equal(t, "~/keys/vertex.json", vertexProvider.Vertex.Credentials)
+	require.Equal(t, "europe-west4", vertexProvider.Vertex.Location)
+}
+
 func TestConfig_configureProvidersVertexAIWithoutCredentials(t *testing.T) {
 	knownProviders := []catwalk.Provider{
 		{
@@ -468,7 +549,7 @@ func TestConfig_setupAgentsWithNoDisabledTools(t *testing.T) {
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
-	assert.Equal(t, []string{"glob", "grep", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
+	assert.Equal(t, []string{"glob", "grep", "code_search", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
 }
 
 func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
@@ -486,11 +567,11 @@ func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
 
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_restart", "fetch", "agentic_fetch", "glob", "ls", "sourcegraph", "todos", "view", "write", "list_mcp_resources", "read_mcp_resource"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "emit_artifact", "edit_symbol", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_restart", "fetch", "agentic_fetch", "glob", "code_search", "ls", "sourcegraph", "todos", "view", "write", "list_mcp_resources", "read_mcp_resource", "recall", "replace_all", "run_tests", "reset_shell", "process", "verify_clean", "terminal_scrollback", "web_search", "github_issue", "github_create_pr", "github_review"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
-	assert.Equal(t, []string{"glob", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
+	assert.Equal(t, []string{"glob", "code_search", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
 }
 
 func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
@@ -499,6 +580,7 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 			DisabledTools: []string{
 				"glob",
 				"grep",
+				"code_search",
 				"ls",
 				"sourcegraph",
 				"view",
@@ -509,7 +591,7 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 	cfg.SetupAgents()
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_restart", "fetch", "agentic_fetch", "todos", "write", "list_mcp_resources", "read_mcp_resource"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "download", "emit_artifact", "edit", "edit_symbol", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_restart", "fetch", "agentic_fetch", "todos", "write", "list_mcp_resources", "read_mcp_resource", "recall", "replace_all", "run_tests", "reset_shell", "process", "verify_clean", "terminal_scrollback", "web_search", "github_issue", "github_create_pr", "github_review"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
@@ -1458,3 +1540,67 @@ func TestConfig_configureSelectedModels(t *testing.T) {
 		require.Equal(t, int64(100), large.MaxTokens)
 	})
 }
+
+func TestConfig_configureSelectedModelsRoles(t *testing.T) {
+	knownProviders := []catwalk.Provider{
+		{
+			ID:                  "openai",
+			APIKey:              "abc",
+			DefaultLargeModelID: "large-model",
+			DefaultSmallModelID: "small-model",
+			Models: []catwalk.Model{
+				{ID: "large-model", DefaultMaxTokens: 1000},
+				{ID: "small-model", DefaultMaxTokens: 500},
+				{ID: "cheap-model", DefaultMaxTokens: 200},
+			},
+		},
+	}
+
+	cfg := &Config{
+		Models: map[SelectedModelType]SelectedModel{
+			SelectedModelTypeSummary: {Provider: "openai", Model: "cheap-model"},
+			SelectedModelTypeTask:    {Provider: "openai", Model: "missing-model"},
+		},
+	}
+	cfg.setDefaults("/tmp", "")
+	env := env.NewFromMap(map[string]string{})
+	resolver := NewEnvironmentVariableResolver(env)
+	require.NoError(t, cfg.configureProviders(env, resolver, knownProviders))
+	require.NoError(t, cfg.configureSelectedModels(knownProviders))
+
+	summary := cfg.Models[SelectedModelTypeSummary]
+	require.Equal(t, "cheap-model", summary.Model)
+	require.Equal(t, int64(200), summary.MaxTokens)
+	require.Equal(t, SelectedModelTypeSummary, cfg.ModelTypeFor(SelectedModelTypeSummary))
+
+	// Unavailable models and unselected roles fall back.
+	require.NotContains(t, cfg.Models, SelectedModelTypeTask)
+	require.Equal(t, SelectedModelTypeLarge, cfg.ModelTypeFor(SelectedModelTypeTask))
+	require.Equal(t, SelectedModelTypeSmall, cfg.ModelTypeFor(SelectedModelTypeTitle))
+
+	delete(cfg.Models, SelectedModelTypeSummary)
+	cfg.Options.SummaryModel = SelectedModelTypeSmall
+	require.Equal(t, SelectedModelTypeSmall, cfg.ModelTypeFor(SelectedModelTypeSummary))
+}
+
+func TestConfig_PromptOverride(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cfg := &Config{}
+	cfg.setDefaults(dir, "")
+	require.Empty(t, cfg.PromptOverride(PromptCoder))
+
+	prompts := filepath.Join(cfg.Options.DataDirectory, "prompts")
+	require.NoError(t, os.MkdirAll(prompts, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(prompts, "coder.tmpl"), []byte("{{.BuiltIn}}"), 0o644))
+	require.Equal(t, filepath.Join(prompts, "coder.tmpl"), cfg.PromptOverride(PromptCoder))
+	require.Empty(t, cfg.PromptOverride(PromptTask))
+
+	cfg.Options.PromptTemplate = "legacy.tpl"
+	require.Equal(t, filepath.Join(dir, "legacy.tpl"), cfg.PromptOverride(PromptCoder))
+
+	cfg.Options.PromptOverrides = &PromptOverrides{Coder: "house/coder.tmpl", Task: "/abs/task.tmpl"}
+	require.Equal(t, filepath.Join(dir, "house", "coder.tmpl"), cfg.PromptOverride(PromptCoder))
+	require.Equal(t, "/abs/task.tmpl", cfg.PromptOverride(PromptTask))
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// defaultOpenRouterURL is the API the live OpenRouter model catalog is
+// fetched from.
+const defaultOpenRouterURL = "https://openrouter.ai/api/v1"
+
+type openrouterClient interface {
+	Models(context.Context) ([]catwalk.Model, error)
+}
+
+var _ syncer[[]catwalk.Model] = (*openrouterSync)(nil)
+
+// openrouterSync fetches the live model catalog of OpenRouter, which lists
+// many more models, with fresher prices, than Catwalk does.
+type openrouterSync struct {
+	once       sync.Once
+	result     []catwalk.Model
+	cache      cache[[]catwalk.Model]
+	client     openrouterClient
+	autoupdate bool
+	init       atomic.Bool
+}
+
+func (s *openrouterSync) Init(client openrouterClient, path string, autoupdate bool) {
+	s.client = client
+	s.cache = newCache[[]catwalk.Model](path)
+	s.autoupdate = autoupdate
+	s.init.Store(true)
+}
+
+// Get returns the models of the catalog, or none when it was never fetched
+// and cannot be, in which case those of Catwalk are kept. Without auto
+// update, the catalog last fetched with crush update-providers is used.
+func (s *openrouterSync) Get(ctx context.Context) ([]catwalk.Model, error) {
+	if !s.init.Load() {
+		panic("called Get before Init")
+	}
+
+	var throwErr error
+	s.once.Do(func() {
+		cached, _, _ := s.cache.Get()
+		if !s.autoupdate {
+			s.result = cached
+			return
+		}
+
+		slog.Info("Fetching models from OpenRouter")
+		result, err := s.client.Models(ctx)
+		if err != nil {
+			slog.Warn("OpenRouter models not updated", "error", err)
+			s.result = cached
+			return
+		}
+		if len(result) == 0 {
+			s.result = cached
+			throwErr = errors.New("empty model list from OpenRouter")
+			return
+		}
+
+		s.result = result
+		throwErr = s.cache.Store(result)
+	})
+	return s.result, throwErr
+}
+
+var _ openrouterClient = realOpenRouterClient{}
+
+type realOpenRouterClient struct {
+	baseURL string
+}
+
+// Models implements openrouterClient.
+func (r realOpenRouterClient) Models(ctx context.Context) ([]catwalk.Model, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return decodeOpenRouterModels(resp.Body)
+}
+
+// openrouterModel is a model of the OpenRouter catalog. Prices are in
+// dollars per token.
+type openrouterModel struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	ContextLength int64  `json:"context_length"`
+	Pricing       struct {
+		Prompt          string `json:"prompt"`
+		Completion      string `json:"completion"`
+		InputCacheRead  string `json:"input_cache_read"`
+		InputCacheWrite string `json:"input_cache_write"`
+	} `json:"pricing"`
+	TopProvider struct {
+		ContextLength       int64 `json:"context_length"`
+		MaxCompletionTokens int64 `json:"max_completion_tokens"`
+	} `json:"top_provider"`
+	Architecture struct {
+		InputModalities []string `json:"input_modalities"`
+	} `json:"architecture"`
+	SupportedParameters []string `json:"supported_parameters"`
+}
+
+// decodeOpenRouterModels reads the catalog of the OpenRouter models API,
+// keeping the models that can call tools, which the agent needs.
+func decodeOpenRouterModels(r io.Reader) ([]catwalk.Model, error) {
+	var catalog struct {
+		Data []openrouterModel `json:"data"`
+	}
+	if err := json.NewDecoder(r).Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	models := make([]catwalk.Model, 0, len(catalog.Data))
+	for _, m := range catalog.Data {
+		if !slices.Contains(m.SupportedParameters, "tools") {
+			continue
+		}
+		in, out := perMillion(m.Pricing.Prompt), perMillion(m.Pricing.Completion)
+		if in < 0 || out < 0 {
+			continue // routers, priced by the model they pick
+		}
+		model := catwalk.Model{
+			ID:                 m.ID,
+			Name:               m.Name,
+			CostPer1MIn:        in,
+			CostPer1MOut:       out,
+			CostPer1MInCached:  perMillion(m.Pricing.InputCacheWrite),
+			CostPer1MOutCached: perMillion(m.Pricing.InputCacheRead),
+			ContextWindow:      firstPositive(m.TopProvider.ContextLength, m.ContextLength),
+			DefaultMaxTokens:   m.TopProvider.MaxCompletionTokens,
+			SupportsImages:     slices.Contains(m.Architecture.InputModalities, "image"),
+		}
+		if model.DefaultMaxTokens <= 0 || model.DefaultMaxTokens > model.ContextWindow/2 {
+			model.DefaultMaxTokens = model.ContextWindow / 10
+		}
+		if slices.Contains(m.SupportedParameters, "reasoning") {
+			model.CanReason = true
+			model.ReasoningLevels = []string{"low", "medium", "high"}
+			model.DefaultReasoningEffort = "medium"
+		}
+		models = append(models, model)
+	}
+	return models, nil
+}
+
+// perMillion converts a price per token to a price per million tokens.
+func perMillion(price string) float64 {
+	v, err := strconv.ParseFloat(price, 64)
+	if err != nil {
+		return 0
+	}
+	return v * 1e6
+}
+
+func firstPositive(values ...int64) int64 {
+	for _, v := range values {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+// withOpenRouterModels sets the models of the OpenRouter provider of
+// providers to those of the live catalog. The settings Catwalk has for a
+// model are kept, with the context window and prices of the catalog.
+func withOpenRouterModels(providers []catwalk.Provider, models []catwalk.Model) {
+	if len(models) == 0 {
+		return
+	}
+	for i, p := range providers {
+		if p.ID != catwalk.InferenceProviderOpenRouter {
+			continue
+		}
+		known := make(map[string]catwalk.Model, len(p.Models))
+		for _, m := range p.Models {
+			known[m.ID] = m
+		}
+		merged := make([]catwalk.Model, 0, len(models))
+		for _, live := range models {
+			m, ok := known[live.ID]
+			if !ok {
+				merged = append(merged, live)
+				continue
+			}
+			m.ContextWindow = live.ContextWindow
+			m.CostPer1MIn, m.CostPer1MOut = live.CostPer1MIn, live.CostPer1MOut
+			m.CostPer1MInCached, m.CostPer1MOutCached = live.CostPer1MInCached, live.CostPer1MOutCached
+			merged = append(merged, m)
+			delete(known, live.ID)
+		}
+		// The default models stay selectable should the catalog miss them.
+		for _, id := range []string{p.DefaultLargeModelID, p.DefaultSmallModelID} {
+			if m, ok := known[id]; ok {
+				merged = append(merged, m)
+				delete(known, id)
+			}
+		}
+		providers[i].Models = merged
+	}
+}
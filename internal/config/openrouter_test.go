@@ -0,0 +1,154 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/stretchr/testify/require"
+)
+
+type mockOpenRouterClient struct {
+	models    []catwalk.Model
+	err       error
+	callCount int
+}
+
+func (m *mockOpenRouterClient) Models(context.Context) ([]catwalk.Model, error) {
+	m.callCount++
+	return m.models, m.err
+}
+
+const openrouterCatalog = `{"data":[
+	{
+		"id": "anthropic/claude-sonnet-4",
+		"name": "Anthropic: Claude Sonnet 4",
+		"context_length": 200000,
+		"pricing": {"prompt": "0.000003", "completion": "0.000015", "input_cache_read": "0.0000003", "input_cache_write": "0.00000375"},
+		"top_provider": {"context_length": 1000000, "max_completion_tokens": 64000},
+		"architecture": {"input_modalities": ["text", "image"]},
+		"supported_parameters": ["tools", "reasoning", "max_tokens"]
+	},
+	{
+		"id": "acme/no-tools",
+		"name": "Acme: No Tools",
+		"context_length": 8192,
+		"pricing": {"prompt": "0", "completion": "0"},
+		"supported_parameters": ["max_tokens"]
+	},
+	{
+		"id": "openrouter/auto",
+		"name": "Auto Router",
+		"context_length": 2000000,
+		"pricing": {"prompt": "-1", "completion": "-1"},
+		"supported_parameters": ["tools"]
+	},
+	{
+		"id": "acme/small",
+		"name": "Acme: Small",
+		"context_length": 32000,
+		"pricing": {"prompt": "0.0000001", "completion": "0.0000002"},
+		"top_provider": {"max_completion_tokens": null},
+		"supported_parameters": ["tools"]
+	}
+]}`
+
+func TestDecodeOpenRouterModels(t *testing.T) {
+	t.Parallel()
+
+	models, err := decodeOpenRouterModels(strings.NewReader(openrouterCatalog))
+	require.NoError(t, err)
+	require.Len(t, models, 2, "models without tools and routers are left out")
+
+	sonnet := models[0]
+	require.Equal(t, "anthropic/claude-sonnet-4", sonnet.ID)
+	require.Equal(t, int64(1000000), sonnet.ContextWindow)
+	require.Equal(t, int64(64000), sonnet.DefaultMaxTokens)
+	require.InDelta(t, 3, sonnet.CostPer1MIn, 1e-9)
+	require.InDelta(t, 15, sonnet.CostPer1MOut, 1e-9)
+	require.InDelta(t, 3.75, sonnet.CostPer1MInCached, 1e-9)
+	require.InDelta(t, 0.3, sonnet.CostPer1MOutCached, 1e-9)
+	require.True(t, sonnet.CanReason)
+	require.True(t, sonnet.SupportsImages)
+
+	small := models[1]
+	require.Equal(t, int64(32000), small.ContextWindow)
+	require.Equal(t, int64(3200), small.DefaultMaxTokens)
+	require.False(t, small.CanReason)
+	require.False(t, small.SupportsImages)
+}
+
+func TestOpenRouterSync_GetFreshModels(t *testing.T) {
+	t.Parallel()
+
+	path := t.TempDir() + "/openrouter.json"
+	client := &mockOpenRouterClient{models: []catwalk.Model{{ID: "a/b"}}}
+	syncer := &openrouterSync{}
+	syncer.Init(client, path, true)
+
+	models, err := syncer.Get(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []catwalk.Model{{ID: "a/b"}}, models)
+
+	cached, _, err := newCache[[]catwalk.Model](path).Get()
+	require.NoError(t, err)
+	require.Equal(t, models, cached)
+}
+
+func TestOpenRouterSync_GetErrorUsesCached(t *testing.T) {
+	t.Parallel()
+
+	path := t.TempDir() + "/openrouter.json"
+	require.NoError(t, newCache[[]catwalk.Model](path).Store([]catwalk.Model{{ID: "cached/model"}}))
+	syncer := &openrouterSync{}
+	syncer.Init(&mockOpenRouterClient{err: errors.New("offline")}, path, true)
+
+	models, err := syncer.Get(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []catwalk.Model{{ID: "cached/model"}}, models)
+}
+
+func TestOpenRouterSync_GetWithoutAutoUpdate(t *testing.T) {
+	t.Parallel()
+
+	path := t.TempDir() + "/openrouter.json"
+	client := &mockOpenRouterClient{models: []catwalk.Model{{ID: "fresh/model"}}}
+	syncer := &openrouterSync{}
+	syncer.Init(client, path, false)
+
+	models, err := syncer.Get(t.Context())
+	require.NoError(t, err)
+	require.Empty(t, models)
+	require.Zero(t, client.callCount)
+}
+
+func TestWithOpenRouterModels(t *testing.T) {
+	t.Parallel()
+
+	providers := []catwalk.Provider{
+		{ID: catwalk.InferenceProviderOpenAI, Models: []catwalk.Model{{ID: "gpt-5"}}},
+		{
+			ID:                  catwalk.InferenceProviderOpenRouter,
+			DefaultLargeModelID: "anthropic/claude-sonnet-4",
+			DefaultSmallModelID: "gone/small",
+			Models: []catwalk.Model{
+				{ID: "anthropic/claude-sonnet-4", Name: "Claude Sonnet 4", ContextWindow: 200000, DefaultMaxTokens: 50000, CostPer1MIn: 2},
+				{ID: "gone/small", Name: "Small"},
+				{ID: "gone/other"},
+			},
+		},
+	}
+	withOpenRouterModels(providers, []catwalk.Model{
+		{ID: "anthropic/claude-sonnet-4", Name: "Anthropic: Claude Sonnet 4", ContextWindow: 1000000, DefaultMaxTokens: 64000, CostPer1MIn: 3},
+		{ID: "acme/new", Name: "Acme: New"},
+	})
+
+	require.Equal(t, []catwalk.Model{{ID: "gpt-5"}}, providers[0].Models)
+	require.Equal(t, []catwalk.Model{
+		{ID: "anthropic/claude-sonnet-4", Name: "Claude Sonnet 4", ContextWindow: 1000000, DefaultMaxTokens: 50000, CostPer1MIn: 3},
+		{ID: "acme/new", Name: "Acme: New"},
+		{ID: "gone/small", Name: "Small"},
+	}, providers[1].Models)
+}
@@ -125,9 +125,45 @@ func UpdateHyper(pathOrURL string) error {
 	return nil
 }
 
+// UpdateOpenRouter updates the OpenRouter model catalog from a specified
+// URL or file, in the format of the OpenRouter models API.
+func UpdateOpenRouter(pathOrURL string) error {
+	var models []catwalk.Model
+	pathOrURL = cmp.Or(pathOrURL, os.Getenv("OPENROUTER_URL"), defaultOpenRouterURL)
+
+	switch {
+	case strings.HasPrefix(pathOrURL, "http://") || strings.HasPrefix(pathOrURL, "https://"):
+		var err error
+		models, err = realOpenRouterClient{baseURL: pathOrURL}.Models(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to fetch models from OpenRouter: %w", err)
+		}
+	default:
+		f, err := os.Open(pathOrURL)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		defer f.Close() //nolint:errcheck
+		if models, err = decodeOpenRouterModels(f); err != nil {
+			return err
+		}
+	}
+	if len(models) == 0 {
+		return fmt.Errorf("no models found in the provided source")
+	}
+
+	if err := newCache[[]catwalk.Model](cachePathFor("openrouter")).Store(models); err != nil {
+		return fmt.Errorf("failed to save OpenRouter models to cache: %w", err)
+	}
+
+	slog.Info("OpenRouter models updated successfully", "count", len(models), "from", pathOrURL, "to", cachePathFor("openrouter"))
+	return nil
+}
+
 var (
-	catwalkSyncer = &catwalkSync{}
-	hyperSyncer   = &hyperSync{}
+	catwalkSyncer    = &catwalkSync{}
+	hyperSyncer      = &hyperSync{}
+	openrouterSyncer = &openrouterSync{}
 )
 
 // Providers returns the list of providers, taking into account cached results
@@ -183,14 +219,43 @@ func Providers(cfg *Config) ([]catwalk.Provider, error) {
 			providers.Append(item)
 		})
 
+		var openrouterModels []catwalk.Model
+		wg.Go(func() {
+			if customProvidersOnly || !usesOpenRouter(cfg) {
+				return
+			}
+			baseURL := cmp.Or(os.Getenv("OPENROUTER_URL"), defaultOpenRouterURL)
+			openrouterSyncer.Init(realOpenRouterClient{baseURL: baseURL}, cachePathFor("openrouter"), autoupdate)
+
+			models, err := openrouterSyncer.Get(ctx)
+			if err != nil {
+				slog.Warn("Failed to update the OpenRouter models", "error", err)
+			}
+			openrouterModels = models
+		})
+
 		wg.Wait()
 
 		providerList = slices.Collect(providers.Seq())
+		withOpenRouterModels(providerList, openrouterModels)
 		providerErr = errors.Join(errs...)
 	})
 	return providerList, providerErr
 }
 
+// usesOpenRouter reports whether OpenRouter is set up, by its API key or in
+// the config, so its catalog is worth fetching.
+func usesOpenRouter(cfg *Config) bool {
+	if os.Getenv("OPENROUTER_API_KEY") != "" {
+		return true
+	}
+	if cfg.Providers == nil {
+		return false
+	}
+	p, ok := cfg.Providers.Get(string(catwalk.InferenceProviderOpenRouter))
+	return ok && !p.Disable
+}
+
 type cache[T any] struct {
 	path string
 }
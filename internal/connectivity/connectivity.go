@@ -0,0 +1,224 @@
+// Package connectivity tracks whether the providers can be reached, so that
+// turns failing because the network went away can wait for it to come back
+// instead of failing.
+package connectivity
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+const (
+	initialProbeDelay = time.Second
+	maxProbeDelay     = 30 * time.Second
+	probeTimeout      = 5 * time.Second
+	// fallbackProbeAddr is dialed when the failed request does not say
+	// where it was sent. Reaching it needs DNS as well as a route out.
+	fallbackProbeAddr = "one.one.one.one:443"
+)
+
+// Event is published when connectivity is lost or comes back, and when a
+// turn starts or stops waiting for it.
+type Event struct {
+	Online bool
+	// Pending is the number of turns waiting for connectivity.
+	Pending int
+}
+
+var broker = pubsub.NewBroker[Event]()
+
+// Subscribe returns a channel that receives an event every time
+// connectivity changes.
+func Subscribe(ctx context.Context) <-chan pubsub.Event[Event] {
+	return broker.Subscribe(ctx)
+}
+
+// offlineErrors are the system errors of a machine that cannot reach the
+// network at all, as opposed to a provider that is down.
+var offlineErrors = []error{
+	syscall.ENETDOWN,
+	syscall.ENETUNREACH,
+	syscall.EHOSTUNREACH,
+	syscall.ECONNREFUSED,
+	syscall.ECONNRESET,
+	syscall.ECONNABORTED,
+	syscall.ETIMEDOUT,
+}
+
+// offlineMessages match the same errors once they lost their type.
+var offlineMessages = []string{
+	"no such host",
+	"network is unreachable",
+	"network is down",
+	"no route to host",
+	"connection refused",
+	"connection reset",
+	"i/o timeout",
+	"dial tcp",
+}
+
+// IsNetworkError reports whether err is a request failing because the
+// network could not be reached. Errors with a response from the provider
+// are not.
+func IsNetworkError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var providerErr *fantasy.ProviderError
+	if errors.As(err, &providerErr) {
+		if providerErr.StatusCode != 0 {
+			return false
+		}
+		if providerErr.Cause != nil {
+			err = providerErr.Cause
+		}
+	}
+	for _, target := range offlineErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range offlineMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProbeAddr returns the host and port the request that failed with err was
+// sent to, or a well-known address when err does not say.
+func ProbeAddr(err error) string {
+	var raw string
+	var urlErr *url.Error
+	var providerErr *fantasy.ProviderError
+	switch {
+	case errors.As(err, &urlErr):
+		raw = urlErr.URL
+	case errors.As(err, &providerErr):
+		raw = providerErr.URL
+	}
+	u, parseErr := url.Parse(raw)
+	if raw == "" || parseErr != nil || u.Hostname() == "" {
+		return fallbackProbeAddr
+	}
+	if port := u.Port(); port != "" {
+		return net.JoinHostPort(u.Hostname(), port)
+	}
+	if u.Scheme == "http" {
+		return net.JoinHostPort(u.Hostname(), "80")
+	}
+	return net.JoinHostPort(u.Hostname(), "443")
+}
+
+// Monitor holds turns until connectivity returns. Once a turn waits, the
+// monitor dials the address the turn failed to reach, less and less often,
+// until it answers.
+type Monitor struct {
+	probe func(ctx context.Context, addr string) error
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu      sync.Mutex
+	pending int
+	// online is closed when connectivity returns. It is nil while online.
+	online chan struct{}
+}
+
+// NewMonitor creates a monitor that is online until a turn waits on it.
+func NewMonitor() *Monitor {
+	return &Monitor{probe: dial, sleep: sleep}
+}
+
+func dial(ctx context.Context, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Online reports whether no turn is waiting for connectivity.
+func (m *Monitor) Online() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.online == nil
+}
+
+// Wait marks connectivity as lost and waits until addr can be reached
+// again, or ctx is done.
+func (m *Monitor) Wait(ctx context.Context, addr string) error {
+	m.mu.Lock()
+	if m.online == nil {
+		m.online = make(chan struct{})
+		go m.probeUntilOnline(m.online, addr)
+	}
+	online := m.online
+	m.pending++
+	broker.Publish(pubsub.UpdatedEvent, Event{Pending: m.pending})
+	m.mu.Unlock()
+
+	select {
+	case <-online:
+		return nil
+	case <-ctx.Done():
+		m.mu.Lock()
+		m.pending--
+		if m.online != nil {
+			broker.Publish(pubsub.UpdatedEvent, Event{Pending: m.pending})
+		}
+		m.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (m *Monitor) probeUntilOnline(online chan struct{}, addr string) {
+	ctx := context.Background()
+	delay := initialProbeDelay
+	for {
+		_ = m.sleep(ctx, delay)
+		probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		err := m.probe(probeCtx, addr)
+		cancel()
+		if err == nil {
+			break
+		}
+		delay = min(delay*2, maxProbeDelay)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.online = nil
+	m.pending = 0
+	close(online)
+	broker.Publish(pubsub.UpdatedEvent, Event{Online: true})
+}
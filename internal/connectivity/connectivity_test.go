@@ -0,0 +1,73 @@
+package connectivity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsNetworkError(t *testing.T) {
+	t.Parallel()
+
+	dnsErr := &url.Error{Op: "Post", URL: "https://api.anthropic.com/v1/messages", Err: &net.DNSError{Err: "no such host", Name: "api.anthropic.com"}}
+	require.True(t, IsNetworkError(dnsErr))
+	require.True(t, IsNetworkError(&fantasy.ProviderError{Cause: fmt.Errorf("read: %w", syscall.ENETUNREACH)}))
+	require.True(t, IsNetworkError(&net.OpError{Op: "dial", Err: errors.New("boom")}))
+	require.True(t, IsNetworkError(errors.New("dial tcp 1.2.3.4:443: connect: network is unreachable")))
+	require.False(t, IsNetworkError(&fantasy.ProviderError{StatusCode: http.StatusServiceUnavailable, Cause: syscall.ECONNRESET}))
+	require.False(t, IsNetworkError(context.Canceled))
+	require.False(t, IsNetworkError(errors.New("invalid request")))
+	require.False(t, IsNetworkError(nil))
+}
+
+func TestProbeAddr(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "api.anthropic.com:443", ProbeAddr(&url.Error{URL: "https://api.anthropic.com/v1/messages", Err: syscall.ECONNREFUSED}))
+	require.Equal(t, "localhost:11434", ProbeAddr(&fantasy.ProviderError{URL: "http://localhost:11434/v1/chat"}))
+	require.Equal(t, "example.com:80", ProbeAddr(&url.Error{URL: "http://example.com/v1", Err: syscall.ECONNREFUSED}))
+	require.Equal(t, fallbackProbeAddr, ProbeAddr(syscall.ENETUNREACH))
+}
+
+func TestMonitorWaitsUntilOnline(t *testing.T) {
+	t.Parallel()
+
+	var probes atomic.Int32
+	m := NewMonitor()
+	m.sleep = func(context.Context, time.Duration) error { return nil }
+	m.probe = func(_ context.Context, addr string) error {
+		require.Equal(t, "api.example.com:443", addr)
+		if probes.Add(1) < 3 {
+			return syscall.ENETUNREACH
+		}
+		return nil
+	}
+	require.True(t, m.Online())
+
+	require.NoError(t, m.Wait(t.Context(), "api.example.com:443"))
+	require.Equal(t, int32(3), probes.Load())
+	require.True(t, m.Online())
+}
+
+func TestMonitorWaitCanceled(t *testing.T) {
+	t.Parallel()
+
+	m := NewMonitor()
+	// The probe never gets to run.
+	m.sleep = func(context.Context, time.Duration) error { select {} }
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	require.ErrorIs(t, m.Wait(ctx, "api.example.com:443"), context.Canceled)
+	require.False(t, m.Online(), "connectivity is still being probed")
+}
@@ -24,15 +24,24 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.addSessionTagStmt, err = db.PrepareContext(ctx, addSessionTag); err != nil {
+		return nil, fmt.Errorf("error preparing query AddSessionTag: %w", err)
+	}
 	if q.createFileStmt, err = db.PrepareContext(ctx, createFile); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateFile: %w", err)
 	}
 	if q.createMessageStmt, err = db.PrepareContext(ctx, createMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateMessage: %w", err)
 	}
+	if q.createRecallTurnStmt, err = db.PrepareContext(ctx, createRecallTurn); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateRecallTurn: %w", err)
+	}
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
+	if q.createUsageRecordStmt, err = db.PrepareContext(ctx, createUsageRecord); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateUsageRecord: %w", err)
+	}
 	if q.deleteFileStmt, err = db.PrepareContext(ctx, deleteFile); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteFile: %w", err)
 	}
@@ -45,12 +54,21 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.deleteSessionFilesStmt, err = db.PrepareContext(ctx, deleteSessionFiles); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSessionFiles: %w", err)
 	}
+	if q.deleteSessionLabelStmt, err = db.PrepareContext(ctx, deleteSessionLabel); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSessionLabel: %w", err)
+	}
 	if q.deleteSessionMessagesStmt, err = db.PrepareContext(ctx, deleteSessionMessages); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSessionMessages: %w", err)
 	}
+	if q.deleteSessionTagsStmt, err = db.PrepareContext(ctx, deleteSessionTags); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSessionTags: %w", err)
+	}
 	if q.getAverageResponseTimeStmt, err = db.PrepareContext(ctx, getAverageResponseTime); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAverageResponseTime: %w", err)
 	}
+	if q.getCostByModelStmt, err = db.PrepareContext(ctx, getCostByModel); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCostByModel: %w", err)
+	}
 	if q.getFileStmt, err = db.PrepareContext(ctx, getFile); err != nil {
 		return nil, fmt.Errorf("error preparing query GetFile: %w", err)
 	}
@@ -72,6 +90,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getSessionByIDStmt, err = db.PrepareContext(ctx, getSessionByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSessionByID: %w", err)
 	}
+	if q.getSessionLabelStmt, err = db.PrepareContext(ctx, getSessionLabel); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSessionLabel: %w", err)
+	}
 	if q.getToolUsageStmt, err = db.PrepareContext(ctx, getToolUsage); err != nil {
 		return nil, fmt.Errorf("error preparing query GetToolUsage: %w", err)
 	}
@@ -87,9 +108,18 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getUsageByHourStmt, err = db.PrepareContext(ctx, getUsageByHour); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUsageByHour: %w", err)
 	}
+	if q.getUsageByLabelStmt, err = db.PrepareContext(ctx, getUsageByLabel); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUsageByLabel: %w", err)
+	}
 	if q.getUsageByModelStmt, err = db.PrepareContext(ctx, getUsageByModel); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUsageByModel: %w", err)
 	}
+	if q.getUsageBySessionStmt, err = db.PrepareContext(ctx, getUsageBySession); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUsageBySession: %w", err)
+	}
+	if q.listAllSessionTagsStmt, err = db.PrepareContext(ctx, listAllSessionTags); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAllSessionTags: %w", err)
+	}
 	if q.listAllUserMessagesStmt, err = db.PrepareContext(ctx, listAllUserMessages); err != nil {
 		return nil, fmt.Errorf("error preparing query ListAllUserMessages: %w", err)
 	}
@@ -102,15 +132,27 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.listLatestSessionFilesStmt, err = db.PrepareContext(ctx, listLatestSessionFiles); err != nil {
 		return nil, fmt.Errorf("error preparing query ListLatestSessionFiles: %w", err)
 	}
+	if q.listMessagesBeforeBySessionStmt, err = db.PrepareContext(ctx, listMessagesBeforeBySession); err != nil {
+		return nil, fmt.Errorf("error preparing query ListMessagesBeforeBySession: %w", err)
+	}
 	if q.listMessagesBySessionStmt, err = db.PrepareContext(ctx, listMessagesBySession); err != nil {
 		return nil, fmt.Errorf("error preparing query ListMessagesBySession: %w", err)
 	}
 	if q.listNewFilesStmt, err = db.PrepareContext(ctx, listNewFiles); err != nil {
 		return nil, fmt.Errorf("error preparing query ListNewFiles: %w", err)
 	}
+	if q.listRecallTurnsBySessionStmt, err = db.PrepareContext(ctx, listRecallTurnsBySession); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRecallTurnsBySession: %w", err)
+	}
+	if q.listRecentMessagesBySessionStmt, err = db.PrepareContext(ctx, listRecentMessagesBySession); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRecentMessagesBySession: %w", err)
+	}
 	if q.listSessionReadFilesStmt, err = db.PrepareContext(ctx, listSessionReadFiles); err != nil {
 		return nil, fmt.Errorf("error preparing query ListSessionReadFiles: %w", err)
 	}
+	if q.listSessionTagsStmt, err = db.PrepareContext(ctx, listSessionTags); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSessionTags: %w", err)
+	}
 	if q.listSessionsStmt, err = db.PrepareContext(ctx, listSessions); err != nil {
 		return nil, fmt.Errorf("error preparing query ListSessions: %w", err)
 	}
@@ -120,20 +162,40 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.recordFileReadStmt, err = db.PrepareContext(ctx, recordFileRead); err != nil {
 		return nil, fmt.Errorf("error preparing query RecordFileRead: %w", err)
 	}
+	if q.setSessionLabelStmt, err = db.PrepareContext(ctx, setSessionLabel); err != nil {
+		return nil, fmt.Errorf("error preparing query SetSessionLabel: %w", err)
+	}
 	if q.updateMessageStmt, err = db.PrepareContext(ctx, updateMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateMessage: %w", err)
 	}
 	if q.updateSessionStmt, err = db.PrepareContext(ctx, updateSession); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateSession: %w", err)
 	}
+	if q.updateSessionChatOnlyStmt, err = db.PrepareContext(ctx, updateSessionChatOnly); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateSessionChatOnly: %w", err)
+	}
+	if q.updateSessionPinnedStmt, err = db.PrepareContext(ctx, updateSessionPinned); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateSessionPinned: %w", err)
+	}
+	if q.updateSessionTitleStmt, err = db.PrepareContext(ctx, updateSessionTitle); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateSessionTitle: %w", err)
+	}
 	if q.updateSessionTitleAndUsageStmt, err = db.PrepareContext(ctx, updateSessionTitleAndUsage); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateSessionTitleAndUsage: %w", err)
 	}
+	if q.updateSessionTranslateStmt, err = db.PrepareContext(ctx, updateSessionTranslate); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateSessionTranslate: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.addSessionTagStmt != nil {
+		if cerr := q.addSessionTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addSessionTagStmt: %w", cerr)
+		}
+	}
 	if q.createFileStmt != nil {
 		if cerr := q.createFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createFileStmt: %w", cerr)
@@ -144,11 +206,21 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createMessageStmt: %w", cerr)
 		}
 	}
+	if q.createRecallTurnStmt != nil {
+		if cerr := q.createRecallTurnStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createRecallTurnStmt: %w", cerr)
+		}
+	}
 	if q.createSessionStmt != nil {
 		if cerr := q.createSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
 		}
 	}
+	if q.createUsageRecordStmt != nil {
+		if cerr := q.createUsageRecordStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createUsageRecordStmt: %w", cerr)
+		}
+	}
 	if q.deleteFileStmt != nil {
 		if cerr := q.deleteFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteFileStmt: %w", cerr)
@@ -169,16 +241,31 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteSessionFilesStmt: %w", cerr)
 		}
 	}
+	if q.deleteSessionLabelStmt != nil {
+		if cerr := q.deleteSessionLabelStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSessionLabelStmt: %w", cerr)
+		}
+	}
 	if q.deleteSessionMessagesStmt != nil {
 		if cerr := q.deleteSessionMessagesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteSessionMessagesStmt: %w", cerr)
 		}
 	}
+	if q.deleteSessionTagsStmt != nil {
+		if cerr := q.deleteSessionTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSessionTagsStmt: %w", cerr)
+		}
+	}
 	if q.getAverageResponseTimeStmt != nil {
 		if cerr := q.getAverageResponseTimeStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAverageResponseTimeStmt: %w", cerr)
 		}
 	}
+	if q.getCostByModelStmt != nil {
+		if cerr := q.getCostByModelStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCostByModelStmt: %w", cerr)
+		}
+	}
 	if q.getFileStmt != nil {
 		if cerr := q.getFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getFileStmt: %w", cerr)
@@ -214,6 +301,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getSessionByIDStmt: %w", cerr)
 		}
 	}
+	if q.getSessionLabelStmt != nil {
+		if cerr := q.getSessionLabelStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSessionLabelStmt: %w", cerr)
+		}
+	}
 	if q.getToolUsageStmt != nil {
 		if cerr := q.getToolUsageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getToolUsageStmt: %w", cerr)
@@ -239,11 +331,26 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getUsageByHourStmt: %w", cerr)
 		}
 	}
+	if q.getUsageByLabelStmt != nil {
+		if cerr := q.getUsageByLabelStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUsageByLabelStmt: %w", cerr)
+		}
+	}
 	if q.getUsageByModelStmt != nil {
 		if cerr := q.getUsageByModelStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getUsageByModelStmt: %w", cerr)
 		}
 	}
+	if q.getUsageBySessionStmt != nil {
+		if cerr := q.getUsageBySessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUsageBySessionStmt: %w", cerr)
+		}
+	}
+	if q.listAllSessionTagsStmt != nil {
+		if cerr := q.listAllSessionTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAllSessionTagsStmt: %w", cerr)
+		}
+	}
 	if q.listAllUserMessagesStmt != nil {
 		if cerr := q.listAllUserMessagesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listAllUserMessagesStmt: %w", cerr)
@@ -264,6 +371,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listLatestSessionFilesStmt: %w", cerr)
 		}
 	}
+	if q.listMessagesBeforeBySessionStmt != nil {
+		if cerr := q.listMessagesBeforeBySessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listMessagesBeforeBySessionStmt: %w", cerr)
+		}
+	}
 	if q.listMessagesBySessionStmt != nil {
 		if cerr := q.listMessagesBySessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listMessagesBySessionStmt: %w", cerr)
@@ -274,11 +386,26 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listNewFilesStmt: %w", cerr)
 		}
 	}
+	if q.listRecallTurnsBySessionStmt != nil {
+		if cerr := q.listRecallTurnsBySessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRecallTurnsBySessionStmt: %w", cerr)
+		}
+	}
+	if q.listRecentMessagesBySessionStmt != nil {
+		if cerr := q.listRecentMessagesBySessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRecentMessagesBySessionStmt: %w", cerr)
+		}
+	}
 	if q.listSessionReadFilesStmt != nil {
 		if cerr := q.listSessionReadFilesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listSessionReadFilesStmt: %w", cerr)
 		}
 	}
+	if q.listSessionTagsStmt != nil {
+		if cerr := q.listSessionTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSessionTagsStmt: %w", cerr)
+		}
+	}
 	if q.listSessionsStmt != nil {
 		if cerr := q.listSessionsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listSessionsStmt: %w", cerr)
@@ -294,6 +421,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing recordFileReadStmt: %w", cerr)
 		}
 	}
+	if q.setSessionLabelStmt != nil {
+		if cerr := q.setSessionLabelStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setSessionLabelStmt: %w", cerr)
+		}
+	}
 	if q.updateMessageStmt != nil {
 		if cerr := q.updateMessageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateMessageStmt: %w", cerr)
@@ -304,11 +436,31 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing updateSessionStmt: %w", cerr)
 		}
 	}
+	if q.updateSessionChatOnlyStmt != nil {
+		if cerr := q.updateSessionChatOnlyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateSessionChatOnlyStmt: %w", cerr)
+		}
+	}
+	if q.updateSessionPinnedStmt != nil {
+		if cerr := q.updateSessionPinnedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateSessionPinnedStmt: %w", cerr)
+		}
+	}
+	if q.updateSessionTitleStmt != nil {
+		if cerr := q.updateSessionTitleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateSessionTitleStmt: %w", cerr)
+		}
+	}
 	if q.updateSessionTitleAndUsageStmt != nil {
 		if cerr := q.updateSessionTitleAndUsageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateSessionTitleAndUsageStmt: %w", cerr)
 		}
 	}
+	if q.updateSessionTranslateStmt != nil {
+		if cerr := q.updateSessionTranslateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateSessionTranslateStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -346,83 +498,121 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                             DBTX
-	tx                             *sql.Tx
-	createFileStmt                 *sql.Stmt
-	createMessageStmt              *sql.Stmt
-	createSessionStmt              *sql.Stmt
-	deleteFileStmt                 *sql.Stmt
-	deleteMessageStmt              *sql.Stmt
-	deleteSessionStmt              *sql.Stmt
-	deleteSessionFilesStmt         *sql.Stmt
-	deleteSessionMessagesStmt      *sql.Stmt
-	getAverageResponseTimeStmt     *sql.Stmt
-	getFileStmt                    *sql.Stmt
-	getFileByPathAndSessionStmt    *sql.Stmt
-	getFileReadStmt                *sql.Stmt
-	getHourDayHeatmapStmt          *sql.Stmt
-	getMessageStmt                 *sql.Stmt
-	getRecentActivityStmt          *sql.Stmt
-	getSessionByIDStmt             *sql.Stmt
-	getToolUsageStmt               *sql.Stmt
-	getTotalStatsStmt              *sql.Stmt
-	getUsageByDayStmt              *sql.Stmt
-	getUsageByDayOfWeekStmt        *sql.Stmt
-	getUsageByHourStmt             *sql.Stmt
-	getUsageByModelStmt            *sql.Stmt
-	listAllUserMessagesStmt        *sql.Stmt
-	listFilesByPathStmt            *sql.Stmt
-	listFilesBySessionStmt         *sql.Stmt
-	listLatestSessionFilesStmt     *sql.Stmt
-	listMessagesBySessionStmt      *sql.Stmt
-	listNewFilesStmt               *sql.Stmt
-	listSessionReadFilesStmt       *sql.Stmt
-	listSessionsStmt               *sql.Stmt
-	listUserMessagesBySessionStmt  *sql.Stmt
-	recordFileReadStmt             *sql.Stmt
-	updateMessageStmt              *sql.Stmt
-	updateSessionStmt              *sql.Stmt
-	updateSessionTitleAndUsageStmt *sql.Stmt
+	db                              DBTX
+	tx                              *sql.Tx
+	addSessionTagStmt               *sql.Stmt
+	createFileStmt                  *sql.Stmt
+	createMessageStmt               *sql.Stmt
+	createRecallTurnStmt            *sql.Stmt
+	createSessionStmt               *sql.Stmt
+	createUsageRecordStmt           *sql.Stmt
+	deleteFileStmt                  *sql.Stmt
+	deleteMessageStmt               *sql.Stmt
+	deleteSessionStmt               *sql.Stmt
+	deleteSessionFilesStmt          *sql.Stmt
+	deleteSessionLabelStmt          *sql.Stmt
+	deleteSessionMessagesStmt       *sql.Stmt
+	deleteSessionTagsStmt           *sql.Stmt
+	getAverageResponseTimeStmt      *sql.Stmt
+	getCostByModelStmt              *sql.Stmt
+	getFileStmt                     *sql.Stmt
+	getFileByPathAndSessionStmt     *sql.Stmt
+	getFileReadStmt                 *sql.Stmt
+	getHourDayHeatmapStmt           *sql.Stmt
+	getMessageStmt                  *sql.Stmt
+	getRecentActivityStmt           *sql.Stmt
+	getSessionByIDStmt              *sql.Stmt
+	getSessionLabelStmt             *sql.Stmt
+	getToolUsageStmt                *sql.Stmt
+	getTotalStatsStmt               *sql.Stmt
+	getUsageByDayStmt               *sql.Stmt
+	getUsageByDayOfWeekStmt         *sql.Stmt
+	getUsageByHourStmt              *sql.Stmt
+	getUsageByLabelStmt             *sql.Stmt
+	getUsageByModelStmt             *sql.Stmt
+	getUsageBySessionStmt           *sql.Stmt
+	listAllSessionTagsStmt          *sql.Stmt
+	listAllUserMessagesStmt         *sql.Stmt
+	listFilesByPathStmt             *sql.Stmt
+	listFilesBySessionStmt          *sql.Stmt
+	listLatestSessionFilesStmt      *sql.Stmt
+	listMessagesBeforeBySessionStmt *sql.Stmt
+	listMessagesBySessionStmt       *sql.Stmt
+	listNewFilesStmt                *sql.Stmt
+	listRecallTurnsBySessionStmt    *sql.Stmt
+	listRecentMessagesBySessionStmt *sql.Stmt
+	listSessionReadFilesStmt        *sql.Stmt
+	listSessionTagsStmt             *sql.Stmt
+	listSessionsStmt                *sql.Stmt
+	listUserMessagesBySessionStmt   *sql.Stmt
+	recordFileReadStmt              *sql.Stmt
+	setSessionLabelStmt             *sql.Stmt
+	updateMessageStmt               *sql.Stmt
+	updateSessionStmt               *sql.Stmt
+	updateSessionChatOnlyStmt       *sql.Stmt
+	updateSessionPinnedStmt         *sql.Stmt
+	updateSessionTitleStmt          *sql.Stmt
+	updateSessionTitleAndUsageStmt  *sql.Stmt
+	updateSessionTranslateStmt      *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                             tx,
-		tx:                             tx,
-		createFileStmt:                 q.createFileStmt,
-		createMessageStmt:              q.createMessageStmt,
-		createSessionStmt:              q.createSessionStmt,
-		deleteFileStmt:                 q.deleteFileStmt,
-		deleteMessageStmt:              q.deleteMessageStmt,
-		deleteSessionStmt:              q.deleteSessionStmt,
-		deleteSessionFilesStmt:         q.deleteSessionFilesStmt,
-		deleteSessionMessagesStmt:      q.deleteSessionMessagesStmt,
-		getAverageResponseTimeStmt:     q.getAverageResponseTimeStmt,
-		getFileStmt:                    q.getFileStmt,
-		getFileByPathAndSessionStmt:    q.getFileByPathAndSessionStmt,
-		getFileReadStmt:                q.getFileReadStmt,
-		getHourDayHeatmapStmt:          q.getHourDayHeatmapStmt,
-		getMessageStmt:                 q.getMessageStmt,
-		getRecentActivityStmt:          q.getRecentActivityStmt,
-		getSessionByIDStmt:             q.getSessionByIDStmt,
-		getToolUsageStmt:               q.getToolUsageStmt,
-		getTotalStatsStmt:              q.getTotalStatsStmt,
-		getUsageByDayStmt:              q.getUsageByDayStmt,
-		getUsageByDayOfWeekStmt:        q.getUsageByDayOfWeekStmt,
-		getUsageByHourStmt:             q.getUsageByHourStmt,
-		getUsageByModelStmt:            q.getUsageByModelStmt,
-		listAllUserMessagesStmt:        q.listAllUserMessagesStmt,
-		listFilesByPathStmt:            q.listFilesByPathStmt,
-		listFilesBySessionStmt:         q.listFilesBySessionStmt,
-		listLatestSessionFilesStmt:     q.listLatestSessionFilesStmt,
-		listMessagesBySessionStmt:      q.listMessagesBySessionStmt,
-		listNewFilesStmt:               q.listNewFilesStmt,
-		listSessionReadFilesStmt:       q.listSessionReadFilesStmt,
-		listSessionsStmt:               q.listSessionsStmt,
-		listUserMessagesBySessionStmt:  q.listUserMessagesBySessionStmt,
-		recordFileReadStmt:             q.recordFileReadStmt,
-		updateMessageStmt:              q.updateMessageStmt,
-		updateSessionStmt:              q.updateSessionStmt,
-		updateSessionTitleAndUsageStmt: q.updateSessionTitleAndUsageStmt,
+		db:                              tx,
+		tx:                              tx,
+		addSessionTagStmt:               q.addSessionTagStmt,
+		createFileStmt:                  q.createFileStmt,
+		createMessageStmt:               q.createMessageStmt,
+		createRecallTurnStmt:            q.createRecallTurnStmt,
+		createSessionStmt:               q.createSessionStmt,
+		createUsageRecordStmt:           q.createUsageRecordStmt,
+		deleteFileStmt:                  q.deleteFileStmt,
+		deleteMessageStmt:               q.deleteMessageStmt,
+		deleteSessionStmt:               q.deleteSessionStmt,
+		deleteSessionFilesStmt:          q.deleteSessionFilesStmt,
+		deleteSessionLabelStmt:          q.deleteSessionLabelStmt,
+		deleteSessionMessagesStmt:       q.deleteSessionMessagesStmt,
+		deleteSessionTagsStmt:           q.deleteSessionTagsStmt,
+		getAverageResponseTimeStmt:      q.getAverageResponseTimeStmt,
+		getCostByModelStmt:              q.getCostByModelStmt,
+		getFileStmt:                     q.getFileStmt,
+		getFileByPathAndSessionStmt:     q.getFileByPathAndSessionStmt,
+		getFileReadStmt:                 q.getFileReadStmt,
+		getHourDayHeatmapStmt:           q.getHourDayHeatmapStmt,
+		getMessageStmt:                  q.getMessageStmt,
+		getRecentActivityStmt:           q.getRecentActivityStmt,
+		getSessionByIDStmt:              q.getSessionByIDStmt,
+		getSessionLabelStmt:             q.getSessionLabelStmt,
+		getToolUsageStmt:                q.getToolUsageStmt,
+		getTotalStatsStmt:               q.getTotalStatsStmt,
+		getUsageByDayStmt:               q.getUsageByDayStmt,
+		getUsageByDayOfWeekStmt:         q.getUsageByDayOfWeekStmt,
+		getUsageByHourStmt:              q.getUsageByHourStmt,
+		getUsageByLabelStmt:             q.getUsageByLabelStmt,
+		getUsageByModelStmt:             q.getUsageByModelStmt,
+		getUsageBySessionStmt:           q.getUsageBySessionStmt,
+		listAllSessionTagsStmt:          q.listAllSessionTagsStmt,
+		listAllUserMessagesStmt:         q.listAllUserMessagesStmt,
+		listFilesByPathStmt:             q.listFilesByPathStmt,
+		listFilesBySessionStmt:          q.listFilesBySessionStmt,
+		listLatestSessionFilesStmt:      q.listLatestSessionFilesStmt,
+		listMessagesBeforeBySessionStmt: q.listMessagesBeforeBySessionStmt,
+		listMessagesBySessionStmt:       q.listMessagesBySessionStmt,
+		listNewFilesStmt:                q.listNewFilesStmt,
+		listRecallTurnsBySessionStmt:    q.listRecallTurnsBySessionStmt,
+		listRecentMessagesBySessionStmt: q.listRecentMessagesBySessionStmt,
+		listSessionReadFilesStmt:        q.listSessionReadFilesStmt,
+		listSessionTagsStmt:             q.listSessionTagsStmt,
+		listSessionsStmt:                q.listSessionsStmt,
+		listUserMessagesBySessionStmt:   q.listUserMessagesBySessionStmt,
+		recordFileReadStmt:              q.recordFileReadStmt,
+		setSessionLabelStmt:             q.setSessionLabelStmt,
+		updateMessageStmt:               q.updateMessageStmt,
+		updateSessionStmt:               q.updateSessionStmt,
+		updateSessionChatOnlyStmt:       q.updateSessionChatOnlyStmt,
+		updateSessionPinnedStmt:         q.updateSessionPinnedStmt,
+		updateSessionTitleStmt:          q.updateSessionTitleStmt,
+		updateSessionTitleAndUsageStmt:  q.updateSessionTitleAndUsageStmt,
+		updateSessionTranslateStmt:      q.updateSessionTranslateStmt,
 	}
 }
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE sessions ADD COLUMN chat_only INTEGER DEFAULT 0 NOT NULL;
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN chat_only;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sessions ADD COLUMN project TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS session_tags (
+    session_id TEXT NOT NULL CHECK (session_id != ''),
+    tag TEXT NOT NULL CHECK (tag != ''),
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE,
+    PRIMARY KEY (session_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_tags_tag ON session_tags (tag);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_session_tags_tag;
+DROP TABLE IF EXISTS session_tags;
+ALTER TABLE sessions DROP COLUMN project;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS session_labels (
+    session_id TEXT PRIMARY KEY CHECK (session_id != ''),
+    label TEXT NOT NULL CHECK (label != ''),
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS usage_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL CHECK (session_id != ''),
+    label TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    provider TEXT NOT NULL DEFAULT '',
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    cost REAL NOT NULL DEFAULT 0.0,
+    created_at INTEGER NOT NULL,  -- Unix timestamp in seconds
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_label ON usage_records (label);
+CREATE INDEX IF NOT EXISTS idx_usage_records_session_id ON usage_records (session_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_usage_records_session_id;
+DROP INDEX IF EXISTS idx_usage_records_label;
+DROP TABLE IF EXISTS usage_records;
+DROP TABLE IF EXISTS session_labels;
+-- +goose StatementEnd
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE messages ADD COLUMN reasoning_tokens INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE sessions ADD COLUMN reasoning_tokens INTEGER DEFAULT 0 NOT NULL;
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN reasoning_tokens;
+ALTER TABLE messages DROP COLUMN reasoning_tokens;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS recall_turns (
+    message_id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL CHECK (session_id != ''),
+    role TEXT NOT NULL,
+    content TEXT NOT NULL,
+    embedding BLOB NOT NULL,  -- little endian float32 values
+    created_at INTEGER NOT NULL,  -- Unix timestamp in seconds when the turn was compacted
+    FOREIGN KEY (message_id) REFERENCES messages (id) ON DELETE CASCADE,
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_recall_turns_session_id ON recall_turns (session_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_recall_turns_session_id;
+DROP TABLE IF EXISTS recall_turns;
+-- +goose StatementEnd
@@ -29,6 +29,7 @@ type Message struct {
 	FinishedAt       sql.NullInt64  `json:"finished_at"`
 	Provider         sql.NullString `json:"provider"`
 	IsSummaryMessage int64          `json:"is_summary_message"`
+	ReasoningTokens  int64          `json:"reasoning_tokens"`
 }
 
 type ReadFile struct {
@@ -37,6 +38,15 @@ type ReadFile struct {
 	ReadAt    int64  `json:"read_at"` // Unix timestamp when file was last read
 }
 
+type RecallTurn struct {
+	MessageID string `json:"message_id"`
+	SessionID string `json:"session_id"`
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	Embedding []byte `json:"embedding"`
+	CreatedAt int64  `json:"created_at"`
+}
+
 type Session struct {
 	ID               string         `json:"id"`
 	ParentSessionID  sql.NullString `json:"parent_session_id"`
@@ -49,4 +59,31 @@ type Session struct {
 	CreatedAt        int64          `json:"created_at"`
 	SummaryMessageID sql.NullString `json:"summary_message_id"`
 	Todos            sql.NullString `json:"todos"`
+	ChatOnly         int64          `json:"chat_only"`
+	Project          string         `json:"project"`
+	Pinned           int64          `json:"pinned"`
+	Translate        int64          `json:"translate"`
+	ReasoningTokens  int64          `json:"reasoning_tokens"`
+}
+
+type SessionLabel struct {
+	SessionID string `json:"session_id"`
+	Label     string `json:"label"`
+}
+
+type SessionTag struct {
+	SessionID string `json:"session_id"`
+	Tag       string `json:"tag"`
+}
+
+type UsageRecord struct {
+	ID               int64   `json:"id"`
+	SessionID        string  `json:"session_id"`
+	Label            string  `json:"label"`
+	Model            string  `json:"model"`
+	Provider         string  `json:"provider"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+	CreatedAt        int64   `json:"created_at"` // Unix timestamp in seconds
 }
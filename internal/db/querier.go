@@ -9,15 +9,21 @@ import (
 )
 
 type Querier interface {
+	AddSessionTag(ctx context.Context, arg AddSessionTagParams) error
 	CreateFile(ctx context.Context, arg CreateFileParams) (File, error)
 	CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error)
+	CreateRecallTurn(ctx context.Context, arg CreateRecallTurnParams) error
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateUsageRecord(ctx context.Context, arg CreateUsageRecordParams) error
 	DeleteFile(ctx context.Context, id string) error
 	DeleteMessage(ctx context.Context, id string) error
 	DeleteSession(ctx context.Context, id string) error
 	DeleteSessionFiles(ctx context.Context, sessionID string) error
+	DeleteSessionLabel(ctx context.Context, sessionID string) error
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
+	DeleteSessionTags(ctx context.Context, sessionID string) error
 	GetAverageResponseTime(ctx context.Context) (int64, error)
+	GetCostByModel(ctx context.Context) ([]GetCostByModelRow, error)
 	GetFile(ctx context.Context, id string) (File, error)
 	GetFileByPathAndSession(ctx context.Context, arg GetFileByPathAndSessionParams) (File, error)
 	GetFileRead(ctx context.Context, arg GetFileReadParams) (ReadFile, error)
@@ -25,25 +31,38 @@ type Querier interface {
 	GetMessage(ctx context.Context, id string) (Message, error)
 	GetRecentActivity(ctx context.Context) ([]GetRecentActivityRow, error)
 	GetSessionByID(ctx context.Context, id string) (Session, error)
+	GetSessionLabel(ctx context.Context, sessionID string) (string, error)
 	GetToolUsage(ctx context.Context) ([]GetToolUsageRow, error)
 	GetTotalStats(ctx context.Context) (GetTotalStatsRow, error)
 	GetUsageByDay(ctx context.Context) ([]GetUsageByDayRow, error)
 	GetUsageByDayOfWeek(ctx context.Context) ([]GetUsageByDayOfWeekRow, error)
 	GetUsageByHour(ctx context.Context) ([]GetUsageByHourRow, error)
+	GetUsageByLabel(ctx context.Context) ([]GetUsageByLabelRow, error)
 	GetUsageByModel(ctx context.Context) ([]GetUsageByModelRow, error)
+	GetUsageBySession(ctx context.Context) ([]GetUsageBySessionRow, error)
+	ListAllSessionTags(ctx context.Context) ([]SessionTag, error)
 	ListAllUserMessages(ctx context.Context) ([]Message, error)
 	ListFilesByPath(ctx context.Context, path string) ([]File, error)
 	ListFilesBySession(ctx context.Context, sessionID string) ([]File, error)
 	ListLatestSessionFiles(ctx context.Context, sessionID string) ([]File, error)
+	ListMessagesBeforeBySession(ctx context.Context, arg ListMessagesBeforeBySessionParams) ([]Message, error)
 	ListMessagesBySession(ctx context.Context, sessionID string) ([]Message, error)
 	ListNewFiles(ctx context.Context) ([]File, error)
+	ListRecentMessagesBySession(ctx context.Context, arg ListRecentMessagesBySessionParams) ([]Message, error)
+	ListRecallTurnsBySession(ctx context.Context, sessionID string) ([]RecallTurn, error)
 	ListSessionReadFiles(ctx context.Context, sessionID string) ([]ReadFile, error)
+	ListSessionTags(ctx context.Context, sessionID string) ([]string, error)
 	ListSessions(ctx context.Context) ([]Session, error)
 	ListUserMessagesBySession(ctx context.Context, sessionID string) ([]Message, error)
 	RecordFileRead(ctx context.Context, arg RecordFileReadParams) error
+	SetSessionLabel(ctx context.Context, arg SetSessionLabelParams) error
 	UpdateMessage(ctx context.Context, arg UpdateMessageParams) error
 	UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error)
+	UpdateSessionChatOnly(ctx context.Context, arg UpdateSessionChatOnlyParams) (Session, error)
+	UpdateSessionPinned(ctx context.Context, arg UpdateSessionPinnedParams) (Session, error)
+	UpdateSessionTitle(ctx context.Context, arg UpdateSessionTitleParams) (Session, error)
 	UpdateSessionTitleAndUsage(ctx context.Context, arg UpdateSessionTitleAndUsageParams) error
+	UpdateSessionTranslate(ctx context.Context, arg UpdateSessionTranslateParams) (Session, error)
 }
 
 var _ Querier = (*Queries)(nil)
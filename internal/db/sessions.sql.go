@@ -20,6 +20,7 @@ INSERT INTO sessions (
     completion_tokens,
     cost,
     summary_message_id,
+    project,
     updated_at,
     created_at
 ) VALUES (
@@ -31,9 +32,10 @@ INSERT INTO sessions (
     ?,
     ?,
     null,
+    ?,
     strftime('%s', 'now'),
     strftime('%s', 'now')
-) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, chat_only, project, pinned, translate, reasoning_tokens
 `
 
 type CreateSessionParams struct {
@@ -44,6 +46,7 @@ type CreateSessionParams struct {
 	PromptTokens     int64          `json:"prompt_tokens"`
 	CompletionTokens int64          `json:"completion_tokens"`
 	Cost             float64        `json:"cost"`
+	Project          string         `json:"project"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
@@ -55,6 +58,7 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		arg.PromptTokens,
 		arg.CompletionTokens,
 		arg.Cost,
+		arg.Project,
 	)
 	var i Session
 	err := row.Scan(
@@ -69,6 +73,11 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.ChatOnly,
+		&i.Project,
+		&i.Pinned,
+		&i.Translate,
+		&i.ReasoningTokens,
 	)
 	return i, err
 }
@@ -84,7 +93,7 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, chat_only, project, pinned, translate, reasoning_tokens
 FROM sessions
 WHERE id = ? LIMIT 1
 `
@@ -104,15 +113,20 @@ func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.ChatOnly,
+		&i.Project,
+		&i.Pinned,
+		&i.Translate,
+		&i.ReasoningTokens,
 	)
 	return i, err
 }
 
 const listSessions = `-- name: ListSessions :many
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, chat_only, project, pinned, translate, reasoning_tokens
 FROM sessions
 WHERE parent_session_id is NULL
-ORDER BY updated_at DESC
+ORDER BY pinned DESC, updated_at DESC
 `
 
 func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
@@ -136,6 +150,11 @@ func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
 			&i.CreatedAt,
 			&i.SummaryMessageID,
 			&i.Todos,
+			&i.ChatOnly,
+			&i.Project,
+			&i.Pinned,
+			&i.Translate,
+			&i.ReasoningTokens,
 		); err != nil {
 			return nil, err
 		}
@@ -156,17 +175,19 @@ SET
     title = ?,
     prompt_tokens = ?,
     completion_tokens = ?,
+    reasoning_tokens = ?,
     summary_message_id = ?,
     cost = ?,
     todos = ?
 WHERE id = ?
-RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, chat_only, project, pinned, translate, reasoning_tokens
 `
 
 type UpdateSessionParams struct {
 	Title            string         `json:"title"`
 	PromptTokens     int64          `json:"prompt_tokens"`
 	CompletionTokens int64          `json:"completion_tokens"`
+	ReasoningTokens  int64          `json:"reasoning_tokens"`
 	SummaryMessageID sql.NullString `json:"summary_message_id"`
 	Cost             float64        `json:"cost"`
 	Todos            sql.NullString `json:"todos"`
@@ -178,6 +199,7 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		arg.Title,
 		arg.PromptTokens,
 		arg.CompletionTokens,
+		arg.ReasoningTokens,
 		arg.SummaryMessageID,
 		arg.Cost,
 		arg.Todos,
@@ -196,6 +218,119 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.ChatOnly,
+		&i.Project,
+		&i.Pinned,
+		&i.Translate,
+		&i.ReasoningTokens,
+	)
+	return i, err
+}
+
+const updateSessionChatOnly = `-- name: UpdateSessionChatOnly :one
+UPDATE sessions
+SET chat_only = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, chat_only, project, pinned, translate, reasoning_tokens
+`
+
+type UpdateSessionChatOnlyParams struct {
+	ChatOnly int64  `json:"chat_only"`
+	ID       string `json:"id"`
+}
+
+func (q *Queries) UpdateSessionChatOnly(ctx context.Context, arg UpdateSessionChatOnlyParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionChatOnlyStmt, updateSessionChatOnly, arg.ChatOnly, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.ChatOnly,
+		&i.Project,
+		&i.Pinned,
+		&i.Translate,
+		&i.ReasoningTokens,
+	)
+	return i, err
+}
+
+const updateSessionPinned = `-- name: UpdateSessionPinned :one
+UPDATE sessions
+SET pinned = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, chat_only, project, pinned, translate, reasoning_tokens
+`
+
+type UpdateSessionPinnedParams struct {
+	Pinned int64  `json:"pinned"`
+	ID     string `json:"id"`
+}
+
+func (q *Queries) UpdateSessionPinned(ctx context.Context, arg UpdateSessionPinnedParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionPinnedStmt, updateSessionPinned, arg.Pinned, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.ChatOnly,
+		&i.Project,
+		&i.Pinned,
+		&i.Translate,
+		&i.ReasoningTokens,
+	)
+	return i, err
+}
+
+const updateSessionTitle = `-- name: UpdateSessionTitle :one
+UPDATE sessions
+SET title = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, chat_only, project, pinned, translate, reasoning_tokens
+`
+
+type UpdateSessionTitleParams struct {
+	Title string `json:"title"`
+	ID    string `json:"id"`
+}
+
+func (q *Queries) UpdateSessionTitle(ctx context.Context, arg UpdateSessionTitleParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionTitleStmt, updateSessionTitle, arg.Title, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.ChatOnly,
+		&i.Project,
+		&i.Pinned,
+		&i.Translate,
+		&i.ReasoningTokens,
 	)
 	return i, err
 }
@@ -228,3 +363,39 @@ func (q *Queries) UpdateSessionTitleAndUsage(ctx context.Context, arg UpdateSess
 	)
 	return err
 }
+
+const updateSessionTranslate = `-- name: UpdateSessionTranslate :one
+UPDATE sessions
+SET translate = ?
+WHERE id = ?
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, chat_only, project, pinned, translate, reasoning_tokens
+`
+
+type UpdateSessionTranslateParams struct {
+	Translate int64  `json:"translate"`
+	ID        string `json:"id"`
+}
+
+func (q *Queries) UpdateSessionTranslate(ctx context.Context, arg UpdateSessionTranslateParams) (Session, error) {
+	row := q.queryRow(ctx, q.updateSessionTranslateStmt, updateSessionTranslate, arg.Translate, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.ChatOnly,
+		&i.Project,
+		&i.Pinned,
+		&i.Translate,
+		&i.ReasoningTokens,
+	)
+	return i, err
+}
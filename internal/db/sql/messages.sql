@@ -9,6 +9,23 @@ FROM messages
 WHERE session_id = ?
 ORDER BY created_at ASC;
 
+-- name: ListRecentMessagesBySession :many
+SELECT *
+FROM messages
+WHERE session_id = ?
+ORDER BY created_at DESC, rowid DESC
+LIMIT ?;
+
+-- name: ListMessagesBeforeBySession :many
+SELECT *
+FROM messages
+WHERE session_id = sqlc.arg(session_id)
+  AND (created_at, rowid) < (
+      SELECT created_at, rowid FROM messages WHERE id = sqlc.arg(before_id)
+  )
+ORDER BY created_at DESC, rowid DESC
+LIMIT sqlc.arg(limit);
+
 -- name: CreateMessage :one
 INSERT INTO messages (
     id,
@@ -30,6 +47,7 @@ UPDATE messages
 SET
     parts = ?,
     finished_at = ?,
+    reasoning_tokens = ?,
     updated_at = strftime('%s', 'now')
 WHERE id = ?;
 
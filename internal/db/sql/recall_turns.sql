@@ -0,0 +1,21 @@
+-- name: CreateRecallTurn :exec
+INSERT INTO recall_turns (
+    message_id,
+    session_id,
+    role,
+    content,
+    embedding,
+    created_at
+) VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    strftime('%s', 'now')
+) ON CONFLICT(message_id) DO NOTHING;
+
+-- name: ListRecallTurnsBySession :many
+SELECT * FROM recall_turns
+WHERE session_id = ?
+ORDER BY created_at ASC, rowid ASC;
@@ -0,0 +1,23 @@
+-- name: AddSessionTag :exec
+INSERT INTO session_tags (
+    session_id,
+    tag
+) VALUES (
+    ?,
+    ?
+) ON CONFLICT(session_id, tag) DO NOTHING;
+
+-- name: DeleteSessionTags :exec
+DELETE FROM session_tags
+WHERE session_id = ?;
+
+-- name: ListSessionTags :many
+SELECT tag
+FROM session_tags
+WHERE session_id = ?
+ORDER BY tag;
+
+-- name: ListAllSessionTags :many
+SELECT session_id, tag
+FROM session_tags
+ORDER BY session_id, tag;
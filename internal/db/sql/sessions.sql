@@ -8,6 +8,7 @@ INSERT INTO sessions (
     completion_tokens,
     cost,
     summary_message_id,
+    project,
     updated_at,
     created_at
 ) VALUES (
@@ -19,6 +20,7 @@ INSERT INTO sessions (
     ?,
     ?,
     null,
+    ?,
     strftime('%s', 'now'),
     strftime('%s', 'now')
 ) RETURNING *;
@@ -32,7 +34,7 @@ WHERE id = ? LIMIT 1;
 SELECT *
 FROM sessions
 WHERE parent_session_id is NULL
-ORDER BY updated_at DESC;
+ORDER BY pinned DESC, updated_at DESC;
 
 -- name: UpdateSession :one
 UPDATE sessions
@@ -40,12 +42,31 @@ SET
     title = ?,
     prompt_tokens = ?,
     completion_tokens = ?,
+    reasoning_tokens = ?,
     summary_message_id = ?,
     cost = ?,
     todos = ?
 WHERE id = ?
 RETURNING *;
 
+-- name: UpdateSessionChatOnly :one
+UPDATE sessions
+SET chat_only = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateSessionPinned :one
+UPDATE sessions
+SET pinned = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateSessionTitle :one
+UPDATE sessions
+SET title = ?
+WHERE id = ?
+RETURNING *;
+
 -- name: UpdateSessionTitleAndUsage :exec
 UPDATE sessions
 SET
@@ -55,6 +76,12 @@ SET
     cost = cost + ?
 WHERE id = ?;
 
+-- name: UpdateSessionTranslate :one
+UPDATE sessions
+SET translate = ?
+WHERE id = ?
+RETURNING *;
+
 
 -- name: DeleteSession :exec
 DELETE FROM sessions
@@ -91,3 +91,46 @@ FROM sessions
 WHERE parent_session_id IS NULL
 GROUP BY day_of_week, hour
 ORDER BY day_of_week, hour;
+
+-- name: GetCostByModel :many
+SELECT
+    m.model,
+    m.provider,
+    COUNT(*) as session_count,
+    CAST(SUM(m.message_count) AS INTEGER) as message_count,
+    SUM(s.prompt_tokens * m.message_count * 1.0 / t.message_count) as prompt_tokens,
+    SUM(s.completion_tokens * m.message_count * 1.0 / t.message_count) as completion_tokens,
+    SUM(s.cost * m.message_count / t.message_count) as cost
+FROM (
+    SELECT
+        session_id,
+        COALESCE(model, 'unknown') as model,
+        COALESCE(provider, 'unknown') as provider,
+        COUNT(*) as message_count
+    FROM messages
+    WHERE role = 'assistant'
+    GROUP BY session_id, model, provider
+) m
+JOIN (
+    SELECT session_id, COUNT(*) as message_count
+    FROM messages
+    WHERE role = 'assistant'
+    GROUP BY session_id
+) t ON t.session_id = m.session_id
+JOIN sessions s ON s.id = m.session_id
+WHERE s.parent_session_id IS NULL
+GROUP BY m.model, m.provider
+ORDER BY cost DESC;
+
+-- name: GetUsageBySession :many
+SELECT
+    id,
+    title,
+    created_at,
+    message_count,
+    prompt_tokens,
+    completion_tokens,
+    cost
+FROM sessions
+WHERE parent_session_id IS NULL
+ORDER BY created_at DESC;
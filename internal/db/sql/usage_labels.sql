@@ -0,0 +1,51 @@
+-- name: SetSessionLabel :exec
+INSERT INTO session_labels (
+    session_id,
+    label
+) VALUES (
+    ?,
+    ?
+) ON CONFLICT(session_id) DO UPDATE SET
+    label = excluded.label;
+
+-- name: DeleteSessionLabel :exec
+DELETE FROM session_labels
+WHERE session_id = ?;
+
+-- name: GetSessionLabel :one
+SELECT label
+FROM session_labels
+WHERE session_id = ?;
+
+-- name: CreateUsageRecord :exec
+INSERT INTO usage_records (
+    session_id,
+    label,
+    model,
+    provider,
+    prompt_tokens,
+    completion_tokens,
+    cost,
+    created_at
+) VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    strftime('%s', 'now')
+);
+
+-- name: GetUsageByLabel :many
+SELECT
+    label,
+    COUNT(DISTINCT session_id) AS session_count,
+    COUNT(*) AS request_count,
+    CAST(SUM(prompt_tokens) AS INTEGER) AS prompt_tokens,
+    CAST(SUM(completion_tokens) AS INTEGER) AS completion_tokens,
+    CAST(SUM(cost) AS REAL) AS cost
+FROM usage_records
+GROUP BY label
+ORDER BY cost DESC, label;
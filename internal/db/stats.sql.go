@@ -26,6 +26,78 @@ func (q *Queries) GetAverageResponseTime(ctx context.Context) (int64, error) {
 	return avg_response_seconds, err
 }
 
+const getCostByModel = `-- name: GetCostByModel :many
+SELECT
+    m.model,
+    m.provider,
+    COUNT(*) as session_count,
+    CAST(SUM(m.message_count) AS INTEGER) as message_count,
+    SUM(s.prompt_tokens * m.message_count * 1.0 / t.message_count) as prompt_tokens,
+    SUM(s.completion_tokens * m.message_count * 1.0 / t.message_count) as completion_tokens,
+    SUM(s.cost * m.message_count / t.message_count) as cost
+FROM (
+    SELECT
+        session_id,
+        COALESCE(model, 'unknown') as model,
+        COALESCE(provider, 'unknown') as provider,
+        COUNT(*) as message_count
+    FROM messages
+    WHERE role = 'assistant'
+    GROUP BY session_id, model, provider
+) m
+JOIN (
+    SELECT session_id, COUNT(*) as message_count
+    FROM messages
+    WHERE role = 'assistant'
+    GROUP BY session_id
+) t ON t.session_id = m.session_id
+JOIN sessions s ON s.id = m.session_id
+WHERE s.parent_session_id IS NULL
+GROUP BY m.model, m.provider
+ORDER BY cost DESC
+`
+
+type GetCostByModelRow struct {
+	Model            string          `json:"model"`
+	Provider         string          `json:"provider"`
+	SessionCount     int64           `json:"session_count"`
+	MessageCount     int64           `json:"message_count"`
+	PromptTokens     sql.NullFloat64 `json:"prompt_tokens"`
+	CompletionTokens sql.NullFloat64 `json:"completion_tokens"`
+	Cost             sql.NullFloat64 `json:"cost"`
+}
+
+func (q *Queries) GetCostByModel(ctx context.Context) ([]GetCostByModelRow, error) {
+	rows, err := q.query(ctx, q.getCostByModelStmt, getCostByModel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetCostByModelRow{}
+	for rows.Next() {
+		var i GetCostByModelRow
+		if err := rows.Scan(
+			&i.Model,
+			&i.Provider,
+			&i.SessionCount,
+			&i.MessageCount,
+			&i.PromptTokens,
+			&i.CompletionTokens,
+			&i.Cost,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getHourDayHeatmap = `-- name: GetHourDayHeatmap :many
 SELECT
     CAST(strftime('%w', created_at, 'unixepoch') AS INTEGER) as day_of_week,
@@ -343,6 +415,61 @@ type GetUsageByModelRow struct {
 	MessageCount int64  `json:"message_count"`
 }
 
+const getUsageBySession = `-- name: GetUsageBySession :many
+SELECT
+    id,
+    title,
+    created_at,
+    message_count,
+    prompt_tokens,
+    completion_tokens,
+    cost
+FROM sessions
+WHERE parent_session_id IS NULL
+ORDER BY created_at DESC
+`
+
+type GetUsageBySessionRow struct {
+	ID               string  `json:"id"`
+	Title            string  `json:"title"`
+	CreatedAt        int64   `json:"created_at"`
+	MessageCount     int64   `json:"message_count"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (q *Queries) GetUsageBySession(ctx context.Context) ([]GetUsageBySessionRow, error) {
+	rows, err := q.query(ctx, q.getUsageBySessionStmt, getUsageBySession)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetUsageBySessionRow{}
+	for rows.Next() {
+		var i GetUsageBySessionRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.CreatedAt,
+			&i.MessageCount,
+			&i.PromptTokens,
+			&i.CompletionTokens,
+			&i.Cost,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 func (q *Queries) GetUsageByModel(ctx context.Context) ([]GetUsageByModelRow, error) {
 	rows, err := q.query(ctx, q.getUsageByModelStmt, getUsageByModel)
 	if err != nil {
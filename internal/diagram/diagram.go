@@ -0,0 +1,203 @@
+// Package diagram finds the diagrams written in Markdown replies and renders
+// them to images with the tools of their language.
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind is the language a diagram is written in.
+type Kind string
+
+const (
+	KindMermaid  Kind = "mermaid"
+	KindGraphviz Kind = "graphviz"
+	KindPlantUML Kind = "plantuml"
+)
+
+// ErrNoRenderer is returned when the tool rendering a kind of diagram is not
+// installed.
+var ErrNoRenderer = errors.New("diagram renderer not installed")
+
+// renderTimeout bounds how long a tool may take to render a diagram.
+const renderTimeout = 30 * time.Second
+
+// Block is a diagram of a Markdown document.
+type Block struct {
+	Kind   Kind
+	Source string
+}
+
+// kinds maps the languages of fenced code blocks to the diagrams they hold.
+var kinds = map[string]Kind{
+	"mermaid":  KindMermaid,
+	"dot":      KindGraphviz,
+	"gv":       KindGraphviz,
+	"graphviz": KindGraphviz,
+	"plantuml": KindPlantUML,
+	"puml":     KindPlantUML,
+}
+
+// Find returns the diagrams of the fenced code blocks of markdown, in the
+// order they appear. Blocks of other languages are skipped.
+func Find(markdown string) []Block {
+	var (
+		blocks []Block
+		fence  string
+		kind   Kind
+		source strings.Builder
+	)
+	for line := range strings.SplitSeq(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			marker, info, ok := openingFence(trimmed)
+			if !ok {
+				continue
+			}
+			fence = marker
+			lang, _, _ := strings.Cut(info, " ")
+			kind = kinds[strings.ToLower(lang)]
+			source.Reset()
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			if kind != "" && strings.TrimSpace(source.String()) != "" {
+				blocks = append(blocks, Block{Kind: kind, Source: source.String()})
+			}
+			fence = ""
+			continue
+		}
+		if kind != "" {
+			source.WriteString(line)
+			source.WriteByte('\n')
+		}
+	}
+	return blocks
+}
+
+// openingFence reports whether line opens a fenced code block, returning
+// its fence and info string.
+func openingFence(line string) (fence, info string, ok bool) {
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		if n >= 3 {
+			return line[:n], strings.TrimSpace(line[n:]), true
+		}
+	}
+	return "", "", false
+}
+
+var (
+	renderedMu sync.Mutex
+	rendered   = map[[sha256.Size]byte]image.Image{}
+)
+
+// Render renders b to an image with the tool of its kind: dot for
+// Graphviz, plantuml for PlantUML and mmdc, of mermaid-cli, for Mermaid.
+// Images are cached, so that viewing a diagram again is instant.
+func Render(ctx context.Context, b Block) (image.Image, error) {
+	key := sha256.Sum256([]byte(string(b.Kind) + "\x00" + b.Source))
+	renderedMu.Lock()
+	img, ok := rendered[key]
+	renderedMu.Unlock()
+	if ok {
+		return img, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, renderTimeout)
+	defer cancel()
+
+	var (
+		data []byte
+		err  error
+	)
+	switch b.Kind {
+	case KindGraphviz:
+		data, err = pipe(ctx, b.Source, "dot", "-Tpng")
+	case KindPlantUML:
+		source := b.Source
+		if !strings.Contains(source, "@start") {
+			source = "@startuml\n" + source + "@enduml\n"
+		}
+		data, err = pipe(ctx, source, "plantuml", "-tpng", "-pipe")
+	case KindMermaid:
+		data, err = mermaid(ctx, b.Source)
+	default:
+		return nil, fmt.Errorf("unknown diagram kind %q", b.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	img, err = png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode %s diagram: %w", b.Kind, err)
+	}
+	renderedMu.Lock()
+	rendered[key] = img
+	renderedMu.Unlock()
+	return img, nil
+}
+
+// pipe runs name with args, writing source to its input, and returns what
+// it outputs.
+func pipe(ctx context.Context, source, name string, args ...string) ([]byte, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoRenderer, name)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = strings.NewReader(source)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, toolError(name, err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+// mermaid renders source with mermaid-cli, which only reads and writes
+// files.
+func mermaid(ctx context.Context, source string) ([]byte, error) {
+	path, err := exec.LookPath("mmdc")
+	if err != nil {
+		return nil, fmt.Errorf("%w: mmdc", ErrNoRenderer)
+	}
+	dir, err := os.MkdirTemp("", "crush-mermaid-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in, out := filepath.Join(dir, "diagram.mmd"), filepath.Join(dir, "diagram.png")
+	if err := os.WriteFile(in, []byte(source), 0o600); err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, "-q", "-b", "white", "-i", in, "-o", out)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, toolError("mmdc", err, stderr.String())
+	}
+	return os.ReadFile(out)
+}
+
+func toolError(name string, err error, stderr string) error {
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		return fmt.Errorf("%s: %s", name, msg)
+	}
+	return fmt.Errorf("%s: %w", name, err)
+}
@@ -0,0 +1,69 @@
+package diagram
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	markdown := "Here is the flow:\n\n" +
+		"```mermaid\ngraph TD\n  A --> B\n```\n\n" +
+		"And some code:\n\n" +
+		"````go\nfmt.Println(\"```dot\")\n````\n\n" +
+		"~~~ DOT {.wide}\ndigraph { a -> b }\n~~~\n\n" +
+		"```puml\nAlice -> Bob\n```\n\n" +
+		"```plantuml\n```\n"
+
+	require.Equal(t, []Block{
+		{Kind: KindMermaid, Source: "graph TD\n  A --> B\n"},
+		{Kind: KindGraphviz, Source: "digraph { a -> b }\n"},
+		{Kind: KindPlantUML, Source: "Alice -> Bob\n"},
+	}, Find(markdown))
+}
+
+func TestFindUnclosed(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, Find("```mermaid\ngraph TD\n"))
+	require.Empty(t, Find("No diagrams here."))
+}
+
+func TestRenderWithoutRenderer(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := Render(t.Context(), Block{Kind: KindGraphviz, Source: "digraph { missing }"})
+	require.ErrorIs(t, err, ErrNoRenderer)
+}
+
+func TestRenderGraphviz(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake renderer is a shell script")
+	}
+
+	var data bytes.Buffer
+	require.NoError(t, png.Encode(&data, image.NewRGBA(image.Rect(0, 0, 3, 2))))
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.png")
+	require.NoError(t, os.WriteFile(out, data.Bytes(), 0o600))
+	script := "#!/bin/sh\ncat > /dev/null\ncat " + out + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dot"), []byte(script), 0o700))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	img, err := Render(t.Context(), Block{Kind: KindGraphviz, Source: "digraph { a -> b }"})
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 3, 2), img.Bounds())
+
+	// Rendered diagrams are cached.
+	require.NoError(t, os.Remove(filepath.Join(dir, "dot")))
+	_, err = Render(t.Context(), Block{Kind: KindGraphviz, Source: "digraph { a -> b }"})
+	require.NoError(t, err)
+}
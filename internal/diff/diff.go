@@ -2,18 +2,62 @@ package diff
 
 import (
 	"strings"
+	"sync/atomic"
 
 	"github.com/aymanbagabas/go-udiff"
 )
 
+// Algorithm is how the lines two contents have in common are found.
+type Algorithm string
+
+const (
+	// Myers finds the fewest changed lines, which can pair unrelated lines
+	// such as braces and blank ones.
+	Myers Algorithm = "myers"
+	// Patience anchors the diff on lines unique to both sides, which keeps
+	// functions whole when code moves around them.
+	Patience Algorithm = "patience"
+	// Histogram is patience that also anchors on lines that are rare rather
+	// than unique.
+	Histogram Algorithm = "histogram"
+)
+
+var algorithm atomic.Value
+
+// SetAlgorithm sets the algorithm every diff uses from now on. Unknown
+// algorithms use Myers.
+func SetAlgorithm(a Algorithm) {
+	algorithm.Store(a)
+}
+
+func currentAlgorithm() Algorithm {
+	a, _ := algorithm.Load().(Algorithm)
+	return a
+}
+
+// Edits returns the edits that turn before into after, using the algorithm
+// set with SetAlgorithm.
+func Edits(before, after string) []udiff.Edit {
+	if before == after {
+		return nil
+	}
+	switch currentAlgorithm() {
+	case Patience:
+		return lineEdits(before, after, patience)
+	case Histogram:
+		return lineEdits(before, after, histogram)
+	}
+	return udiff.Strings(before, after)
+}
+
 // GenerateDiff creates a unified diff from two file contents
 func GenerateDiff(beforeContent, afterContent, fileName string) (string, int, int) {
 	fileName = strings.TrimPrefix(fileName, "/")
 
 	var (
-		unified   = udiff.Unified("a/"+fileName, "b/"+fileName, beforeContent, afterContent)
-		additions = 0
-		removals  = 0
+		unified, _ = udiff.ToUnified("a/"+fileName, "b/"+fileName, beforeContent, Edits(beforeContent, afterContent), udiff.DefaultContextLines)
+		additions  = 0
+		removals   = 0
 	)
 
 	lines := strings.SplitSeq(unified, "\n")
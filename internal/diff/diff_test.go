@@ -0,0 +1,103 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditsApply(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct{ before, after string }{
+		{"", "a\nb\n"},
+		{"a\nb\n", ""},
+		{"a\nb\nc\n", "a\nc\n"},
+		{"a\nb", "a\nb\n"},
+		{"x\n}\n\ny\n}\n", "y\n}\n\nx\n}\n\nz\n"},
+		{"a\nb\nc\nd\ne\n", "e\nd\nc\nb\na\n"},
+	}
+	for _, algo := range []lineDiff{patience, histogram} {
+		for _, c := range cases {
+			got, err := udiff.Apply(c.before, lineEdits(c.before, c.after, algo))
+			require.NoError(t, err)
+			require.Equal(t, c.after, got)
+		}
+	}
+}
+
+// TestHistogramKeepsFunctionsWhole checks the diff of a function added
+// before another pairs the lines of each function, where Myers pairs their
+// braces and splits both.
+func TestHistogramKeepsFunctionsWhole(t *testing.T) {
+	t.Parallel()
+
+	before := "func a() {\n\treturn 1\n}\n"
+	after := "func b() {\n\treturn 2\n}\n\nfunc a() {\n\treturn 1\n}\n"
+	for _, algo := range []lineDiff{patience, histogram} {
+		u, err := udiff.ToUnifiedDiff("a", "b", before, lineEdits(before, after, algo), 0)
+		require.NoError(t, err)
+		require.Len(t, u.Hunks, 1)
+		var inserted []string
+		for _, l := range u.Hunks[0].Lines {
+			require.Equal(t, udiff.Insert, l.Kind)
+			inserted = append(inserted, l.Content)
+		}
+		require.Equal(t, "func b() {\n\treturn 2\n}\n\n", strings.Join(inserted, ""))
+	}
+}
+
+func TestDetectMoves(t *testing.T) {
+	t.Parallel()
+
+	block := "func helper() {\n\tdoThings()\n\treturn\n}\n"
+	main := "func main() {\n\tsetup()\n\thelper()\n\thelper()\n\tteardown()\n}\n"
+	before := "package main\n\n" + block + "\n" + main
+	after := "package main\n\n" + main + "\n" + block
+	u, err := udiff.ToUnifiedDiff("a", "b", before, udiff.Strings(before, after), udiff.DefaultContextLines)
+	require.NoError(t, err)
+
+	moves := DetectMoves(u)
+	require.Len(t, moves, 1)
+	require.Equal(t, 3, moves[0].From)
+	require.Equal(t, 10, moves[0].To)
+	require.GreaterOrEqual(t, moves[0].Lines, 4)
+
+	_, ok := moves.From(4)
+	require.True(t, ok)
+	_, ok = moves.To(1)
+	require.False(t, ok)
+
+	compact := GenerateCompactDiff(before, after, "main.go")
+	require.Contains(t, compact, "lines moved to line 10\n")
+	require.Contains(t, compact, "lines moved here from line 3\n")
+	require.NotContains(t, compact, "+\tdoThings()")
+	require.Less(t, len(compact), len(udiff.Unified("a/main.go", "b/main.go", before, after)))
+}
+
+func TestDetectMovesIgnoresShortBlocks(t *testing.T) {
+	t.Parallel()
+
+	before := "}\n\nx := 1\n}\n"
+	after := "x := 2\n}\n\n}\n"
+	u, err := udiff.ToUnifiedDiff("a", "b", before, udiff.Strings(before, after), udiff.DefaultContextLines)
+	require.NoError(t, err)
+	require.Empty(t, DetectMoves(u))
+	require.Equal(t, udiff.Unified("a/f", "b/f", before, after), GenerateCompactDiff(before, after, "f"))
+}
+
+func TestDetectRenames(t *testing.T) {
+	t.Parallel()
+
+	content := "package util\n\nfunc A() {}\n\nfunc B() {}\n"
+	renames := DetectRenames(
+		map[string]string{"old/util.go": content, "gone.go": "package gone\n"},
+		map[string]string{"new/util.go": strings.Replace(content, "func B() {}", "func C() {}", 1), "other.go": "package other\n"},
+	)
+	require.Equal(t, []Rename{{From: "old/util.go", To: "new/util.go", Similarity: 80}}, renames)
+
+	patch := GenerateRenameDiff(content, content, "old/util.go", "new/util.go")
+	require.Equal(t, "diff --git a/old/util.go b/new/util.go\nsimilarity index 100%\nrename from old/util.go\nrename to new/util.go\n", patch)
+}
@@ -0,0 +1,241 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/aymanbagabas/go-udiff/lcs"
+)
+
+// maxOccurrences is how often a line may appear in the old side of a
+// region for histogram to anchor on it, as in git.
+const maxOccurrences = 64
+
+// change is the replacement of lines a[a0:a1] with lines b[b0:b1].
+type change struct {
+	a0, a1, b0, b1 int
+}
+
+// lineDiff finds the changes between two sequences of interned lines.
+type lineDiff func(a, b []rune) []change
+
+// lineEdits diffs before and after line by line with diff, and returns the
+// changes as edits of before.
+func lineEdits(before, after string, diff lineDiff) []udiff.Edit {
+	aLines, bLines := splitLines(before), splitLines(after)
+	a, b := intern(aLines, bLines)
+	aOffsets, bOffsets := offsets(aLines), offsets(bLines)
+
+	changes := diff(a, b)
+	edits := make([]udiff.Edit, 0, len(changes))
+	for _, c := range changes {
+		edits = append(edits, udiff.Edit{
+			Start: aOffsets[c.a0],
+			End:   aOffsets[c.a1],
+			New:   after[bOffsets[c.b0]:bOffsets[c.b1]],
+		})
+	}
+	return edits
+}
+
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// offsets returns the byte offset each line starts at, followed by the
+// length of the content.
+func offsets(lines []string) []int {
+	offsets := make([]int, len(lines)+1)
+	for i, line := range lines {
+		offsets[i+1] = offsets[i] + len(line)
+	}
+	return offsets
+}
+
+// intern maps every distinct line to a rune of its own, so the lines can be
+// compared, and diffed by the character diff of udiff, as runes.
+func intern(a, b []string) ([]rune, []rune) {
+	ids := make(map[string]rune)
+	next := rune(1)
+	id := func(line string) rune {
+		r, ok := ids[line]
+		if !ok {
+			r = next
+			ids[line] = r
+			next++
+			if next == 0xD800 {
+				next = 0xE000 // skip surrogates, which DiffRunes cannot hold
+			}
+		}
+		return r
+	}
+	ra, rb := make([]rune, len(a)), make([]rune, len(b))
+	for i, line := range a {
+		ra[i] = id(line)
+	}
+	for i, line := range b {
+		rb[i] = id(line)
+	}
+	return ra, rb
+}
+
+// myers diffs a[a0:a1] against b[b0:b1] with the Myers diff of udiff.
+func myers(a, b []rune, a0, a1, b0, b1 int) []change {
+	diffs := lcs.DiffRunes(a[a0:a1], b[b0:b1])
+	changes := make([]change, 0, len(diffs))
+	for _, d := range diffs {
+		changes = append(changes, change{a0 + d.Start, a0 + d.End, b0 + d.ReplStart, b0 + d.ReplEnd})
+	}
+	return changes
+}
+
+// anchorFunc picks the lines of a[a0:a1] and b[b0:b1] to keep unchanged,
+// splitting the region around them. It returns them in order, or none to
+// fall back to Myers.
+type anchorFunc func(a, b []rune, a0, a1, b0, b1 int) [][2]int
+
+// recursive diffs by trimming the lines both sides start and end with, then
+// splitting what is left around the anchors picked by anchors.
+func recursive(anchors anchorFunc) lineDiff {
+	return func(a, b []rune) []change {
+		var changes []change
+		var diff func(a0, a1, b0, b1 int)
+		diff = func(a0, a1, b0, b1 int) {
+			for a0 < a1 && b0 < b1 && a[a0] == b[b0] {
+				a0++
+				b0++
+			}
+			for a0 < a1 && b0 < b1 && a[a1-1] == b[b1-1] {
+				a1--
+				b1--
+			}
+			switch {
+			case a0 == a1 && b0 == b1:
+				return
+			case a0 == a1 || b0 == b1:
+				changes = append(changes, change{a0, a1, b0, b1})
+				return
+			}
+			matches := anchors(a, b, a0, a1, b0, b1)
+			if len(matches) == 0 {
+				changes = append(changes, myers(a, b, a0, a1, b0, b1)...)
+				return
+			}
+			for _, m := range matches {
+				diff(a0, m[0], b0, m[1])
+				a0, b0 = m[0]+1, m[1]+1
+			}
+			diff(a0, a1, b0, b1)
+		}
+		diff(0, len(a), 0, len(b))
+		return changes
+	}
+}
+
+// patience anchors on the longest increasing run of the lines that appear
+// exactly once on each side.
+var patience = recursive(func(a, b []rune, a0, a1, b0, b1 int) [][2]int {
+	type count struct{ a, b, aIndex int }
+	counts := make(map[rune]*count)
+	for i := a0; i < a1; i++ {
+		c, ok := counts[a[i]]
+		if !ok {
+			c = &count{}
+			counts[a[i]] = c
+		}
+		c.a++
+		c.aIndex = i
+	}
+	for j := b0; j < b1; j++ {
+		if c, ok := counts[b[j]]; ok {
+			c.b++
+		}
+	}
+
+	var unique [][2]int
+	for j := b0; j < b1; j++ {
+		if c := counts[b[j]]; c != nil && c.a == 1 && c.b == 1 {
+			unique = append(unique, [2]int{c.aIndex, j})
+		}
+	}
+	return longestIncreasing(unique)
+})
+
+// longestIncreasing returns the longest run of pairs, ordered by their
+// second index, whose first indexes increase too.
+func longestIncreasing(pairs [][2]int) [][2]int {
+	if len(pairs) == 0 {
+		return nil
+	}
+	var tails []int // index in pairs of the last pair of each run length
+	prev := make([]int, len(pairs))
+	for i, p := range pairs {
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if pairs[tails[mid]][0] < p[0] {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		prev[i] = -1
+		if lo > 0 {
+			prev[i] = tails[lo-1]
+		}
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+	run := make([][2]int, len(tails))
+	for i, k := len(tails)-1, tails[len(tails)-1]; i >= 0; i, k = i-1, prev[k] {
+		run[i] = pairs[k]
+	}
+	return run
+}
+
+// histogram anchors on the longest common run of lines around the line of
+// b that is rarest in a, so that common lines such as braces never lead.
+var histogram = recursive(func(a, b []rune, a0, a1, b0, b1 int) [][2]int {
+	positions := make(map[rune][]int)
+	for i := a0; i < a1; i++ {
+		positions[a[i]] = append(positions[a[i]], i)
+	}
+
+	best, bestCount := change{}, maxOccurrences+1
+	for j := b0; j < b1; j++ {
+		at := positions[b[j]]
+		if len(at) == 0 || len(at) > bestCount {
+			continue
+		}
+		for _, i := range at {
+			s, t := i, j
+			for s > a0 && t > b0 && a[s-1] == b[t-1] {
+				s--
+				t--
+			}
+			e, f := i+1, j+1
+			for e < a1 && f < b1 && a[e] == b[f] {
+				e++
+				f++
+			}
+			if len(at) < bestCount || e-s > best.a1-best.a0 {
+				best, bestCount = change{s, e, t, f}, len(at)
+			}
+		}
+	}
+	if bestCount > maxOccurrences {
+		return nil
+	}
+	anchors := make([][2]int, 0, best.a1-best.a0)
+	for k := 0; k < best.a1-best.a0; k++ {
+		anchors = append(anchors, [2]int{best.a0 + k, best.b0 + k})
+	}
+	return anchors
+})
@@ -0,0 +1,215 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+)
+
+const (
+	// minMoveLines is the fewest non-blank lines a block needs to count as
+	// moved rather than deleted and inserted by chance.
+	minMoveLines = 3
+	// maxMoveCandidates bounds the deleted times inserted lines compared to
+	// find moves, which takes quadratic time.
+	maxMoveCandidates = 4_000_000
+)
+
+// A Move is a block of lines deleted in one place and inserted unchanged in
+// another.
+type Move struct {
+	// From is the first line of the block in the old content, 1-based.
+	From int
+	// To is the first line of the block in the new content, 1-based.
+	To    int
+	Lines int
+}
+
+// Moves are the blocks a diff moved.
+type Moves []Move
+
+// From returns the move that deleted line of the old content.
+func (m Moves) From(line int) (Move, bool) {
+	for _, move := range m {
+		if line >= move.From && line < move.From+move.Lines {
+			return move, true
+		}
+	}
+	return Move{}, false
+}
+
+// To returns the move that inserted line of the new content.
+func (m Moves) To(line int) (Move, bool) {
+	for _, move := range m {
+		if line >= move.To && line < move.To+move.Lines {
+			return move, true
+		}
+	}
+	return Move{}, false
+}
+
+type changedLine struct {
+	line    int
+	content string
+}
+
+// DetectMoves finds the blocks of at least three non-blank lines that u
+// deletes in one place and inserts in another, longest first.
+func DetectMoves(u udiff.UnifiedDiff) Moves {
+	var deleted, inserted []changedLine
+	for _, h := range u.Hunks {
+		from, to := h.FromLine, h.ToLine
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case udiff.Equal:
+				from++
+				to++
+			case udiff.Delete:
+				deleted = append(deleted, changedLine{from, strings.TrimSuffix(l.Content, "\n")})
+				from++
+			case udiff.Insert:
+				inserted = append(inserted, changedLine{to, strings.TrimSuffix(l.Content, "\n")})
+				to++
+			}
+		}
+	}
+	if len(deleted) < minMoveLines || len(inserted) < minMoveLines || len(deleted)*len(inserted) > maxMoveCandidates {
+		return nil
+	}
+
+	usedDeleted := make([]bool, len(deleted))
+	usedInserted := make([]bool, len(inserted))
+	var moves Moves
+	for {
+		i, j, n := longestBlock(deleted, inserted, usedDeleted, usedInserted)
+		if nonBlank(deleted[i-n+1:i+1]) < minMoveLines {
+			break
+		}
+		for k := range n {
+			usedDeleted[i-k] = true
+			usedInserted[j-k] = true
+		}
+		moves = append(moves, Move{From: deleted[i-n+1].line, To: inserted[j-n+1].line, Lines: n})
+	}
+	return moves
+}
+
+// longestBlock returns the longest run of consecutive lines deleted and
+// inserted alike that no move took yet, as the indexes of its last lines and
+// its length.
+func longestBlock(deleted, inserted []changedLine, usedDeleted, usedInserted []bool) (int, int, int) {
+	var bi, bj, best int
+	prev := make([]int, len(inserted))
+	cur := make([]int, len(inserted))
+	for i, d := range deleted {
+		for j, in := range inserted {
+			cur[j] = 0
+			if usedDeleted[i] || usedInserted[j] || d.content != in.content {
+				continue
+			}
+			cur[j] = 1
+			if i > 0 && j > 0 && deleted[i-1].line+1 == d.line && inserted[j-1].line+1 == in.line {
+				cur[j] += prev[j-1]
+			}
+			if cur[j] > best {
+				bi, bj, best = i, j, cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return bi, bj, best
+}
+
+func nonBlank(lines []changedLine) int {
+	n := 0
+	for _, l := range lines {
+		if strings.TrimSpace(l.content) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// GenerateCompactDiff creates a unified diff from two file contents in which
+// the blocks moved unchanged are each told in one line rather than deleted
+// and inserted line by line. It is for reading rather than applying.
+func GenerateCompactDiff(beforeContent, afterContent, fileName string) string {
+	fileName = strings.TrimPrefix(fileName, "/")
+	u, err := udiff.ToUnifiedDiff("a/"+fileName, "b/"+fileName, beforeContent, Edits(beforeContent, afterContent), udiff.DefaultContextLines)
+	if err != nil || len(u.Hunks) == 0 {
+		return ""
+	}
+	moves := DetectMoves(u)
+	if len(moves) == 0 {
+		return u.String()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", u.From, u.To)
+	for _, h := range u.Hunks {
+		writeHunkHeader(&b, h)
+		from, to := h.FromLine, h.ToLine
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case udiff.Equal:
+				writeLine(&b, " ", l.Content)
+				from++
+				to++
+			case udiff.Delete:
+				if m, ok := moves.From(from); !ok {
+					writeLine(&b, "-", l.Content)
+				} else if from == m.From {
+					fmt.Fprintf(&b, "~ %d lines moved to line %d\n", m.Lines, m.To)
+				}
+				from++
+			case udiff.Insert:
+				if m, ok := moves.To(to); !ok {
+					writeLine(&b, "+", l.Content)
+				} else if to == m.To {
+					fmt.Fprintf(&b, "~ %d lines moved here from line %d\n", m.Lines, m.From)
+				}
+				to++
+			}
+		}
+	}
+	return b.String()
+}
+
+// writeHunkHeader writes the header of h as udiff does.
+func writeHunkHeader(b *strings.Builder, h *udiff.Hunk) {
+	fromCount, toCount := 0, 0
+	for _, l := range h.Lines {
+		switch l.Kind {
+		case udiff.Delete:
+			fromCount++
+		case udiff.Insert:
+			toCount++
+		default:
+			fromCount++
+			toCount++
+		}
+	}
+	b.WriteString("@@")
+	b.WriteString(hunkRange("-", h.FromLine, fromCount))
+	b.WriteString(hunkRange("+", h.ToLine, toCount))
+	b.WriteString(" @@\n")
+}
+
+func hunkRange(sign string, line, count int) string {
+	switch {
+	case count > 1:
+		return fmt.Sprintf(" %s%d,%d", sign, line, count)
+	case line == 1 && count == 0:
+		return " " + sign + "0,0"
+	}
+	return fmt.Sprintf(" %s%d", sign, line)
+}
+
+func writeLine(b *strings.Builder, prefix, content string) {
+	b.WriteString(prefix)
+	b.WriteString(content)
+	if !strings.HasSuffix(content, "\n") {
+		b.WriteString("\n\\ No newline at end of file\n")
+	}
+}
@@ -0,0 +1,81 @@
+package diff
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+)
+
+// minSimilarity is how alike, in percent, a created file must be to a
+// removed one to count as renamed from it, as in git.
+const minSimilarity = 50
+
+// Similarity returns how alike two file contents are: the percentage of
+// their lines they have in common.
+func Similarity(beforeContent, afterContent string) int {
+	if beforeContent == afterContent {
+		return 100
+	}
+	aLines, bLines := splitLines(beforeContent), splitLines(afterContent)
+	a, b := intern(aLines, bLines)
+	common := len(a)
+	for _, c := range myers(a, b, 0, len(a), 0, len(b)) {
+		common -= c.a1 - c.a0
+	}
+	return 200 * common / (len(a) + len(b))
+}
+
+// A Rename pairs a removed file with the created file it became.
+type Rename struct {
+	From, To   string
+	Similarity int
+}
+
+// DetectRenames pairs the removed files with the created files at least
+// half like them, most alike first. Both map paths to their contents.
+func DetectRenames(removed, created map[string]string) []Rename {
+	var candidates []Rename
+	for from, before := range removed {
+		for to, after := range created {
+			if s := Similarity(before, after); s >= minSimilarity {
+				candidates = append(candidates, Rename{From: from, To: to, Similarity: s})
+			}
+		}
+	}
+	slices.SortFunc(candidates, func(a, b Rename) int {
+		return cmp.Or(
+			cmp.Compare(b.Similarity, a.Similarity),
+			strings.Compare(a.From, b.From),
+			strings.Compare(a.To, b.To),
+		)
+	})
+
+	var renames []Rename
+	paired := make(map[string]bool)
+	for _, r := range candidates {
+		if paired[r.From] || paired[r.To] {
+			continue
+		}
+		paired[r.From], paired[r.To] = true, true
+		renames = append(renames, r)
+	}
+	return renames
+}
+
+// GenerateRenameDiff creates a git diff renaming fromName to toName, with
+// the changes made to its content on the way.
+func GenerateRenameDiff(beforeContent, afterContent, fromName, toName string) string {
+	fromName = strings.TrimPrefix(fromName, "/")
+	toName = strings.TrimPrefix(toName, "/")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n", fromName, toName)
+	fmt.Fprintf(&b, "similarity index %d%%\n", Similarity(beforeContent, afterContent))
+	fmt.Fprintf(&b, "rename from %s\nrename to %s\n", fromName, toName)
+	unified, _ := udiff.ToUnified("a/"+fromName, "b/"+toName, beforeContent, Edits(beforeContent, afterContent), udiff.DefaultContextLines)
+	b.WriteString(unified)
+	return b.String()
+}
@@ -0,0 +1,136 @@
+// Package editor serves a JSON-RPC 2.0 protocol on a local socket so editor
+// plugins can open sessions, send selections as context and review the
+// edits the agent proposes.
+package editor
+
+// ProtocolVersion is the version of the protocol. It changes whenever a
+// change would break existing clients; clients send the version they speak
+// with [MethodInitialize] and are refused when it differs.
+const ProtocolVersion = 1
+
+// Methods clients call.
+const (
+	// MethodInitialize opens the connection with [InitializeParams] and
+	// returns [InitializeResult]. It must be the first call.
+	MethodInitialize = "initialize"
+	// MethodSessionCreate creates a session with [CreateSessionParams] and
+	// returns its [SessionInfo].
+	MethodSessionCreate = "session/create"
+	// MethodSessionList returns the [SessionInfo] of the sessions of the
+	// project, pinned sessions first, then the most recently updated.
+	MethodSessionList = "session/list"
+	// MethodSessionPrompt sends [PromptParams] to the agent and returns
+	// [PromptResult] once it has answered.
+	MethodSessionPrompt = "session/prompt"
+	// MethodSessionCancel stops the agent working in the session of
+	// [SessionParams].
+	MethodSessionCancel = "session/cancel"
+	// MethodEditList returns the [ProposedEdit]s waiting for review.
+	MethodEditList = "edit/list"
+	// MethodEditApply writes the proposed edit of [EditParams] to disk.
+	MethodEditApply = "edit/apply"
+	// MethodEditDiscard drops the proposed edit of [EditParams].
+	MethodEditDiscard = "edit/discard"
+)
+
+// Notifications the server sends once the connection is initialized.
+const (
+	// NotifyMessage sends a [MessageUpdate] as the agent answers in a
+	// session the client created or prompted.
+	NotifyMessage = "message/updated"
+	// NotifyEditProposed sends a [ProposedEdit] when the agent proposes an
+	// edit in review mode, or when a proposed edit changes.
+	NotifyEditProposed = "edit/proposed"
+	// NotifyEditResolved sends the [EditParams] of a proposed edit that was
+	// applied or discarded, from any client or from the TUI.
+	NotifyEditResolved = "edit/resolved"
+)
+
+// Error codes, besides those of JSON-RPC 2.0.
+const (
+	// CodeVersionMismatch is returned by [MethodInitialize] when the client
+	// speaks another version of the protocol.
+	CodeVersionMismatch = -32001
+	// CodeNotInitialized is returned for calls made before
+	// [MethodInitialize].
+	CodeNotInitialized = -32002
+	// CodeFailed is returned when the call was understood but failed, such
+	// as a prompt the agent could not answer.
+	CodeFailed = -32003
+)
+
+type InitializeParams struct {
+	// ProtocolVersion is the version of the protocol the client speaks.
+	ProtocolVersion int `json:"protocolVersion"`
+	// Client names the client, such as "vscode", for the logs.
+	Client string `json:"client,omitempty"`
+}
+
+type InitializeResult struct {
+	ProtocolVersion int    `json:"protocolVersion"`
+	Version         string `json:"version"`
+	WorkingDir      string `json:"workingDir"`
+}
+
+type CreateSessionParams struct {
+	Title string `json:"title,omitempty"`
+}
+
+type SessionParams struct {
+	SessionID string `json:"sessionId"`
+}
+
+type SessionInfo struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+// Selection is a part of a file the user selected in the editor, sent to
+// the agent along with the prompt.
+type Selection struct {
+	// Path is the path of the file, absolute or relative to the project.
+	Path string `json:"path"`
+	// StartLine and EndLine are the 1-based lines the selection spans.
+	StartLine int `json:"startLine,omitempty"`
+	EndLine   int `json:"endLine,omitempty"`
+	// Text is the selected text.
+	Text string `json:"text"`
+}
+
+type PromptParams struct {
+	SessionID  string      `json:"sessionId"`
+	Prompt     string      `json:"prompt"`
+	Selections []Selection `json:"selections,omitempty"`
+}
+
+type PromptResult struct {
+	// Response is the last answer of the agent.
+	Response string `json:"response"`
+}
+
+type EditParams struct {
+	ID string `json:"id"`
+}
+
+// ProposedEdit is an edit to a single file the agent proposed in review
+// mode.
+type ProposedEdit struct {
+	ID        string `json:"id"`
+	SessionID string `json:"sessionId"`
+	// Path is the absolute path of the file.
+	Path string `json:"path"`
+	// Diff is the change as a unified diff.
+	Diff      string `json:"diff"`
+	Additions int    `json:"additions"`
+	Removals  int    `json:"removals"`
+}
+
+type MessageUpdate struct {
+	SessionID string `json:"sessionId"`
+	MessageID string `json:"messageId"`
+	// Text is the whole text of the answer so far.
+	Text     string `json:"text"`
+	Finished bool   `json:"finished"`
+}
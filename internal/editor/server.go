@@ -0,0 +1,423 @@
+package editor
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/staging"
+	"github.com/charmbracelet/crush/internal/version"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// SocketName is the name of the socket in the data directory.
+const SocketName = "editor.sock"
+
+// maxSocketPath is the longest socket path every platform takes; macOS
+// allows 104 bytes.
+const maxSocketPath = 100
+
+// Backend is what the server works with; the app implements it.
+type Backend interface {
+	CreateSession(ctx context.Context, title string) (session.Session, error)
+	ListSessions(ctx context.Context, filter session.Filter) ([]session.Session, error)
+	SendMessage(ctx context.Context, sessionID, prompt string, attachments []message.Attachment) (*fantasy.AgentResult, error)
+	CancelSession(sessionID string)
+	PendingEdits() []staging.Edit
+	ApplyEdit(ctx context.Context, id string) error
+	DiscardEdit(id string) error
+	SubscribeMessages(ctx context.Context) <-chan pubsub.Event[message.Message]
+	SubscribeEdits(ctx context.Context) <-chan pubsub.Event[staging.Edit]
+}
+
+// SocketPath returns the path of the socket of the project whose data
+// directory is dataDir. It is in the data directory unless that path is too
+// long for a socket, in which case it is in the temporary directory, named
+// after the data directory.
+func SocketPath(dataDir string) string {
+	if abs, err := filepath.Abs(dataDir); err == nil {
+		dataDir = abs
+	}
+	path := filepath.Join(dataDir, SocketName)
+	if len(path) <= maxSocketPath {
+		return path
+	}
+	sum := sha256.Sum256([]byte(dataDir))
+	return filepath.Join(os.TempDir(), "crush-"+hex.EncodeToString(sum[:8])+".sock")
+}
+
+// Server serves editor clients on a unix domain socket, which Windows
+// supports too.
+type Server struct {
+	backend    Backend
+	workingDir string
+	path       string
+	listener   net.Listener
+	ctx        context.Context
+	cancel     context.CancelFunc
+
+	mu      sync.Mutex
+	clients map[*client]struct{}
+}
+
+// Listen starts serving backend on the socket at path. It fails when another
+// instance of crush serves it already; a socket left behind by one that
+// exited is replaced. Only the user may connect to the socket.
+func Listen(ctx context.Context, path, workingDir string, backend Backend) (*Server, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("another crush is serving %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Server{
+		backend:    backend,
+		workingDir: workingDir,
+		path:       path,
+		listener:   listener,
+		ctx:        ctx,
+		cancel:     cancel,
+		clients:    make(map[*client]struct{}),
+	}
+	go s.broadcast(backend.SubscribeMessages(ctx), backend.SubscribeEdits(ctx))
+	go s.accept()
+	slog.Info("Serving editor clients", "socket", path)
+	return s, nil
+}
+
+// Path returns the path of the socket.
+func (s *Server) Path() string {
+	return s.path
+}
+
+// Close stops serving, disconnects the clients and removes the socket.
+func (s *Server) Close() error {
+	s.cancel()
+	err := s.listener.Close()
+	s.mu.Lock()
+	for c := range s.clients {
+		_ = c.conn.Close()
+	}
+	s.mu.Unlock()
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Debug("Failed to remove editor socket", "error", err)
+	}
+	return err
+}
+
+func (s *Server) accept() {
+	for {
+		netConn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				slog.Error("Failed to accept editor client", "error", err)
+			}
+			return
+		}
+		c := &client{server: s, sessions: make(map[string]bool)}
+		stream := jsonrpc2.NewBufferedStream(netConn, jsonrpc2.VSCodeObjectCodec{})
+		handler := jsonrpc2.HandlerWithError(c.handle).SuppressErrClosed()
+		c.conn = jsonrpc2.NewConn(s.ctx, stream, jsonrpc2.AsyncHandler(handler), jsonrpc2.SetLogger(logger{}))
+
+		s.mu.Lock()
+		s.clients[c] = struct{}{}
+		s.mu.Unlock()
+		go func() {
+			<-c.conn.DisconnectNotify()
+			s.mu.Lock()
+			delete(s.clients, c)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// broadcast sends the answers of the agent and the proposed edits to the
+// clients.
+func (s *Server) broadcast(messages <-chan pubsub.Event[message.Message], edits <-chan pubsub.Event[staging.Edit]) {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case event, ok := <-messages:
+			if !ok {
+				return
+			}
+			msg := event.Payload
+			if msg.Role != message.Assistant {
+				continue
+			}
+			update := MessageUpdate{
+				SessionID: msg.SessionID,
+				MessageID: msg.ID,
+				Text:      msg.Content().String(),
+				Finished:  msg.IsFinished(),
+			}
+			s.notify(NotifyMessage, update, func(c *client) bool { return c.follows(msg.SessionID) })
+		case event, ok := <-edits:
+			if !ok {
+				return
+			}
+			if event.Type == pubsub.DeletedEvent {
+				s.notify(NotifyEditResolved, EditParams{ID: event.Payload.ID}, nil)
+			} else {
+				s.notify(NotifyEditProposed, proposedEdit(event.Payload), nil)
+			}
+		}
+	}
+}
+
+// notify sends a notification to the initialized clients that want it.
+func (s *Server) notify(method string, params any, want func(*client) bool) {
+	s.mu.Lock()
+	var clients []*client
+	for c := range s.clients {
+		if c.initialized.Load() && (want == nil || want(c)) {
+			clients = append(clients, c)
+		}
+	}
+	s.mu.Unlock()
+	for _, c := range clients {
+		if err := c.conn.Notify(s.ctx, method, params); err != nil && !errors.Is(err, jsonrpc2.ErrClosed) {
+			slog.Debug("Failed to notify editor client", "method", method, "error", err)
+		}
+	}
+}
+
+// client is a connection of an editor.
+type client struct {
+	server      *Server
+	conn        *jsonrpc2.Conn
+	initialized atomic.Bool
+
+	mu sync.Mutex
+	// sessions holds the sessions the client created or prompted, whose
+	// answers it is sent.
+	sessions map[string]bool
+}
+
+func (c *client) follow(sessionID string) {
+	c.mu.Lock()
+	c.sessions[sessionID] = true
+	c.mu.Unlock()
+}
+
+func (c *client) follows(sessionID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sessions[sessionID]
+}
+
+func (c *client) handle(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+	if req.Method != MethodInitialize && !c.initialized.Load() {
+		return nil, &jsonrpc2.Error{Code: CodeNotInitialized, Message: "call initialize first"}
+	}
+	// Prompts outlive the connection; cancel them with session/cancel.
+	ctx = context.WithoutCancel(ctx)
+	backend := c.server.backend
+
+	switch req.Method {
+	case MethodInitialize:
+		var params InitializeParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		if params.ProtocolVersion != ProtocolVersion {
+			return nil, &jsonrpc2.Error{
+				Code:    CodeVersionMismatch,
+				Message: fmt.Sprintf("crush speaks version %d of the protocol, not %d", ProtocolVersion, params.ProtocolVersion),
+			}
+		}
+		c.initialized.Store(true)
+		slog.Info("Editor client connected", "client", params.Client)
+		return InitializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Version:         version.Version,
+			WorkingDir:      c.server.workingDir,
+		}, nil
+
+	case MethodSessionCreate:
+		var params CreateSessionParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		sess, err := backend.CreateSession(ctx, cmp.Or(strings.TrimSpace(params.Title), "Editor session"))
+		if err != nil {
+			return nil, failed(err)
+		}
+		c.follow(sess.ID)
+		return sessionInfo(sess), nil
+
+	case MethodSessionList:
+		sessions, err := backend.ListSessions(ctx, session.Filter{})
+		if err != nil {
+			return nil, failed(err)
+		}
+		infos := make([]SessionInfo, 0, len(sessions))
+		for _, sess := range sessions {
+			infos = append(infos, sessionInfo(sess))
+		}
+		return infos, nil
+
+	case MethodSessionPrompt:
+		var params PromptParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		if params.SessionID == "" {
+			return nil, invalidParams("sessionId is required")
+		}
+		attachments, err := selectionAttachments(c.server.workingDir, params.Selections)
+		if err != nil {
+			return nil, failed(err)
+		}
+		c.follow(params.SessionID)
+		result, err := backend.SendMessage(ctx, params.SessionID, params.Prompt, attachments)
+		if err != nil {
+			return nil, failed(err)
+		}
+		var response PromptResult
+		if result != nil {
+			response.Response = result.Response.Content.Text()
+		}
+		return response, nil
+
+	case MethodSessionCancel:
+		var params SessionParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		backend.CancelSession(params.SessionID)
+		return nil, nil
+
+	case MethodEditList:
+		edits := backend.PendingEdits()
+		proposed := make([]ProposedEdit, 0, len(edits))
+		for _, edit := range edits {
+			proposed = append(proposed, proposedEdit(edit))
+		}
+		return proposed, nil
+
+	case MethodEditApply, MethodEditDiscard:
+		var params EditParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		var err error
+		if req.Method == MethodEditApply {
+			err = backend.ApplyEdit(ctx, params.ID)
+		} else {
+			err = backend.DiscardEdit(params.ID)
+		}
+		if err != nil {
+			return nil, failed(err)
+		}
+		return nil, nil
+
+	default:
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: "unknown method: " + req.Method}
+	}
+}
+
+// selectionAttachments turns selections into text attachments, named after
+// the file and the lines they span. Paths denied to the agent are refused.
+func selectionAttachments(workingDir string, selections []Selection) ([]message.Attachment, error) {
+	attachments := make([]message.Attachment, 0, len(selections))
+	for _, sel := range selections {
+		path := filepathext.SmartJoin(workingDir, sel.Path)
+		if fsext.Denied(path) {
+			return nil, fmt.Errorf("access to %s is denied", sel.Path)
+		}
+		label := path
+		if sel.StartLine > 0 {
+			label = fmt.Sprintf("%s:%d-%d", path, sel.StartLine, max(sel.EndLine, sel.StartLine))
+		}
+		attachments = append(attachments, message.Attachment{
+			FilePath: label,
+			FileName: filepath.Base(path),
+			MimeType: "text/plain",
+			Content:  []byte(sel.Text),
+		})
+	}
+	return attachments, nil
+}
+
+func sessionInfo(sess session.Session) SessionInfo {
+	return SessionInfo{
+		ID:        sess.ID,
+		Title:     sess.Title,
+		CreatedAt: sess.CreatedAt,
+		UpdatedAt: sess.UpdatedAt,
+	}
+}
+
+func proposedEdit(edit staging.Edit) ProposedEdit {
+	unified, _, _ := diff.GenerateDiff(edit.OldContent, edit.NewContent, edit.Path)
+	return ProposedEdit{
+		ID:        edit.ID,
+		SessionID: edit.SessionID,
+		Path:      edit.Path,
+		Diff:      unified,
+		Additions: edit.Additions,
+		Removals:  edit.Removals,
+	}
+}
+
+func decode(req *jsonrpc2.Request, v any) error {
+	if req.Params == nil {
+		return nil
+	}
+	if err := json.Unmarshal(*req.Params, v); err != nil {
+		return invalidParams(err.Error())
+	}
+	return nil
+}
+
+func invalidParams(msg string) *jsonrpc2.Error {
+	return &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: msg}
+}
+
+func failed(err error) *jsonrpc2.Error {
+	return &jsonrpc2.Error{Code: CodeFailed, Message: err.Error()}
+}
+
+// logger sends the logs of the connections to slog rather than stderr,
+// which the TUI owns.
+type logger struct{}
+
+func (logger) Printf(format string, v ...any) {
+	slog.Debug("Editor connection: " + strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
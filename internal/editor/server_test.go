@@ -0,0 +1,152 @@
+package editor
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/staging"
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackend struct {
+	messages *pubsub.Broker[message.Message]
+	edits    *pubsub.Broker[staging.Edit]
+	prompts  chan []message.Attachment
+}
+
+func (b *fakeBackend) CreateSession(_ context.Context, title string) (session.Session, error) {
+	return session.Session{ID: "s1", Title: title}, nil
+}
+
+func (b *fakeBackend) ListSessions(context.Context, session.Filter) ([]session.Session, error) {
+	return []session.Session{{ID: "s1", Title: "One"}}, nil
+}
+
+func (b *fakeBackend) SendMessage(_ context.Context, sessionID, prompt string, attachments []message.Attachment) (*fantasy.AgentResult, error) {
+	b.prompts <- attachments
+	b.messages.Publish(pubsub.UpdatedEvent, message.Message{
+		ID:        "m1",
+		SessionID: sessionID,
+		Role:      message.Assistant,
+		Parts:     []message.ContentPart{message.TextContent{Text: "Done"}},
+	})
+	return &fantasy.AgentResult{Response: fantasy.Response{Content: fantasy.ResponseContent{fantasy.TextContent{Text: "Done"}}}}, nil
+}
+
+func (b *fakeBackend) CancelSession(string)                    {}
+func (b *fakeBackend) PendingEdits() []staging.Edit            { return nil }
+func (b *fakeBackend) ApplyEdit(context.Context, string) error { return nil }
+func (b *fakeBackend) DiscardEdit(string) error                { return nil }
+
+func (b *fakeBackend) SubscribeMessages(ctx context.Context) <-chan pubsub.Event[message.Message] {
+	return b.messages.Subscribe(ctx)
+}
+
+func (b *fakeBackend) SubscribeEdits(ctx context.Context) <-chan pubsub.Event[staging.Edit] {
+	return b.edits.Subscribe(ctx)
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	backend := &fakeBackend{
+		messages: pubsub.NewBroker[message.Message](),
+		edits:    pubsub.NewBroker[staging.Edit](),
+		prompts:  make(chan []message.Attachment, 1),
+	}
+	server, err := Listen(t.Context(), filepath.Join(dir, SocketName), dir, backend)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = server.Close() })
+
+	_, err = Listen(t.Context(), server.Path(), dir, backend)
+	require.ErrorContains(t, err, "another crush")
+
+	netConn, err := net.Dial("unix", server.Path())
+	require.NoError(t, err)
+	notifications := make(chan *jsonrpc2.Request, 10)
+	handler := jsonrpc2.HandlerWithError(func(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		notifications <- req
+		return nil, nil
+	})
+	conn := jsonrpc2.NewConn(t.Context(), jsonrpc2.NewBufferedStream(netConn, jsonrpc2.VSCodeObjectCodec{}), handler)
+	t.Cleanup(func() { _ = conn.Close() })
+	ctx := t.Context()
+
+	var rpcErr *jsonrpc2.Error
+	err = conn.Call(ctx, MethodSessionList, nil, nil)
+	require.ErrorAs(t, err, &rpcErr)
+	require.EqualValues(t, CodeNotInitialized, rpcErr.Code)
+
+	err = conn.Call(ctx, MethodInitialize, InitializeParams{ProtocolVersion: ProtocolVersion + 1}, nil)
+	require.ErrorAs(t, err, &rpcErr)
+	require.EqualValues(t, CodeVersionMismatch, rpcErr.Code)
+
+	var initialized InitializeResult
+	require.NoError(t, conn.Call(ctx, MethodInitialize, InitializeParams{ProtocolVersion: ProtocolVersion, Client: "test"}, &initialized))
+	require.Equal(t, ProtocolVersion, initialized.ProtocolVersion)
+	require.Equal(t, dir, initialized.WorkingDir)
+
+	var sess SessionInfo
+	require.NoError(t, conn.Call(ctx, MethodSessionCreate, CreateSessionParams{}, &sess))
+	require.Equal(t, SessionInfo{ID: "s1", Title: "Editor session"}, sess)
+
+	var result PromptResult
+	require.NoError(t, conn.Call(ctx, MethodSessionPrompt, PromptParams{
+		SessionID:  "s1",
+		Prompt:     "Explain this",
+		Selections: []Selection{{Path: "main.go", StartLine: 3, EndLine: 5, Text: "func main() {}"}},
+	}, &result))
+	require.Equal(t, "Done", result.Response)
+	attachments := <-backend.prompts
+	require.Len(t, attachments, 1)
+	require.Equal(t, filepath.Join(dir, "main.go")+":3-5", attachments[0].FilePath)
+	require.Equal(t, "func main() {}", string(attachments[0].Content))
+
+	update := waitNotification(t, notifications, NotifyMessage)
+	require.Contains(t, string(*update.Params), `"text":"Done"`)
+
+	backend.edits.Publish(pubsub.CreatedEvent, staging.Edit{ID: "e1", Path: filepath.Join(dir, "a.txt"), OldContent: "a\n", NewContent: "b\n"})
+	proposed := waitNotification(t, notifications, NotifyEditProposed)
+	require.Contains(t, string(*proposed.Params), `"id":"e1"`)
+	require.Contains(t, string(*proposed.Params), `+b`)
+
+	err = conn.Call(ctx, "unknown", nil, nil)
+	require.ErrorAs(t, err, &rpcErr)
+	require.EqualValues(t, jsonrpc2.CodeMethodNotFound, rpcErr.Code)
+}
+
+func waitNotification(t *testing.T, notifications <-chan *jsonrpc2.Request, method string) *jsonrpc2.Request {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case req := <-notifications:
+			if req.Method == method {
+				return req
+			}
+		case <-timeout:
+			t.Fatalf("no %s notification", method)
+		}
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, filepath.Join("/project", ".crush", SocketName), SocketPath("/project/.crush"))
+
+	long := "/" + strings.Repeat("a", maxSocketPath) + "/.crush"
+	path := SocketPath(long)
+	require.LessOrEqual(t, len(path), maxSocketPath)
+	require.Equal(t, path, SocketPath(long))
+}
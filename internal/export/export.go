@@ -0,0 +1,248 @@
+// Package export renders the conversation of a session as a document to
+// share: Markdown, or a standalone HTML page with highlighted code and the
+// tool outputs collapsed.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// Format is the format of an exported transcript.
+type Format string
+
+const (
+	Markdown Format = "markdown"
+	HTML     Format = "html"
+)
+
+// ParseFormat returns the format named s, accepting "md" for Markdown.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "markdown", "md":
+		return Markdown, nil
+	case "html":
+		return HTML, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q: use markdown or html", s)
+	}
+}
+
+// Ext returns the file extension of the format, with its dot.
+func (f Format) Ext() string {
+	if f == HTML {
+		return ".html"
+	}
+	return ".md"
+}
+
+// maxOutput is how much of each tool output is exported.
+const maxOutput = 20000
+
+// Render renders the conversation of sess in format.
+func Render(format Format, sess session.Session, messages []message.Message) ([]byte, error) {
+	return RenderRedacted(format, sess, messages, nil)
+}
+
+// RenderRedacted renders the conversation of sess in format like [Render],
+// passing every text taken from the session, tool inputs and outputs
+// included, through redact first.
+func RenderRedacted(format Format, sess session.Session, messages []message.Message, redact func(string) string) ([]byte, error) {
+	conv := newConversation(sess, messages)
+	if redact != nil {
+		conv.redact(redact)
+	}
+	switch format {
+	case Markdown:
+		return []byte(conv.markdown()), nil
+	case HTML:
+		return conv.html()
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// conversation is a session as exported: its turns, with the result of each
+// tool call next to the call.
+type conversation struct {
+	session session.Session
+	turns   []turn
+}
+
+type turn struct {
+	role      message.MessageRole
+	model     string
+	summary   bool
+	at        time.Time
+	text      string
+	thinking  string
+	files     []string
+	toolCalls []toolCall
+}
+
+type toolCall struct {
+	name    string
+	title   string
+	input   string
+	output  string
+	isError bool
+	done    bool
+}
+
+func newConversation(sess session.Session, messages []message.Message) conversation {
+	results := make(map[string]message.ToolResult)
+	for _, msg := range messages {
+		for _, result := range msg.ToolResults() {
+			results[result.ToolCallID] = result
+		}
+	}
+	conv := conversation{session: sess}
+	for _, msg := range messages {
+		if msg.Role == message.Tool {
+			continue
+		}
+		t := turn{
+			role:     msg.Role,
+			model:    msg.Model,
+			summary:  msg.IsSummaryMessage,
+			at:       time.Unix(msg.CreatedAt, 0),
+			text:     strings.TrimSpace(msg.Content().Text),
+			thinking: strings.TrimSpace(msg.ReasoningContent().Thinking),
+		}
+		for _, bc := range msg.BinaryContent() {
+			t.files = append(t.files, bc.Path)
+		}
+		for _, call := range msg.ToolCalls() {
+			tc := toolCall{name: call.Name, title: callTitle(call), input: prettyJSON(call.Input)}
+			if result, ok := results[call.ID]; ok {
+				tc.done = true
+				tc.isError = result.IsError
+				tc.output = result.Content
+				if len(tc.output) > maxOutput {
+					tc.output = tc.output[:maxOutput] + fmt.Sprintf("\n… %d more bytes", len(result.Content)-maxOutput)
+				}
+			}
+			t.toolCalls = append(t.toolCalls, tc)
+		}
+		if t.text == "" && t.thinking == "" && len(t.files) == 0 && len(t.toolCalls) == 0 {
+			continue
+		}
+		conv.turns = append(conv.turns, t)
+	}
+	return conv
+}
+
+func (c *conversation) redact(redact func(string) string) {
+	c.session.Title = redact(c.session.Title)
+	for i := range c.turns {
+		t := &c.turns[i]
+		t.text = redact(t.text)
+		t.thinking = redact(t.thinking)
+		for j := range t.toolCalls {
+			tc := &t.toolCalls[j]
+			tc.title = redact(tc.title)
+			tc.input = redact(tc.input)
+			tc.output = redact(tc.output)
+		}
+	}
+}
+
+// heading returns the heading of the turn, such as "Assistant (gpt-4o)".
+func (t turn) heading() string {
+	switch {
+	case t.role == message.User:
+		return "User"
+	case t.summary:
+		return "Summary"
+	case t.model != "":
+		return "Assistant (" + t.model + ")"
+	default:
+		return "Assistant"
+	}
+}
+
+// subtitle describes the session under its title.
+func (c conversation) subtitle() string {
+	created := time.Unix(c.session.CreatedAt, 0).Format("2006-01-02 15:04")
+	return fmt.Sprintf("Crush session %s, started %s.", c.session.ID, created)
+}
+
+func (c conversation) markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n%s\n", c.session.Title, c.subtitle())
+	for _, t := range c.turns {
+		fmt.Fprintf(&sb, "\n## %s · %s\n", t.heading(), t.at.Format("2006-01-02 15:04"))
+		if t.thinking != "" {
+			fmt.Fprintf(&sb, "\n<details>\n<summary>Thinking</summary>\n\n%s\n\n</details>\n", t.thinking)
+		}
+		if t.text != "" {
+			fmt.Fprintf(&sb, "\n%s\n", t.text)
+		}
+		if len(t.files) > 0 {
+			fmt.Fprintf(&sb, "\nAttached: %s\n", strings.Join(t.files, ", "))
+		}
+		for _, tc := range t.toolCalls {
+			fmt.Fprintf(&sb, "\n<details>\n<summary>%s</summary>\n\n%s%s</details>\n", escapeSummary(tc.summary()), fenced(tc.input, "json"), fenced(tc.output, ""))
+		}
+	}
+	return sb.String()
+}
+
+// summary is the line a collapsed tool call shows.
+func (tc toolCall) summary() string {
+	switch {
+	case !tc.done:
+		return tc.title + " (no result)"
+	case tc.isError:
+		return tc.title + " (failed)"
+	default:
+		return tc.title
+	}
+}
+
+// fenced puts s in a fenced code block long enough not to be closed by s.
+func fenced(s, lang string) string {
+	if strings.TrimSpace(s) == "" {
+		return ""
+	}
+	fence := "```"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	return fmt.Sprintf("%s%s\n%s\n%s\n\n", fence, lang, strings.TrimRight(s, "\n"), fence)
+}
+
+func escapeSummary(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// callTitle describes a tool call by its name and main parameter.
+func callTitle(call message.ToolCall) string {
+	var params map[string]any
+	if json.Unmarshal([]byte(call.Input), &params) == nil {
+		for _, key := range []string{"command", "file_path", "path", "pattern", "url", "query", "prompt"} {
+			if value, ok := params[key].(string); ok && value != "" {
+				line, _, _ := strings.Cut(strings.TrimSpace(value), "\n")
+				return call.Name + ": " + line
+			}
+		}
+	}
+	return call.Name
+}
+
+func prettyJSON(s string) string {
+	var v any
+	if json.Unmarshal([]byte(s), &v) != nil {
+		return s
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return s
+	}
+	return string(data)
+}
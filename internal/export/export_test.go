@@ -0,0 +1,79 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func conversationMessages() []message.Message {
+	return []message.Message{
+		{ID: "u1", Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "Why does <main> fail?"}}},
+		{ID: "a1", Role: message.Assistant, Model: "gpt-4o", Parts: []message.ContentPart{
+			message.ReasoningContent{Thinking: "Run the tests."},
+			message.TextContent{Text: "Let me check.\n\n```go\nfunc main() {}\n```"},
+			message.ToolCall{ID: "c1", Name: "bash", Input: `{"command":"go test ./..."}`, Finished: true},
+		}},
+		{ID: "t1", Role: message.Tool, Parts: []message.ContentPart{
+			message.ToolResult{ToolCallID: "c1", Name: "bash", Content: "FAIL main_test.go ```", IsError: true},
+		}},
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	t.Parallel()
+
+	out, err := Render(Markdown, session.Session{ID: "s1", Title: "Debugging"}, conversationMessages())
+	require.NoError(t, err)
+	md := string(out)
+	require.True(t, strings.HasPrefix(md, "# Debugging\n"))
+	require.Contains(t, md, "## Assistant (gpt-4o)")
+	require.Contains(t, md, "<summary>Thinking</summary>")
+	require.Contains(t, md, "<summary>bash: go test ./... (failed)</summary>")
+	require.Contains(t, md, "````\nFAIL main_test.go ```\n````")
+	require.Contains(t, md, "\"command\": \"go test ./...\"")
+	require.Equal(t, 2, strings.Count(md, "\n## "), "tool messages are shown with their calls")
+}
+
+func TestRenderHTML(t *testing.T) {
+	t.Parallel()
+
+	out, err := Render(HTML, session.Session{ID: "s1", Title: "Debugging <1>"}, conversationMessages())
+	require.NoError(t, err)
+	page := string(out)
+	require.True(t, strings.HasPrefix(page, "<!DOCTYPE html>"))
+	require.Contains(t, page, "<title>Debugging &lt;1&gt;</title>")
+	require.Contains(t, page, "Why does &lt;main&gt; fail?")
+	require.Contains(t, page, `<pre class="chroma">`)
+	require.Contains(t, page, `<details class="failed"><summary>bash: go test ./... (failed)</summary>`)
+	require.Contains(t, page, "<pre>FAIL main_test.go ```</pre>")
+	require.Contains(t, page, ".chroma")
+}
+
+func TestRenderRedacted(t *testing.T) {
+	t.Parallel()
+
+	redact := func(s string) string { return strings.ReplaceAll(s, "go test", "[REDACTED]") }
+	out, err := RenderRedacted(Markdown, session.Session{ID: "s1", Title: "Debugging"}, conversationMessages(), redact)
+	require.NoError(t, err)
+	md := string(out)
+	require.NotContains(t, md, "go test")
+	require.Contains(t, md, "<summary>bash: [REDACTED] ./... (failed)</summary>")
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+
+	format, err := ParseFormat("MD")
+	require.NoError(t, err)
+	require.Equal(t, Markdown, format)
+	require.Equal(t, ".md", format.Ext())
+	format, err = ParseFormat("html")
+	require.NoError(t, err)
+	require.Equal(t, ".html", format.Ext())
+	_, err = ParseFormat("pdf")
+	require.Error(t, err)
+}
@@ -0,0 +1,240 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// codeStyle is the chroma style code is highlighted with.
+const codeStyle = "github"
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="Crush">
+<title>{{.Title}}</title>
+<style>
+body { font: 16px/1.5 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; max-width: 860px; margin: 2rem auto; padding: 0 1rem; }
+header p, .meta { color: #59636e; }
+section { border-top: 1px solid #d1d9e0; padding: 0.5rem 0 1rem; }
+section h2 { font-size: 1rem; margin: 0.5rem 0; }
+section.user h2 { color: #0969da; }
+section.assistant h2 { color: #8250df; }
+.meta { font-weight: normal; margin-left: 0.5rem; }
+details { border: 1px solid #d1d9e0; border-radius: 6px; margin: 0.5rem 0; padding: 0.25rem 0.75rem; }
+details summary { cursor: pointer; font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 0.875rem; }
+details.failed summary { color: #d1242f; }
+pre { overflow-x: auto; padding: 0.75rem; border-radius: 6px; background: #f6f8fa; font-size: 0.875rem; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d1d9e0; padding: 0.25rem 0.5rem; }
+{{.CSS}}
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<p>{{.Subtitle}}</p>
+</header>
+{{range .Turns}}<section class="{{.Class}}">
+<h2>{{.Heading}}<span class="meta">{{.Time}}</span></h2>
+{{if .Thinking}}<details><summary>Thinking</summary>{{.Thinking}}</details>
+{{end}}{{.Text}}
+{{if .Files}}<p class="meta">Attached: {{.Files}}</p>
+{{end}}{{range .ToolCalls}}<details{{if .Failed}} class="failed"{{end}}><summary>{{.Summary}}</summary>
+{{.Input}}{{if .Output}}<pre>{{.Output}}</pre>{{end}}
+</details>
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
+
+type pageData struct {
+	Title    string
+	Subtitle string
+	CSS      template.CSS
+	Turns    []pageTurn
+}
+
+type pageTurn struct {
+	Class     string
+	Heading   string
+	Time      string
+	Thinking  template.HTML
+	Text      template.HTML
+	Files     string
+	ToolCalls []pageToolCall
+}
+
+type pageToolCall struct {
+	Summary string
+	Failed  bool
+	Input   template.HTML
+	Output  string
+}
+
+func (c conversation) html() ([]byte, error) {
+	style := styles.Get(codeStyle)
+	formatter := chromahtml.New(chromahtml.WithClasses(true), chromahtml.TabWidth(4))
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(renderer.WithNodeRenderers(
+			util.Prioritized(&nodeRenderer{style: style, formatter: formatter}, 100),
+		)),
+	)
+	toHTML := func(s string) (template.HTML, error) {
+		if s == "" {
+			return "", nil
+		}
+		var buf bytes.Buffer
+		err := md.Convert([]byte(s), &buf)
+		// Raw HTML is escaped by nodeRenderer, so the output is safe to
+		// include.
+		return template.HTML(buf.String()), err //nolint:gosec
+	}
+
+	var css bytes.Buffer
+	if err := formatter.WriteCSS(&css, style); err != nil {
+		return nil, err
+	}
+	data := pageData{
+		Title:    c.session.Title,
+		Subtitle: c.subtitle(),
+		CSS:      template.CSS(css.String()), //nolint:gosec
+	}
+	for _, t := range c.turns {
+		pt := pageTurn{
+			Class:   string(t.role),
+			Heading: t.heading(),
+			Time:    t.at.Format("2006-01-02 15:04"),
+			Files:   strings.Join(t.files, ", "),
+		}
+		var err error
+		if pt.Thinking, err = toHTML(t.thinking); err != nil {
+			return nil, err
+		}
+		if pt.Text, err = toHTML(t.text); err != nil {
+			return nil, err
+		}
+		for _, tc := range t.toolCalls {
+			input, err := highlight(formatter, style, tc.input, "json")
+			if err != nil {
+				return nil, err
+			}
+			pt.ToolCalls = append(pt.ToolCalls, pageToolCall{
+				Summary: tc.summary(),
+				Failed:  tc.isError,
+				Input:   input,
+				Output:  strings.TrimRight(tc.output, "\n"),
+			})
+		}
+		data.Turns = append(data.Turns, pt)
+	}
+
+	var out bytes.Buffer
+	if err := page.Execute(&out, data); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// nodeRenderer renders fenced code blocks highlighted by chroma, and raw
+// HTML as the text it is rather than leaving it out.
+type nodeRenderer struct {
+	style     *chroma.Style
+	formatter *chromahtml.Formatter
+}
+
+func (r *nodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderCode)
+	reg.Register(ast.KindRawHTML, r.renderRawHTML)
+	reg.Register(ast.KindHTMLBlock, r.renderHTMLBlock)
+}
+
+func (r *nodeRenderer) renderCode(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	block := node.(*ast.FencedCodeBlock)
+	highlighted, err := highlight(r.formatter, r.style, linesText(block.Lines(), source), string(block.Language(source)))
+	if err != nil {
+		return ast.WalkStop, err
+	}
+	_, err = w.WriteString(string(highlighted))
+	return ast.WalkSkipChildren, err
+}
+
+func (r *nodeRenderer) renderRawHTML(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	segments := node.(*ast.RawHTML).Segments
+	for i := range segments.Len() {
+		segment := segments.At(i)
+		if _, err := w.Write(util.EscapeHTML(segment.Value(source))); err != nil {
+			return ast.WalkStop, err
+		}
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *nodeRenderer) renderHTMLBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	block := node.(*ast.HTMLBlock)
+	text := linesText(block.Lines(), source)
+	if block.HasClosure() {
+		text += string(block.ClosureLine.Value(source))
+	}
+	_, err := fmt.Fprintf(w, "<p>%s</p>\n", util.EscapeHTML([]byte(strings.TrimRight(text, "\n"))))
+	return ast.WalkSkipChildren, err
+}
+
+func linesText(lines *text.Segments, source []byte) string {
+	var sb strings.Builder
+	for i := range lines.Len() {
+		segment := lines.At(i)
+		sb.Write(segment.Value(source))
+	}
+	return sb.String()
+}
+
+// highlight renders code in lang, guessed when unknown, as highlighted HTML.
+func highlight(formatter *chromahtml.Formatter, style *chroma.Style, code, lang string) (template.HTML, error) {
+	if strings.TrimSpace(code) == "" {
+		return "", nil
+	}
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	it, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := formatter.Format(&buf, style, it); err != nil {
+		return "", err
+	}
+	// chroma escapes the code it formats.
+	return template.HTML(buf.String()), nil //nolint:gosec
+}
@@ -0,0 +1,264 @@
+// Package filecache shares the files agents read between them, so that
+// sub-agents working on the same workspace in parallel do not each read
+// the same large files again, and serializes the writes they make.
+package filecache
+
+import (
+	"container/list"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultMaxBytes is how much file content a cache holds at most.
+const DefaultMaxBytes = 64 << 20 // 64MB
+
+// Cache is a read-through cache of file contents. A cached file is served
+// as long as its size and modification time are unchanged, and it is
+// dropped as soon as a file watcher reports a change to it, which catches
+// changes too quick to move the modification time. A nil *Cache reads and
+// writes the file system directly.
+type Cache struct {
+	maxBytes int64
+	group    singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // Most recently used first.
+	bytes   int64
+	dirs    map[string]int // Watched directories, by cached files in them.
+	locks   map[string]*sync.Mutex
+
+	watcher *fsnotify.Watcher
+}
+
+type entry struct {
+	path    string
+	data    []byte
+	size    int64
+	modTime time.Time
+}
+
+// New creates a cache holding up to maxBytes of file content, or
+// [DefaultMaxBytes] when it is not positive. Files are still validated by
+// their modification time when no watcher can be started.
+func New(maxBytes int64) *Cache {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	c := &Cache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		dirs:     make(map[string]int),
+		locks:    make(map[string]*sync.Mutex),
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		slog.Warn("Failed to watch cached files", "error", err)
+		return c
+	}
+	c.watcher = watcher
+	go c.watch()
+	return c
+}
+
+// Close stops watching the cached files.
+func (c *Cache) Close() error {
+	if c == nil || c.watcher == nil {
+		return nil
+	}
+	return c.watcher.Close()
+}
+
+func (c *Cache) watch() {
+	for {
+		select {
+		case event, ok := <-c.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op != fsnotify.Chmod {
+				c.Invalidate(event.Name)
+			}
+		case err, ok := <-c.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Debug("File watcher error", "error", err)
+		}
+	}
+}
+
+// ReadFile returns the content of the file at path, reading it only when it
+// is not cached or changed since. Callers share the returned slice and must
+// not modify it. Reads of the same file at the same time read it once.
+func (c *Cache) ReadFile(path string) ([]byte, error) {
+	if c == nil {
+		return os.ReadFile(path)
+	}
+	path = normalize(path)
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		c.Invalidate(path)
+		return os.ReadFile(path)
+	}
+	if data, ok := c.get(path, info); ok {
+		return data, nil
+	}
+	data, err, _ := c.group.Do(path, func() (any, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		c.put(path, data, info)
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data.([]byte), nil
+}
+
+// WriteFile writes data to the file at path and caches it. Writes that read
+// the file first should hold [Cache.Lock] from the read to the write.
+func (c *Cache) WriteFile(path string, data []byte, perm os.FileMode) error {
+	if c == nil {
+		return os.WriteFile(path, data, perm)
+	}
+	path = normalize(path)
+	c.Invalidate(path)
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		c.put(path, append([]byte(nil), data...), info)
+	}
+	return nil
+}
+
+// Lock locks the file at path for writing, so that agents changing the
+// same file do not overwrite each other's changes. It returns the function
+// unlocking it.
+func (c *Cache) Lock(path string) (unlock func()) {
+	if c == nil {
+		return func() {}
+	}
+	path = normalize(path)
+	c.mu.Lock()
+	lock, ok := c.locks[path]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.locks[path] = lock
+	}
+	c.mu.Unlock()
+	lock.Lock()
+	return lock.Unlock
+}
+
+// Invalidate drops the file at path from the cache.
+func (c *Cache) Invalidate(path string) {
+	if c == nil {
+		return
+	}
+	path = normalize(path)
+	c.mu.Lock()
+	var unwatch string
+	if el, ok := c.entries[path]; ok {
+		unwatch = c.remove(el)
+	}
+	c.mu.Unlock()
+	c.unwatch(unwatch)
+}
+
+func (c *Cache) get(path string, info os.FileInfo) ([]byte, bool) {
+	c.mu.Lock()
+	el, ok := c.entries[path]
+	if !ok {
+		c.mu.Unlock()
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		c.lru.MoveToFront(el)
+		c.mu.Unlock()
+		return e.data, true
+	}
+	unwatch := c.remove(el)
+	c.mu.Unlock()
+	c.unwatch(unwatch)
+	return nil, false
+}
+
+// put caches data as the content of the file at path, evicting the least
+// recently used files to make room. Files larger than a quarter of the
+// cache are not cached.
+func (c *Cache) put(path string, data []byte, info os.FileInfo) {
+	size := int64(len(data))
+	if size > c.maxBytes/4 {
+		return
+	}
+	var watch string
+	var unwatch []string
+	c.mu.Lock()
+	if el, ok := c.entries[path]; ok {
+		unwatch = append(unwatch, c.remove(el))
+	}
+	for c.bytes+size > c.maxBytes && c.lru.Len() > 0 {
+		unwatch = append(unwatch, c.remove(c.lru.Back()))
+	}
+	c.entries[path] = c.lru.PushFront(&entry{path: path, data: data, size: info.Size(), modTime: info.ModTime()})
+	c.bytes += size
+	dir := filepath.Dir(path)
+	if c.dirs[dir] == 0 {
+		watch = dir
+	}
+	c.dirs[dir]++
+	c.mu.Unlock()
+
+	// The watcher is only touched outside the lock, since it may be
+	// waiting to deliver an event that needs it.
+	for _, dir := range unwatch {
+		if dir != watch {
+			c.unwatch(dir)
+		}
+	}
+	if watch != "" && c.watcher != nil {
+		if err := c.watcher.Add(watch); err != nil {
+			slog.Debug("Failed to watch directory", "dir", watch, "error", err)
+		}
+	}
+}
+
+// remove drops a cached file. It returns the directory to stop watching
+// when no other file in it is cached, if any. c.mu must be held.
+func (c *Cache) remove(el *list.Element) string {
+	e := c.lru.Remove(el).(*entry)
+	delete(c.entries, e.path)
+	c.bytes -= int64(len(e.data))
+	dir := filepath.Dir(e.path)
+	if c.dirs[dir]--; c.dirs[dir] > 0 {
+		return ""
+	}
+	delete(c.dirs, dir)
+	return dir
+}
+
+func (c *Cache) unwatch(dir string) {
+	if dir == "" || c.watcher == nil {
+		return
+	}
+	_ = c.watcher.Remove(dir)
+}
+
+func normalize(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
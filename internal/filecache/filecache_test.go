@@ -0,0 +1,129 @@
+package filecache
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFileCachesUntilChanged(t *testing.T) {
+	t.Parallel()
+
+	c := New(0)
+	t.Cleanup(func() { c.Close() })
+	path := filepath.Join(t.TempDir(), "big.go")
+	require.NoError(t, os.WriteFile(path, []byte("package big"), 0o644))
+
+	first, err := c.ReadFile(path)
+	require.NoError(t, err)
+	second, err := c.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "package big", string(second))
+	require.Same(t, &first[0], &second[0], "the second read is served from the cache")
+
+	// Changed behind the cache's back, with the old modification time.
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("package new"), 0o644))
+	require.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+	require.Eventually(t, func() bool {
+		data, err := c.ReadFile(path)
+		return err == nil && string(data) == "package new"
+	}, 5*time.Second, 10*time.Millisecond, "the watcher drops the changed file")
+
+	require.NoError(t, os.WriteFile(path, []byte("package newer"), 0o644))
+	data, err := c.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "package newer", string(data), "a new size is noticed without the watcher")
+
+	require.NoError(t, os.Remove(path))
+	_, err = c.ReadFile(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestWriteFile(t *testing.T) {
+	t.Parallel()
+
+	c := New(0)
+	t.Cleanup(func() { c.Close() })
+	path := filepath.Join(t.TempDir(), "a.txt")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0o644))
+	_, err := c.ReadFile(path)
+	require.NoError(t, err)
+
+	require.NoError(t, c.WriteFile(path, []byte("new content"), 0o644))
+	onDisk, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "new content", string(onDisk))
+	data, err := c.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "new content", string(data))
+}
+
+func TestEviction(t *testing.T) {
+	t.Parallel()
+
+	c := New(40)
+	t.Cleanup(func() { c.Close() })
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("0123456789"), 0o644))
+		_, err := c.ReadFile(path)
+		require.NoError(t, err)
+	}
+	big := filepath.Join(dir, "big")
+	require.NoError(t, os.WriteFile(big, make([]byte, 11), 0o644))
+	_, err := c.ReadFile(big)
+	require.NoError(t, err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	require.Equal(t, int64(40), c.bytes)
+	require.Len(t, c.entries, 4)
+	require.NotContains(t, c.entries, filepath.Join(dir, "a"), "the least recently used file goes first")
+	require.NotContains(t, c.entries, big, "files over a quarter of the cache are not kept")
+	require.Equal(t, map[string]int{dir: 4}, c.dirs)
+}
+
+func TestLockSerializesEdits(t *testing.T) {
+	t.Parallel()
+
+	c := New(0)
+	t.Cleanup(func() { c.Close() })
+	path := filepath.Join(t.TempDir(), "count")
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			defer c.Lock(path)()
+			data, err := c.ReadFile(path)
+			require.NoError(t, err)
+			require.NoError(t, c.WriteFile(path, append(append([]byte(nil), data...), 'x'), 0o644))
+		})
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, data, 20, "no edit was lost")
+}
+
+func TestNilCache(t *testing.T) {
+	t.Parallel()
+
+	var c *Cache
+	path := filepath.Join(t.TempDir(), "a.txt")
+	require.NoError(t, c.WriteFile(path, []byte("hi"), 0o644))
+	data, err := c.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "hi", string(data))
+	c.Lock(path)()
+	c.Invalidate(path)
+	require.NoError(t, c.Close())
+}
@@ -0,0 +1,121 @@
+// Package fileheader applies and checks the license or copyright headers a
+// project requires at the top of its source files.
+package fileheader
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// YearPlaceholder is replaced with the current year in header templates.
+// When checking for an existing header it matches any year or year range.
+const YearPlaceholder = "{year}"
+
+// yearPattern matches years such as "2024", "2021-2024" or "2021, 2024".
+const yearPattern = `\d{4}(?:\s*[-–,]\s*\d{4})*`
+
+// Policy adds the configured header to new files and reports files missing
+// it. A nil Policy is valid and requires no headers.
+type Policy struct {
+	check     bool
+	templates map[string]string
+	now       func() time.Time
+}
+
+// New creates a policy from the file_headers options in cfg. It returns nil
+// when no header template is configured.
+func New(cfg *config.Config) *Policy {
+	if cfg.Options == nil || cfg.Options.FileHeaders == nil {
+		return nil
+	}
+	opts := cfg.Options.FileHeaders
+	templates := make(map[string]string, len(opts.Templates))
+	for ext, tmpl := range opts.Templates {
+		ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+		if ext == "" || strings.TrimSpace(tmpl) == "" {
+			continue
+		}
+		templates[ext] = tmpl
+	}
+	if len(templates) == 0 {
+		return nil
+	}
+	return &Policy{
+		check:     opts.Mode == config.FileHeadersCheck,
+		templates: templates,
+		now:       time.Now,
+	}
+}
+
+// Checking reports whether the policy only reports missing headers instead
+// of adding them.
+func (p *Policy) Checking() bool {
+	return p != nil && p.check
+}
+
+// Header returns the rendered header for path, or an empty string if its
+// file type has no template.
+func (p *Policy) Header(path string) string {
+	tmpl := p.template(path)
+	if tmpl == "" {
+		return ""
+	}
+	header := strings.ReplaceAll(tmpl, YearPlaceholder, strconv.Itoa(p.now().Year()))
+	return strings.TrimRight(header, "\n") + "\n"
+}
+
+// Apply returns content with the header for path prepended, keeping a
+// leading shebang line first. Content that already has the header, or whose
+// file type has no template, is returned unchanged. It does nothing in check
+// mode.
+func (p *Policy) Apply(path, content string) string {
+	if p.Checking() || !p.Missing(path, content) {
+		return content
+	}
+	header := p.Header(path)
+
+	var shebang string
+	if strings.HasPrefix(content, "#!") {
+		if i := strings.IndexByte(content, '\n'); i >= 0 {
+			shebang, content = content[:i+1], content[i+1:]
+		} else {
+			shebang, content = content+"\n", ""
+		}
+	}
+	if strings.TrimSpace(content) == "" {
+		return shebang + header
+	}
+	return shebang + header + "\n" + strings.TrimLeft(content, "\n")
+}
+
+// Missing reports whether path requires a header that content lacks.
+func (p *Policy) Missing(path, content string) bool {
+	tmpl := p.template(path)
+	if tmpl == "" {
+		return false
+	}
+	return !matcher(tmpl).MatchString(strings.ReplaceAll(content, "\r\n", "\n"))
+}
+
+func (p *Policy) template(path string) string {
+	if p == nil {
+		return ""
+	}
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	return p.templates[ext]
+}
+
+// matcher builds a regexp finding the template near the top of a file,
+// ignoring the year and surrounding whitespace.
+func matcher(tmpl string) *regexp.Regexp {
+	parts := strings.Split(strings.TrimSpace(tmpl), YearPlaceholder)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile(`\A(?:#![^\n]*\n)?\s*` + strings.Join(parts, yearPattern))
+}
@@ -0,0 +1,71 @@
+package fileheader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func testPolicy(mode config.FileHeadersMode) *Policy {
+	p := New(&config.Config{Options: &config.Options{
+		FileHeaders: &config.FileHeaders{
+			Mode: mode,
+			Templates: map[string]string{
+				".go": "// Copyright {year} Acme Inc.\n// SPDX-License-Identifier: MIT",
+				"sh":  "# Copyright {year} Acme Inc.",
+				"md":  "  ",
+			},
+		},
+	}})
+	p.now = func() time.Time { return time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC) }
+	return p
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, New(&config.Config{Options: &config.Options{}}))
+	require.Nil(t, New(&config.Config{Options: &config.Options{FileHeaders: &config.FileHeaders{}}}))
+
+	p := testPolicy("")
+	require.False(t, p.Checking())
+	require.Len(t, p.templates, 2)
+	require.True(t, testPolicy(config.FileHeadersCheck).Checking())
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	p := testPolicy(config.FileHeadersApply)
+	header := "// Copyright 2026 Acme Inc.\n// SPDX-License-Identifier: MIT\n"
+	require.Equal(t, header, p.Header("main.go"))
+
+	require.Equal(t, header+"\npackage main\n", p.Apply("main.go", "package main\n"))
+	require.Equal(t, header, p.Apply("main.go", ""))
+	require.Equal(t, "#!/bin/sh\n# Copyright 2026 Acme Inc.\n\necho hi\n", p.Apply("run.sh", "#!/bin/sh\necho hi\n"))
+	require.Equal(t, "notes\n", p.Apply("README.md", "notes\n"))
+	require.Equal(t, "x = 1\n", p.Apply("a.py", "x = 1\n"))
+
+	existing := "// Copyright 2019-2024 Acme Inc.\n// SPDX-License-Identifier: MIT\n\npackage main\n"
+	require.Equal(t, existing, p.Apply("main.go", existing))
+	require.Equal(t, "#!/bin/sh\n# Copyright 2020 Acme Inc.\n", p.Apply("run.sh", "#!/bin/sh\n# Copyright 2020 Acme Inc.\n"))
+
+	checking := testPolicy(config.FileHeadersCheck)
+	require.Equal(t, "package main\n", checking.Apply("main.go", "package main\n"))
+
+	var nilPolicy *Policy
+	require.Equal(t, "package main\n", nilPolicy.Apply("main.go", "package main\n"))
+	require.False(t, nilPolicy.Missing("main.go", "package main\n"))
+}
+
+func TestMissing(t *testing.T) {
+	t.Parallel()
+
+	p := testPolicy(config.FileHeadersCheck)
+	require.True(t, p.Missing("main.go", "package main\n"))
+	require.True(t, p.Missing("main.go", "package main\n\n// Copyright 2026 Acme Inc.\n// SPDX-License-Identifier: MIT\n"))
+	require.False(t, p.Missing("main.go", "// Copyright 2026 Acme Inc.\r\n// SPDX-License-Identifier: MIT\r\npackage main\r\n"))
+	require.False(t, p.Missing("main.txt", "anything"))
+}
@@ -23,15 +23,27 @@ type Service interface {
 
 	// ListReadFiles returns the paths of all files read in a session.
 	ListReadFiles(ctx context.Context, sessionID string) ([]string, error)
+
+	// Changed reports whether a file changed outside of the session since
+	// the session last read it, such as by the user in their editor.
+	Changed(ctx context.Context, sessionID, path string) bool
+
+	// ChangedFiles returns the absolute paths of the files read in a
+	// session that changed outside of it since, until it reads them again.
+	ChangedFiles(ctx context.Context, sessionID string) []string
+
+	// Close stops watching the files read.
+	Close() error
 }
 
 type service struct {
-	q *db.Queries
+	q     *db.Queries
+	watch *watcher
 }
 
 // NewService creates a new file tracker service.
 func NewService(q *db.Queries) Service {
-	return &service{q: q}
+	return &service{q: q, watch: newWatcher()}
 }
 
 // RecordRead records when a file was read.
@@ -42,6 +54,7 @@ func (s *service) RecordRead(ctx context.Context, sessionID, path string) {
 	}); err != nil {
 		slog.Error("Error recording file read", "error", err, "file", path)
 	}
+	s.watch.record(sessionID, path)
 }
 
 // LastReadTime returns when a file was last read.
@@ -91,3 +104,21 @@ func (s *service) ListReadFiles(ctx context.Context, sessionID string) ([]string
 	}
 	return paths, nil
 }
+
+// Changed reports whether a file changed outside of the session since the
+// session last read it. Only the reads recorded since crush started are
+// watched.
+func (s *service) Changed(_ context.Context, sessionID, path string) bool {
+	return s.watch.changed(sessionID, path)
+}
+
+// ChangedFiles returns the absolute paths of the files read in a session
+// that changed outside of it since.
+func (s *service) ChangedFiles(_ context.Context, sessionID string) []string {
+	return s.watch.changedFiles(sessionID)
+}
+
+// Close stops watching the files read.
+func (s *service) Close() error {
+	return s.watch.close()
+}
@@ -2,6 +2,8 @@ package filetracker
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"testing/synctest"
 	"time"
@@ -114,3 +116,39 @@ func TestService_RecordRead_DifferentPaths(t *testing.T) {
 	lastRead2 := env.svc.LastReadTime(env.ctx, sessionID, path2)
 	require.True(t, lastRead2.IsZero(), "path2 should not be recorded")
 }
+
+func TestService_ChangedFiles(t *testing.T) {
+	env := setupTest(t)
+	t.Cleanup(func() { env.svc.Close() })
+
+	sessionID := "test-session-4"
+	env.createSession(t, sessionID)
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+
+	env.svc.RecordRead(env.ctx, sessionID, path)
+	require.Empty(t, env.svc.ChangedFiles(env.ctx, sessionID))
+
+	// A write of the session itself is recorded as a read right after.
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0o644))
+	env.svc.RecordRead(env.ctx, sessionID, path)
+	require.False(t, env.svc.Changed(env.ctx, sessionID, path))
+
+	require.NoError(t, os.WriteFile(path, []byte("package app\n"), 0o644))
+	require.True(t, env.svc.Changed(env.ctx, sessionID, path))
+	require.Equal(t, []string{path}, env.svc.ChangedFiles(env.ctx, sessionID))
+	require.Empty(t, env.svc.ChangedFiles(env.ctx, "other-session"))
+
+	env.svc.RecordRead(env.ctx, sessionID, path)
+	require.Empty(t, env.svc.ChangedFiles(env.ctx, sessionID))
+
+	t.Run("same size and modification time", func(t *testing.T) {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, []byte("package ppa\n"), 0o644))
+		require.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+		require.Eventually(t, func() bool {
+			return env.svc.Changed(env.ctx, sessionID, path)
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,208 @@
+package filetracker
+
+import (
+	"crypto/sha256"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// maxHashSize is the size up to which the content of read files is hashed,
+// to catch changes that keep their size and modification time.
+const maxHashSize = 8 << 20 // 8MB
+
+// snapshot is what a file was like when a session last read it.
+type snapshot struct {
+	size    int64
+	modTime time.Time
+	sum     [sha256.Size]byte
+	hashed  bool
+	// changed is set once the file is found to differ from the snapshot.
+	changed bool
+}
+
+// watcher keeps the files sessions read and watches them for changes made
+// outside of them, such as by the user in an editor. Files are compared
+// with what they were like when last read, so the writes of the session
+// itself, which record a read right after, do not count.
+type watcher struct {
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+	started bool
+	// reads holds the snapshots of the files read, by path then session.
+	reads map[string]map[string]*snapshot
+	// dirty holds the files the watcher reported changes to since they were
+	// last compared to their snapshots.
+	dirty map[string]bool
+	dirs  map[string]bool
+}
+
+func newWatcher() *watcher {
+	return &watcher{
+		reads: make(map[string]map[string]*snapshot),
+		dirty: make(map[string]bool),
+		dirs:  make(map[string]bool),
+	}
+}
+
+// record takes a snapshot of the file at path as read by the session.
+func (w *watcher) record(sessionID, path string) {
+	path = absPath(path)
+	snap, ok := takeSnapshot(path)
+	if !ok {
+		return
+	}
+	w.mu.Lock()
+	sessions, ok := w.reads[path]
+	if !ok {
+		sessions = make(map[string]*snapshot)
+		w.reads[path] = sessions
+	}
+	sessions[sessionID] = snap
+	dir := filepath.Dir(path)
+	watch := !w.dirs[dir]
+	w.dirs[dir] = true
+	fsw := w.start()
+	w.mu.Unlock()
+
+	// The watcher is only touched outside the lock, since it may be
+	// waiting to deliver an event that needs it.
+	if watch && fsw != nil {
+		if err := fsw.Add(dir); err != nil {
+			slog.Debug("Failed to watch directory", "dir", dir, "error", err)
+		}
+	}
+}
+
+// start starts the file system watcher the first time a file is read,
+// returning it, or nil when it cannot be started. w.mu must be held.
+func (w *watcher) start() *fsnotify.Watcher {
+	if w.started {
+		return w.watcher
+	}
+	w.started = true
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		slog.Warn("Failed to watch read files", "error", err)
+		return nil
+	}
+	w.watcher = fsw
+	go w.watch(fsw)
+	return fsw
+}
+
+func (w *watcher) watch(fsw *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			w.mu.Lock()
+			if _, ok := w.reads[event.Name]; ok {
+				w.dirty[event.Name] = true
+			}
+			w.mu.Unlock()
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return
+			}
+			slog.Debug("File watcher error", "error", err)
+		}
+	}
+}
+
+func (w *watcher) close() error {
+	w.mu.Lock()
+	fsw := w.watcher
+	w.watcher = nil
+	w.mu.Unlock()
+	if fsw == nil {
+		return nil
+	}
+	return fsw.Close()
+}
+
+// changed reports whether the file at path changed since the session last
+// read it.
+func (w *watcher) changed(sessionID, path string) bool {
+	path = absPath(path)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.compare(sessionID, path)
+}
+
+// changedFiles returns the files the session read that changed since,
+// sorted.
+func (w *watcher) changedFiles(sessionID string) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var paths []string
+	for path := range w.reads {
+		if w.compare(sessionID, path) {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// compare compares the file at path with the snapshots sessions took of
+// it, when the watcher reported a change or its size or modification time
+// differ from the snapshot of the session. w.mu must be held.
+func (w *watcher) compare(sessionID, path string) bool {
+	snap, ok := w.reads[path][sessionID]
+	if !ok {
+		return false
+	}
+	if snap.changed {
+		return true
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != snap.size || !info.ModTime().Equal(snap.modTime) {
+		// Deleted, replaced or written to.
+		snap.changed = true
+		return true
+	}
+	if !w.dirty[path] {
+		return false
+	}
+	current, ok := takeSnapshot(path)
+	for _, s := range w.reads[path] {
+		if !ok || s.hashed && current.hashed && s.sum != current.sum {
+			s.changed = true
+		}
+	}
+	delete(w.dirty, path)
+	return snap.changed
+}
+
+func takeSnapshot(path string) (*snapshot, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil, false
+	}
+	snap := &snapshot{size: info.Size(), modTime: info.ModTime()}
+	if info.Size() <= maxHashSize {
+		if data, err := os.ReadFile(path); err == nil {
+			snap.sum = sha256.Sum256(data)
+			snap.hashed = true
+		}
+	}
+	return snap, true
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
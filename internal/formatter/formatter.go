@@ -0,0 +1,184 @@
+// Package formatter runs configured code formatters on files the agent edits.
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/home"
+)
+
+// FilePlaceholder is replaced with the path of the file being formatted in
+// formatter arguments.
+const FilePlaceholder = "{file}"
+
+const defaultTimeout = 10 * time.Second
+
+// Runner formats files with the formatters configured for their extension.
+// A nil Runner is valid and formats nothing.
+type Runner struct {
+	workingDir string
+	resolver   config.VariableResolver
+	formatters []config.Formatter
+}
+
+// New creates a runner from the formatters in cfg. It returns nil when
+// formatting is disabled or no formatter is configured.
+func New(cfg *config.Config) *Runner {
+	if cfg.Options != nil && cfg.Options.DisableFormatters {
+		return nil
+	}
+	var formatters []config.Formatter
+	for _, f := range cfg.Formatters.Sorted() {
+		if f.Formatter.Disabled || f.Formatter.Command == "" {
+			continue
+		}
+		formatters = append(formatters, f)
+	}
+	if len(formatters) == 0 {
+		return nil
+	}
+	return &Runner{
+		workingDir: cfg.WorkingDir(),
+		resolver:   cfg.Resolver(),
+		formatters: formatters,
+	}
+}
+
+// Format runs every formatter that handles path, in name order, and reports
+// whether the file content changed.
+func (r *Runner) Format(ctx context.Context, path string) (bool, error) {
+	if r == nil {
+		return false, nil
+	}
+	matching := r.forFile(path)
+	if len(matching) == 0 {
+		return false, nil
+	}
+
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	for _, f := range matching {
+		if err := r.run(ctx, f, path); err != nil {
+			return false, fmt.Errorf("formatter %s: %w", f.Name, err)
+		}
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read file: %w", err)
+	}
+	return !bytes.Equal(before, after), nil
+}
+
+// FormatContent runs every formatter that handles path on content and
+// returns the result, leaving path untouched. The formatters run on a
+// temporary file next to path, so they find the same configuration, and
+// hidden, so build tools skip it.
+func (r *Runner) FormatContent(ctx context.Context, path string, content []byte) ([]byte, error) {
+	if r == nil || len(r.forFile(path)) == 0 {
+		return content, nil
+	}
+
+	dir := filepath.Dir(path)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		// The directory of a new file is only created once it is approved.
+		dir = ""
+	}
+	ext := filepath.Ext(path)
+	tmp, err := os.CreateTemp(dir, "."+strings.TrimSuffix(filepath.Base(path), ext)+".crush-*"+ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	if _, err := r.Format(ctx, tmp.Name()); err != nil {
+		return nil, err
+	}
+	formatted, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return formatted, nil
+}
+
+func (r *Runner) forFile(path string) []config.Formatter {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if ext == "" {
+		return nil
+	}
+	var matching []config.Formatter
+	for _, f := range r.formatters {
+		if slices.ContainsFunc(f.Formatter.FileTypes, func(ft string) bool {
+			return strings.EqualFold(strings.TrimPrefix(ft, "."), ext)
+		}) {
+			matching = append(matching, f)
+		}
+	}
+	return matching
+}
+
+func (r *Runner) run(ctx context.Context, f config.Formatter, path string) error {
+	command, err := r.resolver.ResolveValue(f.Formatter.Command)
+	if err != nil {
+		return fmt.Errorf("invalid formatter command: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if f.Formatter.Timeout > 0 {
+		timeout = time.Duration(f.Formatter.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, home.Long(command), expandArgs(f.Formatter.Args, path)...)
+	cmd.Dir = cmp.Or(r.workingDir, filepath.Dir(path))
+	cmd.Env = append(os.Environ(), f.Formatter.ResolvedEnv()...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		slog.Debug("Formatter failed", "formatter", f.Name, "file", path, "output", string(output))
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// expandArgs substitutes [FilePlaceholder] in args with path, appending path
+// when no argument references it.
+func expandArgs(args []string, path string) []string {
+	expanded := make([]string, 0, len(args)+1)
+	found := false
+	for _, arg := range args {
+		if strings.Contains(arg, FilePlaceholder) {
+			found = true
+			arg = strings.ReplaceAll(arg, FilePlaceholder, path)
+		}
+		expanded = append(expanded, arg)
+	}
+	if !found {
+		expanded = append(expanded, path)
+	}
+	return expanded
+}
@@ -0,0 +1,129 @@
+package formatter
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandArgs(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"-w", "main.go"}, expandArgs([]string{"-w"}, "main.go"))
+	require.Equal(t, []string{"--stdin-filepath=a.ts", "--write"}, expandArgs([]string{"--stdin-filepath={file}", "--write"}, "a.ts"))
+	require.Equal(t, []string{"a.py"}, expandArgs(nil, "a.py"))
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		Options: &config.Options{},
+		Formatters: config.Formatters{
+			"gofmt": {Command: "gofmt", Args: []string{"-w"}, FileTypes: []string{"go"}},
+			"off":   {Command: "black", FileTypes: []string{"py"}, Disabled: true},
+		},
+	}
+	r := New(cfg)
+	require.NotNil(t, r)
+	require.Len(t, r.formatters, 1)
+	require.Len(t, r.forFile("/tmp/main.go"), 1)
+	require.Empty(t, r.forFile("/tmp/main.py"))
+	require.Empty(t, r.forFile("/tmp/Makefile"))
+
+	cfg.Options.DisableFormatters = true
+	require.Nil(t, New(cfg))
+	require.Nil(t, New(&config.Config{Options: &config.Options{}}))
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	dir := t.TempDir()
+	r := &Runner{
+		workingDir: dir,
+		resolver:   config.NewEnvironmentVariableResolver(env.NewFromMap(nil)),
+		formatters: []config.Formatter{{
+			Name: "upper",
+			Formatter: config.FormatterConfig{
+				Command:   "sh",
+				Args:      []string{"-c", `tr a-z A-Z < "$0" > "$0.tmp" && mv "$0.tmp" "$0"`, "{file}"},
+				FileTypes: []string{".txt"},
+			},
+		}},
+	}
+
+	path := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0o644))
+	changed, err := r.Format(t.Context(), path)
+	require.NoError(t, err)
+	require.True(t, changed)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "HELLO\n", string(content))
+
+	changed, err = r.Format(t.Context(), path)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	other := filepath.Join(dir, "a.md")
+	require.NoError(t, os.WriteFile(other, []byte("hello\n"), 0o644))
+	changed, err = r.Format(t.Context(), other)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	var nilRunner *Runner
+	changed, err = nilRunner.Format(t.Context(), path)
+	require.NoError(t, err)
+	require.False(t, changed)
+}
+
+func TestFormatContent(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	dir := t.TempDir()
+	r := &Runner{
+		workingDir: dir,
+		resolver:   config.NewEnvironmentVariableResolver(env.NewFromMap(nil)),
+		formatters: []config.Formatter{{
+			Name: "upper",
+			Formatter: config.FormatterConfig{
+				Command:   "sh",
+				Args:      []string{"-c", `tr a-z A-Z < "$0" > "$0.tmp" && mv "$0.tmp" "$0"`, "{file}"},
+				FileTypes: []string{".txt"},
+			},
+		}},
+	}
+
+	path := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644))
+	formatted, err := r.FormatContent(t.Context(), path, []byte("hello\n"))
+	require.NoError(t, err)
+	require.Equal(t, "HELLO\n", string(formatted))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "old\n", string(content), "the target is left untouched")
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "the temporary file is removed")
+
+	formatted, err = r.FormatContent(t.Context(), filepath.Join(dir, "new", "b.txt"), []byte("new\n"))
+	require.NoError(t, err)
+	require.Equal(t, "NEW\n", string(formatted))
+	require.NoDirExists(t, filepath.Join(dir, "new"))
+
+	formatted, err = r.FormatContent(t.Context(), filepath.Join(dir, "a.md"), []byte("hello\n"))
+	require.NoError(t, err)
+	require.Equal(t, "hello\n", string(formatted))
+}
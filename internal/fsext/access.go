@@ -0,0 +1,132 @@
+package fsext
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// CrushIgnoreName is the name of the files listing paths crush keeps away
+// from the agent.
+const CrushIgnoreName = ".crushignore"
+
+// deniedPaths is the access policy set with [SetDeniedPaths].
+type deniedPaths struct {
+	root     string
+	patterns []gitignore.Pattern
+}
+
+var denied atomic.Pointer[deniedPaths]
+
+// SetDeniedPaths sets the project whose .crushignore files deny the agent
+// access to the paths they match, along with gitignore-style patterns,
+// relative to root, of more paths to deny, such as "secrets/**" or ".env*".
+func SetDeniedPaths(root string, patterns []string) {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	denied.Store(&deniedPaths{root: root, patterns: parsePatterns(patterns, nil)})
+}
+
+// Denied reports whether the agent is kept away from path, because it
+// matches a denied pattern of [SetDeniedPaths] or a .crushignore file of
+// its directory or of one above it in the project, or is inside a directory
+// that does. Paths outside of the project are never denied.
+func Denied(path string) bool {
+	d := denied.Load()
+	if d == nil {
+		return false
+	}
+	components, ok := d.components(path)
+	if !ok {
+		return false
+	}
+	patterns := append([]gitignore.Pattern(nil), d.patterns...)
+	dir := d.root
+	for i := range components {
+		patterns = append(patterns, crushIgnorePatterns(dir, components[:i])...)
+		dir = filepath.Join(dir, components[i])
+	}
+	return matchesAncestors(gitignore.NewMatcher(patterns), components, isDir(path))
+}
+
+// deniedByConfig reports whether path matches a denied pattern of
+// [SetDeniedPaths]; listings read the .crushignore files themselves.
+func deniedByConfig(path string, dir bool) bool {
+	d := denied.Load()
+	if d == nil || len(d.patterns) == 0 {
+		return false
+	}
+	components, ok := d.components(path)
+	if !ok {
+		return false
+	}
+	return matchesAncestors(gitignore.NewMatcher(d.patterns), components, dir)
+}
+
+// components splits path into its components relative to the root, or
+// reports false when it is the root or outside of it.
+func (d *deniedPaths) components(path string) ([]string, bool) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	rel, err := filepath.Rel(d.root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, false
+	}
+	return pathToComponents(rel), true
+}
+
+// matchesAncestors reports whether matcher matches the path of components
+// or one of the directories it is in.
+func matchesAncestors(matcher gitignore.Matcher, components []string, dir bool) bool {
+	for i := 1; i < len(components); i++ {
+		if matcher.Match(components[:i], true) {
+			return true
+		}
+	}
+	return matcher.Match(components, dir)
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// crushIgnoreFile is a parsed .crushignore file, kept until it changes.
+type crushIgnoreFile struct {
+	modTime  time.Time
+	size     int64
+	patterns []gitignore.Pattern
+}
+
+var crushIgnoreFiles sync.Map // map[string]crushIgnoreFile
+
+// crushIgnorePatterns returns the patterns of the .crushignore file of dir,
+// whose components relative to the root are domain.
+func crushIgnorePatterns(dir string, domain []string) []gitignore.Pattern {
+	path := filepath.Join(dir, CrushIgnoreName)
+	info, err := os.Stat(path)
+	if err != nil {
+		crushIgnoreFiles.Delete(path)
+		return nil
+	}
+	if cached, ok := crushIgnoreFiles.Load(path); ok {
+		f := cached.(crushIgnoreFile)
+		if f.modTime.Equal(info.ModTime()) && f.size == info.Size() {
+			return f.patterns
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	patterns := parsePatterns(strings.Split(string(content), "\n"), append([]string(nil), domain...))
+	crushIgnoreFiles.Store(path, crushIgnoreFile{modTime: info.ModTime(), size: info.Size(), patterns: patterns})
+	return patterns
+}
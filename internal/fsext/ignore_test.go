@@ -120,3 +120,32 @@ func TestShouldExcludeFileCommonPatterns(t *testing.T) {
 		require.True(t, ShouldExcludeFile(tempDir, dir), "Expected %s to be ignored by common patterns", filepath.Base(dir))
 	}
 }
+
+func TestDenied(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "secrets", "prod"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "src", "fixtures"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "src", CrushIgnoreName), []byte("fixtures/\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "src", "fixtures", "key.pem"), []byte("key"), 0o644))
+
+	SetDeniedPaths(root, []string{"secrets/**", ".env*"})
+	t.Cleanup(func() { denied.Store(nil) })
+
+	require.True(t, Denied(filepath.Join(root, ".env")))
+	require.True(t, Denied(filepath.Join(root, ".env.local")))
+	require.True(t, Denied(filepath.Join(root, "secrets", "prod", "db.key")))
+	require.True(t, Denied(filepath.Join(root, "src", "fixtures")))
+	require.True(t, Denied(filepath.Join(root, "src", "fixtures", "key.pem")))
+	require.False(t, Denied(filepath.Join(root, "src", "main.go")))
+	require.False(t, Denied(root))
+	require.False(t, Denied(filepath.Join(filepath.Dir(root), ".env")), "paths outside of the project are not denied")
+
+	// Listings leave out the denied paths.
+	paths, _, err := ListDirectory(root, nil, 0, 0)
+	require.NoError(t, err)
+	for _, path := range paths {
+		require.NotContains(t, path, "secrets")
+		require.NotContains(t, path, "fixtures")
+	}
+	require.Contains(t, paths, filepath.Join(root, "src")+string(filepath.Separator))
+}
@@ -216,6 +216,10 @@ func (dl *directoryLister) shouldIgnore(path string, ignorePatterns []string, is
 		return false
 	}
 
+	if deniedByConfig(path, isDir) {
+		return true
+	}
+
 	relPath, err := filepath.Rel(dl.rootPath, path)
 	if err != nil {
 		relPath = path
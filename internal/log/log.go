@@ -16,6 +16,7 @@ import (
 var (
 	initOnce    sync.Once
 	initialized atomic.Bool
+	redaction   atomic.Pointer[func(string) string]
 )
 
 func Setup(logFile string, debug bool) {
@@ -38,11 +39,17 @@ func Setup(logFile string, debug bool) {
 			AddSource: true,
 		})
 
-		slog.SetDefault(slog.New(logger))
+		slog.SetDefault(slog.New(&redactingHandler{Handler: logger}))
 		initialized.Store(true)
 	})
 }
 
+// SetRedaction installs fn to mask secrets in every message and attribute
+// written to the log from now on.
+func SetRedaction(fn func(string) string) {
+	redaction.Store(&fn)
+}
+
 func Initialized() bool {
 	return initialized.Load()
 }
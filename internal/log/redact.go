@@ -0,0 +1,62 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// redactingHandler masks secrets with the function installed by
+// [SetRedaction] before records reach the wrapped handler.
+type redactingHandler struct {
+	slog.Handler
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	fn := redaction.Load()
+	if fn == nil {
+		return h.Handler.Handle(ctx, r)
+	}
+	redacted := slog.NewRecord(r.Time, r.Level, (*fn)(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(*fn, a))
+		return true
+	})
+	return h.Handler.Handle(ctx, redacted)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if fn := redaction.Load(); fn != nil {
+		for i, a := range attrs {
+			attrs[i] = redactAttr(*fn, a)
+		}
+	}
+	return &redactingHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+func redactAttr(fn func(string) string, a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(fn(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, g := range group {
+			attrs[i] = redactAttr(fn, g)
+		}
+		a.Value = slog.GroupValue(attrs...)
+	case slog.KindAny:
+		// Errors, URLs and the like are only replaced by their string form
+		// when they actually contain a secret.
+		s := fmt.Sprint(v.Any())
+		if redacted := fn(s); redacted != s {
+			a.Value = slog.StringValue(redacted)
+		}
+	}
+	return a
+}
@@ -0,0 +1,31 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(&redactingHandler{Handler: slog.NewTextHandler(&buf, nil)})
+	fn := func(s string) string { return strings.ReplaceAll(s, "hunter2", "[REDACTED]") }
+	SetRedaction(fn)
+	t.Cleanup(func() { redaction.Store(nil) })
+
+	logger.With("password", "hunter2").Info("login hunter2",
+		"error", errors.New("bad password hunter2"),
+		"count", 2,
+		slog.Group("req", "body", "pw=hunter2"),
+	)
+	out := buf.String()
+	require.NotContains(t, out, "hunter2")
+	require.Contains(t, out, `msg="login [REDACTED]"`)
+	require.Contains(t, out, `error="bad password [REDACTED]"`)
+	require.Contains(t, out, "count=2")
+	require.Contains(t, out, `req.body="pw=[REDACTED]"`)
+}
@@ -1,10 +1,17 @@
 package message
 
 import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 )
 
+// MimePDF is the MIME type of PDF documents.
+const MimePDF = "application/pdf"
+
 type Attachment struct {
 	FilePath string
 	FileName string
@@ -14,6 +21,23 @@ type Attachment struct {
 
 func (a Attachment) IsText() bool  { return strings.HasPrefix(a.MimeType, "text/") }
 func (a Attachment) IsImage() bool { return strings.HasPrefix(a.MimeType, "image/") }
+func (a Attachment) IsPDF() bool   { return a.MimeType == MimePDF }
+
+// NewAttachmentFromFile reads the file at path into an attachment, detecting
+// its MIME type from the content.
+func NewAttachmentFromFile(path string) (Attachment, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	mimeBufferSize := min(512, len(content))
+	return Attachment{
+		FilePath: path,
+		FileName: filepath.Base(path),
+		MimeType: http.DetectContentType(content[:mimeBufferSize]),
+		Content:  content,
+	}, nil
+}
 
 // ContainsTextAttachment returns true if any of the attachments is a text attachment.
 func ContainsTextAttachment(attachments []Attachment) bool {
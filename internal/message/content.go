@@ -125,6 +125,16 @@ type Finish struct {
 
 func (Finish) isPart() {}
 
+// Translation is the text of a message in another language: the English the
+// model reads in place of a prompt written in Language, or a reply of the
+// model translated to Language for the user to read.
+type Translation struct {
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+func (Translation) isPart() {}
+
 type Message struct {
 	ID               string
 	Role             MessageRole
@@ -135,6 +145,9 @@ type Message struct {
 	CreatedAt        int64
 	UpdatedAt        int64
 	IsSummaryMessage bool
+	// ReasoningTokens is how many of the output tokens of the message the
+	// model spent reasoning, as reported by the provider.
+	ReasoningTokens int64
 }
 
 func (m *Message) Content() TextContent {
@@ -146,6 +159,27 @@ func (m *Message) Content() TextContent {
 	return TextContent{}
 }
 
+// Translation returns the translation of the message, if it has one.
+func (m *Message) Translation() (Translation, bool) {
+	for _, part := range m.Parts {
+		if t, ok := part.(Translation); ok {
+			return t, true
+		}
+	}
+	return Translation{}, false
+}
+
+// SetTranslation sets the translation of the message, replacing any it had.
+func (m *Message) SetTranslation(t Translation) {
+	for i, part := range m.Parts {
+		if _, ok := part.(Translation); ok {
+			m.Parts[i] = t
+			return
+		}
+	}
+	m.Parts = append(m.Parts, t)
+}
+
 func (m *Message) ReasoningContent() ReasoningContent {
 	for _, part := range m.Parts {
 		if c, ok := part.(ReasoningContent); ok {
@@ -466,6 +500,9 @@ func (m *Message) ToAIMessage() []fantasy.Message {
 	case User:
 		var parts []fantasy.MessagePart
 		text := strings.TrimSpace(m.Content().Text)
+		if t, ok := m.Translation(); ok {
+			text = strings.TrimSpace(t.Text)
+		}
 		var textAttachments []Attachment
 		for _, content := range m.BinaryContent() {
 			if !strings.HasPrefix(content.MIMEType, "text/") {
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/charmbracelet/crush/internal/db"
@@ -26,10 +27,19 @@ type Service interface {
 	Update(ctx context.Context, message Message) error
 	Get(ctx context.Context, id string) (Message, error)
 	List(ctx context.Context, sessionID string) ([]Message, error)
+	// ListPage returns, in order, up to limit messages of a session that came
+	// before the message beforeID, or its most recent ones if beforeID is
+	// empty.
+	ListPage(ctx context.Context, sessionID, beforeID string, limit int) ([]Message, error)
 	ListUserMessages(ctx context.Context, sessionID string) ([]Message, error)
 	ListAllUserMessages(ctx context.Context) ([]Message, error)
 	Delete(ctx context.Context, id string) error
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
+	// Truncate deletes a message and every later message of its session,
+	// returning the deleted messages in order.
+	Truncate(ctx context.Context, id string) ([]Message, error)
+	// Copy adds a copy of a message, parts included, to a session.
+	Copy(ctx context.Context, sessionID string, message Message) (Message, error)
 }
 
 type service struct {
@@ -111,6 +121,70 @@ func (s *service) DeleteSessionMessages(ctx context.Context, sessionID string) e
 	return nil
 }
 
+func (s *service) Truncate(ctx context.Context, id string) ([]Message, error) {
+	target, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.List(ctx, target.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(messages, func(m Message) bool { return m.ID == id })
+	if idx < 0 {
+		return nil, fmt.Errorf("message %s not found in session %s", id, target.SessionID)
+	}
+	deleted := messages[idx:]
+	// Delete the newest first, so the session never shows a reply without
+	// the message it answers.
+	for i := len(deleted) - 1; i >= 0; i-- {
+		if err := s.Delete(ctx, deleted[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return deleted, nil
+}
+
+func (s *service) Copy(ctx context.Context, sessionID string, message Message) (Message, error) {
+	partsJSON, err := marshalParts(message.Parts)
+	if err != nil {
+		return Message{}, err
+	}
+	isSummary := int64(0)
+	if message.IsSummaryMessage {
+		isSummary = 1
+	}
+	dbMessage, err := s.q.CreateMessage(ctx, db.CreateMessageParams{
+		ID:               uuid.New().String(),
+		SessionID:        sessionID,
+		Role:             string(message.Role),
+		Parts:            string(partsJSON),
+		Model:            sql.NullString{String: message.Model, Valid: true},
+		Provider:         sql.NullString{String: message.Provider, Valid: message.Provider != ""},
+		IsSummaryMessage: isSummary,
+	})
+	if err != nil {
+		return Message{}, err
+	}
+	if f := message.FinishPart(); f != nil {
+		err = s.q.UpdateMessage(ctx, db.UpdateMessageParams{
+			ID:              dbMessage.ID,
+			Parts:           dbMessage.Parts,
+			FinishedAt:      sql.NullInt64{Int64: f.Time, Valid: true},
+			ReasoningTokens: message.ReasoningTokens,
+		})
+		if err != nil {
+			return Message{}, err
+		}
+	}
+	created, err := s.fromDBItem(dbMessage)
+	if err != nil {
+		return Message{}, err
+	}
+	s.Publish(pubsub.CreatedEvent, created.Clone())
+	return created, nil
+}
+
 func (s *service) Update(ctx context.Context, message Message) error {
 	parts, err := marshalParts(message.Parts)
 	if err != nil {
@@ -122,9 +196,10 @@ func (s *service) Update(ctx context.Context, message Message) error {
 		finishedAt.Valid = true
 	}
 	err = s.q.UpdateMessage(ctx, db.UpdateMessageParams{
-		ID:         message.ID,
-		Parts:      string(parts),
-		FinishedAt: finishedAt,
+		ID:              message.ID,
+		Parts:           string(parts),
+		FinishedAt:      finishedAt,
+		ReasoningTokens: message.ReasoningTokens,
 	})
 	if err != nil {
 		return err
@@ -159,6 +234,38 @@ func (s *service) List(ctx context.Context, sessionID string) ([]Message, error)
 	return messages, nil
 }
 
+func (s *service) ListPage(ctx context.Context, sessionID, beforeID string, limit int) ([]Message, error) {
+	var (
+		dbMessages []db.Message
+		err        error
+	)
+	if beforeID == "" {
+		dbMessages, err = s.q.ListRecentMessagesBySession(ctx, db.ListRecentMessagesBySessionParams{
+			SessionID: sessionID,
+			Limit:     int64(limit),
+		})
+	} else {
+		dbMessages, err = s.q.ListMessagesBeforeBySession(ctx, db.ListMessagesBeforeBySessionParams{
+			SessionID: sessionID,
+			BeforeID:  beforeID,
+			Limit:     int64(limit),
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The page is queried newest first, to take the messages closest to
+	// beforeID.
+	messages := make([]Message, len(dbMessages))
+	for i, dbMessage := range dbMessages {
+		messages[len(messages)-1-i], err = s.fromDBItem(dbMessage)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
 func (s *service) ListUserMessages(ctx context.Context, sessionID string) ([]Message, error) {
 	dbMessages, err := s.q.ListUserMessagesBySession(ctx, sessionID)
 	if err != nil {
@@ -204,6 +311,7 @@ func (s *service) fromDBItem(item db.Message) (Message, error) {
 		CreatedAt:        item.CreatedAt,
 		UpdatedAt:        item.UpdatedAt,
 		IsSummaryMessage: item.IsSummaryMessage != 0,
+		ReasoningTokens:  item.ReasoningTokens,
 	}, nil
 }
 
@@ -217,6 +325,7 @@ const (
 	toolCallType   partType = "tool_call"
 	toolResultType partType = "tool_result"
 	finishType     partType = "finish"
+	translateType  partType = "translation"
 )
 
 type partWrapper struct {
@@ -245,6 +354,8 @@ func marshalParts(parts []ContentPart) ([]byte, error) {
 			typ = toolResultType
 		case Finish:
 			typ = finishType
+		case Translation:
+			typ = translateType
 		default:
 			return nil, fmt.Errorf("unknown part type: %T", part)
 		}
@@ -319,6 +430,12 @@ func unmarshalParts(data []byte) ([]ContentPart, error) {
 				return nil, err
 			}
 			parts = append(parts, part)
+		case translateType:
+			part := Translation{}
+			if err := json.Unmarshal(wrapper.Data, &part); err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
 		default:
 			return nil, fmt.Errorf("unknown part type: %s", wrapper.Type)
 		}
@@ -0,0 +1,57 @@
+package message
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPage(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	_, err = q.CreateSession(t.Context(), db.CreateSessionParams{ID: "session", Title: "Session"})
+	require.NoError(t, err)
+	svc := NewService(q)
+
+	// The messages are created within the same second, so only their
+	// insertion order tells them apart.
+	var ids []string
+	for i := range 5 {
+		msg, err := svc.Create(t.Context(), "session", CreateMessageParams{
+			Role:  User,
+			Parts: []ContentPart{TextContent{Text: fmt.Sprint(i)}},
+		})
+		require.NoError(t, err)
+		ids = append(ids, msg.ID)
+	}
+
+	pageIDs := func(msgs []Message) []string {
+		var ids []string
+		for _, msg := range msgs {
+			ids = append(ids, msg.ID)
+		}
+		return ids
+	}
+
+	recent, err := svc.ListPage(t.Context(), "session", "", 2)
+	require.NoError(t, err)
+	require.Equal(t, ids[3:], pageIDs(recent))
+
+	older, err := svc.ListPage(t.Context(), "session", recent[0].ID, 2)
+	require.NoError(t, err)
+	require.Equal(t, ids[1:3], pageIDs(older))
+
+	oldest, err := svc.ListPage(t.Context(), "session", older[0].ID, 2)
+	require.NoError(t, err)
+	require.Equal(t, ids[:1], pageIDs(oldest))
+
+	none, err := svc.ListPage(t.Context(), "session", oldest[0].ID, 2)
+	require.NoError(t, err)
+	require.Empty(t, none)
+}
@@ -0,0 +1,160 @@
+// Package notify sends desktop notifications through the terminal, with the
+// OSC 9 or OSC 777 escape sequences, and through a command of the user's.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/x/ansi"
+	"mvdan.cc/sh/v3/shell"
+)
+
+const (
+	DefaultMinDuration = 10 * time.Second
+
+	// commandTimeout is how long the notify command may run.
+	commandTimeout = 10 * time.Second
+)
+
+// Notifier sends the notifications configured in the TUI options.
+type Notifier struct {
+	command     string
+	minDuration time.Duration
+	quiet       *quietHours
+}
+
+// New returns the notifier configured in cfg, or nil when notifications are
+// not enabled. Quiet hours that fail to parse are ignored with a warning.
+func New(cfg *config.Config) *Notifier {
+	if cfg.Options == nil || cfg.Options.TUI == nil || !cfg.Options.TUI.Notifications.Enabled {
+		return nil
+	}
+	opts := cfg.Options.TUI.Notifications
+	n := &Notifier{
+		command:     opts.Command,
+		minDuration: time.Duration(opts.MinDuration) * time.Second,
+	}
+	if n.minDuration <= 0 {
+		n.minDuration = DefaultMinDuration
+	}
+	if opts.QuietHours != "" {
+		quiet, err := parseQuietHours(opts.QuietHours)
+		if err != nil {
+			slog.Warn("Ignoring the quiet hours of notifications", "error", err)
+		} else {
+			n.quiet = &quiet
+		}
+	}
+	return n
+}
+
+// LongTurn returns whether a turn that ran for d is long enough for its end
+// to be notified.
+func (n *Notifier) LongTurn(d time.Duration) bool {
+	return d >= n.minDuration
+}
+
+// Quiet returns whether t falls in the quiet hours.
+func (n *Notifier) Quiet(t time.Time) bool {
+	return n.quiet != nil && n.quiet.contains(t)
+}
+
+// Sequence returns the escape sequence that notifies title and body in the
+// terminal getenv describes: OSC 777 for rxvt, foot and VTE terminals, which
+// do not know OSC 9, and OSC 9 for the others. Inside tmux it is passed
+// through to the outer terminal.
+func Sequence(getenv func(string) string, title, body string) string {
+	var seq string
+	term := getenv("TERM")
+	if strings.Contains(term, "rxvt") || strings.HasPrefix(term, "foot") || getenv("VTE_VERSION") != "" {
+		// Fields of OSC 777 are separated by semicolons.
+		seq = "\x1b]777;notify;" + strings.ReplaceAll(clean(title), ";", ",") + ";" + clean(body) + "\x07"
+	} else {
+		seq = ansi.Notify(clean(title) + ": " + clean(body))
+	}
+	if getenv("TMUX") != "" {
+		seq = ansi.TmuxPassthrough(seq)
+	}
+	return seq
+}
+
+// clean puts s on a line, without the control characters that would end
+// the sequence.
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return ' '
+		case r < 0x20 || r == 0x7f:
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Run runs the notify command, if one is configured, with title and body in
+// CRUSH_NOTIFY_TITLE and CRUSH_NOTIFY_BODY.
+func (n *Notifier) Run(ctx context.Context, title, body string) error {
+	if n.command == "" {
+		return nil
+	}
+	fields, err := shell.Fields(n.command, nil)
+	if err != nil {
+		return fmt.Errorf("notify command: %w", err)
+	}
+	if len(fields) == 0 {
+		return errors.New("notify command: empty command")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, home.Long(fields[0]), fields[1:]...)
+	cmd.Env = append(os.Environ(), "CRUSH_NOTIFY_TITLE="+title, "CRUSH_NOTIFY_BODY="+body)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notify command: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// quietHours is a range of the day, in minutes since midnight, that may
+// wrap past midnight.
+type quietHours struct {
+	from, to int
+}
+
+// parseQuietHours parses a range like 22:00-07:00.
+func parseQuietHours(s string) (quietHours, error) {
+	fromStr, toStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return quietHours{}, fmt.Errorf("quiet hours %q: want a range like 22:00-07:00", s)
+	}
+	from, err := time.Parse("15:04", strings.TrimSpace(fromStr))
+	if err != nil {
+		return quietHours{}, fmt.Errorf("quiet hours %q: %w", s, err)
+	}
+	to, err := time.Parse("15:04", strings.TrimSpace(toStr))
+	if err != nil {
+		return quietHours{}, fmt.Errorf("quiet hours %q: %w", s, err)
+	}
+	return quietHours{
+		from: from.Hour()*60 + from.Minute(),
+		to:   to.Hour()*60 + to.Minute(),
+	}, nil
+}
+
+func (q quietHours) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if q.from <= q.to {
+		return minute >= q.from && minute < q.to
+	}
+	return minute >= q.from || minute < q.to
+}
@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, New(&config.Config{Options: &config.Options{}}))
+	require.Nil(t, New(&config.Config{Options: &config.Options{TUI: &config.TUIOptions{}}}))
+
+	n := New(&config.Config{Options: &config.Options{TUI: &config.TUIOptions{
+		Notifications: config.NotificationOptions{Enabled: true, QuietHours: "late"},
+	}}})
+	require.NotNil(t, n)
+	require.False(t, n.LongTurn(DefaultMinDuration-time.Second))
+	require.True(t, n.LongTurn(DefaultMinDuration))
+	require.Nil(t, n.quiet, "quiet hours that fail to parse are ignored")
+}
+
+func TestQuietHours(t *testing.T) {
+	t.Parallel()
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 14, hour, minute, 0, 0, time.Local)
+	}
+
+	night, err := parseQuietHours("22:00-07:00")
+	require.NoError(t, err)
+	require.True(t, night.contains(at(23, 30)))
+	require.True(t, night.contains(at(6, 59)))
+	require.False(t, night.contains(at(7, 0)))
+	require.False(t, night.contains(at(12, 0)))
+
+	lunch, err := parseQuietHours("12:00 - 13:30")
+	require.NoError(t, err)
+	require.True(t, lunch.contains(at(13, 0)))
+	require.False(t, lunch.contains(at(13, 30)))
+
+	_, err = parseQuietHours("22:00")
+	require.Error(t, err)
+	_, err = parseQuietHours("10pm-7am")
+	require.Error(t, err)
+}
+
+func TestSequence(t *testing.T) {
+	t.Parallel()
+
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	require.Equal(t, "\x1b]9;Crush: Turn finished\x07",
+		Sequence(env(map[string]string{"TERM": "xterm-ghostty"}), "Crush", "Turn\nfinished\x1b"))
+	require.Equal(t, "\x1b]777;notify;Crush, done;Turn finished\x07",
+		Sequence(env(map[string]string{"TERM": "foot"}), "Crush; done", "Turn finished"))
+	require.Equal(t, "\x1bPtmux;\x1b\x1b]9;Crush: Turn finished\x07\x1b\\",
+		Sequence(env(map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0"}), "Crush", "Turn finished"))
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the notify command is a shell script")
+	}
+
+	out := filepath.Join(t.TempDir(), "out")
+	script := filepath.Join(t.TempDir(), "notify.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"$CRUSH_NOTIFY_TITLE|$CRUSH_NOTIFY_BODY|$1\" > \"$2\"\n"), 0o700))
+
+	n := &Notifier{command: script + " arg " + out}
+	require.NoError(t, n.Run(t.Context(), "Crush", "Turn finished"))
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, "Crush|Turn finished|arg\n", string(data))
+
+	require.NoError(t, (&Notifier{}).Run(t.Context(), "Crush", "Turn finished"))
+	require.Error(t, (&Notifier{command: "false"}).Run(t.Context(), "Crush", "Turn finished"))
+}
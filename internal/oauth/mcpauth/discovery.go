@@ -0,0 +1,156 @@
+// Package mcpauth signs Crush in to MCP servers protected with OAuth, using
+// the authorization code flow with PKCE and a local callback, as described by
+// the authorization part of the MCP specification.
+package mcpauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Server is the authorization server of an MCP server.
+type Server struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	RegistrationEndpoint  string   `json:"registration_endpoint,omitempty"`
+	ScopesSupported       []string `json:"scopes_supported,omitempty"`
+}
+
+// resourceMetadata is the protected resource metadata of an MCP server
+// (RFC 9728).
+type resourceMetadata struct {
+	Resource             string   `json:"resource"`
+	AuthorizationServers []string `json:"authorization_servers"`
+	ScopesSupported      []string `json:"scopes_supported,omitempty"`
+}
+
+var resourceMetadataParam = regexp.MustCompile(`resource_metadata="([^"]+)"`)
+
+// Discover finds the authorization server of the MCP server at serverURL:
+// from the protected resource metadata of the server, then the metadata of
+// the authorization server itself. Servers publishing no metadata are
+// assumed to use the default endpoints at the root of their URL.
+func Discover(ctx context.Context, serverURL string) (*Server, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid mcp server url: %q", serverURL)
+	}
+
+	issuer := origin(u)
+	var scopes []string
+	if meta := findResourceMetadata(ctx, u); meta != nil {
+		if len(meta.AuthorizationServers) > 0 {
+			issuer = meta.AuthorizationServers[0]
+		}
+		scopes = meta.ScopesSupported
+	}
+
+	iu, err := url.Parse(issuer)
+	if err != nil || iu.Host == "" {
+		return nil, fmt.Errorf("invalid authorization server url: %q", issuer)
+	}
+	for _, metaURL := range serverMetadataURLs(iu) {
+		var srv Server
+		if err := getJSON(ctx, metaURL, &srv); err != nil {
+			continue
+		}
+		if srv.AuthorizationEndpoint == "" || srv.TokenEndpoint == "" {
+			continue
+		}
+		if len(srv.ScopesSupported) == 0 {
+			srv.ScopesSupported = scopes
+		}
+		return &srv, nil
+	}
+
+	base := origin(iu)
+	return &Server{
+		Issuer:                base,
+		AuthorizationEndpoint: base + "/authorize",
+		TokenEndpoint:         base + "/token",
+		RegistrationEndpoint:  base + "/register",
+		ScopesSupported:       scopes,
+	}, nil
+}
+
+// findResourceMetadata returns the protected resource metadata of the MCP
+// server at u, from the URL given by the WWW-Authenticate header of its
+// unauthorized response, or else from its well-known locations.
+func findResourceMetadata(ctx context.Context, u *url.URL) *resourceMetadata {
+	var candidates []string
+	if req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil); err == nil {
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if resp, err := httpClient.Do(req); err == nil {
+			resp.Body.Close()
+			for _, header := range resp.Header.Values("WWW-Authenticate") {
+				if m := resourceMetadataParam.FindStringSubmatch(header); m != nil {
+					candidates = append(candidates, m[1])
+				}
+			}
+		}
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	if path != "" {
+		candidates = append(candidates, origin(u)+"/.well-known/oauth-protected-resource"+path)
+	}
+	candidates = append(candidates, origin(u)+"/.well-known/oauth-protected-resource")
+
+	for _, candidate := range candidates {
+		var meta resourceMetadata
+		if err := getJSON(ctx, candidate, &meta); err == nil {
+			return &meta
+		}
+	}
+	return nil
+}
+
+// serverMetadataURLs returns where the metadata of the authorization server
+// issuer may be, in the order to try them.
+func serverMetadataURLs(issuer *url.URL) []string {
+	base := origin(issuer)
+	path := strings.TrimSuffix(issuer.Path, "/")
+	urls := []string{
+		base + "/.well-known/oauth-authorization-server" + path,
+		base + "/.well-known/openid-configuration" + path,
+	}
+	if path != "" {
+		urls = append(urls, base+path+"/.well-known/openid-configuration")
+	}
+	return urls
+}
+
+// origin returns the scheme and host of u.
+func origin(u *url.URL) string {
+	return u.Scheme + "://" + u.Host
+}
+
+func getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,328 @@
+package mcpauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/oauth"
+)
+
+// DefaultCallbackPort is the local port the browser is sent back to after
+// signing in. It is fixed so clients registered once keep working.
+const DefaultCallbackPort = 19876
+
+// ErrNoRegistration is returned by [Register] when the authorization server
+// does not support dynamic client registration.
+var ErrNoRegistration = errors.New("the authorization server does not support dynamic client registration")
+
+// Client is Crush as a client of an authorization server.
+type Client struct {
+	Server      *Server
+	ID          string
+	Secret      string
+	RedirectURI string
+	// Resource is the URL of the MCP server the tokens are for.
+	Resource string
+	Scopes   []string
+}
+
+// Register registers Crush with the authorization server as a public client
+// sent back to redirectURI, returning its client ID and secret, if any.
+func Register(ctx context.Context, srv *Server, redirectURI string) (id, secret string, err error) {
+	if srv.RegistrationEndpoint == "" {
+		return "", "", ErrNoRegistration
+	}
+	body, err := json.Marshal(map[string]any{
+		"client_name":                "Crush",
+		"redirect_uris":              []string{redirectURI},
+		"grant_types":                []string{"authorization_code", "refresh_token"},
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+	})
+	if err != nil {
+		return "", "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.RegistrationEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	var result struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := doJSON(req, &result); err != nil {
+		return "", "", fmt.Errorf("failed to register client: %w", err)
+	}
+	if result.ClientID == "" {
+		return "", "", errors.New("failed to register client: no client_id in response")
+	}
+	return result.ClientID, result.ClientSecret, nil
+}
+
+// LoginOptions configure [Login].
+type LoginOptions struct {
+	ServerURL string
+	// ClientID is the client registered ahead of time, if any. Crush
+	// registers itself when it is empty.
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// CallbackPort is DefaultCallbackPort when zero.
+	CallbackPort int
+}
+
+// Login signs in to the MCP server at opts.ServerURL. It finds the
+// authorization server, registers Crush with it unless a client ID is
+// given, calls open with the URL to sign in at and waits for the browser to
+// come back with the code to exchange for a token.
+func Login(ctx context.Context, opts LoginOptions, open func(authURL string)) (Credentials, error) {
+	srv, err := Discover(ctx, opts.ServerURL)
+	if err != nil {
+		return Credentials{}, err
+	}
+	port := opts.CallbackPort
+	if port == 0 {
+		port = DefaultCallbackPort
+	}
+	callback, err := Listen(port)
+	if err != nil {
+		return Credentials{}, err
+	}
+	defer callback.Close() //nolint:errcheck
+
+	client := &Client{
+		Server:      srv,
+		ID:          opts.ClientID,
+		Secret:      opts.ClientSecret,
+		RedirectURI: callback.RedirectURI(),
+		Resource:    opts.ServerURL,
+		Scopes:      opts.Scopes,
+	}
+	if client.ID == "" {
+		if client.ID, client.Secret, err = Register(ctx, srv, client.RedirectURI); err != nil {
+			return Credentials{}, err
+		}
+	}
+
+	state, verifier := NewVerifier(), NewVerifier()
+	open(client.AuthCodeURL(state, verifier))
+	code, err := callback.Wait(ctx, state)
+	if err != nil {
+		return Credentials{}, err
+	}
+	token, err := client.Exchange(ctx, code, verifier)
+	if err != nil {
+		return Credentials{}, err
+	}
+	return Credentials{
+		ClientID:     client.ID,
+		ClientSecret: client.Secret,
+		TokenURL:     srv.TokenEndpoint,
+		Token:        token,
+	}, nil
+}
+
+// NewVerifier returns a random PKCE code verifier, also fit for a state.
+func NewVerifier() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// AuthCodeURL returns the URL to sign in at, with the PKCE challenge of
+// verifier.
+func (c *Client) AuthCodeURL(state, verifier string) string {
+	challenge := sha256.Sum256([]byte(verifier))
+	v := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.ID},
+		"redirect_uri":          {c.RedirectURI},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	if c.Resource != "" {
+		v.Set("resource", c.Resource)
+	}
+	if len(c.Scopes) > 0 {
+		v.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	sep := "?"
+	if strings.Contains(c.Server.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return c.Server.AuthorizationEndpoint + sep + v.Encode()
+}
+
+// Exchange exchanges the code the callback received for a token.
+func (c *Client) Exchange(ctx context.Context, code, verifier string) (*oauth.Token, error) {
+	return c.token(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.RedirectURI},
+		"code_verifier": {verifier},
+	})
+}
+
+// Refresh exchanges a refresh token for a new token. The refresh token is
+// kept when the server does not rotate it.
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*oauth.Token, error) {
+	token, err := c.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+func (c *Client) token(ctx context.Context, form url.Values) (*oauth.Token, error) {
+	form.Set("client_id", c.ID)
+	if c.Secret != "" {
+		form.Set("client_secret", c.Secret)
+	}
+	if c.Resource != "" {
+		form.Set("resource", c.Resource)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Server.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token oauth.Token
+	if err := doJSON(req, &token); err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("failed to get token: no access_token in response")
+	}
+	if token.ExpiresIn > 0 {
+		token.SetExpiresAt()
+	}
+	return &token, nil
+}
+
+// doJSON sends req and decodes its JSON response into v, returning the
+// OAuth error of the response when it fails.
+func doJSON(req *http.Request, v any) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+			return fmt.Errorf("%s: %s", oauthErr.Error, oauthErr.Description)
+		}
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// Callback receives the browser sent back after signing in.
+type Callback struct {
+	listener net.Listener
+	server   *http.Server
+	results  chan callbackResult
+}
+
+type callbackResult struct {
+	code  string
+	state string
+	err   error
+}
+
+// Listen listens for the callback on port of the loopback interface, or on
+// a free port when port is zero.
+func Listen(port int) (*Callback, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the callback: %w", err)
+	}
+	c := &Callback{
+		listener: listener,
+		results:  make(chan callbackResult, 1),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", c.handle)
+	c.server = &http.Server{Handler: mux}
+	go c.server.Serve(listener) //nolint:errcheck
+	return c, nil
+}
+
+// RedirectURI returns the URL of the callback.
+func (c *Callback) RedirectURI() string {
+	return fmt.Sprintf("http://127.0.0.1:%d/callback", c.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func (c *Callback) handle(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	result := callbackResult{code: q.Get("code"), state: q.Get("state")}
+	if e := q.Get("error"); e != "" {
+		result.err = fmt.Errorf("authorization failed: %s", strings.TrimSpace(e+" "+q.Get("error_description")))
+	} else if result.code == "" {
+		result.err = errors.New("authorization failed: no code in callback")
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if result.err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, "<p>Signing in to the MCP server failed. You can close this window and check Crush.</p>")
+	} else {
+		fmt.Fprint(w, "<p>You're signed in. You can close this window and go back to Crush.</p>")
+	}
+	select {
+	case c.results <- result:
+	default:
+	}
+}
+
+// Wait waits for the callback and returns the code it received, checking
+// that it comes with state.
+func (c *Callback) Wait(ctx context.Context, state string) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case result := <-c.results:
+		if result.err != nil {
+			return "", result.err
+		}
+		if result.state != state {
+			return "", errors.New("authorization failed: state mismatch")
+		}
+		return result.code, nil
+	}
+}
+
+// Close stops listening for the callback.
+func (c *Callback) Close() error {
+	return c.server.Close()
+}
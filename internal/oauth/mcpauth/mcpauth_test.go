@@ -0,0 +1,164 @@
+package mcpauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/oauth"
+	"github.com/stretchr/testify/require"
+)
+
+// newAuthServer returns an MCP server at /mcp with its authorization server,
+// issuing the token "access" for the code "code".
+func newAuthServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var challenge string
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer resource_metadata="`+srv.URL+`/meta"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	mux.HandleFunc("/meta", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"resource":              srv.URL + "/mcp",
+			"authorization_servers": []string{srv.URL + "/auth"},
+		})
+	})
+	mux.HandleFunc("/.well-known/oauth-authorization-server/auth", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Server{
+			Issuer:                srv.URL + "/auth",
+			AuthorizationEndpoint: srv.URL + "/auth/authorize",
+			TokenEndpoint:         srv.URL + "/auth/token",
+			RegistrationEndpoint:  srv.URL + "/auth/register",
+		})
+	})
+	mux.HandleFunc("/auth/register", func(w http.ResponseWriter, r *http.Request) {
+		var meta struct {
+			RedirectURIs []string `json:"redirect_uris"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&meta))
+		require.Len(t, meta.RedirectURIs, 1)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]string{"client_id": "crush-client"})
+	})
+	mux.HandleFunc("/auth/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		challenge = q.Get("code_challenge")
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code=code&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+	})
+	mux.HandleFunc("/auth/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "crush-client", r.Form.Get("client_id"))
+		require.Equal(t, srv.URL+"/mcp", r.Form.Get("resource"))
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if r.Form.Get("code") != "code" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "access", "refresh_token": "refresh", "expires_in": 3600})
+		case "refresh_token":
+			require.Equal(t, "refresh", r.Form.Get("refresh_token"))
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "refreshed", "expires_in": 3600})
+		}
+	})
+	return srv
+}
+
+func TestDiscover(t *testing.T) {
+	t.Parallel()
+	srv := newAuthServer(t)
+
+	got, err := Discover(t.Context(), srv.URL+"/mcp")
+	require.NoError(t, err)
+	require.Equal(t, srv.URL+"/auth/token", got.TokenEndpoint)
+	require.Equal(t, srv.URL+"/auth/register", got.RegistrationEndpoint)
+}
+
+func TestDiscoverDefaults(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	got, err := Discover(t.Context(), srv.URL+"/mcp")
+	require.NoError(t, err)
+	require.Equal(t, srv.URL+"/authorize", got.AuthorizationEndpoint)
+	require.Equal(t, srv.URL+"/token", got.TokenEndpoint)
+}
+
+func TestLogin(t *testing.T) {
+	t.Parallel()
+	srv := newAuthServer(t)
+
+	open := func(authURL string) {
+		go func() {
+			resp, err := http.Get(authURL)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+	}
+	creds, err := Login(t.Context(), LoginOptions{ServerURL: srv.URL + "/mcp", CallbackPort: freePort(t)}, open)
+	require.NoError(t, err)
+	require.Equal(t, "crush-client", creds.ClientID)
+	require.Equal(t, srv.URL+"/auth/token", creds.TokenURL)
+	require.Equal(t, "access", creds.Token.AccessToken)
+	require.False(t, creds.Token.IsExpired())
+
+	token, err := creds.Client(srv.URL+"/mcp").Refresh(t.Context(), creds.Token.RefreshToken)
+	require.NoError(t, err)
+	require.Equal(t, "refreshed", token.AccessToken)
+	require.Equal(t, "refresh", token.RefreshToken)
+}
+
+func TestCallbackStateMismatch(t *testing.T) {
+	t.Parallel()
+	callback, err := Listen(0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = callback.Close() })
+
+	resp, err := http.Get(callback.RedirectURI() + "?code=code&state=other")
+	require.NoError(t, err)
+	resp.Body.Close()
+	_, err = callback.Wait(t.Context(), "state")
+	require.ErrorContains(t, err, "state mismatch")
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+	store := NewStore(filepath.Join(t.TempDir(), "credentials.json"))
+
+	_, ok := store.Get("https://example.com/mcp")
+	require.False(t, ok)
+
+	creds := Credentials{ClientID: "id", TokenURL: "https://example.com/token", Token: &oauth.Token{AccessToken: "access"}}
+	require.NoError(t, store.Set("https://example.com/mcp", creds))
+	got, ok := store.Get("https://example.com/mcp")
+	require.True(t, ok)
+	require.Equal(t, creds, got)
+
+	require.NoError(t, store.Delete("https://example.com/mcp"))
+	_, ok = store.Get("https://example.com/mcp")
+	require.False(t, ok)
+}
+
+// freePort returns a local port free to listen on.
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
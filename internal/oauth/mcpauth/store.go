@@ -0,0 +1,107 @@
+package mcpauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/oauth"
+)
+
+// Credentials are what Crush keeps of signing in to an MCP server.
+type Credentials struct {
+	ClientID     string       `json:"client_id"`
+	ClientSecret string       `json:"client_secret,omitempty"`
+	TokenURL     string       `json:"token_url"`
+	Token        *oauth.Token `json:"token"`
+}
+
+// Client returns the client the credentials were issued to, enough to
+// refresh their token for the MCP server at resource.
+func (c Credentials) Client(resource string) *Client {
+	return &Client{
+		Server:   &Server{TokenEndpoint: c.TokenURL},
+		ID:       c.ClientID,
+		Secret:   c.ClientSecret,
+		Resource: resource,
+	}
+}
+
+// Store keeps the credentials of MCP servers in a file, by server URL, so
+// they are shared by all projects.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore returns the store kept at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Get returns the credentials of the MCP server at serverURL.
+func (s *Store) Get(serverURL string) (Credentials, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.load()
+	if err != nil {
+		return Credentials{}, false
+	}
+	c, ok := all[serverURL]
+	return c, ok && c.Token != nil
+}
+
+// Set saves the credentials of the MCP server at serverURL.
+func (s *Store) Set(serverURL string, c Credentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	all[serverURL] = c
+	return s.save(all)
+}
+
+// Delete forgets the credentials of the MCP server at serverURL.
+func (s *Store) Delete(serverURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	delete(all, serverURL)
+	return s.save(all)
+}
+
+func (s *Store) load() (map[string]Credentials, error) {
+	all := make(map[string]Credentials)
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mcp credentials: %w", err)
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("failed to parse mcp credentials: %w", err)
+	}
+	return all, nil
+}
+
+func (s *Store) save(all map[string]Credentials) error {
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create mcp credentials directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write mcp credentials: %w", err)
+	}
+	return nil
+}
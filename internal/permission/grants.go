@@ -0,0 +1,111 @@
+package permission
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// GrantsFilename is the file in the data directory the project grants are
+// kept in.
+const GrantsFilename = "permissions.json"
+
+// Grant always allows a request in the project, across sessions.
+type Grant struct {
+	ToolName string `json:"tool_name"`
+	Action   string `json:"action"`
+	// Subject is the command, target or directory of the request.
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (g Grant) String() string {
+	return fmt.Sprintf("%s %s: %s", g.ToolName, g.Action, g.Subject)
+}
+
+func (g Grant) same(other Grant) bool {
+	return g.ToolName == other.ToolName && g.Action == other.Action && g.Subject == other.Subject
+}
+
+// grantStore keeps the project grants in a JSON file. Without a path they
+// only last as long as the process.
+type grantStore struct {
+	path   string
+	mu     sync.RWMutex
+	grants []Grant
+}
+
+func loadGrants(path string) *grantStore {
+	store := &grantStore{path: path}
+	if path == "" {
+		return store
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &store.grants)
+	}
+	if err != nil {
+		slog.Warn("Failed to load project permissions", "path", path, "error", err)
+	}
+	return store
+}
+
+func (s *grantStore) list() []Grant {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.grants)
+}
+
+func (s *grantStore) allows(want Grant) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.ContainsFunc(s.grants, want.same)
+}
+
+func (s *grantStore) add(grant Grant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slices.ContainsFunc(s.grants, grant.same) {
+		return nil
+	}
+	s.grants = append(s.grants, grant)
+	return s.save()
+}
+
+func (s *grantStore) revoke(grant Grant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.grants)
+	s.grants = slices.DeleteFunc(s.grants, grant.same)
+	if len(s.grants) == n {
+		return fmt.Errorf("no project permission for %s", grant)
+	}
+	return s.save()
+}
+
+func (s *grantStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	grants := s.grants
+	if grants == nil {
+		grants = []Grant{}
+	}
+	data, err := json.MarshalIndent(grants, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0o600)
+}
@@ -0,0 +1,97 @@
+package permission
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionService_ProjectGrants(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".crush", GrantsFilename)
+	service := NewPermissionService(dir, false, nil, nil, path)
+	events := service.Subscribe(t.Context())
+
+	req := CreatePermissionRequest{
+		SessionID: "first",
+		ToolName:  "bash",
+		Action:    "execute",
+		Path:      dir,
+		Command:   "go test ./...",
+	}
+	var granted bool
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		granted, _ = service.Request(t.Context(), req)
+	})
+	event := <-events
+	require.Equal(t, "go test ./...", event.Payload.Command)
+	service.GrantForProject(event.Payload)
+	wg.Wait()
+	require.True(t, granted)
+	require.FileExists(t, path)
+
+	// A new service, as in a later run, loads the grant.
+	later := NewPermissionService(dir, false, nil, nil, path)
+	req.SessionID = "second"
+	decision := later.Explain(req)
+	require.Equal(t, EffectAllow, decision.Effect)
+	require.Equal(t, "bash execute: go test ./...", decision.Rule)
+
+	other := req
+	other.Command = "go test -race ./..."
+	require.Equal(t, EffectAsk, later.Explain(other).Effect, "only identical requests are allowed")
+
+	risky := req
+	risky.Risk = &shell.Verdict{Severity: shell.SeverityHigh}
+	require.Equal(t, EffectAsk, later.Explain(risky).Effect, "high risk commands are still asked for")
+
+	grants := later.ProjectGrants()
+	require.Len(t, grants, 1)
+	require.NoError(t, later.RevokeProjectGrant(grants[0]))
+	require.Empty(t, later.ProjectGrants())
+	require.Error(t, later.RevokeProjectGrant(grants[0]))
+	require.Equal(t, EffectAsk, NewPermissionService(dir, false, nil, nil, path).Explain(req).Effect)
+}
+
+func TestPermissionService_ProjectGrantsRefuseSudo(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".crush", GrantsFilename)
+	service := NewPermissionService(dir, false, nil, nil, path)
+	events := service.Subscribe(t.Context())
+
+	var granted bool
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		granted, _ = service.Request(t.Context(), CreatePermissionRequest{
+			SessionID: "session",
+			ToolName:  "edit",
+			Action:    SudoWriteAction,
+			Path:      dir,
+			Target:    filepath.Join(dir, "hosts"),
+			Confirm:   true,
+		})
+	})
+	service.GrantForProject((<-events).Payload)
+	wg.Wait()
+	require.False(t, granted)
+	require.NoFileExists(t, path)
+	require.Empty(t, service.ProjectGrants())
+}
+
+func TestPermissionService_ProjectGrantsCorruptFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), GrantsFilename)
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+	service := NewPermissionService(t.TempDir(), false, nil, nil, path)
+	require.Empty(t, service.ProjectGrants())
+}
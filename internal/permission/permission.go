@@ -3,18 +3,26 @@ package permission
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"slices"
 	"sync"
+	"time"
 
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/shell"
 	"github.com/google/uuid"
 )
 
 var ErrorPermissionDenied = errors.New("user denied permission")
 
+// SudoWriteAction is the action of the requests to write a file as root
+// with sudo, which are only ever allowed once.
+const SudoWriteAction = "sudo_write"
+
 type CreatePermissionRequest struct {
 	SessionID   string `json:"session_id"`
 	ToolCallID  string `json:"tool_call_id"`
@@ -23,6 +31,16 @@ type CreatePermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	// Command is the shell command the call runs, and Target the file or URL
+	// it acts on. Policy rules match them, or Path when both are empty.
+	Command string `json:"command,omitempty"`
+	Target  string `json:"target,omitempty"`
+	// Confirm always asks the user, ignoring the allowlist and earlier
+	// grants. Skip mode still applies.
+	Confirm bool `json:"confirm,omitempty"`
+	// Risk is how the command safety classifier judged Command. High risk
+	// commands are asked for even when allowed before.
+	Risk *shell.Verdict `json:"risk,omitempty"`
 }
 
 type PermissionNotification struct {
@@ -40,6 +58,10 @@ type PermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	Command     string `json:"command,omitempty"`
+	Target      string `json:"target,omitempty"`
+	// Risk is the verdict of the command safety classifier, if any.
+	Risk *shell.Verdict `json:"risk,omitempty"`
 }
 
 type Service interface {
@@ -51,6 +73,14 @@ type Service interface {
 	AutoApproveSession(sessionID string)
 	SetSkipRequests(skip bool)
 	SkipRequests() bool
+	// Explain returns how a request would be decided, and why, without
+	// asking the user.
+	Explain(opts CreatePermissionRequest) Decision
+	// GrantForProject allows the request and identical ones in later
+	// sessions of the project.
+	GrantForProject(permission PermissionRequest)
+	ProjectGrants() []Grant
+	RevokeProjectGrant(grant Grant) error
 	SubscribeNotifications(ctx context.Context) <-chan pubsub.Event[PermissionNotification]
 }
 
@@ -66,6 +96,8 @@ type permissionService struct {
 	autoApproveSessionsMu sync.RWMutex
 	skip                  bool
 	allowedTools          []string
+	policy                Policy
+	grants                *grantStore
 
 	// used to make sure we only process one request at a time
 	requestMu       sync.Mutex
@@ -94,6 +126,32 @@ func (s *permissionService) GrantPersistent(permission PermissionRequest) {
 	s.activeRequestMu.Unlock()
 }
 
+func (s *permissionService) GrantForProject(permission PermissionRequest) {
+	if permission.Action == SudoWriteAction {
+		slog.Warn("Refusing to save a project permission to write files as root", "path", permission.Path)
+		s.Deny(permission)
+		return
+	}
+	grant := Grant{
+		ToolName:  permission.ToolName,
+		Action:    permission.Action,
+		Subject:   requestSubject(permission.Command, permission.Target, permission.Path),
+		CreatedAt: time.Now(),
+	}
+	if err := s.grants.add(grant); err != nil {
+		slog.Error("Failed to save project permission", "grant", grant.String(), "error", err)
+	}
+	s.Grant(permission)
+}
+
+func (s *permissionService) ProjectGrants() []Grant {
+	return s.grants.list()
+}
+
+func (s *permissionService) RevokeProjectGrant(grant Grant) error {
+	return s.grants.revoke(grant)
+}
+
 func (s *permissionService) Grant(permission PermissionRequest) {
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 		ToolCallID: permission.ToolCallID,
@@ -131,7 +189,9 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 
 func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRequest) (bool, error) {
 	if s.skip {
-		return true, nil
+		if decision, ok := s.policy.Evaluate(opts, s.workingDir); !ok || decision.Effect != EffectDeny {
+			return true, nil
+		}
 	}
 
 	// tell the UI that a permission was requested
@@ -141,17 +201,14 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 	s.requestMu.Lock()
 	defer s.requestMu.Unlock()
 
-	// Check if the tool/action combination is in the allowlist
-	commandKey := opts.ToolName + ":" + opts.Action
-	if slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName) {
-		return true, nil
-	}
-
-	s.autoApproveSessionsMu.RLock()
-	autoApprove := s.autoApproveSessions[opts.SessionID]
-	s.autoApproveSessionsMu.RUnlock()
-
-	if autoApprove {
+	switch s.Explain(opts).Effect {
+	case EffectDeny:
+		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+			ToolCallID: opts.ToolCallID,
+			Denied:     true,
+		})
+		return false, nil
+	case EffectAllow:
 		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 			ToolCallID: opts.ToolCallID,
 			Granted:    true,
@@ -159,43 +216,20 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 		return true, nil
 	}
 
-	fileInfo, err := os.Stat(opts.Path)
-	dir := opts.Path
-	if err == nil {
-		if fileInfo.IsDir() {
-			dir = opts.Path
-		} else {
-			dir = filepath.Dir(opts.Path)
-		}
-	}
-
-	if dir == "." {
-		dir = s.workingDir
-	}
 	permission := PermissionRequest{
 		ID:          uuid.New().String(),
-		Path:        dir,
+		Path:        s.requestDir(opts.Path),
 		SessionID:   opts.SessionID,
 		ToolCallID:  opts.ToolCallID,
 		ToolName:    opts.ToolName,
 		Description: opts.Description,
 		Action:      opts.Action,
 		Params:      opts.Params,
+		Command:     opts.Command,
+		Target:      opts.Target,
+		Risk:        opts.Risk,
 	}
 
-	s.sessionPermissionsMu.RLock()
-	for _, p := range s.sessionPermissions {
-		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
-			s.sessionPermissionsMu.RUnlock()
-			s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
-				ToolCallID: opts.ToolCallID,
-				Granted:    true,
-			})
-			return true, nil
-		}
-	}
-	s.sessionPermissionsMu.RUnlock()
-
 	s.activeRequestMu.Lock()
 	s.activeRequest = &permission
 	s.activeRequestMu.Unlock()
@@ -215,6 +249,79 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 	}
 }
 
+func (s *permissionService) Explain(opts CreatePermissionRequest) Decision {
+	decision, matched := s.policy.Evaluate(opts, s.workingDir)
+	switch {
+	case matched && decision.Effect == EffectDeny:
+		return decision
+	case s.skip:
+		return Decision{Effect: EffectAllow, Reason: "permission requests are skipped"}
+	case opts.Confirm:
+		return Decision{Effect: EffectAsk, Reason: "the tool always asks for this request"}
+	case matched && decision.Effect == EffectAllow:
+		return decision
+	}
+
+	s.autoApproveSessionsMu.RLock()
+	autoApprove := s.autoApproveSessions[opts.SessionID]
+	s.autoApproveSessionsMu.RUnlock()
+	if autoApprove {
+		return Decision{Effect: EffectAllow, Reason: "the session approves every request"}
+	}
+	if matched {
+		return decision
+	}
+	if opts.Risk != nil && opts.Risk.Severity >= shell.SeverityHigh {
+		return Decision{Effect: EffectAsk, Reason: "the command is classified as high risk"}
+	}
+
+	// Check if the tool/action combination is in the allowlist
+	for _, entry := range []string{opts.ToolName + ":" + opts.Action, opts.ToolName} {
+		if slices.Contains(s.allowedTools, entry) {
+			return Decision{Effect: EffectAllow, Rule: entry, Reason: fmt.Sprintf("%s is in permissions.allowed_tools", entry)}
+		}
+	}
+
+	dir := s.requestDir(opts.Path)
+	grant := Grant{ToolName: opts.ToolName, Action: opts.Action, Subject: requestSubject(opts.Command, opts.Target, dir)}
+	if s.grants.allows(grant) {
+		return Decision{Effect: EffectAllow, Rule: grant.String(), Reason: "the user always allows it in the project"}
+	}
+
+	s.sessionPermissionsMu.RLock()
+	defer s.sessionPermissionsMu.RUnlock()
+	for _, p := range s.sessionPermissions {
+		if p.ToolName == opts.ToolName && p.Action == opts.Action && p.SessionID == opts.SessionID && p.Path == dir {
+			return Decision{Effect: EffectAllow, Reason: "the user allowed it for the session"}
+		}
+	}
+	return Decision{Effect: EffectAsk, Reason: "no rule decides it"}
+}
+
+// requestSubject returns what a project grant for a request is for: its
+// command or target or, for other requests, dir.
+func requestSubject(command, target, dir string) string {
+	if command != "" {
+		return command
+	}
+	if target != "" {
+		return target
+	}
+	return dir
+}
+
+// requestDir returns the directory a request for path is granted for.
+func (s *permissionService) requestDir(path string) string {
+	dir := path
+	if fileInfo, err := os.Stat(path); err == nil && !fileInfo.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	if dir == "." {
+		dir = s.workingDir
+	}
+	return dir
+}
+
 func (s *permissionService) AutoApproveSession(sessionID string) {
 	s.autoApproveSessionsMu.Lock()
 	s.autoApproveSessions[sessionID] = true
@@ -233,7 +340,10 @@ func (s *permissionService) SkipRequests() bool {
 	return s.skip
 }
 
-func NewPermissionService(workingDir string, skip bool, allowedTools []string) Service {
+// NewPermissionService creates a permission service for the project in
+// workingDir. Project grants are kept in grantsPath, or only in memory when
+// it is empty.
+func NewPermissionService(workingDir string, skip bool, allowedTools []string, policy Policy, grantsPath string) Service {
 	return &permissionService{
 		Broker:              pubsub.NewBroker[PermissionRequest](),
 		notificationBroker:  pubsub.NewBroker[PermissionNotification](),
@@ -242,6 +352,8 @@ func NewPermissionService(workingDir string, skip bool, allowedTools []string) S
 		autoApproveSessions: make(map[string]bool),
 		skip:                skip,
 		allowedTools:        allowedTools,
+		policy:              policy,
+		grants:              loadGrants(grantsPath),
 		pendingRequests:     csync.NewMap[string, chan bool](),
 	}
 }
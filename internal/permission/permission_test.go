@@ -4,6 +4,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/charmbracelet/crush/internal/shell"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -55,7 +56,7 @@ func TestPermissionService_AllowedCommands(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service := NewPermissionService("/tmp", false, tt.allowedTools)
+			service := NewPermissionService("/tmp", false, tt.allowedTools, nil, "")
 
 			// Create a channel to capture the permission request
 			// Since we're testing the allowlist logic, we need to simulate the request
@@ -80,7 +81,7 @@ func TestPermissionService_AllowedCommands(t *testing.T) {
 }
 
 func TestPermissionService_SkipMode(t *testing.T) {
-	service := NewPermissionService("/tmp", true, []string{})
+	service := NewPermissionService("/tmp", true, []string{}, nil, "")
 
 	result, err := service.Request(t.Context(), CreatePermissionRequest{
 		SessionID:   "test-session",
@@ -97,9 +98,66 @@ func TestPermissionService_SkipMode(t *testing.T) {
 	}
 }
 
+func TestPermissionService_Confirm(t *testing.T) {
+	service := NewPermissionService("/tmp", false, []string{"edit"}, nil, "")
+	service.AutoApproveSession("confirm-session")
+	events := service.Subscribe(t.Context())
+
+	req := CreatePermissionRequest{
+		SessionID:   "confirm-session",
+		ToolName:    "edit",
+		Action:      "write",
+		Description: "Edit file owned by @other",
+		Path:        "/tmp/owned.go",
+		Confirm:     true,
+	}
+
+	var result bool
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		result, _ = service.Request(t.Context(), req)
+	})
+
+	event := <-events
+	require.Equal(t, "edit", event.Payload.ToolName)
+	service.Deny(event.Payload)
+	wg.Wait()
+	assert.False(t, result, "Confirm requests should not be auto-approved")
+
+	req.Confirm = false
+	result, err := service.Request(t.Context(), req)
+	require.NoError(t, err)
+	assert.True(t, result, "Allowlisted requests without Confirm should be approved")
+}
+
+func TestPermissionService_HighRisk(t *testing.T) {
+	t.Parallel()
+
+	service := NewPermissionService("/tmp", false, []string{"bash"}, nil, "")
+	req := CreatePermissionRequest{
+		SessionID: "risk-session",
+		ToolName:  "bash",
+		Action:    "execute",
+		Path:      "/tmp",
+		Command:   "rm -rf build",
+	}
+	require.Equal(t, EffectAllow, service.Explain(req).Effect)
+
+	req.Risk = &shell.Verdict{Severity: shell.SeverityMedium}
+	require.Equal(t, EffectAllow, service.Explain(req).Effect, "only high risk overrides the allowlist")
+
+	req.Risk = &shell.Verdict{Severity: shell.SeverityHigh}
+	decision := service.Explain(req)
+	require.Equal(t, EffectAsk, decision.Effect)
+	require.Contains(t, decision.Reason, "high risk")
+
+	service.AutoApproveSession("risk-session")
+	require.Equal(t, EffectAllow, service.Explain(req).Effect)
+}
+
 func TestPermissionService_SequentialProperties(t *testing.T) {
 	t.Run("Sequential permission requests with persistent grants", func(t *testing.T) {
-		service := NewPermissionService("/tmp", false, []string{})
+		service := NewPermissionService("/tmp", false, []string{}, nil, "")
 
 		req1 := CreatePermissionRequest{
 			SessionID:   "session1",
@@ -144,7 +202,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 		assert.True(t, result2, "Second request should be auto-approved")
 	})
 	t.Run("Sequential requests with temporary grants", func(t *testing.T) {
-		service := NewPermissionService("/tmp", false, []string{})
+		service := NewPermissionService("/tmp", false, []string{}, nil, "")
 
 		req := CreatePermissionRequest{
 			SessionID:   "session2",
@@ -184,7 +242,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 		assert.False(t, result2, "Second request should be denied")
 	})
 	t.Run("Concurrent requests with different outcomes", func(t *testing.T) {
-		service := NewPermissionService("/tmp", false, []string{})
+		service := NewPermissionService("/tmp", false, []string{}, nil, "")
 
 		events := service.Subscribe(t.Context())
 
@@ -0,0 +1,177 @@
+package permission
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+// Effect is what a permission decision does with a request.
+type Effect string
+
+const (
+	EffectAllow Effect = "allow"
+	EffectAsk   Effect = "ask"
+	EffectDeny  Effect = "deny"
+)
+
+// Decision is how a request is handled and why.
+type Decision struct {
+	Effect Effect `json:"effect"`
+	// Rule is the policy rule or allowed_tools entry that decided, if any.
+	Rule   string `json:"rule,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Rule is a policy entry such as "bash(git *)": allow. A rule without a
+// pattern matches every call of the tool.
+type Rule struct {
+	Tool    string
+	Pattern string
+	Effect  Effect
+
+	wildcard *regexp.Regexp
+}
+
+func (r Rule) String() string {
+	if r.Pattern == "" {
+		return r.Tool
+	}
+	return r.Tool + "(" + r.Pattern + ")"
+}
+
+// Policy is the set of rules that decide requests before the user is asked.
+// When several rules match, deny wins over ask, and ask over allow.
+type Policy []Rule
+
+// ParsePolicy parses rules keyed by "tool(pattern)" or "tool", with the
+// effect as value.
+func ParsePolicy(rules map[string]string) (Policy, error) {
+	policy := make(Policy, 0, len(rules))
+	for key, value := range rules {
+		rule := Rule{Tool: strings.TrimSpace(key), Effect: Effect(strings.ToLower(strings.TrimSpace(value)))}
+		if tool, pattern, ok := strings.Cut(rule.Tool, "("); ok {
+			if !strings.HasSuffix(pattern, ")") {
+				return nil, fmt.Errorf("permission rule %q: missing closing parenthesis", key)
+			}
+			rule.Tool = strings.TrimSpace(tool)
+			rule.Pattern = strings.TrimSpace(strings.TrimSuffix(pattern, ")"))
+		}
+		if rule.Tool == "" {
+			return nil, fmt.Errorf("permission rule %q: missing tool name", key)
+		}
+		if !slices.Contains([]Effect{EffectAllow, EffectAsk, EffectDeny}, rule.Effect) {
+			return nil, fmt.Errorf("permission rule %q: effect must be allow, ask or deny, not %q", key, value)
+		}
+		if rule.Pattern != "" {
+			if !doublestar.ValidatePattern(rule.Pattern) {
+				return nil, fmt.Errorf("permission rule %q: invalid pattern", key)
+			}
+			rule.wildcard = compileWildcard(rule.Pattern)
+		}
+		policy = append(policy, rule)
+	}
+	slices.SortFunc(policy, func(a, b Rule) int { return strings.Compare(a.String(), b.String()) })
+	return policy, nil
+}
+
+// Evaluate returns the decision of the rules matching a request, and false
+// when none match.
+func (p Policy) Evaluate(opts CreatePermissionRequest, workingDir string) (Decision, bool) {
+	var rules []Rule
+	for _, rule := range p {
+		if rule.Tool == opts.ToolName {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return Decision{}, false
+	}
+
+	// Each command of a shell command line has to be allowed on its own, so
+	// "git status && rm -rf ." is not allowed by "bash(git *)".
+	// Nor is a command line that writes files through redirections, so
+	// "git log > ~/.bashrc" is still asked for.
+	var subjects []string
+	allowedAll := true
+	switch {
+	case opts.Command != "":
+		subjects = shell.SplitCommands(opts.Command)
+		allowedAll = len(shell.WriteRedirects(opts.Command)) == 0
+	case opts.Target != "":
+		subjects = []string{opts.Target}
+	default:
+		subjects = []string{opts.Path}
+	}
+
+	var deny, ask, allow *Rule
+	for _, subject := range subjects {
+		allowedThis := false
+		for _, rule := range rules {
+			if !rule.matches(subject, workingDir) {
+				continue
+			}
+			switch rule.Effect {
+			case EffectDeny:
+				deny = cmp.Or(deny, &rule)
+			case EffectAsk:
+				ask = cmp.Or(ask, &rule)
+			case EffectAllow:
+				allow = cmp.Or(allow, &rule)
+				allowedThis = true
+			}
+		}
+		allowedAll = allowedAll && allowedThis
+	}
+
+	switch {
+	case deny != nil:
+		return Decision{Effect: EffectDeny, Rule: deny.String(), Reason: fmt.Sprintf("denied by the permission rule %s", deny)}, true
+	case ask != nil:
+		return Decision{Effect: EffectAsk, Rule: ask.String(), Reason: fmt.Sprintf("the permission rule %s asks first", ask)}, true
+	case allow != nil && allowedAll:
+		return Decision{Effect: EffectAllow, Rule: allow.String(), Reason: fmt.Sprintf("allowed by the permission rule %s", allow)}, true
+	}
+	return Decision{}, false
+}
+
+// matches reports whether the rule applies to subject: a file path, matched
+// relative to workingDir when inside it, or a command or URL.
+func (r Rule) matches(subject, workingDir string) bool {
+	if r.Pattern == "" {
+		return true
+	}
+	if filepath.IsAbs(subject) {
+		pattern := filepath.ToSlash(home.Long(r.Pattern))
+		if rel, err := filepath.Rel(workingDir, subject); err == nil && !strings.HasPrefix(rel, "..") {
+			if ok, _ := doublestar.Match(pattern, filepath.ToSlash(rel)); ok {
+				return true
+			}
+		}
+		ok, _ := doublestar.Match(pattern, filepath.ToSlash(subject))
+		return ok
+	}
+	return r.wildcard.MatchString(subject)
+}
+
+// compileWildcard turns a command pattern into a regular expression where *
+// matches anything. A trailing " *" also matches the bare command, so
+// "git *" matches "git".
+func compileWildcard(pattern string) *regexp.Regexp {
+	optionalArgs := strings.HasSuffix(pattern, " *")
+	if optionalArgs {
+		pattern = strings.TrimSuffix(pattern, " *")
+	}
+	expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, `.*`)
+	if optionalArgs {
+		expr += `( .*)?`
+	}
+	return regexp.MustCompile(`^` + expr + `$`)
+}
@@ -0,0 +1,122 @@
+package permission
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := ParsePolicy(map[string]string{
+		"bash(git *)":  "allow",
+		"edit(src/**)": "Allow",
+		"fetch":        "ask",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"bash(git *)", "edit(src/**)", "fetch"}, []string{policy[0].String(), policy[1].String(), policy[2].String()})
+	require.Equal(t, EffectAllow, policy[1].Effect)
+
+	for key, value := range map[string]string{
+		"bash(git *": "allow",
+		"(git *)":    "allow",
+		"bash":       "maybe",
+	} {
+		_, err := ParsePolicy(map[string]string{key: value})
+		require.Error(t, err, key)
+	}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	t.Parallel()
+
+	policy, err := ParsePolicy(map[string]string{
+		"bash(git *)":                 "allow",
+		"bash(go test*)":              "allow",
+		"bash(git push*)":             "ask",
+		"bash(rm *)":                  "deny",
+		"edit(src/**)":                "allow",
+		"edit(**/*.lock)":             "deny",
+		"fetch(https://pkg.go.dev/*)": "allow",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		req    CreatePermissionRequest
+		effect Effect
+		rule   string
+	}{
+		{CreatePermissionRequest{ToolName: "bash", Command: "git status"}, EffectAllow, "bash(git *)"},
+		{CreatePermissionRequest{ToolName: "bash", Command: "git"}, EffectAllow, "bash(git *)"},
+		{CreatePermissionRequest{ToolName: "bash", Command: "git diff && go test ./..."}, EffectAllow, "bash(git *)"},
+		{CreatePermissionRequest{ToolName: "bash", Command: "git push origin main"}, EffectAsk, "bash(git push*)"},
+		{CreatePermissionRequest{ToolName: "bash", Command: "git status; rm -rf ."}, EffectDeny, "bash(rm *)"},
+		{CreatePermissionRequest{ToolName: "bash", Command: "echo $(rm -rf .)"}, EffectDeny, "bash(rm *)"},
+		{CreatePermissionRequest{ToolName: "bash", Command: "git log 2>&1 > /dev/null"}, EffectAllow, "bash(git *)"},
+		{CreatePermissionRequest{ToolName: "bash", Command: "git log > out.txt; rm -rf ."}, EffectDeny, "bash(rm *)"},
+		{CreatePermissionRequest{ToolName: "edit", Target: "/p/src/a/b.go"}, EffectAllow, "edit(src/**)"},
+		{CreatePermissionRequest{ToolName: "edit", Target: "/p/src/go.lock"}, EffectDeny, "edit(**/*.lock)"},
+		{CreatePermissionRequest{ToolName: "fetch", Target: "https://pkg.go.dev/fmt?tab=doc&x=1"}, EffectAllow, "fetch(https://pkg.go.dev/*)"},
+	}
+	for _, tt := range tests {
+		decision, ok := policy.Evaluate(tt.req, "/p")
+		require.True(t, ok, tt.req)
+		require.Equal(t, tt.effect, decision.Effect, tt.req)
+		require.Equal(t, tt.rule, decision.Rule, tt.req)
+	}
+
+	for _, req := range []CreatePermissionRequest{
+		{ToolName: "bash", Command: "git status && make"},
+		{ToolName: "bash", Command: "gitk"},
+		{ToolName: "bash", Command: "git log > ~/.bashrc"},
+		{ToolName: "bash", Command: "git status >> .git/hooks/pre-commit"},
+		{ToolName: "bash", Command: "go test ./... &> $LOG"},
+		{ToolName: "edit", Target: "/p/docs/a.md"},
+		{ToolName: "write", Target: "/p/src/a.go"},
+	} {
+		_, ok := policy.Evaluate(req, "/p")
+		require.False(t, ok, req)
+	}
+}
+
+func TestPermissionService_Policy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := ParsePolicy(map[string]string{
+		"bash(rm *)":  "deny",
+		"bash(ls *)":  "allow",
+		"bash(make*)": "ask",
+	})
+	require.NoError(t, err)
+	service := NewPermissionService("/tmp", false, []string{"bash"}, policy, "")
+
+	granted, err := service.Request(t.Context(), CreatePermissionRequest{SessionID: "s", ToolName: "bash", Action: "execute", Command: "rm -rf /"})
+	require.NoError(t, err)
+	require.False(t, granted, "deny rules win over allowed_tools")
+
+	granted, err = service.Request(t.Context(), CreatePermissionRequest{SessionID: "s", ToolName: "bash", Action: "execute", Command: "ls -la"})
+	require.NoError(t, err)
+	require.True(t, granted)
+
+	events := service.Subscribe(t.Context())
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		granted, err = service.Request(t.Context(), CreatePermissionRequest{SessionID: "s", ToolName: "bash", Action: "execute", Command: "make"})
+	})
+	event := <-events
+	service.Grant(event.Payload)
+	wg.Wait()
+	require.NoError(t, err)
+	require.True(t, granted, "ask rules prompt even for allowed tools")
+
+	require.Equal(t, Decision{Effect: EffectAllow, Rule: "bash", Reason: "bash is in permissions.allowed_tools"},
+		service.Explain(CreatePermissionRequest{ToolName: "bash", Action: "execute", Command: "go build"}))
+	require.Equal(t, EffectAsk, service.Explain(CreatePermissionRequest{ToolName: "edit", Action: "write"}).Effect)
+
+	yolo := NewPermissionService("/tmp", true, nil, policy, "")
+	granted, err = yolo.Request(t.Context(), CreatePermissionRequest{ToolName: "bash", Command: "rm -rf /"})
+	require.NoError(t, err)
+	require.False(t, granted, "deny rules apply even when requests are skipped")
+}
@@ -0,0 +1,177 @@
+// Package postprocess processes the outputs of tools before they enter the
+// context, as configured in options.tool_output_processors, to keep
+// tool-heavy sessions within budget.
+package postprocess
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// commandTimeout is how long a command processor may run.
+const commandTimeout = 10 * time.Second
+
+// summarySystemPrompt is the system prompt of the summaries of outputs.
+const summarySystemPrompt = `You summarize the output of tools for a coding agent that will only see your summary. Keep what the agent needs to act on: errors and warnings with their file paths and line numbers, failing tests, counts and final results. Quote error messages exactly. Leave out progress lines, repeated lines and steps that succeeded. Answer with the summary only.`
+
+// summaryPrompt asks for the summary of the output of a tool.
+const summaryPrompt = `Summarize the output of the %s tool below.
+
+<output>
+%s
+</output>`
+
+// Summarizer asks the small model for the answer to prompt, with
+// systemPrompt.
+type Summarizer func(ctx context.Context, systemPrompt, prompt string) (string, error)
+
+// Pipeline applies processors to the outputs of tools, in order.
+type Pipeline struct {
+	processors []config.ToolOutputProcessor
+	workingDir string
+	summarize  Summarizer
+}
+
+// New returns the pipeline of processors, running commands in workingDir and
+// summarizing with summarize. It returns nil when there are no processors.
+func New(processors []config.ToolOutputProcessor, workingDir string, summarize Summarizer) *Pipeline {
+	if len(processors) == 0 {
+		return nil
+	}
+	return &Pipeline{processors: processors, workingDir: workingDir, summarize: summarize}
+}
+
+// Process returns the output of tool after the processors that apply to it.
+// A processor that fails leaves the output as it was.
+func (p *Pipeline) Process(ctx context.Context, tool, output string) string {
+	for _, proc := range p.processors {
+		if !applies(proc, tool, output) {
+			continue
+		}
+		processed, err := p.run(ctx, proc, tool, output)
+		if err != nil {
+			slog.Warn("Tool output processor failed", "type", proc.Type, "tool", tool, "error", err)
+			continue
+		}
+		output = processed
+	}
+	return output
+}
+
+// applies reports whether proc processes the output of tool.
+func applies(proc config.ToolOutputProcessor, tool, output string) bool {
+	if len(proc.Tools) > 0 && !slices.Contains(proc.Tools, tool) {
+		return false
+	}
+	minSize := proc.MinSize
+	if minSize == 0 && proc.Type == config.ToolOutputSummarize {
+		minSize = config.DefaultSummarizeMinSize
+	}
+	return output != "" && len(output) >= minSize
+}
+
+func (p *Pipeline) run(ctx context.Context, proc config.ToolOutputProcessor, tool, output string) (string, error) {
+	switch proc.Type {
+	case config.ToolOutputStripANSI:
+		return ansi.Strip(output), nil
+	case config.ToolOutputFailingTests:
+		return FailingTests(output), nil
+	case config.ToolOutputSummarize:
+		if p.summarize == nil {
+			return "", errors.New("no model to summarize with")
+		}
+		summary, err := p.summarize(ctx, summarySystemPrompt, fmt.Sprintf(summaryPrompt, tool, output))
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(summary) == "" {
+			return "", errors.New("empty summary")
+		}
+		return fmt.Sprintf("[Summary of %d bytes of output]\n%s", len(output), summary), nil
+	case config.ToolOutputCommand:
+		return p.command(ctx, proc, output)
+	default:
+		return "", fmt.Errorf("unknown processor type %q", proc.Type)
+	}
+}
+
+// command pipes output through the command of proc.
+func (p *Pipeline) command(ctx context.Context, proc config.ToolOutputProcessor, output string) (string, error) {
+	if strings.TrimSpace(proc.Command) == "" {
+		return "", errors.New("command processor requires a command")
+	}
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, home.Long(proc.Command), proc.Args...)
+	cmd.Dir = p.workingDir
+	cmd.Stdin = strings.NewReader(output)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// failurePatterns match the lines test runners report failures on: go test,
+// pytest, cargo test, and jest, vitest and mocha.
+var failurePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^\s*--- FAIL: `),
+	regexp.MustCompile(`^FAIL\s`),
+	regexp.MustCompile(`^(panic|fatal error): `),
+	regexp.MustCompile(`^(FAILED|ERROR) \S`),
+	regexp.MustCompile(`^=+ .*\b(failed|errors?)\b.* =+$`),
+	regexp.MustCompile(`^test \S+ \.\.\. FAILED$`),
+	regexp.MustCompile(`^\s*(✕|×|✗) `),
+	regexp.MustCompile(`^\s*● `),
+}
+
+// maxFailureDetail is how many of the lines indented under a failure are
+// kept with it.
+const maxFailureDetail = 10
+
+// FailingTests returns the lines of test runner output about failing tests,
+// with the messages indented under them. The output is returned as is when
+// it reports no failure.
+func FailingTests(output string) string {
+	lines := strings.Split(ansi.Strip(output), "\n")
+	var kept []string
+	detail := -1
+	for _, line := range lines {
+		if slices.ContainsFunc(failurePatterns, func(re *regexp.Regexp) bool { return re.MatchString(line) }) {
+			kept = append(kept, line)
+			detail = 0
+			continue
+		}
+		indented := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+		if detail >= 0 && indented && strings.TrimSpace(line) != "" && detail < maxFailureDetail {
+			kept = append(kept, line)
+			detail++
+			continue
+		}
+		if !indented {
+			detail = -1
+		}
+	}
+	if len(kept) == 0 {
+		return output
+	}
+	return fmt.Sprintf("[Failing tests from %d lines of output]\n%s", len(lines), strings.Join(kept, "\n"))
+}
@@ -0,0 +1,115 @@
+package postprocess
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailingTests(t *testing.T) {
+	t.Parallel()
+
+	t.Run("go test", func(t *testing.T) {
+		t.Parallel()
+		output := strings.Join([]string{
+			"=== RUN   TestA",
+			"--- PASS: TestA (0.00s)",
+			"=== RUN   TestB",
+			"--- FAIL: TestB (0.00s)",
+			"    b_test.go:12: got 1, want 2",
+			"ok  \tpkg/a\t0.01s",
+			"FAIL\tpkg/b\t0.02s",
+		}, "\n")
+		require.Equal(t, "[Failing tests from 7 lines of output]\n"+
+			"--- FAIL: TestB (0.00s)\n"+
+			"    b_test.go:12: got 1, want 2\n"+
+			"FAIL\tpkg/b\t0.02s", FailingTests(output))
+	})
+
+	t.Run("pytest", func(t *testing.T) {
+		t.Parallel()
+		output := "tests/test_a.py ..F\nFAILED tests/test_a.py::test_c - assert 1 == 2\n===== 1 failed, 2 passed in 0.1s =====\n"
+		got := FailingTests(output)
+		require.Contains(t, got, "FAILED tests/test_a.py::test_c")
+		require.Contains(t, got, "1 failed, 2 passed")
+		require.NotContains(t, got, "..F")
+	})
+
+	t.Run("no failures", func(t *testing.T) {
+		t.Parallel()
+		output := "ok  \tpkg/a\t0.01s\n"
+		require.Equal(t, output, FailingTests(output))
+	})
+}
+
+func TestPipelineProcess(t *testing.T) {
+	t.Parallel()
+
+	var prompts []string
+	summarize := func(_ context.Context, _, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		return "it built", nil
+	}
+	p := New([]config.ToolOutputProcessor{
+		{Type: config.ToolOutputStripANSI},
+		{Type: config.ToolOutputSummarize, Tools: []string{"bash"}, MinSize: 10},
+	}, t.TempDir(), summarize)
+
+	require.Equal(t, "red", p.Process(t.Context(), "view", "\x1b[31mred\x1b[0m"))
+	require.Empty(t, prompts)
+
+	got := p.Process(t.Context(), "bash", "\x1b[1mcompiling everything\x1b[0m")
+	require.Equal(t, "[Summary of 20 bytes of output]\nit built", got)
+	require.Len(t, prompts, 1)
+	require.Contains(t, prompts[0], "<output>\ncompiling everything\n</output>")
+}
+
+func TestPipelineFailureKeepsOutput(t *testing.T) {
+	t.Parallel()
+
+	p := New([]config.ToolOutputProcessor{
+		{Type: config.ToolOutputSummarize, MinSize: 1},
+		{Type: config.ToolOutputCommand, Command: "false"},
+	}, t.TempDir(), func(context.Context, string, string) (string, error) {
+		return "", errors.New("no model")
+	})
+	require.Equal(t, "output", p.Process(t.Context(), "bash", "output"))
+}
+
+func TestPipelineCommand(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("tr"); err != nil {
+		t.Skip("tr not found")
+	}
+
+	p := New([]config.ToolOutputProcessor{
+		{Type: config.ToolOutputCommand, Command: "tr", Args: []string{"a-z", "A-Z"}},
+	}, t.TempDir(), nil)
+	require.Equal(t, "LOUD", p.Process(t.Context(), "bash", "loud"))
+}
+
+func TestWrapTools(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, New(nil, "", nil))
+
+	tool := fantasy.NewAgentTool("echo", "", func(ctx context.Context, params struct{}, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		return fantasy.NewTextErrorResponse("\x1b[31mfailed\x1b[0m"), nil
+	})
+	p := New([]config.ToolOutputProcessor{{Type: config.ToolOutputStripANSI}}, "", nil)
+	wrapped := p.WrapTools([]fantasy.AgentTool{tool})
+	wrapped = p.WrapTools(wrapped)
+
+	resp, err := wrapped[0].Run(t.Context(), fantasy.ToolCall{Input: "{}"})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+	require.Equal(t, "failed", resp.Content)
+	_, double := wrapped[0].(*processedTool).AgentTool.(*processedTool)
+	require.False(t, double)
+}
@@ -0,0 +1,36 @@
+package postprocess
+
+import (
+	"context"
+
+	"charm.land/fantasy"
+)
+
+// processedTool wraps an agent tool to process its outputs.
+type processedTool struct {
+	fantasy.AgentTool
+	pipeline *Pipeline
+}
+
+// WrapTools processes the outputs of every tool in agentTools in place and
+// returns the slice. It does nothing when p is nil.
+func (p *Pipeline) WrapTools(agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	if p == nil {
+		return agentTools
+	}
+	for i, tool := range agentTools {
+		if _, ok := tool.(*processedTool); !ok {
+			agentTools[i] = &processedTool{AgentTool: tool, pipeline: p}
+		}
+	}
+	return agentTools
+}
+
+func (t *processedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	resp, err := t.AgentTool.Run(ctx, call)
+	if err != nil || resp.Type != "text" || ctx.Err() != nil {
+		return resp, err
+	}
+	resp.Content = t.pipeline.Process(ctx, t.Info().Name, resp.Content)
+	return resp, nil
+}
@@ -0,0 +1,223 @@
+// Package recall keeps the conversation turns dropped by compaction, with
+// embeddings to find them again by similarity.
+package recall
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+const (
+	// embeddingDims is the number of dimensions of the hashed embeddings.
+	embeddingDims = 512
+	// maxTurnLength caps how much of a single turn is kept.
+	maxTurnLength = 4000
+	// cachedSessions is how many sessions have their turns kept in memory
+	// between searches.
+	cachedSessions = 8
+)
+
+// Turn is a dropped conversation turn.
+type Turn struct {
+	MessageID string
+	Role      message.MessageRole
+	Text      string
+	embedding []float32
+}
+
+// Store stores the turns dropped by compaction in the database.
+type Store struct {
+	q        *db.Queries
+	messages message.Service
+	cache    *lru.Cache[string, []Turn]
+}
+
+// New returns a store of dropped turns, that reads the messages of sessions
+// compacted before it was used from messages.
+func New(q *db.Queries, messages message.Service) *Store {
+	cache, _ := lru.New[string, []Turn](cachedSessions)
+	return &Store{q: q, messages: messages, cache: cache}
+}
+
+// Index stores the turns of msgs, the messages a compaction of the session
+// dropped from the context, with their embeddings.
+func (s *Store) Index(ctx context.Context, sessionID string, msgs []message.Message) error {
+	defer s.cache.Remove(sessionID)
+	for _, msg := range msgs {
+		text := turnText(msg)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if err := s.q.CreateRecallTurn(ctx, db.CreateRecallTurnParams{
+			MessageID: msg.ID,
+			SessionID: sessionID,
+			Role:      string(msg.Role),
+			Content:   text,
+			Embedding: encodeEmbedding(embed(text)),
+		}); err != nil {
+			return fmt.Errorf("failed to store turn %s: %w", msg.ID, err)
+		}
+	}
+	return nil
+}
+
+// Turns returns the turns the compactions of the session dropped, the last
+// of which made summaryMessageID. Sessions compacted before their turns were
+// stored are indexed on the way.
+func (s *Store) Turns(ctx context.Context, sessionID, summaryMessageID string) ([]Turn, error) {
+	if turns, ok := s.cache.Get(sessionID); ok {
+		return turns, nil
+	}
+	turns, err := s.load(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(turns) == 0 && summaryMessageID != "" {
+		msgs, err := s.messages.List(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list messages: %w", err)
+		}
+		if i := slices.IndexFunc(msgs, func(msg message.Message) bool { return msg.ID == summaryMessageID }); i > 0 {
+			if err := s.Index(ctx, sessionID, msgs[:i]); err != nil {
+				return nil, err
+			}
+			if turns, err = s.load(ctx, sessionID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	s.cache.Add(sessionID, turns)
+	return turns, nil
+}
+
+func (s *Store) load(ctx context.Context, sessionID string) ([]Turn, error) {
+	rows, err := s.q.ListRecallTurnsBySession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list turns: %w", err)
+	}
+	turns := make([]Turn, 0, len(rows))
+	for _, row := range rows {
+		turns = append(turns, Turn{
+			MessageID: row.MessageID,
+			Role:      message.MessageRole(row.Role),
+			Text:      row.Content,
+			embedding: decodeEmbedding(row.Embedding),
+		})
+	}
+	return turns, nil
+}
+
+// Search returns up to limit of turns ranked by similarity to query. Turns
+// that share no terms with the query are never returned.
+func Search(turns []Turn, query string, limit int) []Turn {
+	q := embed(query)
+	type scored struct {
+		turn  Turn
+		score float64
+	}
+	var results []scored
+	for _, turn := range turns {
+		if score := cosine(q, turn.embedding); score > 0 {
+			results = append(results, scored{turn, score})
+		}
+	}
+	slices.SortStableFunc(results, func(a, b scored) int {
+		return cmp.Compare(b.score, a.score)
+	})
+
+	matches := make([]Turn, 0, min(limit, len(results)))
+	for _, r := range results[:min(limit, len(results))] {
+		matches = append(matches, r.turn)
+	}
+	return matches
+}
+
+// turnText flattens a message into plain text.
+func turnText(msg message.Message) string {
+	var parts []string
+	if text := msg.Content().Text; text != "" {
+		parts = append(parts, text)
+	}
+	for _, tc := range msg.ToolCalls() {
+		parts = append(parts, fmt.Sprintf("[tool call %s] %s", tc.Name, tc.Input))
+	}
+	for _, tr := range msg.ToolResults() {
+		parts = append(parts, fmt.Sprintf("[tool result %s] %s", tr.Name, tr.Content))
+	}
+	text := strings.Join(parts, "\n")
+	if len(text) > maxTurnLength {
+		cut := maxTurnLength
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + "\n[truncated]"
+	}
+	return text
+}
+
+// embed computes a normalized, hashed bag-of-words embedding. It is cheap,
+// deterministic and works offline, which is all we need to rank a single
+// session's history.
+func embed(text string) []float32 {
+	vec := make([]float32, embeddingDims)
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, token := range tokens {
+		if len(token) < 2 {
+			continue
+		}
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(token))
+		vec[h.Sum32()%embeddingDims]++
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v * v)
+	}
+	if norm == 0 {
+		return vec
+	}
+	norm = math.Sqrt(norm)
+	for i := range vec {
+		vec[i] = float32(float64(vec[i]) / norm)
+	}
+	return vec
+}
+
+func encodeEmbedding(vec []float32) []byte {
+	data := make([]byte, 0, 4*len(vec))
+	for _, v := range vec {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(v))
+	}
+	return data
+}
+
+func decodeEmbedding(data []byte) []float32 {
+	vec := make([]float32, len(data)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return vec
+}
+
+func cosine(a, b []float32) float64 {
+	var dot float64
+	for i := range min(len(a), len(b)) {
+		dot += float64(a[i] * b[i])
+	}
+	return dot
+}
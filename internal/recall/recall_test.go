@@ -0,0 +1,126 @@
+package recall
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func setup(t *testing.T) (*db.Queries, message.Service) {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	return q, message.NewService(q)
+}
+
+func createMessages(t *testing.T, q *db.Queries, messages message.Service, sessionID string, parts ...message.ContentPart) []message.Message {
+	t.Helper()
+	_, err := q.CreateSession(t.Context(), db.CreateSessionParams{ID: sessionID, Title: "Session"})
+	require.NoError(t, err)
+	var msgs []message.Message
+	for _, part := range parts {
+		role := message.User
+		if _, ok := part.(message.ToolResult); ok {
+			role = message.Tool
+		}
+		msg, err := messages.Create(t.Context(), sessionID, message.CreateMessageParams{Role: role, Parts: []message.ContentPart{part}})
+		require.NoError(t, err)
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	q, messages := setup(t)
+	msgs := createMessages(t, q, messages, "session",
+		message.TextContent{Text: "The database migration fails with error code 1062"},
+		message.TextContent{Text: "Let's rename the handler in server.go"},
+		message.ToolResult{Name: "bash", Content: "go test ./... passed"},
+		message.TextContent{Text: ""},
+	)
+	require.NoError(t, New(q, messages).Index(t.Context(), "session", msgs))
+
+	// A new store, as after a restart, reads the stored turns.
+	turns, err := New(q, messages).Turns(t.Context(), "session", "summary")
+	require.NoError(t, err)
+	require.Len(t, turns, 3)
+
+	matches := Search(turns, "migration error", 5)
+	require.Len(t, matches, 1)
+	require.Equal(t, msgs[0].ID, matches[0].MessageID)
+	require.Equal(t, message.User, matches[0].Role)
+
+	matches = Search(turns, "server.go handler", 1)
+	require.Len(t, matches, 1)
+	require.Equal(t, msgs[1].ID, matches[0].MessageID)
+
+	require.Empty(t, Search(turns, "kubernetes", 5))
+}
+
+func TestStoreIndexesEarlierCompactions(t *testing.T) {
+	t.Parallel()
+
+	q, messages := setup(t)
+	msgs := createMessages(t, q, messages, "session",
+		message.TextContent{Text: "dropped turn"},
+		message.TextContent{Text: "summary"},
+		message.TextContent{Text: "turn kept in the context"},
+	)
+	turns, err := New(q, messages).Turns(t.Context(), "session", msgs[1].ID)
+	require.NoError(t, err)
+	require.Len(t, turns, 1)
+	require.Equal(t, msgs[0].ID, turns[0].MessageID)
+
+	rows, err := q.ListRecallTurnsBySession(t.Context(), "session")
+	require.NoError(t, err)
+	require.Len(t, rows, 1, "the turns are stored on the way")
+}
+
+func TestStoreCache(t *testing.T) {
+	t.Parallel()
+
+	q, messages := setup(t)
+	store := New(q, messages)
+	for i := range cachedSessions + 2 {
+		sessionID := fmt.Sprint("session-", i)
+		msgs := createMessages(t, q, messages, sessionID, message.TextContent{Text: "turn"})
+		require.NoError(t, store.Index(t.Context(), sessionID, msgs))
+		_, err := store.Turns(t.Context(), sessionID, "summary")
+		require.NoError(t, err)
+	}
+	require.Equal(t, cachedSessions, store.cache.Len())
+	require.False(t, store.cache.Contains("session-0"), "the least recently searched sessions are evicted")
+
+	msgs := createMessages(t, q, messages, "new", message.TextContent{Text: "first"})
+	require.NoError(t, store.Index(t.Context(), "new", msgs))
+	turns, err := store.Turns(t.Context(), "new", "summary")
+	require.NoError(t, err)
+	require.Len(t, turns, 1)
+
+	more, err := messages.Create(t.Context(), "new", message.CreateMessageParams{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "second"}}})
+	require.NoError(t, err)
+	require.NoError(t, store.Index(t.Context(), "new", []message.Message{more}))
+	turns, err = store.Turns(t.Context(), "new", "summary")
+	require.NoError(t, err)
+	require.Len(t, turns, 2, "indexing drops the cached turns of the session")
+}
+
+func TestTurnText(t *testing.T) {
+	t.Parallel()
+
+	text := turnText(message.Message{Parts: []message.ContentPart{
+		message.TextContent{Text: "a" + strings.Repeat("é", maxTurnLength)},
+	}})
+	require.True(t, utf8.ValidString(text))
+	require.True(t, strings.HasSuffix(text, "\n[truncated]"))
+	require.LessOrEqual(t, len(text), maxTurnLength+len("\n[truncated]"))
+}
@@ -0,0 +1,77 @@
+package redact
+
+import (
+	"context"
+	"errors"
+
+	"charm.land/fantasy"
+)
+
+// redactedModel masks secrets in every prompt before it is sent.
+type redactedModel struct {
+	fantasy.LanguageModel
+	r *Redactor
+}
+
+// WrapModel masks secrets in the prompts sent to model. It returns model as
+// is when r is nil.
+func WrapModel(model fantasy.LanguageModel, r *Redactor) fantasy.LanguageModel {
+	if model == nil || r == nil {
+		return model
+	}
+	return &redactedModel{LanguageModel: model, r: r}
+}
+
+func (m *redactedModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	call.Prompt = m.r.prompt(call.Prompt)
+	return m.LanguageModel.Generate(ctx, call)
+}
+
+func (m *redactedModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	call.Prompt = m.r.prompt(call.Prompt)
+	return m.LanguageModel.Stream(ctx, call)
+}
+
+// prompt returns a copy of prompt with secrets masked in text, tool calls
+// and tool results, which is where file contents and command output end
+// up. Reasoning is left alone since providers sign it.
+func (r *Redactor) prompt(prompt fantasy.Prompt) fantasy.Prompt {
+	out := make(fantasy.Prompt, len(prompt))
+	for i, msg := range prompt {
+		parts := make([]fantasy.MessagePart, len(msg.Content))
+		for j, part := range msg.Content {
+			parts[j] = r.part(part)
+		}
+		msg.Content = parts
+		out[i] = msg
+	}
+	return out
+}
+
+func (r *Redactor) part(part fantasy.MessagePart) fantasy.MessagePart {
+	switch p := part.(type) {
+	case fantasy.TextPart:
+		p.Text = r.Redact(p.Text)
+		return p
+	case fantasy.ToolCallPart:
+		p.Input = r.Redact(p.Input)
+		return p
+	case fantasy.ToolResultPart:
+		switch o := p.Output.(type) {
+		case fantasy.ToolResultOutputContentText:
+			o.Text = r.Redact(o.Text)
+			p.Output = o
+		case fantasy.ToolResultOutputContentMedia:
+			o.Text = r.Redact(o.Text)
+			p.Output = o
+		case fantasy.ToolResultOutputContentError:
+			if o.Error != nil {
+				if msg := r.Redact(o.Error.Error()); msg != o.Error.Error() {
+					p.Output = fantasy.ToolResultOutputContentError{Error: errors.New(msg)}
+				}
+			}
+		}
+		return p
+	}
+	return part
+}
@@ -0,0 +1,208 @@
+// Package redact masks secrets in text before it leaves the machine, be it
+// in a prompt sent to a provider or a line written to the logs.
+package redact
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// minEnvValueLength keeps short .env values such as ports and flags from
+// being masked everywhere they happen to appear.
+const minEnvValueLength = 8
+
+// defaultEnvFiles are read for values to mask when none are configured.
+var defaultEnvFiles = []string{".env", ".env.local"}
+
+type rule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// builtinRules match well-known credential formats. When a rule has a
+// capture group only the group is masked, so the key name stays readable.
+var builtinRules = []rule{
+	{"aws_access_key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws_secret_key", regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+]{40})`)},
+	{"github_token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"api_key", regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`)},
+	{"slack_token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"google_api_key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"private_key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+}
+
+type literal struct {
+	name  string
+	value string
+}
+
+// Redactor replaces secrets with a placeholder naming what was masked. A
+// nil *Redactor leaves text untouched.
+type Redactor struct {
+	rules    []rule
+	literals []literal
+	allow    []*regexp.Regexp
+}
+
+// New builds the redactor configured in cfg, or returns nil when redaction
+// is disabled. Invalid patterns are logged and skipped.
+func New(cfg *config.Config) *Redactor {
+	opts := &config.RedactionOptions{}
+	if cfg.Options != nil && cfg.Options.Redaction != nil {
+		opts = cfg.Options.Redaction
+	}
+	return newRedactor(cfg.WorkingDir(), opts)
+}
+
+// NewForPublishing builds the redactor configured in cfg like [New], but
+// masks secrets even when redaction is disabled: what is published outlives
+// the session.
+func NewForPublishing(cfg *config.Config) *Redactor {
+	opts := config.RedactionOptions{}
+	if cfg.Options != nil && cfg.Options.Redaction != nil {
+		opts = *cfg.Options.Redaction
+	}
+	opts.Disabled = false
+	return newRedactor(cfg.WorkingDir(), &opts)
+}
+
+func newRedactor(workingDir string, opts *config.RedactionOptions) *Redactor {
+	if opts.Disabled {
+		return nil
+	}
+
+	r := &Redactor{rules: slices.Clone(builtinRules)}
+	for _, p := range opts.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			slog.Warn("Invalid redaction pattern", "pattern", p, "error", err)
+			continue
+		}
+		r.rules = append(r.rules, rule{"custom", re})
+	}
+	for _, p := range opts.Allow {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			slog.Warn("Invalid redaction allow pattern", "pattern", p, "error", err)
+			continue
+		}
+		r.allow = append(r.allow, re)
+	}
+
+	envFiles := opts.EnvFiles
+	if envFiles == nil {
+		envFiles = defaultEnvFiles
+	}
+	for _, name := range envFiles {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workingDir, path)
+		}
+		literals, err := envValues(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				slog.Warn("Failed to read env file for redaction", "path", path, "error", err)
+			}
+			continue
+		}
+		r.literals = append(r.literals, literals...)
+	}
+	// Mask longer values first so a value containing another is masked
+	// whole.
+	slices.SortFunc(r.literals, func(a, b literal) int {
+		return len(b.value) - len(a.value)
+	})
+	return r
+}
+
+// envValues reads the values of a dotenv file.
+func envValues(path string) ([]literal, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var literals []literal
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		if len(value) < minEnvValueLength {
+			continue
+		}
+		literals = append(literals, literal{name: "env:" + strings.TrimSpace(key), value: value})
+	}
+	return literals, scanner.Err()
+}
+
+// Redact returns s with every secret replaced.
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, rule := range r.rules {
+		s = r.replace(s, rule)
+	}
+	for _, l := range r.literals {
+		if strings.Contains(s, l.value) && !r.allowed(l.value) {
+			s = strings.ReplaceAll(s, l.value, placeholder(l.name))
+		}
+	}
+	return s
+}
+
+func (r *Redactor) replace(s string, rule rule) string {
+	matches := rule.re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		// Only mask the first group when the rule has one.
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if r.allowed(s[start:end]) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(placeholder(rule.name))
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func (r *Redactor) allowed(secret string) bool {
+	for _, re := range r.allow {
+		if re.MatchString(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+func placeholder(name string) string {
+	return fmt.Sprintf("[REDACTED:%s]", name)
+}
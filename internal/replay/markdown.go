@@ -0,0 +1,87 @@
+package replay
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// maxPromptLength is how much of each prompt Markdown shows in headings.
+const maxPromptLength = 80
+
+// Markdown renders the report for reading.
+func (r Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Replay of %q\n\n", r.Original.Title)
+	b.WriteString("| | Original | Replay |\n|---|---|---|\n")
+	fmt.Fprintf(&b, "| Session | %s | %s |\n", r.Original.SessionID, r.Replay.SessionID)
+	fmt.Fprintf(&b, "| Model | %s | %s |\n", orDash(r.Original.Model), orDash(r.Replay.Model))
+	fmt.Fprintf(&b, "| Prompt template | %s | %s |\n", orBuiltIn(r.Original.PromptTemplate), orBuiltIn(r.Replay.PromptTemplate))
+	fmt.Fprintf(&b, "| Turns | %d | %d |\n", len(r.Original.Turns), len(r.Replay.Turns))
+	fmt.Fprintf(&b, "| Tokens in / out | %d / %d | %d / %d |\n",
+		r.Original.PromptTokens, r.Original.CompletionTokens, r.Replay.PromptTokens, r.Replay.CompletionTokens)
+	fmt.Fprintf(&b, "| Cost | $%.4f | $%.4f |\n", r.Original.Cost, r.Replay.Cost)
+	if r.Replay.Error != "" {
+		fmt.Fprintf(&b, "\nThe replay stopped early: %s\n", r.Replay.Error)
+	}
+
+	for _, turn := range r.Turns {
+		fmt.Fprintf(&b, "\n## Turn %d: %s\n\n", turn.Index, headline(turn.Prompt))
+		if turn.Original.Attachments > 0 {
+			fmt.Fprintf(&b, "The original prompt had %d attachments, which were not replayed.\n\n", turn.Original.Attachments)
+		}
+		if turn.Replay == nil {
+			b.WriteString("Not replayed.\n")
+			continue
+		}
+		fmt.Fprintf(&b, "- Response similarity: %.0f%%\n", turn.Similarity*100)
+		fmt.Fprintf(&b, "- Tool calls: %s → %s\n", toolCalls(turn.Original), toolCalls(*turn.Replay))
+		if turn.Original.FailedToolCalls > 0 || turn.Replay.FailedToolCalls > 0 {
+			fmt.Fprintf(&b, "- Failed tool calls: %d → %d\n", turn.Original.FailedToolCalls, turn.Replay.FailedToolCalls)
+		}
+		if len(turn.FilesOnlyOriginal) > 0 {
+			fmt.Fprintf(&b, "- Changed only in the original: %s\n", strings.Join(turn.FilesOnlyOriginal, ", "))
+		}
+		if len(turn.FilesOnlyReplay) > 0 {
+			fmt.Fprintf(&b, "- Changed only in the replay: %s\n", strings.Join(turn.FilesOnlyReplay, ", "))
+		}
+		fmt.Fprintf(&b, "\n### Original\n\n%s\n\n### Replay\n\n%s\n", orDash(turn.Original.Response), orDash(turn.Replay.Response))
+	}
+	return b.String()
+}
+
+// toolCalls lists the calls of a turn, such as "edit ×2, view ×1".
+func toolCalls(turn Turn) string {
+	if len(turn.ToolCalls) == 0 {
+		return "none"
+	}
+	var calls []string
+	for _, name := range slices.Sorted(maps.Keys(turn.ToolCalls)) {
+		calls = append(calls, fmt.Sprintf("%s ×%d", name, turn.ToolCalls[name]))
+	}
+	return strings.Join(calls, ", ")
+}
+
+// headline returns the first line of prompt, shortened to maxPromptLength.
+func headline(prompt string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(prompt), "\n")
+	if len(line) > maxPromptLength {
+		line = line[:maxPromptLength-3] + "..."
+	}
+	return line
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func orBuiltIn(path string) string {
+	if path == "" {
+		return "built-in"
+	}
+	return path
+}
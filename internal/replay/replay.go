@@ -0,0 +1,221 @@
+// Package replay compares the transcript of a session with a replay of its
+// user turns, such as one against a different model or system prompt.
+package replay
+
+import (
+	"encoding/json"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// editTools are the tools whose file_path parameter is a file they change.
+var editTools = []string{tools.EditToolName, tools.EditSymbolToolName, tools.MultiEditToolName, tools.WriteToolName}
+
+// Turn is a user prompt and everything the agent did in response.
+type Turn struct {
+	Prompt string `json:"prompt"`
+	// Attachments is the number of files attached to the prompt. They are
+	// not replayed.
+	Attachments int `json:"attachments,omitempty"`
+	// Response is the text of the last assistant message of the turn.
+	Response string `json:"response"`
+	// ToolCalls counts the calls of each tool.
+	ToolCalls map[string]int `json:"tool_calls"`
+	// FailedToolCalls is the number of tool calls that returned an error.
+	FailedToolCalls int `json:"failed_tool_calls"`
+	// Files are the files changed with edit, multiedit and write.
+	Files []string `json:"files"`
+}
+
+// Run is a session to compare.
+type Run struct {
+	SessionID        string  `json:"session_id"`
+	Title            string  `json:"title"`
+	Model            string  `json:"model"`
+	PromptTemplate   string  `json:"prompt_template,omitempty"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+	Turns            []Turn  `json:"turns"`
+	Error            string  `json:"error,omitempty"`
+}
+
+// TurnDiff compares a turn of the original session with its replay.
+type TurnDiff struct {
+	Index    int    `json:"index"`
+	Prompt   string `json:"prompt"`
+	Original Turn   `json:"original"`
+	Replay   *Turn  `json:"replay,omitempty"`
+	// Similarity is the share of words the two responses have in common,
+	// from 0 to 1.
+	Similarity float64 `json:"similarity"`
+	// ToolCallDelta is, per tool, how many more calls the replay made.
+	ToolCallDelta map[string]int `json:"tool_call_delta,omitempty"`
+	// FilesOnlyOriginal and FilesOnlyReplay are the changed files the other
+	// run did not change.
+	FilesOnlyOriginal []string `json:"files_only_original,omitempty"`
+	FilesOnlyReplay   []string `json:"files_only_replay,omitempty"`
+}
+
+// Report is the comparison of a session with its replay.
+type Report struct {
+	Original Run        `json:"original"`
+	Replay   Run        `json:"replay"`
+	Turns    []TurnDiff `json:"turns"`
+}
+
+// Turns splits the messages of a session into turns, each starting at a user
+// message. Summaries left by compaction are skipped.
+func Turns(msgs []message.Message) []Turn {
+	var turns []Turn
+	failed := make(map[string]bool)
+	for _, msg := range msgs {
+		for _, result := range msg.ToolResults() {
+			if result.IsError {
+				failed[result.ToolCallID] = true
+			}
+		}
+	}
+
+	for _, msg := range msgs {
+		if msg.IsSummaryMessage {
+			continue
+		}
+		switch msg.Role {
+		case message.User:
+			turns = append(turns, Turn{
+				Prompt:      msg.Content().Text,
+				Attachments: len(msg.BinaryContent()),
+				ToolCalls:   map[string]int{},
+				Files:       []string{},
+			})
+		case message.Assistant:
+			if len(turns) == 0 {
+				continue
+			}
+			turn := &turns[len(turns)-1]
+			if text := strings.TrimSpace(msg.Content().Text); text != "" {
+				turn.Response = text
+			}
+			for _, call := range msg.ToolCalls() {
+				turn.ToolCalls[call.Name]++
+				if failed[call.ID] {
+					turn.FailedToolCalls++
+					continue
+				}
+				if path := editedFile(call); path != "" && !slices.Contains(turn.Files, path) {
+					turn.Files = append(turn.Files, path)
+				}
+			}
+		}
+	}
+	return turns
+}
+
+// Model returns the provider and model of the last assistant message, as
+// provider/model.
+func Model(msgs []message.Message) string {
+	for _, msg := range slices.Backward(msgs) {
+		if msg.Role == message.Assistant && msg.Model != "" {
+			if msg.Provider == "" {
+				return msg.Model
+			}
+			return msg.Provider + "/" + msg.Model
+		}
+	}
+	return ""
+}
+
+// editedFile returns the file a tool call changes, if any.
+func editedFile(call message.ToolCall) string {
+	if !slices.Contains(editTools, call.Name) {
+		return ""
+	}
+	var params struct {
+		FilePath string `json:"file_path"`
+	}
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return ""
+	}
+	return params.FilePath
+}
+
+// Compare compares each turn of original with the same turn of replay.
+func Compare(original, replay Run) Report {
+	report := Report{Original: original, Replay: replay, Turns: []TurnDiff{}}
+	for i, turn := range original.Turns {
+		diff := TurnDiff{Index: i + 1, Prompt: turn.Prompt, Original: turn}
+		if i < len(replay.Turns) {
+			other := replay.Turns[i]
+			diff.Replay = &other
+			diff.Similarity = Similarity(turn.Response, other.Response)
+			diff.ToolCallDelta = toolCallDelta(turn.ToolCalls, other.ToolCalls)
+			diff.FilesOnlyOriginal = missing(turn.Files, other.Files)
+			diff.FilesOnlyReplay = missing(other.Files, turn.Files)
+		}
+		report.Turns = append(report.Turns, diff)
+	}
+	return report
+}
+
+// Similarity returns the Jaccard index of the words of a and b, rounded to
+// two decimals. Two empty texts are identical.
+func Similarity(a, b string) float64 {
+	wordsA, wordsB := words(a), words(b)
+	if len(wordsA) == 0 && len(wordsB) == 0 {
+		return 1
+	}
+	var common int
+	for word := range wordsA {
+		if wordsB[word] {
+			common++
+		}
+	}
+	union := len(wordsA) + len(wordsB) - common
+	return math.Round(float64(common)/float64(union)*100) / 100
+}
+
+func words(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		set[word] = true
+	}
+	return set
+}
+
+func toolCallDelta(original, replay map[string]int) map[string]int {
+	delta := make(map[string]int)
+	for name := range maps.Keys(original) {
+		if d := replay[name] - original[name]; d != 0 {
+			delta[name] = d
+		}
+	}
+	for name, n := range replay {
+		if _, ok := original[name]; !ok {
+			delta[name] = n
+		}
+	}
+	if len(delta) == 0 {
+		return nil
+	}
+	return delta
+}
+
+// missing returns the elements of a that are not in b.
+func missing(a, b []string) []string {
+	var out []string
+	for _, s := range a {
+		if !slices.Contains(b, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package replay
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func userMessage(text string) message.Message {
+	return message.Message{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: text}}}
+}
+
+func assistantMessage(text string, calls ...message.ToolCall) message.Message {
+	msg := message.Message{Role: message.Assistant, Model: "gpt-5", Provider: "openai"}
+	if text != "" {
+		msg.Parts = append(msg.Parts, message.TextContent{Text: text})
+	}
+	for _, call := range calls {
+		msg.Parts = append(msg.Parts, call)
+	}
+	return msg
+}
+
+func toolMessage(results ...message.ToolResult) message.Message {
+	msg := message.Message{Role: message.Tool}
+	for _, result := range results {
+		msg.Parts = append(msg.Parts, result)
+	}
+	return msg
+}
+
+func TestTurns(t *testing.T) {
+	t.Parallel()
+
+	msgs := []message.Message{
+		userMessage("fix the bug"),
+		assistantMessage("",
+			message.ToolCall{ID: "1", Name: tools.ViewToolName, Input: `{"file_path":"main.go"}`},
+			message.ToolCall{ID: "2", Name: tools.EditToolName, Input: `{"file_path":"main.go"}`},
+			message.ToolCall{ID: "3", Name: tools.EditToolName, Input: `{"file_path":"util.go"}`},
+		),
+		toolMessage(
+			message.ToolResult{ToolCallID: "1"},
+			message.ToolResult{ToolCallID: "2"},
+			message.ToolResult{ToolCallID: "3", IsError: true},
+		),
+		assistantMessage("Fixed it."),
+		{Role: message.Assistant, IsSummaryMessage: true, Parts: []message.ContentPart{message.TextContent{Text: "summary"}}},
+		userMessage("thanks"),
+		assistantMessage("You're welcome."),
+	}
+
+	turns := Turns(msgs)
+	require.Len(t, turns, 2)
+	require.Equal(t, Turn{
+		Prompt:          "fix the bug",
+		Response:        "Fixed it.",
+		ToolCalls:       map[string]int{tools.ViewToolName: 1, tools.EditToolName: 2},
+		FailedToolCalls: 1,
+		Files:           []string{"main.go"},
+	}, turns[0])
+	require.Equal(t, "You're welcome.", turns[1].Response)
+	require.Equal(t, "openai/gpt-5", Model(msgs))
+}
+
+func TestSimilarity(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, 1.0, Similarity("", ""))
+	require.Equal(t, 1.0, Similarity("Fixed the bug.", "fixed the BUG"))
+	require.Equal(t, 0.5, Similarity("fixed the bug", "fixed the test, bug and docs"))
+	require.Equal(t, 0.0, Similarity("yes", "no"))
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	original := Run{Turns: []Turn{
+		{Prompt: "one", Response: "done", ToolCalls: map[string]int{"edit": 2, "view": 1}, Files: []string{"a.go", "b.go"}},
+		{Prompt: "two", Response: "ok"},
+	}}
+	replay := Run{Turns: []Turn{
+		{Prompt: "one", Response: "done", ToolCalls: map[string]int{"edit": 2, "bash": 1}, Files: []string{"a.go", "c.go"}},
+	}, Error: "turn 2: boom"}
+
+	report := Compare(original, replay)
+	require.Len(t, report.Turns, 2)
+
+	first := report.Turns[0]
+	require.Equal(t, 1.0, first.Similarity)
+	require.Equal(t, map[string]int{"view": -1, "bash": 1}, first.ToolCallDelta)
+	require.Equal(t, []string{"b.go"}, first.FilesOnlyOriginal)
+	require.Equal(t, []string{"c.go"}, first.FilesOnlyReplay)
+	require.Nil(t, report.Turns[1].Replay)
+
+	md := report.Markdown()
+	require.Contains(t, md, "The replay stopped early: turn 2: boom")
+	require.Contains(t, md, "- Tool calls: edit ×2, view ×1 → bash ×1, edit ×2")
+	require.Contains(t, md, "- Changed only in the replay: c.go")
+	require.Contains(t, md, "## Turn 2: two\n\nNot replayed.")
+}
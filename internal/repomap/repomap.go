@@ -0,0 +1,194 @@
+// Package repomap condenses the layout of a project into a short map of its
+// files and of the exported symbols of its Go sources, which the agent gets
+// in its system prompt so it knows where things are without listing
+// directories first.
+package repomap
+
+import (
+	"cmp"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+const (
+	defaultMaxFiles = 500
+	defaultMaxBytes = 16000
+)
+
+// Map renders the map of the project in a directory. A nil *Map renders
+// nothing.
+type Map struct {
+	dir      string
+	maxFiles int
+	maxBytes int
+
+	mu sync.Mutex
+	// files holds the symbols of the Go files parsed so far, by path.
+	files map[string]goFile
+}
+
+type goFile struct {
+	size    int64
+	modTime time.Time
+	symbols []string
+}
+
+// New returns the map of the working directory configured in cfg, or nil
+// when it is disabled.
+func New(cfg *config.Config) *Map {
+	opts := cfg.Options.RepoMap
+	if opts == nil || !opts.Enabled {
+		return nil
+	}
+	return newMap(cfg.WorkingDir(), opts.MaxFiles, opts.MaxBytes)
+}
+
+func newMap(dir string, maxFiles, maxBytes int) *Map {
+	return &Map{
+		dir:      dir,
+		maxFiles: cmp.Or(maxFiles, defaultMaxFiles),
+		maxBytes: cmp.Or(maxBytes, defaultMaxBytes),
+		files:    make(map[string]goFile),
+	}
+}
+
+// String returns the map of the project as it is now: its files as an
+// indented tree, ignored files left out, each Go file followed by its
+// exported symbols. Only the Go files changed since the last call are
+// parsed again, and an unchanged project renders the same map.
+func (m *Map) String() string {
+	if m == nil {
+		return ""
+	}
+	paths, truncated, err := fsext.ListDirectory(m.dir, nil, 0, m.maxFiles)
+	if err != nil {
+		return ""
+	}
+	rels := make([]string, 0, len(paths))
+	for _, path := range paths {
+		rel, err := filepath.Rel(m.dir, path)
+		if err != nil || rel == "." {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasSuffix(path, string(filepath.Separator)) {
+			rel += "/"
+		}
+		rels = append(rels, rel)
+	}
+	slices.Sort(rels)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seen := make(map[string]bool)
+	var sb strings.Builder
+	for _, rel := range rels {
+		name := strings.TrimSuffix(rel, "/")
+		depth := strings.Count(name, "/")
+		line := strings.Repeat("  ", depth) + filepath.Base(name)
+		if strings.HasSuffix(rel, "/") {
+			line += "/"
+		} else if isGoSource(rel) {
+			seen[rel] = true
+			if symbols := m.symbols(rel); len(symbols) > 0 {
+				line += ": " + strings.Join(symbols, ", ")
+			}
+		}
+		if sb.Len()+len(line)+1 > m.maxBytes {
+			truncated = true
+			break
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	for path := range m.files {
+		if !seen[path] {
+			delete(m.files, path)
+		}
+	}
+	if truncated {
+		sb.WriteString("…\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func isGoSource(rel string) bool {
+	return strings.HasSuffix(rel, ".go") && !strings.HasSuffix(rel, "_test.go")
+}
+
+// symbols returns the exported symbols of the Go file at rel, parsing it
+// when it changed since it was last parsed.
+func (m *Map) symbols(rel string) []string {
+	path := filepath.Join(m.dir, filepath.FromSlash(rel))
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if f, ok := m.files[rel]; ok && f.size == info.Size() && f.modTime.Equal(info.ModTime()) {
+		return f.symbols
+	}
+	symbols := goSymbols(path)
+	m.files[rel] = goFile{size: info.Size(), modTime: info.ModTime(), symbols: symbols}
+	return symbols
+}
+
+// goSymbols lists the exported types, functions and methods of the Go file
+// at path, methods as Type.Method.
+func goSymbols(path string) []string {
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	var symbols []string
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if !decl.Name.IsExported() {
+				continue
+			}
+			if decl.Recv == nil || len(decl.Recv.List) == 0 {
+				symbols = append(symbols, decl.Name.Name+"()")
+				continue
+			}
+			if recv := receiverName(decl.Recv.List[0].Type); ast.IsExported(recv) {
+				symbols = append(symbols, recv+"."+decl.Name.Name+"()")
+			}
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				if spec, ok := spec.(*ast.TypeSpec); ok && spec.Name.IsExported() {
+					symbols = append(symbols, spec.Name.Name)
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+// receiverName returns the name of the type of a method receiver.
+func receiverName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(expr.X)
+	case *ast.IndexExpr:
+		return receiverName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,66 @@
+package repomap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapString(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("go.mod", "module example.com/app\n")
+	write("cmd/app/main.go", "package main\n\nfunc main() {}\n")
+	write("store/store.go", `package store
+
+type Store struct{}
+
+type item struct{}
+
+type List[T any] []T
+
+func New() *Store { return nil }
+
+func (s *Store) Get(key string) string { return "" }
+
+func (s *Store) put() {}
+
+func (l List[T]) Len() int { return 0 }
+
+func (i item) Name() string { return "" }
+`)
+	write("store/store_test.go", "package store\n\nfunc TestStore() {}\n")
+	write("store/broken.go", "package store\n\nfunc (\n")
+
+	m := newMap(dir, 0, 0)
+	require.Equal(t, `cmd/
+  app/
+    main.go
+go.mod
+store/
+  broken.go
+  store.go: Store, List, New(), Store.Get(), List.Len()
+  store_test.go`, m.String())
+
+	later := time.Now().Add(time.Minute)
+	write("store/store.go", "package store\n\nfunc Open() {}\n")
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "store/store.go"), later, later))
+	require.NoError(t, os.Remove(filepath.Join(dir, "store/broken.go")))
+	require.Contains(t, m.String(), "  store.go: Open()\n")
+	require.NotContains(t, m.files, "store/broken.go")
+
+	m = newMap(dir, 0, 24)
+	require.Equal(t, "cmd/\n  app/\n    main.go\n…", m.String())
+
+	var nilMap *Map
+	require.Empty(t, nilMap.String())
+}
@@ -0,0 +1,224 @@
+package retry
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"charm.land/fantasy"
+)
+
+// retryingModel retries the requests of a model that fail with a transient
+// error.
+type retryingModel struct {
+	fantasy.LanguageModel
+	opts  Options
+	sleep func(context.Context, time.Duration) error
+}
+
+// WrapModel retries the requests sent to model that fail with a transient
+// error, waiting longer after each failure. It returns model as is when
+// retries are disabled.
+func WrapModel(model fantasy.LanguageModel, opts Options) fantasy.LanguageModel {
+	if model == nil || opts.MaxRetries <= 0 {
+		return model
+	}
+	return &retryingModel{LanguageModel: model, opts: opts, sleep: sleep}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// wait waits before the given retry of a request that failed with err. It
+// returns false when the request should fail instead.
+func (m *retryingModel) wait(ctx context.Context, err error, retry int) bool {
+	if retry > m.opts.MaxRetries || ctx.Err() != nil || !IsTransient(err) {
+		return false
+	}
+	delay := m.opts.delay(err, retry)
+	slog.Warn("Model request failed, retrying",
+		"provider", m.Provider(), "model", m.Model(),
+		"retry", retry, "max_retries", m.opts.MaxRetries, "delay", delay, "error", err)
+	return m.sleep(ctx, delay) == nil
+}
+
+func (m *retryingModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	for retry := 1; ; retry++ {
+		resp, err := m.LanguageModel.Generate(ctx, call)
+		if err == nil || !m.wait(ctx, err, retry) {
+			return resp, err
+		}
+	}
+}
+
+// open starts a stream, retrying while it fails to start.
+func (m *retryingModel) open(ctx context.Context, call fantasy.Call, retries *int) (fantasy.StreamResponse, error) {
+	for {
+		stream, err := m.LanguageModel.Stream(ctx, call)
+		if err == nil {
+			return stream, nil
+		}
+		*retries++
+		if !m.wait(ctx, err, *retries) {
+			return nil, err
+		}
+	}
+}
+
+func (m *retryingModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	var retries int
+	stream, err := m.open(ctx, call, &retries)
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(fantasy.StreamPart) bool) {
+		var s streamState
+		for {
+			stopped, err := s.forward(stream, yield)
+			if stopped || err == nil {
+				return
+			}
+			retries++
+			if stream, err = m.resume(ctx, call, &s, err, &retries); err != nil {
+				yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeError, Error: err})
+				return
+			}
+		}
+	}, nil
+}
+
+// resume sends the request of a stream that failed with err again. The new
+// stream carries on from the received text when the provider allows it and
+// nothing but text was received; otherwise it starts over, after the
+// received output is discarded. A stream that produced tool calls, which
+// may have run already, is never sent again.
+func (m *retryingModel) resume(ctx context.Context, call fantasy.Call, s *streamState, err error, retries *int) (fantasy.StreamResponse, error) {
+	var discard func() error
+	prefill := false
+	switch {
+	case s.committed:
+		return nil, err
+	case !s.received:
+	case s.textOnly && m.opts.Prefill && strings.TrimSpace(s.text.String()) != "":
+		prefill = true
+	default:
+		if discard = restartFromContext(ctx); discard == nil {
+			return nil, err
+		}
+	}
+	if !m.wait(ctx, err, *retries) {
+		return nil, err
+	}
+	if discard != nil {
+		if discardErr := discard(); discardErr != nil {
+			return nil, discardErr
+		}
+		s.reset()
+	}
+	if prefill {
+		// Providers reject a trailing assistant message ending with white
+		// space, so it is left out and not repeated by the continuation.
+		text := s.text.String()
+		prefix := strings.TrimRightFunc(text, unicode.IsSpace)
+		call.Prompt = append(slices.Clone(call.Prompt), fantasy.Message{
+			Role:    fantasy.MessageRoleAssistant,
+			Content: []fantasy.MessagePart{fantasy.TextPart{Text: prefix}},
+		})
+		s.resuming = true
+		s.trimSpace = prefix != text
+	}
+	return m.open(ctx, call, retries)
+}
+
+// streamState is what was received of a stream across its retries.
+type streamState struct {
+	// received is set once any output was forwarded.
+	received bool
+	// textOnly is set while the output forwarded is a single text block
+	// that has not ended yet.
+	textOnly bool
+	// committed is set once a block ended or a tool call started; such
+	// output can neither be taken back nor carried on from.
+	committed bool
+	textID    string
+	text      strings.Builder
+	warned    bool
+	// resuming is set when the current stream carries on from text, and
+	// trimSpace until the white space it starts with is dropped.
+	resuming  bool
+	trimSpace bool
+}
+
+func (s *streamState) reset() {
+	warned := s.warned
+	*s = streamState{warned: warned}
+}
+
+// forward yields the parts of stream until it fails, ends or the consumer
+// stops. It reports whether the consumer stopped, and returns the error the
+// stream failed with.
+func (s *streamState) forward(stream fantasy.StreamResponse, yield func(fantasy.StreamPart) bool) (bool, error) {
+	for part := range stream {
+		switch part.Type {
+		case fantasy.StreamPartTypeError:
+			return false, part.Error
+		case fantasy.StreamPartTypeWarnings:
+			if s.warned {
+				continue
+			}
+			s.warned = true
+		case fantasy.StreamPartTypeTextStart:
+			if s.resuming && s.textID != "" {
+				continue
+			}
+			s.textOnly = !s.received
+			s.textID = part.ID
+			s.text.Reset()
+		case fantasy.StreamPartTypeTextDelta:
+			if s.resuming && s.textID != "" {
+				part.ID = s.textID
+				if s.trimSpace {
+					if part.Delta = strings.TrimLeftFunc(part.Delta, unicode.IsSpace); part.Delta == "" {
+						continue
+					}
+					s.trimSpace = false
+				}
+			}
+			if part.ID == s.textID {
+				s.text.WriteString(part.Delta)
+			}
+		case fantasy.StreamPartTypeTextEnd:
+			if s.resuming && s.textID != "" {
+				part.ID = s.textID
+			}
+			s.committed = true
+			s.resuming = false
+			s.trimSpace = false
+			s.textID = ""
+		case fantasy.StreamPartTypeReasoningStart, fantasy.StreamPartTypeReasoningDelta:
+			s.textOnly = false
+		case fantasy.StreamPartTypeFinish:
+		default:
+			s.textOnly = false
+			s.committed = true
+		}
+		if part.Type != fantasy.StreamPartTypeWarnings {
+			s.received = true
+		}
+		if !yield(part) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
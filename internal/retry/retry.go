@@ -0,0 +1,184 @@
+// Package retry retries model requests that fail with a transient error,
+// resuming or restarting streams interrupted halfway instead of failing the
+// whole turn.
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+const (
+	defaultMaxRetries   = 4
+	defaultInitialDelay = time.Second
+	defaultMaxDelay     = 30 * time.Second
+)
+
+// Options configures the retries of one model.
+type Options struct {
+	MaxRetries   int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	// Prefill is set for providers that carry on from a trailing assistant
+	// message, which lets an interrupted answer be resumed where it stopped
+	// rather than generated again.
+	Prefill bool
+}
+
+// NewOptions returns the options set in cfg, with defaults for the ones left
+// out. cfg may be nil.
+func NewOptions(cfg *config.RetryOptions, prefill bool) Options {
+	opts := Options{
+		MaxRetries:   defaultMaxRetries,
+		InitialDelay: defaultInitialDelay,
+		MaxDelay:     defaultMaxDelay,
+		Prefill:      prefill,
+	}
+	if cfg == nil {
+		return opts
+	}
+	if cfg.MaxRetries != nil {
+		opts.MaxRetries = max(0, *cfg.MaxRetries)
+	}
+	if cfg.InitialDelayMs > 0 {
+		opts.InitialDelay = time.Duration(cfg.InitialDelayMs) * time.Millisecond
+	}
+	if cfg.MaxDelayMs > 0 {
+		opts.MaxDelay = time.Duration(cfg.MaxDelayMs) * time.Millisecond
+	}
+	return opts
+}
+
+// transientStatusCodes are the HTTP statuses worth trying again: timeouts,
+// rate limits and servers that are down or overloaded (529 is Anthropic's).
+var transientStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusConflict,
+	http.StatusTooEarly,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+	529,
+}
+
+// transientMessages match errors that lost their type on the way, such as
+// the error events providers send in the middle of a stream.
+var transientMessages = []string{
+	"overloaded",
+	"rate limit",
+	"connection reset",
+	"broken pipe",
+	"unexpected eof",
+	"stream error",
+	"internal server error",
+	"bad gateway",
+	"service unavailable",
+	"gateway timeout",
+}
+
+// IsTransient reports whether err is likely to go away when the request is
+// sent again.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var providerErr *fantasy.ProviderError
+	if errors.As(err, &providerErr) {
+		if providerErr.StatusCode != 0 {
+			for _, code := range transientStatusCodes {
+				if providerErr.StatusCode == code {
+					return true
+				}
+			}
+			return false
+		}
+		if providerErr.Cause != nil {
+			err = providerErr.Cause
+		}
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range transientMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns how long to wait before the given retry, counted from 1.
+// The wait doubles with every retry, with some jitter so that clients
+// failing together do not retry together, unless the provider said how
+// long to wait.
+func (o Options) delay(err error, retry int) time.Duration {
+	if d := retryAfter(err); d > 0 {
+		return min(d, o.MaxDelay)
+	}
+	d := o.InitialDelay
+	for i := 1; i < retry && d < o.MaxDelay; i++ {
+		d *= 2
+	}
+	d = min(d, o.MaxDelay)
+	return d + rand.N(d/10+1)
+}
+
+// retryAfter returns the wait asked for by the retry-after-ms or
+// retry-after headers of a provider error, or 0.
+func retryAfter(err error) time.Duration {
+	var providerErr *fantasy.ProviderError
+	if !errors.As(err, &providerErr) || providerErr.ResponseHeaders == nil {
+		return 0
+	}
+	headers := providerErr.ResponseHeaders
+	if v, ok := headers["retry-after-ms"]; ok {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms > 0 {
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	if v, ok := headers["retry-after"]; ok {
+		if s, err := strconv.ParseFloat(v, 64); err == nil && s > 0 {
+			return time.Duration(s * float64(time.Second))
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t)
+		}
+	}
+	return 0
+}
+
+// restartKey is the context key of the function discarding the output of a
+// stream that is about to be generated again.
+type restartKey struct{}
+
+// WithRestart returns a context in which streams that fail after some of
+// their output was received may be generated again from the start: discard
+// is called first to drop what was received. Without it, such streams are
+// only resumed when the provider allows, and fail otherwise.
+func WithRestart(ctx context.Context, discard func() error) context.Context {
+	return context.WithValue(ctx, restartKey{}, discard)
+}
+
+func restartFromContext(ctx context.Context) func() error {
+	discard, _ := ctx.Value(restartKey{}).(func() error)
+	return discard
+}
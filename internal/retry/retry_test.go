@@ -0,0 +1,183 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeModel streams the parts of one attempt per call, failing to start
+// when an attempt has no parts but an error.
+type fakeModel struct {
+	fantasy.LanguageModel
+	attempts []attempt
+	calls    []fantasy.Call
+}
+
+type attempt struct {
+	err   error
+	parts []fantasy.StreamPart
+}
+
+func (m *fakeModel) Provider() string { return "fake" }
+func (m *fakeModel) Model() string    { return "fake" }
+
+func (m *fakeModel) Stream(_ context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	m.calls = append(m.calls, call)
+	a := m.attempts[len(m.calls)-1]
+	if a.err != nil {
+		return nil, a.err
+	}
+	return func(yield func(fantasy.StreamPart) bool) {
+		for _, part := range a.parts {
+			if !yield(part) {
+				return
+			}
+		}
+	}, nil
+}
+
+func wrap(t *testing.T, inner *fakeModel, prefill bool) *retryingModel {
+	t.Helper()
+	m := WrapModel(inner, Options{MaxRetries: 3, InitialDelay: time.Second, MaxDelay: time.Minute, Prefill: prefill}).(*retryingModel)
+	m.sleep = func(context.Context, time.Duration) error { return nil }
+	return m
+}
+
+func collect(t *testing.T, ctx context.Context, m fantasy.LanguageModel) []fantasy.StreamPart {
+	t.Helper()
+	stream, err := m.Stream(ctx, fantasy.Call{Prompt: fantasy.Prompt{fantasy.NewUserMessage("hi")}})
+	require.NoError(t, err)
+	var parts []fantasy.StreamPart
+	for part := range stream {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+func text(id, delta string) fantasy.StreamPart {
+	return fantasy.StreamPart{Type: fantasy.StreamPartTypeTextDelta, ID: id, Delta: delta}
+}
+
+var (
+	textStart = fantasy.StreamPart{Type: fantasy.StreamPartTypeTextStart, ID: "0"}
+	textEnd   = fantasy.StreamPart{Type: fantasy.StreamPartTypeTextEnd, ID: "0"}
+	finish    = fantasy.StreamPart{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonStop}
+	reset     = fantasy.StreamPart{Type: fantasy.StreamPartTypeError, Error: fmt.Errorf("read: %w", syscall.ECONNRESET)}
+)
+
+func TestIsTransient(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, IsTransient(&fantasy.ProviderError{StatusCode: http.StatusTooManyRequests}))
+	require.True(t, IsTransient(&fantasy.ProviderError{StatusCode: 529}))
+	require.True(t, IsTransient(&fantasy.ProviderError{Cause: io.ErrUnexpectedEOF}))
+	require.True(t, IsTransient(reset.Error))
+	require.True(t, IsTransient(errors.New(`received error while streaming: {"type":"overloaded_error"}`)))
+	require.False(t, IsTransient(&fantasy.ProviderError{StatusCode: http.StatusUnauthorized, Message: "overloaded"}))
+	require.False(t, IsTransient(context.Canceled))
+	require.False(t, IsTransient(errors.New("invalid request")))
+}
+
+func TestDelay(t *testing.T) {
+	t.Parallel()
+
+	opts := Options{InitialDelay: time.Second, MaxDelay: 5 * time.Second}
+	require.InDelta(t, float64(time.Second), float64(opts.delay(reset.Error, 1)), float64(time.Second/10))
+	require.InDelta(t, float64(4*time.Second), float64(opts.delay(reset.Error, 3)), float64(time.Second/2))
+	require.InDelta(t, float64(5*time.Second), float64(opts.delay(reset.Error, 8)), float64(time.Second/2))
+
+	limited := &fantasy.ProviderError{StatusCode: http.StatusTooManyRequests, ResponseHeaders: map[string]string{"retry-after": "2"}}
+	require.Equal(t, 2*time.Second, opts.delay(limited, 1))
+}
+
+func TestNewOptions(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, Options{MaxRetries: 4, InitialDelay: time.Second, MaxDelay: 30 * time.Second, Prefill: true}, NewOptions(nil, true))
+
+	none := 0
+	inner := &fakeModel{}
+	require.Same(t, fantasy.LanguageModel(inner), WrapModel(inner, NewOptions(&config.RetryOptions{MaxRetries: &none}, false)))
+}
+
+func TestStreamRetriesBeforeOutput(t *testing.T) {
+	t.Parallel()
+
+	inner := &fakeModel{attempts: []attempt{
+		{err: &fantasy.ProviderError{StatusCode: http.StatusServiceUnavailable}},
+		{parts: []fantasy.StreamPart{reset}},
+		{parts: []fantasy.StreamPart{textStart, text("0", "Hello"), textEnd, finish}},
+	}}
+	parts := collect(t, t.Context(), wrap(t, inner, false))
+	require.Equal(t, []fantasy.StreamPart{textStart, text("0", "Hello"), textEnd, finish}, parts)
+	require.Len(t, inner.calls, 3)
+}
+
+func TestStreamResumesText(t *testing.T) {
+	t.Parallel()
+
+	inner := &fakeModel{attempts: []attempt{
+		{parts: []fantasy.StreamPart{textStart, text("0", "Hello, "), reset}},
+		{parts: []fantasy.StreamPart{
+			{Type: fantasy.StreamPartTypeTextStart, ID: "1"},
+			text("1", " world"),
+			{Type: fantasy.StreamPartTypeTextEnd, ID: "1"},
+			finish,
+		}},
+	}}
+	parts := collect(t, t.Context(), wrap(t, inner, true))
+	require.Equal(t, []fantasy.StreamPart{textStart, text("0", "Hello, "), text("0", "world"), textEnd, finish}, parts)
+
+	require.Len(t, inner.calls, 2)
+	prompt := inner.calls[1].Prompt
+	require.Len(t, prompt, 2)
+	require.Equal(t, fantasy.MessageRoleAssistant, prompt[1].Role)
+	require.Equal(t, []fantasy.MessagePart{fantasy.TextPart{Text: "Hello,"}}, prompt[1].Content)
+}
+
+func TestStreamRestartsAfterDiscarding(t *testing.T) {
+	t.Parallel()
+
+	reasoning := fantasy.StreamPart{Type: fantasy.StreamPartTypeReasoningStart, ID: "r", Delta: "hmm"}
+	inner := &fakeModel{attempts: []attempt{
+		{parts: []fantasy.StreamPart{reasoning, reset}},
+		{parts: []fantasy.StreamPart{textStart, text("0", "Hi"), textEnd, finish}},
+	}}
+
+	// Without a way to drop the reasoning received, the stream fails.
+	parts := collect(t, t.Context(), wrap(t, &fakeModel{attempts: inner.attempts}, true))
+	require.Equal(t, []fantasy.StreamPart{reasoning, reset}, parts)
+
+	var discarded int
+	ctx := WithRestart(t.Context(), func() error {
+		discarded++
+		return nil
+	})
+	parts = collect(t, ctx, wrap(t, inner, true))
+	require.Equal(t, 1, discarded)
+	require.Equal(t, []fantasy.StreamPart{reasoning, textStart, text("0", "Hi"), textEnd, finish}, parts)
+}
+
+func TestStreamKeepsToolCalls(t *testing.T) {
+	t.Parallel()
+
+	call := fantasy.StreamPart{Type: fantasy.StreamPartTypeToolCall, ID: "t", ToolCallName: "bash", ToolCallInput: "{}"}
+	inner := &fakeModel{attempts: []attempt{
+		{parts: []fantasy.StreamPart{call, reset}},
+		{parts: []fantasy.StreamPart{finish}},
+	}}
+	ctx := WithRestart(t.Context(), func() error { return nil })
+	parts := collect(t, ctx, wrap(t, inner, true))
+	require.Equal(t, []fantasy.StreamPart{call, reset}, parts)
+	require.Len(t, inner.calls, 1)
+}
@@ -0,0 +1,196 @@
+// Package scrollback reads the recent output of the user's terminal, from a
+// tmux pane or from a log written by a wrapper such as script(1).
+package scrollback
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	DefaultMaxLines   = 200
+	DefaultMaxBytes   = 16000
+	DefaultTmuxTarget = "{last}"
+
+	// logReadLimit is how much of the end of a log file is read, so a log
+	// that grew for days is not loaded whole.
+	logReadLimit = 1 << 20
+)
+
+// ErrNoSource is returned when there is neither a log file configured nor a
+// tmux session to capture.
+var ErrNoSource = errors.New("no terminal to read: run crush inside tmux or set options.terminal_scrollback.log_file")
+
+// Capture is the tail of a terminal's output.
+type Capture struct {
+	// Source describes where the output was read from.
+	Source string
+	Text   string
+	Lines  int
+	// Truncated is set when older output was dropped to fit the limits.
+	Truncated bool
+}
+
+// Reader captures terminal output within the configured limits.
+type Reader struct {
+	tmuxTarget string
+	logFile    string
+	maxLines   int
+	maxBytes   int
+	tmux       func(ctx context.Context, args ...string) ([]byte, error)
+}
+
+// New returns the reader configured in cfg, or nil when the scrollback is
+// not enabled.
+func New(cfg *config.Config) *Reader {
+	if cfg.Options == nil || cfg.Options.Scrollback == nil || !cfg.Options.Scrollback.Enabled {
+		return nil
+	}
+	opts := cfg.Options.Scrollback
+	r := &Reader{
+		tmuxTarget: opts.TmuxTarget,
+		maxLines:   opts.MaxLines,
+		maxBytes:   opts.MaxBytes,
+		tmux:       runTmux,
+	}
+	if r.tmuxTarget == "" {
+		r.tmuxTarget = DefaultTmuxTarget
+	}
+	if r.maxLines <= 0 {
+		r.maxLines = DefaultMaxLines
+	}
+	if r.maxBytes <= 0 {
+		r.maxBytes = DefaultMaxBytes
+	}
+	if opts.LogFile != "" {
+		r.logFile = home.Long(opts.LogFile)
+		if !filepath.IsAbs(r.logFile) {
+			r.logFile = filepath.Join(cfg.WorkingDir(), r.logFile)
+		}
+	}
+	return r
+}
+
+// MaxLines returns the most lines Read returns.
+func (r *Reader) MaxLines() int {
+	return r.maxLines
+}
+
+// Read returns up to lines of the most recent terminal output, or the
+// configured maximum when lines is zero. Escape sequences are stripped and
+// trailing blank lines dropped.
+func (r *Reader) Read(ctx context.Context, lines int) (Capture, error) {
+	if lines <= 0 || lines > r.maxLines {
+		lines = r.maxLines
+	}
+
+	var out []byte
+	var source string
+	var err error
+	switch {
+	case r.logFile != "":
+		source = r.logFile
+		out, err = readTail(r.logFile, logReadLimit)
+	case os.Getenv("TMUX") != "":
+		source = "tmux pane " + r.tmuxTarget
+		out, err = r.tmux(ctx, "capture-pane", "-p", "-J", "-t", r.tmuxTarget, "-S", "-"+strconv.Itoa(lines))
+	default:
+		return Capture{}, ErrNoSource
+	}
+	if err != nil {
+		return Capture{}, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+
+	text, truncated := clip(clean(string(out)), lines, r.maxBytes)
+	return Capture{
+		Source:    source,
+		Text:      text,
+		Lines:     strings.Count(text, "\n") + min(1, len(text)),
+		Truncated: truncated,
+	}, nil
+}
+
+// clean strips escape sequences and carriage returns, keeping what the line
+// showed last, and drops trailing blank lines.
+func clean(s string) string {
+	s = ansi.Strip(s)
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if j := strings.LastIndex(line, "\r"); j >= 0 {
+			line = line[j+1:]
+		}
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// clip keeps the last lines of s, dropping whole lines from the start until
+// it fits in maxBytes.
+func clip(s string, lines, maxBytes int) (string, bool) {
+	all := strings.Split(s, "\n")
+	truncated := false
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+		truncated = true
+	}
+	size := len(all) - 1
+	for _, line := range all {
+		size += len(line)
+	}
+	for len(all) > 0 && size > maxBytes {
+		size -= len(all[0]) + 1
+		all = all[1:]
+		truncated = true
+	}
+	return strings.Join(all, "\n"), truncated
+}
+
+func readTail(path string, limit int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := max(0, info.Size()-limit)
+	data, err := io.ReadAll(io.NewSectionReader(f, offset, info.Size()-offset))
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		// Skip the partial first line.
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	return data, nil
+}
+
+func runTmux(ctx context.Context, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "tmux", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,69 @@
+package scrollback
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, New(&config.Config{Options: &config.Options{}}))
+
+	path := filepath.Join(t.TempDir(), "term.log")
+	r := New(&config.Config{Options: &config.Options{Scrollback: &config.ScrollbackOptions{Enabled: true, LogFile: path}}})
+	require.NotNil(t, r)
+	require.Equal(t, path, r.logFile)
+	require.Equal(t, DefaultMaxLines, r.MaxLines())
+	require.Equal(t, DefaultTmuxTarget, r.tmuxTarget)
+}
+
+func TestReadLogFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "term.log")
+	log := "Script started\r\n$ go test ./...\r\n\x1b[31mFAIL\x1b[0m parser\r\nprogress 10%\rprogress 100%\r\n$   \r\n\r\n"
+	require.NoError(t, os.WriteFile(path, []byte(log), 0o600))
+
+	r := &Reader{logFile: path, maxLines: 3, maxBytes: 1000}
+	capture, err := r.Read(t.Context(), 0)
+	require.NoError(t, err)
+	require.Equal(t, path, capture.Source)
+	require.Equal(t, "FAIL parser\nprogress 100%\n$", capture.Text)
+	require.Equal(t, 3, capture.Lines)
+	require.True(t, capture.Truncated)
+
+	r.maxBytes = 16
+	capture, err = r.Read(t.Context(), 0)
+	require.NoError(t, err)
+	require.Equal(t, "progress 100%\n$", capture.Text, "older lines are dropped to fit the byte limit")
+
+	r.logFile = filepath.Join(t.TempDir(), "missing.log")
+	_, err = r.Read(t.Context(), 0)
+	require.Error(t, err)
+}
+
+func TestReadTmux(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+
+	var args []string
+	r := &Reader{tmuxTarget: "{last}", maxLines: 50, maxBytes: 1000, tmux: func(_ context.Context, a ...string) ([]byte, error) {
+		args = a
+		return []byte("$ make\nerror: missing rule\n\n\n"), nil
+	}}
+	capture, err := r.Read(t.Context(), 10)
+	require.NoError(t, err)
+	require.Equal(t, "capture-pane -p -J -t {last} -S -10", strings.Join(args, " "))
+	require.Equal(t, "$ make\nerror: missing rule", capture.Text)
+	require.False(t, capture.Truncated)
+
+	t.Setenv("TMUX", "")
+	_, err = r.Read(t.Context(), 10)
+	require.ErrorIs(t, err, ErrNoSource)
+}
@@ -0,0 +1,133 @@
+package session
+
+import (
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Filter narrows a list of sessions. The zero value matches every session.
+type Filter struct {
+	// Tags must all be set on the session.
+	Tags []string
+	// Project matches sessions whose project path contains it, ignoring
+	// case.
+	Project string
+	// After and Before bound the time the session was last updated. After
+	// is inclusive, Before exclusive; the zero time leaves that side open.
+	After  time.Time
+	Before time.Time
+}
+
+// Match reports whether s passes the filter.
+func (f Filter) Match(s Session) bool {
+	for _, tag := range NormalizeTags(f.Tags) {
+		if !slices.Contains(s.Tags, tag) {
+			return false
+		}
+	}
+	if f.Project != "" && !strings.Contains(strings.ToLower(filepath.ToSlash(s.Project)), strings.ToLower(filepath.ToSlash(f.Project))) {
+		return false
+	}
+	updated := time.Unix(s.UpdatedAt, 0)
+	if !f.After.IsZero() && updated.Before(f.After) {
+		return false
+	}
+	if !f.Before.IsZero() && !updated.Before(f.Before) {
+		return false
+	}
+	return true
+}
+
+// Apply returns the sessions that pass the filter, in order.
+func (f Filter) Apply(sessions []Session) []Session {
+	var matched []Session
+	for _, s := range sessions {
+		if f.Match(s) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+// ParseFilter reads filter terms out of a search query and returns the
+// filter along with the remaining text. The terms are:
+//
+//	#tag or tag:name     sessions with the tag
+//	project:name         sessions whose project path contains name
+//	after:DATE           sessions updated on or after DATE
+//	before:DATE          sessions updated before DATE
+//
+// DATE is either 2006-01-02 or a duration back from now such as 12h, 3d or
+// 2w. Terms that do not parse, like a date still being typed, are left in
+// the text.
+func ParseFilter(query string, now time.Time) (Filter, string) {
+	var f Filter
+	var rest []string
+	for _, field := range strings.Fields(query) {
+		name, value, ok := strings.Cut(field, ":")
+		switch {
+		case strings.HasPrefix(field, "#") && len(field) > 1:
+			f.Tags = append(f.Tags, field[1:])
+		case ok && value != "" && name == "tag":
+			f.Tags = append(f.Tags, value)
+		case ok && value != "" && name == "project":
+			f.Project = value
+		case ok && (name == "after" || name == "before"):
+			t, valid := parseFilterTime(value, now)
+			if !valid {
+				rest = append(rest, field)
+			} else if name == "after" {
+				f.After = t
+			} else {
+				f.Before = t
+			}
+		default:
+			rest = append(rest, field)
+		}
+	}
+	return f, strings.Join(rest, " ")
+}
+
+func parseFilterTime(value string, now time.Time) (time.Time, bool) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, now.Location()); err == nil {
+		return t, true
+	}
+	if len(value) < 2 {
+		return time.Time{}, false
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, false
+	}
+	switch value[len(value)-1] {
+	case 'h':
+		return now.Add(-time.Duration(n) * time.Hour), true
+	case 'd':
+		return now.AddDate(0, 0, -n), true
+	case 'w':
+		return now.AddDate(0, 0, -7*n), true
+	}
+	return time.Time{}, false
+}
+
+// NormalizeTags lower-cases the tags, drops a leading # and splits on
+// spaces and commas. The result is sorted and free of duplicates.
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		for field := range strings.FieldsFuncSeq(tag, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		}) {
+			field = strings.ToLower(strings.TrimLeft(field, "#"))
+			if field != "" && !slices.Contains(normalized, field) {
+				normalized = append(normalized, field)
+			}
+		}
+	}
+	slices.Sort(normalized)
+	return normalized
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		query  string
+		filter Filter
+		rest   string
+	}{
+		{query: "parser fix", rest: "parser fix"},
+		{query: "#bug parser tag:Review", filter: Filter{Tags: []string{"bug", "Review"}}, rest: "parser"},
+		{query: "project:api", filter: Filter{Project: "api"}},
+		{query: "after:2026-10-01 before:1w", filter: Filter{
+			After:  time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			Before: time.Date(2026, 10, 7, 12, 0, 0, 0, time.UTC),
+		}},
+		{query: "after:12h", filter: Filter{After: now.Add(-12 * time.Hour)}},
+		{query: "after:2026-1 # project:", rest: "after:2026-1 # project:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			t.Parallel()
+			filter, rest := ParseFilter(tt.query, now)
+			require.Equal(t, tt.filter, filter)
+			require.Equal(t, tt.rest, rest)
+		})
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	t.Parallel()
+
+	updated := time.Date(2026, 10, 10, 9, 0, 0, 0, time.UTC)
+	s := Session{Project: "/home/me/src/API", Tags: []string{"bug", "review"}, UpdatedAt: updated.Unix()}
+
+	require.True(t, Filter{}.Match(s))
+	require.True(t, Filter{Tags: []string{"#Bug"}}.Match(s))
+	require.False(t, Filter{Tags: []string{"bug", "docs"}}.Match(s))
+	require.True(t, Filter{Project: "src/api"}.Match(s))
+	require.False(t, Filter{Project: "web"}.Match(s))
+	require.True(t, Filter{After: updated, Before: updated.Add(time.Hour)}.Match(s))
+	require.False(t, Filter{Before: updated}.Match(s), "before is exclusive")
+	require.False(t, Filter{After: updated.Add(time.Second)}.Match(s))
+}
+
+func TestNormalizeTags(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"api", "bug", "review"}, NormalizeTags([]string{"#Bug review,api", " bug ", ""}))
+	require.Empty(t, NormalizeTags(nil))
+}
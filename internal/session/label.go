@@ -0,0 +1,95 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/db"
+)
+
+// Usage is what one request to a model cost.
+type Usage struct {
+	SessionID        string
+	Model            string
+	Provider         string
+	PromptTokens     int64
+	CompletionTokens int64
+	Cost             float64
+}
+
+// LabelUsage is the usage of the turns sent under one label. Turns sent
+// without a label have an empty Label.
+type LabelUsage struct {
+	Label            string
+	SessionCount     int64
+	RequestCount     int64
+	PromptTokens     int64
+	CompletionTokens int64
+	Cost             float64
+}
+
+// SetLabel sets the label the next turns of the session are counted under,
+// such as a ticket ID. An empty label stops labeling them.
+func (s *service) SetLabel(ctx context.Context, sessionID, label string) error {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return s.q.DeleteSessionLabel(ctx, sessionID)
+	}
+	return s.q.SetSessionLabel(ctx, db.SetSessionLabelParams{SessionID: sessionID, Label: label})
+}
+
+// Label returns the label the turns of the session are counted under, or
+// an empty string when there is none.
+func (s *service) Label(ctx context.Context, sessionID string) (string, error) {
+	label, err := s.q.GetSessionLabel(ctx, sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return label, err
+}
+
+// RecordUsage records a request under the current label of its session.
+// Requests of sub-agents count for the session that started them, so they
+// share its label.
+func (s *service) RecordUsage(ctx context.Context, usage Usage) error {
+	sessionID := usage.SessionID
+	for {
+		dbSession, err := s.q.GetSessionByID(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+		if !dbSession.ParentSessionID.Valid || dbSession.ParentSessionID.String == "" {
+			break
+		}
+		sessionID = dbSession.ParentSessionID.String
+	}
+	label, err := s.Label(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	return s.q.CreateUsageRecord(ctx, db.CreateUsageRecordParams{
+		SessionID:        sessionID,
+		Label:            label,
+		Model:            usage.Model,
+		Provider:         usage.Provider,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Cost:             usage.Cost,
+	})
+}
+
+// UsageByLabel returns the recorded usage grouped by label, most expensive
+// first.
+func (s *service) UsageByLabel(ctx context.Context) ([]LabelUsage, error) {
+	rows, err := s.q.GetUsageByLabel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usage := make([]LabelUsage, 0, len(rows))
+	for _, row := range rows {
+		usage = append(usage, LabelUsage(row))
+	}
+	return usage, nil
+}
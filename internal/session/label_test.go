@@ -0,0 +1,45 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageByLabel(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	svc := NewService(db.New(conn), conn, "")
+
+	sess, err := svc.Create(t.Context(), "Work")
+	require.NoError(t, err)
+	task, err := svc.CreateTaskSession(t.Context(), "call-1", sess.ID, "Search")
+	require.NoError(t, err)
+
+	label, err := svc.Label(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Empty(t, label)
+	require.NoError(t, svc.RecordUsage(t.Context(), Usage{SessionID: sess.ID, PromptTokens: 10, CompletionTokens: 1, Cost: 0.01}))
+
+	require.NoError(t, svc.SetLabel(t.Context(), sess.ID, " JIRA-123 "))
+	label, err = svc.Label(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, "JIRA-123", label)
+	require.NoError(t, svc.RecordUsage(t.Context(), Usage{SessionID: sess.ID, PromptTokens: 100, CompletionTokens: 10, Cost: 0.5}))
+	// Sub-agents share the label of the session that started them.
+	require.NoError(t, svc.RecordUsage(t.Context(), Usage{SessionID: task.ID, PromptTokens: 50, CompletionTokens: 5, Cost: 0.25}))
+
+	require.NoError(t, svc.SetLabel(t.Context(), sess.ID, ""))
+	require.NoError(t, svc.RecordUsage(t.Context(), Usage{SessionID: sess.ID, PromptTokens: 10, CompletionTokens: 1, Cost: 0.01}))
+
+	usage, err := svc.UsageByLabel(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []LabelUsage{
+		{Label: "JIRA-123", SessionCount: 1, RequestCount: 2, PromptTokens: 150, CompletionTokens: 15, Cost: 0.75},
+		{Label: "", SessionCount: 1, RequestCount: 2, PromptTokens: 20, CompletionTokens: 2, Cost: 0.02},
+	}, usage)
+}
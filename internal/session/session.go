@@ -45,9 +45,17 @@ type Session struct {
 	MessageCount     int64
 	PromptTokens     int64
 	CompletionTokens int64
+	// ReasoningTokens is the total of the output tokens spent reasoning
+	// over the whole session.
+	ReasoningTokens  int64
 	SummaryMessageID string
 	Cost             float64
 	Todos            []Todo
+	ChatOnly         bool
+	Project          string
+	Tags             []string
+	Pinned           bool
+	Translate        bool
 	CreatedAt        int64
 	UpdatedAt        int64
 }
@@ -61,6 +69,15 @@ type Service interface {
 	List(ctx context.Context) ([]Session, error)
 	Save(ctx context.Context, session Session) (Session, error)
 	UpdateTitleAndUsage(ctx context.Context, sessionID, title string, promptTokens, completionTokens int64, cost float64) error
+	SetChatOnly(ctx context.Context, sessionID string, chatOnly bool) (Session, error)
+	Rename(ctx context.Context, sessionID, title string) (Session, error)
+	SetTags(ctx context.Context, sessionID string, tags []string) (Session, error)
+	SetPinned(ctx context.Context, sessionID string, pinned bool) (Session, error)
+	SetTranslate(ctx context.Context, sessionID string, translate bool) (Session, error)
+	SetLabel(ctx context.Context, sessionID, label string) error
+	Label(ctx context.Context, sessionID string) (string, error)
+	RecordUsage(ctx context.Context, usage Usage) error
+	UsageByLabel(ctx context.Context) ([]LabelUsage, error)
 	Delete(ctx context.Context, id string) error
 
 	// Agent tool session management
@@ -71,14 +88,16 @@ type Service interface {
 
 type service struct {
 	*pubsub.Broker[Session]
-	db *sql.DB
-	q  *db.Queries
+	db      *sql.DB
+	q       *db.Queries
+	project string
 }
 
 func (s *service) Create(ctx context.Context, title string) (Session, error) {
 	dbSession, err := s.q.CreateSession(ctx, db.CreateSessionParams{
-		ID:    uuid.New().String(),
-		Title: title,
+		ID:      uuid.New().String(),
+		Title:   title,
+		Project: s.project,
 	})
 	if err != nil {
 		return Session{}, err
@@ -94,6 +113,7 @@ func (s *service) CreateTaskSession(ctx context.Context, toolCallID, parentSessi
 		ID:              toolCallID,
 		ParentSessionID: sql.NullString{String: parentSessionID, Valid: true},
 		Title:           title,
+		Project:         s.project,
 	})
 	if err != nil {
 		return Session{}, err
@@ -108,6 +128,7 @@ func (s *service) CreateTitleSession(ctx context.Context, parentSessionID string
 		ID:              "title-" + parentSessionID,
 		ParentSessionID: sql.NullString{String: parentSessionID, Valid: true},
 		Title:           "Generate a title",
+		Project:         s.project,
 	})
 	if err != nil {
 		return Session{}, err
@@ -154,7 +175,7 @@ func (s *service) Get(ctx context.Context, id string) (Session, error) {
 	if err != nil {
 		return Session{}, err
 	}
-	return s.fromDBItem(dbSession), nil
+	return s.withTags(ctx, s.fromDBItem(dbSession))
 }
 
 func (s *service) Save(ctx context.Context, session Session) (Session, error) {
@@ -168,6 +189,7 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 		Title:            session.Title,
 		PromptTokens:     session.PromptTokens,
 		CompletionTokens: session.CompletionTokens,
+		ReasoningTokens:  session.ReasoningTokens,
 		SummaryMessageID: sql.NullString{
 			String: session.SummaryMessageID,
 			Valid:  session.SummaryMessageID != "",
@@ -181,7 +203,10 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 	if err != nil {
 		return Session{}, err
 	}
-	session = s.fromDBItem(dbSession)
+	session, err = s.withTags(ctx, s.fromDBItem(dbSession))
+	if err != nil {
+		return Session{}, err
+	}
 	s.Publish(pubsub.UpdatedEvent, session)
 	return session, nil
 }
@@ -198,18 +223,154 @@ func (s *service) UpdateTitleAndUsage(ctx context.Context, sessionID, title stri
 	})
 }
 
+// SetChatOnly turns chat-only mode on or off for a session. It is kept out
+// of Save so saving a stale copy of the session cannot undo it.
+func (s *service) SetChatOnly(ctx context.Context, sessionID string, chatOnly bool) (Session, error) {
+	var value int64
+	if chatOnly {
+		value = 1
+	}
+	dbSession, err := s.q.UpdateSessionChatOnly(ctx, db.UpdateSessionChatOnlyParams{
+		ID:       sessionID,
+		ChatOnly: value,
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session, err := s.withTags(ctx, s.fromDBItem(dbSession))
+	if err != nil {
+		return Session{}, err
+	}
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// Rename sets the title of a session. Like SetChatOnly, it leaves the other
+// fields alone.
+func (s *service) Rename(ctx context.Context, sessionID, title string) (Session, error) {
+	dbSession, err := s.q.UpdateSessionTitle(ctx, db.UpdateSessionTitleParams{
+		ID:    sessionID,
+		Title: title,
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session, err := s.withTags(ctx, s.fromDBItem(dbSession))
+	if err != nil {
+		return Session{}, err
+	}
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// SetPinned pins a session to the top of [Service.List], or unpins it.
+func (s *service) SetPinned(ctx context.Context, sessionID string, pinned bool) (Session, error) {
+	var value int64
+	if pinned {
+		value = 1
+	}
+	dbSession, err := s.q.UpdateSessionPinned(ctx, db.UpdateSessionPinnedParams{
+		ID:     sessionID,
+		Pinned: value,
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session, err := s.withTags(ctx, s.fromDBItem(dbSession))
+	if err != nil {
+		return Session{}, err
+	}
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// SetTranslate turns the translation of the prompts and replies of a
+// session on or off. Like chat-only mode, it is kept out of Save.
+func (s *service) SetTranslate(ctx context.Context, sessionID string, translate bool) (Session, error) {
+	var value int64
+	if translate {
+		value = 1
+	}
+	dbSession, err := s.q.UpdateSessionTranslate(ctx, db.UpdateSessionTranslateParams{
+		ID:        sessionID,
+		Translate: value,
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session, err := s.withTags(ctx, s.fromDBItem(dbSession))
+	if err != nil {
+		return Session{}, err
+	}
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
+// SetTags replaces the tags of a session. The tags are normalized with
+// [NormalizeTags], so an empty list removes them all.
+func (s *service) SetTags(ctx context.Context, sessionID string, tags []string) (Session, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Session{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	qtx := s.q.WithTx(tx)
+	dbSession, err := qtx.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return Session{}, err
+	}
+	if err := qtx.DeleteSessionTags(ctx, sessionID); err != nil {
+		return Session{}, fmt.Errorf("deleting session tags: %w", err)
+	}
+	tags = NormalizeTags(tags)
+	for _, tag := range tags {
+		if err := qtx.AddSessionTag(ctx, db.AddSessionTagParams{SessionID: sessionID, Tag: tag}); err != nil {
+			return Session{}, fmt.Errorf("adding session tag: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return Session{}, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	session := s.fromDBItem(dbSession)
+	session.Tags = tags
+	s.Publish(pubsub.UpdatedEvent, session)
+	return session, nil
+}
+
 func (s *service) List(ctx context.Context) ([]Session, error) {
 	dbSessions, err := s.q.ListSessions(ctx)
 	if err != nil {
 		return nil, err
 	}
+	dbTags, err := s.q.ListAllSessionTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string][]string)
+	for _, t := range dbTags {
+		tags[t.SessionID] = append(tags[t.SessionID], t.Tag)
+	}
 	sessions := make([]Session, len(dbSessions))
 	for i, dbSession := range dbSessions {
 		sessions[i] = s.fromDBItem(dbSession)
+		sessions[i].Tags = tags[dbSession.ID]
 	}
 	return sessions, nil
 }
 
+func (s *service) withTags(ctx context.Context, session Session) (Session, error) {
+	tags, err := s.q.ListSessionTags(ctx, session.ID)
+	if err != nil {
+		return Session{}, fmt.Errorf("listing session tags: %w", err)
+	}
+	if len(tags) > 0 {
+		session.Tags = tags
+	}
+	return session, nil
+}
+
 func (s service) fromDBItem(item db.Session) Session {
 	todos, err := unmarshalTodos(item.Todos.String)
 	if err != nil {
@@ -222,9 +383,14 @@ func (s service) fromDBItem(item db.Session) Session {
 		MessageCount:     item.MessageCount,
 		PromptTokens:     item.PromptTokens,
 		CompletionTokens: item.CompletionTokens,
+		ReasoningTokens:  item.ReasoningTokens,
 		SummaryMessageID: item.SummaryMessageID.String,
 		Cost:             item.Cost,
 		Todos:            todos,
+		ChatOnly:         item.ChatOnly != 0,
+		Project:          item.Project,
+		Pinned:           item.Pinned != 0,
+		Translate:        item.Translate != 0,
 		CreatedAt:        item.CreatedAt,
 		UpdatedAt:        item.UpdatedAt,
 	}
@@ -252,12 +418,15 @@ func unmarshalTodos(data string) ([]Todo, error) {
 	return todos, nil
 }
 
-func NewService(q *db.Queries, conn *sql.DB) Service {
+// NewService returns a session service. project is recorded on the sessions
+// it creates, usually the working directory.
+func NewService(q *db.Queries, conn *sql.DB, project string) Service {
 	broker := pubsub.NewBroker[Session]()
 	return &service{
-		Broker: broker,
-		db:     conn,
-		q:      q,
+		Broker:  broker,
+		db:      conn,
+		q:       q,
+		project: project,
 	}
 }
 
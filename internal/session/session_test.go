@@ -0,0 +1,184 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetChatOnly(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	svc := NewService(db.New(conn), conn, "")
+
+	created, err := svc.Create(t.Context(), "Chat")
+	require.NoError(t, err)
+	require.False(t, created.ChatOnly)
+
+	updated, err := svc.SetChatOnly(t.Context(), created.ID, true)
+	require.NoError(t, err)
+	require.True(t, updated.ChatOnly)
+
+	// Saving a copy from before the switch must not undo it.
+	created.Title = "Renamed"
+	_, err = svc.Save(t.Context(), created)
+	require.NoError(t, err)
+
+	got, err := svc.Get(t.Context(), created.ID)
+	require.NoError(t, err)
+	require.True(t, got.ChatOnly)
+	require.Equal(t, "Renamed", got.Title)
+
+	got, err = svc.SetChatOnly(t.Context(), created.ID, false)
+	require.NoError(t, err)
+	require.False(t, got.ChatOnly)
+}
+
+func TestRename(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	svc := NewService(db.New(conn), conn, "")
+	events := svc.Subscribe(t.Context())
+
+	created, err := svc.Create(t.Context(), "New Session")
+	require.NoError(t, err)
+	<-events
+	require.NoError(t, svc.UpdateTitleAndUsage(t.Context(), created.ID, "New Session", 10, 5, 0.5))
+
+	renamed, err := svc.Rename(t.Context(), created.ID, "Fix the parser")
+	require.NoError(t, err)
+	require.Equal(t, "Fix the parser", renamed.Title)
+	require.Equal(t, int64(10), renamed.PromptTokens, "renaming keeps the usage")
+
+	event := <-events
+	require.Equal(t, "Fix the parser", event.Payload.Title)
+
+	_, err = svc.Rename(t.Context(), "missing", "Title")
+	require.Error(t, err)
+}
+
+func TestSetTags(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	svc := NewService(db.New(conn), conn, "/src/api")
+
+	tagged, err := svc.Create(t.Context(), "Tagged")
+	require.NoError(t, err)
+	require.Equal(t, "/src/api", tagged.Project)
+	_, err = svc.Create(t.Context(), "Plain")
+	require.NoError(t, err)
+
+	got, err := svc.SetTags(t.Context(), tagged.ID, []string{"Bug, #review", "bug"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"bug", "review"}, got.Tags)
+
+	// Saving keeps the tags, which are not part of Save.
+	got.Title = "Renamed"
+	got, err = svc.Save(t.Context(), got)
+	require.NoError(t, err)
+	require.Equal(t, []string{"bug", "review"}, got.Tags)
+
+	sessions, err := svc.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	matched := Filter{Tags: []string{"review"}}.Apply(sessions)
+	require.Len(t, matched, 1)
+	require.Equal(t, "Renamed", matched[0].Title)
+
+	got, err = svc.SetTags(t.Context(), tagged.ID, nil)
+	require.NoError(t, err)
+	require.Empty(t, got.Tags)
+	got, err = svc.Get(t.Context(), tagged.ID)
+	require.NoError(t, err)
+	require.Empty(t, got.Tags)
+
+	_, err = svc.SetTags(t.Context(), "missing", []string{"bug"})
+	require.Error(t, err)
+}
+
+func TestSetPinned(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	svc := NewService(db.New(conn), conn, "")
+
+	older, err := svc.Create(t.Context(), "Older")
+	require.NoError(t, err)
+	_, err = svc.Create(t.Context(), "Newer")
+	require.NoError(t, err)
+
+	got, err := svc.SetPinned(t.Context(), older.ID, true)
+	require.NoError(t, err)
+	require.True(t, got.Pinned)
+
+	// Pinned sessions are listed first, however old they are.
+	sessions, err := svc.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.Equal(t, older.ID, sessions[0].ID)
+	require.True(t, sessions[0].Pinned)
+
+	got, err = svc.SetPinned(t.Context(), older.ID, false)
+	require.NoError(t, err)
+	require.False(t, got.Pinned)
+
+	_, err = svc.SetPinned(t.Context(), "missing", true)
+	require.Error(t, err)
+}
+
+func TestSetTranslate(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	svc := NewService(db.New(conn), conn, "")
+
+	sess, err := svc.Create(t.Context(), "Translated")
+	require.NoError(t, err)
+	require.False(t, sess.Translate)
+
+	got, err := svc.SetTranslate(t.Context(), sess.ID, true)
+	require.NoError(t, err)
+	require.True(t, got.Translate)
+
+	// Saving a copy from before the toggle keeps it on.
+	_, err = svc.Save(t.Context(), sess)
+	require.NoError(t, err)
+	got, err = svc.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.True(t, got.Translate)
+}
+
+func TestSaveReasoningTokens(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	svc := NewService(db.New(conn), conn, "")
+
+	created, err := svc.Create(t.Context(), "Reasoning")
+	require.NoError(t, err)
+	created.CompletionTokens = 300
+	created.ReasoningTokens = 120
+	_, err = svc.Save(t.Context(), created)
+	require.NoError(t, err)
+
+	got, err := svc.Get(t.Context(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(300), got.CompletionTokens)
+	require.Equal(t, int64(120), got.ReasoningTokens)
+}
@@ -0,0 +1,83 @@
+// Package share publishes the transcript of a session for others to read,
+// either to an upload endpoint or as a standalone HTML file.
+package share
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/version"
+)
+
+// DefaultClient is the client transcripts are uploaded with.
+var DefaultClient = &http.Client{Timeout: 60 * time.Second}
+
+// Publish uploads page, a standalone HTML transcript, to endpoint and
+// returns the link the endpoint answers with: the "url" of a JSON object,
+// or else the first line of the body.
+func Publish(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, page []byte) (string, error) {
+	if client == nil {
+		client = DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(page))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "text/html; charset=utf-8")
+	req.Header.Set("User-Agent", "crush/"+version.Version)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload transcript: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", fmt.Errorf("failed to read upload response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		msg, _, _ := strings.Cut(strings.TrimSpace(string(body)), "\n")
+		return "", fmt.Errorf("upload failed: %s: %s", resp.Status, msg)
+	}
+
+	var answer struct {
+		URL string `json:"url"`
+	}
+	link, _, _ := strings.Cut(strings.TrimSpace(string(body)), "\n")
+	if json.Unmarshal(body, &answer) == nil {
+		link = answer.URL
+	}
+	link = strings.TrimSpace(link)
+	if u, err := url.Parse(link); err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("upload response has no link: %q", link)
+	}
+	return link, nil
+}
+
+// WriteFile writes page to dir as name.html, replacing an earlier share of
+// the same name, and returns a file:// link to it.
+func WriteFile(dir, name string, page []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create share directory: %w", err)
+	}
+	path, err := filepath.Abs(filepath.Join(dir, name+".html"))
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, page, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String(), nil
+}
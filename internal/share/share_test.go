@@ -0,0 +1,66 @@
+package share
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.Equal(t, "text/html; charset=utf-8", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		got = string(body)
+		switch r.URL.Path {
+		case "/json":
+			fmt.Fprint(w, `{"url":"https://share.example.com/s/1"}`)
+		case "/text":
+			fmt.Fprint(w, "https://share.example.com/s/2\n")
+		case "/none":
+			fmt.Fprint(w, "ok")
+		default:
+			http.Error(w, "quota exceeded", http.StatusForbidden)
+		}
+	}))
+	t.Cleanup(server.Close)
+	headers := map[string]string{"Authorization": "Bearer token"}
+
+	link, err := Publish(t.Context(), server.Client(), server.URL+"/json", headers, []byte("<html>"))
+	require.NoError(t, err)
+	require.Equal(t, "https://share.example.com/s/1", link)
+	require.Equal(t, "<html>", got)
+
+	link, err = Publish(t.Context(), server.Client(), server.URL+"/text", headers, []byte("<html>"))
+	require.NoError(t, err)
+	require.Equal(t, "https://share.example.com/s/2", link)
+
+	_, err = Publish(t.Context(), server.Client(), server.URL+"/none", headers, []byte("<html>"))
+	require.ErrorContains(t, err, "no link")
+
+	_, err = Publish(t.Context(), server.Client(), server.URL+"/full", headers, []byte("<html>"))
+	require.ErrorContains(t, err, "403 Forbidden: quota exceeded")
+}
+
+func TestWriteFile(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "shares")
+	link, err := WriteFile(dir, "s1", []byte("<html>"))
+	require.NoError(t, err)
+	require.Equal(t, "file://"+filepath.ToSlash(filepath.Join(dir, "s1.html")), link)
+	content, err := os.ReadFile(filepath.Join(dir, "s1.html"))
+	require.NoError(t, err)
+	require.Equal(t, "<html>", string(content))
+}
@@ -50,6 +50,7 @@ type BackgroundShell struct {
 	Description string
 	Shell       *Shell
 	WorkingDir  string
+	StartedAt   time.Time
 	ctx         context.Context
 	cancel      context.CancelFunc
 	stdout      *syncBuffer
@@ -87,6 +88,16 @@ func GetBackgroundShellManager() *BackgroundShellManager {
 
 // Start creates and starts a new background shell with the given command.
 func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, blockFuncs []BlockFunc, command string, description string) (*BackgroundShell, error) {
+	shell := NewShell(&Options{
+		WorkingDir: workingDir,
+		BlockFuncs: blockFuncs,
+	})
+	return m.StartIn(ctx, shell, command, description)
+}
+
+// StartIn starts command in the background in an existing shell, such as a
+// session's persistent shell, so the command sees and updates its state.
+func (m *BackgroundShellManager) StartIn(ctx context.Context, shell *Shell, command string, description string) (*BackgroundShell, error) {
 	// Check job limit
 	if m.shells.Len() >= MaxBackgroundJobs {
 		return nil, fmt.Errorf("maximum number of background jobs (%d) reached. Please terminate or wait for some jobs to complete", MaxBackgroundJobs)
@@ -94,18 +105,14 @@ func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, b
 
 	id := fmt.Sprintf("%03X", idCounter.Add(1))
 
-	shell := NewShell(&Options{
-		WorkingDir: workingDir,
-		BlockFuncs: blockFuncs,
-	})
-
 	shellCtx, cancel := context.WithCancel(ctx)
 
 	bgShell := &BackgroundShell{
 		ID:          id,
 		Command:     command,
 		Description: description,
-		WorkingDir:  workingDir,
+		WorkingDir:  shell.GetWorkingDir(),
+		StartedAt:   time.Now(),
 		Shell:       shell,
 		ctx:         shellCtx,
 		cancel:      cancel,
@@ -155,6 +162,18 @@ func (m *BackgroundShellManager) Kill(id string) error {
 	return nil
 }
 
+// KillIn terminates the background shells running in shell and returns how
+// many there were.
+func (m *BackgroundShellManager) KillIn(shell *Shell) int {
+	var killed int
+	for bgShell := range m.shells.Seq() {
+		if bgShell.Shell == shell && m.Kill(bgShell.ID) == nil {
+			killed++
+		}
+	}
+	return killed
+}
+
 // BackgroundShellInfo contains information about a background shell.
 type BackgroundShellInfo struct {
 	ID          string
@@ -171,6 +190,15 @@ func (m *BackgroundShellManager) List() []string {
 	return ids
 }
 
+// All returns all background shells, oldest first.
+func (m *BackgroundShellManager) All() []*BackgroundShell {
+	shells := slices.Collect(m.shells.Seq())
+	slices.SortFunc(shells, func(a, b *BackgroundShell) int {
+		return a.StartedAt.Compare(b.StartedAt)
+	})
+	return shells
+}
+
 // Cleanup removes completed jobs that have been finished for more than the retention period
 func (m *BackgroundShellManager) Cleanup() int {
 	now := time.Now().Unix()
@@ -230,6 +258,11 @@ func (bs *BackgroundShell) IsDone() bool {
 	}
 }
 
+// Done returns a channel that is closed when the background shell completes.
+func (bs *BackgroundShell) Done() <-chan struct{} {
+	return bs.done
+}
+
 // Wait blocks until the background shell completes.
 func (bs *BackgroundShell) Wait() {
 	<-bs.done
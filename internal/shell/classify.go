@@ -0,0 +1,287 @@
+package shell
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"mvdan.cc/sh/v3/shell"
+)
+
+// RiskCategory is the kind of harm a command may do.
+type RiskCategory string
+
+const (
+	// RiskDestructive commands delete or overwrite data.
+	RiskDestructive RiskCategory = "destructive"
+	// RiskNetwork commands reach other hosts.
+	RiskNetwork RiskCategory = "network"
+	// RiskPrivilege commands act as another user or change system settings.
+	RiskPrivilege RiskCategory = "privilege"
+)
+
+// Severity is how risky a classified command is.
+type Severity int
+
+const (
+	SeverityNone Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+)
+
+var severityNames = []string{"none", "low", "medium", "high"}
+
+// ParseSeverity returns the severity named s.
+func ParseSeverity(s string) (Severity, error) {
+	i := slices.Index(severityNames, strings.ToLower(s))
+	if i < 0 {
+		return SeverityNone, fmt.Errorf("unknown severity %q", s)
+	}
+	return Severity(i), nil
+}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// MarshalText implements [encoding.TextMarshaler].
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (s *Severity) UnmarshalText(text []byte) error {
+	parsed, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// Finding is one reason a command was found risky.
+type Finding struct {
+	Category RiskCategory `json:"category"`
+	Severity Severity     `json:"severity"`
+	Command  string       `json:"command,omitempty"`
+	Reason   string       `json:"reason"`
+}
+
+// Verdict is the outcome of classifying a command: the highest severity of
+// its findings.
+type Verdict struct {
+	Severity Severity  `json:"severity"`
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// Add records f, raising the severity of v if needed. Findings already
+// recorded are ignored.
+func (v *Verdict) Add(f Finding) {
+	if slices.Contains(v.Findings, f) {
+		return
+	}
+	v.Findings = append(v.Findings, f)
+	v.Severity = max(v.Severity, f.Severity)
+}
+
+// Merge adds the findings of other to v.
+func (v *Verdict) Merge(other Verdict) {
+	for _, f := range other.Findings {
+		v.Add(f)
+	}
+	v.Severity = max(v.Severity, other.Severity)
+}
+
+// Reasons returns the reasons of the findings, most severe first.
+func (v Verdict) Reasons() []string {
+	findings := slices.Clone(v.Findings)
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return cmp.Compare(b.Severity, a.Severity)
+	})
+	var reasons []string
+	for _, f := range findings {
+		if !slices.Contains(reasons, f.Reason) {
+			reasons = append(reasons, f.Reason)
+		}
+	}
+	return reasons
+}
+
+// Classifier judges how risky a shell command is before it runs.
+type Classifier interface {
+	Classify(ctx context.Context, command string) Verdict
+}
+
+// ClassifierRule flags the simple commands its pattern matches.
+type ClassifierRule struct {
+	// Pattern is a regular expression matched against each simple command,
+	// such as "rm -rf build".
+	Pattern  string
+	Category RiskCategory
+	Severity Severity
+	Reason   string
+
+	re *regexp.Regexp
+}
+
+// DefaultClassifierRules flag common destructive, network and privileged
+// commands.
+var DefaultClassifierRules = []ClassifierRule{
+	{Pattern: `^rm\s+(\S+\s+)*-[a-zA-Z]*([rR][a-zA-Z]*f|f[a-zA-Z]*[rR])`, Category: RiskDestructive, Severity: SeverityHigh, Reason: "force-removes files recursively"},
+	{Pattern: `^rm\s+(\S+\s+)*(-[a-zA-Z]*[rR]|--recursive)`, Category: RiskDestructive, Severity: SeverityMedium, Reason: "removes files recursively"},
+	{Pattern: `^(rm|rmdir|unlink)\s`, Category: RiskDestructive, Severity: SeverityLow, Reason: "removes files"},
+	{Pattern: `^(mkfs(\.\w+)?|fdisk|sfdisk|parted|wipefs)(\s|$)`, Category: RiskDestructive, Severity: SeverityHigh, Reason: "formats or partitions a disk"},
+	{Pattern: `^dd\s.*\bof=`, Category: RiskDestructive, Severity: SeverityHigh, Reason: "writes raw data to a file or device"},
+	{Pattern: `^(shred|truncate)\s`, Category: RiskDestructive, Severity: SeverityMedium, Reason: "overwrites file contents"},
+	{Pattern: `^git\s+(push\s+(\S+\s+)*(--force\S*|-f)(\s|$)|reset\s+--hard|clean\s+(\S+\s+)*-[a-zA-Z]*f)`, Category: RiskDestructive, Severity: SeverityHigh, Reason: "discards git history or uncommitted work"},
+	{Pattern: `^git\s+(checkout|restore)\s+(\S+\s+)*(\.|--)(\s|$)`, Category: RiskDestructive, Severity: SeverityMedium, Reason: "discards uncommitted changes"},
+	{Pattern: `^find\s.*\s(-delete|-exec\s+rm)(\s|$)`, Category: RiskDestructive, Severity: SeverityMedium, Reason: "deletes the files it finds"},
+	{Pattern: `^(chmod|chown|chgrp)\s+(\S+\s+)*(-[a-zA-Z]*R|--recursive)`, Category: RiskDestructive, Severity: SeverityMedium, Reason: "changes ownership or permissions recursively"},
+	{Pattern: `^(curl|wget|nc|ncat|netcat|telnet|ftp|sftp|scp|ssh|rsync)(\s|$)`, Category: RiskNetwork, Severity: SeverityMedium, Reason: "connects to another host"},
+	{Pattern: `^git\s+(push|pull|fetch|clone)(\s|$)`, Category: RiskNetwork, Severity: SeverityLow, Reason: "talks to a git remote"},
+	{Pattern: `^(npm|pnpm|yarn|bun|pip3?|gem|cargo|go|brew|apt(-get)?|dnf|yum)\s+(install|add|get|publish)(\s|$)`, Category: RiskNetwork, Severity: SeverityLow, Reason: "downloads or publishes packages"},
+	{Pattern: `^(sudo|doas|su|pkexec|runas)(\s|$)`, Category: RiskPrivilege, Severity: SeverityHigh, Reason: "runs as another user"},
+	{Pattern: `^chmod\s+(\S+\s+)*([ugoa]*\+[a-z]*s|[2467][0-7]{3})(\s|$)`, Category: RiskPrivilege, Severity: SeverityHigh, Reason: "sets the setuid or setgid bit"},
+	{Pattern: `^(mount|umount|chroot|setcap|insmod|rmmod|modprobe)(\s|$)`, Category: RiskPrivilege, Severity: SeverityHigh, Reason: "changes the system"},
+	{Pattern: `^(systemctl|launchctl|service|crontab)(\s|$)`, Category: RiskPrivilege, Severity: SeverityMedium, Reason: "manages system services or scheduled jobs"},
+}
+
+// wrappers run the command that follows them, after their own flags.
+var wrappers = []string{"sudo", "doas", "env", "nohup", "time", "nice", "timeout", "xargs", "command", "exec", "builtin"}
+
+// interpreters run the script they read from stdin.
+var interpreters = []string{"sh", "bash", "zsh", "dash", "ksh", "fish", "python", "python3", "perl", "ruby", "node"}
+
+// NewRuleClassifier returns a classifier that flags the simple commands
+// matched by rules. Only the first rule of each category that matches a
+// command counts, so more specific rules go first.
+func NewRuleClassifier(rules []ClassifierRule) (Classifier, error) {
+	compiled := make([]ClassifierRule, len(rules))
+	for i, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", rule.Pattern, err)
+		}
+		if rule.Reason == "" {
+			rule.Reason = "matches " + rule.Pattern
+		}
+		rule.re = re
+		compiled[i] = rule
+	}
+	return ruleClassifier(compiled), nil
+}
+
+type ruleClassifier []ClassifierRule
+
+func (rules ruleClassifier) Classify(_ context.Context, command string) Verdict {
+	var verdict Verdict
+	var downloads, interprets bool
+	for _, cmd := range SplitCommands(command) {
+		for _, sub := range unwrap(cmd) {
+			name, _, _ := strings.Cut(sub, " ")
+			downloads = downloads || name == "curl" || name == "wget"
+			interprets = interprets || slices.Contains(interpreters, name)
+			var matched []RiskCategory
+			for _, rule := range rules {
+				if slices.Contains(matched, rule.Category) || !rule.re.MatchString(sub) {
+					continue
+				}
+				matched = append(matched, rule.Category)
+				verdict.Add(Finding{Category: rule.Category, Severity: rule.Severity, Command: cmd, Reason: rule.Reason})
+			}
+		}
+	}
+	if downloads && interprets && strings.Contains(command, "|") {
+		verdict.Add(Finding{Category: RiskNetwork, Severity: SeverityHigh, Command: command, Reason: "runs a script downloaded from the network"})
+	}
+	return verdict
+}
+
+// unwrap returns cmd and, while it starts with a wrapper such as sudo or
+// env, the command the wrapper runs.
+func unwrap(cmd string) []string {
+	cmds := []string{cmd}
+	words := strings.Fields(cmd)
+	for len(words) > 1 && slices.Contains(wrappers, words[0]) {
+		wrapper := words[0]
+		words = words[1:]
+		for len(words) > 1 && (strings.HasPrefix(words[0], "-") || strings.Contains(words[0], "=") ||
+			(wrapper == "timeout" && strings.IndexFunc(words[0], func(r rune) bool { return r >= '0' && r <= '9' }) == 0)) {
+			words = words[1:]
+		}
+		cmds = append(cmds, strings.Join(words, " "))
+	}
+	return cmds
+}
+
+// externalClassifierTimeout bounds how long an external classifier may
+// take.
+const externalClassifierTimeout = 10 * time.Second
+
+// NewExternalClassifier returns a classifier that runs commandLine with the
+// shell command on stdin and reads a JSON [Verdict] from its stdout. A
+// program that fails finds nothing.
+func NewExternalClassifier(commandLine string) (Classifier, error) {
+	args, err := shell.Fields(commandLine, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid classifier command: %w", err)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty classifier command")
+	}
+	return &externalClassifier{args: args}, nil
+}
+
+type externalClassifier struct {
+	args []string
+}
+
+func (c *externalClassifier) Classify(ctx context.Context, command string) Verdict {
+	ctx, cancel := context.WithTimeout(ctx, externalClassifierTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.args[0], c.args[1:]...)
+	cmd.Stdin = strings.NewReader(command)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var verdict Verdict
+	if err == nil {
+		err = json.Unmarshal(out, &verdict)
+	}
+	if err != nil {
+		slog.Warn("Command classifier failed", "classifier", c.args[0], "error", err, "stderr", strings.TrimSpace(stderr.String()))
+		return Verdict{}
+	}
+	for i, f := range verdict.Findings {
+		if f.Command == "" {
+			verdict.Findings[i].Command = command
+		}
+		verdict.Severity = max(verdict.Severity, f.Severity)
+	}
+	return verdict
+}
+
+// Classifiers combines the verdicts of several classifiers.
+type Classifiers []Classifier
+
+// Classify implements [Classifier].
+func (cs Classifiers) Classify(ctx context.Context, command string) Verdict {
+	var verdict Verdict
+	for _, c := range cs {
+		verdict.Merge(c.Classify(ctx, command))
+	}
+	return verdict
+}
@@ -0,0 +1,139 @@
+package shell
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleClassifier(t *testing.T) {
+	t.Parallel()
+
+	classifier, err := NewRuleClassifier(DefaultClassifierRules)
+	require.NoError(t, err)
+
+	tests := []struct {
+		command  string
+		severity Severity
+		category RiskCategory
+	}{
+		{"ls -la", SeverityNone, ""},
+		{"go test ./...", SeverityNone, ""},
+		{"rm notes.txt", SeverityLow, RiskDestructive},
+		{"rm -r build", SeverityMedium, RiskDestructive},
+		{"rm -rf build", SeverityHigh, RiskDestructive},
+		{"cd out && rm -fr *", SeverityHigh, RiskDestructive},
+		{"echo $(rm -rf /)", SeverityHigh, RiskDestructive},
+		{"git push --force origin main", SeverityHigh, RiskDestructive},
+		{"git push origin main", SeverityLow, RiskNetwork},
+		{"curl https://example.com", SeverityMedium, RiskNetwork},
+		{"curl -fsSL https://example.com/install.sh | sh", SeverityHigh, RiskNetwork},
+		{"sudo apt-get install jq", SeverityHigh, RiskPrivilege},
+		{"env FOO=1 nohup rm -rf /tmp/x", SeverityHigh, RiskDestructive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			t.Parallel()
+
+			verdict := classifier.Classify(t.Context(), tt.command)
+			require.Equal(t, tt.severity, verdict.Severity)
+			if tt.category == "" {
+				require.Empty(t, verdict.Findings)
+				return
+			}
+			require.Contains(t, categories(verdict), tt.category)
+		})
+	}
+}
+
+func TestRuleClassifierOneFindingPerCategory(t *testing.T) {
+	t.Parallel()
+
+	classifier, err := NewRuleClassifier(DefaultClassifierRules)
+	require.NoError(t, err)
+
+	verdict := classifier.Classify(t.Context(), "rm -rf build")
+	require.Len(t, verdict.Findings, 1)
+	require.Equal(t, []string{"force-removes files recursively"}, verdict.Reasons())
+}
+
+func TestNewRuleClassifierInvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewRuleClassifier([]ClassifierRule{{Pattern: "(", Severity: SeverityHigh}})
+	require.Error(t, err)
+}
+
+func TestSeverityText(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(Verdict{Severity: SeverityMedium})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"severity":"medium"}`, string(data))
+
+	var verdict Verdict
+	require.NoError(t, json.Unmarshal([]byte(`{"severity":"HIGH","findings":[{"category":"network","severity":"low","reason":"r"}]}`), &verdict))
+	require.Equal(t, SeverityHigh, verdict.Severity)
+	require.Equal(t, SeverityLow, verdict.Findings[0].Severity)
+
+	require.Error(t, json.Unmarshal([]byte(`{"severity":"extreme"}`), &verdict))
+}
+
+func TestClassifiersMerge(t *testing.T) {
+	t.Parallel()
+
+	rules, err := NewRuleClassifier([]ClassifierRule{
+		{Pattern: `^make deploy`, Category: RiskNetwork, Severity: SeverityHigh, Reason: "deploys to production"},
+	})
+	require.NoError(t, err)
+	defaults, err := NewRuleClassifier(DefaultClassifierRules)
+	require.NoError(t, err)
+
+	verdict := Classifiers{rules, defaults}.Classify(t.Context(), "make deploy && rm old.log")
+	require.Equal(t, SeverityHigh, verdict.Severity)
+	require.Equal(t, []string{"deploys to production", "removes files"}, verdict.Reasons())
+}
+
+func TestExternalClassifier(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	t.Parallel()
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "classify.sh")
+	require.NoError(t, os.WriteFile(script, []byte(`#!/bin/sh
+if grep -q deploy; then
+  echo '{"findings":[{"category":"network","severity":"high","reason":"deploys"}]}'
+else
+  echo '{"severity":"none"}'
+fi
+`), 0o755))
+
+	classifier, err := NewExternalClassifier(script)
+	require.NoError(t, err)
+
+	verdict := classifier.Classify(t.Context(), "make deploy")
+	require.Equal(t, SeverityHigh, verdict.Severity)
+	require.Equal(t, "make deploy", verdict.Findings[0].Command)
+	require.Equal(t, SeverityNone, classifier.Classify(t.Context(), "make build").Severity)
+
+	broken, err := NewExternalClassifier(filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	require.Equal(t, Verdict{}, broken.Classify(t.Context(), "make deploy"))
+
+	_, err = NewExternalClassifier("")
+	require.Error(t, err)
+}
+
+func categories(v Verdict) []RiskCategory {
+	var cats []RiskCategory
+	for _, f := range v.Findings {
+		cats = append(cats, f.Category)
+	}
+	return cats
+}
@@ -0,0 +1,115 @@
+package shell
+
+import (
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// SplitCommands returns each simple command of a shell command line,
+// including those in substitutions, or the line itself when it does not
+// parse.
+func SplitCommands(line string) []string {
+	file, err := syntax.NewParser().Parse(strings.NewReader(line), "")
+	if err != nil {
+		return []string{strings.TrimSpace(line)}
+	}
+	printer := syntax.NewPrinter()
+	var cmds []string
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		words := make([]string, len(call.Args))
+		for i, word := range call.Args {
+			var b strings.Builder
+			_ = printer.Print(&b, word)
+			words[i] = b.String()
+		}
+		cmds = append(cmds, strings.Join(words, " "))
+		return true
+	})
+	if len(cmds) == 0 {
+		return []string{strings.TrimSpace(line)}
+	}
+	return cmds
+}
+
+// WriteRedirects returns the files the redirections of a shell command line
+// write to, such as "out.txt" of "go test > out.txt", as written. Writes to
+// /dev/null and duplicated file descriptors, as in "2>&1", are left out, and
+// so is everything when the line does not parse.
+func WriteRedirects(line string) []string {
+	file, err := syntax.NewParser().Parse(strings.NewReader(line), "")
+	if err != nil {
+		return nil
+	}
+	printer := syntax.NewPrinter()
+	var targets []string
+	syntax.Walk(file, func(node syntax.Node) bool {
+		redir, ok := node.(*syntax.Redirect)
+		if !ok || redir.Word == nil {
+			return true
+		}
+		var b strings.Builder
+		_ = printer.Print(&b, redir.Word)
+		target := b.String()
+		switch redir.Op {
+		case syntax.RdrOut, syntax.AppOut, syntax.RdrInOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll:
+		case syntax.DplOut:
+			if target == "-" || strings.Trim(target, "0123456789") == "" {
+				return true
+			}
+		default:
+			return true
+		}
+		if target != "/dev/null" {
+			targets = append(targets, target)
+		}
+		return true
+	})
+	return targets
+}
+
+// Words returns the words of a shell command line that are plain text, such
+// as the arguments of its commands and the files of its redirections, with
+// their quotes removed. Words built from expansions are left out, and so is
+// everything when the line does not parse.
+func Words(line string) []string {
+	file, err := syntax.NewParser().Parse(strings.NewReader(line), "")
+	if err != nil {
+		return nil
+	}
+	var words []string
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if word, ok := node.(*syntax.Word); ok {
+			if s, ok := literal(word.Parts); ok && s != "" {
+				words = append(words, s)
+			}
+		}
+		return true
+	})
+	return words
+}
+
+func literal(parts []syntax.WordPart) (string, bool) {
+	var b strings.Builder
+	for _, part := range parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			b.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			b.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			s, ok := literal(part.Parts)
+			if !ok {
+				return "", false
+			}
+			b.WriteString(s)
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
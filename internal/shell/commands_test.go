@@ -0,0 +1,26 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRedirects(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string][]string{
+		"git status":                          nil,
+		"git log > ~/.bashrc":                 {"~/.bashrc"},
+		"git status >> .git/hooks/pre-commit": {".git/hooks/pre-commit"},
+		"go test ./... 2>&1 | tee out.txt":    nil,
+		"make &> build.log":                   {"build.log"},
+		"echo $(cat a > b) >| c":              {"b", "c"},
+		"git diff > /dev/null 2>&1":           nil,
+		"cat < input.txt":                     nil,
+		"echo 'unterminated":                  nil,
+	}
+	for line, want := range tests {
+		require.Equal(t, want, WriteRedirects(line), line)
+	}
+}
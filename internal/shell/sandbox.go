@@ -0,0 +1,167 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+// SandboxBackend is the program external commands are confined with.
+type SandboxBackend string
+
+const (
+	// SandboxNone runs commands without a sandbox.
+	SandboxNone SandboxBackend = "none"
+	// SandboxBwrap uses bubblewrap, on Linux.
+	SandboxBwrap SandboxBackend = "bwrap"
+	// SandboxExec uses sandbox-exec, on macOS.
+	SandboxExec SandboxBackend = "sandbox-exec"
+	// SandboxDocker runs each command in a throwaway container.
+	SandboxDocker SandboxBackend = "docker"
+)
+
+// DefaultSandboxImage is the image [SandboxDocker] uses when none is set.
+const DefaultSandboxImage = "debian:stable-slim"
+
+// Sandbox confines the commands of a shell to its root: the filesystem is
+// read-only except for Root and WritablePaths, and the network is off unless
+// Network is set.
+//
+// External commands are run through Backend. Builtins such as cd and echo
+// run in the interpreter itself, so redirections are checked by Crush
+// instead.
+type Sandbox struct {
+	Backend       SandboxBackend
+	Root          string
+	WritablePaths []string
+	Network       bool
+	// Image is the container image for [SandboxDocker].
+	Image string
+}
+
+// Enabled reports whether s confines commands.
+func (s *Sandbox) Enabled() bool {
+	return s != nil && s.Backend != "" && s.Backend != SandboxNone
+}
+
+// writable returns the directories commands may write to.
+func (s *Sandbox) writable() []string {
+	dirs := []string{s.Root}
+	if s.Backend != SandboxDocker {
+		dirs = append(dirs, os.TempDir())
+	}
+	return append(dirs, s.WritablePaths...)
+}
+
+// canWrite reports whether path is within a writable directory.
+func (s *Sandbox) canWrite(path string) bool {
+	if path == os.DevNull {
+		return true
+	}
+	return slices.ContainsFunc(s.writable(), func(dir string) bool {
+		rel, err := filepath.Rel(dir, path)
+		return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	})
+}
+
+// Wrap returns the command line that runs args, started in dir, inside the
+// sandbox.
+func (s *Sandbox) Wrap(args []string, dir string) ([]string, error) {
+	switch s.Backend {
+	case SandboxBwrap:
+		wrapped := []string{
+			"bwrap",
+			"--ro-bind", "/", "/",
+			"--dev", "/dev",
+			"--proc", "/proc",
+			"--die-with-parent",
+		}
+		for _, path := range s.writable() {
+			wrapped = append(wrapped, "--bind-try", path, path)
+		}
+		if !s.Network {
+			wrapped = append(wrapped, "--unshare-net")
+		}
+		wrapped = append(wrapped, "--chdir", dir, "--")
+		return append(wrapped, args...), nil
+	case SandboxExec:
+		return append([]string{"sandbox-exec", "-p", s.seatbeltProfile()}, args...), nil
+	case SandboxDocker:
+		wrapped := []string{"docker", "run", "--rm", "-i", "--read-only", "--tmpfs", "/tmp"}
+		if !s.Network {
+			wrapped = append(wrapped, "--network", "none")
+		}
+		for _, path := range s.writable() {
+			wrapped = append(wrapped, "-v", path+":"+path)
+		}
+		image := s.Image
+		if image == "" {
+			image = DefaultSandboxImage
+		}
+		wrapped = append(wrapped, "-w", dir, image)
+		return append(wrapped, args...), nil
+	default:
+		return nil, fmt.Errorf("unknown sandbox backend %q", s.Backend)
+	}
+}
+
+// seatbeltProfile returns the sandbox-exec profile that allows writes only
+// to the writable directories.
+func (s *Sandbox) seatbeltProfile() string {
+	var profile strings.Builder
+	profile.WriteString("(version 1)\n(allow default)\n(deny file-write*)\n")
+	profile.WriteString(`(allow file-write* (literal "/dev/null") (literal "/dev/tty") (subpath "/dev/fd")`)
+	for _, path := range s.writable() {
+		// The profile sees resolved paths, such as /private/tmp for /tmp.
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		fmt.Fprintf(&profile, " (subpath %q)", path)
+	}
+	profile.WriteString(")\n")
+	if !s.Network {
+		profile.WriteString("(deny network*)\n(allow network* (remote unix-socket))\n")
+	}
+	return profile.String()
+}
+
+// execHandler runs external commands inside the sandbox.
+func (s *Sandbox) execHandler(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(ctx context.Context, args []string) error {
+		if len(args) == 0 {
+			return next(ctx, args)
+		}
+		if _, err := exec.LookPath(string(s.Backend)); err != nil {
+			return fmt.Errorf("sandbox backend %s is not installed: %w", s.Backend, err)
+		}
+		wrapped, err := s.Wrap(args, interp.HandlerCtx(ctx).Dir)
+		if err != nil {
+			return err
+		}
+		return next(ctx, wrapped)
+	}
+}
+
+// openHandler refuses redirections that write outside the writable
+// directories.
+func (s *Sandbox) openHandler(next interp.OpenHandlerFunc) interp.OpenHandlerFunc {
+	return func(ctx context.Context, path string, flag int, perm os.FileMode) (io.ReadWriteCloser, error) {
+		if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+			abs := path
+			if !filepath.IsAbs(abs) {
+				abs = filepath.Join(interp.HandlerCtx(ctx).Dir, abs)
+			}
+			if !s.canWrite(filepath.Clean(abs)) {
+				return nil, fmt.Errorf("sandbox: %s is outside the writable directories", path)
+			}
+		}
+		return next(ctx, path, flag, perm)
+	}
+}
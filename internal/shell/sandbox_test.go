@@ -0,0 +1,102 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxEnabled(t *testing.T) {
+	t.Parallel()
+
+	var nilSandbox *Sandbox
+	require.False(t, nilSandbox.Enabled())
+	require.False(t, (&Sandbox{Backend: SandboxNone}).Enabled())
+	require.True(t, (&Sandbox{Backend: SandboxBwrap}).Enabled())
+}
+
+func TestSandboxWrap(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	tmp := os.TempDir()
+
+	bwrap, err := (&Sandbox{Backend: SandboxBwrap, Root: root}).Wrap([]string{"go", "test"}, root)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"bwrap", "--ro-bind", "/", "/", "--dev", "/dev", "--proc", "/proc", "--die-with-parent",
+		"--bind-try", root, root, "--bind-try", tmp, tmp,
+		"--unshare-net", "--chdir", root, "--", "go", "test",
+	}, bwrap)
+
+	online, err := (&Sandbox{Backend: SandboxBwrap, Root: root, Network: true}).Wrap([]string{"go"}, root)
+	require.NoError(t, err)
+	require.NotContains(t, online, "--unshare-net")
+
+	docker, err := (&Sandbox{Backend: SandboxDocker, Root: root}).Wrap([]string{"ls"}, root)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"docker", "run", "--rm", "-i", "--read-only", "--tmpfs", "/tmp", "--network", "none",
+		"-v", root + ":" + root, "-w", root, DefaultSandboxImage, "ls",
+	}, docker)
+
+	seatbelt, err := (&Sandbox{Backend: SandboxExec, Root: root}).Wrap([]string{"ls"}, root)
+	require.NoError(t, err)
+	require.Equal(t, "sandbox-exec", seatbelt[0])
+	require.Contains(t, seatbelt[2], "(deny file-write*)")
+	require.Contains(t, seatbelt[2], "(deny network*)")
+	require.Equal(t, "ls", seatbelt[3])
+
+	_, err = (&Sandbox{Backend: "jail", Root: root}).Wrap([]string{"ls"}, root)
+	require.Error(t, err)
+}
+
+func TestSandboxCanWrite(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	extra := t.TempDir()
+	s := &Sandbox{Backend: SandboxDocker, Root: root, WritablePaths: []string{extra}}
+	require.True(t, s.canWrite(root))
+	require.True(t, s.canWrite(filepath.Join(root, "a", "b.txt")))
+	require.True(t, s.canWrite(filepath.Join(extra, "c.txt")))
+	require.True(t, s.canWrite(os.DevNull))
+	require.False(t, s.canWrite(filepath.Dir(root)))
+	require.False(t, s.canWrite(root+"-other"))
+}
+
+func TestSandboxRedirections(t *testing.T) {
+	t.Parallel()
+
+	parent := t.TempDir()
+	root := filepath.Join(parent, "project")
+	require.NoError(t, os.Mkdir(root, 0o755))
+	sh := NewShell(&Options{
+		WorkingDir: root,
+		Sandbox:    &Sandbox{Backend: SandboxDocker, Root: root},
+	})
+
+	_, _, err := sh.Exec(t.Context(), "echo inside > notes.txt && read line < notes.txt && echo $line > /dev/null")
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(root, "notes.txt"))
+
+	_, stderr, err := sh.Exec(t.Context(), "echo outside > ../escape.txt")
+	require.Error(t, err)
+	require.Contains(t, stderr+err.Error(), "outside the writable directories")
+	require.NoFileExists(t, filepath.Join(parent, "escape.txt"))
+}
+
+func TestSandboxMissingBackend(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("sandbox-exec"); err == nil {
+		t.Skip("sandbox-exec is installed")
+	}
+
+	root := t.TempDir()
+	sh := NewShell(&Options{WorkingDir: root, Sandbox: &Sandbox{Backend: SandboxExec, Root: root}})
+	_, _, err := sh.Exec(t.Context(), "ls")
+	require.ErrorContains(t, err, "sandbox backend sandbox-exec is not installed")
+}
@@ -0,0 +1,48 @@
+package shell
+
+import (
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/csync"
+)
+
+// SessionShells keeps one persistent shell per conversation, so the working
+// directory and exported variables, such as those set by activating a
+// virtualenv, carry over from one command to the next.
+type SessionShells struct {
+	shells *csync.Map[string, *Shell]
+	mu     sync.Mutex
+}
+
+var (
+	sessionShells     *SessionShells
+	sessionShellsOnce sync.Once
+)
+
+// GetSessionShells returns the singleton set of session shells.
+func GetSessionShells() *SessionShells {
+	sessionShellsOnce.Do(func() {
+		sessionShells = &SessionShells{shells: csync.NewMap[string, *Shell]()}
+	})
+	return sessionShells
+}
+
+// Get returns the shell of a session, starting one with newShell when the
+// session has none.
+func (s *SessionShells) Get(sessionID string, newShell func() *Shell) *Shell {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sh, ok := s.shells.Get(sessionID); ok {
+		return sh
+	}
+	sh := newShell()
+	s.shells.Set(sessionID, sh)
+	return sh
+}
+
+// Reset drops the shell of a session so the next command starts from a
+// fresh one, and returns the old shell, if any. Commands still running in it
+// are not stopped; see [BackgroundShellManager.KillIn].
+func (s *SessionShells) Reset(sessionID string) (*Shell, bool) {
+	return s.shells.Take(sessionID)
+}
@@ -0,0 +1,36 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionShells(t *testing.T) {
+	t.Parallel()
+
+	shells := &SessionShells{shells: csync.NewMap[string, *Shell]()}
+	dir := t.TempDir()
+	newShell := func() *Shell { return NewShell(&Options{WorkingDir: dir}) }
+
+	sh := shells.Get("a", newShell)
+	require.Same(t, sh, shells.Get("a", newShell), "a session keeps its shell")
+	require.NotSame(t, sh, shells.Get("b", newShell))
+
+	_, _, err := sh.Exec(t.Context(), "export FOO=bar && cd ..")
+	require.NoError(t, err)
+	stdout, _, err := sh.Exec(t.Context(), "echo $FOO")
+	require.NoError(t, err)
+	require.Equal(t, "bar\n", stdout)
+
+	old, ok := shells.Reset("a")
+	require.True(t, ok)
+	require.Same(t, sh, old)
+	_, ok = shells.Reset("a")
+	require.False(t, ok)
+
+	fresh := shells.Get("a", newShell)
+	require.NotSame(t, sh, fresh)
+	require.Equal(t, dir, fresh.GetWorkingDir())
+}
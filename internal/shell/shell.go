@@ -56,6 +56,7 @@ type Shell struct {
 	mu         sync.Mutex
 	logger     Logger
 	blockFuncs []BlockFunc
+	sandbox    *Sandbox
 }
 
 // Options for creating a new shell
@@ -64,6 +65,8 @@ type Options struct {
 	Env        []string
 	Logger     Logger
 	BlockFuncs []BlockFunc
+	// Sandbox, when enabled, confines the commands of the shell.
+	Sandbox *Sandbox
 }
 
 // NewShell creates a new shell instance with the given options
@@ -92,6 +95,7 @@ func NewShell(opts *Options) *Shell {
 		env:        env,
 		logger:     logger,
 		blockFuncs: opts.BlockFuncs,
+		sandbox:    opts.Sandbox,
 	}
 }
 
@@ -111,6 +115,15 @@ func (s *Shell) ExecStream(ctx context.Context, command string, stdout, stderr i
 	return s.execStream(ctx, command, stdout, stderr)
 }
 
+// Busy reports whether a command is running in the shell.
+func (s *Shell) Busy() bool {
+	if !s.mu.TryLock() {
+		return true
+	}
+	s.mu.Unlock()
+	return false
+}
+
 // GetWorkingDir returns the current working directory
 func (s *Shell) GetWorkingDir() string {
 	s.mu.Lock()
@@ -238,13 +251,17 @@ func (s *Shell) blockHandler() func(next interp.ExecHandlerFunc) interp.ExecHand
 
 // newInterp creates a new interpreter with the current shell state
 func (s *Shell) newInterp(stdout, stderr io.Writer) (*interp.Runner, error) {
-	return interp.New(
+	opts := []interp.RunnerOption{
 		interp.StdIO(nil, stdout, stderr),
 		interp.Interactive(false),
 		interp.Env(expand.ListEnviron(s.env...)),
 		interp.Dir(s.cwd),
 		interp.ExecHandlers(s.execHandlers()...),
-	)
+	}
+	if s.sandbox.Enabled() {
+		opts = append(opts, interp.OpenHandler(s.sandbox.openHandler(interp.DefaultOpenHandler())))
+	}
+	return interp.New(opts...)
 }
 
 // updateShellFromRunner updates the shell from the interpreter after execution.
@@ -301,6 +318,9 @@ func (s *Shell) execHandlers() []func(next interp.ExecHandlerFunc) interp.ExecHa
 	handlers := []func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc{
 		s.blockHandler(),
 	}
+	if s.sandbox.Enabled() {
+		handlers = append(handlers, s.sandbox.execHandler)
+	}
 	if useGoCoreUtils {
 		handlers = append(handlers, coreutils.ExecHandler)
 	}
@@ -0,0 +1,221 @@
+// Package staging holds file edits proposed by the agent until the user
+// reviews them, so nothing is written to the workspace without being
+// applied by hand.
+package staging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound is returned for unknown edit IDs.
+	ErrNotFound = errors.New("staged edit not found")
+	// ErrConflict is returned when a file changed after the edit to it was
+	// staged, so applying it would throw those changes away.
+	ErrConflict = errors.New("file changed since the edit was staged")
+)
+
+// Edit is a proposed change to a single file.
+type Edit struct {
+	ID         string    `json:"id"`
+	SessionID  string    `json:"session_id"`
+	ToolCallID string    `json:"tool_call_id"`
+	Tool       string    `json:"tool"`
+	Path       string    `json:"path"`
+	OldContent string    `json:"old_content"`
+	NewContent string    `json:"new_content"`
+	Additions  int       `json:"additions"`
+	Removals   int       `json:"removals"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Queue holds staged edits in memory until they are applied or discarded.
+// It publishes an event whenever an edit is staged, changed or leaves the
+// queue.
+type Queue struct {
+	*pubsub.Broker[Edit]
+
+	files history.Service
+
+	mu    sync.Mutex
+	edits map[string]Edit
+}
+
+// New creates an empty queue that records applied edits in files.
+func New(files history.Service) *Queue {
+	return &Queue{
+		Broker: pubsub.NewBroker[Edit](),
+		files:  files,
+		edits:  make(map[string]Edit),
+	}
+}
+
+// Stage adds edit to the queue and returns it with its ID set. Line
+// endings are normalized so the diff shows only real changes.
+func (q *Queue) Stage(edit Edit) Edit {
+	edit.ID = uuid.NewString()
+	edit.CreatedAt = time.Now()
+	edit.OldContent, _ = fsext.ToUnixLineEndings(edit.OldContent)
+	edit.NewContent, _ = fsext.ToUnixLineEndings(edit.NewContent)
+	_, edit.Additions, edit.Removals = diff.GenerateDiff(edit.OldContent, edit.NewContent, edit.Path)
+
+	q.mu.Lock()
+	q.edits[edit.ID] = edit
+	q.mu.Unlock()
+	q.Publish(pubsub.CreatedEvent, edit)
+	return edit
+}
+
+// Pending returns the staged edits, oldest first.
+func (q *Queue) Pending() []Edit {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	edits := make([]Edit, 0, len(q.edits))
+	for _, edit := range q.edits {
+		edits = append(edits, edit)
+	}
+	slices.SortFunc(edits, func(a, b Edit) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return edits
+}
+
+// Get returns a staged edit.
+func (q *Queue) Get(id string) (Edit, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	edit, ok := q.edits[id]
+	if !ok {
+		return Edit{}, ErrNotFound
+	}
+	return edit, nil
+}
+
+// Update replaces what a staged edit writes, for when the user wants to
+// adjust the proposal before applying it.
+func (q *Queue) Update(id, content string) (Edit, error) {
+	q.mu.Lock()
+	edit, ok := q.edits[id]
+	if !ok {
+		q.mu.Unlock()
+		return Edit{}, ErrNotFound
+	}
+	edit.NewContent, _ = fsext.ToUnixLineEndings(content)
+	_, edit.Additions, edit.Removals = diff.GenerateDiff(edit.OldContent, edit.NewContent, edit.Path)
+	q.edits[id] = edit
+	q.mu.Unlock()
+	q.Publish(pubsub.UpdatedEvent, edit)
+	return edit, nil
+}
+
+// Apply writes a staged edit to disk and removes it from the queue. It
+// returns ErrConflict, keeping the edit staged, when the file no longer
+// has the content the edit was made against.
+func (q *Queue) Apply(ctx context.Context, id string) (Edit, error) {
+	edit, err := q.Get(id)
+	if err != nil {
+		return Edit{}, err
+	}
+
+	current, isCrlf, err := readFile(edit.Path)
+	if err != nil {
+		return Edit{}, err
+	}
+	if current != edit.OldContent {
+		return Edit{}, fmt.Errorf("%s: %w", edit.Path, ErrConflict)
+	}
+
+	content := edit.NewContent
+	if isCrlf {
+		content, _ = fsext.ToWindowsLineEndings(content)
+	}
+	if err := os.MkdirAll(filepath.Dir(edit.Path), 0o755); err != nil {
+		return Edit{}, fmt.Errorf("failed to create parent directories: %w", err)
+	}
+	if err := os.WriteFile(edit.Path, []byte(content), 0o644); err != nil {
+		return Edit{}, fmt.Errorf("failed to write file: %w", err)
+	}
+	q.recordHistory(ctx, edit)
+
+	q.remove(id)
+	return edit, nil
+}
+
+// Discard drops a staged edit without applying it.
+func (q *Queue) Discard(id string) error {
+	if _, err := q.Get(id); err != nil {
+		return err
+	}
+	q.remove(id)
+	return nil
+}
+
+func (q *Queue) remove(id string) {
+	q.mu.Lock()
+	edit, ok := q.edits[id]
+	delete(q.edits, id)
+	q.mu.Unlock()
+	if ok {
+		q.Publish(pubsub.DeletedEvent, edit)
+	}
+}
+
+// recordHistory stores the applied change in the session's file history
+// the same way the edit tools do.
+func (q *Queue) recordHistory(ctx context.Context, edit Edit) {
+	if q.files == nil || edit.SessionID == "" {
+		return
+	}
+	file, err := q.files.GetByPathAndSession(ctx, edit.Path, edit.SessionID)
+	if err != nil {
+		if _, err := q.files.Create(ctx, edit.SessionID, edit.Path, edit.OldContent); err != nil {
+			slog.Error("Error creating file history", "error", err)
+			return
+		}
+	} else if file.Content != edit.OldContent {
+		if _, err := q.files.CreateVersion(ctx, edit.SessionID, edit.Path, edit.OldContent); err != nil {
+			slog.Error("Error creating file history version", "error", err)
+		}
+	}
+	if _, err := q.files.CreateVersion(ctx, edit.SessionID, edit.Path, edit.NewContent); err != nil {
+		slog.Error("Error creating file history version", "error", err)
+	}
+}
+
+// readFile returns the content of path with Unix line endings, or an empty
+// string when it does not exist.
+func readFile(path string) (string, bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read file: %w", err)
+	}
+	content, isCrlf := fsext.ToUnixLineEndings(string(data))
+	return content, isCrlf, nil
+}
+
+// Summary describes what an edit changes in one line.
+func (e Edit) Summary(workingDir string) string {
+	path := e.Path
+	if rel, err := filepath.Rel(workingDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		path = rel
+	}
+	return fmt.Sprintf("%s (+%d -%d)", filepath.ToSlash(path), e.Additions, e.Removals)
+}
@@ -0,0 +1,89 @@
+package staging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueApply(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\r\n"), 0o644))
+
+	q := New(nil)
+	edit := q.Stage(Edit{Path: path, OldContent: "package main\r\n", NewContent: "package main\r\n\r\nfunc main() {}\r\n"})
+	require.NotEmpty(t, edit.ID)
+	require.Equal(t, 2, edit.Additions)
+	require.Len(t, q.Pending(), 1)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "package main\r\n", string(data), "staging leaves the file alone")
+
+	_, err = q.Apply(t.Context(), edit.ID)
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "package main\r\n\r\nfunc main() {}\r\n", string(data), "line endings are kept")
+	require.Empty(t, q.Pending())
+
+	_, err = q.Apply(t.Context(), edit.ID)
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestQueueApplyNewFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "sub", "notes.md")
+	q := New(nil)
+	edit := q.Stage(Edit{Path: path, NewContent: "# Notes\n"})
+
+	_, err := q.Apply(t.Context(), edit.ID)
+	require.NoError(t, err)
+	require.FileExists(t, path)
+}
+
+func TestQueueApplyConflict(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("one\n"), 0o644))
+
+	q := New(nil)
+	edit := q.Stage(Edit{Path: path, OldContent: "one\n", NewContent: "two\n"})
+	require.NoError(t, os.WriteFile(path, []byte("changed\n"), 0o644))
+
+	_, err := q.Apply(t.Context(), edit.ID)
+	require.ErrorIs(t, err, ErrConflict)
+	require.Len(t, q.Pending(), 1, "a conflicting edit stays staged")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "changed\n", string(data))
+}
+
+func TestQueueUpdateAndDiscard(t *testing.T) {
+	t.Parallel()
+
+	q := New(nil)
+	first := q.Stage(Edit{Path: "a.txt", OldContent: "a\n", NewContent: "b\n"})
+	second := q.Stage(Edit{Path: "b.txt", NewContent: "b\n"})
+	require.Equal(t, []string{first.ID, second.ID}, []string{q.Pending()[0].ID, q.Pending()[1].ID})
+
+	updated, err := q.Update(first.ID, "a\nc\r\n")
+	require.NoError(t, err)
+	require.Equal(t, "a\nc\n", updated.NewContent)
+	require.Equal(t, 1, updated.Additions)
+	require.Equal(t, 0, updated.Removals)
+
+	require.NoError(t, q.Discard(first.ID))
+	require.ErrorIs(t, q.Discard(first.ID), ErrNotFound)
+	_, err = q.Update(first.ID, "")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Len(t, q.Pending(), 1)
+}
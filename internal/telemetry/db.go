@@ -0,0 +1,71 @@
+package telemetry
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryNameRE extracts the query name sqlc puts at the top of each query.
+var queryNameRE = regexp.MustCompile(`^-- name: (\w+)`)
+
+// tracedDB wraps a database handle with a span and a duration metric per
+// operation. Spans are named after the sqlc query so statements and their
+// arguments are never exported.
+type tracedDB struct {
+	db db.DBTX
+}
+
+// WrapDB instruments the queries run through conn.
+func WrapDB(conn db.DBTX) db.DBTX {
+	return &tracedDB{db: conn}
+}
+
+func (t *tracedDB) start(ctx context.Context, query string) (context.Context, trace.Span, []attribute.KeyValue) {
+	operation := "query"
+	if m := queryNameRE.FindStringSubmatch(query); m != nil {
+		operation = m[1]
+	}
+	attrs := []attribute.KeyValue{
+		attrDBSystem.String("sqlite"),
+		attrDBOperation.String(operation),
+	}
+	ctx, span := tracer().Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx, span, attrs
+}
+
+func (t *tracedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	ctx, span, attrs := t.start(ctx, query)
+	res, err := t.db.ExecContext(ctx, query, args...)
+	end(ctx, span, meters().dbDuration, start, err, attrs...)
+	return res, err
+}
+
+func (t *tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return t.db.PrepareContext(ctx, query)
+}
+
+func (t *tracedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	start := time.Now()
+	ctx, span, attrs := t.start(ctx, query)
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	end(ctx, span, meters().dbDuration, start, err, attrs...)
+	return rows, err
+}
+
+func (t *tracedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	start := time.Now()
+	ctx, span, attrs := t.start(ctx, query)
+	row := t.db.QueryRowContext(ctx, query, args...)
+	end(ctx, span, meters().dbDuration, start, row.Err(), attrs...)
+	return row
+}
@@ -0,0 +1,89 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys follow the OpenTelemetry generative AI and database
+// semantic conventions where they exist.
+const (
+	attrGenAISystem     = attribute.Key("gen_ai.system")
+	attrGenAIOperation  = attribute.Key("gen_ai.operation.name")
+	attrGenAIModel      = attribute.Key("gen_ai.request.model")
+	attrGenAITokenType  = attribute.Key("gen_ai.token.type")
+	attrGenAIInputToks  = attribute.Key("gen_ai.usage.input_tokens")
+	attrGenAIOutputToks = attribute.Key("gen_ai.usage.output_tokens")
+	attrGenAIFinish     = attribute.Key("gen_ai.response.finish_reasons")
+	attrToolName        = attribute.Key("gen_ai.tool.name")
+	attrToolCallID      = attribute.Key("gen_ai.tool.call.id")
+	attrDBSystem        = attribute.Key("db.system.name")
+	attrDBOperation     = attribute.Key("db.operation.name")
+	attrErrorType       = attribute.Key("error.type")
+)
+
+type instruments struct {
+	providerDuration metric.Float64Histogram
+	tokenUsage       metric.Int64Histogram
+	toolDuration     metric.Float64Histogram
+	dbDuration       metric.Float64Histogram
+}
+
+// meters lazily creates the instruments. The global meter provider delegates
+// to the SDK once [Setup] installs it, so creating them early is fine.
+var meters = sync.OnceValue(func() instruments {
+	m := meter()
+	var i instruments
+	var err error
+	if i.providerDuration, err = m.Float64Histogram(
+		"gen_ai.client.operation.duration",
+		metric.WithDescription("Duration of provider requests"),
+		metric.WithUnit("s"),
+	); err != nil {
+		slog.Debug("Failed to create metric", "error", err)
+	}
+	if i.tokenUsage, err = m.Int64Histogram(
+		"gen_ai.client.token.usage",
+		metric.WithDescription("Tokens used by provider requests"),
+		metric.WithUnit("{token}"),
+	); err != nil {
+		slog.Debug("Failed to create metric", "error", err)
+	}
+	if i.toolDuration, err = m.Float64Histogram(
+		"crush.tool.duration",
+		metric.WithDescription("Duration of tool executions"),
+		metric.WithUnit("s"),
+	); err != nil {
+		slog.Debug("Failed to create metric", "error", err)
+	}
+	if i.dbDuration, err = m.Float64Histogram(
+		"db.client.operation.duration",
+		metric.WithDescription("Duration of database operations"),
+		metric.WithUnit("s"),
+	); err != nil {
+		slog.Debug("Failed to create metric", "error", err)
+	}
+	return i
+})
+
+// end finishes span and records its duration on histogram, marking both as
+// failed when err is not nil.
+func end(ctx context.Context, span trace.Span, histogram metric.Float64Histogram, start time.Time, err error, attrs ...attribute.KeyValue) {
+	if err != nil {
+		attrs = append(attrs, attrErrorType.String(fmt.Sprintf("%T", err)))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if histogram != nil {
+		histogram.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	}
+	span.End()
+}
@@ -0,0 +1,102 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"charm.land/fantasy"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedModel wraps a language model with a span and metrics per request.
+type tracedModel struct {
+	fantasy.LanguageModel
+}
+
+// WrapModel instruments the Generate and Stream calls of model.
+func WrapModel(model fantasy.LanguageModel) fantasy.LanguageModel {
+	if model == nil {
+		return nil
+	}
+	if _, ok := model.(*tracedModel); ok {
+		return model
+	}
+	return &tracedModel{LanguageModel: model}
+}
+
+func (m *tracedModel) attrs(operation string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attrGenAIOperation.String(operation),
+		attrGenAISystem.String(m.Provider()),
+		attrGenAIModel.String(m.Model()),
+	}
+}
+
+func (m *tracedModel) start(ctx context.Context, operation string) (context.Context, trace.Span, []attribute.KeyValue) {
+	attrs := m.attrs(operation)
+	ctx, span := tracer().Start(ctx, operation+" "+m.Model(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx, span, attrs
+}
+
+func (m *tracedModel) finish(ctx context.Context, span trace.Span, start time.Time, usage fantasy.Usage, finish fantasy.FinishReason, err error, attrs []attribute.KeyValue) {
+	span.SetAttributes(
+		attrGenAIInputToks.Int64(usage.InputTokens),
+		attrGenAIOutputToks.Int64(usage.OutputTokens),
+	)
+	if finish != "" {
+		span.SetAttributes(attrGenAIFinish.StringSlice([]string{string(finish)}))
+	}
+	i := meters()
+	if i.tokenUsage != nil && err == nil {
+		i.tokenUsage.Record(ctx, usage.InputTokens, metric.WithAttributes(append(attrs, attrGenAITokenType.String("input"))...))
+		i.tokenUsage.Record(ctx, usage.OutputTokens, metric.WithAttributes(append(attrs, attrGenAITokenType.String("output"))...))
+	}
+	end(ctx, span, i.providerDuration, start, err, attrs...)
+}
+
+func (m *tracedModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	start := time.Now()
+	ctx, span, attrs := m.start(ctx, "chat")
+	resp, err := m.LanguageModel.Generate(ctx, call)
+	if resp != nil {
+		m.finish(ctx, span, start, resp.Usage, resp.FinishReason, err, attrs)
+	} else {
+		m.finish(ctx, span, start, fantasy.Usage{}, "", err, attrs)
+	}
+	return resp, err
+}
+
+func (m *tracedModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	start := time.Now()
+	ctx, span, attrs := m.start(ctx, "chat")
+	stream, err := m.LanguageModel.Stream(ctx, call)
+	if err != nil {
+		m.finish(ctx, span, start, fantasy.Usage{}, "", err, attrs)
+		return nil, err
+	}
+	return func(yield func(fantasy.StreamPart) bool) {
+		var usage fantasy.Usage
+		var finish fantasy.FinishReason
+		var streamErr error
+		defer func() {
+			m.finish(ctx, span, start, usage, finish, streamErr, attrs)
+		}()
+		for part := range stream {
+			switch part.Type {
+			case fantasy.StreamPartTypeFinish:
+				usage = part.Usage
+				finish = part.FinishReason
+			case fantasy.StreamPartTypeError:
+				streamErr = part.Error
+			}
+			if !yield(part) {
+				return
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,173 @@
+// Package telemetry exports OpenTelemetry traces and metrics for provider
+// requests, tool executions and database operations.
+//
+// Export is enabled by the telemetry config block or the standard OTEL_*
+// environment variables. When it is off the global no-op providers are left
+// in place, so the instrumentation costs next to nothing.
+package telemetry
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/charmbracelet/crush"
+	defaultServiceName  = "crush"
+)
+
+// Enabled reports whether telemetry should be exported for cfg.
+func Enabled(cfg *config.Config) bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	if cfg.Telemetry != nil {
+		if cfg.Telemetry.Disabled {
+			return false
+		}
+		if cfg.Telemetry.Endpoint != "" {
+			return true
+		}
+	}
+	return !envUnset(
+		"OTEL_EXPORTER_OTLP_ENDPOINT",
+		"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT",
+		"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT",
+	)
+}
+
+// Setup installs the global tracer and meter providers exporting over
+// OTLP/HTTP. The returned function flushes and stops them; it is a no-op
+// when telemetry is not enabled.
+func Setup(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+	if !Enabled(cfg) {
+		return noop, nil
+	}
+
+	var tcfg config.TelemetryConfig
+	if cfg.Telemetry != nil {
+		tcfg = *cfg.Telemetry
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceName(cmp.Or(tcfg.ServiceName, defaultServiceName)),
+			semconv.ServiceVersion(version.Version),
+		),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return noop, fmt.Errorf("failed to create telemetry resource: %w", err)
+	}
+
+	headers := resolveHeaders(cfg, tcfg.Headers)
+
+	var traceOpts []otlptracehttp.Option
+	var metricOpts []otlpmetrichttp.Option
+	// Environment variables take precedence over the config block.
+	endpoint := strings.TrimSuffix(tcfg.Endpoint, "/")
+	if endpoint != "" && envUnset("OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") {
+		traceOpts = append(traceOpts, otlptracehttp.WithEndpointURL(endpoint+"/v1/traces"))
+	}
+	if endpoint != "" && envUnset("OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_METRICS_ENDPOINT") {
+		metricOpts = append(metricOpts, otlpmetrichttp.WithEndpointURL(endpoint+"/v1/metrics"))
+	}
+	if len(headers) > 0 && envUnset("OTEL_EXPORTER_OTLP_HEADERS") {
+		traceOpts = append(traceOpts, otlptracehttp.WithHeaders(headers))
+		metricOpts = append(metricOpts, otlpmetrichttp.WithHeaders(headers))
+	}
+
+	traceExporter, err := otlptracehttp.New(ctx, traceOpts...)
+	if err != nil {
+		return noop, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOpts...)
+	if err != nil {
+		return noop, fmt.Errorf("failed to create metric exporter: %w", err)
+	}
+
+	tracerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithResource(res),
+	}
+	if tcfg.SampleRatio != nil && envUnset("OTEL_TRACES_SAMPLER") {
+		tracerOpts = append(tracerOpts, sdktrace.WithSampler(
+			sdktrace.ParentBased(sdktrace.TraceIDRatioBased(*tcfg.SampleRatio)),
+		))
+	}
+	tracerProvider := sdktrace.NewTracerProvider(tracerOpts...)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		sdkmetric.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Debug("OpenTelemetry error", "error", err)
+	}))
+
+	slog.Info("OpenTelemetry export enabled")
+	return func(ctx context.Context) error {
+		return errors.Join(
+			tracerProvider.Shutdown(ctx),
+			meterProvider.Shutdown(ctx),
+		)
+	}, nil
+}
+
+func envUnset(keys ...string) bool {
+	for _, key := range keys {
+		if os.Getenv(key) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func resolveHeaders(cfg *config.Config, headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	resolved := make(map[string]string, len(headers))
+	for k, v := range headers {
+		value, err := cfg.Resolver().ResolveValue(v)
+		if err != nil {
+			slog.Warn("Failed to resolve telemetry header", "header", k, "error", err)
+			continue
+		}
+		resolved[k] = value
+	}
+	return resolved
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName, trace.WithInstrumentationVersion(version.Version))
+}
+
+func meter() metric.Meter {
+	return otel.Meter(instrumentationName, metric.WithInstrumentationVersion(version.Version))
+}
@@ -0,0 +1,136 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recorder collects the spans of every test. The global tracer provider can
+// only be installed once per process, so the tests do not run in parallel.
+var recorder = tracetest.NewSpanRecorder()
+
+func TestMain(m *testing.M) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	os.Exit(m.Run())
+}
+
+func lastSpan(t *testing.T) sdktrace.ReadOnlySpan {
+	t.Helper()
+	spans := recorder.Ended()
+	require.NotEmpty(t, spans)
+	return spans[len(spans)-1]
+}
+
+type fakeModel struct {
+	fantasy.LanguageModel
+	parts []fantasy.StreamPart
+}
+
+func (fakeModel) Provider() string { return "fake" }
+func (fakeModel) Model() string    { return "fake-1" }
+
+func (m fakeModel) Stream(context.Context, fantasy.Call) (fantasy.StreamResponse, error) {
+	return func(yield func(fantasy.StreamPart) bool) {
+		for _, part := range m.parts {
+			if !yield(part) {
+				return
+			}
+		}
+	}, nil
+}
+
+func TestWrapModelStream(t *testing.T) {
+	model := WrapModel(fakeModel{parts: []fantasy.StreamPart{
+		{Type: fantasy.StreamPartTypeTextDelta, Delta: "hi"},
+		{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonStop, Usage: fantasy.Usage{InputTokens: 10, OutputTokens: 5}},
+	}})
+	require.Same(t, model, WrapModel(model))
+	require.Equal(t, "fake-1", model.Model())
+
+	stream, err := model.Stream(t.Context(), fantasy.Call{})
+	require.NoError(t, err)
+	var parts int
+	for range stream {
+		parts++
+	}
+	require.Equal(t, 2, parts)
+
+	span := lastSpan(t)
+	require.Equal(t, "chat fake-1", span.Name())
+	require.Contains(t, span.Attributes(), attrGenAISystem.String("fake"))
+	require.Contains(t, span.Attributes(), attrGenAIInputToks.Int64(10))
+	require.Contains(t, span.Attributes(), attrGenAIOutputToks.Int64(5))
+	require.Equal(t, codes.Unset, span.Status().Code)
+}
+
+func TestWrapModelStreamError(t *testing.T) {
+	model := WrapModel(fakeModel{parts: []fantasy.StreamPart{
+		{Type: fantasy.StreamPartTypeError, Error: errors.New("rate limited")},
+	}})
+	stream, err := model.Stream(t.Context(), fantasy.Call{})
+	require.NoError(t, err)
+	for range stream {
+	}
+
+	span := lastSpan(t)
+	require.Equal(t, codes.Error, span.Status().Code)
+	require.Equal(t, "rate limited", span.Status().Description)
+}
+
+func TestWrapTool(t *testing.T) {
+	tool := WrapTools([]fantasy.AgentTool{
+		fantasy.NewAgentTool("echo", "Echo", func(_ context.Context, input struct{ Fail bool }, _ fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if input.Fail {
+				return fantasy.NewTextErrorResponse("failed"), nil
+			}
+			return fantasy.NewTextResponse("ok"), nil
+		}),
+	})[0]
+	require.Equal(t, "echo", tool.Info().Name)
+
+	resp, err := tool.Run(t.Context(), fantasy.ToolCall{ID: "call-1", Name: "echo", Input: `{}`})
+	require.NoError(t, err)
+	require.Equal(t, "ok", resp.Content)
+	span := lastSpan(t)
+	require.Equal(t, "execute_tool echo", span.Name())
+	require.Contains(t, span.Attributes(), attrToolCallID.String("call-1"))
+	require.Equal(t, codes.Unset, span.Status().Code)
+
+	_, err = tool.Run(t.Context(), fantasy.ToolCall{ID: "call-2", Name: "echo", Input: `{"Fail":true}`})
+	require.NoError(t, err)
+	require.Equal(t, codes.Error, lastSpan(t).Status().Code)
+}
+
+func TestQueryName(t *testing.T) {
+	m := queryNameRE.FindStringSubmatch("-- name: GetSessionByID :one\nSELECT * FROM sessions")
+	require.Equal(t, "GetSessionByID", m[1])
+	require.Nil(t, queryNameRE.FindStringSubmatch("SELECT 1"))
+}
+
+func TestEnabled(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "")
+	t.Setenv("OTEL_SDK_DISABLED", "")
+
+	require.False(t, Enabled(&config.Config{}))
+	require.True(t, Enabled(&config.Config{Telemetry: &config.TelemetryConfig{Endpoint: "http://localhost:4318"}}))
+	require.False(t, Enabled(&config.Config{Telemetry: &config.TelemetryConfig{Endpoint: "http://localhost:4318", Disabled: true}}))
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+	require.True(t, Enabled(&config.Config{}))
+	require.False(t, Enabled(&config.Config{Telemetry: &config.TelemetryConfig{Disabled: true}}))
+
+	t.Setenv("OTEL_SDK_DISABLED", "true")
+	require.False(t, Enabled(&config.Config{}))
+}
@@ -0,0 +1,55 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"charm.land/fantasy"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedTool wraps an agent tool with a span and a duration metric per
+// execution.
+type tracedTool struct {
+	fantasy.AgentTool
+}
+
+// WrapTool instruments the executions of tool.
+func WrapTool(tool fantasy.AgentTool) fantasy.AgentTool {
+	if _, ok := tool.(*tracedTool); ok {
+		return tool
+	}
+	return &tracedTool{AgentTool: tool}
+}
+
+// WrapTools instruments every tool in tools in place and returns the slice.
+func WrapTools(tools []fantasy.AgentTool) []fantasy.AgentTool {
+	for i, tool := range tools {
+		tools[i] = WrapTool(tool)
+	}
+	return tools
+}
+
+func (t *tracedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	name := t.Info().Name
+	attrs := []attribute.KeyValue{
+		attrGenAIOperation.String("execute_tool"),
+		attrToolName.String(name),
+	}
+	start := time.Now()
+	ctx, span := tracer().Start(ctx, "execute_tool "+name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(append(attrs, attrToolCallID.String(call.ID))...),
+	)
+
+	resp, err := t.AgentTool.Run(ctx, call)
+	if err == nil && resp.IsError {
+		// The tool ran but reported a failure back to the model.
+		attrs = append(attrs, attrErrorType.String("tool_error"))
+		span.SetStatus(codes.Error, resp.Content)
+	}
+	end(ctx, span, meters().toolDuration, start, err, attrs...)
+	return resp, err
+}
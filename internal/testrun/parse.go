@@ -0,0 +1,392 @@
+package testrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// maxMessageBytes caps the message kept for a single failure.
+	maxMessageBytes = 1500
+	// maxOutputBytes caps the raw output kept next to the failures.
+	maxOutputBytes = 8000
+	// headLines and tailLines are kept from output without parsed
+	// failures; the start shows what ran and the end why it stopped.
+	headLines = 30
+	tailLines = 70
+	// summaryLines are kept from output whose failures were parsed, which
+	// only needs the closing summary.
+	summaryLines = 25
+)
+
+// goEvent is a line of go test -json output.
+type goEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Output  string
+}
+
+var (
+	goLocation  = regexp.MustCompile(`^\s*([\w./\\-]+\.go):(\d+)(?::\d+)?:\s?(.*)$`)
+	goStackLine = regexp.MustCompile(`^\s+(\S+\.go):(\d+)`)
+)
+
+func parseGo(output string) Result {
+	var result Result
+	var transcript strings.Builder
+	testOutput := map[string][]string{}
+	pkgOutput := map[string][]string{}
+	var failed []goEvent
+	failedPkgs := map[string]bool{}
+
+	for line := range strings.Lines(output) {
+		var ev goEvent
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &ev) != nil {
+			// Build errors are printed as plain text.
+			transcript.WriteString(line)
+			pkgOutput[""] = append(pkgOutput[""], strings.TrimRight(line, "\n"))
+			continue
+		}
+		switch ev.Action {
+		case "output", "build-output":
+			transcript.WriteString(ev.Output)
+			text := strings.TrimRight(ev.Output, "\n")
+			if ev.Test != "" {
+				key := ev.Package + "\x00" + ev.Test
+				testOutput[key] = append(testOutput[key], text)
+			} else {
+				pkgOutput[ev.Package] = append(pkgOutput[ev.Package], text)
+			}
+		case "pass":
+			if ev.Test != "" {
+				result.Passed++
+			}
+		case "skip":
+			if ev.Test != "" {
+				result.Skipped++
+			}
+		case "fail":
+			if ev.Test != "" {
+				result.Failed++
+				failed = append(failed, ev)
+			} else {
+				failedPkgs[ev.Package] = true
+			}
+		}
+	}
+
+	for _, ev := range failed {
+		// A parent fails along with its subtests; report the subtests.
+		if slices.ContainsFunc(failed, func(other goEvent) bool {
+			return other.Package == ev.Package && strings.HasPrefix(other.Test, ev.Test+"/")
+		}) {
+			continue
+		}
+		failure := goFailure(testOutput[ev.Package+"\x00"+ev.Test])
+		failure.Test = ev.Package + "." + ev.Test
+		result.Failures = append(result.Failures, failure)
+	}
+
+	// Packages can also fail without a failing test, when they do not build
+	// or crash outside a test. Compiler errors are printed outside any
+	// package.
+	result.Failures = append(result.Failures, goBuildFailures("", pkgOutput[""])...)
+	for _, pkg := range slices.Sorted(maps.Keys(failedPkgs)) {
+		if slices.ContainsFunc(failed, func(ev goEvent) bool { return ev.Package == pkg }) {
+			continue
+		}
+		result.Failures = append(result.Failures, goBuildFailures(pkg, pkgOutput[pkg])...)
+	}
+
+	result.Output = transcript.String()
+	return result
+}
+
+// goFailure finds where a test failed in its output: the first t.Error
+// style location, or the first frame of a panic outside the runtime.
+func goFailure(lines []string) Failure {
+	var failure Failure
+	var message []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") {
+			continue
+		}
+		// The goroutine dump of a panic is only searched for a location.
+		if strings.HasPrefix(trimmed, "goroutine ") {
+			break
+		}
+		if failure.File == "" {
+			if m := goLocation.FindStringSubmatch(line); m != nil {
+				failure.File = m[1]
+				failure.Line, _ = strconv.Atoi(m[2])
+				message = append(message, m[3])
+				continue
+			}
+		}
+		message = append(message, trimmed)
+	}
+	if failure.File == "" {
+		for _, line := range lines {
+			m := goStackLine.FindStringSubmatch(line)
+			if m != nil && !strings.Contains(m[1], "/runtime/") && !strings.Contains(m[1], "/testing/") {
+				failure.File = m[1]
+				failure.Line, _ = strconv.Atoi(m[2])
+				break
+			}
+		}
+	}
+	failure.Message = joinMessage(message)
+	return failure
+}
+
+// goBuildFailures turns compiler output into one failure per error.
+func goBuildFailures(pkg string, lines []string) []Failure {
+	var failures []Failure
+	for _, line := range lines {
+		m := goLocation.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		lineNo, _ := strconv.Atoi(m[2])
+		failures = append(failures, Failure{Test: "build", File: m[1], Line: lineNo, Message: m[3]})
+	}
+	buildFailed := slices.ContainsFunc(lines, func(line string) bool {
+		return strings.Contains(line, "[build failed]") || strings.Contains(line, "[setup failed]")
+	})
+	if len(failures) == 0 && pkg != "" && !buildFailed {
+		failures = append(failures, Failure{Test: pkg, Message: joinMessage(lines)})
+	}
+	return failures
+}
+
+var (
+	pytestLocation = regexp.MustCompile(`^(\S+\.py):(\d+): (.+)$`)
+	pytestSummary  = regexp.MustCompile(`^(FAILED|ERROR) (\S+)(?: - (.*))?$`)
+	testCounts     = regexp.MustCompile(`(\d+) (passed|failed|skipped|errors?|xfailed|xpassed|passing|failing|pending|todo)\b`)
+)
+
+func parsePytest(output string) Result {
+	var result Result
+	var locations []Failure
+	var summary []Failure
+	var countLine string
+	for line := range strings.Lines(output) {
+		line = strings.TrimRight(line, "\n")
+		if m := pytestLocation.FindStringSubmatch(line); m != nil {
+			lineNo, _ := strconv.Atoi(m[2])
+			locations = append(locations, Failure{File: m[1], Line: lineNo, Message: m[3]})
+			continue
+		}
+		if m := pytestSummary.FindStringSubmatch(line); m != nil {
+			summary = append(summary, Failure{
+				Test:    m[2],
+				File:    strings.SplitN(m[2], "::", 2)[0],
+				Message: m[3],
+			})
+			continue
+		}
+		if testCounts.MatchString(line) {
+			countLine = line
+		}
+	}
+
+	used := make([]bool, len(locations))
+	for _, failure := range summary {
+		// --tb=line prints one location per failure, but errors come before
+		// failures there and after them in the summary, so pair them up by
+		// file, or by message for errors raised in fixtures.
+		for i, location := range locations {
+			if used[i] || !strings.HasSuffix(filepath.ToSlash(location.File), filepath.ToSlash(failure.File)) && location.Message != failure.Message {
+				continue
+			}
+			used[i] = true
+			failure.File = location.File
+			failure.Line = location.Line
+			failure.Message = location.Message
+			break
+		}
+		result.Failures = append(result.Failures, failure)
+	}
+	if len(summary) == 0 {
+		result.Failures = locations
+	}
+	countTests(&result, countLine)
+	return result
+}
+
+var (
+	cargoTest        = regexp.MustCompile(`^test (\S+) \.\.\. (ok|FAILED|ignored)`)
+	cargoPanic       = regexp.MustCompile(`^thread '([^']+)' panicked at (\S+?):(\d+):\d+:$`)
+	cargoPanicOld    = regexp.MustCompile(`^thread '([^']+)' panicked at '(.*)', (\S+?):(\d+):\d+`)
+	cargoError       = regexp.MustCompile(`^error(?:\[\w+\])?: (.+)$`)
+	cargoErrorSource = regexp.MustCompile(`^\s*--> (\S+?):(\d+):\d+`)
+)
+
+func parseCargo(output string) Result {
+	var result Result
+	var failedTests []string
+	panics := map[string]Failure{}
+	lines := slices.Collect(strings.Lines(output))
+	var buildError string
+
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\n")
+		if m := cargoTest.FindStringSubmatch(line); m != nil {
+			switch m[2] {
+			case "ok":
+				result.Passed++
+			case "ignored":
+				result.Skipped++
+			default:
+				result.Failed++
+				failedTests = append(failedTests, m[1])
+			}
+			continue
+		}
+		if m := cargoPanic.FindStringSubmatch(line); m != nil {
+			lineNo, _ := strconv.Atoi(m[3])
+			failure := Failure{Test: m[1], File: m[2], Line: lineNo}
+			var message []string
+			for _, next := range lines[i+1:] {
+				next = strings.TrimRight(next, "\n")
+				if next == "" || strings.HasPrefix(next, "stack backtrace:") || strings.HasPrefix(next, "note: ") {
+					break
+				}
+				message = append(message, next)
+			}
+			failure.Message = joinMessage(message)
+			panics[m[1]] = failure
+			continue
+		}
+		if m := cargoPanicOld.FindStringSubmatch(line); m != nil {
+			lineNo, _ := strconv.Atoi(m[4])
+			panics[m[1]] = Failure{Test: m[1], File: m[3], Line: lineNo, Message: m[2]}
+			continue
+		}
+		if m := cargoError.FindStringSubmatch(line); m != nil {
+			buildError = m[1]
+			continue
+		}
+		if m := cargoErrorSource.FindStringSubmatch(line); m != nil && buildError != "" {
+			lineNo, _ := strconv.Atoi(m[2])
+			result.Failures = append(result.Failures, Failure{Test: "build", File: m[1], Line: lineNo, Message: buildError})
+			buildError = ""
+		}
+	}
+
+	for _, name := range failedTests {
+		failure, ok := panics[name]
+		if !ok {
+			failure = Failure{Test: name}
+		}
+		result.Failures = append(result.Failures, failure)
+	}
+	return result
+}
+
+var (
+	jsLocation = regexp.MustCompile(`\(?((?:[\w@.-]+/)*[\w@.-]+\.[cm]?[jt]sx?):(\d+):\d+\)?`)
+	jsCounts   = regexp.MustCompile(`^\s*(Tests:?|\d+ (passing|failing|pending))`)
+)
+
+// parseJS reads Jest, Vitest and Mocha style output, as npm test can run
+// any of them.
+func parseJS(output string) Result {
+	var result Result
+	lines := slices.Collect(strings.Lines(ansi.Strip(output)))
+	var current *Failure
+	flush := func() {
+		if current != nil {
+			current.Message = strings.TrimSpace(current.Message)
+			result.Failures = append(result.Failures, *current)
+			current = nil
+		}
+	}
+
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\n")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "● ") && !strings.HasPrefix(trimmed, "● Console"):
+			flush()
+			current = &Failure{Test: strings.TrimPrefix(trimmed, "● ")}
+			continue
+		case strings.HasPrefix(trimmed, "FAIL ") && strings.Contains(trimmed, " > "):
+			flush()
+			name := strings.TrimSpace(strings.TrimPrefix(trimmed, "FAIL "))
+			current = &Failure{Test: name, File: strings.SplitN(name, " > ", 2)[0]}
+			continue
+		}
+		if jsCounts.MatchString(line) {
+			countTests(&result, line)
+		}
+		if current == nil || trimmed == "" {
+			continue
+		}
+		if current.Line == 0 {
+			if m := jsLocation.FindStringSubmatch(trimmed); m != nil && !strings.Contains(m[1], "node_modules") {
+				current.File = m[1]
+				current.Line, _ = strconv.Atoi(m[2])
+				continue
+			}
+		}
+		if current.Message == "" || len(current.Message) < maxMessageBytes && !strings.HasPrefix(trimmed, "at ") {
+			current.Message += trimmed + "\n"
+		}
+	}
+	flush()
+	return result
+}
+
+// countTests adds the counts in a summary line to result.
+func countTests(result *Result, line string) {
+	for _, m := range testCounts.FindAllStringSubmatch(line, -1) {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "passed", "passing", "xfailed":
+			result.Passed += n
+		case "failed", "failing", "error", "errors", "xpassed":
+			result.Failed += n
+		case "skipped", "pending", "todo":
+			result.Skipped += n
+		}
+	}
+}
+
+func joinMessage(lines []string) string {
+	message := strings.TrimSpace(strings.Join(lines, "\n"))
+	if len(message) > maxMessageBytes {
+		message = message[:maxMessageBytes] + "\n..."
+	}
+	return message
+}
+
+// trimOutput keeps the parts of output worth reading: the closing summary
+// when the failures were parsed, or the start and end of it otherwise.
+func trimOutput(output string, hasFailures bool) string {
+	output = strings.TrimSpace(ansi.Strip(output))
+	lines := strings.Split(output, "\n")
+	switch {
+	case hasFailures && len(lines) > summaryLines:
+		lines = lines[len(lines)-summaryLines:]
+		lines = append([]string{"..."}, lines...)
+	case len(lines) > headLines+tailLines:
+		omitted := len(lines) - headLines - tailLines
+		lines = slices.Concat(lines[:headLines], []string{fmt.Sprintf("... (%d lines omitted) ...", omitted)}, lines[len(lines)-tailLines:])
+	}
+	output = strings.Join(lines, "\n")
+	if len(output) > maxOutputBytes {
+		output = "...\n" + output[len(output)-maxOutputBytes:]
+	}
+	return output
+}
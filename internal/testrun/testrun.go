@@ -0,0 +1,233 @@
+// Package testrun runs a project's tests with the usual tool for its
+// language and turns the output into structured failures, so the agent gets
+// the file, line and message of each failure instead of pages of logs.
+package testrun
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+// Framework is a test runner testrun knows how to drive.
+type Framework string
+
+const (
+	Go     Framework = "go"
+	Pytest Framework = "pytest"
+	Cargo  Framework = "cargo"
+	NPM    Framework = "npm"
+)
+
+// Frameworks lists the supported frameworks.
+var Frameworks = []Framework{Go, Pytest, Cargo, NPM}
+
+// ErrNoFramework is returned when no framework is given and none can be
+// detected from the project files.
+var ErrNoFramework = errors.New("could not detect the test framework")
+
+// markers are the files that identify each framework's projects, in the
+// order they are tried.
+var markers = []struct {
+	framework Framework
+	files     []string
+}{
+	{Go, []string{"go.mod"}},
+	{Cargo, []string{"Cargo.toml"}},
+	{Pytest, []string{"pytest.ini", "pyproject.toml", "setup.cfg", "tox.ini", "conftest.py", "setup.py"}},
+	{NPM, []string{"package.json"}},
+}
+
+const (
+	// DefaultTimeout bounds a test run when no timeout is given.
+	DefaultTimeout = 5 * time.Minute
+	// maxFailures is the number of failures reported in detail.
+	maxFailures = 20
+)
+
+// Options select what to run.
+type Options struct {
+	// Framework to use. It is detected from dir when empty.
+	Framework Framework
+	// Dir is the directory to run the tests in.
+	Dir string
+	// Filter limits the run to tests matching it, using each framework's
+	// own name filter.
+	Filter string
+	// Timeout bounds the run. It defaults to [DefaultTimeout].
+	Timeout time.Duration
+}
+
+// Failure is a single failed test or build error.
+type Failure struct {
+	Test    string `json:"test,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Location returns "file:line", or an empty string when the failure has no
+// known location.
+func (f Failure) Location() string {
+	switch {
+	case f.File == "":
+		return ""
+	case f.Line == 0:
+		return f.File
+	default:
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+}
+
+// Result is the outcome of a test run.
+type Result struct {
+	Framework Framework `json:"framework"`
+	Command   string    `json:"command"`
+	Dir       string    `json:"dir"`
+	Passed    int       `json:"passed"`
+	Failed    int       `json:"failed"`
+	Skipped   int       `json:"skipped"`
+	Failures  []Failure `json:"failures,omitempty"`
+	ExitCode  int       `json:"exit_code"`
+	TimedOut  bool      `json:"timed_out,omitempty"`
+	// Output is the raw output trimmed to the parts worth reading.
+	Output string `json:"-"`
+}
+
+// OK reports whether every test passed.
+func (r Result) OK() bool {
+	return r.ExitCode == 0 && !r.TimedOut && r.Failed == 0 && len(r.Failures) == 0
+}
+
+// Detect returns the framework of the closest project above dir.
+func Detect(dir string) (Framework, error) {
+	best, bestDir := Framework(""), ""
+	for _, m := range markers {
+		for _, file := range m.files {
+			found, ok := fsext.LookupClosest(dir, file)
+			// Prefer the closest project; ties go to the earlier framework.
+			if ok && len(filepath.Dir(found)) > len(bestDir) {
+				best, bestDir = m.framework, filepath.Dir(found)
+			}
+		}
+	}
+	if best == "" {
+		return "", ErrNoFramework
+	}
+	return best, nil
+}
+
+// Command returns the program and arguments that run the tests.
+func Command(framework Framework, filter string) (string, []string, error) {
+	switch framework {
+	case Go:
+		args := []string{"test", "-json"}
+		if filter != "" {
+			args = append(args, "-run", filter)
+		}
+		return "go", append(args, "./..."), nil
+	case Pytest:
+		args := []string{"-q", "--tb=line", "-rfE", "-p", "no:cacheprovider"}
+		if filter != "" {
+			args = append(args, "-k", filter)
+		}
+		return "pytest", args, nil
+	case Cargo:
+		args := []string{"test", "--no-fail-fast"}
+		if filter != "" {
+			args = append(args, filter)
+		}
+		return "cargo", args, nil
+	case NPM:
+		args := []string{"test", "--silent"}
+		if filter != "" {
+			// Jest and Vitest both take -t to filter by test name.
+			args = append(args, "--", "-t", filter)
+		}
+		return "npm", args, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported test framework %q", framework)
+	}
+}
+
+// Run runs the tests described by opts and parses the results. Failing
+// tests are not an error; err is only set when the tests could not be run.
+func Run(ctx context.Context, opts Options) (Result, error) {
+	framework := opts.Framework
+	if framework == "" {
+		var err error
+		if framework, err = Detect(opts.Dir); err != nil {
+			return Result{}, err
+		}
+	}
+	name, args, err := Command(framework, opts.Filter)
+	if err != nil {
+		return Result{}, err
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return Result{}, fmt.Errorf("%s is not installed", name)
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, name, args...)
+	cmd.Dir = opts.Dir
+	// Keep color codes, backtraces and interactive watchers out of the
+	// output.
+	cmd.Env = append(os.Environ(), "CI=1", "NO_COLOR=1", "FORCE_COLOR=0", "RUST_BACKTRACE=0")
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return Result{}, ctx.Err()
+	}
+
+	result := Parse(framework, output.String())
+	result.Framework = framework
+	result.Command = strings.Join(append([]string{name}, args...), " ")
+	result.Dir = opts.Dir
+	result.TimedOut = runCtx.Err() != nil
+	if exitErr := (*exec.ExitError)(nil); errors.As(runErr, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+	} else if runErr != nil && !result.TimedOut {
+		return Result{}, fmt.Errorf("failed to run %s: %w", name, runErr)
+	}
+	return result, nil
+}
+
+// Parse reads the output of a test run.
+func Parse(framework Framework, output string) Result {
+	var result Result
+	switch framework {
+	case Go:
+		result = parseGo(output)
+	case Pytest:
+		result = parsePytest(output)
+	case Cargo:
+		result = parseCargo(output)
+	default:
+		result = parseJS(output)
+	}
+	if len(result.Failures) > maxFailures {
+		result.Failures = result.Failures[:maxFailures]
+	}
+	if result.Output == "" {
+		result.Output = output
+	}
+	result.Output = trimOutput(result.Output, len(result.Failures) > 0)
+	return result
+}
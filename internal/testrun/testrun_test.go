@@ -0,0 +1,158 @@
+package testrun
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestParseGo(t *testing.T) {
+	t.Parallel()
+
+	result := Parse(Go, readFixture(t, "go.json"))
+	require.Equal(t, 1, result.Passed)
+	require.Equal(t, 3, result.Failed)
+	require.Equal(t, 1, result.Skipped)
+	require.Equal(t, []Failure{
+		{Test: "example.com/gt/a.TestBad/sub", File: "a_test.go", Line: 7, Message: "want 1, got 2"},
+		{Test: "example.com/gt/a.TestPanic", File: "/tmp/gt/a/a_test.go", Line: 10, Message: result.Failures[1].Message},
+		{Test: "build", File: "b/b.go", Line: 3, Message: "undefined: undefined"},
+	}, result.Failures)
+	require.Contains(t, result.Failures[1].Message, "assignment to entry in nil map")
+	require.NotContains(t, result.Failures[1].Message, "goroutine ")
+}
+
+func TestParsePytest(t *testing.T) {
+	t.Parallel()
+
+	result := Parse(Pytest, readFixture(t, "pytest.txt"))
+	require.Equal(t, 4, result.Passed)
+	require.Equal(t, 2, result.Failed)
+	require.Equal(t, 1, result.Skipped)
+	require.Equal(t, []Failure{
+		{Test: "tests/test_math.py::test_add", File: "/home/user/app/tests/test_math.py", Line: 8, Message: "AssertionError: assert 2 == 3"},
+		{Test: "tests/test_db.py::test_database", File: "/home/user/app/tests/conftest.py", Line: 14, Message: "RuntimeError: database is not running"},
+	}, result.Failures)
+}
+
+func TestParseCargo(t *testing.T) {
+	t.Parallel()
+
+	result := Parse(Cargo, readFixture(t, "cargo.txt"))
+	require.Equal(t, 1, result.Passed)
+	require.Equal(t, 1, result.Failed)
+	require.Equal(t, 1, result.Skipped)
+	require.Equal(t, []Failure{{
+		Test:    "tests::broken",
+		File:    "src/lib.rs",
+		Line:    9,
+		Message: "assertion `left == right` failed: math is hard\n  left: 2\n right: 3",
+	}}, result.Failures)
+}
+
+func TestParseCargoBuildError(t *testing.T) {
+	t.Parallel()
+
+	result := Parse(Cargo, `   Compiling ct v0.1.0 (/tmp/ct)
+error[E0425]: cannot find value `+"`y`"+` in this scope
+ --> src/lib.rs:2:5
+  |
+2 |     y
+  |     ^ not found in this scope
+
+error: could not compile `+"`ct`"+` (lib test) due to 1 previous error
+`)
+	require.Equal(t, []Failure{{Test: "build", File: "src/lib.rs", Line: 2, Message: "cannot find value `y` in this scope"}}, result.Failures)
+}
+
+func TestParseJest(t *testing.T) {
+	t.Parallel()
+
+	result := Parse(NPM, readFixture(t, "jest.txt"))
+	require.Equal(t, 1, result.Passed)
+	require.Equal(t, 1, result.Failed)
+	require.Equal(t, 1, result.Skipped)
+	require.Len(t, result.Failures, 1)
+	failure := result.Failures[0]
+	require.Equal(t, "sum › adds negative numbers", failure.Test)
+	require.Equal(t, "src/sum.test.js:11", failure.Location())
+	require.Contains(t, failure.Message, "Expected: -3")
+	require.NotContains(t, failure.Message, "node_modules")
+}
+
+func TestParseCapsFailures(t *testing.T) {
+	t.Parallel()
+
+	var output strings.Builder
+	for i := range maxFailures + 5 {
+		fmt.Fprintf(&output, "FAILED tests/test_x.py::test_%d - boom\n", i)
+	}
+	result := Parse(Pytest, output.String())
+	require.Len(t, result.Failures, maxFailures)
+}
+
+func TestTrimOutput(t *testing.T) {
+	t.Parallel()
+
+	lines := make([]string, 500)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	output := strings.Join(lines, "\n")
+
+	trimmed := trimOutput(output, false)
+	require.Contains(t, trimmed, "line 0\n")
+	require.Contains(t, trimmed, "lines omitted")
+	require.True(t, strings.HasSuffix(trimmed, "line 499"))
+
+	trimmed = trimOutput(output, true)
+	require.NotContains(t, trimmed, "line 0\n")
+	require.Len(t, strings.Split(trimmed, "\n"), summaryLines+1)
+
+	require.LessOrEqual(t, len(trimOutput(strings.Repeat("x", 3*maxOutputBytes), false)), maxOutputBytes+4)
+}
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	web := filepath.Join(dir, "web", "src")
+	require.NoError(t, os.MkdirAll(web, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module x\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "web", "package.json"), []byte("{}"), 0o644))
+
+	framework, err := Detect(dir)
+	require.NoError(t, err)
+	require.Equal(t, Go, framework)
+
+	framework, err = Detect(web)
+	require.NoError(t, err)
+	require.Equal(t, NPM, framework, "the closest project wins")
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	name, args, err := Command(Go, "TestFoo")
+	require.NoError(t, err)
+	require.Equal(t, "go", name)
+	require.Equal(t, []string{"test", "-json", "-run", "TestFoo", "./..."}, args)
+
+	_, args, err = Command(NPM, "adds")
+	require.NoError(t, err)
+	require.Equal(t, []string{"test", "--silent", "--", "-t", "adds"}, args)
+
+	_, _, err = Command("maven", "")
+	require.Error(t, err)
+}
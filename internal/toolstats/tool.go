@@ -0,0 +1,45 @@
+package toolstats
+
+import (
+	"context"
+	"errors"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+)
+
+// measuredTool wraps an agent tool to record each execution.
+type measuredTool struct {
+	fantasy.AgentTool
+	recorder *Recorder
+}
+
+// WrapTools records the executions of every tool in tools in place and
+// returns the slice. It does nothing when r is nil.
+func (r *Recorder) WrapTools(agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	if r == nil {
+		return agentTools
+	}
+	for i, tool := range agentTools {
+		if _, ok := tool.(*measuredTool); !ok {
+			agentTools[i] = &measuredTool{AgentTool: tool, recorder: r}
+		}
+	}
+	return agentTools
+}
+
+func (t *measuredTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	start := t.recorder.now()
+	resp, err := t.AgentTool.Run(ctx, call)
+
+	failure := err
+	if failure == nil && resp.IsError {
+		// The tool ran but reported a failure back to the model.
+		failure = errors.New(resp.Content)
+	}
+	if ctx.Err() != nil {
+		failure = ctx.Err()
+	}
+	t.recorder.Record(tools.GetSessionFromContext(ctx), t.Info().Name, t.recorder.now().Sub(start), failure)
+	return resp, err
+}
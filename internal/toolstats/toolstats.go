@@ -0,0 +1,230 @@
+// Package toolstats keeps per-tool execution statistics: how often each tool
+// runs, how often it fails and how long it takes, for every session and for
+// the whole app. It warns when a tool keeps failing or runs unusually long,
+// which usually points at a configuration problem rather than a bad call.
+package toolstats
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+const (
+	// FailureStreak is the number of consecutive failures of a tool in a
+	// session that triggers a warning.
+	FailureStreak = 3
+	// SlowCall is how long a call may take before it triggers a warning.
+	SlowCall = 2 * time.Minute
+	// maxSamples caps the durations kept per tool for the percentiles, so
+	// long sessions do not grow without bound.
+	maxSamples = 1000
+)
+
+// Stats summarizes the executions of a tool.
+type Stats struct {
+	Tool     string `json:"tool"`
+	Calls    int    `json:"calls"`
+	Failures int    `json:"failures"`
+	// P50, P90 and P99 are latency percentiles over the most recent calls.
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+}
+
+// FailureRate returns the share of calls that failed, between 0 and 1.
+func (s Stats) FailureRate() float64 {
+	if s.Calls == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Calls)
+}
+
+// WarningKind is the reason for a [Warning].
+type WarningKind string
+
+const (
+	WarningFailing WarningKind = "failing"
+	WarningSlow    WarningKind = "slow"
+)
+
+// Warning is published when a tool fails repeatedly or a call is slow.
+type Warning struct {
+	Kind      WarningKind
+	SessionID string
+	Tool      string
+	// Failures is the number of consecutive failures, for WarningFailing.
+	Failures int
+	// Duration is how long the call took, for WarningSlow.
+	Duration time.Duration
+	// LastError is the error of the latest failed call.
+	LastError string
+}
+
+// Message describes the warning for the user.
+func (w Warning) Message() string {
+	switch w.Kind {
+	case WarningSlow:
+		return fmt.Sprintf("The %s tool took %s", w.Tool, w.Duration.Round(time.Second))
+	default:
+		return fmt.Sprintf("The %s tool failed %d times in a row, check its configuration", w.Tool, w.Failures)
+	}
+}
+
+// series is the record of one tool in one scope.
+type series struct {
+	calls     int
+	failures  int
+	streak    int
+	warnedAt  int
+	slowWarn  bool
+	samples   []time.Duration
+	next      int
+	max       time.Duration
+	lastError string
+}
+
+func (s *series) add(d time.Duration, failure string) {
+	s.calls++
+	s.max = max(s.max, d)
+	if len(s.samples) < maxSamples {
+		s.samples = append(s.samples, d)
+	} else {
+		s.samples[s.next] = d
+		s.next = (s.next + 1) % maxSamples
+	}
+	if failure == "" {
+		s.streak = 0
+		s.warnedAt = 0
+		return
+	}
+	s.failures++
+	s.streak++
+	s.lastError = failure
+}
+
+func (s *series) stats(tool string) Stats {
+	sorted := slices.Clone(s.samples)
+	slices.Sort(sorted)
+	return Stats{
+		Tool:     tool,
+		Calls:    s.calls,
+		Failures: s.failures,
+		P50:      percentile(sorted, 50),
+		P90:      percentile(sorted, 90),
+		P99:      percentile(sorted, 99),
+		Max:      s.max,
+	}
+}
+
+// percentile uses the nearest-rank method on sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// Recorder collects the statistics of the tools it wraps. It is safe for
+// concurrent use.
+type Recorder struct {
+	mu       sync.Mutex
+	global   map[string]*series
+	sessions map[string]map[string]*series
+	broker   *pubsub.Broker[Warning]
+	now      func() time.Time
+}
+
+// New returns an empty recorder.
+func New() *Recorder {
+	return &Recorder{
+		global:   make(map[string]*series),
+		sessions: make(map[string]map[string]*series),
+		broker:   pubsub.NewBroker[Warning](),
+		now:      time.Now,
+	}
+}
+
+// Subscribe returns a channel that receives the warnings of every session.
+func (r *Recorder) Subscribe(ctx context.Context) <-chan pubsub.Event[Warning] {
+	return r.broker.Subscribe(ctx)
+}
+
+// Record adds a call of tool that took d. err is the error of the call, or
+// the text of an error response; it is nil for successful calls. Denied
+// permissions and canceled calls are not counted as failures, since the tool
+// did not get to run.
+func (r *Recorder) Record(sessionID, tool string, d time.Duration, err error) {
+	failure := ""
+	if err != nil && !errors.Is(err, permission.ErrorPermissionDenied) && !errors.Is(err, context.Canceled) {
+		failure = err.Error()
+	}
+
+	r.mu.Lock()
+	get(r.global, tool).add(d, failure)
+	perSession, ok := r.sessions[sessionID]
+	if !ok {
+		perSession = make(map[string]*series)
+		r.sessions[sessionID] = perSession
+	}
+	s := get(perSession, tool)
+	s.add(d, failure)
+
+	var warnings []Warning
+	if s.streak >= FailureStreak && s.streak >= 2*s.warnedAt {
+		// Warn at 3 failures, then again at 6, 12 and so on, so a tool that
+		// keeps failing is not reported on every call.
+		s.warnedAt = s.streak
+		warnings = append(warnings, Warning{Kind: WarningFailing, SessionID: sessionID, Tool: tool, Failures: s.streak, LastError: s.lastError})
+	}
+	if d >= SlowCall && !s.slowWarn {
+		s.slowWarn = true
+		warnings = append(warnings, Warning{Kind: WarningSlow, SessionID: sessionID, Tool: tool, Duration: d})
+	}
+	r.mu.Unlock()
+
+	for _, w := range warnings {
+		r.broker.Publish(pubsub.CreatedEvent, w)
+	}
+}
+
+// Stats returns the statistics of every tool called in a session, or across
+// all sessions when sessionID is empty, sorted by tool name.
+func (r *Recorder) Stats(sessionID string) []Stats {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	scope := r.global
+	if sessionID != "" {
+		scope = r.sessions[sessionID]
+	}
+	stats := make([]Stats, 0, len(scope))
+	for tool, s := range scope {
+		stats = append(stats, s.stats(tool))
+	}
+	slices.SortFunc(stats, func(a, b Stats) int {
+		return cmp.Compare(a.Tool, b.Tool)
+	})
+	return stats
+}
+
+func get(scope map[string]*series, tool string) *series {
+	s, ok := scope[tool]
+	if !ok {
+		s = &series{}
+		scope[tool] = s
+	}
+	return s
+}
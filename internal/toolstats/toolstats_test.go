@@ -0,0 +1,94 @@
+package toolstats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderStats(t *testing.T) {
+	t.Parallel()
+
+	r := New()
+	for i := range 10 {
+		r.Record("a", "bash", time.Duration(i+1)*time.Second, nil)
+	}
+	r.Record("a", "bash", time.Second, errors.New("exit status 1"))
+	r.Record("b", "view", time.Millisecond, nil)
+	r.Record("b", "view", time.Millisecond, permission.ErrorPermissionDenied)
+
+	stats := r.Stats("a")
+	require.Len(t, stats, 1)
+	bash := stats[0]
+	require.Equal(t, "bash", bash.Tool)
+	require.Equal(t, 11, bash.Calls)
+	require.Equal(t, 1, bash.Failures)
+	require.InDelta(t, 1.0/11, bash.FailureRate(), 1e-9)
+	require.Equal(t, 5*time.Second, bash.P50)
+	require.Equal(t, 9*time.Second, bash.P90)
+	require.Equal(t, 10*time.Second, bash.P99)
+	require.Equal(t, 10*time.Second, bash.Max)
+
+	global := r.Stats("")
+	require.Len(t, global, 2)
+	require.Equal(t, "view", global[1].Tool)
+	require.Equal(t, 0, global[1].Failures, "denied permissions are not failures")
+	require.Empty(t, r.Stats("missing"))
+	require.Nil(t, (*Recorder)(nil).Stats(""))
+}
+
+func TestRecorderWarnings(t *testing.T) {
+	t.Parallel()
+
+	r := New()
+	warnings := r.Subscribe(t.Context())
+	fail := errors.New("lsp not configured")
+
+	for range FailureStreak - 1 {
+		r.Record("a", "lsp_diagnostics", time.Second, fail)
+	}
+	r.Record("b", "lsp_diagnostics", time.Second, fail)
+	r.Record("a", "lsp_diagnostics", time.Second, fail)
+	w := (<-warnings).Payload
+	require.Equal(t, Warning{Kind: WarningFailing, SessionID: "a", Tool: "lsp_diagnostics", Failures: FailureStreak, LastError: "lsp not configured"}, w)
+	require.Contains(t, w.Message(), "failed 3 times in a row")
+
+	r.Record("a", "lsp_diagnostics", time.Second, fail)
+	r.Record("a", "bash", SlowCall, nil)
+	w = (<-warnings).Payload
+	require.Equal(t, WarningSlow, w.Kind, "a continued streak is not reported again right away")
+	require.Equal(t, "The bash tool took 2m0s", w.Message())
+
+	r.Record("a", "bash", SlowCall, nil)
+	r.Record("a", "lsp_diagnostics", time.Second, nil)
+	for range FailureStreak {
+		r.Record("a", "lsp_diagnostics", time.Second, fail)
+	}
+	w = (<-warnings).Payload
+	require.Equal(t, WarningFailing, w.Kind, "a success starts a new streak")
+	require.Equal(t, FailureStreak, w.Failures)
+}
+
+func TestWrapTools(t *testing.T) {
+	t.Parallel()
+
+	r := New()
+	tool := fantasy.NewAgentTool("probe", "", func(ctx context.Context, params struct{}, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		return fantasy.NewTextErrorResponse("broken"), nil
+	})
+	wrapped := r.WrapTools(r.WrapTools([]fantasy.AgentTool{tool}))
+	ctx := context.WithValue(t.Context(), tools.SessionIDContextKey, "a")
+	_, err := wrapped[0].Run(ctx, fantasy.ToolCall{ID: "1", Name: "probe", Input: "{}"})
+	require.NoError(t, err)
+
+	stats := r.Stats("a")
+	require.Len(t, stats, 1)
+	require.Equal(t, 1, stats[0].Calls, "wrapping twice records once")
+	require.Equal(t, 1, stats[0].Failures)
+}
@@ -118,7 +118,7 @@ func (a *AssistantMessageItem) Render(width int) string {
 func (a *AssistantMessageItem) renderMessageContent(width int) string {
 	var messageParts []string
 	thinking := strings.TrimSpace(a.message.ReasoningContent().Thinking)
-	content := strings.TrimSpace(a.message.Content().Text)
+	content := strings.TrimSpace(a.Text())
 	// if the massage has reasoning content add that first
 	if thinking != "" {
 		messageParts = append(messageParts, a.renderThinking(a.message.ReasoningContent().Thinking, width))
@@ -148,8 +148,17 @@ func (a *AssistantMessageItem) renderMessageContent(width int) string {
 
 // renderThinking renders the thinking/reasoning content with footer.
 func (a *AssistantMessageItem) renderThinking(thinking string, width int) string {
-	renderer := common.PlainMarkdownRenderer(a.sty, width)
-	rendered, err := renderer.Render(thinking)
+	// Renders are cached once the message is finished; while it streams,
+	// each is of content never seen again.
+	var (
+		rendered string
+		err      error
+	)
+	if a.message.IsFinished() {
+		rendered, err = common.RenderPlainMarkdown(a.sty, thinking, width)
+	} else {
+		rendered, err = common.PlainMarkdownRenderer(a.sty, width).Render(thinking)
+	}
 	if err != nil {
 		rendered = thinking
 	}
@@ -179,6 +188,13 @@ func (a *AssistantMessageItem) renderThinking(thinking string, width int) string
 			footer = a.sty.Chat.Message.ThinkingFooterTitle.Render("Thought for ") +
 				a.sty.Chat.Message.ThinkingFooterDuration.Render(duration.String())
 		}
+		if tokens := a.message.ReasoningTokens; tokens > 0 {
+			if footer == "" {
+				footer = a.sty.Chat.Message.ThinkingFooterTitle.Render("Thought")
+			}
+			footer += a.sty.Chat.Message.ThinkingFooterTitle.Render(" using ") +
+				a.sty.Chat.Message.ThinkingFooterDuration.Render(common.FormatTokens(tokens)+" tokens")
+		}
 	}
 
 	if footer != "" {
@@ -190,8 +206,15 @@ func (a *AssistantMessageItem) renderThinking(thinking string, width int) string
 
 // renderMarkdown renders content as markdown.
 func (a *AssistantMessageItem) renderMarkdown(content string, width int) string {
-	renderer := common.MarkdownRenderer(a.sty, width)
-	result, err := renderer.Render(content)
+	var (
+		result string
+		err    error
+	)
+	if a.message.IsFinished() {
+		result, err = common.RenderMarkdown(a.sty, content, width)
+	} else {
+		result, err = common.MarkdownRenderer(a.sty, width).Render(content)
+	}
 	if err != nil {
 		return content
 	}
@@ -217,6 +240,15 @@ func (a *AssistantMessageItem) renderError(width int) string {
 	return fmt.Sprintf("%s\n\n%s", title, details)
 }
 
+// Text returns the text of the message as the user reads it, translated
+// to their language when the session translates the replies.
+func (a *AssistantMessageItem) Text() string {
+	if t, ok := a.message.Translation(); ok {
+		return t.Text
+	}
+	return a.message.Content().Text
+}
+
 // isSpinning returns true if the assistant message is still generating.
 func (a *AssistantMessageItem) isSpinning() bool {
 	isThinking := a.message.IsThinking()
@@ -259,7 +291,7 @@ func (a *AssistantMessageItem) HandleMouseClick(btn ansi.MouseButton, x, y int)
 // HandleKeyEvent implements KeyEventHandler.
 func (a *AssistantMessageItem) HandleKeyEvent(key tea.KeyMsg) (bool, tea.Cmd) {
 	if k := key.String(); k == "c" || k == "y" {
-		text := a.message.Content().Text
+		text := a.Text()
 		return true, common.CopyToClipboard(text, "Message copied to clipboard")
 	}
 	return false, nil
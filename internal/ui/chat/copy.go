@@ -0,0 +1,137 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+)
+
+// CopyPart is a part of a message item that can be copied on its own, such
+// as one of its code blocks.
+type CopyPart struct {
+	Title string
+	Text  string
+}
+
+// Copyable is an interface for items whose parts can be copied one by one.
+type Copyable interface {
+	// CopyParts returns the parts of the item, the whole of it first.
+	CopyParts() []CopyPart
+}
+
+// CopyParts implements [Copyable].
+func (m *UserMessageItem) CopyParts() []CopyPart {
+	text := m.message.Content().Text
+	return append([]CopyPart{{Title: "Message", Text: text}}, codeBlockParts(text)...)
+}
+
+// CopyParts implements [Copyable].
+func (a *AssistantMessageItem) CopyParts() []CopyPart {
+	text := a.Text()
+	parts := []CopyPart{{Title: "Message", Text: text}}
+	if thinking := strings.TrimSpace(a.message.ReasoningContent().Thinking); thinking != "" {
+		parts = append(parts, CopyPart{Title: "Thinking", Text: thinking})
+	}
+	return append(parts, codeBlockParts(text)...)
+}
+
+// CopyParts implements [Copyable].
+func (t *baseToolMessageItem) CopyParts() []CopyPart {
+	parts := []CopyPart{{Title: "Tool call", Text: t.formatToolForCopy()}}
+	if input := t.inputForCopy(); input != "" {
+		parts = append(parts, CopyPart{Title: "Input", Text: input})
+	}
+	if t.result != nil && t.result.Content != "" && t.result.Data == "" {
+		title := "Output"
+		if t.result.IsError {
+			title = "Error"
+		}
+		parts = append(parts, CopyPart{Title: title, Text: t.result.Content})
+	}
+	return parts
+}
+
+// inputForCopy returns the command of a bash call, and the parameters of
+// other calls as indented JSON.
+func (t *baseToolMessageItem) inputForCopy() string {
+	if t.toolCall.Name == tools.BashToolName {
+		var params tools.BashParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			return params.Command
+		}
+	}
+	var params any
+	if json.Unmarshal([]byte(t.toolCall.Input), &params) != nil {
+		return t.toolCall.Input
+	}
+	data, err := json.MarshalIndent(params, "", "  ")
+	if err != nil {
+		return t.toolCall.Input
+	}
+	return string(data)
+}
+
+// codeBlockParts returns the fenced code blocks of the Markdown text, titled
+// by their number and language.
+func codeBlockParts(text string) []CopyPart {
+	var parts []CopyPart
+	for i, block := range codeBlocks(text) {
+		title := fmt.Sprintf("Code block %d", i+1)
+		if block.lang != "" {
+			title += " (" + block.lang + ")"
+		}
+		parts = append(parts, CopyPart{Title: title, Text: block.code})
+	}
+	return parts
+}
+
+type codeBlock struct {
+	lang string
+	code string
+}
+
+// codeBlocks returns the fenced code blocks of the Markdown text. A block
+// left open runs to the end of the text, as it is rendered.
+func codeBlocks(text string) []codeBlock {
+	var (
+		blocks []codeBlock
+		fence  string
+		lang   string
+		lines  []string
+	)
+	for line := range strings.SplitSeq(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if fence == "" {
+			if f := fenceOf(trimmed); f != "" {
+				fence = f
+				lang, _, _ = strings.Cut(strings.TrimSpace(trimmed[len(f):]), " ")
+				lines = nil
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1])) == "" {
+			blocks = append(blocks, codeBlock{lang: lang, code: strings.TrimRight(strings.Join(lines, "\n"), "\n")})
+			fence = ""
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if fence != "" && len(lines) > 0 {
+		blocks = append(blocks, codeBlock{lang: lang, code: strings.TrimRight(strings.Join(lines, "\n"), "\n")})
+	}
+	return blocks
+}
+
+// fenceOf returns the fence a line opens a code block with, of three or
+// more backticks or tildes.
+func fenceOf(line string) string {
+	for _, c := range []string{"`", "~"} {
+		n := len(line) - len(strings.TrimLeft(line, c))
+		if n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
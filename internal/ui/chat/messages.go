@@ -164,6 +164,11 @@ func (c *cachedMessageItem) clearCache() {
 	c.height = 0
 }
 
+// Evict implements [list.Evictable].
+func (c *cachedMessageItem) Evict() {
+	c.clearCache()
+}
+
 // focusableMessageItem is a base struct for message items that can be focused.
 type focusableMessageItem struct {
 	focused bool
@@ -233,20 +238,79 @@ func (a *AssistantInfoItem) renderContent(width int) string {
 	duration := finishTime.Sub(a.lastUserMessageTime)
 	infoMsg := a.sty.Chat.Message.AssistantInfoDuration.Render(duration.String())
 	icon := a.sty.Chat.Message.AssistantInfoIcon.Render(styles.ModelIcon)
-	model := a.cfg.GetModel(a.message.Provider, a.message.Model)
-	if model == nil {
-		model = &catwalk.Model{Name: "Unknown Model"}
-	}
-	modelFormatted := a.sty.Chat.Message.AssistantInfoModel.Render(model.Name)
-	providerName := a.message.Provider
-	if providerConfig, ok := a.cfg.Providers.Get(a.message.Provider); ok {
-		providerName = providerConfig.Name
-	}
+	modelName, providerName := modelNames(a.cfg, a.message.Provider, a.message.Model)
+	modelFormatted := a.sty.Chat.Message.AssistantInfoModel.Render(modelName)
 	provider := a.sty.Chat.Message.AssistantInfoProvider.Render(fmt.Sprintf("via %s", providerName))
 	assistant := fmt.Sprintf("%s %s %s %s", icon, modelFormatted, provider, infoMsg)
 	return common.Section(a.sty, assistant, width)
 }
 
+// modelNames returns the names of a model and its provider to show.
+func modelNames(cfg *config.Config, providerID, modelID string) (model, provider string) {
+	m := cfg.GetModel(providerID, modelID)
+	if m == nil {
+		m = &catwalk.Model{Name: "Unknown Model"}
+	}
+	provider = providerID
+	if providerConfig, ok := cfg.Providers.Get(providerID); ok {
+		provider = providerConfig.Name
+	}
+	return m.Name, provider
+}
+
+// ModelSwitchID returns a stable ID for model switch items.
+func ModelSwitchID(messageID string) string {
+	return fmt.Sprintf("%s:model-switch", messageID)
+}
+
+// ModelSwitchItem marks where the model of a session changed, before the
+// first message sent to the new model.
+type ModelSwitchItem struct {
+	*cachedMessageItem
+
+	id      string
+	message *message.Message
+	sty     *styles.Styles
+	cfg     *config.Config
+}
+
+// NewModelSwitchItem creates a new ModelSwitchItem for the first message
+// sent to the model it records.
+func NewModelSwitchItem(sty *styles.Styles, message *message.Message, cfg *config.Config) MessageItem {
+	return &ModelSwitchItem{
+		cachedMessageItem: &cachedMessageItem{},
+		id:                ModelSwitchID(message.ID),
+		message:           message,
+		sty:               sty,
+		cfg:               cfg,
+	}
+}
+
+// ID implements MessageItem.
+func (s *ModelSwitchItem) ID() string {
+	return s.id
+}
+
+// RawRender implements MessageItem.
+func (s *ModelSwitchItem) RawRender(width int) string {
+	innerWidth := max(0, width-MessageLeftPaddingTotal)
+	content, _, ok := s.getCachedRender(innerWidth)
+	if !ok {
+		modelName, providerName := modelNames(s.cfg, s.message.Provider, s.message.Model)
+		icon := s.sty.Chat.Message.AssistantInfoIcon.Render(styles.ModelIcon)
+		model := s.sty.Chat.Message.AssistantInfoModel.Render(modelName)
+		provider := s.sty.Chat.Message.AssistantInfoProvider.Render(fmt.Sprintf("via %s", providerName))
+		content = common.Section(s.sty, fmt.Sprintf("%s Switched to %s %s", icon, model, provider), innerWidth)
+		s.setCachedRender(content, innerWidth, lipgloss.Height(content))
+	}
+	return content
+}
+
+// Render implements MessageItem.
+func (s *ModelSwitchItem) Render(width int) string {
+	return s.sty.Chat.Message.SectionHeader.Render(s.RawRender(width))
+}
+
 // cappedMessageWidth returns the maximum width for message content for readability.
 func cappedMessageWidth(availableWidth int) int {
 	return min(availableWidth-MessageLeftPaddingTotal, maxTextWidth)
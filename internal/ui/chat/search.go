@@ -133,6 +133,78 @@ func (g *GrepToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *
 	return joinToolParts(header, body)
 }
 
+// -----------------------------------------------------------------------------
+// Code Search Tool
+// -----------------------------------------------------------------------------
+
+// CodeSearchToolMessageItem is a message item that represents a code_search
+// tool call.
+type CodeSearchToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*CodeSearchToolMessageItem)(nil)
+
+// NewCodeSearchToolMessageItem creates a new [CodeSearchToolMessageItem].
+func NewCodeSearchToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &CodeSearchToolRenderContext{}, canceled)
+}
+
+// CodeSearchToolRenderContext renders code_search tool messages.
+type CodeSearchToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (c *CodeSearchToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Code Search", opts.Anim)
+	}
+
+	var params tools.CodeSearchParams
+	if err := json.Unmarshal([]byte(opts.ToolCall.Input), &params); err != nil {
+		return toolErrorContent(sty, &message.ToolResult{Content: "Invalid parameters"}, cappedWidth)
+	}
+
+	toolParams := []string{params.Pattern}
+	if params.Path != "" {
+		toolParams = append(toolParams, "path", params.Path)
+	}
+	if params.Type != "" {
+		toolParams = append(toolParams, "type", params.Type)
+	}
+	if params.Include != "" {
+		toolParams = append(toolParams, "include", params.Include)
+	}
+	if params.Multiline {
+		toolParams = append(toolParams, "multiline", "true")
+	}
+	if params.Literal {
+		toolParams = append(toolParams, "literal", "true")
+	}
+
+	header := toolHeader(sty, opts.Status, "Code Search", cappedWidth, opts.Compact, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
+
 // -----------------------------------------------------------------------------
 // LS Tool
 // -----------------------------------------------------------------------------
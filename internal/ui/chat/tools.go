@@ -168,7 +168,7 @@ func newBaseToolMessageItem(
 	canceled bool,
 ) *baseToolMessageItem {
 	// we only do full width for diffs (as far as I know)
-	hasCappedWidth := toolCall.Name != tools.EditToolName && toolCall.Name != tools.MultiEditToolName
+	hasCappedWidth := toolCall.Name != tools.EditToolName && toolCall.Name != tools.EditSymbolToolName && toolCall.Name != tools.MultiEditToolName
 
 	status := ToolStatusRunning
 	if canceled {
@@ -222,7 +222,7 @@ func NewToolMessageItem(
 		item = NewViewToolMessageItem(sty, toolCall, result, canceled)
 	case tools.WriteToolName:
 		item = NewWriteToolMessageItem(sty, toolCall, result, canceled)
-	case tools.EditToolName:
+	case tools.EditToolName, tools.EditSymbolToolName:
 		item = NewEditToolMessageItem(sty, toolCall, result, canceled)
 	case tools.MultiEditToolName:
 		item = NewMultiEditToolMessageItem(sty, toolCall, result, canceled)
@@ -230,6 +230,8 @@ func NewToolMessageItem(
 		item = NewGlobToolMessageItem(sty, toolCall, result, canceled)
 	case tools.GrepToolName:
 		item = NewGrepToolMessageItem(sty, toolCall, result, canceled)
+	case tools.CodeSearchToolName:
+		item = NewCodeSearchToolMessageItem(sty, toolCall, result, canceled)
 	case tools.LSToolName:
 		item = NewLSToolMessageItem(sty, toolCall, result, canceled)
 	case tools.DownloadToolName:
@@ -350,6 +352,11 @@ func (t *baseToolMessageItem) SetResult(res *message.ToolResult) {
 	t.clearCache()
 }
 
+// Result returns the tool result, or nil while the tool is running.
+func (t *baseToolMessageItem) Result() *message.ToolResult {
+	return t.result
+}
+
 // MessageID returns the ID of the message containing this tool call.
 func (t *baseToolMessageItem) MessageID() string {
 	return t.messageID
@@ -579,7 +586,7 @@ func toolOutputCodeContent(sty *styles.Styles, path, content string, offset, wid
 	}
 
 	bg := sty.Tool.ContentCodeBg
-	highlighted, _ := common.SyntaxHighlight(sty, strings.Join(displayLines, "\n"), path, bg)
+	highlighted, _ := common.CachedSyntaxHighlight(sty, strings.Join(displayLines, "\n"), path, bg)
 	highlightedLines := strings.Split(highlighted, "\n")
 
 	// Calculate line number width.
@@ -778,8 +785,7 @@ func toolOutputMarkdownContent(sty *styles.Styles, content string, width int, ex
 		width = maxTextWidth
 	}
 
-	renderer := common.PlainMarkdownRenderer(sty, width)
-	rendered, err := renderer.Render(content)
+	rendered, err := common.RenderPlainMarkdown(sty, content, width)
 	if err != nil {
 		return toolOutputPlainContent(sty, content, width, expanded)
 	}
@@ -873,6 +879,11 @@ func (t *baseToolMessageItem) formatParametersForCopy() string {
 		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
 			return fmt.Sprintf("**File:** %s", fsext.PrettyPath(params.FilePath))
 		}
+	case tools.EditSymbolToolName:
+		var params tools.EditSymbolParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			return fmt.Sprintf("**File:** %s\n**Symbol:** %s", fsext.PrettyPath(params.FilePath), params.Symbol)
+		}
 	case tools.MultiEditToolName:
 		var params tools.MultiEditParams
 		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
@@ -932,6 +943,28 @@ func (t *baseToolMessageItem) formatParametersForCopy() string {
 			}
 			return strings.Join(parts, "\n")
 		}
+	case tools.CodeSearchToolName:
+		var params tools.CodeSearchParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			var parts []string
+			parts = append(parts, fmt.Sprintf("**Pattern:** %s", params.Pattern))
+			if params.Path != "" {
+				parts = append(parts, fmt.Sprintf("**Path:** %s", params.Path))
+			}
+			if params.Type != "" {
+				parts = append(parts, fmt.Sprintf("**Type:** %s", params.Type))
+			}
+			if params.Include != "" {
+				parts = append(parts, fmt.Sprintf("**Include:** %s", params.Include))
+			}
+			if params.Multiline {
+				parts = append(parts, "**Multiline:** true")
+			}
+			if params.Literal {
+				parts = append(parts, "**Literal:** true")
+			}
+			return strings.Join(parts, "\n")
+		}
 	case tools.GlobToolName:
 		var params tools.GlobParams
 		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
@@ -1018,7 +1051,7 @@ func (t *baseToolMessageItem) formatResultForCopy() string {
 		return t.formatBashResultForCopy()
 	case tools.ViewToolName:
 		return t.formatViewResultForCopy()
-	case tools.EditToolName:
+	case tools.EditToolName, tools.EditSymbolToolName:
 		return t.formatEditResultForCopy()
 	case tools.MultiEditToolName:
 		return t.formatMultiEditResultForCopy()
@@ -1032,7 +1065,7 @@ func (t *baseToolMessageItem) formatResultForCopy() string {
 		return t.formatWebFetchResultForCopy()
 	case agent.AgentToolName:
 		return t.formatAgentResultForCopy()
-	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName, tools.TodosToolName:
+	case tools.DownloadToolName, tools.GrepToolName, tools.CodeSearchToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName, tools.TodosToolName:
 		return fmt.Sprintf("```\n%s\n```", t.result.Content)
 	default:
 		return t.result.Content
@@ -1360,8 +1393,20 @@ func prettifyToolName(name string) string {
 		return "Download"
 	case tools.EditToolName:
 		return "Edit"
+	case tools.EditSymbolToolName:
+		return "Edit Symbol"
+	case tools.EmitArtifactToolName:
+		return "Artifact"
 	case tools.MultiEditToolName:
 		return "Multi-Edit"
+	case tools.ReplaceAllToolName:
+		return "Replace All"
+	case tools.RunTestsToolName:
+		return "Run Tests"
+	case tools.ResetShellToolName:
+		return "Reset Shell"
+	case tools.ProcessToolName:
+		return "Process"
 	case tools.FetchToolName:
 		return "Fetch"
 	case tools.AgenticFetchToolName:
@@ -1374,12 +1419,26 @@ func prettifyToolName(name string) string {
 		return "Glob"
 	case tools.GrepToolName:
 		return "Grep"
+	case tools.CodeSearchToolName:
+		return "Code Search"
 	case tools.LSToolName:
 		return "List"
+	case tools.RecallToolName:
+		return "Recall"
 	case tools.SourcegraphToolName:
 		return "Sourcegraph"
+	case tools.GitHubIssueToolName:
+		return "GitHub Issue"
+	case tools.GitHubCreatePRToolName:
+		return "Pull Request"
+	case tools.GitHubReviewToolName:
+		return "GitHub Review"
+	case tools.TerminalScrollbackToolName:
+		return "Terminal"
 	case tools.TodosToolName:
 		return "To-Do"
+	case tools.VerifyCleanToolName:
+		return "Verify Clean"
 	case tools.ViewToolName:
 		return "View"
 	case tools.WriteToolName:
@@ -44,10 +44,8 @@ func (m *UserMessageItem) RawRender(width int) string {
 		return m.renderHighlighted(content, cappedWidth, height)
 	}
 
-	renderer := common.MarkdownRenderer(m.sty, cappedWidth)
-
 	msgContent := strings.TrimSpace(m.message.Content().Text)
-	result, err := renderer.Render(msgContent)
+	result, err := common.RenderMarkdown(m.sty, msgContent, cappedWidth)
 	if err != nil {
 		content = msgContent
 	} else {
@@ -82,6 +80,11 @@ func (m *UserMessageItem) ID() string {
 	return m.message.ID
 }
 
+// Message returns the message the item shows.
+func (m *UserMessageItem) Message() *message.Message {
+	return m.message
+}
+
 // renderAttachments renders attachments.
 func (m *UserMessageItem) renderAttachments(width int) string {
 	var attachments []message.Attachment
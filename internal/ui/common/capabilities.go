@@ -104,6 +104,16 @@ func (c Capabilities) SupportsSixelGraphics() bool {
 	return c.SixelGraphics
 }
 
+// SupportsITerm2Images returns true if the terminal supports the inline
+// images protocol of iTerm2, which does not answer queries for it.
+func (c Capabilities) SupportsITerm2Images() bool {
+	switch c.Env.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm":
+		return true
+	}
+	return c.Env.Getenv("LC_TERMINAL") == "iTerm2"
+}
+
 // CellSize returns the size of a single terminal cell in pixels.
 func (c Capabilities) CellSize() (width, height int) {
 	if c.Columns == 0 || c.Rows == 0 {
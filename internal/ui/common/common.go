@@ -3,6 +3,7 @@ package common
 import (
 	"fmt"
 	"image"
+	"log/slog"
 	"os"
 
 	tea "charm.land/bubbletea/v2"
@@ -20,10 +21,15 @@ const MaxAttachmentSize = int64(5 * 1024 * 1024)
 // AllowedImageTypes defines the permitted image file types.
 var AllowedImageTypes = []string{".jpg", ".jpeg", ".png"}
 
+// AllowedAttachmentTypes defines the file types that can be attached as
+// binary content: images plus PDF documents.
+var AllowedAttachmentTypes = []string{".jpg", ".jpeg", ".png", ".pdf"}
+
 // Common defines common UI options and configurations.
 type Common struct {
 	App    *app.App
 	Styles *styles.Styles
+	Themes *styles.Registry
 }
 
 // Config returns the configuration associated with this [Common] instance.
@@ -31,13 +37,34 @@ func (c *Common) Config() *config.Config {
 	return c.App.Config()
 }
 
-// DefaultCommon returns the default common UI configurations.
+// DefaultCommon returns the default common UI configurations, styled with
+// the theme selected in the configuration.
 func DefaultCommon(app *app.App) *Common {
-	s := styles.DefaultStyles()
+	themes := LoadThemes()
+	var name string
+	if opts := app.Config().Options; opts != nil && opts.TUI != nil {
+		name = opts.TUI.Theme
+	}
+	s, err := themes.Styles(name)
+	if err != nil {
+		slog.Warn("Using the default theme", "error", err)
+		s = styles.DefaultStyles()
+	}
 	return &Common{
 		App:    app,
 		Styles: &s,
+		Themes: themes,
+	}
+}
+
+// LoadThemes returns the registry of the bundled themes and those of the
+// user themes directory.
+func LoadThemes() *styles.Registry {
+	themes := styles.NewRegistry()
+	if err := themes.LoadDir(config.GlobalThemesDir()); err != nil {
+		slog.Warn("Failed to load themes", "error", err)
 	}
+	return themes
 }
 
 // CenterRect returns a new [Rectangle] centered within the given area with the
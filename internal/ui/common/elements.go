@@ -74,22 +74,7 @@ func ModelInfo(t *styles.Styles, modelName, providerName, reasoningInfo string,
 // formatTokensAndCost formats token usage and cost with appropriate units
 // (K/M) and percentage of context window.
 func formatTokensAndCost(t *styles.Styles, tokens, contextWindow int64, cost float64) string {
-	var formattedTokens string
-	switch {
-	case tokens >= 1_000_000:
-		formattedTokens = fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
-	case tokens >= 1_000:
-		formattedTokens = fmt.Sprintf("%.1fK", float64(tokens)/1_000)
-	default:
-		formattedTokens = fmt.Sprintf("%d", tokens)
-	}
-
-	if strings.HasSuffix(formattedTokens, ".0K") {
-		formattedTokens = strings.Replace(formattedTokens, ".0K", "K", 1)
-	}
-	if strings.HasSuffix(formattedTokens, ".0M") {
-		formattedTokens = strings.Replace(formattedTokens, ".0M", "M", 1)
-	}
+	formattedTokens := FormatTokens(tokens)
 
 	percentage := (float64(tokens) / float64(contextWindow)) * 100
 
@@ -105,6 +90,27 @@ func formatTokensAndCost(t *styles.Styles, tokens, contextWindow int64, cost flo
 	return fmt.Sprintf("%s %s", formattedTokens, formattedCost)
 }
 
+// FormatTokens formats a token count with K/M units, such as 1.5K.
+func FormatTokens(tokens int64) string {
+	var formatted string
+	switch {
+	case tokens >= 1_000_000:
+		formatted = fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
+	case tokens >= 1_000:
+		formatted = fmt.Sprintf("%.1fK", float64(tokens)/1_000)
+	default:
+		formatted = fmt.Sprintf("%d", tokens)
+	}
+
+	if strings.HasSuffix(formatted, ".0K") {
+		formatted = strings.Replace(formatted, ".0K", "K", 1)
+	}
+	if strings.HasSuffix(formatted, ".0M") {
+		formatted = strings.Replace(formatted, ".0M", "M", 1)
+	}
+	return formatted
+}
+
 // StatusOpts defines options for rendering a status line with icon, title,
 // description, and optional extra content.
 type StatusOpts struct {
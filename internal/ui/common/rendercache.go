@@ -0,0 +1,98 @@
+package common
+
+import (
+	"crypto/sha256"
+	"image/color"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// renderCacheSize is how many renders the cache keeps: enough for the
+// messages of a long session at a couple of widths.
+const renderCacheSize = 2048
+
+type renderKind uint8
+
+const (
+	renderMarkdown renderKind = iota
+	renderPlainMarkdown
+	renderHighlight
+)
+
+// renderKey identifies a render by the styles and width it was made with
+// and a hash of its input.
+type renderKey struct {
+	sty   *styles.Styles
+	kind  renderKind
+	width int
+	sum   [sha256.Size]byte
+}
+
+// renderCache holds the Markdown and syntax highlighting rendered for the
+// chat, so that resizing the terminal back, or rendering a message again
+// once its own cache was dropped, does not run the renderers again.
+var renderCache, _ = lru.New[renderKey, string](renderCacheSize)
+
+func newRenderKey(sty *styles.Styles, kind renderKind, width int, input ...string) renderKey {
+	return renderKey{
+		sty:   sty,
+		kind:  kind,
+		width: width,
+		sum:   sha256.Sum256([]byte(strings.Join(input, "\x00"))),
+	}
+}
+
+// ClearRenderCache drops the cached renders, those made with styles that
+// changed in place.
+func ClearRenderCache() {
+	renderCache.Purge()
+}
+
+// RenderMarkdown renders content as Markdown at width with
+// [MarkdownRenderer], caching the result.
+func RenderMarkdown(sty *styles.Styles, content string, width int) (string, error) {
+	key := newRenderKey(sty, renderMarkdown, width, content)
+	if out, ok := renderCache.Get(key); ok {
+		return out, nil
+	}
+	out, err := MarkdownRenderer(sty, width).Render(content)
+	if err != nil {
+		return "", err
+	}
+	renderCache.Add(key, out)
+	return out, nil
+}
+
+// RenderPlainMarkdown renders content as Markdown at width with
+// [PlainMarkdownRenderer], caching the result.
+func RenderPlainMarkdown(sty *styles.Styles, content string, width int) (string, error) {
+	key := newRenderKey(sty, renderPlainMarkdown, width, content)
+	if out, ok := renderCache.Get(key); ok {
+		return out, nil
+	}
+	out, err := PlainMarkdownRenderer(sty, width).Render(content)
+	if err != nil {
+		return "", err
+	}
+	renderCache.Add(key, out)
+	return out, nil
+}
+
+// CachedSyntaxHighlight is [SyntaxHighlight], caching the result.
+func CachedSyntaxHighlight(sty *styles.Styles, source, fileName string, bg color.Color) (string, error) {
+	r, g, b, a := bg.RGBA()
+	key := newRenderKey(sty, renderHighlight, 0, source, fileName, string([]byte{
+		byte(r >> 8), byte(g >> 8), byte(b >> 8), byte(a >> 8),
+	}))
+	if out, ok := renderCache.Get(key); ok {
+		return out, nil
+	}
+	out, err := SyntaxHighlight(sty, source, fileName, bg)
+	if err != nil {
+		return "", err
+	}
+	renderCache.Add(key, out)
+	return out, nil
+}
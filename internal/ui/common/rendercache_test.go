@@ -0,0 +1,83 @@
+package common
+
+import (
+	"fmt"
+	"image/color"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	"github.com/stretchr/testify/require"
+)
+
+// The tests below share the render cache, so they don't run in parallel.
+
+func TestRenderCacheKey(t *testing.T) {
+	ClearRenderCache()
+	t.Cleanup(ClearRenderCache)
+	sty := styles.DefaultStyles()
+
+	renderCache.Add(newRenderKey(&sty, renderMarkdown, 40, "# Title"), "cached")
+
+	out, err := RenderMarkdown(&sty, "# Title", 40)
+	require.NoError(t, err)
+	require.Equal(t, "cached", out, "the same content at the same width hits")
+
+	out, err = RenderMarkdown(&sty, "# Title", 60)
+	require.NoError(t, err)
+	require.NotEqual(t, "cached", out, "another width misses")
+	require.Contains(t, out, "Title")
+	require.True(t, renderCache.Contains(newRenderKey(&sty, renderMarkdown, 60, "# Title")))
+
+	out, err = RenderMarkdown(&sty, "# Other", 40)
+	require.NoError(t, err)
+	require.NotEqual(t, "cached", out, "other content misses")
+
+	out, err = RenderPlainMarkdown(&sty, "# Title", 40)
+	require.NoError(t, err)
+	require.NotEqual(t, "cached", out, "another renderer misses")
+
+	other := styles.DefaultStyles()
+	out, err = RenderMarkdown(&other, "# Title", 40)
+	require.NoError(t, err)
+	require.NotEqual(t, "cached", out, "other styles miss")
+}
+
+func TestRenderCacheHighlight(t *testing.T) {
+	ClearRenderCache()
+	t.Cleanup(ClearRenderCache)
+	sty := styles.DefaultStyles()
+	black := color.RGBA{A: 0xff}
+
+	first, err := CachedSyntaxHighlight(&sty, "package main", "main.go", black)
+	require.NoError(t, err)
+	require.Equal(t, 1, renderCache.Len())
+	again, err := CachedSyntaxHighlight(&sty, "package main", "main.go", black)
+	require.NoError(t, err)
+	require.Equal(t, first, again)
+	require.Equal(t, 1, renderCache.Len(), "the same source hits")
+
+	_, err = CachedSyntaxHighlight(&sty, "package main", "main.go", color.RGBA{R: 0xff, A: 0xff})
+	require.NoError(t, err)
+	require.Equal(t, 2, renderCache.Len(), "another background misses")
+}
+
+func TestRenderCacheBound(t *testing.T) {
+	ClearRenderCache()
+	t.Cleanup(ClearRenderCache)
+	sty := styles.DefaultStyles()
+
+	first := newRenderKey(&sty, renderMarkdown, 40, "0")
+	renderCache.Add(first, "0")
+	for i := 1; i < renderCacheSize; i++ {
+		renderCache.Add(newRenderKey(&sty, renderMarkdown, 40, fmt.Sprint(i)), fmt.Sprint(i))
+	}
+	require.Equal(t, renderCacheSize, renderCache.Len())
+
+	_, err := RenderMarkdown(&sty, "one more", 40)
+	require.NoError(t, err)
+	require.Equal(t, renderCacheSize, renderCache.Len())
+	require.False(t, renderCache.Contains(first), "the least recently used render is evicted")
+
+	ClearRenderCache()
+	require.Zero(t, renderCache.Len())
+}
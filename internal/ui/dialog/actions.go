@@ -8,12 +8,18 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/annotation"
+	"github.com/charmbracelet/crush/internal/artifact"
+	"github.com/charmbracelet/crush/internal/batch"
 	"github.com/charmbracelet/crush/internal/commands"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/export"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/oauth"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/staging"
 	"github.com/charmbracelet/crush/internal/ui/common"
 	"github.com/charmbracelet/crush/internal/ui/util"
 )
@@ -47,15 +53,52 @@ type (
 	ActionNewSession        struct{}
 	ActionToggleHelp        struct{}
 	ActionToggleCompactMode struct{}
+	ActionToggleSidePane    struct{}
 	ActionToggleThinking    struct{}
 	ActionTogglePills       struct{}
 	ActionExternalEditor    struct{}
 	ActionToggleYoloMode    struct{}
+	ActionTogglePlanMode    struct{}
+	ActionToggleReviewMode  struct{}
+	ActionToggleChatOnly    struct{}
+	ActionToggleTranslate   struct{}
 	// ActionInitializeProject is a message to initialize a project.
 	ActionInitializeProject struct{}
 	ActionSummarize         struct {
 		SessionID string
 	}
+	// ActionRenameSession is a message to rename the current session.
+	ActionRenameSession struct{}
+	// ActionExportSession is sent to write the transcript of a session to
+	// the workspace.
+	ActionExportSession struct {
+		SessionID string
+		Format    export.Format
+	}
+	// ActionCopy is sent when a part of a message is picked to copy.
+	ActionCopy struct {
+		Title string
+		Text  string
+	}
+	// ActionSelectTheme is sent when a theme is picked.
+	ActionSelectTheme struct {
+		Name string
+	}
+	// ActionConfirmShare is sent to ask before sharing a session.
+	ActionConfirmShare struct {
+		SessionID string
+	}
+	// ActionShareSession is sent to publish the transcript of a session
+	// once the user confirmed.
+	ActionShareSession struct {
+		SessionID string
+	}
+	// ActionLabelSession is a message to set the label the next turns of
+	// the current session are counted under.
+	ActionLabelSession struct {
+		SessionID string
+		Args      map[string]string // Actual argument values
+	}
 	// ActionSelectReasoningEffort is a message indicating a reasoning effort has been selected.
 	ActionSelectReasoningEffort struct {
 		Effort string
@@ -81,6 +124,87 @@ type (
 	}
 )
 
+// Messages for the staged edits dialog.
+type (
+	// ActionApplyStagedEdits is sent to write staged edits to disk.
+	ActionApplyStagedEdits struct {
+		IDs []string
+	}
+	// ActionDiscardStagedEdit is sent to drop a staged edit.
+	ActionDiscardStagedEdit struct {
+		ID string
+	}
+	// ActionEditStagedEdit is sent to change a staged edit in the external
+	// editor before applying it.
+	ActionEditStagedEdit struct {
+		Edit staging.Edit
+	}
+)
+
+// Messages for committing the staged changes.
+type (
+	// ActionWriteCommitMessage is sent to have the small model write the
+	// message of the staged changes.
+	ActionWriteCommitMessage struct{}
+	// ActionCommit is sent to commit the staged changes with Message.
+	ActionCommit struct {
+		Message string
+	}
+	// ActionEditCommitMessage is sent to change the commit message in the
+	// external editor.
+	ActionEditCommitMessage struct {
+		Message string
+	}
+)
+
+// Messages for the artifacts dialog.
+type (
+	// ActionOpenArtifact is sent to open an artifact with the default
+	// application for its type.
+	ActionOpenArtifact struct {
+		Artifact artifact.Artifact
+	}
+	// ActionExportArtifact is sent to copy an artifact to the workspace.
+	ActionExportArtifact struct {
+		Artifact artifact.Artifact
+	}
+)
+
+// ActionQuickAction is sent to run a quick action on the latest reply of
+// the agent.
+type ActionQuickAction struct {
+	Action QuickAction
+}
+
+// ActionTourDone is sent when the tour is finished or skipped.
+type ActionTourDone struct{}
+
+// ActionSetAnnotations replaces the line comments sent with the next
+// message.
+type ActionSetAnnotations struct {
+	Annotations []annotation.Annotation
+}
+
+// ActionSendBatch is sent to have the agent apply one instruction at each
+// of the sites picked from a search.
+type ActionSendBatch struct {
+	Instruction string
+	Sites       []batch.Site
+}
+
+// ActionTrimAttachments is sent to send attachments over the attachment
+// budget using Strategy. An empty Strategy sends them as they are.
+type ActionTrimAttachments struct {
+	Strategy agent.TrimStrategy
+}
+
+// ActionSendRaw makes the next message raw, sent with History of the
+// session. Cancel makes it a normal message again.
+type ActionSendRaw struct {
+	History agent.RawHistory
+	Cancel  bool
+}
+
 // Messages for API key input dialog.
 type (
 	ActionChangeAPIKeyState struct {
@@ -135,7 +259,7 @@ func (a ActionFilePickerSelected) Cmd() tea.Cmd {
 		if err != nil {
 			return util.InfoMsg{
 				Type: util.InfoTypeError,
-				Msg:  fmt.Sprintf("unable to read the image: %v", err),
+				Msg:  fmt.Sprintf("unable to read the file: %v", err),
 			}
 		}
 		if isFileLarge {
@@ -149,7 +273,7 @@ func (a ActionFilePickerSelected) Cmd() tea.Cmd {
 		if err != nil {
 			return util.InfoMsg{
 				Type: util.InfoTypeError,
-				Msg:  fmt.Sprintf("unable to read the image: %v", err),
+				Msg:  fmt.Sprintf("unable to read the file: %v", err),
 			}
 		}
 
@@ -0,0 +1,489 @@
+package dialog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/annotation"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/diffview"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// AnnotateID is the identifier for the line comments dialog.
+const AnnotateID = "annotate"
+
+// AnnotateFile is a file changed in the session, as it was before the
+// session changed it and as it is now.
+type AnnotateFile struct {
+	// Path is the path comments refer to, relative to the working
+	// directory when the file is in it.
+	Path   string
+	Before string
+	After  string
+}
+
+// annotateLine is a row of the dialog that can be commented on.
+type annotateLine struct {
+	line int
+	side annotation.Side
+}
+
+// Annotate is a dialog for leaving comments on lines of the session diff or
+// of a file preview, to be sent with the next message.
+type Annotate struct {
+	com         *common.Common
+	files       []AnnotateFile
+	index       int
+	preview     bool
+	annotations []annotation.Annotation
+
+	// lines has an entry for every rendered row of the current file; rows
+	// that cannot be commented on, like hunk headers, are nil.
+	lines  []*annotateLine
+	cursor int
+
+	commenting bool
+	input      textinput.Model
+
+	viewport    viewport.Model
+	diffXOffset int
+
+	help   help.Model
+	keyMap annotateKeyMap
+}
+
+type annotateKeyMap struct {
+	Previous      key.Binding
+	Next          key.Binding
+	Up            key.Binding
+	Down          key.Binding
+	UpDown        key.Binding
+	Comment       key.Binding
+	Delete        key.Binding
+	TogglePreview key.Binding
+	ScrollLeft    key.Binding
+	ScrollRight   key.Binding
+	Save          key.Binding
+	Cancel        key.Binding
+	Close         key.Binding
+}
+
+func defaultAnnotateKeyMap() annotateKeyMap {
+	return annotateKeyMap{
+		Previous: key.NewBinding(
+			key.WithKeys("left", "p"),
+			key.WithHelp("←/→", "previous/next file"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("right", "n"),
+			key.WithHelp("←/→", "previous/next file"),
+		),
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+		),
+		UpDown: key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑/↓", "choose line"),
+		),
+		Comment: key.NewBinding(
+			key.WithKeys("enter", "c"),
+			key.WithHelp("enter", "comment"),
+		),
+		Delete: key.NewBinding(
+			key.WithKeys("d", "x"),
+			key.WithHelp("d", "delete comment"),
+		),
+		TogglePreview: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "diff/file"),
+		),
+		ScrollLeft: key.NewBinding(
+			key.WithKeys("shift+left", "H"),
+			key.WithHelp("shift+←", "scroll left"),
+		),
+		ScrollRight: key.NewBinding(
+			key.WithKeys("shift+right", "L"),
+			key.WithHelp("shift+→", "scroll right"),
+		),
+		Save: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "save"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+		Close: CloseKey,
+	}
+}
+
+var _ Dialog = (*Annotate)(nil)
+
+// NewAnnotate creates a new line comments dialog over files, with the
+// comments already left.
+func NewAnnotate(com *common.Common, files []AnnotateFile, annotations []annotation.Annotation) *Annotate {
+	h := help.New()
+	h.Styles = com.Styles.DialogHelpStyles()
+
+	input := textinput.New()
+	input.SetVirtualCursor(false)
+	input.Placeholder = "What should change here?"
+	input.SetStyles(com.Styles.TextInput)
+
+	vp := viewport.New()
+	vp.KeyMap = viewport.KeyMap{
+		Up:           key.NewBinding(key.WithDisabled()),
+		Down:         key.NewBinding(key.WithDisabled()),
+		PageUp:       key.NewBinding(key.WithDisabled()),
+		PageDown:     key.NewBinding(key.WithDisabled()),
+		HalfPageUp:   key.NewBinding(key.WithDisabled()),
+		HalfPageDown: key.NewBinding(key.WithDisabled()),
+		Left:         key.NewBinding(key.WithDisabled()),
+		Right:        key.NewBinding(key.WithDisabled()),
+	}
+
+	return &Annotate{
+		com:         com,
+		files:       files,
+		annotations: annotations,
+		input:       input,
+		viewport:    vp,
+		help:        h,
+		keyMap:      defaultAnnotateKeyMap(),
+		cursor:      -1,
+	}
+}
+
+// ID implements [Dialog].
+func (*Annotate) ID() string {
+	return AnnotateID
+}
+
+// HandleMsg implements [Dialog].
+func (a *Annotate) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		if a.commenting {
+			return a.handleCommentKey(msg)
+		}
+		switch {
+		case key.Matches(msg, a.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, a.keyMap.Previous):
+			a.moveFile(-1)
+		case key.Matches(msg, a.keyMap.Next):
+			a.moveFile(1)
+		case key.Matches(msg, a.keyMap.Up):
+			a.moveCursor(-1)
+		case key.Matches(msg, a.keyMap.Down):
+			a.moveCursor(1)
+		case key.Matches(msg, a.keyMap.TogglePreview):
+			a.preview = !a.preview
+			a.resetView()
+		case key.Matches(msg, a.keyMap.ScrollLeft):
+			a.diffXOffset = max(0, a.diffXOffset-horizontalScrollStep)
+		case key.Matches(msg, a.keyMap.ScrollRight):
+			a.diffXOffset += horizontalScrollStep
+		case key.Matches(msg, a.keyMap.Comment):
+			if current, ok := a.currentAnnotation(); ok {
+				a.commenting = true
+				a.input.SetValue(current.Comment)
+				a.input.CursorEnd()
+				return ActionCmd{a.input.Focus()}
+			}
+		case key.Matches(msg, a.keyMap.Delete):
+			if current, ok := a.currentAnnotation(); ok {
+				current.Comment = ""
+				return a.setAnnotation(current)
+			}
+		}
+	case tea.MouseWheelMsg:
+		a.viewport, _ = a.viewport.Update(msg)
+	case tea.PasteMsg:
+		if a.commenting {
+			var cmd tea.Cmd
+			a.input, cmd = a.input.Update(msg)
+			return ActionCmd{cmd}
+		}
+	}
+	return nil
+}
+
+func (a *Annotate) handleCommentKey(msg tea.KeyPressMsg) Action {
+	switch {
+	case key.Matches(msg, a.keyMap.Cancel):
+		a.stopCommenting()
+	case key.Matches(msg, a.keyMap.Save):
+		current, ok := a.currentAnnotation()
+		current.Comment = a.input.Value()
+		a.stopCommenting()
+		if ok {
+			return a.setAnnotation(current)
+		}
+	default:
+		var cmd tea.Cmd
+		a.input, cmd = a.input.Update(msg)
+		return ActionCmd{cmd}
+	}
+	return nil
+}
+
+func (a *Annotate) stopCommenting() {
+	a.commenting = false
+	a.input.Reset()
+	a.input.Blur()
+}
+
+func (a *Annotate) setAnnotation(current annotation.Annotation) Action {
+	a.annotations = annotation.Set(a.annotations, current)
+	return ActionSetAnnotations{Annotations: a.annotations}
+}
+
+// currentAnnotation returns the comment on the line under the cursor, with
+// an empty comment when there is none yet.
+func (a *Annotate) currentAnnotation() (annotation.Annotation, bool) {
+	if a.cursor < 0 || a.cursor >= len(a.lines) || a.lines[a.cursor] == nil {
+		return annotation.Annotation{}, false
+	}
+	file := a.current()
+	line := a.lines[a.cursor]
+	content := file.After
+	if line.side == annotation.SideOld {
+		content = file.Before
+	}
+	current := annotation.Annotation{
+		Path: file.Path,
+		Line: line.line,
+		Side: line.side,
+		Code: strings.TrimSpace(lineOf(content, line.line)),
+	}
+	for _, existing := range a.annotations {
+		if existing.Same(current) {
+			current.Comment = existing.Comment
+		}
+	}
+	return current, true
+}
+
+// lineOf returns the 1-based line n of content.
+func lineOf(content string, n int) string {
+	i := 1
+	for line := range strings.Lines(content) {
+		if i == n {
+			return strings.TrimRight(line, "\r\n")
+		}
+		i++
+	}
+	return ""
+}
+
+func (a *Annotate) current() AnnotateFile {
+	if a.index < 0 || a.index >= len(a.files) {
+		return AnnotateFile{}
+	}
+	return a.files[a.index]
+}
+
+func (a *Annotate) moveFile(delta int) {
+	if len(a.files) == 0 {
+		return
+	}
+	a.index = (a.index + delta + len(a.files)) % len(a.files)
+	a.resetView()
+}
+
+func (a *Annotate) resetView() {
+	a.lines = nil
+	a.cursor = -1
+	a.diffXOffset = 0
+	a.viewport.GotoTop()
+}
+
+// moveCursor moves the cursor to the next line that can be commented on in
+// the given direction.
+func (a *Annotate) moveCursor(delta int) {
+	for i := a.cursor + delta; i >= 0 && i < len(a.lines); i += delta {
+		if a.lines[i] != nil {
+			a.cursor = i
+			break
+		}
+	}
+	if a.cursor < a.viewport.YOffset() {
+		a.viewport.SetYOffset(a.cursor)
+	} else if bottom := a.viewport.YOffset() + a.viewport.Height() - 1; a.cursor > bottom {
+		a.viewport.SetYOffset(a.viewport.YOffset() + a.cursor - bottom)
+	}
+}
+
+// render returns the rows of the current file, as a diff or as the whole
+// file, and records which line each row shows.
+func (a *Annotate) render(width int) []string {
+	t := a.com.Styles
+	file := a.current()
+	if a.preview {
+		lines := strings.Split(strings.TrimSuffix(file.After, "\n"), "\n")
+		digits := len(strconv.Itoa(len(lines)))
+		a.lines = make([]*annotateLine, len(lines))
+		rows := make([]string, len(lines))
+		for i, line := range lines {
+			a.lines[i] = &annotateLine{line: i + 1, side: annotation.SideNew}
+			code := ansi.Cut(strings.ReplaceAll(strings.TrimSuffix(line, "\r"), "\t", "    "), a.diffXOffset, a.diffXOffset+width)
+			rows[i] = ansi.Truncate(t.Muted.Render(fmt.Sprintf("%*d ", digits, i+1))+t.Base.Render(code), width, "…")
+		}
+		return rows
+	}
+
+	formatter := common.DiffFormatter(t).
+		Before(file.Path, file.Before).
+		After(file.Path, file.After).
+		XOffset(a.diffXOffset).
+		Width(width).
+		Unified()
+	diffRows := formatter.Rows()
+	a.lines = make([]*annotateLine, len(diffRows))
+	for i, row := range diffRows {
+		switch row.Kind {
+		case diffview.RowEqual, diffview.RowInsert:
+			a.lines[i] = &annotateLine{line: row.After, side: annotation.SideNew}
+		case diffview.RowDelete:
+			a.lines[i] = &annotateLine{line: row.Before, side: annotation.SideOld}
+		}
+	}
+	return strings.Split(formatter.String(), "\n")
+}
+
+// Draw implements [Dialog].
+func (a *Annotate) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := a.com.Styles
+	width, maxHeight := area.Dx(), area.Dy()
+	if width > minWindowWidth && maxHeight > minWindowHeight {
+		width = min(int(float64(width)*diffSizeRatio), diffMaxWidth)
+		maxHeight = int(float64(maxHeight) * diffSizeRatio)
+	}
+
+	dialogStyle := t.Dialog.View.Width(width).Padding(0, 1)
+	const dialogHorizontalPadding = 2
+	contentWidth := width - t.Dialog.View.GetHorizontalFrameSize() - dialogHorizontalPadding
+
+	header := a.renderHeader(contentWidth)
+	footer := a.renderFooter(contentWidth)
+	frameHeight := dialogStyle.GetVerticalFrameSize() + layoutSpacingLines
+	availableHeight := max(3, maxHeight-lipgloss.Height(header)-lipgloss.Height(footer)-frameHeight)
+
+	// Two columns for the cursor and comment markers, one for the scrollbar.
+	const gutterWidth = 2
+	viewportWidth := contentWidth - 1
+	rows := a.render(viewportWidth - gutterWidth)
+	if a.cursor < 0 || a.cursor >= len(a.lines) {
+		a.cursor = -1
+		a.moveCursor(1)
+	}
+	for i, row := range rows {
+		rows[i] = a.gutter(i) + row
+	}
+
+	a.viewport.SetWidth(viewportWidth)
+	a.viewport.SetHeight(availableHeight)
+	a.viewport.SetContent(strings.Join(rows, "\n"))
+	scrollbar := common.Scrollbar(t, availableHeight, a.viewport.TotalLineCount(), availableHeight, a.viewport.YOffset())
+	content := lipgloss.JoinHorizontal(lipgloss.Top, a.viewport.View(), scrollbar)
+
+	inner := lipgloss.JoinVertical(lipgloss.Left, header, "", content, "", footer)
+	view := dialogStyle.Render(inner)
+
+	var cur *tea.Cursor
+	if a.commenting {
+		if cur = a.input.Cursor(); cur != nil {
+			inputStyle := t.Dialog.InputPrompt
+			cur.X += dialogStyle.GetBorderLeftSize() + dialogStyle.GetPaddingLeft() +
+				inputStyle.GetBorderLeftSize() + inputStyle.GetMarginLeft() + inputStyle.GetPaddingLeft()
+			// The input is below the header, the content and a blank line
+			// around it.
+			cur.Y += dialogStyle.GetBorderTopSize() + dialogStyle.GetPaddingTop() +
+				lipgloss.Height(header) + 1 + lipgloss.Height(content) + 1 +
+				inputStyle.GetBorderTopSize() + inputStyle.GetMarginTop() + inputStyle.GetPaddingTop()
+		}
+	}
+	DrawCenterCursor(scr, area, view, cur)
+	return cur
+}
+
+// gutter marks the row under the cursor and the rows with a comment.
+func (a *Annotate) gutter(row int) string {
+	t := a.com.Styles
+	marker := " "
+	if row == a.cursor {
+		marker = t.Base.Foreground(t.Primary).Render("▌")
+	}
+	comment := " "
+	if line := a.lines[row]; line != nil {
+		here := annotation.Annotation{Path: a.current().Path, Line: line.line, Side: line.side}
+		for _, existing := range a.annotations {
+			if existing.Same(here) {
+				comment = t.Base.Foreground(t.Secondary).Render("●")
+				break
+			}
+		}
+	}
+	return marker + comment
+}
+
+func (a *Annotate) renderHeader(contentWidth int) string {
+	t := a.com.Styles
+	title := common.DialogTitle(t, "Line Comments", contentWidth-t.Dialog.Title.GetHorizontalFrameSize(), t.Primary, t.Secondary)
+	title = t.Dialog.Title.Render(title)
+	if len(a.files) == 0 {
+		return lipgloss.JoinVertical(lipgloss.Left, title, "", t.Muted.Render("No files changed in this session"))
+	}
+
+	file := a.current()
+	position := t.Muted.Render(fmt.Sprintf("%d of %d", a.index+1, len(a.files)))
+	path := t.Base.Render(" " + file.Path)
+	view := " • diff"
+	if a.preview {
+		view = " • file"
+	}
+	info := t.Muted.Render(fmt.Sprintf("%s • %d comments", view, len(a.annotations)))
+	return lipgloss.JoinVertical(lipgloss.Left, title, "", position+path+info)
+}
+
+func (a *Annotate) renderFooter(contentWidth int) string {
+	t := a.com.Styles
+	a.help.SetWidth(contentWidth)
+	if !a.commenting {
+		return a.help.View(a)
+	}
+	current, _ := a.currentAnnotation()
+	a.input.SetWidth(max(0, contentWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-2)) // (2) cursor and prompt padding
+	label := t.Muted.Render("Comment on " + current.Location())
+	return lipgloss.JoinVertical(lipgloss.Left, t.Dialog.InputPrompt.Render(a.input.View()), label, a.help.View(a))
+}
+
+// ShortHelp implements [help.KeyMap].
+func (a *Annotate) ShortHelp() []key.Binding {
+	if a.commenting {
+		return []key.Binding{a.keyMap.Save, a.keyMap.Cancel}
+	}
+	bindings := []key.Binding{a.keyMap.UpDown, a.keyMap.Comment, a.keyMap.Delete, a.keyMap.TogglePreview}
+	if len(a.files) > 1 {
+		bindings = append(bindings, a.keyMap.Next)
+	}
+	return append(bindings, a.keyMap.Close)
+}
+
+// FullHelp implements [help.KeyMap].
+func (a *Annotate) FullHelp() [][]key.Binding {
+	return [][]key.Binding{a.ShortHelp()}
+}
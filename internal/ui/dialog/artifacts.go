@@ -0,0 +1,210 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/artifact"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	// ArtifactsID is the identifier for the artifacts dialog.
+	ArtifactsID          = "artifacts"
+	artifactsDialogWidth = 72
+	// artifactsMaxVisible is how many artifacts are listed at once.
+	artifactsMaxVisible = 12
+)
+
+// Artifacts lists the files the agent generated in a session, to open them
+// or export them to the workspace.
+type Artifacts struct {
+	com       *common.Common
+	help      help.Model
+	artifacts []artifact.Artifact
+	selected  int
+	offset    int
+
+	keyMap struct {
+		Open     key.Binding
+		Export   key.Binding
+		CopyPath key.Binding
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*Artifacts)(nil)
+
+// NewArtifacts creates a new artifacts dialog listing artifacts.
+func NewArtifacts(com *common.Common, artifacts []artifact.Artifact) *Artifacts {
+	a := &Artifacts{com: com, artifacts: artifacts}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	a.help = help
+
+	a.keyMap.Open = key.NewBinding(
+		key.WithKeys("enter", "o"),
+		key.WithHelp("enter", "open"),
+	)
+	a.keyMap.Export = key.NewBinding(
+		key.WithKeys("x", "X"),
+		key.WithHelp("x", "export to workspace"),
+	)
+	a.keyMap.CopyPath = key.NewBinding(
+		key.WithKeys("c", "y"),
+		key.WithHelp("c", "copy path"),
+	)
+	a.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n", "j"),
+		key.WithHelp("↓", "next item"),
+	)
+	a.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p", "k"),
+		key.WithHelp("↑", "previous item"),
+	)
+	a.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑/↓", "choose"),
+	)
+	a.keyMap.Close = CloseKey
+	return a
+}
+
+// SetArtifacts replaces the artifacts listed, keeping the selected one when
+// it is still there.
+func (a *Artifacts) SetArtifacts(artifacts []artifact.Artifact) {
+	current, ok := a.current()
+	a.artifacts = artifacts
+	if ok {
+		for i, art := range artifacts {
+			if art.Path == current.Path {
+				a.selected = i
+				return
+			}
+		}
+	}
+	a.selected = min(a.selected, max(0, len(artifacts)-1))
+}
+
+func (a *Artifacts) current() (artifact.Artifact, bool) {
+	if a.selected < 0 || a.selected >= len(a.artifacts) {
+		return artifact.Artifact{}, false
+	}
+	return a.artifacts[a.selected], true
+}
+
+// ID implements [Dialog].
+func (*Artifacts) ID() string {
+	return ArtifactsID
+}
+
+// HandleMsg implements [Dialog].
+func (a *Artifacts) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		current, ok := a.current()
+		switch {
+		case key.Matches(msg, a.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, a.keyMap.Next):
+			if len(a.artifacts) > 0 {
+				a.selected = (a.selected + 1) % len(a.artifacts)
+			}
+		case key.Matches(msg, a.keyMap.Previous):
+			if len(a.artifacts) > 0 {
+				a.selected = (a.selected - 1 + len(a.artifacts)) % len(a.artifacts)
+			}
+		case key.Matches(msg, a.keyMap.Open):
+			if ok {
+				return ActionOpenArtifact{Artifact: current}
+			}
+		case key.Matches(msg, a.keyMap.Export):
+			if ok {
+				return ActionExportArtifact{Artifact: current}
+			}
+		case key.Matches(msg, a.keyMap.CopyPath):
+			if ok {
+				return ActionCmd{Cmd: common.CopyToClipboard(current.Path, "Artifact path copied to clipboard")}
+			}
+		}
+	}
+	return nil
+}
+
+// Draw implements [Dialog].
+func (a *Artifacts) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := a.com.Styles
+	width := max(0, min(artifactsDialogWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize()
+	a.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Artifacts"
+	if len(a.artifacts) == 0 {
+		rc.AddPart(t.Base.Padding(0, 1).Width(innerWidth).Render(
+			t.Muted.Render("The agent has not generated any artifacts in this session"),
+		))
+		rc.Help = a.help.View(a)
+		DrawCenter(scr, area, rc.Render())
+		return nil
+	}
+
+	// Keep the selected artifact in view.
+	visible := min(artifactsMaxVisible, len(a.artifacts))
+	if a.selected < a.offset {
+		a.offset = a.selected
+	} else if a.selected >= a.offset+visible {
+		a.offset = a.selected - visible + 1
+	}
+	a.offset = min(a.offset, len(a.artifacts)-visible)
+
+	var list strings.Builder
+	for i, art := range a.artifacts[a.offset : a.offset+visible] {
+		style := t.Dialog.NormalItem
+		if a.offset+i == a.selected {
+			style = t.Dialog.SelectedItem
+		}
+		if i > 0 {
+			list.WriteString("\n")
+		}
+		info := fmt.Sprintf("%s • %s", humanize.IBytes(uint64(max(art.Size, 0))), humanize.Time(art.ModTime))
+		// Long names lose their start, as the file name matters most.
+		name := art.Name
+		if room := innerWidth - 3 - ansi.StringWidth(info); room > 1 && ansi.StringWidth(name) > room {
+			name = "…" + ansi.TruncateLeft(name, ansi.StringWidth(name)-room+1, "")
+		}
+		gap := max(1, innerWidth-2-ansi.StringWidth(name)-ansi.StringWidth(info))
+		list.WriteString(style.Width(innerWidth).Render(name + strings.Repeat(" ", gap) + info))
+	}
+	rc.AddPart(list.String())
+	if len(a.artifacts) > visible {
+		rc.AddPart(t.Base.Padding(0, 1).Render(t.Muted.Render(fmt.Sprintf("%d of %d", a.selected+1, len(a.artifacts)))))
+	}
+	rc.Help = a.help.View(a)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp implements [help.KeyMap].
+func (a *Artifacts) ShortHelp() []key.Binding {
+	if len(a.artifacts) == 0 {
+		return []key.Binding{a.keyMap.Close}
+	}
+	return []key.Binding{a.keyMap.UpDown, a.keyMap.Open, a.keyMap.Export, a.keyMap.CopyPath, a.keyMap.Close}
+}
+
+// FullHelp implements [help.KeyMap].
+func (a *Artifacts) FullHelp() [][]key.Binding {
+	return [][]key.Binding{a.ShortHelp()}
+}
@@ -0,0 +1,149 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+const (
+	// AttachmentBudgetID is the identifier for the attachment budget dialog.
+	AttachmentBudgetID          = "attachment_budget"
+	attachmentBudgetDialogWidth = 64
+)
+
+// attachmentBudgetOption is a way to send attachments over the budget.
+type attachmentBudgetOption struct {
+	strategy    agent.TrimStrategy
+	title       string
+	description string
+}
+
+var attachmentBudgetOptions = []attachmentBudgetOption{
+	{agent.TrimOutline, "Outline only", "Send the declarations and headings of each file"},
+	{agent.TrimTopMatches, "Top matches", "Send the parts of each file that best match the prompt"},
+	{agent.TrimSplit, "Split across turns", "Send the files whole over several messages"},
+	{"", "Send anyway", "Send everything in one message"},
+}
+
+// AttachmentBudget asks how to send attachments that are over the
+// attachment budget.
+type AttachmentBudget struct {
+	com      *common.Common
+	help     help.Model
+	tokens   int64
+	budget   int64
+	selected int
+
+	keyMap struct {
+		Select   key.Binding
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*AttachmentBudget)(nil)
+
+// NewAttachmentBudget creates a new attachment budget dialog for
+// attachments of tokens estimated tokens.
+func NewAttachmentBudget(com *common.Common, tokens, budget int64) *AttachmentBudget {
+	a := &AttachmentBudget{com: com, tokens: tokens, budget: budget}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	a.help = help
+
+	a.keyMap.Select = key.NewBinding(
+		key.WithKeys("enter", "ctrl+y"),
+		key.WithHelp("enter", "confirm"),
+	)
+	a.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n", "j"),
+		key.WithHelp("↓", "next item"),
+	)
+	a.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p", "k"),
+		key.WithHelp("↑", "previous item"),
+	)
+	a.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑/↓", "choose"),
+	)
+	a.keyMap.Close = CloseKey
+	return a
+}
+
+// ID implements [Dialog].
+func (*AttachmentBudget) ID() string {
+	return AttachmentBudgetID
+}
+
+// HandleMsg implements [Dialog].
+func (a *AttachmentBudget) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, a.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, a.keyMap.Next):
+			a.selected = (a.selected + 1) % len(attachmentBudgetOptions)
+		case key.Matches(msg, a.keyMap.Previous):
+			a.selected = (a.selected - 1 + len(attachmentBudgetOptions)) % len(attachmentBudgetOptions)
+		case key.Matches(msg, a.keyMap.Select):
+			return ActionTrimAttachments{Strategy: attachmentBudgetOptions[a.selected].strategy}
+		}
+	}
+	return nil
+}
+
+// Draw implements [Dialog].
+func (a *AttachmentBudget) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := a.com.Styles
+	width := max(0, min(attachmentBudgetDialogWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize()
+	a.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Attachments Over Budget"
+	rc.AddPart(t.Base.Padding(0, 1).Width(innerWidth).Render(fmt.Sprintf(
+		"The attachments are about %d tokens, over the budget of %d. How should they be sent?",
+		a.tokens, a.budget,
+	)))
+
+	var options strings.Builder
+	for i, opt := range attachmentBudgetOptions {
+		style := t.Dialog.NormalItem
+		if i == a.selected {
+			style = t.Dialog.SelectedItem
+		}
+		if i > 0 {
+			options.WriteString("\n")
+		}
+		options.WriteString(style.Width(innerWidth).Render(opt.title))
+		options.WriteString("\n")
+		options.WriteString(t.Dialog.NormalItem.Width(innerWidth).Render(t.Muted.Render(opt.description)))
+	}
+	rc.AddPart(options.String())
+	rc.Help = a.help.View(a)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp implements [help.KeyMap].
+func (a *AttachmentBudget) ShortHelp() []key.Binding {
+	return []key.Binding{a.keyMap.UpDown, a.keyMap.Select, a.keyMap.Close}
+}
+
+// FullHelp implements [help.KeyMap].
+func (a *AttachmentBudget) FullHelp() [][]key.Binding {
+	return [][]key.Binding{a.ShortHelp()}
+}
@@ -0,0 +1,306 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/batch"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// BatchID is the identifier for the batch instruction dialog.
+const BatchID = "batch"
+
+// Batch is a dialog for picking matches of a search and giving one
+// instruction to apply at all of them.
+type Batch struct {
+	com      *common.Common
+	sites    []batch.Site
+	selected []bool
+	cursor   int
+
+	instructing bool
+	input       textinput.Model
+
+	viewport viewport.Model
+
+	help   help.Model
+	keyMap batchKeyMap
+}
+
+type batchKeyMap struct {
+	Up        key.Binding
+	Down      key.Binding
+	UpDown    key.Binding
+	Toggle    key.Binding
+	ToggleAll key.Binding
+	Instruct  key.Binding
+	Send      key.Binding
+	Cancel    key.Binding
+	Close     key.Binding
+}
+
+func defaultBatchKeyMap() batchKeyMap {
+	return batchKeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+		),
+		UpDown: key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑/↓", "choose match"),
+		),
+		Toggle: key.NewBinding(
+			key.WithKeys("space", "x"),
+			key.WithHelp("space", "select"),
+		),
+		ToggleAll: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "select all/none"),
+		),
+		Instruct: key.NewBinding(
+			key.WithKeys("enter", "i"),
+			key.WithHelp("enter", "write instruction"),
+		),
+		Send: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "send"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "back"),
+		),
+		Close: CloseKey,
+	}
+}
+
+var _ Dialog = (*Batch)(nil)
+
+// NewBatch creates a new batch instruction dialog over the sites a search
+// found, all of them selected.
+func NewBatch(com *common.Common, sites []batch.Site) *Batch {
+	h := help.New()
+	h.Styles = com.Styles.DialogHelpStyles()
+
+	input := textinput.New()
+	input.SetVirtualCursor(false)
+	input.Placeholder = "What should change at each site?"
+	input.SetStyles(com.Styles.TextInput)
+
+	vp := viewport.New()
+	vp.KeyMap = viewport.KeyMap{
+		Up:           key.NewBinding(key.WithDisabled()),
+		Down:         key.NewBinding(key.WithDisabled()),
+		PageUp:       key.NewBinding(key.WithDisabled()),
+		PageDown:     key.NewBinding(key.WithDisabled()),
+		HalfPageUp:   key.NewBinding(key.WithDisabled()),
+		HalfPageDown: key.NewBinding(key.WithDisabled()),
+		Left:         key.NewBinding(key.WithDisabled()),
+		Right:        key.NewBinding(key.WithDisabled()),
+	}
+
+	selected := make([]bool, len(sites))
+	for i := range selected {
+		selected[i] = true
+	}
+	return &Batch{
+		com:      com,
+		sites:    sites,
+		selected: selected,
+		input:    input,
+		viewport: vp,
+		help:     h,
+		keyMap:   defaultBatchKeyMap(),
+	}
+}
+
+// ID implements [Dialog].
+func (*Batch) ID() string {
+	return BatchID
+}
+
+// HandleMsg implements [Dialog].
+func (b *Batch) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		if b.instructing {
+			return b.handleInstructionKey(msg)
+		}
+		switch {
+		case key.Matches(msg, b.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, b.keyMap.Up):
+			b.moveCursor(-1)
+		case key.Matches(msg, b.keyMap.Down):
+			b.moveCursor(1)
+		case key.Matches(msg, b.keyMap.Toggle):
+			if b.cursor < len(b.selected) {
+				b.selected[b.cursor] = !b.selected[b.cursor]
+			}
+		case key.Matches(msg, b.keyMap.ToggleAll):
+			all := len(b.Selected()) < len(b.sites)
+			for i := range b.selected {
+				b.selected[i] = all
+			}
+		case key.Matches(msg, b.keyMap.Instruct):
+			if len(b.Selected()) > 0 {
+				b.instructing = true
+				return ActionCmd{b.input.Focus()}
+			}
+		}
+	case tea.MouseWheelMsg:
+		b.viewport, _ = b.viewport.Update(msg)
+	case tea.PasteMsg:
+		if b.instructing {
+			var cmd tea.Cmd
+			b.input, cmd = b.input.Update(msg)
+			return ActionCmd{cmd}
+		}
+	}
+	return nil
+}
+
+func (b *Batch) handleInstructionKey(msg tea.KeyPressMsg) Action {
+	switch {
+	case key.Matches(msg, b.keyMap.Cancel):
+		b.instructing = false
+		b.input.Blur()
+	case key.Matches(msg, b.keyMap.Send):
+		if instruction := strings.TrimSpace(b.input.Value()); instruction != "" {
+			return ActionSendBatch{Instruction: instruction, Sites: b.Selected()}
+		}
+	default:
+		var cmd tea.Cmd
+		b.input, cmd = b.input.Update(msg)
+		return ActionCmd{cmd}
+	}
+	return nil
+}
+
+// Selected returns the sites picked so far, in the order of the search.
+func (b *Batch) Selected() []batch.Site {
+	var sites []batch.Site
+	for i, site := range b.sites {
+		if b.selected[i] {
+			sites = append(sites, site)
+		}
+	}
+	return sites
+}
+
+func (b *Batch) moveCursor(delta int) {
+	b.cursor = max(0, min(len(b.sites)-1, b.cursor+delta))
+	if b.cursor < b.viewport.YOffset() {
+		b.viewport.SetYOffset(b.cursor)
+	} else if bottom := b.viewport.YOffset() + b.viewport.Height() - 1; b.cursor > bottom {
+		b.viewport.SetYOffset(b.viewport.YOffset() + b.cursor - bottom)
+	}
+}
+
+func (b *Batch) renderRow(i, width int) string {
+	t := b.com.Styles
+	site := b.sites[i]
+	marker := " "
+	if i == b.cursor {
+		marker = t.Base.Foreground(t.Primary).Render("▌")
+	}
+	check := t.Muted.Render("[ ] ")
+	if b.selected[i] {
+		check = t.Base.Foreground(t.Secondary).Render("[x] ")
+	}
+	location := t.Muted.Render(site.Location())
+	code := t.Base.Render("  " + strings.ReplaceAll(strings.TrimSpace(site.Code), "\t", "    "))
+	return ansi.Truncate(marker+" "+check+location+code, width, "…")
+}
+
+// Draw implements [Dialog].
+func (b *Batch) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := b.com.Styles
+	width, maxHeight := area.Dx(), area.Dy()
+	if width > minWindowWidth && maxHeight > minWindowHeight {
+		width = min(int(float64(width)*diffSizeRatio), diffMaxWidth)
+		maxHeight = int(float64(maxHeight) * diffSizeRatio)
+	}
+
+	dialogStyle := t.Dialog.View.Width(width).Padding(0, 1)
+	const dialogHorizontalPadding = 2
+	contentWidth := width - t.Dialog.View.GetHorizontalFrameSize() - dialogHorizontalPadding
+
+	header := b.renderHeader(contentWidth)
+	footer := b.renderFooter(contentWidth)
+	frameHeight := dialogStyle.GetVerticalFrameSize() + layoutSpacingLines
+	availableHeight := max(3, min(len(b.sites), maxHeight-lipgloss.Height(header)-lipgloss.Height(footer)-frameHeight))
+
+	viewportWidth := contentWidth - 1 // Reserve space for the scrollbar.
+	rows := make([]string, len(b.sites))
+	for i := range b.sites {
+		rows[i] = b.renderRow(i, viewportWidth)
+	}
+
+	b.viewport.SetWidth(viewportWidth)
+	b.viewport.SetHeight(availableHeight)
+	b.viewport.SetContent(strings.Join(rows, "\n"))
+	scrollbar := common.Scrollbar(t, availableHeight, b.viewport.TotalLineCount(), availableHeight, b.viewport.YOffset())
+	content := lipgloss.JoinHorizontal(lipgloss.Top, b.viewport.View(), scrollbar)
+
+	inner := lipgloss.JoinVertical(lipgloss.Left, header, "", content, "", footer)
+	view := dialogStyle.Render(inner)
+
+	var cur *tea.Cursor
+	if b.instructing {
+		if cur = b.input.Cursor(); cur != nil {
+			inputStyle := t.Dialog.InputPrompt
+			cur.X += dialogStyle.GetBorderLeftSize() + dialogStyle.GetPaddingLeft() +
+				inputStyle.GetBorderLeftSize() + inputStyle.GetMarginLeft() + inputStyle.GetPaddingLeft()
+			// The input is below the header, the content and a blank line
+			// around it.
+			cur.Y += dialogStyle.GetBorderTopSize() + dialogStyle.GetPaddingTop() +
+				lipgloss.Height(header) + 1 + lipgloss.Height(content) + 1 +
+				inputStyle.GetBorderTopSize() + inputStyle.GetMarginTop() + inputStyle.GetPaddingTop()
+		}
+	}
+	DrawCenterCursor(scr, area, view, cur)
+	return cur
+}
+
+func (b *Batch) renderHeader(contentWidth int) string {
+	t := b.com.Styles
+	title := common.DialogTitle(t, "Batch Instruction", contentWidth-t.Dialog.Title.GetHorizontalFrameSize(), t.Primary, t.Secondary)
+	title = t.Dialog.Title.Render(title)
+	info := t.Muted.Render(fmt.Sprintf("%d of %d matches selected", len(b.Selected()), len(b.sites)))
+	return lipgloss.JoinVertical(lipgloss.Left, title, "", info)
+}
+
+func (b *Batch) renderFooter(contentWidth int) string {
+	t := b.com.Styles
+	b.help.SetWidth(contentWidth)
+	if !b.instructing {
+		return b.help.View(b)
+	}
+	b.input.SetWidth(max(0, contentWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-2)) // (2) cursor and prompt padding
+	label := t.Muted.Render(fmt.Sprintf("Applied at each of the %d selected matches", len(b.Selected())))
+	return lipgloss.JoinVertical(lipgloss.Left, t.Dialog.InputPrompt.Render(b.input.View()), label, b.help.View(b))
+}
+
+// ShortHelp implements [help.KeyMap].
+func (b *Batch) ShortHelp() []key.Binding {
+	if b.instructing {
+		return []key.Binding{b.keyMap.Send, b.keyMap.Cancel}
+	}
+	return []key.Binding{b.keyMap.UpDown, b.keyMap.Toggle, b.keyMap.ToggleAll, b.keyMap.Instruct, b.keyMap.Close}
+}
+
+// FullHelp implements [help.KeyMap].
+func (b *Batch) FullHelp() [][]key.Binding {
+	return [][]key.Binding{b.ShortHelp()}
+}
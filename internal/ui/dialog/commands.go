@@ -1,6 +1,7 @@
 package dialog
 
 import (
+	"fmt"
 	"os"
 	"strings"
 
@@ -11,6 +12,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"github.com/charmbracelet/crush/internal/commands"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/export"
 	"github.com/charmbracelet/crush/internal/ui/common"
 	"github.com/charmbracelet/crush/internal/ui/list"
 	"github.com/charmbracelet/crush/internal/ui/styles"
@@ -390,11 +392,19 @@ func (c *Commands) defaultCommands() []*CommandItem {
 		NewCommandItem(c.com.Styles, "new_session", "New Session", "ctrl+n", ActionNewSession{}),
 		NewCommandItem(c.com.Styles, "switch_session", "Sessions", "ctrl+s", ActionOpenDialog{SessionsID}),
 		NewCommandItem(c.com.Styles, "switch_model", "Switch Model", "ctrl+l", ActionOpenDialog{ModelsID}),
+		NewCommandItem(c.com.Styles, "switch_theme", "Switch Theme", "", ActionOpenDialog{ThemesID}),
 	}
 
 	// Only show compact command if there's an active session
 	if c.hasSession {
-		commands = append(commands, NewCommandItem(c.com.Styles, "summarize", "Summarize Session", "", ActionSummarize{SessionID: c.sessionID}))
+		commands = append(commands,
+			NewCommandItem(c.com.Styles, "summarize", "Summarize Session", "", ActionSummarize{SessionID: c.sessionID}),
+			NewCommandItem(c.com.Styles, "rename_session", "Rename Session", "", ActionRenameSession{}),
+			NewCommandItem(c.com.Styles, "export_markdown", "Export Session as Markdown", "", ActionExportSession{SessionID: c.sessionID, Format: export.Markdown}),
+			NewCommandItem(c.com.Styles, "export_html", "Export Session as HTML", "", ActionExportSession{SessionID: c.sessionID, Format: export.HTML}),
+			NewCommandItem(c.com.Styles, "share_session", "Share Session", "", ActionConfirmShare{SessionID: c.sessionID}),
+			NewCommandItem(c.com.Styles, "label", "Label Turns", "", ActionLabelSession{SessionID: c.sessionID}),
+		)
 	}
 
 	// Add reasoning toggle for models that support it
@@ -426,6 +436,9 @@ func (c *Commands) defaultCommands() []*CommandItem {
 	if c.windowWidth >= sidebarCompactModeBreakpoint && c.hasSession {
 		commands = append(commands, NewCommandItem(c.com.Styles, "toggle_sidebar", "Toggle Sidebar", "", ActionToggleCompactMode{}))
 	}
+	if c.hasSession {
+		commands = append(commands, NewCommandItem(c.com.Styles, "toggle_side_pane", "Toggle Changes Pane", "alt+p", ActionToggleSidePane{}))
+	}
 	if c.hasSession {
 		cfg := c.com.Config()
 		agentCfg := cfg.Agents[config.AgentCoder]
@@ -443,6 +456,23 @@ func (c *Commands) defaultCommands() []*CommandItem {
 		commands = append(commands, NewCommandItem(c.com.Styles, "open_external_editor", "Open External Editor", "ctrl+o", ActionExternalEditor{}))
 	}
 
+	if c.hasSession {
+		commands = append(commands, NewCommandItem(c.com.Styles, "line_comments", "Comment on Changed Lines", "", ActionOpenDialog{AnnotateID}))
+		commands = append(commands, NewCommandItem(c.com.Styles, "quick_actions", "Act on Latest Reply", "a", ActionOpenDialog{QuickActionsID}))
+	}
+
+	commands = append(commands, NewCommandItem(c.com.Styles, "tour", "Take the Tour", "", ActionOpenDialog{TourID}))
+
+	if c.hasSession {
+		if artifacts, err := c.com.App.Artifacts(c.sessionID); err == nil && len(artifacts) > 0 {
+			commands = append(commands, NewCommandItem(c.com.Styles, "artifacts", fmt.Sprintf("Artifacts (%d)", len(artifacts)), "", ActionOpenDialog{ArtifactsID}))
+		}
+	}
+
+	if pending := len(c.com.App.PendingEdits()); pending > 0 {
+		commands = append(commands, NewCommandItem(c.com.Styles, "review_edits", fmt.Sprintf("Review Staged Edits (%d)", pending), "", ActionOpenDialog{StagedEditsID}))
+	}
+
 	if c.hasTodos || c.hasQueue {
 		var label string
 		switch {
@@ -458,7 +488,15 @@ func (c *Commands) defaultCommands() []*CommandItem {
 
 	commands = append(commands,
 		NewCommandItem(c.com.Styles, "toggle_yolo", "Toggle Yolo Mode", "", ActionToggleYoloMode{}),
+		NewCommandItem(c.com.Styles, "toggle_plan", "Toggle Plan Mode", "shift+tab", ActionTogglePlanMode{}),
+		NewCommandItem(c.com.Styles, "toggle_review", "Toggle Review Mode", "shift+tab", ActionToggleReviewMode{}),
+		NewCommandItem(c.com.Styles, "toggle_chat_only", "Toggle Chat-Only Session", "", ActionToggleChatOnly{}),
+		NewCommandItem(c.com.Styles, "toggle_translation", "Toggle Translation", "", ActionToggleTranslate{}),
+		NewCommandItem(c.com.Styles, "raw", "Send Raw Message", "", ActionOpenDialog{RawMessageID}),
+		NewCommandItem(c.com.Styles, "project_permissions", "Project Permissions", "", ActionOpenDialog{ProjectPermissionsID}),
+		NewCommandItem(c.com.Styles, "mcp_servers", "MCP Servers", "", ActionOpenDialog{MCPServersID}),
 		NewCommandItem(c.com.Styles, "toggle_help", "Toggle Help", "ctrl+g", ActionToggleHelp{}),
+		NewCommandItem(c.com.Styles, "commit", "Commit Staged Changes", "", ActionWriteCommitMessage{}),
 		NewCommandItem(c.com.Styles, "init", "Initialize Project", "", ActionInitializeProject{}),
 		NewCommandItem(c.com.Styles, "quit", "Quit", "ctrl+c", tea.QuitMsg{}),
 	)
@@ -0,0 +1,110 @@
+package dialog
+
+import (
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+const (
+	// CommitID is the identifier for the commit dialog.
+	CommitID          = "commit"
+	commitDialogWidth = 80
+)
+
+// Commit shows the commit message written for the staged changes and
+// commits them once confirmed.
+type Commit struct {
+	com     *common.Common
+	help    help.Model
+	message string
+
+	keyMap struct {
+		Commit     key.Binding
+		Edit       key.Binding
+		Regenerate key.Binding
+		Close      key.Binding
+	}
+}
+
+var _ Dialog = (*Commit)(nil)
+
+// NewCommit creates a new commit dialog for message.
+func NewCommit(com *common.Common, message string) *Commit {
+	c := &Commit{com: com, message: message}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	c.help = help
+
+	c.keyMap.Commit = key.NewBinding(
+		key.WithKeys("enter", "ctrl+y"),
+		key.WithHelp("enter", "commit"),
+	)
+	c.keyMap.Edit = key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit"),
+	)
+	c.keyMap.Regenerate = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "rewrite"),
+	)
+	c.keyMap.Close = CloseKey
+	return c
+}
+
+// SetMessage replaces the message, after it was edited or rewritten.
+func (c *Commit) SetMessage(message string) {
+	c.message = message
+}
+
+// ID implements [Dialog].
+func (*Commit) ID() string {
+	return CommitID
+}
+
+// HandleMsg implements [Dialog].
+func (c *Commit) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, c.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, c.keyMap.Commit):
+			return ActionCommit{Message: c.message}
+		case key.Matches(msg, c.keyMap.Edit):
+			return ActionEditCommitMessage{Message: c.message}
+		case key.Matches(msg, c.keyMap.Regenerate):
+			return ActionWriteCommitMessage{}
+		}
+	}
+	return nil
+}
+
+// Draw implements [Dialog].
+func (c *Commit) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := c.com.Styles
+	width := max(0, min(commitDialogWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize()
+	c.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Commit Staged Changes"
+	rc.AddPart(t.Base.Padding(0, 1).Width(innerWidth).Render(c.message))
+	rc.Help = c.help.View(c)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp implements [help.KeyMap].
+func (c *Commit) ShortHelp() []key.Binding {
+	return []key.Binding{c.keyMap.Commit, c.keyMap.Edit, c.keyMap.Regenerate, c.keyMap.Close}
+}
+
+// FullHelp implements [help.KeyMap].
+func (c *Commit) FullHelp() [][]key.Binding {
+	return [][]key.Binding{c.ShortHelp()}
+}
@@ -0,0 +1,161 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// CopyID is the identifier for the copy dialog.
+	CopyID          = "copy"
+	copyDialogWidth = 72
+	// copyMaxItems is how many parts are listed at once.
+	copyMaxItems = 8
+	// copyPreviewLines is how many lines of the chosen part are previewed.
+	copyPreviewLines = 8
+)
+
+// CopyPart is a part of a message that can be copied on its own.
+type CopyPart struct {
+	Title string
+	Text  string
+}
+
+// Copy lets the user pick a part of the selected message, such as one of
+// its code blocks or the output of a tool, and copy it to the clipboard.
+type Copy struct {
+	com      *common.Common
+	help     help.Model
+	parts    []CopyPart
+	selected int
+	offset   int
+
+	keyMap struct {
+		Copy     key.Binding
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*Copy)(nil)
+
+// NewCopy creates a new copy dialog for parts, the whole message first.
+func NewCopy(com *common.Common, parts []CopyPart) *Copy {
+	c := &Copy{com: com, parts: parts}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	c.help = help
+
+	c.keyMap.Copy = key.NewBinding(
+		key.WithKeys("enter", "y", "c", "ctrl+y"),
+		key.WithHelp("enter", "copy"),
+	)
+	c.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n", "j", "tab"),
+		key.WithHelp("↓", "next part"),
+	)
+	c.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p", "k", "shift+tab"),
+		key.WithHelp("↑", "previous part"),
+	)
+	c.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑/↓", "choose"),
+	)
+	c.keyMap.Close = CloseKey
+	return c
+}
+
+// ID implements [Dialog].
+func (*Copy) ID() string {
+	return CopyID
+}
+
+// HandleMsg implements [Dialog].
+func (c *Copy) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, c.keyMap.Close):
+			return ActionClose{}
+		case len(c.parts) == 0:
+		case key.Matches(msg, c.keyMap.Next):
+			c.selected = (c.selected + 1) % len(c.parts)
+		case key.Matches(msg, c.keyMap.Previous):
+			c.selected = (c.selected - 1 + len(c.parts)) % len(c.parts)
+		case key.Matches(msg, c.keyMap.Copy):
+			part := c.parts[c.selected]
+			return ActionCopy{Title: part.Title, Text: part.Text}
+		}
+	}
+	// Keep the chosen part in the listed window.
+	c.offset = min(c.selected, max(c.offset, c.selected-copyMaxItems+1))
+	return nil
+}
+
+// Draw implements [Dialog].
+func (c *Copy) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := c.com.Styles
+	width := max(0, min(copyDialogWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize()
+	c.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Copy"
+
+	var items strings.Builder
+	end := min(len(c.parts), c.offset+copyMaxItems)
+	for i := c.offset; i < end; i++ {
+		style := t.Dialog.NormalItem
+		if i == c.selected {
+			style = t.Dialog.SelectedItem
+		}
+		if i > c.offset {
+			items.WriteString("\n")
+		}
+		items.WriteString(style.Width(innerWidth).Render(c.parts[i].Title))
+	}
+	rc.AddPart(items.String())
+
+	if len(c.parts) > 0 {
+		rc.AddPart(t.Subtle.Padding(0, 1).Render(c.preview(innerWidth - 2)))
+	}
+	rc.Help = c.help.View(c)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// preview returns the first lines of the chosen part, cut to width.
+func (c *Copy) preview(width int) string {
+	lines := strings.Split(strings.TrimRight(c.parts[c.selected].Text, "\n"), "\n")
+	more := len(lines) - copyPreviewLines
+	lines = lines[:min(len(lines), copyPreviewLines)]
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(strings.ReplaceAll(line, "\t", "    "), width, "…")
+	}
+	if more > 0 {
+		lines = append(lines, fmt.Sprintf("… %d more lines", more))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ShortHelp implements [help.KeyMap].
+func (c *Copy) ShortHelp() []key.Binding {
+	return []key.Binding{c.keyMap.UpDown, c.keyMap.Copy, c.keyMap.Close}
+}
+
+// FullHelp implements [help.KeyMap].
+func (c *Copy) FullHelp() [][]key.Binding {
+	return [][]key.Binding{c.ShortHelp()}
+}
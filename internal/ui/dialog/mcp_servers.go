@@ -0,0 +1,206 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/util"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// MCPServersID is the identifier for the MCP servers dialog.
+	MCPServersID          = "mcp_servers"
+	mcpServersDialogWidth = 80
+	mcpServersMaxVisible  = 8
+)
+
+// MCPServers shows the state and tools of the configured MCP servers, and
+// enables, disables and restarts them.
+type MCPServers struct {
+	com      *common.Common
+	help     help.Model
+	servers  []config.MCP
+	selected int
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Toggle   key.Binding
+		Restart  key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*MCPServers)(nil)
+
+// NewMCPServers creates a new MCP servers dialog.
+func NewMCPServers(com *common.Common) *MCPServers {
+	s := &MCPServers{com: com, servers: com.Config().MCP.Sorted()}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	s.help = help
+
+	s.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n", "j"),
+		key.WithHelp("↓", "next item"),
+	)
+	s.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p", "k"),
+		key.WithHelp("↑", "previous item"),
+	)
+	s.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑/↓", "choose"),
+	)
+	s.keyMap.Toggle = key.NewBinding(
+		key.WithKeys("space", "e"),
+		key.WithHelp("space", "enable/disable"),
+	)
+	s.keyMap.Restart = key.NewBinding(
+		key.WithKeys("r", "R"),
+		key.WithHelp("r", "restart"),
+	)
+	s.keyMap.Close = CloseKey
+	return s
+}
+
+// ID implements [Dialog].
+func (*MCPServers) ID() string {
+	return MCPServersID
+}
+
+// HandleMsg implements [Dialog].
+func (s *MCPServers) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, s.keyMap.Close):
+			return ActionClose{}
+		case len(s.servers) == 0:
+		case key.Matches(msg, s.keyMap.Next):
+			s.selected = (s.selected + 1) % len(s.servers)
+		case key.Matches(msg, s.keyMap.Previous):
+			s.selected = (s.selected - 1 + len(s.servers)) % len(s.servers)
+		case key.Matches(msg, s.keyMap.Toggle):
+			name := s.servers[s.selected].Name
+			if mcp.Enabled(name) {
+				if err := mcp.Disable(name); err != nil {
+					return ActionCmd{util.ReportError(err)}
+				}
+				return ActionCmd{util.ReportInfo("Disabled " + name)}
+			}
+			if err := mcp.Enable(name); err != nil {
+				return ActionCmd{util.ReportError(err)}
+			}
+			return ActionCmd{util.ReportInfo("Starting " + name)}
+		case key.Matches(msg, s.keyMap.Restart):
+			name := s.servers[s.selected].Name
+			if err := mcp.Restart(name); err != nil {
+				return ActionCmd{util.ReportError(err)}
+			}
+			return ActionCmd{util.ReportInfo("Restarting " + name)}
+		}
+	}
+	return nil
+}
+
+// Draw implements [Dialog].
+func (s *MCPServers) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := s.com.Styles
+	width := max(0, min(mcpServersDialogWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize()
+	s.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "MCP Servers"
+	if len(s.servers) == 0 {
+		rc.AddPart(t.Base.Padding(0, 1).Width(innerWidth).Render(
+			t.Muted.Render("No MCP servers are configured. Add them under \"mcp\" in crush.json."),
+		))
+	} else {
+		tools := make(map[string][]*mcp.Tool)
+		for name, serverTools := range mcp.Tools() {
+			tools[name] = serverTools
+		}
+
+		start := max(0, min(s.selected-mcpServersMaxVisible/2, len(s.servers)-mcpServersMaxVisible))
+		end := min(len(s.servers), start+mcpServersMaxVisible)
+		var items strings.Builder
+		for i := start; i < end; i++ {
+			server := s.servers[i]
+			state, ok := mcp.GetState(server.Name)
+			if !ok {
+				state = mcp.ClientInfo{Name: server.Name}
+				if server.MCP.Disabled {
+					state.State = mcp.StateDisabled
+				}
+			}
+			style := t.Dialog.NormalItem
+			if i == s.selected {
+				style = t.Dialog.SelectedItem
+			}
+			if i > start {
+				items.WriteString("\n")
+			}
+			items.WriteString(style.Width(innerWidth).Render(server.Name + " • " + state.State.String()))
+			items.WriteString("\n")
+			items.WriteString(t.Dialog.NormalItem.Width(innerWidth).Render(t.Muted.Render(
+				ansi.Truncate(mcpServerDetail(server, state, tools[server.Name]), innerWidth-2, "…"),
+			)))
+		}
+		rc.AddPart(items.String())
+	}
+	rc.Help = s.help.View(s)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// mcpServerDetail describes the connection of server and the tools it
+// offers.
+func mcpServerDetail(server config.MCP, state mcp.ClientInfo, tools []*mcp.Tool) string {
+	switch state.State {
+	case mcp.StateConnected:
+		names := make([]string, 0, len(tools))
+		for _, tool := range tools {
+			names = append(names, tool.Name)
+		}
+		detail := fmt.Sprintf("%s since %s", server.MCP.Type, state.ConnectedAt.Format("15:04:05"))
+		if len(names) == 0 {
+			return detail + " • no tools"
+		}
+		return fmt.Sprintf("%s • %d tools: %s", detail, len(names), strings.Join(names, ", "))
+	case mcp.StateError:
+		if state.Error != nil {
+			return "error: " + state.Error.Error()
+		}
+		return "error"
+	case mcp.StateStarting:
+		return "connecting..."
+	default:
+		return string(server.MCP.Type)
+	}
+}
+
+// ShortHelp implements [help.KeyMap].
+func (s *MCPServers) ShortHelp() []key.Binding {
+	if len(s.servers) == 0 {
+		return []key.Binding{s.keyMap.Close}
+	}
+	return []key.Binding{s.keyMap.UpDown, s.keyMap.Toggle, s.keyMap.Restart, s.keyMap.Close}
+}
+
+// FullHelp implements [help.KeyMap].
+func (s *MCPServers) FullHelp() [][]key.Binding {
+	return [][]key.Binding{s.ShortHelp()}
+}
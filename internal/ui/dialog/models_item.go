@@ -1,6 +1,9 @@
 package dialog
 
 import (
+	"fmt"
+	"strings"
+
 	"charm.land/catwalk/pkg/catwalk"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/crush/internal/config"
@@ -102,9 +105,15 @@ func (m *ModelItem) ID() string {
 
 // Render implements ListItem.
 func (m *ModelItem) Render(width int) string {
-	var providerInfo string
+	var info []string
 	if m.showProvider {
-		providerInfo = string(m.prov.Name)
+		info = append(info, string(m.prov.Name))
+	}
+	if m.model.ContextWindow > 0 {
+		info = append(info, common.FormatTokens(m.model.ContextWindow))
+	}
+	if m.model.CostPer1MIn > 0 || m.model.CostPer1MOut > 0 {
+		info = append(info, fmt.Sprintf("$%s/$%s", formatPrice(m.model.CostPer1MIn), formatPrice(m.model.CostPer1MOut)))
 	}
 	styles := ListItemStyles{
 		ItemBlurred:     m.t.Dialog.NormalItem,
@@ -112,7 +121,13 @@ func (m *ModelItem) Render(width int) string {
 		InfoTextBlurred: m.t.Base,
 		InfoTextFocused: m.t.Base,
 	}
-	return renderItem(styles, m.model.Name, providerInfo, m.focused, width, m.cache, &m.m)
+	return renderItem(styles, m.model.Name, strings.Join(info, " · "), m.focused, width, m.cache, &m.m)
+}
+
+// formatPrice formats the price of a million tokens, dropping cents when
+// there are none.
+func formatPrice(price float64) string {
+	return strings.TrimSuffix(fmt.Sprintf("%.2f", price), ".00")
 }
 
 // SetFocused implements ListItem.
@@ -13,6 +13,7 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/shell"
 	"github.com/charmbracelet/crush/internal/stringext"
 	"github.com/charmbracelet/crush/internal/ui/common"
 	"github.com/charmbracelet/crush/internal/ui/styles"
@@ -28,6 +29,7 @@ type PermissionAction string
 const (
 	PermissionAllow           PermissionAction = "allow"
 	PermissionAllowForSession PermissionAction = "allow_session"
+	PermissionAllowForProject PermissionAction = "allow_project"
 	PermissionDeny            PermissionAction = "deny"
 )
 
@@ -61,7 +63,10 @@ type Permissions struct {
 	fullscreen   bool // true when dialog is fullscreen
 
 	permission     permission.PermissionRequest
-	selectedOption int // 0: Allow, 1: Allow for session, 2: Deny
+	selectedOption int // 0: Allow, 1: Allow for session, 2: Allow for project, 3: Deny
+	// onceOnly hides the options that allow the request for later, for
+	// writes as root with sudo.
+	onceOnly bool
 
 	viewport      viewport.Model
 	viewportDirty bool // true when viewport content needs to be re-rendered
@@ -85,6 +90,7 @@ type permissionsKeyMap struct {
 	Select           key.Binding
 	Allow            key.Binding
 	AllowSession     key.Binding
+	AllowProject     key.Binding
 	Deny             key.Binding
 	Close            key.Binding
 	ToggleDiffMode   key.Binding
@@ -123,6 +129,10 @@ func defaultPermissionsKeyMap() permissionsKeyMap {
 			key.WithKeys("s", "S", "ctrl+s"),
 			key.WithHelp("s", "allow session"),
 		),
+		AllowProject: key.NewBinding(
+			key.WithKeys("p", "P"),
+			key.WithHelp("p", "allow project"),
+		),
 		Deny: key.NewBinding(
 			key.WithKeys("d", "D"),
 			key.WithHelp("d", "deny"),
@@ -200,10 +210,14 @@ func NewPermissions(com *common.Common, perm permission.PermissionRequest, opts
 		com:            com,
 		permission:     perm,
 		selectedOption: 0,
+		onceOnly:       perm.Action == tools.SudoWriteAction,
 		viewport:       vp,
 		help:           h,
 		keyMap:         km,
 	}
+	if perm.Risk != nil && perm.Risk.Severity >= shell.SeverityHigh {
+		p.selectedOption = 3
+	}
 
 	for _, opt := range opts {
 		opt(p)
@@ -233,16 +247,18 @@ func (p *Permissions) HandleMsg(msg tea.Msg) Action {
 			// Escape denies the permission request.
 			return p.respond(PermissionDeny)
 		case key.Matches(msg, p.keyMap.Right), key.Matches(msg, p.keyMap.Tab):
-			p.selectedOption = (p.selectedOption + 1) % 3
+			p.moveSelection(1)
 		case key.Matches(msg, p.keyMap.Left):
-			// Add 2 instead of subtracting 1 to avoid negative modulo.
-			p.selectedOption = (p.selectedOption + 2) % 3
+			// Add 3 instead of subtracting 1 to avoid negative modulo.
+			p.moveSelection(3)
 		case key.Matches(msg, p.keyMap.Select):
 			return p.selectCurrentOption()
 		case key.Matches(msg, p.keyMap.Allow):
 			return p.respond(PermissionAllow)
-		case key.Matches(msg, p.keyMap.AllowSession):
+		case key.Matches(msg, p.keyMap.AllowSession) && !p.onceOnly:
 			return p.respond(PermissionAllowForSession)
+		case key.Matches(msg, p.keyMap.AllowProject) && !p.onceOnly:
+			return p.respond(PermissionAllowForProject)
 		case key.Matches(msg, p.keyMap.Deny):
 			return p.respond(PermissionDeny)
 		case key.Matches(msg, p.keyMap.ToggleDiffMode):
@@ -296,12 +312,23 @@ func (p *Permissions) HandleMsg(msg tea.Msg) Action {
 	return nil
 }
 
+// moveSelection moves the selected option by step, skipping the options
+// that are hidden.
+func (p *Permissions) moveSelection(step int) {
+	p.selectedOption = (p.selectedOption + step) % 4
+	for p.onceOnly && (p.selectedOption == 1 || p.selectedOption == 2) {
+		p.selectedOption = (p.selectedOption + step) % 4
+	}
+}
+
 func (p *Permissions) selectCurrentOption() tea.Msg {
 	switch p.selectedOption {
 	case 0:
 		return p.respond(PermissionAllow)
 	case 1:
 		return p.respond(PermissionAllowForSession)
+	case 2:
+		return p.respond(PermissionAllowForProject)
 	default:
 		return p.respond(PermissionDeny)
 	}
@@ -315,8 +342,11 @@ func (p *Permissions) respond(action PermissionAction) tea.Msg {
 }
 
 func (p *Permissions) hasDiffView() bool {
+	if p.permission.Action == tools.SudoWriteAction {
+		return false
+	}
 	switch p.permission.ToolName {
-	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName:
+	case tools.EditToolName, tools.EditSymbolToolName, tools.WriteToolName, tools.MultiEditToolName:
 		return true
 	}
 	return false
@@ -463,7 +493,7 @@ func (p *Permissions) renderHeader(contentWidth int) string {
 			lines = append(lines, p.renderKeyValue("URL", params.URL, contentWidth))
 			lines = append(lines, p.renderKeyValue("File", fsext.PrettyPath(params.FilePath), contentWidth))
 		}
-	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.ViewToolName:
+	case tools.EditToolName, tools.EditSymbolToolName, tools.WriteToolName, tools.MultiEditToolName, tools.ViewToolName:
 		var filePath string
 		switch params := p.permission.Params.(type) {
 		case tools.EditPermissionsParams:
@@ -482,11 +512,55 @@ func (p *Permissions) renderHeader(contentWidth int) string {
 		if params, ok := p.permission.Params.(tools.LSPermissionsParams); ok {
 			lines = append(lines, p.renderKeyValue("Directory", fsext.PrettyPath(params.Path), contentWidth))
 		}
+	case tools.ReplaceAllToolName:
+		if params, ok := p.permission.Params.(tools.ReplaceAllPermissionsParams); ok {
+			lines = append(lines, p.renderKeyValue("Pattern", params.Pattern, contentWidth))
+			lines = append(lines, p.renderKeyValue("Matches", fmt.Sprintf("%d in %d files", params.Matches, params.Files), contentWidth))
+		}
+	case tools.RunTestsToolName:
+		if params, ok := p.permission.Params.(tools.RunTestsPermissionsParams); ok {
+			lines = append(lines, p.renderKeyValue("Framework", params.Framework, contentWidth))
+		}
+	case tools.ProcessToolName:
+		if params, ok := p.permission.Params.(tools.ProcessPermissionsParams); ok && params.Description != "" {
+			lines = append(lines, p.renderKeyValue("Desc", params.Description, contentWidth))
+		}
+	}
+	if params, ok := p.permission.Params.(tools.SudoWritePermissionsParams); ok {
+		lines = append(lines, p.renderKeyValue("File", fsext.PrettyPath(params.FilePath), contentWidth))
+		lines = append(lines, p.renderElevated(contentWidth))
+	}
+	if risk := p.permission.Risk; risk != nil && risk.Severity > shell.SeverityNone {
+		lines = append(lines, p.renderRisk(*risk, contentWidth))
 	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, lines...)
 }
 
+// renderRisk renders the verdict of the command safety classifier.
+func (p *Permissions) renderRisk(risk shell.Verdict, width int) string {
+	t := p.com.Styles
+	color := t.Warning
+	if risk.Severity >= shell.SeverityHigh {
+		color = t.Error
+	}
+	keyStr := t.Muted.Render("Risk")
+	value := risk.Severity.String()
+	if reasons := risk.Reasons(); len(reasons) > 0 {
+		value += ": " + strings.Join(reasons, ", ")
+	}
+	valueStr := t.Base.Foreground(color).Width(width - lipgloss.Width(keyStr) - 1).Render(" " + value)
+	return lipgloss.JoinHorizontal(lipgloss.Left, keyStr, valueStr)
+}
+
+// renderElevated warns that the request runs as root.
+func (p *Permissions) renderElevated(width int) string {
+	t := p.com.Styles
+	keyStr := t.Muted.Render("Runs as")
+	valueStr := t.Base.Foreground(t.Error).Width(width - lipgloss.Width(keyStr) - 1).Render(" root, through sudo, after the change was approved")
+	return lipgloss.JoinHorizontal(lipgloss.Left, keyStr, valueStr)
+}
+
 func (p *Permissions) renderKeyValue(key, value string, width int) string {
 	t := p.com.Styles
 	keyStyle := t.Muted
@@ -522,21 +596,36 @@ func prettyName(name string) string {
 }
 
 func (p *Permissions) renderContent(width int) string {
+	if params, ok := p.permission.Params.(tools.SudoWritePermissionsParams); ok {
+		return p.renderContentPanel(params.Command, width)
+	}
 	switch p.permission.ToolName {
 	case tools.BashToolName:
 		return p.renderBashContent(width)
-	case tools.EditToolName:
+	case tools.EditToolName, tools.EditSymbolToolName:
 		return p.renderEditContent(width)
 	case tools.WriteToolName:
 		return p.renderWriteContent(width)
 	case tools.MultiEditToolName:
 		return p.renderMultiEditContent(width)
+	case tools.ReplaceAllToolName:
+		return p.renderReplaceAllContent(width)
+	case tools.RunTestsToolName:
+		return p.renderRunTestsContent(width)
+	case tools.ProcessToolName:
+		return p.renderProcessContent(width)
 	case tools.DownloadToolName:
 		return p.renderDownloadContent(width)
 	case tools.FetchToolName:
 		return p.renderFetchContent(width)
 	case tools.AgenticFetchToolName:
 		return p.renderAgenticFetchContent(width)
+	case tools.WebSearchToolName:
+		return p.renderWebSearchContent(width)
+	case tools.GitHubCreatePRToolName:
+		return p.renderGitHubCreatePRContent(width)
+	case tools.GitHubReviewToolName:
+		return p.renderGitHubReviewContent(width)
 	case tools.ViewToolName:
 		return p.renderViewContent(width)
 	case tools.LSToolName:
@@ -555,6 +644,24 @@ func (p *Permissions) renderBashContent(width int) string {
 	return p.renderContentPanel(params.Command, width)
 }
 
+func (p *Permissions) renderRunTestsContent(width int) string {
+	params, ok := p.permission.Params.(tools.RunTestsPermissionsParams)
+	if !ok {
+		return ""
+	}
+
+	return p.renderContentPanel(params.Command, width)
+}
+
+func (p *Permissions) renderProcessContent(width int) string {
+	params, ok := p.permission.Params.(tools.ProcessPermissionsParams)
+	if !ok {
+		return ""
+	}
+
+	return p.renderContentPanel(params.Command, width)
+}
+
 func (p *Permissions) renderEditContent(contentWidth int) string {
 	params, ok := p.permission.Params.(tools.EditPermissionsParams)
 	if !ok {
@@ -579,6 +686,14 @@ func (p *Permissions) renderMultiEditContent(contentWidth int) string {
 	return p.renderDiff(params.FilePath, params.OldContent, params.NewContent, contentWidth)
 }
 
+func (p *Permissions) renderReplaceAllContent(width int) string {
+	params, ok := p.permission.Params.(tools.ReplaceAllPermissionsParams)
+	if !ok {
+		return ""
+	}
+	return p.renderContentPanel(params.Diff, width)
+}
+
 func (p *Permissions) renderDiff(filePath, oldContent, newContent string, contentWidth int) string {
 	if !p.viewportDirty {
 		if p.isSplitMode() {
@@ -631,6 +746,37 @@ func (p *Permissions) renderFetchContent(width int) string {
 	return p.renderContentPanel(params.URL, width)
 }
 
+func (p *Permissions) renderWebSearchContent(width int) string {
+	params, ok := p.permission.Params.(tools.WebSearchPermissionsParams)
+	if !ok {
+		return ""
+	}
+
+	return p.renderContentPanel(params.Query, width)
+}
+
+func (p *Permissions) renderGitHubCreatePRContent(width int) string {
+	params, ok := p.permission.Params.(tools.GitHubCreatePRPermissionsParams)
+	if !ok {
+		return ""
+	}
+
+	content := params.Title
+	if params.Body != "" {
+		content += "\n\n" + params.Body
+	}
+	return p.renderContentPanel(content, width)
+}
+
+func (p *Permissions) renderGitHubReviewContent(width int) string {
+	params, ok := p.permission.Params.(tools.GitHubReviewPermissionsParams)
+	if !ok {
+		return ""
+	}
+
+	return p.renderContentPanel(params.Body, width)
+}
+
 func (p *Permissions) renderAgenticFetchContent(width int) string {
 	params, ok := p.permission.Params.(tools.AgenticFetchPermissionsParams)
 	if !ok {
@@ -733,7 +879,11 @@ func (p *Permissions) renderButtons(contentWidth int) string {
 	buttons := []common.ButtonOpts{
 		{Text: "Allow", UnderlineIndex: 0, Selected: p.selectedOption == 0},
 		{Text: "Allow for Session", UnderlineIndex: 10, Selected: p.selectedOption == 1},
-		{Text: "Deny", UnderlineIndex: 0, Selected: p.selectedOption == 2},
+		{Text: "Allow for Project", UnderlineIndex: 10, Selected: p.selectedOption == 2},
+		{Text: "Deny", UnderlineIndex: 0, Selected: p.selectedOption == 3},
+	}
+	if p.onceOnly {
+		buttons = []common.ButtonOpts{buttons[0], buttons[3]}
 	}
 
 	content := common.ButtonGroup(p.com.Styles, buttons, "  ")
@@ -0,0 +1,155 @@
+package dialog
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/util"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+const (
+	// ProjectPermissionsID is the identifier for the project permissions
+	// dialog.
+	ProjectPermissionsID          = "project_permissions"
+	projectPermissionsDialogWidth = 72
+	projectPermissionsMaxVisible  = 10
+)
+
+// ProjectPermissions lists the requests that are always allowed in the
+// project and revokes them.
+type ProjectPermissions struct {
+	com      *common.Common
+	help     help.Model
+	grants   []permission.Grant
+	selected int
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Revoke   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*ProjectPermissions)(nil)
+
+// NewProjectPermissions creates a new project permissions dialog.
+func NewProjectPermissions(com *common.Common) *ProjectPermissions {
+	p := &ProjectPermissions{com: com, grants: com.App.Permissions.ProjectGrants()}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	p.help = help
+
+	p.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n", "j"),
+		key.WithHelp("↓", "next item"),
+	)
+	p.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p", "k"),
+		key.WithHelp("↑", "previous item"),
+	)
+	p.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑/↓", "choose"),
+	)
+	p.keyMap.Revoke = key.NewBinding(
+		key.WithKeys("d", "D", "delete", "backspace"),
+		key.WithHelp("d", "revoke"),
+	)
+	p.keyMap.Close = CloseKey
+	return p
+}
+
+// ID implements [Dialog].
+func (*ProjectPermissions) ID() string {
+	return ProjectPermissionsID
+}
+
+// HandleMsg implements [Dialog].
+func (p *ProjectPermissions) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, p.keyMap.Close):
+			return ActionClose{}
+		case len(p.grants) == 0:
+		case key.Matches(msg, p.keyMap.Next):
+			p.selected = (p.selected + 1) % len(p.grants)
+		case key.Matches(msg, p.keyMap.Previous):
+			p.selected = (p.selected - 1 + len(p.grants)) % len(p.grants)
+		case key.Matches(msg, p.keyMap.Revoke):
+			grant := p.grants[p.selected]
+			if err := p.com.App.Permissions.RevokeProjectGrant(grant); err != nil {
+				return ActionCmd{util.ReportError(err)}
+			}
+			p.grants = p.com.App.Permissions.ProjectGrants()
+			p.selected = min(p.selected, max(0, len(p.grants)-1))
+			return ActionCmd{util.ReportInfo("Revoked " + grant.String())}
+		}
+	}
+	return nil
+}
+
+// Draw implements [Dialog].
+func (p *ProjectPermissions) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := p.com.Styles
+	width := max(0, min(projectPermissionsDialogWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize()
+	p.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Project Permissions"
+	if len(p.grants) == 0 {
+		rc.AddPart(t.Base.Padding(0, 1).Width(innerWidth).Render(
+			t.Muted.Render(`Nothing is always allowed in this project yet. Choose "Allow for Project" when asked for a permission to add it here.`),
+		))
+	} else {
+		rc.AddPart(t.Base.Padding(0, 1).Width(innerWidth).Render(
+			"These requests are allowed without asking in every session of the project.",
+		))
+
+		start := max(0, min(p.selected-projectPermissionsMaxVisible/2, len(p.grants)-projectPermissionsMaxVisible))
+		end := min(len(p.grants), start+projectPermissionsMaxVisible)
+		var items strings.Builder
+		for i := start; i < end; i++ {
+			grant := p.grants[i]
+			style := t.Dialog.NormalItem
+			if i == p.selected {
+				style = t.Dialog.SelectedItem
+			}
+			if i > start {
+				items.WriteString("\n")
+			}
+			items.WriteString(style.Width(innerWidth).Render(grant.Subject))
+			items.WriteString("\n")
+			items.WriteString(t.Dialog.NormalItem.Width(innerWidth).Render(t.Muted.Render(
+				grant.ToolName + " " + grant.Action + " • since " + grant.CreatedAt.Format("2006-01-02"),
+			)))
+		}
+		rc.AddPart(items.String())
+	}
+	rc.Help = p.help.View(p)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp implements [help.KeyMap].
+func (p *ProjectPermissions) ShortHelp() []key.Binding {
+	if len(p.grants) == 0 {
+		return []key.Binding{p.keyMap.Close}
+	}
+	return []key.Binding{p.keyMap.UpDown, p.keyMap.Revoke, p.keyMap.Close}
+}
+
+// FullHelp implements [help.KeyMap].
+func (p *ProjectPermissions) FullHelp() [][]key.Binding {
+	return [][]key.Binding{p.ShortHelp()}
+}
@@ -0,0 +1,156 @@
+package dialog
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// QuickActionsID is the identifier for the quick actions dialog.
+	QuickActionsID          = "quick_actions"
+	quickActionsDialogWidth = 48
+)
+
+// QuickAction is a common follow-up to the latest reply of the agent.
+type QuickAction string
+
+const (
+	QuickActionRetry   QuickAction = "retry"
+	QuickActionCopy    QuickAction = "copy"
+	QuickActionExplain QuickAction = "explain"
+	QuickActionTests   QuickAction = "tests"
+	QuickActionDiff    QuickAction = "diff"
+)
+
+// quickActions are the actions offered, each run by its key.
+var quickActions = []struct {
+	action QuickAction
+	key    string
+	title  string
+}{
+	{QuickActionRetry, "r", "Retry"},
+	{QuickActionCopy, "c", "Copy"},
+	{QuickActionExplain, "e", "Explain more"},
+	{QuickActionTests, "t", "Write tests for this"},
+	{QuickActionDiff, "d", "Show diff"},
+}
+
+// QuickActions offers single-key follow-ups to the latest reply of the
+// agent.
+type QuickActions struct {
+	com      *common.Common
+	help     help.Model
+	selected int
+
+	keyMap struct {
+		Select   key.Binding
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*QuickActions)(nil)
+
+// NewQuickActions creates a new quick actions dialog.
+func NewQuickActions(com *common.Common) *QuickActions {
+	q := &QuickActions{com: com}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	q.help = help
+
+	q.keyMap.Select = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "run"),
+	)
+	q.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n", "j"),
+		key.WithHelp("↓", "next item"),
+	)
+	q.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p", "k"),
+		key.WithHelp("↑", "previous item"),
+	)
+	q.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑/↓", "choose"),
+	)
+	q.keyMap.Close = CloseKey
+	return q
+}
+
+// ID implements [Dialog].
+func (*QuickActions) ID() string {
+	return QuickActionsID
+}
+
+// HandleMsg implements [Dialog].
+func (q *QuickActions) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, q.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, q.keyMap.Next):
+			q.selected = (q.selected + 1) % len(quickActions)
+		case key.Matches(msg, q.keyMap.Previous):
+			q.selected = (q.selected - 1 + len(quickActions)) % len(quickActions)
+		case key.Matches(msg, q.keyMap.Select):
+			return ActionQuickAction{Action: quickActions[q.selected].action}
+		default:
+			for _, a := range quickActions {
+				if msg.String() == a.key {
+					return ActionQuickAction{Action: a.action}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Draw implements [Dialog].
+func (q *QuickActions) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := q.com.Styles
+	width := max(0, min(quickActionsDialogWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize()
+	q.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Latest Reply"
+
+	var list strings.Builder
+	for i, a := range quickActions {
+		style := t.Dialog.NormalItem
+		if i == q.selected {
+			style = t.Dialog.SelectedItem
+		}
+		if i > 0 {
+			list.WriteString("\n")
+		}
+		gap := max(1, innerWidth-2-ansi.StringWidth(a.title)-ansi.StringWidth(a.key))
+		list.WriteString(style.Width(innerWidth).Render(a.title + strings.Repeat(" ", gap) + a.key))
+	}
+	rc.AddPart(list.String())
+	rc.Help = q.help.View(q)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp implements [help.KeyMap].
+func (q *QuickActions) ShortHelp() []key.Binding {
+	return []key.Binding{q.keyMap.UpDown, q.keyMap.Select, q.keyMap.Close}
+}
+
+// FullHelp implements [help.KeyMap].
+func (q *QuickActions) FullHelp() [][]key.Binding {
+	return [][]key.Binding{q.ShortHelp()}
+}
@@ -0,0 +1,153 @@
+package dialog
+
+import (
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+const (
+	// RawMessageID is the identifier for the raw message dialog.
+	RawMessageID          = "raw_message"
+	rawMessageDialogWidth = 64
+)
+
+// rawMessageOption is how much of the session a raw message is sent with.
+type rawMessageOption struct {
+	history     agent.RawHistory
+	cancel      bool
+	title       string
+	description string
+}
+
+var rawMessageOptions = []rawMessageOption{
+	{history: agent.RawHistoryNone, title: "Message only", description: "Send the next message on its own"},
+	{history: agent.RawHistoryLastTurn, title: "With the last turn", description: "Include the last prompt and its answer"},
+	{history: agent.RawHistoryAll, title: "With the whole session", description: "Include every prompt and answer, without tool calls"},
+}
+
+var rawMessageCancel = rawMessageOption{cancel: true, title: "Send normally", description: "Send the next message to the agent as usual"}
+
+// RawMessage asks how much of the session the next message should be sent
+// to the bare model with.
+type RawMessage struct {
+	com      *common.Common
+	help     help.Model
+	options  []rawMessageOption
+	selected int
+
+	keyMap struct {
+		Select   key.Binding
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*RawMessage)(nil)
+
+// NewRawMessage creates a new raw message dialog. When pending is set, the
+// next message is already raw and the dialog also offers to undo that.
+func NewRawMessage(com *common.Common, pending bool) *RawMessage {
+	r := &RawMessage{com: com, options: rawMessageOptions}
+	if pending {
+		r.options = append(slices.Clone(rawMessageOptions), rawMessageCancel)
+	}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	r.help = help
+
+	r.keyMap.Select = key.NewBinding(
+		key.WithKeys("enter", "ctrl+y"),
+		key.WithHelp("enter", "confirm"),
+	)
+	r.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n", "j"),
+		key.WithHelp("↓", "next item"),
+	)
+	r.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p", "k"),
+		key.WithHelp("↑", "previous item"),
+	)
+	r.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑/↓", "choose"),
+	)
+	r.keyMap.Close = CloseKey
+	return r
+}
+
+// ID implements [Dialog].
+func (*RawMessage) ID() string {
+	return RawMessageID
+}
+
+// HandleMsg implements [Dialog].
+func (r *RawMessage) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, r.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, r.keyMap.Next):
+			r.selected = (r.selected + 1) % len(r.options)
+		case key.Matches(msg, r.keyMap.Previous):
+			r.selected = (r.selected - 1 + len(r.options)) % len(r.options)
+		case key.Matches(msg, r.keyMap.Select):
+			opt := r.options[r.selected]
+			return ActionSendRaw{History: opt.history, Cancel: opt.cancel}
+		}
+	}
+	return nil
+}
+
+// Draw implements [Dialog].
+func (r *RawMessage) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := r.com.Styles
+	width := max(0, min(rawMessageDialogWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize()
+	r.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Raw Message"
+	rc.AddPart(t.Base.Padding(0, 1).Width(innerWidth).Render(
+		"The next message goes straight to the model, without the system prompt or tools. How much of the session should it see?",
+	))
+
+	var options strings.Builder
+	for i, opt := range r.options {
+		style := t.Dialog.NormalItem
+		if i == r.selected {
+			style = t.Dialog.SelectedItem
+		}
+		if i > 0 {
+			options.WriteString("\n")
+		}
+		options.WriteString(style.Width(innerWidth).Render(opt.title))
+		options.WriteString("\n")
+		options.WriteString(t.Dialog.NormalItem.Width(innerWidth).Render(t.Muted.Render(opt.description)))
+	}
+	rc.AddPart(options.String())
+	rc.Help = r.help.View(r)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp implements [help.KeyMap].
+func (r *RawMessage) ShortHelp() []key.Binding {
+	return []key.Binding{r.keyMap.UpDown, r.keyMap.Select, r.keyMap.Close}
+}
+
+// FullHelp implements [help.KeyMap].
+func (r *RawMessage) FullHelp() [][]key.Binding {
+	return [][]key.Binding{r.ShortHelp()}
+}
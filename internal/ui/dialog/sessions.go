@@ -1,8 +1,11 @@
 package dialog
 
 import (
+	"cmp"
 	"context"
+	"slices"
 	"strings"
+	"time"
 
 	"charm.land/bubbles/v2/help"
 	"charm.land/bubbles/v2/key"
@@ -26,6 +29,7 @@ const (
 	sessionsModeNormal sessionsMode = iota
 	sessionsModeDeleting
 	sessionsModeUpdating
+	sessionsModeTagging
 )
 
 // Session is a session selector dialog.
@@ -36,6 +40,8 @@ type Session struct {
 	input              textinput.Model
 	selectedSessionInx int
 	sessions           []session.Session
+	// filter holds the tag, project and date terms typed in the input.
+	filter session.Filter
 
 	sessionsMode sessionsMode
 
@@ -46,6 +52,8 @@ type Session struct {
 		UpDown        key.Binding
 		Delete        key.Binding
 		Rename        key.Binding
+		Tag           key.Binding
+		Pin           key.Binding
 		ConfirmRename key.Binding
 		CancelRename  key.Binding
 		ConfirmDelete key.Binding
@@ -84,7 +92,7 @@ func NewSessions(com *common.Common, selectedSessionID string) (*Session, error)
 
 	s.input = textinput.New()
 	s.input.SetVirtualCursor(false)
-	s.input.Placeholder = "Enter session name"
+	s.input.Placeholder = "Enter session name, #tag, project: or after:"
 	s.input.SetStyles(com.Styles.TextInput)
 	s.input.Focus()
 
@@ -112,6 +120,14 @@ func NewSessions(com *common.Common, selectedSessionID string) (*Session, error)
 		key.WithKeys("ctrl+r"),
 		key.WithHelp("ctrl+r", "rename"),
 	)
+	s.keyMap.Tag = key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "tag"),
+	)
+	s.keyMap.Pin = key.NewBinding(
+		key.WithKeys("ctrl+f"),
+		key.WithHelp("ctrl+f", "pin"),
+	)
 	s.keyMap.ConfirmRename = key.NewBinding(
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "confirm"),
@@ -147,23 +163,27 @@ func (s *Session) HandleMsg(msg tea.Msg) Action {
 			switch {
 			case key.Matches(msg, s.keyMap.ConfirmDelete):
 				action := s.confirmDeleteSession()
-				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.sessions...)...)
+				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.visibleSessions()...)...)
 				s.list.SelectFirst()
 				s.list.ScrollToSelected()
 				return action
 			case key.Matches(msg, s.keyMap.CancelDelete):
 				s.sessionsMode = sessionsModeNormal
-				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.sessions...)...)
+				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.visibleSessions()...)...)
 			}
-		case sessionsModeUpdating:
+		case sessionsModeUpdating, sessionsModeTagging:
 			switch {
+			case key.Matches(msg, s.keyMap.ConfirmRename) && s.sessionsMode == sessionsModeTagging:
+				action := s.confirmTagSession()
+				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.visibleSessions()...)...)
+				return action
 			case key.Matches(msg, s.keyMap.ConfirmRename):
 				action := s.confirmRenameSession()
-				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.sessions...)...)
+				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.visibleSessions()...)...)
 				return action
 			case key.Matches(msg, s.keyMap.CancelRename):
 				s.sessionsMode = sessionsModeNormal
-				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.sessions...)...)
+				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.visibleSessions()...)...)
 			default:
 				item := s.list.SelectedItem()
 				if item == nil {
@@ -178,14 +198,18 @@ func (s *Session) HandleMsg(msg tea.Msg) Action {
 			case key.Matches(msg, s.keyMap.Close):
 				return ActionClose{}
 			case key.Matches(msg, s.keyMap.Rename):
-				s.sessionsMode = sessionsModeUpdating
-				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeUpdating, s.sessions...)...)
+				s.StartRename()
+			case key.Matches(msg, s.keyMap.Pin):
+				return s.togglePin()
+			case key.Matches(msg, s.keyMap.Tag):
+				s.sessionsMode = sessionsModeTagging
+				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeTagging, s.visibleSessions()...)...)
 			case key.Matches(msg, s.keyMap.Delete):
 				if s.isCurrentSessionBusy() {
 					return ActionCmd{util.ReportWarn("Agent is busy, please wait...")}
 				}
 				s.sessionsMode = sessionsModeDeleting
-				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeDeleting, s.sessions...)...)
+				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeDeleting, s.visibleSessions()...)...)
 			case key.Matches(msg, s.keyMap.Previous):
 				s.list.Focus()
 				if s.list.IsSelectedFirst() {
@@ -210,8 +234,10 @@ func (s *Session) HandleMsg(msg tea.Msg) Action {
 			default:
 				var cmd tea.Cmd
 				s.input, cmd = s.input.Update(msg)
-				value := s.input.Value()
-				s.list.SetFilter(value)
+				filter, query := session.ParseFilter(s.input.Value(), time.Now())
+				s.filter = filter
+				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.visibleSessions()...)...)
+				s.list.SetFilter(query)
 				s.list.ScrollToTop()
 				s.list.SetSelected(0)
 				return ActionCmd{cmd}
@@ -258,12 +284,16 @@ func (s *Session) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 		rc.TitleGradientToColor = t.Dialog.Sessions.DeletingTitleGradientToColor
 		rc.ViewStyle = t.Dialog.Sessions.DeletingView
 		rc.AddPart(t.Dialog.Sessions.DeletingMessage.Render("Delete this session?"))
-	case sessionsModeUpdating:
+	case sessionsModeUpdating, sessionsModeTagging:
 		rc.TitleStyle = t.Dialog.Sessions.RenamingingTitle
 		rc.TitleGradientFromColor = t.Dialog.Sessions.RenamingTitleGradientFromColor
 		rc.TitleGradientToColor = t.Dialog.Sessions.RenamingTitleGradientToColor
 		rc.ViewStyle = t.Dialog.Sessions.RenamingView
-		message := t.Dialog.Sessions.RenamingingMessage.Render("Rename this session?")
+		prompt := "Rename this session?"
+		if s.sessionsMode == sessionsModeTagging {
+			prompt = "Tag this session? Separate tags with spaces."
+		}
+		message := t.Dialog.Sessions.RenamingingMessage.Render(prompt)
 		rc.AddPart(message)
 		item := s.selectedSessionItem()
 		if item == nil {
@@ -357,6 +387,12 @@ func (s *Session) deleteSessionCmd(id string) tea.Cmd {
 	}
 }
 
+// StartRename switches the dialog to renaming the selected session.
+func (s *Session) StartRename() {
+	s.sessionsMode = sessionsModeUpdating
+	s.list.SetItems(sessionItems(s.com.Styles, sessionsModeUpdating, s.visibleSessions()...)...)
+}
+
 func (s *Session) confirmRenameSession() Action {
 	sessionItem := s.selectedSessionItem()
 	s.sessionsMode = sessionsModeNormal
@@ -371,7 +407,70 @@ func (s *Session) confirmRenameSession() Action {
 	session := sessionItem.Session
 	session.Title = newTitle
 	s.updateSession(session)
-	return ActionCmd{s.updateSessionCmd(session)}
+	return ActionCmd{s.renameSessionCmd(session.ID, newTitle)}
+}
+
+func (s *Session) confirmTagSession() Action {
+	sessionItem := s.selectedSessionItem()
+	s.sessionsMode = sessionsModeNormal
+	if sessionItem == nil {
+		return nil
+	}
+
+	sess := sessionItem.Session
+	sess.Tags = session.NormalizeTags([]string{sessionItem.InputValue()})
+	s.updateSession(sess)
+	return ActionCmd{s.tagSessionCmd(sess.ID, sess.Tags)}
+}
+
+// togglePin pins or unpins the selected session and moves it, as the list
+// keeps pinned sessions first.
+func (s *Session) togglePin() Action {
+	sessionItem := s.selectedSessionItem()
+	if sessionItem == nil {
+		return nil
+	}
+	sess := sessionItem.Session
+	sess.Pinned = !sess.Pinned
+	s.updateSession(sess)
+	slices.SortStableFunc(s.sessions, func(a, b session.Session) int {
+		if a.Pinned != b.Pinned {
+			if a.Pinned {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(b.UpdatedAt, a.UpdatedAt)
+	})
+	s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.visibleSessions()...)...)
+	for i, item := range s.list.FilteredItems() {
+		if item.(*SessionItem).ID() == sess.ID {
+			s.list.SetSelected(i)
+			break
+		}
+	}
+	s.list.ScrollToSelected()
+	return ActionCmd{func() tea.Msg {
+		if err := s.com.App.PinSession(context.TODO(), sess.ID, sess.Pinned); err != nil {
+			return util.NewErrorMsg(err)
+		}
+		return nil
+	}}
+}
+
+func (s *Session) tagSessionCmd(id string, tags []string) tea.Cmd {
+	return func() tea.Msg {
+		if err := s.com.App.TagSession(context.TODO(), id, tags...); err != nil {
+			return util.NewErrorMsg(err)
+		}
+		return nil
+	}
+}
+
+// visibleSessions returns the sessions that pass the tag, project and
+// date terms of the input. The rest of the input is matched by the list.
+func (s *Session) visibleSessions() []session.Session {
+	return s.filter.Apply(s.sessions)
 }
 
 func (s *Session) updateSession(session session.Session) {
@@ -383,10 +482,9 @@ func (s *Session) updateSession(session session.Session) {
 	}
 }
 
-func (s *Session) updateSessionCmd(session session.Session) tea.Cmd {
+func (s *Session) renameSessionCmd(id, title string) tea.Cmd {
 	return func() tea.Msg {
-		_, err := s.com.App.Sessions.Save(context.TODO(), session)
-		if err != nil {
+		if err := s.com.App.RenameSession(context.TODO(), id, title); err != nil {
 			return util.NewErrorMsg(err)
 		}
 		return nil
@@ -414,7 +512,7 @@ func (s *Session) ShortHelp() []key.Binding {
 			s.keyMap.ConfirmDelete,
 			s.keyMap.CancelDelete,
 		}
-	case sessionsModeUpdating:
+	case sessionsModeUpdating, sessionsModeTagging:
 		return []key.Binding{
 			s.keyMap.ConfirmRename,
 			s.keyMap.CancelRename,
@@ -423,6 +521,8 @@ func (s *Session) ShortHelp() []key.Binding {
 		return []key.Binding{
 			s.keyMap.UpDown,
 			s.keyMap.Rename,
+			s.keyMap.Tag,
+			s.keyMap.Pin,
 			s.keyMap.Delete,
 			s.keyMap.Select,
 			s.keyMap.Close,
@@ -436,6 +536,8 @@ func (s *Session) FullHelp() [][]key.Binding {
 	slice := []key.Binding{
 		s.keyMap.UpDown,
 		s.keyMap.Rename,
+		s.keyMap.Tag,
+		s.keyMap.Pin,
 		s.keyMap.Delete,
 		s.keyMap.Select,
 		s.keyMap.Close,
@@ -447,7 +549,7 @@ func (s *Session) FullHelp() [][]key.Binding {
 			s.keyMap.ConfirmDelete,
 			s.keyMap.CancelDelete,
 		}
-	case sessionsModeUpdating:
+	case sessionsModeUpdating, sessionsModeTagging:
 		slice = []key.Binding{
 			s.keyMap.ConfirmRename,
 			s.keyMap.CancelRename,
@@ -76,6 +76,12 @@ func (s *SessionItem) Cursor() *tea.Cursor {
 // Render returns the string representation of the session item.
 func (s *SessionItem) Render(width int) string {
 	info := humanize.Time(time.Unix(s.UpdatedAt, 0))
+	if len(s.Tags) > 0 {
+		info = "#" + strings.Join(s.Tags, " #") + " · " + info
+	}
+	if s.Pinned {
+		info = "pinned · " + info
+	}
 	styles := ListItemStyles{
 		ItemBlurred:     s.t.Dialog.NormalItem,
 		ItemFocused:     s.t.Dialog.SelectedItem,
@@ -87,13 +93,16 @@ func (s *SessionItem) Render(width int) string {
 	case sessionsModeDeleting:
 		styles.ItemBlurred = s.t.Dialog.Sessions.DeletingItemBlurred
 		styles.ItemFocused = s.t.Dialog.Sessions.DeletingItemFocused
-	case sessionsModeUpdating:
+	case sessionsModeUpdating, sessionsModeTagging:
 		styles.ItemBlurred = s.t.Dialog.Sessions.RenamingItemBlurred
 		styles.ItemFocused = s.t.Dialog.Sessions.RenamingingItemFocused
 		if s.focused {
 			inputWidth := width - styles.InfoTextFocused.GetHorizontalFrameSize()
 			s.updateTitleInput.SetWidth(inputWidth)
 			s.updateTitleInput.Placeholder = ansi.Truncate(s.Title, width, "…")
+			if s.sessionsMode == sessionsModeTagging {
+				s.updateTitleInput.Placeholder = "bug review api"
+			}
 			return styles.ItemFocused.Render(s.updateTitleInput.View())
 		}
 	}
@@ -188,7 +197,7 @@ func sessionItems(t *styles.Styles, mode sessionsMode, sessions ...session.Sessi
 	items := make([]list.FilterableItem, len(sessions))
 	for i, s := range sessions {
 		item := &SessionItem{Session: s, t: t, sessionsMode: mode}
-		if mode == sessionsModeUpdating {
+		if mode == sessionsModeUpdating || mode == sessionsModeTagging {
 			item.updateTitleInput = textinput.New()
 			item.updateTitleInput.SetVirtualCursor(false)
 			item.updateTitleInput.Prompt = ""
@@ -196,6 +205,9 @@ func sessionItems(t *styles.Styles, mode sessionsMode, sessions ...session.Sessi
 			inputStyle.Focused.Placeholder = t.Dialog.Sessions.RenamingPlaceholder
 			item.updateTitleInput.SetStyles(inputStyle)
 			item.updateTitleInput.Focus()
+			if mode == sessionsModeTagging {
+				item.updateTitleInput.SetValue(strings.Join(s.Tags, " "))
+			}
 		}
 		items[i] = item
 	}
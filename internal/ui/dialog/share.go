@@ -0,0 +1,105 @@
+package dialog
+
+import (
+	"fmt"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+const (
+	// ShareID is the identifier for the share dialog.
+	ShareID          = "share"
+	shareDialogWidth = 64
+)
+
+// Share asks for confirmation before a session is published.
+type Share struct {
+	com         *common.Common
+	help        help.Model
+	sessionID   string
+	destination string
+	upload      bool
+
+	keyMap struct {
+		Share key.Binding
+		Close key.Binding
+	}
+}
+
+var _ Dialog = (*Share)(nil)
+
+// NewShare creates a new share dialog for the session. When upload is set
+// the transcript goes to the endpoint at destination, otherwise it is
+// written to the destination directory.
+func NewShare(com *common.Common, sessionID, destination string, upload bool) *Share {
+	s := &Share{com: com, sessionID: sessionID, destination: destination, upload: upload}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	s.help = help
+
+	s.keyMap.Share = key.NewBinding(
+		key.WithKeys("y", "Y", "ctrl+y"),
+		key.WithHelp("y", "share"),
+	)
+	s.keyMap.Close = key.NewBinding(
+		key.WithKeys("n", "N", "esc", "alt+esc"),
+		key.WithHelp("n/esc", "cancel"),
+	)
+	return s
+}
+
+// ID implements [Dialog].
+func (*Share) ID() string {
+	return ShareID
+}
+
+// HandleMsg implements [Dialog].
+func (s *Share) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, s.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, s.keyMap.Share):
+			return ActionShareSession{SessionID: s.sessionID}
+		}
+	}
+	return nil
+}
+
+// Draw implements [Dialog].
+func (s *Share) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := s.com.Styles
+	width := max(0, min(shareDialogWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize()
+	s.help.SetWidth(innerWidth)
+
+	text := fmt.Sprintf("Write the transcript of this session as a standalone HTML page to %s?", s.destination)
+	if s.upload {
+		text = fmt.Sprintf("Upload the transcript of this session to %s? Anyone with the link can read it.", s.destination)
+	}
+	text += "\n\n" + t.Muted.Render("Known secrets and the values of .env files are masked first, but check the session holds nothing else private.")
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Share Session"
+	rc.AddPart(t.Base.Padding(0, 1).Width(innerWidth).Render(text))
+	rc.Help = s.help.View(s)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp implements [help.KeyMap].
+func (s *Share) ShortHelp() []key.Binding {
+	return []key.Binding{s.keyMap.Share, s.keyMap.Close}
+}
+
+// FullHelp implements [help.KeyMap].
+func (s *Share) FullHelp() [][]key.Binding {
+	return [][]key.Binding{s.ShortHelp()}
+}
@@ -0,0 +1,296 @@
+package dialog
+
+import (
+	"fmt"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/staging"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+// StagedEditsID is the identifier for the staged edits dialog.
+const StagedEditsID = "staged_edits"
+
+// StagedEdits is a dialog for reviewing the edits staged in review mode one
+// at a time and applying, changing or discarding them.
+type StagedEdits struct {
+	com   *common.Common
+	edits []staging.Edit
+	index int
+
+	viewport     viewport.Model
+	diffSplit    *bool // nil means use default based on width
+	defaultSplit bool
+	diffXOffset  int
+
+	help   help.Model
+	keyMap stagedEditsKeyMap
+}
+
+type stagedEditsKeyMap struct {
+	Previous       key.Binding
+	Next           key.Binding
+	Apply          key.Binding
+	ApplyAll       key.Binding
+	Discard        key.Binding
+	Edit           key.Binding
+	ToggleDiffMode key.Binding
+	ScrollUp       key.Binding
+	ScrollDown     key.Binding
+	ScrollLeft     key.Binding
+	ScrollRight    key.Binding
+	Scroll         key.Binding
+	Close          key.Binding
+}
+
+func defaultStagedEditsKeyMap() stagedEditsKeyMap {
+	return stagedEditsKeyMap{
+		Previous: key.NewBinding(
+			key.WithKeys("left", "h", "p"),
+			key.WithHelp("←/→", "previous/next"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("right", "l", "n"),
+			key.WithHelp("←/→", "previous/next"),
+		),
+		Apply: key.NewBinding(
+			key.WithKeys("a", "enter"),
+			key.WithHelp("a", "apply"),
+		),
+		ApplyAll: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "apply all"),
+		),
+		Discard: key.NewBinding(
+			key.WithKeys("d", "D"),
+			key.WithHelp("d", "discard"),
+		),
+		Edit: key.NewBinding(
+			key.WithKeys("e", "E"),
+			key.WithHelp("e", "edit"),
+		),
+		ToggleDiffMode: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "toggle diff view"),
+		),
+		ScrollUp: key.NewBinding(
+			key.WithKeys("shift+up", "K", "up", "k"),
+			key.WithHelp("↑", "scroll up"),
+		),
+		ScrollDown: key.NewBinding(
+			key.WithKeys("shift+down", "J", "down", "j"),
+			key.WithHelp("↓", "scroll down"),
+		),
+		ScrollLeft: key.NewBinding(
+			key.WithKeys("shift+left", "H"),
+			key.WithHelp("shift+←", "scroll left"),
+		),
+		ScrollRight: key.NewBinding(
+			key.WithKeys("shift+right", "L"),
+			key.WithHelp("shift+→", "scroll right"),
+		),
+		Scroll: key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑↓", "scroll"),
+		),
+		Close: CloseKey,
+	}
+}
+
+var _ Dialog = (*StagedEdits)(nil)
+
+// NewStagedEdits creates a new staged edits dialog.
+func NewStagedEdits(com *common.Common, edits []staging.Edit, split *bool) *StagedEdits {
+	h := help.New()
+	h.Styles = com.Styles.DialogHelpStyles()
+
+	km := defaultStagedEditsKeyMap()
+	vp := viewport.New()
+	vp.KeyMap = viewport.KeyMap{
+		Up:           km.ScrollUp,
+		Down:         km.ScrollDown,
+		PageUp:       key.NewBinding(key.WithDisabled()),
+		PageDown:     key.NewBinding(key.WithDisabled()),
+		HalfPageUp:   key.NewBinding(key.WithDisabled()),
+		HalfPageDown: key.NewBinding(key.WithDisabled()),
+		Left:         key.NewBinding(key.WithDisabled()),
+		Right:        key.NewBinding(key.WithDisabled()),
+	}
+
+	return &StagedEdits{
+		com:       com,
+		edits:     edits,
+		viewport:  vp,
+		diffSplit: split,
+		help:      h,
+		keyMap:    km,
+	}
+}
+
+// SetEdits replaces the edits under review, keeping the position when the
+// current edit is still pending.
+func (s *StagedEdits) SetEdits(edits []staging.Edit) {
+	current := s.current().ID
+	s.edits = edits
+	for i, edit := range edits {
+		if edit.ID == current {
+			s.index = i
+			return
+		}
+	}
+	s.index = min(s.index, max(0, len(edits)-1))
+	s.diffXOffset = 0
+	s.viewport.GotoTop()
+}
+
+func (s *StagedEdits) current() staging.Edit {
+	if s.index < 0 || s.index >= len(s.edits) {
+		return staging.Edit{}
+	}
+	return s.edits[s.index]
+}
+
+// ID implements [Dialog].
+func (*StagedEdits) ID() string {
+	return StagedEditsID
+}
+
+// HandleMsg implements [Dialog].
+func (s *StagedEdits) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		edit := s.current()
+		switch {
+		case key.Matches(msg, s.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, s.keyMap.Previous):
+			s.move(-1)
+		case key.Matches(msg, s.keyMap.Next):
+			s.move(1)
+		case key.Matches(msg, s.keyMap.ApplyAll):
+			ids := make([]string, 0, len(s.edits))
+			for _, e := range s.edits {
+				ids = append(ids, e.ID)
+			}
+			return ActionApplyStagedEdits{IDs: ids}
+		case key.Matches(msg, s.keyMap.Apply):
+			if edit.ID != "" {
+				return ActionApplyStagedEdits{IDs: []string{edit.ID}}
+			}
+		case key.Matches(msg, s.keyMap.Discard):
+			if edit.ID != "" {
+				return ActionDiscardStagedEdit{ID: edit.ID}
+			}
+		case key.Matches(msg, s.keyMap.Edit):
+			if edit.ID != "" {
+				return ActionEditStagedEdit{Edit: edit}
+			}
+		case key.Matches(msg, s.keyMap.ToggleDiffMode):
+			split := !s.isSplitMode()
+			s.diffSplit = &split
+		case key.Matches(msg, s.keyMap.ScrollLeft):
+			s.diffXOffset = max(0, s.diffXOffset-horizontalScrollStep)
+		case key.Matches(msg, s.keyMap.ScrollRight):
+			s.diffXOffset += horizontalScrollStep
+		default:
+			s.viewport, _ = s.viewport.Update(msg)
+		}
+	case tea.MouseWheelMsg:
+		s.viewport, _ = s.viewport.Update(msg)
+	}
+	return nil
+}
+
+func (s *StagedEdits) move(delta int) {
+	if len(s.edits) == 0 {
+		return
+	}
+	s.index = (s.index + delta + len(s.edits)) % len(s.edits)
+	s.diffXOffset = 0
+	s.viewport.GotoTop()
+}
+
+func (s *StagedEdits) isSplitMode() bool {
+	if s.diffSplit != nil {
+		return *s.diffSplit
+	}
+	return s.defaultSplit
+}
+
+// Draw implements [Dialog].
+func (s *StagedEdits) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := s.com.Styles
+	width, maxHeight := area.Dx(), area.Dy()
+	if width > minWindowWidth && maxHeight > minWindowHeight {
+		width = min(int(float64(width)*diffSizeRatio), diffMaxWidth)
+		maxHeight = int(float64(maxHeight) * diffSizeRatio)
+	}
+	s.defaultSplit = width >= splitModeMinWidth
+
+	dialogStyle := t.Dialog.View.Width(width).Padding(0, 1)
+	const dialogHorizontalPadding = 2
+	contentWidth := width - t.Dialog.View.GetHorizontalFrameSize() - dialogHorizontalPadding
+
+	header := s.renderHeader(contentWidth)
+	helpView := s.help.View(s)
+	frameHeight := dialogStyle.GetVerticalFrameSize() + layoutSpacingLines
+	availableHeight := max(3, maxHeight-lipgloss.Height(header)-lipgloss.Height(helpView)-frameHeight)
+
+	viewportWidth := contentWidth - 1 // Reserve space for the scrollbar.
+	edit := s.current()
+	formatter := common.DiffFormatter(t).
+		Before(fsext.PrettyPath(edit.Path), edit.OldContent).
+		After(fsext.PrettyPath(edit.Path), edit.NewContent).
+		XOffset(s.diffXOffset).
+		Width(viewportWidth)
+	if s.isSplitMode() {
+		formatter = formatter.Split()
+	} else {
+		formatter = formatter.Unified()
+	}
+
+	s.viewport.SetWidth(viewportWidth)
+	s.viewport.SetHeight(availableHeight)
+	s.viewport.SetContent(formatter.String())
+	scrollbar := common.Scrollbar(t, availableHeight, s.viewport.TotalLineCount(), availableHeight, s.viewport.YOffset())
+	content := lipgloss.JoinHorizontal(lipgloss.Top, s.viewport.View(), scrollbar)
+
+	inner := lipgloss.JoinVertical(lipgloss.Left, header, "", content, "", helpView)
+	DrawCenterCursor(scr, area, dialogStyle.Render(inner), nil)
+	return nil
+}
+
+func (s *StagedEdits) renderHeader(contentWidth int) string {
+	t := s.com.Styles
+	title := common.DialogTitle(t, "Staged Edits", contentWidth-t.Dialog.Title.GetHorizontalFrameSize(), t.Primary, t.Secondary)
+	title = t.Dialog.Title.Render(title)
+	if len(s.edits) == 0 {
+		return lipgloss.JoinVertical(lipgloss.Left, title, "", t.Muted.Render("No edits waiting for review"))
+	}
+
+	edit := s.current()
+	position := t.Muted.Render(fmt.Sprintf("%d of %d", s.index+1, len(s.edits)))
+	file := t.Base.Render(" " + fsext.PrettyPath(edit.Path))
+	stats := t.Muted.Render(fmt.Sprintf(" +%d -%d • %s", edit.Additions, edit.Removals, edit.Tool))
+	return lipgloss.JoinVertical(lipgloss.Left, title, "", position+file+stats)
+}
+
+// ShortHelp implements [help.KeyMap].
+func (s *StagedEdits) ShortHelp() []key.Binding {
+	bindings := []key.Binding{s.keyMap.Apply, s.keyMap.Discard, s.keyMap.Edit}
+	if len(s.edits) > 1 {
+		bindings = append(bindings, s.keyMap.Next, s.keyMap.ApplyAll)
+	}
+	return append(bindings, s.keyMap.Scroll, s.keyMap.ToggleDiffMode, s.keyMap.Close)
+}
+
+// FullHelp implements [help.KeyMap].
+func (s *StagedEdits) FullHelp() [][]key.Binding {
+	return [][]key.Binding{s.ShortHelp()}
+}
@@ -0,0 +1,135 @@
+package dialog
+
+import (
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+const (
+	// ThemesID is the identifier for the theme picker dialog.
+	ThemesID          = "themes"
+	themesDialogWidth = 48
+)
+
+// Themes lets the user pick the theme of the UI.
+type Themes struct {
+	com      *common.Common
+	help     help.Model
+	names    []string
+	current  string
+	selected int
+
+	keyMap struct {
+		Select   key.Binding
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*Themes)(nil)
+
+// NewThemes creates a new theme picker with current selected.
+func NewThemes(com *common.Common, current string) *Themes {
+	if current == "" {
+		current = styles.DefaultTheme
+	}
+	t := &Themes{com: com, names: com.Themes.Names(), current: current}
+	t.selected = max(0, slices.Index(t.names, current))
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	t.help = help
+
+	t.keyMap.Select = key.NewBinding(
+		key.WithKeys("enter", "ctrl+y"),
+		key.WithHelp("enter", "choose"),
+	)
+	t.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n", "j"),
+		key.WithHelp("↓", "next item"),
+	)
+	t.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p", "k"),
+		key.WithHelp("↑", "previous item"),
+	)
+	t.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑/↓", "choose"),
+	)
+	t.keyMap.Close = CloseKey
+	return t
+}
+
+// ID implements [Dialog].
+func (*Themes) ID() string {
+	return ThemesID
+}
+
+// HandleMsg implements [Dialog].
+func (t *Themes) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, t.keyMap.Close):
+			return ActionClose{}
+		case len(t.names) == 0:
+		case key.Matches(msg, t.keyMap.Next):
+			t.selected = (t.selected + 1) % len(t.names)
+		case key.Matches(msg, t.keyMap.Previous):
+			t.selected = (t.selected - 1 + len(t.names)) % len(t.names)
+		case key.Matches(msg, t.keyMap.Select):
+			return ActionSelectTheme{Name: t.names[t.selected]}
+		}
+	}
+	return nil
+}
+
+// Draw implements [Dialog].
+func (t *Themes) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	sty := t.com.Styles
+	width := max(0, min(themesDialogWidth, area.Dx()))
+	innerWidth := width - sty.Dialog.View.GetHorizontalFrameSize()
+	t.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(sty, width)
+	rc.Title = "Switch Theme"
+
+	var items strings.Builder
+	for i, name := range t.names {
+		style := sty.Dialog.NormalItem
+		if i == t.selected {
+			style = sty.Dialog.SelectedItem
+		}
+		if i > 0 {
+			items.WriteString("\n")
+		}
+		if name == t.current {
+			name += " " + styles.CheckIcon
+		}
+		items.WriteString(style.Width(innerWidth).Render(name))
+	}
+	rc.AddPart(items.String())
+	rc.Help = t.help.View(t)
+
+	DrawCenter(scr, area, rc.Render())
+	return nil
+}
+
+// ShortHelp implements [help.KeyMap].
+func (t *Themes) ShortHelp() []key.Binding {
+	return []key.Binding{t.keyMap.UpDown, t.keyMap.Select, t.keyMap.Close}
+}
+
+// FullHelp implements [help.KeyMap].
+func (t *Themes) FullHelp() [][]key.Binding {
+	return [][]key.Binding{t.ShortHelp()}
+}
@@ -0,0 +1,223 @@
+package dialog
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+const (
+	// TourID is the identifier for the tour dialog.
+	TourID          = "tour"
+	tourDialogWidth = 56
+)
+
+// TourTarget is the part of the interface a step of the tour is about.
+type TourTarget int
+
+const (
+	TourTargetNone TourTarget = iota
+	TourTargetMain
+	TourTargetEditor
+	TourTargetSidebar
+	TourTargetStatus
+)
+
+// tourSteps are the steps of the tour, in order.
+var tourSteps = []struct {
+	target TourTarget
+	title  string
+	body   string
+}{
+	{
+		TourTargetNone,
+		"Welcome to Crush",
+		"This short tour shows you around. Press → or enter to go on, ← to go back and esc to skip it. Take it again any time with /tour.",
+	},
+	{
+		TourTargetMain,
+		"Conversation",
+		"The conversation with the agent: your prompts, its replies and the tools it runs. Press tab to focus it, then ↑/↓ to select a message and a to act on the latest reply.",
+	},
+	{
+		TourTargetEditor,
+		"Editor",
+		"Write your prompts here and press enter to send them. ctrl+j adds a newline, @ mentions a file, ctrl+f attaches one and ctrl+o opens your external editor.",
+	},
+	{
+		TourTargetSidebar,
+		"Sidebar",
+		"The session and model in use, the files the agent changed and the state of the language servers and MCP servers. ctrl+d toggles it in compact mode.",
+	},
+	{
+		TourTargetNone,
+		"Permission Prompts",
+		"The agent asks before running commands or changing files. Press a to allow once, s to allow for the session, p to allow for the project or d to deny. t toggles the diff of the change.",
+	},
+	{
+		TourTargetEditor,
+		"Slash Commands",
+		"Type / in an empty editor, or press ctrl+p anywhere, for the commands: new sessions, models, reasoning, this tour and many more. Start typing to filter them.",
+	},
+	{
+		TourTargetStatus,
+		"Keybindings",
+		"The keys that work where you are are listed here. Press ctrl+g for all of them, ctrl+s for your sessions, ctrl+l for the models and ctrl+n for a new session.",
+	},
+}
+
+// Tour walks new users through the interface, highlighting each part of it
+// in turn.
+type Tour struct {
+	com     *common.Common
+	help    help.Model
+	step    int
+	targets map[TourTarget]uv.Rectangle
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		Skip     key.Binding
+	}
+}
+
+var _ Dialog = (*Tour)(nil)
+
+// NewTour creates a new tour dialog.
+func NewTour(com *common.Common) *Tour {
+	t := &Tour{com: com}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	t.help = help
+
+	t.keyMap.Next = key.NewBinding(
+		key.WithKeys("right", "enter", "l", "n", "space"),
+		key.WithHelp("→", "next"),
+	)
+	t.keyMap.Previous = key.NewBinding(
+		key.WithKeys("left", "h", "p"),
+		key.WithHelp("←", "back"),
+	)
+	t.keyMap.Skip = key.NewBinding(
+		key.WithKeys("esc", "alt+esc"),
+		key.WithHelp("esc", "skip"),
+	)
+	return t
+}
+
+// SetTargets sets where the parts of the interface the tour highlights are
+// on the screen. Parts that are not shown are left out.
+func (t *Tour) SetTargets(targets map[TourTarget]uv.Rectangle) {
+	t.targets = targets
+}
+
+// ID implements [Dialog].
+func (*Tour) ID() string {
+	return TourID
+}
+
+// HandleMsg implements [Dialog].
+func (t *Tour) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, t.keyMap.Skip):
+			return ActionTourDone{}
+		case key.Matches(msg, t.keyMap.Next):
+			if t.step == len(tourSteps)-1 {
+				return ActionTourDone{}
+			}
+			t.step++
+		case key.Matches(msg, t.keyMap.Previous):
+			t.step = max(0, t.step-1)
+		}
+	}
+	return nil
+}
+
+// Draw implements [Dialog].
+func (t *Tour) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	s := t.com.Styles
+	step := tourSteps[t.step]
+	width := max(0, min(tourDialogWidth, area.Dx()))
+	innerWidth := width - s.Dialog.View.GetHorizontalFrameSize()
+	t.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(s, width)
+	rc.Title = step.title
+	rc.AddPart(s.Base.Padding(0, 1).Width(innerWidth).Render(step.body))
+	rc.AddPart(s.Base.Padding(0, 1).Render(s.Muted.Render(fmt.Sprintf("%d of %d", t.step+1, len(tourSteps)))))
+	rc.Help = t.help.View(t)
+	view := rc.Render()
+
+	target, ok := t.targets[step.target]
+	if !ok || target.Empty() {
+		DrawCenter(scr, area, view)
+		return nil
+	}
+	drawTourHighlight(scr, target, s.Primary)
+	uv.NewStyledString(view).Draw(scr, tourCardRect(area, target, view))
+	return nil
+}
+
+// drawTourHighlight draws a frame around target, leaving what is inside it
+// visible.
+func drawTourHighlight(scr uv.Screen, target uv.Rectangle, c color.Color) {
+	w, h := target.Dx(), target.Dy()
+	if w < 2 || h < 2 {
+		return
+	}
+	style := lipgloss.NewStyle().Foreground(c)
+	border := lipgloss.RoundedBorder()
+	line := func(y int, s string) {
+		uv.NewStyledString(style.Render(s)).Draw(scr, uv.Rect(target.Min.X, y, w, 1))
+	}
+	line(target.Min.Y, border.TopLeft+strings.Repeat(border.Top, w-2)+border.TopRight)
+	line(target.Max.Y-1, border.BottomLeft+strings.Repeat(border.Bottom, w-2)+border.BottomRight)
+	side := style.Render(border.Left)
+	for y := target.Min.Y + 1; y < target.Max.Y-1; y++ {
+		uv.NewStyledString(side).Draw(scr, uv.Rect(target.Min.X, y, 1, 1))
+		uv.NewStyledString(side).Draw(scr, uv.Rect(target.Max.X-1, y, 1, 1))
+	}
+}
+
+// tourCardRect returns where to draw the card of a step about target: inside
+// it when it is large enough, otherwise above or below it.
+func tourCardRect(area, target uv.Rectangle, view string) uv.Rectangle {
+	width, height := lipgloss.Size(view)
+	if target.Dx() >= width+2 && target.Dy() >= height+2 {
+		return common.CenterRect(target, width, height)
+	}
+	x := target.Min.X + (target.Dx()-width)/2
+	x = max(area.Min.X, min(x, area.Max.X-width))
+	y := target.Min.Y - height
+	if y < area.Min.Y {
+		y = min(target.Max.Y, area.Max.Y-height)
+	}
+	return uv.Rect(x, max(area.Min.Y, y), width, height)
+}
+
+// ShortHelp implements [help.KeyMap].
+func (t *Tour) ShortHelp() []key.Binding {
+	next := t.keyMap.Next
+	if t.step == len(tourSteps)-1 {
+		next.SetHelp("→", "finish")
+	}
+	if t.step == 0 {
+		return []key.Binding{next, t.keyMap.Skip}
+	}
+	return []key.Binding{t.keyMap.Previous, next, t.keyMap.Skip}
+}
+
+// FullHelp implements [help.KeyMap].
+func (t *Tour) FullHelp() [][]key.Binding {
+	return [][]key.Binding{t.ShortHelp()}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/lexers"
 	"github.com/aymanbagabas/go-udiff"
+	"github.com/charmbracelet/crush/internal/diff"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/zeebo/xxh3"
 )
@@ -51,6 +52,7 @@ type DiffView struct {
 	err        error
 	unified    udiff.UnifiedDiff
 	edits      []udiff.Edit
+	moves      diff.Moves
 
 	splitHunks []splitHunk
 
@@ -248,7 +250,7 @@ func (dv *DiffView) computeDiff() error {
 		return dv.err
 	}
 	dv.isComputed = true
-	dv.edits = udiff.Strings(
+	dv.edits = diff.Edits(
 		dv.before.content,
 		dv.after.content,
 	)
@@ -259,6 +261,7 @@ func (dv *DiffView) computeDiff() error {
 		dv.edits,
 		dv.contextLines,
 	)
+	dv.moves = diff.DetectMoves(dv.unified)
 	return dv.err
 }
 
@@ -472,7 +475,7 @@ outer:
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
 					}
 					b.WriteString(fullContentStyle.Render(
-						ls.Symbol.Render(ternary(leadingEllipsis, "+…", "+ ")) +
+						ls.Symbol.Render(dv.insertSymbol(afterLine, leadingEllipsis)) +
 							ls.Code.Width(dv.codeWidth).Render(content),
 					))
 				}
@@ -486,7 +489,7 @@ outer:
 						b.WriteString(ls.LineNumber.Render(pad(" ", dv.afterNumDigits)))
 					}
 					b.WriteString(fullContentStyle.Render(
-						ls.Symbol.Render(ternary(leadingEllipsis, "-…", "- ")) +
+						ls.Symbol.Render(dv.deleteSymbol(beforeLine, leadingEllipsis)) +
 							ls.Code.Width(dv.codeWidth).Render(content),
 					))
 				}
@@ -604,7 +607,7 @@ outer:
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 					}
 					b.WriteString(beforeFullContentStyle.Render(
-						ls.Symbol.Render(ternary(leadingEllipsis, "-…", "- ")) +
+						ls.Symbol.Render(dv.deleteSymbol(beforeLine, leadingEllipsis)) +
 							ls.Code.Width(dv.codeWidth).Render(content),
 					))
 				}
@@ -642,7 +645,7 @@ outer:
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
 					}
 					b.WriteString(afterFullContentStyle.Render(
-						ls.Symbol.Render(ternary(leadingEllipsis, "+…", "+ ")) +
+						ls.Symbol.Render(dv.insertSymbol(afterLine, leadingEllipsis)) +
 							ls.Code.Width(dv.codeWidth+btoi(dv.extraColOnAfter)).Render(content),
 					))
 				}
@@ -690,6 +693,24 @@ func (dv *DiffView) hunkShownLines(h *udiff.Hunk) (before, after int) {
 	return before, after
 }
 
+// deleteSymbol returns the symbol of the deleted line of the "before" file,
+// which is "<" rather than "-" when the line moved elsewhere.
+func (dv *DiffView) deleteSymbol(line int, leadingEllipsis bool) string {
+	if _, ok := dv.moves.From(line); ok {
+		return ternary(leadingEllipsis, "<…", "< ")
+	}
+	return ternary(leadingEllipsis, "-…", "- ")
+}
+
+// insertSymbol returns the symbol of the inserted line of the "after" file,
+// which is ">" rather than "+" when the line moved from elsewhere.
+func (dv *DiffView) insertSymbol(line int, leadingEllipsis bool) string {
+	if _, ok := dv.moves.To(line); ok {
+		return ternary(leadingEllipsis, ">…", "> ")
+	}
+	return ternary(leadingEllipsis, "+…", "+ ")
+}
+
 func (dv *DiffView) lineStyleForType(t udiff.OpKind) LineStyle {
 	switch t {
 	case udiff.Equal:
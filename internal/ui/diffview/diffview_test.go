@@ -3,6 +3,7 @@ package diffview_test
 import (
 	_ "embed"
 	"fmt"
+	"slices"
 	"strings"
 	"testing"
 
@@ -347,3 +348,52 @@ func assertHeight(t *testing.T, expected int, output string) {
 		t.Errorf("expected output height to be == %d, got %d", expected, lines)
 	}
 }
+
+func TestRows(t *testing.T) {
+	t.Parallel()
+
+	dv := diffview.New().
+		Before("main.go", "a\nb\nc\n").
+		After("main.go", "a\nB\nc\n")
+	rows := dv.Rows()
+	expected := []diffview.Row{
+		{Kind: diffview.RowHunk},
+		{Kind: diffview.RowEqual, Before: 1, After: 1},
+		{Kind: diffview.RowDelete, Before: 2},
+		{Kind: diffview.RowInsert, After: 2},
+		{Kind: diffview.RowEqual, Before: 3, After: 3},
+	}
+	if !slices.Equal(rows, expected) {
+		t.Errorf("expected rows %v, got %v", expected, rows)
+	}
+	// Each row is a rendered line.
+	if lines := strings.Count(dv.String(), "\n") + 1; lines != len(rows) {
+		t.Errorf("expected %d rendered lines, got %d", len(rows), lines)
+	}
+}
+
+func TestMovedLines(t *testing.T) {
+	t.Parallel()
+
+	block := "func helper() {\n\tdoThings()\n\treturn\n}\n"
+	main := "func main() {\n\tsetup()\n\thelper()\n\tteardown()\n}\n"
+	for layoutName, layoutFunc := range LayoutFuncs {
+		t.Run(layoutName, func(t *testing.T) {
+			t.Parallel()
+
+			dv := diffview.New().
+				Before("main.go", block+"\n"+main).
+				After("main.go", main+"\n"+block).
+				LineNumbers(false)
+			output := ansi.Strip(layoutFunc(dv).String())
+			for _, want := range []string{"< func helper() {", "> func helper() {"} {
+				if !strings.Contains(output, want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, output)
+				}
+			}
+			if strings.Contains(output, "+ func helper() {") {
+				t.Errorf("expected moved lines not to be marked as inserted, got:\n%s", output)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package diffview
+
+import "github.com/aymanbagabas/go-udiff"
+
+// RowKind is the kind of a row of the unified layout.
+type RowKind int
+
+const (
+	RowHunk RowKind = iota
+	RowEqual
+	RowInsert
+	RowDelete
+)
+
+// Row is a row of the unified layout, in the order [DiffView.String] renders
+// them when no height is set.
+type Row struct {
+	Kind RowKind
+	// Before and After are the 1-based line numbers of the row in each
+	// version, or zero when the row is not in that version.
+	Before int
+	After  int
+}
+
+// Rows returns the rows of the unified layout, so callers can tell which
+// line of the files each rendered row shows.
+func (dv *DiffView) Rows() []Row {
+	dv.normalizeLineEndings()
+	dv.replaceTabs()
+	if err := dv.computeDiff(); err != nil {
+		return nil
+	}
+	var rows []Row
+	for _, h := range dv.unified.Hunks {
+		rows = append(rows, Row{Kind: RowHunk})
+		before, after := h.FromLine, h.ToLine
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case udiff.Equal:
+				rows = append(rows, Row{Kind: RowEqual, Before: before, After: after})
+				before++
+				after++
+			case udiff.Insert:
+				rows = append(rows, Row{Kind: RowInsert, After: after})
+				after++
+			case udiff.Delete:
+				rows = append(rows, Row{Kind: RowDelete, Before: before})
+				before++
+			}
+		}
+	}
+	return rows
+}
@@ -203,40 +203,7 @@ func (e Encoding) Render(id string, cols, rows int) string {
 
 	switch e {
 	case EncodingBlocks:
-		canvas := paintbrush.New()
-		canvas.SetImage(img)
-		canvas.SetWidth(cols)
-		canvas.SetHeight(rows)
-		canvas.Weights = map[rune]float64{
-			'': .95,
-			'': .95,
-			'▁': .9,
-			'▂': .9,
-			'▃': .9,
-			'▄': .9,
-			'▅': .9,
-			'▆': .85,
-			'█': .85,
-			'▊': .95,
-			'▋': .95,
-			'▌': .95,
-			'▍': .95,
-			'▎': .95,
-			'▏': .95,
-			'●': .95,
-			'◀': .95,
-			'▲': .95,
-			'▶': .95,
-			'▼': .9,
-			'○': .8,
-			'◉': .95,
-			'◧': .9,
-			'◨': .9,
-			'◩': .9,
-			'◪': .9,
-		}
-		canvas.Paint()
-		return strings.TrimSpace(canvas.GetResult())
+		return paintBlocks(img, cols, rows)
 	case EncodingKitty:
 		// Build Kitty graphics unicode place holders
 		var fg color.Color
@@ -286,3 +253,41 @@ func (e Encoding) Render(id string, cols, rows int) string {
 		return ""
 	}
 }
+
+// paintBlocks draws img with block characters over cols by rows cells.
+func paintBlocks(img image.Image, cols, rows int) string {
+	canvas := paintbrush.New()
+	canvas.SetImage(img)
+	canvas.SetWidth(cols)
+	canvas.SetHeight(rows)
+	canvas.Weights = map[rune]float64{
+		'': .95,
+		'': .95,
+		'▁': .9,
+		'▂': .9,
+		'▃': .9,
+		'▄': .9,
+		'▅': .9,
+		'▆': .85,
+		'█': .85,
+		'▊': .95,
+		'▋': .95,
+		'▌': .95,
+		'▍': .95,
+		'▎': .95,
+		'▏': .95,
+		'●': .95,
+		'◀': .95,
+		'▲': .95,
+		'▶': .95,
+		'▼': .9,
+		'○': .8,
+		'◉': .95,
+		'◧': .9,
+		'◨': .9,
+		'◩': .9,
+		'◪': .9,
+	}
+	canvas.Paint()
+	return strings.TrimSpace(canvas.GetResult())
+}
@@ -0,0 +1,95 @@
+package image
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/ansi/iterm2"
+	"github.com/charmbracelet/x/ansi/kitty"
+	"github.com/charmbracelet/x/ansi/sixel"
+	"github.com/disintegration/imaging"
+)
+
+// Protocol is a way for a terminal to draw an image where the cursor is,
+// unlike [Encoding], which renders an image transmitted beforehand.
+type Protocol byte
+
+// Image protocols.
+const (
+	ProtocolBlocks Protocol = iota
+	ProtocolKitty
+	ProtocolITerm2
+	ProtocolSixel
+)
+
+// defaultCellSize is assumed when the terminal does not report the size of
+// its cells.
+var defaultCellSize = CellSize{Width: 8, Height: 16}
+
+// Draw writes img to w with protocol p, fit within cols by rows cells. The
+// image is never scaled up.
+func (p Protocol) Draw(w io.Writer, img image.Image, cs CellSize, cols, rows int, tmux bool) error {
+	if cs.Width == 0 || cs.Height == 0 {
+		cs = defaultCellSize
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() > cols*cs.Width || bounds.Dy() > rows*cs.Height {
+		img = imaging.Fit(img, cols*cs.Width, rows*cs.Height, imaging.Lanczos)
+		bounds = img.Bounds()
+	}
+
+	switch p {
+	case ProtocolKitty:
+		return kitty.EncodeGraphics(w, img, &kitty.Options{
+			Action:       kitty.TransmitAndPut,
+			Transmission: kitty.Direct,
+			Format:       kitty.RGBA,
+			ImageWidth:   bounds.Dx(),
+			ImageHeight:  bounds.Dy(),
+			Quite:        2,
+			Chunk:        true,
+			ChunkFormatter: func(chunk string) string {
+				if tmux {
+					return ansi.TmuxPassthrough(chunk)
+				}
+				return chunk
+			},
+		})
+	case ProtocolITerm2:
+		var data bytes.Buffer
+		if err := png.Encode(&data, img); err != nil {
+			return fmt.Errorf("failed to encode image: %w", err)
+		}
+		seq := ansi.ITerm2(iterm2.File{
+			Name:    "diagram.png",
+			Size:    int64(data.Len()),
+			Width:   iterm2.Pixels(bounds.Dx()),
+			Height:  iterm2.Pixels(bounds.Dy()),
+			Inline:  true,
+			Content: []byte(base64.StdEncoding.EncodeToString(data.Bytes())),
+		})
+		if tmux {
+			seq = ansi.TmuxPassthrough(seq)
+		}
+		_, err := io.WriteString(w, seq)
+		return err
+	case ProtocolSixel:
+		var payload bytes.Buffer
+		if err := new(sixel.Encoder).Encode(&payload, img); err != nil {
+			return fmt.Errorf("failed to encode image: %w", err)
+		}
+		_, err := io.WriteString(w, ansi.SixelGraphics(0, 1, 0, payload.Bytes()))
+		return err
+	default:
+		// Blocks are about twice as high as they are wide.
+		blockCols := min(cols, (bounds.Dx()+cs.Width-1)/cs.Width)
+		blockRows := max(1, min(rows, blockCols*bounds.Dy()/bounds.Dx()/2))
+		_, err := io.WriteString(w, paintBlocks(img, blockCols, blockRows)+"\n")
+		return err
+	}
+}
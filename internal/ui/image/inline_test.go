@@ -0,0 +1,53 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtocolDraw(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	for x := range 64 {
+		img.Set(x, 10, color.Black)
+	}
+
+	for name, tc := range map[string]struct {
+		protocol Protocol
+		prefix   string
+	}{
+		"kitty":  {ProtocolKitty, "\x1b_G"},
+		"iterm2": {ProtocolITerm2, "\x1b]1337;File="},
+		"sixel":  {ProtocolSixel, "\x1bP0;1q"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			require.NoError(t, tc.protocol.Draw(&buf, img, CellSize{Width: 8, Height: 16}, 80, 24, false))
+			require.True(t, bytes.HasPrefix(buf.Bytes(), []byte(tc.prefix)), "got %q", buf.String()[:min(buf.Len(), 20)])
+		})
+	}
+
+	t.Run("blocks", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		require.NoError(t, ProtocolBlocks.Draw(&buf, img, CellSize{}, 80, 24, false))
+		require.NotEmpty(t, bytes.TrimSpace(buf.Bytes()))
+	})
+}
+
+func TestProtocolDrawFits(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	img := image.NewRGBA(image.Rect(0, 0, 1000, 100))
+	require.NoError(t, ProtocolITerm2.Draw(&buf, img, CellSize{Width: 10, Height: 20}, 10, 10, false))
+	require.Contains(t, buf.String(), "width=100px;height=10px")
+}
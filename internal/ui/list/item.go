@@ -21,6 +21,13 @@ type RawRenderable interface {
 	RawRender(width int) string
 }
 
+// Evictable represents an item that caches its render and can drop the cache
+// while it is far out of view.
+type Evictable interface {
+	// Evict drops the cached render of the item.
+	Evict()
+}
+
 // Focusable represents an item that can be aware of focus state changes.
 type Focusable interface {
 	// SetFocused sets the focus state of the item.
@@ -4,6 +4,11 @@ import (
 	"strings"
 )
 
+// evictMargin is how many items on either side of the viewport keep their
+// cached renders; those further away drop them, so that long lists hold
+// only the renders around the view in memory.
+const evictMargin = 50
+
 // List represents a list of items that can be lazily rendered. A list is
 // always rendered like a chat conversation where items are stacked vertically
 // from top to bottom.
@@ -33,6 +38,10 @@ type List struct {
 
 	// renderCallbacks is a list of callbacks to apply when rendering items.
 	renderCallbacks []func(idx, selectedIdx int, item Item) Item
+
+	// keptFrom and keptTo are the items, around the viewport of the last
+	// render, that kept their cached renders.
+	keptFrom, keptTo int
 }
 
 // renderedItem holds the rendered content and height of an item.
@@ -320,6 +329,7 @@ func (l *List) Render() string {
 	}
 
 	l.height = max(l.height, 0)
+	l.evict(l.offsetIdx-evictMargin, currentIdx+evictMargin)
 
 	if len(lines) > l.height {
 		lines = lines[:l.height]
@@ -335,6 +345,29 @@ func (l *List) Render() string {
 	return strings.Join(lines, "\n")
 }
 
+// evict drops the cached renders of the [Evictable] items outside from and
+// to, when those changed since the last render.
+func (l *List) evict(from, to int) {
+	from, to = max(from, 0), min(to, len(l.items))
+	if from == l.keptFrom && to == l.keptTo {
+		return
+	}
+	l.keptFrom, l.keptTo = from, to
+	for idx, item := range l.items {
+		if idx >= from && idx < to {
+			continue
+		}
+		if e, ok := item.(Evictable); ok {
+			e.Evict()
+		}
+	}
+}
+
+// AtTop returns whether the list is showing the first item at the top.
+func (l *List) AtTop() bool {
+	return l.offsetIdx == 0 && l.offsetLine == 0
+}
+
 // PrependItems prepends items to the list.
 func (l *List) PrependItems(items ...Item) {
 	l.items = append(items, l.items...)
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"path/filepath"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/ui/dialog"
+	"github.com/charmbracelet/crush/internal/ui/util"
+)
+
+// openAnnotateDialog opens the dialog for commenting on the lines the
+// session changed.
+func (m *UI) openAnnotateDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.AnnotateID) {
+		m.dialog.BringToFront(dialog.AnnotateID)
+		return nil
+	}
+	cwd := m.com.Config().WorkingDir()
+	var files []dialog.AnnotateFile
+	for _, f := range m.sessionFiles {
+		if f.Additions == 0 && f.Deletions == 0 {
+			continue
+		}
+		path := f.LatestVersion.Path
+		if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = filepath.ToSlash(rel)
+		}
+		files = append(files, dialog.AnnotateFile{
+			Path:   path,
+			Before: f.FirstVersion.Content,
+			After:  f.LatestVersion.Content,
+		})
+	}
+	if len(files) == 0 {
+		return util.ReportWarn("No files changed in this session")
+	}
+	m.dialog.OpenDialog(dialog.NewAnnotate(m.com, files, m.annotations))
+	return nil
+}
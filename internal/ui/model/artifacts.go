@@ -0,0 +1,70 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/artifact"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/ui/dialog"
+	"github.com/charmbracelet/crush/internal/ui/util"
+	"github.com/pkg/browser"
+)
+
+// openArtifactsDialog opens the dialog listing the artifacts of the
+// session.
+func (m *UI) openArtifactsDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.ArtifactsID) {
+		m.dialog.BringToFront(dialog.ArtifactsID)
+		return nil
+	}
+	if !m.hasSession() {
+		return util.ReportWarn("Start a session to see its artifacts")
+	}
+	artifacts, err := m.com.App.Artifacts(m.session.ID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	m.dialog.OpenDialog(dialog.NewArtifacts(m.com, artifacts))
+	return nil
+}
+
+// handleArtifactEvent keeps the artifacts dialog current as the agent
+// emits artifacts.
+func (m *UI) handleArtifactEvent(event pubsub.Event[artifact.Artifact]) tea.Cmd {
+	d, ok := m.dialog.Dialog(dialog.ArtifactsID).(*dialog.Artifacts)
+	if !ok || !m.hasSession() || event.Payload.SessionID != m.session.ID {
+		return nil
+	}
+	artifacts, err := m.com.App.Artifacts(m.session.ID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	d.SetArtifacts(artifacts)
+	return nil
+}
+
+// openArtifact opens an artifact with the default application for its
+// type.
+func (m *UI) openArtifact(a artifact.Artifact) tea.Cmd {
+	return func() tea.Msg {
+		if err := browser.OpenFile(a.Path); err != nil {
+			return util.NewErrorMsg(fmt.Errorf("failed to open %s: %w", a.Name, err))
+		}
+		return nil
+	}
+}
+
+// exportArtifact copies an artifact to the workspace.
+func (m *UI) exportArtifact(a artifact.Artifact) tea.Cmd {
+	dest, err := m.com.App.ExportArtifact(a)
+	if errors.Is(err, artifact.ErrExists) {
+		return util.ReportWarn(fmt.Sprintf("%s already exists in the workspace", a.Name))
+	}
+	if err != nil {
+		return util.ReportError(err)
+	}
+	return util.ReportInfo(fmt.Sprintf("Exported %s", fsext.PrettyPath(dest)))
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"context"
+	"image"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// autocompleteDelay is how long typing pauses before a completion of the
+	// draft is asked for.
+	autocompleteDelay = 600 * time.Millisecond
+	// autocompleteMinLength is how long the draft is before it is completed.
+	autocompleteMinLength = 12
+	// autocompleteTimeout bounds the request for a completion.
+	autocompleteTimeout = 10 * time.Second
+)
+
+// autocompleteTickMsg asks for a completion of the draft, unless the draft
+// changed since it was scheduled.
+type autocompleteTickMsg struct {
+	seq int
+}
+
+// autocompleteMsg is the completion suggested for draft.
+type autocompleteMsg struct {
+	draft      string
+	completion string
+}
+
+// autocompleteEnabled reports whether drafts are completed.
+func (m *UI) autocompleteEnabled() bool {
+	autocomplete := m.com.Config().Options.TUI.Autocomplete
+	return autocomplete != nil && *autocomplete &&
+		m.com.App != nil && m.com.App.AgentCoordinator != nil
+}
+
+// scheduleAutocomplete drops the suggestion of the previous draft and asks
+// for one for the current draft once typing pauses.
+func (m *UI) scheduleAutocomplete() tea.Cmd {
+	m.suggestion = ""
+	m.autocompleteSeq++
+	if !m.autocompleteEnabled() || m.completionsOpen || !m.cursorAtEnd() ||
+		len(strings.TrimSpace(m.textarea.Value())) < autocompleteMinLength {
+		return nil
+	}
+	seq := m.autocompleteSeq
+	return tea.Tick(autocompleteDelay, func(time.Time) tea.Msg {
+		return autocompleteTickMsg{seq: seq}
+	})
+}
+
+// requestAutocomplete asks the small model how the draft goes on.
+func (m *UI) requestAutocomplete(msg autocompleteTickMsg) tea.Cmd {
+	if msg.seq != m.autocompleteSeq || !m.cursorAtEnd() {
+		return nil
+	}
+	draft := m.textarea.Value()
+	var sessionID string
+	if m.hasSession() {
+		sessionID = m.session.ID
+	}
+	coordinator := m.com.App.AgentCoordinator
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), autocompleteTimeout)
+		defer cancel()
+		completion, err := coordinator.CompletePrompt(ctx, sessionID, draft)
+		if err != nil {
+			slog.Debug("Failed to complete prompt", "error", err)
+			return nil
+		}
+		return autocompleteMsg{draft: draft, completion: completion}
+	}
+}
+
+// setSuggestion keeps the completion when the draft is still the one it
+// completes.
+func (m *UI) setSuggestion(msg autocompleteMsg) {
+	if msg.draft == m.textarea.Value() {
+		m.suggestion = msg.completion
+		m.suggestionFor = msg.draft
+	}
+}
+
+// currentSuggestion returns the suggestion for the draft, if any.
+func (m *UI) currentSuggestion() string {
+	if m.suggestion == "" || m.suggestionFor != m.textarea.Value() || !m.cursorAtEnd() {
+		return ""
+	}
+	return m.suggestion
+}
+
+// acceptSuggestion adds the suggestion to the draft. It reports whether
+// there was one.
+func (m *UI) acceptSuggestion() bool {
+	suggestion := m.currentSuggestion()
+	if suggestion == "" {
+		return false
+	}
+	m.textarea.InsertString(suggestion)
+	m.suggestion = ""
+	return true
+}
+
+// cursorAtEnd reports whether the cursor of the editor is after the last
+// character of the draft.
+func (m *UI) cursorAtEnd() bool {
+	if m.textarea.Line() != m.textarea.LineCount()-1 {
+		return false
+	}
+	value := m.textarea.Value()
+	lastLine := value[strings.LastIndex(value, "\n")+1:]
+	return m.textarea.Column() == utf8.RuneCountInString(lastLine)
+}
+
+// drawSuggestion draws the suggestion for the draft as ghost text after the
+// cursor of the editor.
+func (m *UI) drawSuggestion(scr uv.Screen) {
+	suggestion := m.currentSuggestion()
+	if suggestion == "" || !m.textarea.Focused() || m.layout.editor.Dy() <= 0 {
+		return
+	}
+	cur := m.textarea.Cursor()
+	if cur == nil {
+		return
+	}
+	x := cur.X + 1                         // Adjust for app margins
+	y := cur.Y + m.layout.editor.Min.Y + 1 // Offset for attachments row
+	width := m.layout.editor.Max.X - x - 1
+	if width <= 0 {
+		return
+	}
+	ghost := m.com.Styles.Subtle.Render(ansi.Truncate(suggestion, width, "…"))
+	uv.NewStyledString(ghost).Draw(scr, image.Rect(x, y, x+width, y+1))
+}
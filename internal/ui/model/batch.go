@@ -0,0 +1,36 @@
+package model
+
+import (
+	"path/filepath"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	agenttools "github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/batch"
+	"github.com/charmbracelet/crush/internal/ui/dialog"
+	"github.com/charmbracelet/crush/internal/ui/util"
+)
+
+// openBatchDialog opens the dialog for giving one instruction to apply at
+// the matches of the selected search.
+func (m *UI) openBatchDialog() tea.Cmd {
+	call, result, ok := m.chat.SelectedToolResult()
+	if !ok || call.Name != agenttools.GrepToolName || result.IsError {
+		return util.ReportWarn("Select a search result to instruct its matches")
+	}
+	sites := batch.ParseGrep(result.Content)
+	if len(sites) == 0 {
+		return util.ReportWarn("The search found no matches")
+	}
+	cwd := m.com.Config().WorkingDir()
+	for i, site := range sites {
+		if !filepath.IsAbs(site.Path) {
+			continue
+		}
+		if rel, err := filepath.Rel(cwd, site.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			sites[i].Path = filepath.ToSlash(rel)
+		}
+	}
+	m.dialog.OpenDialog(dialog.NewBatch(m.com, sites))
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/ui/anim"
 	"github.com/charmbracelet/crush/internal/ui/chat"
 	"github.com/charmbracelet/crush/internal/ui/common"
@@ -144,6 +145,28 @@ func (m *Chat) AppendMessages(msgs ...chat.MessageItem) {
 	m.list.AppendItems(items...)
 }
 
+// PrependMessages adds message items before those in the chat list, keeping
+// the view where it is.
+func (m *Chat) PrependMessages(msgs ...chat.MessageItem) {
+	for id, idx := range m.idInxMap {
+		m.idInxMap[id] = idx + len(msgs)
+	}
+	items := make([]list.Item, len(msgs))
+	for i, msg := range msgs {
+		m.idInxMap[msg.ID()] = i
+		// Register nested tool IDs for tools that contain nested tools.
+		if container, ok := msg.(chat.NestedToolContainer); ok {
+			for _, nested := range container.NestedTools() {
+				m.idInxMap[nested.ID()] = i
+			}
+		}
+		items[i] = msg
+	}
+	m.list.PrependItems(items...)
+	// Item indices shifted under any selection in progress.
+	m.ClearMouse()
+}
+
 // UpdateNestedToolIDs updates the ID map for nested tools within a container.
 // Call this after modifying nested tools to ensure animations work correctly.
 func (m *Chat) UpdateNestedToolIDs(containerID string) {
@@ -248,6 +271,11 @@ func (m *Chat) AtBottom() bool {
 	return m.list.AtBottom()
 }
 
+// AtTop returns whether the chat list is currently scrolled to the top.
+func (m *Chat) AtTop() bool {
+	return m.list.AtTop()
+}
+
 // Follow returns whether the chat view is in follow mode (auto-scroll to
 // bottom on new messages).
 func (m *Chat) Follow() bool {
@@ -317,6 +345,48 @@ func (m *Chat) SelectedItemInView() bool {
 	return m.list.SelectedItemInView()
 }
 
+// SelectedUserMessage returns the selected message when it is one the user
+// wrote.
+func (m *Chat) SelectedUserMessage() (*message.Message, bool) {
+	item, ok := m.list.SelectedItem().(*chat.UserMessageItem)
+	if !ok {
+		return nil, false
+	}
+	return item.Message(), true
+}
+
+// SelectedAssistantText returns the text of the selected assistant message.
+func (m *Chat) SelectedAssistantText() (string, bool) {
+	item, ok := m.list.SelectedItem().(*chat.AssistantMessageItem)
+	if !ok {
+		return "", false
+	}
+	return item.Text(), true
+}
+
+// SelectedToolResult returns the selected tool call with its result, once
+// it has one.
+func (m *Chat) SelectedToolResult() (message.ToolCall, *message.ToolResult, bool) {
+	item, ok := m.list.SelectedItem().(interface {
+		chat.ToolMessageItem
+		Result() *message.ToolResult
+	})
+	if !ok || item.Result() == nil {
+		return message.ToolCall{}, nil, false
+	}
+	return item.ToolCall(), item.Result(), true
+}
+
+// SelectedCopyParts returns the parts of the selected item that can be
+// copied one by one.
+func (m *Chat) SelectedCopyParts() ([]chat.CopyPart, bool) {
+	item, ok := m.list.SelectedItem().(chat.Copyable)
+	if !ok {
+		return nil, false
+	}
+	return item.CopyParts(), true
+}
+
 func (m *Chat) isSelectable(index int) bool {
 	item := m.list.ItemAt(index)
 	if item == nil {
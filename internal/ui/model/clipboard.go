@@ -1,6 +1,18 @@
 package model
 
-import "errors"
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/ui/common"
+)
 
 type clipboardFormat int
 
@@ -12,4 +24,154 @@ const (
 var (
 	errClipboardPlatformUnsupported = errors.New("clipboard operations are not supported on this platform")
 	errClipboardUnknownFormat       = errors.New("unknown clipboard format")
+	errClipboardNoImage             = errors.New("clipboard does not contain an image")
 )
+
+// clipboardImageCommands are external tools tried, in order, when the native
+// clipboard has no image data. They cover Wayland and X11 sessions where the
+// native clipboard is unavailable, and macOS with pngpaste installed.
+var clipboardImageCommands = [][]string{
+	{"wl-paste", "--no-newline", "--type", "image/png"},
+	{"xclip", "-selection", "clipboard", "-target", "image/png", "-out"},
+	{"pngpaste", "-"},
+}
+
+// readClipboardImage reads image data from the system clipboard, falling back
+// to external clipboard tools when the native clipboard fails.
+func readClipboardImage() ([]byte, error) {
+	data, err := readClipboard(clipboardFormatImage)
+	if err == nil && isImageData(data) {
+		return data, nil
+	}
+	if runtime.GOOS == "windows" {
+		return nil, errClipboardNoImage
+	}
+	for _, args := range clipboardImageCommands {
+		if _, lookErr := exec.LookPath(args[0]); lookErr != nil {
+			continue
+		}
+		out, cmdErr := exec.Command(args[0], args[1:]...).Output()
+		if cmdErr == nil && isImageData(out) {
+			return out, nil
+		}
+	}
+	if err != nil && !errors.Is(err, errClipboardPlatformUnsupported) {
+		return nil, err
+	}
+	return nil, errClipboardNoImage
+}
+
+func isImageData(data []byte) bool {
+	return len(data) > 0 && strings.HasPrefix(mimeOf(data), "image/")
+}
+
+// pasteDir is the temporary directory pasted images are saved in, so their
+// attachments have a real path on disk. It is created on the first paste
+// and removed with everything in it on shutdown.
+type pasteDir struct {
+	mu   sync.Mutex
+	path string
+}
+
+// save writes pasted image data to a file named name and returns its path.
+func (d *pasteDir) save(name string, data []byte) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.path == "" {
+		dir, err := os.MkdirTemp("", "crush-paste-*")
+		if err != nil {
+			return "", fmt.Errorf("failed to create paste directory: %w", err)
+		}
+		d.path = dir
+	}
+	path := filepath.Join(d.path, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		_ = os.Remove(path)
+		return "", fmt.Errorf("failed to save pasted image: %w", err)
+	}
+	return path, nil
+}
+
+// remove deletes the directory and the images pasted into it.
+func (d *pasteDir) remove() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.path == "" {
+		return nil
+	}
+	err := os.RemoveAll(d.path)
+	d.path = ""
+	return err
+}
+
+// isOSC52Image reports whether OSC 52 clipboard content holds image data.
+// Some terminals answer OSC 52 reads with the raw clipboard bytes when it
+// holds an image.
+func isOSC52Image(content string) bool {
+	return isImageData([]byte(content))
+}
+
+// kittyClipboardRequest is the OSC 5522 sequence of the kitty clipboard
+// protocol that reads a PNG image from the clipboard of the terminal, which
+// is the user's even over SSH.
+var kittyClipboardRequest = "\x1b]5522;type=read;" + base64.StdEncoding.EncodeToString([]byte("image/png")) + "\x1b\\"
+
+// kittyClipboardReader collects the image of a kitty clipboard read, which
+// the terminal answers with an OK status, base64 DATA chunks and DONE, or
+// with an error status such as EPERM when the user refused it.
+type kittyClipboardReader struct {
+	pending string
+	data    []byte
+}
+
+// feed handles an OSC sequence from the terminal. It reports whether it was
+// a response to the read and whether the read is over, with a non-nil error
+// when it failed.
+func (r *kittyClipboardReader) feed(seq string) (handled, done bool, err error) {
+	meta, payload, ok := parseKittyClipboard(seq)
+	if !ok || meta["type"] != "read" {
+		return false, false, nil
+	}
+	switch status := meta["status"]; status {
+	case "OK":
+		return true, false, nil
+	case "DATA":
+		// Chunks may split the base64 text anywhere, so only whole quads
+		// are decoded until the rest arrives.
+		r.pending += payload
+		n := len(r.pending) / 4 * 4
+		decoded, err := base64.StdEncoding.DecodeString(r.pending[:n])
+		if err != nil {
+			return true, true, fmt.Errorf("invalid clipboard data: %w", err)
+		}
+		r.pending = r.pending[n:]
+		if int64(len(r.data)) <= common.MaxAttachmentSize {
+			r.data = append(r.data, decoded...)
+		}
+		return true, false, nil
+	case "DONE":
+		return true, true, nil
+	default:
+		return true, true, fmt.Errorf("terminal refused to read the clipboard: %s", status)
+	}
+}
+
+// parseKittyClipboard splits an OSC 5522 sequence into its metadata and its
+// payload. It reports false for any other sequence.
+func parseKittyClipboard(seq string) (map[string]string, string, bool) {
+	seq = strings.TrimPrefix(strings.TrimPrefix(seq, "\x1b]"), "\x9d")
+	for _, st := range []string{"\x1b\\", "\x07", "\x9c"} {
+		seq = strings.TrimSuffix(seq, st)
+	}
+	rest, ok := strings.CutPrefix(seq, "5522;")
+	if !ok {
+		return nil, "", false
+	}
+	metadata, payload, _ := strings.Cut(rest, ";")
+	meta := make(map[string]string)
+	for field := range strings.SplitSeq(metadata, ":") {
+		key, value, _ := strings.Cut(field, "=")
+		meta[key] = value
+	}
+	return meta, payload, true
+}
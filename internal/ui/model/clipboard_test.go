@@ -0,0 +1,78 @@
+package model
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func kittyResponse(meta, payload string) string {
+	seq := "\x1b]5522;" + meta
+	if payload != "" {
+		seq += ";" + payload
+	}
+	return seq + "\x1b\\"
+}
+
+func TestKittyClipboardReader(t *testing.T) {
+	t.Parallel()
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	encoded := base64.StdEncoding.EncodeToString(png)
+	mime := base64.StdEncoding.EncodeToString([]byte("image/png"))
+
+	t.Run("chunks", func(t *testing.T) {
+		t.Parallel()
+		var r kittyClipboardReader
+		handled, done, err := r.feed("\x1b]11;rgb:0000/0000/0000\x1b\\")
+		require.False(t, handled)
+		require.False(t, done)
+		require.NoError(t, err)
+
+		for _, seq := range []string{
+			kittyResponse("type=read:status=OK", ""),
+			// The split falls inside a quad of the base64 text.
+			kittyResponse("type=read:status=DATA:mime="+mime, encoded[:5]),
+			kittyResponse("type=read:status=DATA:mime="+mime, encoded[5:]),
+		} {
+			handled, done, err = r.feed(seq)
+			require.True(t, handled)
+			require.False(t, done)
+			require.NoError(t, err)
+		}
+		handled, done, err = r.feed(kittyResponse("type=read:status=DONE", ""))
+		require.True(t, handled)
+		require.True(t, done)
+		require.NoError(t, err)
+		require.Equal(t, png, r.data)
+		require.True(t, isImageData(r.data))
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		t.Parallel()
+		var r kittyClipboardReader
+		handled, done, err := r.feed(kittyResponse("type=read:status=EPERM", ""))
+		require.True(t, handled)
+		require.True(t, done)
+		require.EqualError(t, err, "terminal refused to read the clipboard: EPERM")
+	})
+}
+
+func TestPasteDir(t *testing.T) {
+	t.Parallel()
+
+	var d pasteDir
+	first, err := d.save("paste_1.png", []byte("one"))
+	require.NoError(t, err)
+	second, err := d.save("paste_2.png", []byte("two"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Dir(first), filepath.Dir(second), "pastes share one directory")
+
+	require.NoError(t, d.remove())
+	_, err = os.Stat(filepath.Dir(first))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.NoError(t, d.remove())
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/ui/dialog"
+	"github.com/charmbracelet/crush/internal/ui/util"
+	"github.com/charmbracelet/x/editor"
+)
+
+// commitMessageMsg is sent when the message of the staged changes is
+// written, by the small model or in the external editor.
+type commitMessageMsg struct {
+	Message string
+}
+
+// writeCommitMessage has the small model write the message of the staged
+// changes, then shows it for confirmation.
+func (m *UI) writeCommitMessage() tea.Cmd {
+	return tea.Batch(
+		util.ReportInfo("Writing commit message..."),
+		func() tea.Msg {
+			message, err := m.com.App.GenerateCommitMessage(context.Background())
+			if err != nil {
+				return util.NewErrorMsg(err)
+			}
+			return commitMessageMsg{Message: message}
+		},
+	)
+}
+
+// showCommitMessage opens the commit dialog with message, or updates it.
+func (m *UI) showCommitMessage(message string) {
+	if d, ok := m.dialog.Dialog(dialog.CommitID).(*dialog.Commit); ok {
+		d.SetMessage(message)
+		m.dialog.BringToFront(dialog.CommitID)
+		return
+	}
+	m.dialog.OpenDialog(dialog.NewCommit(m.com, message))
+}
+
+// commit commits the staged changes with message.
+func (m *UI) commit(message string) tea.Cmd {
+	return func() tea.Msg {
+		summary, err := m.com.App.Commit(context.Background(), message)
+		if err != nil {
+			return util.NewErrorMsg(err)
+		}
+		return util.InfoMsg{Type: util.InfoTypeSuccess, Msg: summary}
+	}
+}
+
+// editCommitMessage opens the commit message in the external editor.
+func (m *UI) editCommitMessage(message string) tea.Cmd {
+	tmpfile, err := os.CreateTemp("", "COMMIT_EDITMSG_*")
+	if err != nil {
+		return util.ReportError(err)
+	}
+	defer tmpfile.Close() //nolint:errcheck
+	if _, err := tmpfile.WriteString(message + "\n"); err != nil {
+		return util.ReportError(err)
+	}
+	cmd, err := editor.Command("crush", tmpfile.Name())
+	if err != nil {
+		return util.ReportError(err)
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(tmpfile.Name())
+		if err != nil {
+			return util.NewErrorMsg(err)
+		}
+		content, err := os.ReadFile(tmpfile.Name())
+		if err != nil {
+			return util.NewErrorMsg(err)
+		}
+		return commitMessageMsg{Message: strings.TrimSpace(string(content))}
+	})
+}
@@ -0,0 +1,170 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/util"
+	"gopkg.in/yaml.v3"
+)
+
+// promptFrontmatter is the YAML frontmatter a prompt composed in $EDITOR can
+// start with, listing the files to attach:
+//
+//	---
+//	attach:
+//	  - internal/app/app.go
+//	  - ~/Pictures/screenshot.png
+//	---
+type promptFrontmatter struct {
+	Attach []string `yaml:"attach"`
+}
+
+// composePrompt returns the prompt as written to the editor, with the
+// attached files in its frontmatter.
+func composePrompt(text string, files []string) string {
+	if len(files) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	sb.WriteString("---\nattach:\n")
+	for _, file := range files {
+		fmt.Fprintf(&sb, "  - %s\n", yamlString(file))
+	}
+	sb.WriteString("---\n")
+	sb.WriteString(text)
+	return sb.String()
+}
+
+// frontmatterLines is how many lines composePrompt puts before the text.
+func frontmatterLines(files []string) int {
+	if len(files) == 0 {
+		return 0
+	}
+	return len(files) + 3
+}
+
+func yamlString(s string) string {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return s
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+// parsePrompt splits a prompt read back from the editor into its text and
+// the files its frontmatter attaches. A prompt without frontmatter attaches
+// no files; one whose frontmatter fails to parse is returned whole, with the
+// error.
+func parsePrompt(content string) (text string, files []string, err error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	rest, ok := strings.CutPrefix(content, "---\n")
+	if !ok {
+		return strings.TrimSpace(content), nil, nil
+	}
+	// A leading --- without a closing one is a rule, not frontmatter.
+	frontmatter, body, ok := strings.Cut("\n"+rest+"\n", "\n---\n")
+	if !ok {
+		return strings.TrimSpace(content), nil, nil
+	}
+	var fm promptFrontmatter
+	dec := yaml.NewDecoder(strings.NewReader(frontmatter))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fm); err != nil && !errors.Is(err, io.EOF) {
+		return strings.TrimSpace(content), nil, fmt.Errorf("frontmatter: %w", err)
+	}
+	return strings.TrimSpace(body), fm.Attach, nil
+}
+
+// attachedFiles returns the paths of the attachments that are files on disk,
+// those the frontmatter of a prompt composed in $EDITOR lists. Pasted text
+// stays attached without being listed.
+func (m *UI) attachedFiles() []string {
+	var files []string
+	for _, a := range m.attachments.List() {
+		if a.FilePath == "" {
+			continue
+		}
+		if info, err := os.Stat(m.resolvePath(a.FilePath)); err == nil && info.Mode().IsRegular() {
+			files = append(files, a.FilePath)
+		}
+	}
+	return files
+}
+
+// loadAttachments reads the files listed in the frontmatter of a prompt,
+// paths relative to the working directory, reporting those it cannot
+// attach.
+func (m *UI) loadAttachments(files []string) ([]message.Attachment, error) {
+	var attachments []message.Attachment
+	var errs []error
+	for _, file := range files {
+		path := m.resolvePath(file)
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+			continue
+		case info.IsDir():
+			errs = append(errs, fmt.Errorf("%s is a directory", file))
+			continue
+		case info.Size() > common.MaxAttachmentSize:
+			errs = append(errs, fmt.Errorf("%s is too big (>5mb)", file))
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		attachments = append(attachments, message.Attachment{
+			FilePath: file,
+			FileName: filepath.Base(file),
+			MimeType: mimeOf(content),
+			Content:  content,
+		})
+	}
+	return attachments, errors.Join(errs...)
+}
+
+func (m *UI) resolvePath(path string) string {
+	path = home.Long(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(m.com.Config().WorkingDir(), path)
+}
+
+// setComposedPrompt puts a prompt composed in $EDITOR in the editor. The
+// files listed when it was opened are detached, and those listed now
+// attached.
+func (m *UI) setComposedPrompt(msg openEditorMsg) tea.Cmd {
+	var cmd tea.Cmd
+	m.textarea.SetValue(msg.Text)
+	m.textarea.MoveToEnd()
+	m.textarea, cmd = m.textarea.Update(msg)
+	if msg.Err != nil {
+		return tea.Batch(cmd, util.ReportWarn(fmt.Sprintf("Prompt attachments not read: %v", msg.Err)))
+	}
+
+	kept := slices.DeleteFunc(slices.Clone(m.attachments.List()), func(a message.Attachment) bool {
+		return slices.Contains(msg.Listed, a.FilePath)
+	})
+	m.attachments.Reset()
+	for _, a := range append(kept, msg.Attachments...) {
+		m.attachments.Update(a)
+	}
+	if msg.AttachErr != nil {
+		return tea.Batch(cmd, util.ReportWarn(fmt.Sprintf("Some files were not attached: %v", msg.AttachErr)))
+	}
+	return cmd
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/ui/dialog"
+	"github.com/charmbracelet/crush/internal/ui/util"
+)
+
+// openCopyDialog opens the dialog that copies a part of the selected
+// message, such as one of its code blocks or the output of a tool.
+func (m *UI) openCopyDialog() tea.Cmd {
+	parts, ok := m.chat.SelectedCopyParts()
+	if !ok {
+		return util.ReportWarn("Select a message to copy from")
+	}
+	var copyParts []dialog.CopyPart
+	for _, part := range parts {
+		if strings.TrimSpace(part.Text) != "" {
+			copyParts = append(copyParts, dialog.CopyPart{Title: part.Title, Text: part.Text})
+		}
+	}
+	if len(copyParts) == 0 {
+		return util.ReportWarn("Nothing to copy in this message")
+	}
+	if m.dialog.ContainsDialog(dialog.CopyID) {
+		m.dialog.CloseDialog(dialog.CopyID)
+	}
+	m.dialog.OpenDialog(dialog.NewCopy(m.com, copyParts))
+	return nil
+}
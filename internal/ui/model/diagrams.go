@@ -0,0 +1,103 @@
+package model
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/diagram"
+	fimage "github.com/charmbracelet/crush/internal/ui/image"
+	"github.com/charmbracelet/crush/internal/ui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// viewDiagrams shows the diagrams of the selected reply full screen, drawn
+// with the image protocol the terminal supports.
+func (m *UI) viewDiagrams() tea.Cmd {
+	text, ok := m.chat.SelectedAssistantText()
+	if !ok {
+		return util.ReportWarn("Select a reply to view its diagrams")
+	}
+	blocks := diagram.Find(text)
+	if len(blocks) == 0 {
+		return util.ReportWarn("This reply has no Mermaid, Graphviz or PlantUML diagrams")
+	}
+
+	viewer := &diagramViewer{
+		blocks:   blocks,
+		protocol: fimage.ProtocolBlocks,
+		cols:     m.width,
+		rows:     m.height,
+	}
+	switch {
+	case m.caps.SupportsKittyGraphics():
+		viewer.protocol = fimage.ProtocolKitty
+	case m.caps.SupportsITerm2Images():
+		viewer.protocol = fimage.ProtocolITerm2
+	case m.caps.SupportsSixelGraphics():
+		viewer.protocol = fimage.ProtocolSixel
+	}
+	viewer.cellSize.Width, viewer.cellSize.Height = m.caps.CellSize()
+	_, viewer.tmux = m.caps.Env.LookupEnv("TMUX")
+
+	return tea.Exec(viewer, func(err error) tea.Msg {
+		if err != nil {
+			return util.ReportError(err)
+		}
+		return nil
+	})
+}
+
+// diagramViewer prints diagrams on the terminal released by the UI, and
+// waits for the user to be done with them. Diagrams that cannot be
+// rendered are printed as their source.
+type diagramViewer struct {
+	blocks   []diagram.Block
+	protocol fimage.Protocol
+	cellSize fimage.CellSize
+	cols     int
+	rows     int
+	tmux     bool
+
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+func (v *diagramViewer) SetStdin(r io.Reader)  { v.stdin = r }
+func (v *diagramViewer) SetStdout(w io.Writer) { v.stdout = w }
+func (v *diagramViewer) SetStderr(io.Writer)   {}
+
+// Run implements [tea.ExecCommand].
+func (v *diagramViewer) Run() error {
+	// Leave room for the title of each diagram and the prompt to return.
+	rows := max(1, v.rows-5)
+	stdin := bufio.NewReader(v.stdin)
+	for i, b := range v.blocks {
+		fmt.Fprint(v.stdout, ansi.EraseEntireScreen+ansi.CursorHomePosition)
+		fmt.Fprintf(v.stdout, "%s (%d/%d)\n\n", b.Kind, i+1, len(v.blocks))
+		img, err := diagram.Render(context.Background(), b)
+		if err == nil {
+			err = v.protocol.Draw(v.stdout, img, v.cellSize, v.cols, rows, v.tmux)
+		}
+		if err != nil {
+			if errors.Is(err, diagram.ErrNoRenderer) {
+				err = fmt.Errorf("%w; install it to see this diagram as an image", err)
+			}
+			fmt.Fprintf(v.stdout, "%s\n\n%s\n", err, strings.TrimRight(b.Source, "\n"))
+		}
+		fmt.Fprint(v.stdout, "\n\nPress enter to ")
+		if i < len(v.blocks)-1 {
+			fmt.Fprint(v.stdout, "see the next diagram")
+		} else {
+			fmt.Fprint(v.stdout, "return")
+		}
+		if _, err := stdin.ReadString('\n'); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/export"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/ui/util"
+)
+
+// exportSession writes the transcript of the session to the workspace.
+func (m *UI) exportSession(sessionID string, format export.Format) tea.Cmd {
+	return func() tea.Msg {
+		path, err := m.com.App.ExportSession(context.Background(), sessionID, format)
+		if err != nil {
+			return util.NewErrorMsg(err)
+		}
+		return util.NewInfoMsg(fmt.Sprintf("Exported %s", fsext.PrettyPath(path)))
+	}
+}
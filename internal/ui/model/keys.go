@@ -1,11 +1,24 @@
 package model
 
-import "charm.land/bubbles/v2/key"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+
+	"charm.land/bubbles/v2/key"
+)
 
 type KeyMap struct {
 	Editor struct {
 		AddFile     key.Binding
 		SendMessage key.Binding
+		Interrupt   key.Binding
 		OpenEditor  key.Binding
 		Newline     key.Binding
 		AddImage    key.Binding
@@ -45,6 +58,12 @@ type KeyMap struct {
 		Home           key.Binding
 		End            key.Binding
 		Copy           key.Binding
+		CopyPart       key.Binding
+		Edit           key.Binding
+		Branch         key.Binding
+		Batch          key.Binding
+		Diagrams       key.Binding
+		QuickActions   key.Binding
 		ClearHighlight key.Binding
 		Expand         key.Binding
 	}
@@ -63,7 +82,9 @@ type KeyMap struct {
 	Models   key.Binding
 	Suspend  key.Binding
 	Sessions key.Binding
+	Mode     key.Binding
 	Tab      key.Binding
+	SidePane key.Binding
 }
 
 func DefaultKeyMap() KeyMap {
@@ -88,10 +109,18 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+z"),
 			key.WithHelp("ctrl+z", "suspend"),
 		),
+		SidePane: key.NewBinding(
+			key.WithKeys("alt+p"),
+			key.WithHelp("alt+p", "changes pane"),
+		),
 		Sessions: key.NewBinding(
 			key.WithKeys("ctrl+s"),
 			key.WithHelp("ctrl+s", "sessions"),
 		),
+		Mode: key.NewBinding(
+			key.WithKeys("shift+tab"),
+			key.WithHelp("shift+tab", "plan mode"),
+		),
 		Tab: key.NewBinding(
 			key.WithKeys("tab"),
 			key.WithHelp("tab", "change focus"),
@@ -106,6 +135,10 @@ func DefaultKeyMap() KeyMap {
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "send"),
 	)
+	km.Editor.Interrupt = key.NewBinding(
+		key.WithKeys("alt+enter"),
+		key.WithHelp("alt+enter", "interrupt and send"),
+	)
 	km.Editor.OpenEditor = key.NewBinding(
 		key.WithKeys("ctrl+o"),
 		key.WithHelp("ctrl+o", "open editor"),
@@ -237,6 +270,30 @@ func DefaultKeyMap() KeyMap {
 		key.WithKeys("c", "y", "C", "Y"),
 		key.WithHelp("c/y", "copy"),
 	)
+	km.Chat.Edit = key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit"),
+	)
+	km.Chat.Branch = key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "edit in new session"),
+	)
+	km.Chat.Batch = key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "instruct matches"),
+	)
+	km.Chat.CopyPart = key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "select to copy"),
+	)
+	km.Chat.Diagrams = key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "view diagrams"),
+	)
+	km.Chat.QuickActions = key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "act on latest reply"),
+	)
 	km.Chat.ClearHighlight = key.NewBinding(
 		key.WithKeys("esc", "alt+esc"),
 		key.WithHelp("esc", "clear selection"),
@@ -264,3 +321,148 @@ func DefaultKeyMap() KeyMap {
 
 	return km
 }
+
+// LoadKeyMap returns the default key map with the bindings of the keymap
+// file at path applied. A missing file changes nothing.
+//
+// The file maps actions to a key or a list of keys, as in
+// {"quit": "ctrl+q", "editor.newline": ["shift+enter", "ctrl+j"]}. An action
+// is named after its field in snake case, prefixed by its group; an empty
+// list unbinds it.
+func LoadKeyMap(path string) (KeyMap, error) {
+	km := DefaultKeyMap()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return km, nil
+	}
+	if err != nil {
+		return km, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return km, fmt.Errorf("keymap %s: %w", path, err)
+	}
+	bindings := make(map[string][]string, len(raw))
+	for action, value := range raw {
+		var keys []string
+		if err := json.Unmarshal(value, &keys); err != nil {
+			var k string
+			if err := json.Unmarshal(value, &k); err != nil {
+				return km, fmt.Errorf("keymap %s: %s: want a key or a list of keys", path, action)
+			}
+			keys = []string{k}
+		}
+		bindings[action] = keys
+	}
+	if err := km.Rebind(bindings); err != nil {
+		return km, fmt.Errorf("keymap %s: %w", path, err)
+	}
+	return km, nil
+}
+
+// Rebind binds each action of bindings to its keys, showing the first one
+// in the help. Actions are named as in the keymap file read by
+// [LoadKeyMap]; unknown ones are reported and the others still rebound.
+//
+// A key newly bound to an action is taken from the default bindings of the
+// actions it clashes with, those of the same group and the global ones.
+// Actions of bindings that clash over a key are reported.
+func (km *KeyMap) Rebind(bindings map[string][]string) error {
+	actions := km.actions()
+	var errs []error
+	var rebound []string
+	defaults := make(map[string][]string)
+	for _, action := range slices.Sorted(maps.Keys(bindings)) {
+		b, ok := actions[action]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown action %q", action))
+			continue
+		}
+		rebound = append(rebound, action)
+		defaults[action] = b.Keys()
+		keys := bindings[action]
+		if len(keys) == 0 {
+			b.Unbind()
+			continue
+		}
+		b.SetKeys(keys...)
+		b.SetHelp(keys[0], b.Help().Desc)
+	}
+
+	for i, action := range rebound {
+		for _, k := range bindings[action] {
+			for _, other := range rebound[i+1:] {
+				if keysClash(action, other) && slices.Contains(bindings[other], k) {
+					errs = append(errs, fmt.Errorf("%s and %s are both bound to %s", action, other, k))
+				}
+			}
+			if !slices.Contains(defaults[action], k) {
+				takeKey(actions, bindings, action, k)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// takeKey takes k from the default bindings of the actions that clash with
+// action, unbinding those left without keys.
+func takeKey(actions map[string]*key.Binding, bindings map[string][]string, action, k string) {
+	for other, b := range actions {
+		if _, ok := bindings[other]; ok || !keysClash(action, other) || !slices.Contains(b.Keys(), k) {
+			continue
+		}
+		if keys := slices.DeleteFunc(slices.Clone(b.Keys()), func(bound string) bool { return bound == k }); len(keys) > 0 {
+			b.SetKeys(keys...)
+		} else {
+			b.Unbind()
+		}
+	}
+}
+
+// keysClash returns whether actions a and b cannot share a key: when they
+// are of the same group, or either is global.
+func keysClash(a, b string) bool {
+	groupA, _, okA := strings.Cut(a, ".")
+	groupB, _, okB := strings.Cut(b, ".")
+	return !okA || !okB || groupA == groupB
+}
+
+// Actions returns the names of the actions that can be rebound.
+func (km *KeyMap) Actions() []string {
+	return slices.Sorted(maps.Keys(km.actions()))
+}
+
+// actions returns the bindings of the key map by action name.
+func (km *KeyMap) actions() map[string]*key.Binding {
+	actions := make(map[string]*key.Binding)
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		for i := range v.NumField() {
+			name := prefix + snakeCase(v.Type().Field(i).Name)
+			switch field := v.Field(i); field.Interface().(type) {
+			case key.Binding:
+				actions[name] = field.Addr().Interface().(*key.Binding)
+			default:
+				if field.Kind() == reflect.Struct {
+					walk(field, name+".")
+				}
+			}
+		}
+	}
+	walk(reflect.ValueOf(km).Elem(), "")
+	return actions
+}
+
+func snakeCase(s string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
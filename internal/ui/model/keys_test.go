@@ -0,0 +1,173 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/bubbles/v2/key"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadKeyMap(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name    string
+		file    string
+		err     string
+		binding func(km *KeyMap) key.Binding
+		keys    []string
+		help    string
+	}{
+		{
+			name:    "missing file",
+			binding: func(km *KeyMap) key.Binding { return km.Quit },
+			keys:    []string{"ctrl+c"},
+			help:    "ctrl+c",
+		},
+		{
+			name:    "a key",
+			file:    `{"quit": "ctrl+q"}`,
+			binding: func(km *KeyMap) key.Binding { return km.Quit },
+			keys:    []string{"ctrl+q"},
+			help:    "ctrl+q",
+		},
+		{
+			name:    "a list of keys",
+			file:    `{"editor.newline": ["ctrl+j", "shift+enter"]}`,
+			binding: func(km *KeyMap) key.Binding { return km.Editor.Newline },
+			keys:    []string{"ctrl+j", "shift+enter"},
+			help:    "ctrl+j",
+		},
+		{
+			name:    "an empty list",
+			file:    `{"suspend": []}`,
+			binding: func(km *KeyMap) key.Binding { return km.Suspend },
+		},
+		{
+			name:    "unknown action",
+			file:    `{"chat.launch": "l", "chat.edit": "E"}`,
+			err:     `unknown action "chat.launch"`,
+			binding: func(km *KeyMap) key.Binding { return km.Chat.Edit },
+			keys:    []string{"E"},
+			help:    "E",
+		},
+		{
+			name:    "not a key",
+			file:    `{"quit": 3}`,
+			err:     "quit: want a key or a list of keys",
+			binding: func(km *KeyMap) key.Binding { return km.Quit },
+			keys:    []string{"ctrl+c"},
+			help:    "ctrl+c",
+		},
+		{
+			name:    "not JSON",
+			file:    `quit = "ctrl+q"`,
+			err:     "invalid character",
+			binding: func(km *KeyMap) key.Binding { return km.Quit },
+			keys:    []string{"ctrl+c"},
+			help:    "ctrl+c",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "keymap.json")
+			if tt.file != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tt.file), 0o600))
+			}
+			km, err := LoadKeyMap(path)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			b := tt.binding(&km)
+			require.Equal(t, tt.keys, b.Keys())
+			if tt.keys != nil {
+				require.Equal(t, tt.help, b.Help().Key)
+			}
+		})
+	}
+}
+
+func TestRebindClashes(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name     string
+		bindings map[string][]string
+		err      string
+		binding  func(km *KeyMap) key.Binding
+		keys     []string
+	}{
+		{
+			name:     "taken from the same group",
+			bindings: map[string][]string{"chat.copy": {"j"}},
+			binding:  func(km *KeyMap) key.Binding { return km.Chat.Down },
+			keys:     []string{"down", "ctrl+j"},
+		},
+		{
+			name:     "taken by a global action",
+			bindings: map[string][]string{"quit": {"ctrl+l"}},
+			binding:  func(km *KeyMap) key.Binding { return km.Models },
+			keys:     []string{"ctrl+m"},
+		},
+		{
+			name:     "taken from a global action",
+			bindings: map[string][]string{"chat.edit": {"ctrl+z"}},
+			binding:  func(km *KeyMap) key.Binding { return km.Suspend },
+		},
+		{
+			name:     "kept by other groups",
+			bindings: map[string][]string{"editor.add_file": {"j"}},
+			binding:  func(km *KeyMap) key.Binding { return km.Chat.Down },
+			keys:     []string{"down", "ctrl+j", "j"},
+		},
+		{
+			name:     "kept when bound by default",
+			bindings: map[string][]string{"tab": {"tab"}},
+			binding:  func(km *KeyMap) key.Binding { return km.Chat.Tab },
+			keys:     DefaultKeyMap().Chat.Tab.Keys(),
+		},
+		{
+			name:     "kept when rebound too",
+			bindings: map[string][]string{"chat.copy": {"e"}, "chat.edit": {"E"}},
+			binding:  func(km *KeyMap) key.Binding { return km.Chat.Edit },
+			keys:     []string{"E"},
+		},
+		{
+			name:     "bound twice",
+			bindings: map[string][]string{"chat.copy": {"x"}, "chat.edit": {"x"}},
+			err:      "chat.copy and chat.edit are both bound to x",
+			binding:  func(km *KeyMap) key.Binding { return km.Chat.Edit },
+			keys:     []string{"x"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			km := DefaultKeyMap()
+			err := km.Rebind(tt.bindings)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.keys, tt.binding(&km).Keys())
+		})
+	}
+}
+
+func TestKeyMapActions(t *testing.T) {
+	t.Parallel()
+
+	km := DefaultKeyMap()
+	actions := km.Actions()
+	require.Contains(t, actions, "quit")
+	require.Contains(t, actions, "editor.send_message")
+	require.Contains(t, actions, "chat.page_down")
+	require.Contains(t, actions, "initialize.yes")
+	require.NotContains(t, actions, "editor")
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/commands"
+	"github.com/charmbracelet/crush/internal/ui/dialog"
+	"github.com/charmbracelet/crush/internal/ui/util"
+)
+
+// newLabelDialog creates the dialog asking for the label the next turns of
+// the session are counted under.
+func (m *UI) newLabelDialog(action dialog.ActionLabelSession) dialog.Dialog {
+	description := "Cost and tokens of the turns sent from now on are counted under this label. Leave it empty to stop labeling."
+	if label, err := m.com.App.Sessions.Label(context.Background(), action.SessionID); err == nil && label != "" {
+		description = fmt.Sprintf("Turns are counted under %s. %s", label, description)
+	}
+	return dialog.NewArguments(
+		m.com,
+		"Label Turns",
+		description,
+		[]commands.Argument{{ID: "label", Title: "Label", Description: "A task or ticket ID, such as JIRA-123"}},
+		action, // Pass the action as the result
+	)
+}
+
+// labelSession sets the label the next turns of the session are counted
+// under.
+func (m *UI) labelSession(sessionID, label string) tea.Cmd {
+	label = strings.TrimSpace(label)
+	return func() tea.Msg {
+		if err := m.com.App.Sessions.SetLabel(context.Background(), sessionID, label); err != nil {
+			return util.ReportError(err)()
+		}
+		if label == "" {
+			return util.NewInfoMsg("Turns are no longer labeled")
+		}
+		return util.NewInfoMsg("Turns are now counted under " + label)
+	}
+}
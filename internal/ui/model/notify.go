@@ -0,0 +1,72 @@
+package model
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/notify"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// notify notifies title and body through the terminal and the notify
+// command, when the terminal is unfocused and out of the quiet hours.
+func (m *UI) notify(title, body string) tea.Cmd {
+	if m.notifier == nil || !m.blurred || m.notifier.Quiet(time.Now()) {
+		return nil
+	}
+	n := m.notifier
+	return tea.Batch(
+		tea.Raw(notify.Sequence(m.caps.Env.Getenv, title, body)),
+		func() tea.Msg {
+			if err := n.Run(context.Background(), title, body); err != nil {
+				slog.Warn("Failed to run the notify command", "error", err)
+			}
+			return nil
+		},
+	)
+}
+
+// notifyTurnEnd notifies the end of a turn of the session that ran long
+// enough, once msg, its last reply, finishes.
+func (m *UI) notifyTurnEnd(msg message.Message) tea.Cmd {
+	if m.notifier == nil || msg.Role != message.Assistant || msg.ID == m.notifiedTurn {
+		return nil
+	}
+	finish := msg.FinishPart()
+	if finish == nil || (finish.Reason != message.FinishReasonEndTurn && finish.Reason != message.FinishReasonError) {
+		return nil
+	}
+	if !m.notifier.LongTurn(time.Since(time.Unix(m.lastUserMessageTime, 0))) {
+		return nil
+	}
+	m.notifiedTurn = msg.ID
+
+	body := "The agent finished its turn"
+	if finish.Reason == message.FinishReasonError {
+		body = "The agent stopped with an error"
+	}
+	return m.notify(m.notificationTitle(), body)
+}
+
+// notifyPermission notifies a permission the agent asks for.
+func (m *UI) notifyPermission(req permission.PermissionRequest) tea.Cmd {
+	body := req.Description
+	switch {
+	case req.Command != "":
+		body = "Run " + req.Command + "?"
+	case body == "":
+		body = "Allow " + req.ToolName + "?"
+	}
+	return m.notify(m.notificationTitle(), body)
+}
+
+// notificationTitle titles notifications with the session they are about.
+func (m *UI) notificationTitle() string {
+	if m.session != nil && m.session.Title != "" {
+		return "Crush: " + m.session.Title
+	}
+	return "Crush"
+}
@@ -0,0 +1,190 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/stringext"
+	"github.com/charmbracelet/crush/internal/ui/chat"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/util"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// sidePaneMinWidth is the width the chat area needs for the side pane
+	// to open next to it.
+	sidePaneMinWidth = 100
+	// sidePaneRatio is the share of the chat area the side pane takes.
+	sidePaneRatio = 0.45
+)
+
+// sidePane is what the side pane shows: the last diff or file the agent
+// touched.
+type sidePane struct {
+	tool string
+	path string
+	// before and after are the contents of the file around an edit; content
+	// the file written or viewed, starting at line offset.
+	before, after string
+	content       string
+	offset        int
+	diff          bool
+
+	// scroll is the first line shown.
+	scroll int
+	// lines is the body rendered at width.
+	lines []string
+	width int
+}
+
+// newSidePane returns what the pane shows for a finished tool call, or nil
+// when the call touched no file.
+func newSidePane(call message.ToolCall, result message.ToolResult) *sidePane {
+	if result.IsError {
+		return nil
+	}
+	switch call.Name {
+	case tools.EditToolName, tools.MultiEditToolName:
+		var params struct {
+			FilePath string `json:"file_path"`
+		}
+		var meta struct {
+			OldContent string `json:"old_content"`
+			NewContent string `json:"new_content"`
+		}
+		if json.Unmarshal([]byte(call.Input), &params) != nil || json.Unmarshal([]byte(result.Metadata), &meta) != nil {
+			return nil
+		}
+		tool := "Edit"
+		if call.Name == tools.MultiEditToolName {
+			tool = "Multi-Edit"
+		}
+		return &sidePane{tool: tool, path: params.FilePath, before: meta.OldContent, after: meta.NewContent, diff: true}
+	case tools.WriteToolName:
+		var params tools.WriteParams
+		if json.Unmarshal([]byte(call.Input), &params) != nil {
+			return nil
+		}
+		return &sidePane{tool: "Write", path: params.FilePath, content: params.Content}
+	case tools.ViewToolName:
+		if result.Data != "" {
+			// Images are not shown in the pane.
+			return nil
+		}
+		var params tools.ViewParams
+		var meta tools.ViewResponseMetadata
+		if json.Unmarshal([]byte(call.Input), &params) != nil || json.Unmarshal([]byte(result.Metadata), &meta) != nil {
+			return nil
+		}
+		return &sidePane{tool: "View", path: params.FilePath, content: meta.Content, offset: params.Offset}
+	}
+	return nil
+}
+
+// updateSidePane shows the last file touched by the tool results of msg,
+// looking their calls up in the chat.
+func (m *UI) updateSidePane(msg message.Message) {
+	for _, result := range msg.ToolResults() {
+		item, ok := m.chat.MessageItem(result.ToolCallID).(chat.ToolMessageItem)
+		if !ok {
+			continue
+		}
+		if pane := newSidePane(item.ToolCall(), result); pane != nil {
+			m.sidePane = pane
+		}
+	}
+}
+
+// setSidePaneFromMessages shows the last file touched in msgs, those of a
+// session being loaded.
+func (m *UI) setSidePaneFromMessages(msgs []message.Message) {
+	m.sidePane = nil
+	calls := make(map[string]message.ToolCall)
+	for _, msg := range msgs {
+		for _, call := range msg.ToolCalls() {
+			calls[call.ID] = call
+		}
+		for _, result := range msg.ToolResults() {
+			if pane := newSidePane(calls[result.ToolCallID], result); pane != nil {
+				m.sidePane = pane
+			}
+		}
+	}
+}
+
+// toggleSidePane opens or closes the side pane, remembering the choice.
+func (m *UI) toggleSidePane() tea.Cmd {
+	m.sidePaneOpen = !m.sidePaneOpen
+	m.updateLayoutAndSize()
+	if err := m.com.Config().SetSidePane(m.sidePaneOpen); err != nil {
+		return util.ReportError(err)
+	}
+	return nil
+}
+
+// scrollSidePane scrolls the side pane by lines.
+func (m *UI) scrollSidePane(lines int) {
+	if m.sidePane == nil {
+		return
+	}
+	maxScroll := max(0, len(m.sidePane.lines)-m.layout.pane.Dy()+1)
+	m.sidePane.scroll = max(0, min(m.sidePane.scroll+lines, maxScroll))
+}
+
+// drawSidePane draws the side pane in area.
+func (m *UI) drawSidePane(scr uv.Screen, area uv.Rectangle) {
+	t := m.com.Styles
+	width := area.Dx()
+	if m.sidePane == nil {
+		header := common.Section(t, "Changes", width)
+		body := t.Subtle.Width(width).Render("The diff or file the agent touches last shows here.")
+		uv.NewStyledString(lipgloss.JoinVertical(lipgloss.Left, header, "", body)).Draw(scr, area)
+		return
+	}
+
+	p := m.sidePane
+	if p.lines == nil || p.width != width {
+		p.lines = strings.Split(p.render(m.com, width), "\n")
+		p.width = width
+	}
+	header := common.Section(t, p.tool, width, fsext.PrettyPath(p.path))
+	height := max(0, area.Dy()-1)
+	end := min(len(p.lines), p.scroll+height)
+	body := strings.Join(p.lines[min(p.scroll, end):end], "\n")
+	uv.NewStyledString(lipgloss.NewStyle().MaxWidth(width).MaxHeight(area.Dy()).Render(
+		lipgloss.JoinVertical(lipgloss.Left, header, body),
+	)).Draw(scr, area)
+}
+
+// render renders the diff or file the pane shows at width.
+func (p *sidePane) render(com *common.Common, width int) string {
+	t := com.Styles
+	if p.diff {
+		return common.DiffFormatter(t).
+			Before(p.path, p.before).
+			After(p.path, p.after).
+			Width(width).
+			String()
+	}
+
+	content := stringext.NormalizeSpace(p.content)
+	highlighted, _ := common.SyntaxHighlight(t, content, p.path, t.Tool.ContentCodeBg)
+	lines := strings.Split(highlighted, "\n")
+	digits := len(fmt.Sprint(len(lines) + p.offset))
+	out := make([]string, 0, len(lines))
+	for i, line := range lines {
+		num := t.Tool.ContentLineNumber.Render(fmt.Sprintf("%*d", digits, i+1+p.offset))
+		codeWidth := max(0, width-lipgloss.Width(num))
+		line = ansi.Truncate(line, codeWidth-t.Tool.ContentCodeLine.GetHorizontalPadding(), "…")
+		out = append(out, lipgloss.JoinHorizontal(lipgloss.Left, num, t.Tool.ContentCodeLine.Width(codeWidth).Render(line)))
+	}
+	return strings.Join(out, "\n")
+}
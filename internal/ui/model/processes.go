@@ -0,0 +1,84 @@
+package model
+
+import (
+	"cmp"
+	"fmt"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+)
+
+// processesRefreshInterval is how often the processes section is redrawn,
+// so the status and uptime of background processes stay current while the
+// agent is idle.
+const processesRefreshInterval = 2 * time.Second
+
+// processesTickMsg is sent to redraw the processes section.
+type processesTickMsg struct{}
+
+// processesTick schedules the next redraw of the processes section.
+func processesTick() tea.Cmd {
+	return tea.Tick(processesRefreshInterval, func(time.Time) tea.Msg {
+		return processesTickMsg{}
+	})
+}
+
+// processesInfo renders the background processes section, which lists the
+// processes started by the agent with their status. It is empty when there
+// are none.
+func (m *UI) processesInfo(width, maxItems int, isSection bool) string {
+	processes := shell.GetBackgroundShellManager().All()
+	if len(processes) == 0 {
+		return ""
+	}
+	t := m.com.Styles
+
+	title := t.ResourceGroupTitle.Render("Processes")
+	if isSection {
+		title = common.Section(t, title, width)
+	}
+	list := processList(t, processes, width, maxItems)
+	return lipgloss.NewStyle().Width(width).Render(fmt.Sprintf("%s\n\n%s", title, list))
+}
+
+// processList renders background processes with their status, truncating
+// to maxItems if needed.
+func processList(t *styles.Styles, processes []*shell.BackgroundShell, width, maxItems int) string {
+	if maxItems <= 0 {
+		return ""
+	}
+	var rendered []string
+	for _, p := range processes {
+		var icon, description string
+		_, _, done, err := p.GetOutput()
+		switch code := shell.ExitCode(err); {
+		case !done:
+			icon = t.ResourceOnlineIcon.String()
+			description = t.ResourceStatus.Render(time.Since(p.StartedAt).Round(time.Second).String())
+		case code != 0:
+			icon = t.ResourceErrorIcon.String()
+			description = t.ResourceStatus.Render(fmt.Sprintf("exited %d", code))
+		default:
+			icon = t.ResourceOfflineIcon.String()
+			description = t.ResourceStatus.Render("exited")
+		}
+		rendered = append(rendered, common.Status(t, common.StatusOpts{
+			Icon:         icon,
+			Title:        t.ResourceName.Render(cmp.Or(p.Description, p.Command)),
+			Description:  description,
+			ExtraContent: t.Subtle.Render(p.ID),
+		}, width))
+	}
+
+	if len(rendered) > maxItems {
+		visibleItems := rendered[:maxItems-1]
+		remaining := len(rendered) - len(visibleItems)
+		visibleItems = append(visibleItems, t.ResourceAdditionalText.Render(fmt.Sprintf("…and %d more", remaining)))
+		return lipgloss.JoinVertical(lipgloss.Left, visibleItems...)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rendered...)
+}
@@ -0,0 +1,178 @@
+package model
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/dialog"
+	"github.com/charmbracelet/crush/internal/ui/util"
+	"github.com/charmbracelet/x/editor"
+)
+
+// Prompts sent by the quick actions that ask the agent for more.
+const (
+	explainMorePrompt = "Explain your last reply in more detail: the reasoning behind it, the trade-offs and anything you left out."
+	writeTestsPrompt  = "Write tests for what you just did, following the conventions of the existing tests, and run them."
+)
+
+// openQuickActionsDialog opens the quick actions on the latest reply of
+// the agent.
+func (m *UI) openQuickActionsDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.QuickActionsID) {
+		m.dialog.BringToFront(dialog.QuickActionsID)
+		return nil
+	}
+	if !m.hasSession() {
+		return util.ReportWarn("Start a session to act on its replies")
+	}
+	m.dialog.OpenDialog(dialog.NewQuickActions(m.com))
+	return nil
+}
+
+// runQuickAction runs a quick action on the latest reply of the agent.
+func (m *UI) runQuickAction(action dialog.QuickAction) tea.Cmd {
+	if !m.hasSession() {
+		return nil
+	}
+	prompt, reply, err := m.latestTurn()
+	if err != nil {
+		return util.ReportError(err)
+	}
+	if reply == nil {
+		return util.ReportWarn("The agent has not replied yet")
+	}
+	if action != dialog.QuickActionCopy && action != dialog.QuickActionDiff && m.isAgentBusy() {
+		return util.ReportWarn("Agent is working, please wait...")
+	}
+
+	switch action {
+	case dialog.QuickActionRetry:
+		if prompt == nil {
+			return util.ReportWarn("There is no message to retry")
+		}
+		m.editing = &editedMessage{id: prompt.ID, sessionID: prompt.SessionID, mode: app.RegenerateTruncate}
+		return m.sendEdit(prompt.Content().Text, nil)
+	case dialog.QuickActionCopy:
+		text := strings.TrimSpace(reply.Content().Text)
+		if text == "" {
+			return util.ReportWarn("The latest reply has no text to copy")
+		}
+		return common.CopyToClipboard(text, "Reply copied to clipboard")
+	case dialog.QuickActionExplain:
+		return m.sendMessage(explainMorePrompt)
+	case dialog.QuickActionTests:
+		return m.sendMessage(writeTestsPrompt)
+	case dialog.QuickActionDiff:
+		var since int64
+		if prompt != nil {
+			since = prompt.CreatedAt
+		}
+		return m.showTurnDiff(since)
+	}
+	return nil
+}
+
+// latestTurn returns the last message the user sent in the session and the
+// last reply of the agent to it.
+func (m *UI) latestTurn() (prompt, reply *message.Message, err error) {
+	msgs, err := m.com.App.Messages.List(context.Background(), m.session.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := len(msgs) - 1; i >= 0; i-- {
+		switch msgs[i].Role {
+		case message.Assistant:
+			if reply == nil {
+				reply = &msgs[i]
+			}
+		case message.User:
+			return &msgs[i], reply, nil
+		}
+	}
+	return nil, reply, nil
+}
+
+// showTurnDiff opens the changes the agent made to files since the unix
+// time since in the external editor.
+func (m *UI) showTurnDiff(since int64) tea.Cmd {
+	files, err := m.com.App.History.ListBySession(context.Background(), m.session.ID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	patch := turnDiff(files, since, m.com.Config().WorkingDir())
+	if patch == "" {
+		return util.ReportInfo("The latest reply changed no files")
+	}
+
+	tmpfile, err := os.CreateTemp("", "reply_*.diff")
+	if err != nil {
+		return util.ReportError(err)
+	}
+	defer tmpfile.Close() //nolint:errcheck
+	if _, err := tmpfile.WriteString(patch); err != nil {
+		return util.ReportError(err)
+	}
+	cmd, err := editor.Command("crush", tmpfile.Name())
+	if err != nil {
+		return util.ReportError(err)
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		os.Remove(tmpfile.Name()) //nolint:errcheck
+		if err != nil {
+			return util.ReportError(err)
+		}
+		return nil
+	})
+}
+
+// turnDiff returns the patch of the files changed since the unix time
+// since, comparing the latest version of each with the one it had before.
+// A file first changed since then is compared with its initial version.
+func turnDiff(files []history.File, since int64, cwd string) string {
+	byPath := make(map[string][]history.File)
+	var paths []string
+	for _, f := range files {
+		if _, ok := byPath[f.Path]; !ok {
+			paths = append(paths, f.Path)
+		}
+		byPath[f.Path] = append(byPath[f.Path], f)
+	}
+	slices.Sort(paths)
+
+	var patch strings.Builder
+	for _, path := range paths {
+		versions := byPath[path]
+		slices.SortFunc(versions, func(a, b history.File) int {
+			return int(a.Version - b.Version)
+		})
+		latest := versions[len(versions)-1]
+		if latest.CreatedAt < since {
+			continue
+		}
+		before := versions[0]
+		for _, v := range versions {
+			if v.CreatedAt < since {
+				before = v
+			}
+		}
+		name := path
+		if rel, err := filepath.Rel(cwd, path); err == nil && filepath.IsLocal(rel) {
+			name = filepath.ToSlash(rel)
+		}
+		unified, additions, deletions := diff.GenerateDiff(before.Content, latest.Content, name)
+		if additions == 0 && deletions == 0 {
+			continue
+		}
+		patch.WriteString(unified)
+	}
+	return patch.String()
+}
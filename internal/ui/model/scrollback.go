@@ -0,0 +1,89 @@
+package model
+
+import (
+	"context"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/util"
+)
+
+// messagePageSize is how many messages of a session are loaded at once: the
+// most recent ones when it is opened, and older ones as the chat is scrolled
+// up to them.
+const messagePageSize = 200
+
+// scrollback tracks the pages of the session's messages loaded in the chat.
+type scrollback struct {
+	// oldest is the oldest message loaded.
+	oldest *message.Message
+	// more is whether the session has messages older than oldest.
+	more bool
+	// loading is whether the page before oldest is being loaded.
+	loading bool
+}
+
+// olderMessagesMsg carries the page of messages before the oldest one loaded
+// in the chat.
+type olderMessagesMsg struct {
+	sessionID string
+	beforeID  string
+	msgs      []message.Message
+	err       error
+}
+
+// setSessionPage sets the chat to the most recent page of the session's
+// messages.
+func (m *UI) setSessionPage(msgs []message.Message) tea.Cmd {
+	m.scrollback = scrollback{more: len(msgs) == messagePageSize}
+	if len(msgs) > 0 {
+		m.scrollback.oldest = &msgs[0]
+	}
+	return m.setSessionMessages(msgs)
+}
+
+// loadOlderMessages loads the page before the oldest message in the chat,
+// once the chat is scrolled up to it.
+func (m *UI) loadOlderMessages() tea.Cmd {
+	sb := &m.scrollback
+	if m.state != uiChat || !m.hasSession() || !sb.more || sb.loading || sb.oldest == nil || !m.chat.AtTop() {
+		return nil
+	}
+	sb.loading = true
+	sessionID, beforeID := m.session.ID, sb.oldest.ID
+	return func() tea.Msg {
+		msgs, err := m.com.App.Messages.ListPage(context.Background(), sessionID, beforeID, messagePageSize)
+		return olderMessagesMsg{sessionID: sessionID, beforeID: beforeID, msgs: msgs, err: err}
+	}
+}
+
+// prependOlderMessages adds a page of older messages to the top of the chat,
+// keeping the view where it is.
+func (m *UI) prependOlderMessages(msg olderMessagesMsg) tea.Cmd {
+	sb := &m.scrollback
+	if !m.hasSession() || m.session.ID != msg.sessionID || sb.oldest == nil || sb.oldest.ID != msg.beforeID {
+		// The session was switched or reloaded meanwhile.
+		return nil
+	}
+	sb.loading = false
+	if msg.err != nil {
+		sb.more = false
+		return util.ReportError(msg.err)
+	}
+	sb.more = len(msg.msgs) == messagePageSize
+	if len(msg.msgs) == 0 {
+		return nil
+	}
+
+	// The page is built as if it were the session's start, leaving the state
+	// the latest messages set for those that follow untouched.
+	lastUserMessageTime, lastMessageModel := m.lastUserMessageTime, m.lastMessageModel
+	m.lastMessageModel = ""
+	items := m.sessionMessageItems(msg.msgs, sb.oldest)
+	m.lastUserMessageTime, m.lastMessageModel = lastUserMessageTime, lastMessageModel
+	m.loadNestedToolCalls(items)
+	sb.oldest = &msg.msgs[0]
+
+	m.chat.PrependMessages(items...)
+	return m.chat.RestartPausedVisibleAnimations()
+}
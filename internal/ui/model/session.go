@@ -52,12 +52,13 @@ func (msg loadSessionMsg) lspFilePaths() []string {
 }
 
 // SessionFile tracks the first and latest versions of a file in a session,
-// along with the total additions and deletions.
+// along with the total additions and deletions and its CODEOWNERS owners.
 type SessionFile struct {
 	FirstVersion  history.File
 	LatestVersion history.File
 	Additions     int
 	Deletions     int
+	Owners        []string
 }
 
 // loadSession loads the session along with its associated files and computes
@@ -123,6 +124,7 @@ func (m *UI) loadSessionFiles(sessionID string) ([]SessionFile, error) {
 			LatestVersion: last,
 			Additions:     additions,
 			Deletions:     deletions,
+			Owners:        m.com.App.CodeOwners.Owners(first.Path),
 		})
 	}
 
@@ -205,6 +207,10 @@ func fileList(t *styles.Styles, cwd string, filesWithChanges []SessionFile, widt
 		if f.Deletions > 0 {
 			statusParts = append(statusParts, t.Files.Deletions.Render(fmt.Sprintf("-%d", f.Deletions)))
 		}
+		if len(f.Owners) > 0 {
+			owners := ansi.Truncate(strings.Join(f.Owners, " "), width/3, "…")
+			statusParts = append(statusParts, t.Subtle.Render(owners))
+		}
 		extraContent := strings.Join(statusParts, " ")
 
 		// Format file path
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"context"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/ui/dialog"
+	"github.com/charmbracelet/crush/internal/ui/util"
+)
+
+// sessionSharedMsg is sent once a session was published.
+type sessionSharedMsg struct {
+	Link string
+}
+
+// openShareDialog asks for confirmation before sharing the session.
+func (m *UI) openShareDialog(sessionID string) {
+	if m.dialog.ContainsDialog(dialog.ShareID) {
+		m.dialog.BringToFront(dialog.ShareID)
+		return
+	}
+	destination, upload := m.com.App.ShareDestination()
+	m.dialog.OpenDialog(dialog.NewShare(m.com, sessionID, destination, upload))
+}
+
+// shareSession publishes the transcript of the session.
+func (m *UI) shareSession(sessionID string) tea.Cmd {
+	return tea.Sequence(
+		util.ReportInfo("Sharing session..."),
+		func() tea.Msg {
+			link, err := m.com.App.ShareSession(context.Background(), sessionID)
+			if err != nil {
+				return util.NewErrorMsg(err)
+			}
+			return sessionSharedMsg{Link: link}
+		},
+	)
+}
@@ -43,6 +43,10 @@ func (m *UI) modelInfo(width int) string {
 		}
 	}
 
+	if m.session != nil && m.session.ReasoningTokens > 0 && reasoningInfo != "" {
+		reasoningInfo += fmt.Sprintf(" (%s tokens)", common.FormatTokens(m.session.ReasoningTokens))
+	}
+
 	var modelContext *common.ModelContextInfo
 	if model != nil && m.session != nil {
 		modelContext = &common.ModelContextInfo{
@@ -102,7 +106,8 @@ func getDynamicHeightLimits(availableHeight int) (maxFiles, maxLSPs, maxMCPs int
 }
 
 // sidebar renders the chat sidebar containing session title, working
-// directory, model info, file list, LSP status, and MCP status.
+// directory, model info, file list, LSP status, MCP status, and background
+// processes.
 func (m *UI) drawSidebar(scr uv.Screen, area uv.Rectangle) {
 	if m.session == nil {
 		return
@@ -143,20 +148,24 @@ func (m *UI) drawSidebar(scr uv.Screen, area uv.Rectangle) {
 	mcpSection := m.mcpInfo(width, maxMCPs, true)
 	filesSection := m.filesInfo(m.com.Config().WorkingDir(), width, maxFiles, true)
 
+	sections := []string{
+		sidebarHeader,
+		filesSection,
+		"",
+		lspSection,
+		"",
+		mcpSection,
+	}
+	if processesSection := m.processesInfo(width, maxMCPs, true); processesSection != "" {
+		sections = append(sections, "", processesSection)
+	}
+
 	uv.NewStyledString(
 		lipgloss.NewStyle().
 			MaxWidth(width).
 			MaxHeight(height).
 			Render(
-				lipgloss.JoinVertical(
-					lipgloss.Left,
-					sidebarHeader,
-					filesSection,
-					"",
-					lspSection,
-					"",
-					mcpSection,
-				),
+				lipgloss.JoinVertical(lipgloss.Left, sections...),
 			),
 	).Draw(scr, area)
 }
@@ -0,0 +1,103 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/staging"
+	"github.com/charmbracelet/crush/internal/ui/dialog"
+	"github.com/charmbracelet/crush/internal/ui/util"
+	"github.com/charmbracelet/x/editor"
+)
+
+// stagedEditChangedMsg is sent when the user finished changing a staged
+// edit in the external editor.
+type stagedEditChangedMsg struct {
+	ID      string
+	Content string
+}
+
+// openStagedEditsDialog opens the dialog for reviewing staged edits.
+func (m *UI) openStagedEditsDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.StagedEditsID) {
+		m.dialog.BringToFront(dialog.StagedEditsID)
+		return nil
+	}
+	var split *bool
+	if diffMode := m.com.Config().Options.TUI.DiffMode; diffMode != "" {
+		s := diffMode == "split"
+		split = &s
+	}
+	m.dialog.OpenDialog(dialog.NewStagedEdits(m.com, m.com.App.PendingEdits(), split))
+	return nil
+}
+
+// refreshStagedEdits updates the staged edits dialog, if open, with what is
+// still pending.
+func (m *UI) refreshStagedEdits() {
+	if d, ok := m.dialog.Dialog(dialog.StagedEditsID).(*dialog.StagedEdits); ok {
+		d.SetEdits(m.com.App.PendingEdits())
+	}
+}
+
+// handleStagedEditEvent keeps the review dialog current and lets the user
+// know when the agent stages an edit.
+func (m *UI) handleStagedEditEvent(event pubsub.Event[staging.Edit]) tea.Cmd {
+	m.refreshStagedEdits()
+	if event.Type != pubsub.CreatedEvent || m.dialog.ContainsDialog(dialog.StagedEditsID) {
+		return nil
+	}
+	pending := len(m.com.App.PendingEdits())
+	return util.ReportInfo(fmt.Sprintf("Staged %s (%d pending). Review from the commands dialog.", fsext.PrettyPath(event.Payload.Path), pending))
+}
+
+// applyStagedEdits writes the given staged edits to disk, stopping at the
+// first one that fails. The dialog catches up through the queue's events.
+func (m *UI) applyStagedEdits(ids []string) tea.Cmd {
+	for _, id := range ids {
+		if err := m.com.App.ApplyEdit(context.Background(), id); err != nil {
+			if errors.Is(err, staging.ErrConflict) {
+				err = fmt.Errorf("%w; discard the edit or ask the agent for a new one", err)
+			}
+			return util.ReportError(err)
+		}
+	}
+	if len(ids) == 1 {
+		return util.ReportInfo("Applied staged edit")
+	}
+	return util.ReportInfo(fmt.Sprintf("Applied %d staged edits", len(ids)))
+}
+
+// editStagedEdit opens the content a staged edit writes in the external
+// editor.
+func (m *UI) editStagedEdit(edit staging.Edit) tea.Cmd {
+	tmpfile, err := os.CreateTemp("", "staged_*"+filepath.Ext(edit.Path))
+	if err != nil {
+		return util.ReportError(err)
+	}
+	defer tmpfile.Close() //nolint:errcheck
+	if _, err := tmpfile.WriteString(edit.NewContent); err != nil {
+		return util.ReportError(err)
+	}
+	cmd, err := editor.Command("crush", tmpfile.Name())
+	if err != nil {
+		return util.ReportError(err)
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(tmpfile.Name())
+		if err != nil {
+			return util.ReportError(err)
+		}
+		content, err := os.ReadFile(tmpfile.Name())
+		if err != nil {
+			return util.ReportError(err)
+		}
+		return stagedEditChangedMsg{ID: edit.ID, Content: string(content)}
+	})
+}
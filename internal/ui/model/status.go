@@ -1,11 +1,15 @@
 package model
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"charm.land/bubbles/v2/help"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/connectivity"
 	"github.com/charmbracelet/crush/internal/ui/common"
 	"github.com/charmbracelet/crush/internal/ui/util"
 	uv "github.com/charmbracelet/ultraviolet"
@@ -22,6 +26,8 @@ type Status struct {
 	help     help.Model
 	helpKm   help.KeyMap
 	msg      util.InfoMsg
+	usage    *agent.ContextUsage
+	offline  *connectivity.Event
 }
 
 // NewStatus creates a new status bar and help model.
@@ -44,6 +50,27 @@ func (s *Status) ClearInfoMsg() {
 	s.msg = util.InfoMsg{}
 }
 
+// SetContextUsage sets the context usage shown in the status bar. A nil
+// usage hides the meter.
+func (s *Status) SetContextUsage(usage *agent.ContextUsage) {
+	s.usage = usage
+}
+
+// SetConnectivity sets the last known connectivity. The status bar shows
+// an offline banner until connectivity returns.
+func (s *Status) SetConnectivity(event connectivity.Event) {
+	if event.Online {
+		s.offline = nil
+		return
+	}
+	s.offline = &event
+}
+
+// Offline reports whether the offline banner is shown.
+func (s *Status) Offline() bool {
+	return s.offline != nil
+}
+
 // SetWidth sets the width of the status bar and help view.
 func (s *Status) SetWidth(width int) {
 	s.help.SetWidth(width)
@@ -71,8 +98,15 @@ func (s *Status) Draw(scr uv.Screen, area uv.Rectangle) {
 		uv.NewStyledString(helpView).Draw(scr, area)
 	}
 
+	if s.usage != nil && !s.help.ShowAll {
+		s.drawContextMeter(scr, area)
+	}
+
 	// Render notifications
 	if s.msg.IsEmpty() {
+		if s.offline != nil {
+			s.drawOfflineBanner(scr, area)
+		}
 		return
 	}
 
@@ -105,6 +139,62 @@ func (s *Status) Draw(scr uv.Screen, area uv.Rectangle) {
 	uv.NewStyledString(ind+info).Draw(scr, area)
 }
 
+// drawOfflineBanner draws the offline banner over the help view.
+func (s *Status) drawOfflineBanner(scr uv.Screen, area uv.Rectangle) {
+	text := "Offline, waiting for the connection to return"
+	switch s.offline.Pending {
+	case 0:
+	case 1:
+		text += " to resume 1 turn"
+	default:
+		text += fmt.Sprintf(" to resume %d turns", s.offline.Pending)
+	}
+	ind := s.com.Styles.Status.WarnIndicator.String()
+	messageWidth := area.Dx() - lipgloss.Width(ind)
+	msg := ansi.Truncate(text, messageWidth, "…")
+	banner := s.com.Styles.Status.WarnMessage.Width(messageWidth).Render(msg)
+	uv.NewStyledString(ind+banner).Draw(scr, area)
+}
+
+// drawContextMeter draws the context usage meter at the right edge of the
+// status bar.
+func (s *Status) drawContextMeter(scr uv.Screen, area uv.Rectangle) {
+	if s.usage.ContextWindow <= 0 {
+		return
+	}
+	style := s.com.Styles.Status.Context
+	if s.usage.NearLimit() {
+		style = s.com.Styles.Status.ContextWarn
+	}
+	meter := style.Render(fmt.Sprintf("%d%% · %s/%s",
+		int(s.usage.Percentage),
+		formatTokenCount(s.usage.PromptTokens),
+		formatTokenCount(s.usage.ContextWindow),
+	))
+	width := lipgloss.Width(meter)
+	if width >= area.Dx() {
+		return
+	}
+	meterArea := area
+	meterArea.Min.X = area.Max.X - width
+	uv.NewStyledString(meter).Draw(scr, meterArea)
+}
+
+// formatTokenCount formats a token count with K/M units.
+func formatTokenCount(tokens int64) string {
+	var formatted string
+	switch {
+	case tokens >= 1_000_000:
+		formatted = fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
+	case tokens >= 1_000:
+		formatted = fmt.Sprintf("%.1fK", float64(tokens)/1_000)
+	default:
+		return fmt.Sprintf("%d", tokens)
+	}
+	formatted = strings.Replace(formatted, ".0K", "K", 1)
+	return strings.Replace(formatted, ".0M", "M", 1)
+}
+
 // clearInfoMsgCmd returns a command that clears the info message after the
 // given TTL.
 func clearInfoMsgCmd(ttl time.Duration) tea.Cmd {
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"fmt"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/dialog"
+	"github.com/charmbracelet/crush/internal/ui/util"
+)
+
+// openThemesDialog opens the theme picker.
+func (m *UI) openThemesDialog() {
+	if m.dialog.ContainsDialog(dialog.ThemesID) {
+		m.dialog.BringToFront(dialog.ThemesID)
+		return
+	}
+	var current string
+	if opts := m.com.Config().Options; opts != nil && opts.TUI != nil {
+		current = opts.TUI.Theme
+	}
+	m.dialog.OpenDialog(dialog.NewThemes(m.com, current))
+}
+
+// selectTheme restyles the UI in the theme called name and records it in
+// the configuration.
+func (m *UI) selectTheme(name string) tea.Cmd {
+	s, err := m.com.Themes.Styles(name)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	// Components share the styles through the pointer; those that copied
+	// them are given the new ones, and the chat is rebuilt to drop the
+	// messages rendered in the old theme.
+	*m.com.Styles = s
+	common.ClearRenderCache()
+	m.textarea.SetStyles(s.TextArea)
+	if m.vim != nil {
+		m.vim.applyCursor(&m.textarea)
+	}
+	if m.sidePane != nil {
+		m.sidePane.lines = nil
+	}
+	m.status.help.Styles = s.Help
+
+	cmds := []tea.Cmd{util.ReportInfo(fmt.Sprintf("Switched to the %s theme", name))}
+	if err := m.com.Config().SetTheme(name); err != nil {
+		cmds = append(cmds, util.ReportError(fmt.Errorf("failed to save theme: %w", err)))
+	}
+	if m.session != nil {
+		cmds = append(cmds, m.loadSession(m.session.ID))
+	}
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"log/slog"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/ui/dialog"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+// openTourDialog opens the tour of the interface.
+func (m *UI) openTourDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.TourID) {
+		m.dialog.BringToFront(dialog.TourID)
+		return nil
+	}
+	tour := dialog.NewTour(m.com)
+	tour.SetTargets(m.tourTargets())
+	m.dialog.OpenDialog(tour)
+	return nil
+}
+
+// startTourOnFirstRun opens the tour unless it was already taken.
+func (m *UI) startTourOnFirstRun() {
+	if m.com.Config().Options.TUI.TourSeen || m.dialog.HasDialogs() {
+		return
+	}
+	m.openTourDialog()
+}
+
+// finishTour closes the tour and records it was taken.
+func (m *UI) finishTour() {
+	m.dialog.CloseDialog(dialog.TourID)
+	if err := m.com.Config().SetTourSeen(); err != nil {
+		slog.Warn("Failed to record the tour was taken", "error", err)
+	}
+}
+
+// updateTourTargets keeps the parts the tour highlights in line with the
+// layout.
+func (m *UI) updateTourTargets() {
+	if tour, ok := m.dialog.Dialog(dialog.TourID).(*dialog.Tour); ok {
+		tour.SetTargets(m.tourTargets())
+	}
+}
+
+// tourTargets returns where the parts of the interface the tour highlights
+// are on the screen.
+func (m *UI) tourTargets() map[dialog.TourTarget]uv.Rectangle {
+	targets := map[dialog.TourTarget]uv.Rectangle{
+		dialog.TourTargetMain:   m.layout.main,
+		dialog.TourTargetEditor: m.layout.editor,
+		dialog.TourTargetStatus: m.layout.status,
+	}
+	if m.state == uiChat && !m.isCompact {
+		targets[dialog.TourTargetSidebar] = m.layout.sidebar
+	}
+	return targets
+}
@@ -24,18 +24,26 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/catwalk/pkg/catwalk"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent"
 	agenttools "github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/annotation"
 	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/artifact"
+	"github.com/charmbracelet/crush/internal/batch"
 	"github.com/charmbracelet/crush/internal/commands"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/connectivity"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/home"
 	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/notify"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/staging"
+	"github.com/charmbracelet/crush/internal/toolstats"
 	"github.com/charmbracelet/crush/internal/ui/anim"
 	"github.com/charmbracelet/crush/internal/ui/attachments"
 	"github.com/charmbracelet/crush/internal/ui/chat"
@@ -89,8 +97,18 @@ const (
 	uiChat
 )
 
+// openEditorMsg carries a prompt composed in $EDITOR.
 type openEditorMsg struct {
 	Text string
+	// Listed are the files the frontmatter listed when the editor opened,
+	// and Attachments those it lists now.
+	Listed      []string
+	Attachments []message.Attachment
+	// Err is set when the frontmatter could not be parsed, leaving the
+	// attachments as they were; AttachErr when some files could not be
+	// attached.
+	Err       error
+	AttachErr error
 }
 
 type (
@@ -135,8 +153,26 @@ type UI struct {
 
 	// keeps track of read files while we don't have a session id
 	sessionFileReads []string
+	// chatOnly makes the next new session chat-only.
+	chatOnly bool
+	// translate makes the next new session translate the conversation.
+	translate bool
+	// rawHistory, when set, sends the next message raw with this much of
+	// the session.
+	rawHistory *agent.RawHistory
+	// editing, when set, sends the editor's content in place of an earlier
+	// message of the session.
+	editing *editedMessage
+	// annotations are the line comments sent with the next message.
+	annotations []annotation.Annotation
+
+	// scrollback tracks the pages of the session's messages in the chat.
+	scrollback scrollback
 
 	lastUserMessageTime int64
+	// lastMessageModel is the provider and model the latest message of the
+	// session was sent to or answered by, to mark where it changes.
+	lastMessageModel string
 
 	// The width and height of the terminal in cells.
 	width  int
@@ -151,6 +187,9 @@ type UI struct {
 	keyMap KeyMap
 	keyenh tea.KeyboardEnhancementsMsg
 
+	// vim edits the prompt with vim keys; nil unless vim mode is on.
+	vim *vim
+
 	dialog *dialog.Overlay
 	status *Status
 
@@ -164,6 +203,14 @@ type UI struct {
 	sendProgressBar    bool
 	progressBarEnabled bool
 
+	// notifier notifies what happens while the terminal is unfocused, if
+	// notifications are enabled.
+	notifier *notify.Notifier
+	// blurred is whether the terminal reported losing focus.
+	blurred bool
+	// notifiedTurn is the last reply whose turn end was notified.
+	notifiedTurn string
+
 	// caps hold different terminal capabilities that we query for.
 	caps common.Capabilities
 
@@ -173,6 +220,14 @@ type UI struct {
 	// Attachment list
 	attachments *attachments.Attachments
 
+	// awaitingClipboardImage is set while an OSC 52 clipboard read requested
+	// by an image paste is pending.
+	awaitingClipboardImage bool
+	// kittyClipboard collects the image of a pending kitty clipboard read.
+	kittyClipboard *kittyClipboardReader
+	// pastes holds the images pasted from the clipboard.
+	pastes *pasteDir
+
 	readyPlaceholder   string
 	workingPlaceholder string
 
@@ -183,6 +238,12 @@ type UI struct {
 	completionsQuery         string
 	completionsPositionStart image.Point // x,y where user typed '@'
 
+	// Autocomplete state: the suggestion of the small model for the draft
+	// suggestionFor.
+	suggestion      string
+	suggestionFor   string
+	autocompleteSeq int
+
 	// Chat components
 	chat *Chat
 
@@ -207,6 +268,11 @@ type UI struct {
 	// forceCompactMode tracks whether compact mode is forced by user toggle
 	forceCompactMode bool
 
+	// sidePaneOpen tracks whether the pane showing the last diff or file
+	// the agent touched is open, and sidePane is what it shows.
+	sidePaneOpen bool
+	sidePane     *sidePane
+
 	// isCompact tracks whether we're currently in compact layout mode (either
 	// by user toggle or auto-switch based on window size)
 	isCompact bool
@@ -247,7 +313,10 @@ func New(com *common.Common) *UI {
 
 	ch := NewChat(com)
 
-	keyMap := DefaultKeyMap()
+	keyMap, err := LoadKeyMap(config.GlobalKeymap())
+	if err != nil {
+		slog.Warn("Failed to load keymap", "error", err)
+	}
 
 	// Completions component
 	comp := completions.New(
@@ -290,6 +359,7 @@ func New(com *common.Common) *UI {
 		todoSpinner: todoSpinner,
 		lspStates:   make(map[string]app.LSPClientInfo),
 		mcpStates:   make(map[string]mcp.ClientInfo),
+		pastes:      &pasteDir{},
 	}
 
 	status := NewStatus(com, ui)
@@ -301,6 +371,7 @@ func New(com *common.Common) *UI {
 
 	// Initialize compact mode from config
 	ui.forceCompactMode = com.Config().Options.TUI.CompactMode
+	ui.sidePaneOpen = com.Config().Options.TUI.SidePane
 
 	// set onboarding state defaults
 	ui.onboarding.yesInitializeSelected = true
@@ -320,12 +391,29 @@ func New(com *common.Common) *UI {
 
 	// disable indeterminate progress bar
 	ui.progressBarEnabled = opts.Progress == nil || *opts.Progress
+	// notify what happens while the terminal is unfocused
+	ui.notifier = notify.New(com.Config())
 	// enable transparent mode
 	ui.isTransparent = opts.TUI.Transparent != nil && *opts.TUI.Transparent
+	// enable viewing diagrams
+	ui.keyMap.Chat.Diagrams.SetEnabled(opts.TUI.Diagrams != nil && *opts.TUI.Diagrams)
+	// edit the prompt with vim keys
+	if opts.TUI.VimMode != nil && *opts.TUI.VimMode {
+		ui.vim = &vim{}
+		ui.vim.applyCursor(&ui.textarea)
+	}
 
 	return ui
 }
 
+// Close removes the images pasted into the prompt. Call it once the program
+// has exited.
+func (m *UI) Close() {
+	if err := m.pastes.remove(); err != nil {
+		slog.Warn("Failed to remove pasted images", "error", err)
+	}
+}
+
 // Init initializes the UI model.
 func (m *UI) Init() tea.Cmd {
 	var cmds []tea.Cmd
@@ -333,11 +421,15 @@ func (m *UI) Init() tea.Cmd {
 		if cmd := m.openModelsDialog(); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+	} else if id := m.com.App.InitialSessionID(); id != "" {
+		// Started with --continue.
+		cmds = append(cmds, m.loadSession(id))
 	}
 	// load the user commands async
 	cmds = append(cmds, m.loadCustomCommands())
 	// load prompt history async
 	cmds = append(cmds, m.loadPromptHistory())
+	cmds = append(cmds, processesTick())
 	return tea.Batch(cmds...)
 }
 
@@ -351,6 +443,9 @@ func (m *UI) setState(state uiState, focus uiFocusState) {
 	m.focus = focus
 	// Changing the state may change layout, so update it.
 	m.updateLayoutAndSize()
+	if state == uiLanding || state == uiChat {
+		m.startTourOnFirstRun()
+	}
 }
 
 // loadCustomCommands loads the custom commands asynchronously.
@@ -397,6 +492,12 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		cmds = append(cmds, common.QueryCmd(uv.Environ(msg)))
 	case loadSessionMsg:
+		if m.editing != nil && m.editing.sessionID != msg.session.ID {
+			m.cancelEdit()
+		}
+		if m.session != nil && m.session.ID != msg.session.ID {
+			m.annotations = nil
+		}
 		if m.forceCompactMode {
 			m.isCompact = true
 		}
@@ -404,14 +505,15 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.session = msg.session
 		m.sessionFiles = msg.files
 		cmds = append(cmds, m.startLSPs(msg.lspFilePaths()))
-		msgs, err := m.com.App.Messages.List(context.Background(), m.session.ID)
+		msgs, err := m.com.App.Messages.ListPage(context.Background(), m.session.ID, "", messagePageSize)
 		if err != nil {
 			cmds = append(cmds, util.ReportError(err))
 			break
 		}
-		if cmd := m.setSessionMessages(msgs); cmd != nil {
+		if cmd := m.setSessionPage(msgs); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+		m.setSidePaneFromMessages(msgs)
 		if hasInProgressTodo(m.session.Todos) {
 			// only start spinner if there is an in-progress todo
 			if m.isAgentBusy() {
@@ -450,6 +552,12 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case mcpStateChangedMsg:
 		m.mcpStates = msg.states
+	case autocompleteTickMsg:
+		if cmd := m.requestAutocomplete(msg); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case autocompleteMsg:
+		m.setSuggestion(msg)
 	case mcpPromptsLoadedMsg:
 		m.mcpPrompts = msg.Prompts
 		dia := m.dialog.Dialog(dialog.CommandsID)
@@ -503,10 +611,18 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.Type {
 		case pubsub.CreatedEvent:
 			cmds = append(cmds, m.appendSessionMessage(msg.Payload))
+			m.updateSidePane(msg.Payload)
 		case pubsub.UpdatedEvent:
 			cmds = append(cmds, m.updateSessionMessage(msg.Payload))
+			m.updateSidePane(msg.Payload)
+			if cmd := m.notifyTurnEnd(msg.Payload); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
 		case pubsub.DeletedEvent:
 			m.chat.RemoveMessage(msg.Payload.ID)
+			if m.chat.MessageItem(chat.ModelSwitchID(msg.Payload.ID)) != nil {
+				m.chat.RemoveMessage(chat.ModelSwitchID(msg.Payload.ID))
+			}
 		}
 		// start the spinner if there is a new message
 		if hasInProgressTodo(m.session.Todos) && m.isAgentBusy() && !m.todoIsSpinning {
@@ -537,14 +653,54 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case mcp.EventResourcesListChanged:
 			return m, handleMCPResourcesEvent(msg.Payload.Name)
 		}
+	case pubsub.Event[agent.CompactedEvent]:
+		if m.session != nil && msg.Payload.SessionID == m.session.ID {
+			switch {
+			case msg.Payload.Overflow:
+				cmds = append(cmds, util.ReportWarn(fmt.Sprintf("Context window exceeded: %d earlier messages summarized, retrying", msg.Payload.Messages)))
+			case msg.Payload.Automatic:
+				cmds = append(cmds, util.ReportInfo("Session compacted to stay within the context window"))
+			}
+		}
+	case pubsub.Event[connectivity.Event]:
+		wasOffline := m.status.Offline()
+		m.status.SetConnectivity(msg.Payload)
+		if msg.Payload.Online && wasOffline {
+			cmds = append(cmds, util.ReportInfo("Connection is back"))
+		}
+	case pubsub.Event[toolstats.Warning]:
+		if m.session != nil && msg.Payload.SessionID == m.session.ID {
+			cmds = append(cmds, util.ReportWarn(msg.Payload.Message()))
+		}
 	case pubsub.Event[permission.PermissionRequest]:
 		if cmd := m.openPermissionsDialog(msg.Payload); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+		if cmd := m.notifyPermission(msg.Payload); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	case pubsub.Event[permission.PermissionNotification]:
 		m.handlePermissionNotification(msg.Payload)
+	case pubsub.Event[staging.Edit]:
+		if cmd := m.handleStagedEditEvent(msg); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case pubsub.Event[artifact.Artifact]:
+		if cmd := m.handleArtifactEvent(msg); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case commitMessageMsg:
+		m.showCommitMessage(msg.Message)
+	case sessionSharedMsg:
+		cmds = append(cmds, common.CopyToClipboard(msg.Link, fmt.Sprintf("Shared %s (link copied)", msg.Link)))
+	case stagedEditChangedMsg:
+		if err := m.com.App.UpdateEdit(msg.ID, msg.Content); err != nil {
+			cmds = append(cmds, util.ReportError(err))
+		}
 	case cancelTimerExpiredMsg:
 		m.isCanceling = false
+	case processesTickMsg:
+		cmds = append(cmds, processesTick())
 	case tea.TerminalVersionMsg:
 		termVersion := strings.ToLower(msg.Name)
 		// Only enable progress bar for the following terminals.
@@ -558,9 +714,22 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyboardEnhancementsMsg:
 		m.keyenh = msg
 		if msg.SupportsKeyDisambiguation() {
-			m.keyMap.Models.SetHelp("ctrl+m", "models")
-			m.keyMap.Editor.Newline.SetHelp("shift+enter", "newline")
+			// Keys rebound in the keymap file keep the help they were given.
+			if slices.Contains(m.keyMap.Models.Keys(), "ctrl+m") {
+				m.keyMap.Models.SetHelp("ctrl+m", "models")
+			}
+			if slices.Contains(m.keyMap.Editor.Newline.Keys(), "shift+enter") {
+				m.keyMap.Editor.Newline.SetHelp("shift+enter", "newline")
+			}
+		}
+	case olderMessagesMsg:
+		if cmd := m.prependOlderMessages(msg); cmd != nil {
+			cmds = append(cmds, cmd)
 		}
+	case tea.FocusMsg:
+		m.blurred = false
+	case tea.BlurMsg:
+		m.blurred = true
 	case copyChatHighlightMsg:
 		cmds = append(cmds, m.copyChatHighlight())
 	case DelayedClickMsg:
@@ -660,6 +829,17 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Batch(cmds...)
 		}
 
+		// Scroll the changes pane under the pointer.
+		if m.state == uiChat && image.Pt(msg.X, msg.Y).In(m.layout.pane) {
+			switch msg.Button {
+			case tea.MouseWheelUp:
+				m.scrollSidePane(-MouseScrollThreshold)
+			case tea.MouseWheelDown:
+				m.scrollSidePane(MouseScrollThreshold)
+			}
+			return m, tea.Batch(cmds...)
+		}
+
 		// Otherwise handle mouse wheel for chat.
 		switch m.state {
 		case uiChat:
@@ -716,12 +896,27 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if cmd := m.handlePasteMsg(msg); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+	case tea.ClipboardMsg:
+		if m.awaitingClipboardImage {
+			m.awaitingClipboardImage = false
+			if isOSC52Image(msg.Content) {
+				content := []byte(msg.Content)
+				cmds = append(cmds, func() tea.Msg {
+					return m.newPastedImage(content)
+				})
+			}
+		}
+	case uv.UnknownOscEvent:
+		if m.kittyClipboard != nil {
+			if cmd := m.handleKittyClipboard(string(msg)); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
+	case pastedImageMsg:
+		m.textarea.InsertString(fmt.Sprintf("[%s] ", msg.attachment.FileName))
+		m.attachments.Update(msg.attachment)
 	case openEditorMsg:
-		var cmd tea.Cmd
-		m.textarea.SetValue(msg.Text)
-		m.textarea.MoveToEnd()
-		m.textarea, cmd = m.textarea.Update(msg)
-		if cmd != nil {
+		if cmd := m.setComposedPrompt(msg); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
 	case util.InfoMsg:
@@ -764,6 +959,27 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.com.App.Permissions.SkipRequests() {
 			m.textarea.Placeholder = "Yolo mode!"
 		}
+		switch {
+		case m.editing != nil:
+			m.textarea.Placeholder = "Editing a message: enter to send it again, esc to cancel"
+		case len(m.annotations) > 0:
+			m.textarea.Placeholder = fmt.Sprintf("%d line comments go with your next message", len(m.annotations))
+		case m.promptQueue > 0 && m.isAgentBusy():
+			m.textarea.Placeholder = fmt.Sprintf("%d queued: enter to queue another, alt+enter to interrupt", m.promptQueue)
+		case m.rawHistory != nil:
+			m.textarea.Placeholder = "Raw: no system prompt or tools for the next message"
+		case m.isChatOnly():
+			m.textarea.Placeholder = "Chat only: no access to files or the shell"
+		case m.com.App.Mode() == agenttools.ModePlan:
+			m.textarea.Placeholder = "Plan mode: edits are proposed, not applied"
+		case m.com.App.Mode() == agenttools.ModeReview:
+			m.textarea.Placeholder = "Review mode: edits are staged for you to apply"
+		}
+	}
+
+	// Page in older messages once the chat is scrolled up to them.
+	if cmd := m.loadOlderMessages(); cmd != nil {
+		cmds = append(cmds, cmd)
 	}
 
 	// at this point this can only handle [message.Attachment] message, and we
@@ -775,12 +991,44 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 // setSessionMessages sets the messages for the current session in the chat
 func (m *UI) setSessionMessages(msgs []message.Message) tea.Cmd {
 	var cmds []tea.Cmd
+	m.lastMessageModel = ""
+	items := m.sessionMessageItems(msgs, nil)
+
+	// Load nested tool calls for agent/agentic_fetch tools.
+	m.loadNestedToolCalls(items)
+
+	// If the user switches between sessions while the agent is working we want
+	// to make sure the animations are shown.
+	for _, item := range items {
+		if animatable, ok := item.(chat.Animatable); ok {
+			if cmd := animatable.StartAnimation(); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
+	}
+
+	m.chat.SetMessages(items...)
+	if cmd := m.chat.ScrollToBottomAndAnimate(); cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+	m.chat.SelectLast()
+	return tea.Batch(cmds...)
+}
+
+// sessionMessageItems returns the chat items of msgs, linking tool calls
+// with their results. Results in next, the message following msgs, are
+// linked too, for calls whose results were paged in before them.
+func (m *UI) sessionMessageItems(msgs []message.Message, next *message.Message) []chat.MessageItem {
 	// Build tool result map to link tool calls with their results
 	msgPtrs := make([]*message.Message, len(msgs))
 	for i := range msgs {
 		msgPtrs[i] = &msgs[i]
 	}
+	if next != nil {
+		msgPtrs = append(msgPtrs, next)
+	}
 	toolResultMap := chat.BuildToolResultMap(msgPtrs)
+	msgPtrs = msgPtrs[:len(msgs)]
 	if len(msgPtrs) > 0 {
 		m.lastUserMessageTime = msgPtrs[0].CreatedAt
 	}
@@ -791,8 +1039,12 @@ func (m *UI) setSessionMessages(msgs []message.Message) tea.Cmd {
 		switch msg.Role {
 		case message.User:
 			m.lastUserMessageTime = msg.CreatedAt
+			if item := m.modelSwitchItem(msg); item != nil {
+				items = append(items, item)
+			}
 			items = append(items, chat.ExtractMessageItems(m.com.Styles, msg, toolResultMap)...)
 		case message.Assistant:
+			m.modelSwitchItem(msg)
 			items = append(items, chat.ExtractMessageItems(m.com.Styles, msg, toolResultMap)...)
 			if msg.FinishPart() != nil && msg.FinishPart().Reason == message.FinishReasonEndTurn {
 				infoItem := chat.NewAssistantInfoItem(m.com.Styles, msg, m.com.Config(), time.Unix(m.lastUserMessageTime, 0))
@@ -802,26 +1054,7 @@ func (m *UI) setSessionMessages(msgs []message.Message) tea.Cmd {
 			items = append(items, chat.ExtractMessageItems(m.com.Styles, msg, toolResultMap)...)
 		}
 	}
-
-	// Load nested tool calls for agent/agentic_fetch tools.
-	m.loadNestedToolCalls(items)
-
-	// If the user switches between sessions while the agent is working we want
-	// to make sure the animations are shown.
-	for _, item := range items {
-		if animatable, ok := item.(chat.Animatable); ok {
-			if cmd := animatable.StartAnimation(); cmd != nil {
-				cmds = append(cmds, cmd)
-			}
-		}
-	}
-
-	m.chat.SetMessages(items...)
-	if cmd := m.chat.ScrollToBottomAndAnimate(); cmd != nil {
-		cmds = append(cmds, cmd)
-	}
-	m.chat.SelectLast()
-	return tea.Batch(cmds...)
+	return items
 }
 
 // loadNestedToolCalls recursively loads nested tool calls for agent/agentic_fetch tools.
@@ -882,6 +1115,22 @@ func (m *UI) loadNestedToolCalls(items []chat.MessageItem) {
 	}
 }
 
+// modelSwitchItem keeps track of the model the messages of the session were
+// sent to, in order, and returns an item marking the switch when msg is the
+// first message sent to another model.
+func (m *UI) modelSwitchItem(msg *message.Message) chat.MessageItem {
+	if msg.Model == "" || msg.IsSummaryMessage {
+		return nil
+	}
+	model := msg.Provider + "/" + msg.Model
+	previous := m.lastMessageModel
+	m.lastMessageModel = model
+	if msg.Role != message.User || previous == "" || previous == model {
+		return nil
+	}
+	return chat.NewModelSwitchItem(m.com.Styles, msg, m.com.Config())
+}
+
 // appendSessionMessage appends a new message to the current session in the chat
 // if the message is a tool result it will update the corresponding tool call message
 func (m *UI) appendSessionMessage(msg message.Message) tea.Cmd {
@@ -897,6 +1146,9 @@ func (m *UI) appendSessionMessage(msg message.Message) tea.Cmd {
 	case message.User:
 		m.lastUserMessageTime = msg.CreatedAt
 		items := chat.ExtractMessageItems(m.com.Styles, &msg, nil)
+		if item := m.modelSwitchItem(&msg); item != nil {
+			items = append([]chat.MessageItem{item}, items...)
+		}
 		for _, item := range items {
 			if animatable, ok := item.(chat.Animatable); ok {
 				if cmd := animatable.StartAnimation(); cmd != nil {
@@ -909,6 +1161,7 @@ func (m *UI) appendSessionMessage(msg message.Message) tea.Cmd {
 			cmds = append(cmds, cmd)
 		}
 	case message.Assistant:
+		m.modelSwitchItem(&msg)
 		items := chat.ExtractMessageItems(m.com.Styles, &msg, nil)
 		for _, item := range items {
 			if animatable, ok := item.(chat.Animatable); ok {
@@ -1180,6 +1433,56 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 		m.com.App.Permissions.SetSkipRequests(yolo)
 		m.setEditorPrompt(yolo)
 		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionTogglePlanMode:
+		cmds = append(cmds, m.toggleMode(agenttools.ModePlan))
+		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionToggleReviewMode:
+		cmds = append(cmds, m.toggleMode(agenttools.ModeReview))
+		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionToggleChatOnly:
+		cmds = append(cmds, m.toggleChatOnly())
+	case dialog.ActionToggleTranslate:
+		cmds = append(cmds, m.toggleTranslate())
+		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionTrimAttachments:
+		m.dialog.CloseDialog(dialog.AttachmentBudgetID)
+		cmds = append(cmds, m.sendTrimmedDraft(msg.Strategy))
+	case dialog.ActionSetAnnotations:
+		m.annotations = msg.Annotations
+	case dialog.ActionSendBatch:
+		m.dialog.CloseDialog(dialog.BatchID)
+		cmds = append(cmds, m.sendMessage(batch.Task(msg.Instruction, msg.Sites)))
+	case dialog.ActionSendRaw:
+		m.dialog.CloseDialog(dialog.RawMessageID)
+		m.rawHistory = nil
+		if !msg.Cancel {
+			m.rawHistory = &msg.History
+		}
+	case dialog.ActionApplyStagedEdits:
+		cmds = append(cmds, m.applyStagedEdits(msg.IDs))
+	case dialog.ActionDiscardStagedEdit:
+		if err := m.com.App.DiscardEdit(msg.ID); err != nil {
+			cmds = append(cmds, util.ReportError(err))
+		}
+	case dialog.ActionWriteCommitMessage:
+		m.dialog.CloseDialog(dialog.CommandsID)
+		cmds = append(cmds, m.writeCommitMessage())
+	case dialog.ActionCommit:
+		m.dialog.CloseDialog(dialog.CommitID)
+		cmds = append(cmds, m.commit(msg.Message))
+	case dialog.ActionEditCommitMessage:
+		cmds = append(cmds, m.editCommitMessage(msg.Message))
+	case dialog.ActionEditStagedEdit:
+		cmds = append(cmds, m.editStagedEdit(msg.Edit))
+	case dialog.ActionQuickAction:
+		m.dialog.CloseDialog(dialog.QuickActionsID)
+		cmds = append(cmds, m.runQuickAction(msg.Action))
+	case dialog.ActionTourDone:
+		m.finishTour()
+	case dialog.ActionOpenArtifact:
+		cmds = append(cmds, m.openArtifact(msg.Artifact))
+	case dialog.ActionExportArtifact:
+		cmds = append(cmds, m.exportArtifact(msg.Artifact))
 	case dialog.ActionNewSession:
 		if m.isAgentBusy() {
 			cmds = append(cmds, util.ReportWarn("Agent is busy, please wait before starting a new session..."))
@@ -1189,6 +1492,34 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			cmds = append(cmds, cmd)
 		}
 		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionRenameSession:
+		m.dialog.CloseDialog(dialog.CommandsID)
+		if cmd := m.openSessionsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+		if sessions, ok := m.dialog.Dialog(dialog.SessionsID).(*dialog.Session); ok {
+			sessions.StartRename()
+		}
+	case dialog.ActionExportSession:
+		m.dialog.CloseDialog(dialog.CommandsID)
+		cmds = append(cmds, m.exportSession(msg.SessionID, msg.Format))
+	case dialog.ActionSelectTheme:
+		m.dialog.CloseDialog(dialog.ThemesID)
+		cmds = append(cmds, m.selectTheme(msg.Name))
+	case dialog.ActionConfirmShare:
+		m.dialog.CloseDialog(dialog.CommandsID)
+		m.openShareDialog(msg.SessionID)
+	case dialog.ActionShareSession:
+		m.dialog.CloseDialog(dialog.ShareID)
+		cmds = append(cmds, m.shareSession(msg.SessionID))
+	case dialog.ActionLabelSession:
+		if msg.Args == nil {
+			m.dialog.CloseFrontDialog()
+			m.dialog.OpenDialog(m.newLabelDialog(msg))
+			break
+		}
+		m.dialog.CloseFrontDialog()
+		cmds = append(cmds, m.labelSession(msg.SessionID, msg.Args["label"]))
 	case dialog.ActionSummarize:
 		if m.isAgentBusy() {
 			cmds = append(cmds, util.ReportWarn("Agent is busy, please wait before summarizing session..."))
@@ -1215,6 +1546,12 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 	case dialog.ActionToggleCompactMode:
 		cmds = append(cmds, m.toggleCompactMode())
 		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionCopy:
+		m.dialog.CloseDialog(dialog.CopyID)
+		cmds = append(cmds, common.CopyToClipboard(msg.Text, msg.Title+" copied to clipboard"))
+	case dialog.ActionToggleSidePane:
+		cmds = append(cmds, m.toggleSidePane())
+		m.dialog.CloseDialog(dialog.CommandsID)
 	case dialog.ActionTogglePills:
 		if cmd := m.togglePillsExpanded(); cmd != nil {
 			cmds = append(cmds, cmd)
@@ -1354,6 +1691,8 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			m.com.App.Permissions.Grant(msg.Permission)
 		case dialog.PermissionAllowForSession:
 			m.com.App.Permissions.GrantPersistent(msg.Permission)
+		case dialog.PermissionAllowForProject:
+			m.com.App.Permissions.GrantForProject(msg.Permission)
 		case dialog.PermissionDeny:
 			m.com.App.Permissions.Deny(msg.Permission)
 		}
@@ -1499,6 +1838,14 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 				}
 				return true
 			}
+		case key.Matches(msg, m.keyMap.Mode):
+			cmds = append(cmds, m.setMode(nextMode(m.com.App.Mode())))
+			return true
+		case key.Matches(msg, m.keyMap.SidePane):
+			if m.state == uiChat {
+				cmds = append(cmds, m.toggleSidePane())
+				return true
+			}
 		case key.Matches(msg, m.keyMap.Suspend):
 			if m.isAgentBusy() {
 				cmds = append(cmds, util.ReportWarn("Agent is busy, please wait..."))
@@ -1524,8 +1871,9 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 		return m.handleDialogMsg(msg)
 	}
 
-	// Handle cancel key when agent is busy.
-	if key.Matches(msg, m.keyMap.Chat.Cancel) {
+	// Handle cancel key when agent is busy. In vim insert mode the key
+	// leaves insert mode first.
+	if key.Matches(msg, m.keyMap.Chat.Cancel) && !m.vimInsertEscape(msg) {
 		if m.isAgentBusy() {
 			if cmd := m.cancelAgent(); cmd != nil {
 				cmds = append(cmds, cmd)
@@ -1568,6 +1916,10 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 				return tea.Batch(cmds...)
 			}
 
+			if m.vim != nil && m.vim.HandleKey(&m.textarea, msg) {
+				return tea.Batch(cmds...)
+			}
+
 			switch {
 			case key.Matches(msg, m.keyMap.Editor.AddImage):
 				if cmd := m.openFilesDialog(); cmd != nil {
@@ -1576,7 +1928,24 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 
 			case key.Matches(msg, m.keyMap.Editor.PasteImage):
 				cmds = append(cmds, m.pasteImageFromClipboard)
+				if isRemoteSession() {
+					// The native clipboard belongs to the remote host; ask the
+					// local terminal for its clipboard as well, with the
+					// kitty clipboard protocol where it is spoken, as OSC 52
+					// is meant for text.
+					if m.supportsKittyClipboard() {
+						m.kittyClipboard = &kittyClipboardReader{}
+						cmds = append(cmds, tea.Raw(kittyClipboardRequest))
+					} else {
+						m.awaitingClipboardImage = true
+						cmds = append(cmds, tea.ReadClipboard)
+					}
+				}
 
+			case key.Matches(msg, m.keyMap.Editor.Interrupt):
+				if cmd := m.interruptWithDraft(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
 			case key.Matches(msg, m.keyMap.Editor.SendMessage):
 				value := m.textarea.Value()
 				if before, ok := strings.CutSuffix(value, "\\"); ok {
@@ -1585,14 +1954,28 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 					break
 				}
 
+				// Attachments over the budget keep the draft in the editor while
+				// the user picks how to trim them.
+				if cmd := m.checkAttachmentBudget(); cmd != nil {
+					return cmd
+				}
+
 				// Otherwise, send the message
 				m.textarea.Reset()
+				if m.vim != nil {
+					m.vim.Reset(&m.textarea)
+				}
 
 				value = strings.TrimSpace(value)
 				if value == "exit" || value == "quit" {
 					return m.openQuitDialog()
 				}
 
+				if len(m.annotations) > 0 {
+					value = annotation.AppendToPrompt(value, m.annotations)
+					m.annotations = nil
+				}
+
 				attachments := m.attachments.List()
 				m.attachments.Reset()
 				if len(value) == 0 && !message.ContainsTextAttachment(attachments) {
@@ -1602,6 +1985,10 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 				m.randomizePlaceholders()
 				m.historyReset()
 
+				if m.editing != nil {
+					return tea.Batch(m.sendEdit(value, attachments), m.loadPromptHistory())
+				}
+
 				return tea.Batch(m.sendMessage(value, attachments...), m.loadPromptHistory())
 			case key.Matches(msg, m.keyMap.Chat.NewSession):
 				if !m.hasSession() {
@@ -1615,6 +2002,9 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 					cmds = append(cmds, cmd)
 				}
 			case key.Matches(msg, m.keyMap.Tab):
+				if m.acceptSuggestion() {
+					break
+				}
 				if m.state != uiLanding {
 					m.setState(m.state, uiFocusMain)
 					m.textarea.Blur()
@@ -1643,6 +2033,8 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 				if cmd != nil {
 					cmds = append(cmds, cmd)
 				}
+			case key.Matches(msg, m.keyMap.Editor.Escape) && m.editing != nil:
+				m.cancelEdit()
 			case key.Matches(msg, m.keyMap.Editor.Escape):
 				cmd := m.handleHistoryEscape(msg)
 				if cmd != nil {
@@ -1687,6 +2079,9 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 
 				// Any text modification becomes the current draft.
 				m.updateHistoryDraft(curValue)
+				if m.textarea.Value() != curValue {
+					cmds = append(cmds, m.scheduleAutocomplete())
+				}
 
 				// After updating textarea, check if we need to filter completions.
 				// Skip filtering on the initial @ keystroke since items are loading async.
@@ -1732,6 +2127,24 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 				}
 			case key.Matches(msg, m.keyMap.Chat.Expand):
 				m.chat.ToggleExpandedSelectedItem()
+			case key.Matches(msg, m.keyMap.Chat.Edit):
+				cmds = append(cmds, m.editSelectedMessage(app.RegenerateTruncate))
+			case key.Matches(msg, m.keyMap.Chat.Branch):
+				cmds = append(cmds, m.editSelectedMessage(app.RegenerateBranch))
+			case key.Matches(msg, m.keyMap.Chat.Batch):
+				if cmd := m.openBatchDialog(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			case key.Matches(msg, m.keyMap.Chat.Diagrams):
+				cmds = append(cmds, m.viewDiagrams())
+			case key.Matches(msg, m.keyMap.Chat.CopyPart):
+				if cmd := m.openCopyDialog(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			case key.Matches(msg, m.keyMap.Chat.QuickActions):
+				if cmd := m.openQuickActionsDialog(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
 			case key.Matches(msg, m.keyMap.Chat.Up):
 				if cmd := m.chat.ScrollByAndAnimate(-1); cmd != nil {
 					cmds = append(cmds, cmd)
@@ -1853,6 +2266,7 @@ func (m *UI) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 
 		editor := uv.NewStyledString(m.renderEditorView(scr.Bounds().Dx()))
 		editor.Draw(scr, layout.editor)
+		m.drawSuggestion(scr)
 
 	case uiChat:
 		if m.isCompact {
@@ -1862,6 +2276,9 @@ func (m *UI) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 		}
 
 		m.chat.Draw(scr, layout.main)
+		if layout.pane.Dx() > 0 {
+			m.drawSidePane(scr, layout.pane)
+		}
 		if layout.pills.Dy() > 0 && m.pillsView != "" {
 			uv.NewStyledString(m.pillsView).Draw(scr, layout.pills)
 		}
@@ -1872,6 +2289,7 @@ func (m *UI) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 		}
 		editor := uv.NewStyledString(m.renderEditorView(editorWidth))
 		editor.Draw(scr, layout.editor)
+		m.drawSuggestion(scr)
 
 		// Draw details overlay in compact mode when open
 		if m.isCompact && m.detailsOpen {
@@ -1883,6 +2301,11 @@ func (m *UI) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 
 	// Add status and help layer
 	m.status.SetHideHelp(isOnboarding)
+	if isOnboarding {
+		m.status.SetContextUsage(nil)
+	} else {
+		m.status.SetContextUsage(m.contextUsage())
+	}
 	m.status.Draw(scr, layout.status)
 
 	// Draw completions popup if open
@@ -1951,6 +2374,7 @@ func (m *UI) View() tea.View {
 		v.BackgroundColor = m.com.Styles.Background
 	}
 	v.MouseMode = tea.MouseModeCellMotion
+	v.ReportFocus = m.notifier != nil
 	v.WindowTitle = "crush " + home.Short(m.com.Config().WorkingDir())
 
 	canvas := uv.NewScreenBuffer(m.width, m.height)
@@ -2024,6 +2448,7 @@ func (m *UI) ShortHelp() []key.Binding {
 				k.Chat.PageUp,
 				k.Chat.PageDown,
 				k.Chat.Copy,
+				k.Chat.Edit,
 			)
 			if m.pillsExpanded && hasIncompleteTodos(m.session.Todos) && m.promptQueue > 0 {
 				binds = append(binds, k.Chat.PillLeft)
@@ -2092,9 +2517,10 @@ func (m *UI) FullHelp() [][]key.Binding {
 			commands,
 			k.Models,
 			k.Sessions,
+			m.modeBinding(),
 		)
 		if hasSession {
-			mainBinds = append(mainBinds, k.Chat.NewSession)
+			mainBinds = append(mainBinds, k.Chat.NewSession, k.SidePane)
 		}
 
 		binds = append(binds, mainBinds)
@@ -2104,6 +2530,7 @@ func (m *UI) FullHelp() [][]key.Binding {
 			binds = append(binds,
 				[]key.Binding{
 					k.Editor.Newline,
+					k.Editor.Interrupt,
 					k.Editor.AddImage,
 					k.Editor.PasteImage,
 					k.Editor.MentionFile,
@@ -2135,6 +2562,12 @@ func (m *UI) FullHelp() [][]key.Binding {
 				},
 				[]key.Binding{
 					k.Chat.Copy,
+					k.Chat.CopyPart,
+					k.Chat.Edit,
+					k.Chat.Branch,
+					k.Chat.Batch,
+					k.Chat.Diagrams,
+					k.Chat.QuickActions,
 					k.Chat.ClearHighlight,
 				},
 			)
@@ -2150,6 +2583,7 @@ func (m *UI) FullHelp() [][]key.Binding {
 					commands,
 					k.Models,
 					k.Sessions,
+					m.modeBinding(),
 				},
 				[]key.Binding{
 					k.Editor.Newline,
@@ -2231,6 +2665,7 @@ func (m *UI) updateSize() {
 	// height here.
 	m.textarea.SetHeight(m.layout.editor.Dy() - 2) // Account for top margin/attachments and bottom margin
 	m.renderPills()
+	m.updateTourTargets()
 
 	// Handle different app states
 	switch m.state {
@@ -2366,6 +2801,14 @@ func (m *UI) generateLayout(w, h int) uiLayout {
 			mainRect, editorRect := layout.SplitVertical(mainRect, layout.Fixed(mainRect.Dy()-editorHeight))
 			mainRect.Max.X -= 1 // Add padding right
 			uiLayout.sidebar = sideRect
+			if m.sidePaneOpen && mainRect.Dx() >= sidePaneMinWidth {
+				// The changes pane takes the right of the chat, above the
+				// editor.
+				paneWidth := int(float64(mainRect.Dx()) * sidePaneRatio)
+				mainRect, uiLayout.pane = layout.SplitHorizontal(mainRect, layout.Fixed(mainRect.Dx()-paneWidth))
+				mainRect.Max.X -= 1 // Add padding between the chat and the pane
+				uiLayout.pane.Max.Y -= 1
+			}
 			pillsHeight := m.pillsAreaHeight()
 			if pillsHeight > 0 {
 				pillsHeight = min(pillsHeight, mainRect.Dy())
@@ -2407,6 +2850,9 @@ type uiLayout struct {
 	// sidebar is the area for the sidebar.
 	sidebar uv.Rectangle
 
+	// pane is the area for the changes pane, next to the chat.
+	pane uv.Rectangle
+
 	// status is the area for the status view.
 	status uv.Rectangle
 
@@ -2414,20 +2860,23 @@ type uiLayout struct {
 	sessionDetails uv.Rectangle
 }
 
+// openEditor opens value in $EDITOR, the attached files listed in its
+// frontmatter, and puts what was saved back in the editor.
 func (m *UI) openEditor(value string) tea.Cmd {
 	tmpfile, err := os.CreateTemp("", "msg_*.md")
 	if err != nil {
 		return util.ReportError(err)
 	}
 	defer tmpfile.Close() //nolint:errcheck
-	if _, err := tmpfile.WriteString(value); err != nil {
+	listed := m.attachedFiles()
+	if _, err := tmpfile.WriteString(composePrompt(value, listed)); err != nil {
 		return util.ReportError(err)
 	}
 	cmd, err := editor.Command(
 		"crush",
 		tmpfile.Name(),
 		editor.AtPosition(
-			m.textarea.Line()+1,
+			m.textarea.Line()+1+frontmatterLines(listed),
 			m.textarea.Column()+1,
 		),
 	)
@@ -2446,12 +2895,119 @@ func (m *UI) openEditor(value string) tea.Cmd {
 			return util.ReportWarn("Message is empty")
 		}
 		os.Remove(tmpfile.Name())
+		text, files, err := parsePrompt(string(content))
+		if err != nil {
+			return openEditorMsg{Text: text, Err: err}
+		}
+		attachments, attachErr := m.loadAttachments(files)
 		return openEditorMsg{
-			Text: strings.TrimSpace(string(content)),
+			Text:        text,
+			Listed:      listed,
+			Attachments: attachments,
+			AttachErr:   attachErr,
 		}
 	})
 }
 
+// nextMode returns the mode shift+tab switches to from mode.
+func nextMode(mode agenttools.Mode) agenttools.Mode {
+	switch mode {
+	case agenttools.ModeBuild:
+		return agenttools.ModePlan
+	case agenttools.ModePlan:
+		return agenttools.ModeReview
+	default:
+		return agenttools.ModeBuild
+	}
+}
+
+// toggleMode switches to mode, or back to build mode when it is on.
+func (m *UI) toggleMode(mode agenttools.Mode) tea.Cmd {
+	if m.com.App.Mode() == mode {
+		mode = agenttools.ModeBuild
+	}
+	return m.setMode(mode)
+}
+
+// setMode switches the agent's mode and says what it means.
+func (m *UI) setMode(mode agenttools.Mode) tea.Cmd {
+	if err := m.com.App.SetMode(mode); err != nil {
+		return util.ReportError(err)
+	}
+	switch mode {
+	case agenttools.ModePlan:
+		return util.ReportInfo("Plan mode: edits are proposed and only read-only commands run")
+	case agenttools.ModeReview:
+		return util.ReportInfo("Review mode: edits are staged until you apply them")
+	default:
+		return util.ReportInfo("Build mode: the agent can change files again")
+	}
+}
+
+// modeBinding returns the mode binding with help naming the mode it
+// switches to.
+func (m *UI) modeBinding() key.Binding {
+	binding := m.keyMap.Mode
+	binding.SetHelp("shift+tab", string(nextMode(m.com.App.Mode()))+" mode")
+	return binding
+}
+
+// isChatOnly reports whether the current session, or the next new one, is
+// chat-only.
+func (m *UI) isChatOnly() bool {
+	if m.hasSession() {
+		return m.session.ChatOnly
+	}
+	return m.chatOnly
+}
+
+// toggleChatOnly switches the current session, or the next new one, between
+// chat-only and full workspace access.
+func (m *UI) toggleChatOnly() tea.Cmd {
+	chatOnly := !m.isChatOnly()
+	if m.hasSession() {
+		updated, err := m.com.App.Sessions.SetChatOnly(context.Background(), m.session.ID, chatOnly)
+		if err != nil {
+			return util.ReportError(err)
+		}
+		m.session = &updated
+	} else {
+		m.chatOnly = chatOnly
+	}
+	if chatOnly {
+		return util.ReportInfo("Chat only: this session has no access to files or the shell")
+	}
+	return util.ReportInfo("This session can use the workspace again")
+}
+
+// isTranslating reports whether the current session, or the next new one,
+// translates the conversation.
+func (m *UI) isTranslating() bool {
+	if m.hasSession() {
+		return m.session.Translate
+	}
+	return m.translate
+}
+
+// toggleTranslate switches translating the conversation of the current
+// session, or the next new one, on or off.
+func (m *UI) toggleTranslate() tea.Cmd {
+	translate := !m.isTranslating()
+	if m.hasSession() {
+		updated, err := m.com.App.Sessions.SetTranslate(context.Background(), m.session.ID, translate)
+		if err != nil {
+			return util.ReportError(err)
+		}
+		m.session = &updated
+	} else {
+		m.translate = translate
+	}
+	if translate {
+		return util.ReportInfo("Translation on: prompts in other languages are sent in English and replies translated back")
+	}
+	return util.ReportInfo("Translation off")
+}
+
 // setEditorPrompt configures the textarea prompt function based on whether
 // yolo mode is enabled.
 func (m *UI) setEditorPrompt(yolo bool) {
@@ -2467,6 +3023,9 @@ func (m *UI) setEditorPrompt(yolo bool) {
 func (m *UI) normalPromptFunc(info textarea.PromptInfo) string {
 	t := m.com.Styles
 	if info.LineNumber == 0 {
+		if info.Focused && m.vim != nil && m.vim.mode == vimNormal {
+			return " N> "
+		}
 		if info.Focused {
 			return "  > "
 		}
@@ -2573,45 +3132,13 @@ func (m *UI) insertMCPResourceCompletion(item completions.ResourceCompletionValu
 	}
 
 	return func() tea.Msg {
-		contents, err := mcp.ReadResource(
-			context.Background(),
-			m.com.Config(),
-			item.MCPName,
-			item.URI,
-		)
+		attachment, err := m.com.App.MCPResourceAttachment(context.Background(), item.MCPName, item.URI)
 		if err != nil {
 			slog.Warn("Failed to read MCP resource", "uri", item.URI, "error", err)
 			return nil
 		}
-		if len(contents) == 0 {
-			return nil
-		}
-
-		content := contents[0]
-		var data []byte
-		if content.Text != "" {
-			data = []byte(content.Text)
-		} else if len(content.Blob) > 0 {
-			data = content.Blob
-		}
-		if len(data) == 0 {
-			return nil
-		}
-
-		mimeType := item.MIMEType
-		if mimeType == "" && content.MIMEType != "" {
-			mimeType = content.MIMEType
-		}
-		if mimeType == "" {
-			mimeType = "text/plain"
-		}
-
-		return message.Attachment{
-			FilePath: item.URI,
-			FileName: displayText,
-			MimeType: mimeType,
-			Content:  data,
-		}
+		attachment.FileName = displayText
+		return attachment
 	}
 }
 
@@ -2691,17 +3218,171 @@ func (m *UI) renderEditorView(width int) string {
 	return strings.Join([]string{
 		attachmentsView,
 		m.textarea.View(),
-		"", // margin at bottom of editor
+		m.renderDraftEstimate(width), // margin at bottom of editor
 	}, "\n")
 }
 
+// renderDraftEstimate renders the estimated tokens of the draft and its
+// attachments, and the cost of sending it, right-aligned in the editor
+// footer. It is empty while there is no draft.
+func (m *UI) renderDraftEstimate(width int) string {
+	usage := m.contextUsage()
+	if usage == nil || usage.DraftTokens == 0 {
+		return ""
+	}
+	t := m.com.Styles
+	style := t.EditorEstimate
+	if usage.NearLimit() {
+		style = t.EditorEstimateWarn
+	}
+	estimate := fmt.Sprintf("~%s tokens", formatTokenCount(usage.DraftTokens))
+	if usage.CostPer1MIn > 0 {
+		estimate += fmt.Sprintf(" · ~$%.2f to send", usage.Cost())
+	}
+	return lipgloss.PlaceHorizontal(width, lipgloss.Right, style.Render(estimate))
+}
+
 // cacheSidebarLogo renders and caches the sidebar logo at the specified width.
 func (m *UI) cacheSidebarLogo(width int) {
 	m.sidebarLogo = renderLogo(m.com.Styles, true, width)
 }
 
+// splitPartPrompt is sent with each but the last part of attachments split
+// across turns.
+const splitPartPrompt = "Attached context, part %d of %d. Reply only with \"ok\"; the question follows after the last part."
+
+// checkAttachmentBudget opens the attachment budget dialog when the
+// attachments of the draft are over the budget, and returns nil otherwise.
+func (m *UI) checkAttachmentBudget() tea.Cmd {
+	attachments := m.attachments.List()
+	if len(attachments) == 0 || m.com.App == nil || m.com.App.AgentCoordinator == nil {
+		return nil
+	}
+	budget := agent.AttachmentBudget(m.com.App.AgentCoordinator.Model(), m.com.Config().Options.AttachmentBudget)
+	tokens := agent.AttachmentTokens(attachments)
+	if budget <= 0 || tokens <= budget {
+		return nil
+	}
+	if m.dialog.ContainsDialog(dialog.AttachmentBudgetID) {
+		m.dialog.BringToFront(dialog.AttachmentBudgetID)
+		return nil
+	}
+	m.dialog.OpenDialog(dialog.NewAttachmentBudget(m.com, tokens, budget))
+	return nil
+}
+
+// sendTrimmedDraft sends the draft with its attachments fitted into the
+// attachment budget using strategy.
+func (m *UI) sendTrimmedDraft(strategy agent.TrimStrategy) tea.Cmd {
+	value := strings.TrimSpace(m.textarea.Value())
+	attachments := m.attachments.List()
+	m.textarea.Reset()
+	m.attachments.Reset()
+	m.randomizePlaceholders()
+	m.historyReset()
+
+	budget := agent.AttachmentBudget(m.com.App.AgentCoordinator.Model(), m.com.Config().Options.AttachmentBudget)
+	turns := agent.TrimAttachments(attachments, strategy, value, budget)
+	return tea.Batch(m.sendTurns(value, turns), m.loadPromptHistory())
+}
+
 // sendMessage sends a message with the given content and attachments.
 func (m *UI) sendMessage(content string, attachments ...message.Attachment) tea.Cmd {
+	return m.sendTurns(content, [][]message.Attachment{attachments})
+}
+
+// editedMessage is a message of the session being edited in the editor.
+type editedMessage struct {
+	id        string
+	sessionID string
+	mode      app.RegenerateMode
+}
+
+// editSelectedMessage puts the text of the selected user message in the
+// editor, to send it again in its place.
+func (m *UI) editSelectedMessage(mode app.RegenerateMode) tea.Cmd {
+	msg, ok := m.chat.SelectedUserMessage()
+	if !ok {
+		return util.ReportWarn("Select one of your messages to edit it")
+	}
+	if m.isAgentBusy() {
+		return util.ReportWarn("Agent is working, please wait...")
+	}
+	m.editing = &editedMessage{id: msg.ID, sessionID: msg.SessionID, mode: mode}
+	m.textarea.SetValue(msg.Content().Text)
+	m.textarea.MoveToEnd()
+	m.focus = uiFocusEditor
+	m.chat.Blur()
+	return m.textarea.Focus()
+}
+
+// cancelEdit leaves the message being edited as it was.
+func (m *UI) cancelEdit() {
+	m.editing = nil
+	m.textarea.Reset()
+}
+
+// sendEdit takes the session back to before the message being edited and
+// sends content in its place, with the attachments the message had. When
+// branching, the chat switches to the new session.
+func (m *UI) sendEdit(content string, attachments []message.Attachment) tea.Cmd {
+	editing := m.editing
+	m.editing = nil
+	rewind, err := m.com.App.Rewind(context.Background(), editing.id, editing.mode)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	var cmds []tea.Cmd
+	if m.session == nil || rewind.Session.ID != m.session.ID {
+		m.session = &rewind.Session
+		cmds = append(cmds, m.loadSession(rewind.Session.ID))
+	}
+	cmds = append(cmds, m.sendMessage(content, append(rewind.Attachments(), attachments...)...))
+	return tea.Batch(cmds...)
+}
+
+// interruptWithDraft stops what the agent is doing and has it continue with
+// the text in the editor, without waiting for the turn to end. Attachments
+// stay in the editor for the next message.
+func (m *UI) interruptWithDraft() tea.Cmd {
+	if !m.hasSession() || !m.com.App.AgentCoordinator.IsSessionBusy(m.session.ID) {
+		return util.ReportWarn("Agent is not working, press enter to send")
+	}
+	value := strings.TrimSpace(m.textarea.Value())
+	if value == "" {
+		return util.ReportWarn("Write what the agent should do differently")
+	}
+	if len(m.annotations) > 0 {
+		value = annotation.AppendToPrompt(value, m.annotations)
+		m.annotations = nil
+	}
+	m.textarea.Reset()
+	m.randomizePlaceholders()
+	m.historyReset()
+
+	sessionID := m.session.ID
+	return tea.Batch(m.loadPromptHistory(), func() tea.Msg {
+		err := m.com.App.Interrupt(sessionID, value)
+		if errors.Is(err, app.ErrNotBusy) {
+			// The turn ended in the meantime, so the note starts the next.
+			_, err = m.com.App.SendMessage(context.Background(), sessionID, value, nil)
+			if err == nil || errors.Is(err, context.Canceled) {
+				return nil
+			}
+		}
+		if err != nil {
+			return util.InfoMsg{
+				Type: util.InfoTypeError,
+				Msg:  err.Error(),
+			}
+		}
+		return util.NewInfoMsg("Interrupted, the agent continues with your note")
+	})
+}
+
+// sendTurns sends content with the attachments of the last turn, after
+// sending the attachments of each earlier turn in a message of its own.
+func (m *UI) sendTurns(content string, turns [][]message.Attachment) tea.Cmd {
 	if m.com.App.AgentCoordinator == nil {
 		return util.ReportError(fmt.Errorf("coder agent is not initialized"))
 	}
@@ -2715,6 +3396,18 @@ func (m *UI) sendMessage(content string, attachments ...message.Attachment) tea.
 		if m.forceCompactMode {
 			m.isCompact = true
 		}
+		if newSession.ID != "" && m.chatOnly {
+			if newSession, err = m.com.App.Sessions.SetChatOnly(context.Background(), newSession.ID, true); err != nil {
+				return util.ReportError(err)
+			}
+			m.chatOnly = false
+		}
+		if newSession.ID != "" && m.translate {
+			if newSession, err = m.com.App.Sessions.SetTranslate(context.Background(), newSession.ID, true); err != nil {
+				return util.ReportError(err)
+			}
+			m.translate = false
+		}
 		if newSession.ID != "" {
 			m.session = &newSession
 			cmds = append(cmds, m.loadSession(newSession.ID))
@@ -2733,17 +3426,32 @@ func (m *UI) sendMessage(content string, attachments ...message.Attachment) tea.
 
 	// Capture session ID to avoid race with main goroutine updating m.session.
 	sessionID := m.session.ID
+	raw := m.rawHistory
+	m.rawHistory = nil
 	cmds = append(cmds, func() tea.Msg {
-		_, err := m.com.App.AgentCoordinator.Run(context.Background(), sessionID, content, attachments...)
-		if err != nil {
-			isCancelErr := errors.Is(err, context.Canceled)
-			isPermissionErr := errors.Is(err, permission.ErrorPermissionDenied)
-			if isCancelErr || isPermissionErr {
-				return nil
+		// The turns run one after the other, so the parts arrive in order
+		// and before the prompt.
+		for i, attachments := range turns {
+			prompt := content
+			if i < len(turns)-1 {
+				prompt = fmt.Sprintf(splitPartPrompt, i+1, len(turns))
 			}
-			return util.InfoMsg{
-				Type: util.InfoTypeError,
-				Msg:  err.Error(),
+			var err error
+			if raw != nil && i == len(turns)-1 {
+				_, err = m.com.App.AgentCoordinator.RunRaw(context.Background(), sessionID, prompt, *raw, attachments...)
+			} else {
+				_, err = m.com.App.AgentCoordinator.Run(context.Background(), sessionID, prompt, attachments...)
+			}
+			if err != nil {
+				isCancelErr := errors.Is(err, context.Canceled)
+				isPermissionErr := errors.Is(err, permission.ErrorPermissionDenied)
+				if isCancelErr || isPermissionErr {
+					return nil
+				}
+				return util.InfoMsg{
+					Type: util.InfoTypeError,
+					Msg:  err.Error(),
+				}
 			}
 		}
 		return nil
@@ -2818,6 +3526,46 @@ func (m *UI) openDialog(id string) tea.Cmd {
 		if cmd := m.openQuitDialog(); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+	case dialog.AnnotateID:
+		if cmd := m.openAnnotateDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.StagedEditsID:
+		if cmd := m.openStagedEditsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.ArtifactsID:
+		if cmd := m.openArtifactsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.QuickActionsID:
+		if cmd := m.openQuickActionsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.TourID:
+		if cmd := m.openTourDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.ThemesID:
+		m.openThemesDialog()
+	case dialog.RawMessageID:
+		if m.dialog.ContainsDialog(dialog.RawMessageID) {
+			m.dialog.BringToFront(dialog.RawMessageID)
+			break
+		}
+		m.dialog.OpenDialog(dialog.NewRawMessage(m.com, m.rawHistory != nil))
+	case dialog.ProjectPermissionsID:
+		if m.dialog.ContainsDialog(dialog.ProjectPermissionsID) {
+			m.dialog.BringToFront(dialog.ProjectPermissionsID)
+			break
+		}
+		m.dialog.OpenDialog(dialog.NewProjectPermissions(m.com))
+	case dialog.MCPServersID:
+		if m.dialog.ContainsDialog(dialog.MCPServersID) {
+			m.dialog.BringToFront(dialog.MCPServersID)
+			break
+		}
+		m.dialog.OpenDialog(dialog.NewMCPServers(m.com))
 	default:
 		// Unknown dialog
 		break
@@ -2980,11 +3728,15 @@ func (m *UI) newSession() tea.Cmd {
 
 	m.session = nil
 	m.sessionFiles = nil
+	m.annotations = nil
 	m.sessionFileReads = nil
+	m.sidePane = nil
 	m.setState(uiLanding, uiFocusEditor)
 	m.textarea.Focus()
 	m.chat.Blur()
 	m.chat.ClearMessages()
+	m.scrollback = scrollback{}
+	m.lastMessageModel = ""
 	m.pillsExpanded = false
 	m.promptQueue = 0
 	m.pillsView = ""
@@ -3009,6 +3761,12 @@ func (m *UI) handlePasteMsg(msg tea.PasteMsg) tea.Cmd {
 		return nil
 	}
 
+	// Terminals send an empty bracketed paste when the clipboard holds
+	// something other than text, typically a screenshot.
+	if msg.Content == "" {
+		return m.pasteImageFromClipboard
+	}
+
 	if strings.Count(msg.Content, "\n") > pasteLinesThreshold {
 		return func() tea.Msg {
 			content := []byte(msg.Content)
@@ -3042,7 +3800,7 @@ func (m *UI) handlePasteMsg(msg tea.PasteMsg) tea.Cmd {
 
 			lowerPath := strings.ToLower(path)
 			isValid := false
-			for _, ext := range common.AllowedImageTypes {
+			for _, ext := range common.AllowedAttachmentTypes {
 				if strings.HasSuffix(lowerPath, ext) {
 					isValid = true
 					break
@@ -3102,21 +3860,9 @@ func (m *UI) handleFilePathPaste(path string) tea.Cmd {
 // creates an attachment. If no image data is found, it falls back to
 // interpreting clipboard text as a file path.
 func (m *UI) pasteImageFromClipboard() tea.Msg {
-	imageData, err := readClipboard(clipboardFormatImage)
-	if int64(len(imageData)) > common.MaxAttachmentSize {
-		return util.InfoMsg{
-			Type: util.InfoTypeError,
-			Msg:  "File too large, max 5MB",
-		}
-	}
-	name := fmt.Sprintf("paste_%d.png", m.pasteIdx())
+	imageData, err := readClipboardImage()
 	if err == nil {
-		return message.Attachment{
-			FilePath: name,
-			FileName: name,
-			MimeType: mimeOf(imageData),
-			Content:  imageData,
-		}
+		return m.newPastedImage(imageData)
 	}
 
 	textData, textErr := readClipboard(clipboardFormatText)
@@ -3172,7 +3918,83 @@ func (m *UI) pasteImageFromClipboard() tea.Msg {
 	}
 }
 
-var pasteRE = regexp.MustCompile(`paste_(\d+).txt`)
+// pastedImageMsg carries an image pasted from the clipboard. Unlike a plain
+// attachment, a reference to it is inserted into the prompt.
+type pastedImageMsg struct {
+	attachment message.Attachment
+}
+
+// newPastedImage saves pasted image data to a temporary file and returns a
+// [pastedImageMsg] for it.
+func (m *UI) newPastedImage(data []byte) tea.Msg {
+	if int64(len(data)) > common.MaxAttachmentSize {
+		return util.InfoMsg{
+			Type: util.InfoTypeError,
+			Msg:  "File too large, max 5MB",
+		}
+	}
+	mimeType := mimeOf(data)
+	var ext string
+	switch mimeType {
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/gif":
+		ext = ".gif"
+	case "image/webp":
+		ext = ".webp"
+	default:
+		ext = ".png"
+	}
+	name := fmt.Sprintf("paste_%d%s", m.pasteIdx(), ext)
+	path, err := m.pastes.save(name, data)
+	if err != nil {
+		slog.Error("Error reported", "error", err)
+		return util.NewErrorMsg(err)
+	}
+	return pastedImageMsg{attachment: message.Attachment{
+		FilePath: path,
+		FileName: name,
+		MimeType: mimeType,
+		Content:  data,
+	}}
+}
+
+// supportsKittyClipboard reports whether the terminal speaks the clipboard
+// protocol of kitty. Its answers do not make it through tmux.
+func (m *UI) supportsKittyClipboard() bool {
+	if _, isTmux := m.caps.Env.LookupEnv("TMUX"); isTmux {
+		return false
+	}
+	return strings.HasPrefix(m.caps.TerminalVersion, "kitty") || m.caps.Env.Getenv("TERM") == "xterm-kitty"
+}
+
+// handleKittyClipboard handles a terminal response to a kitty clipboard
+// read, pasting the image once the read is over.
+func (m *UI) handleKittyClipboard(seq string) tea.Cmd {
+	handled, done, err := m.kittyClipboard.feed(seq)
+	if !handled || !done {
+		return nil
+	}
+	data := m.kittyClipboard.data
+	m.kittyClipboard = nil
+	switch {
+	case err != nil:
+		return util.ReportError(err)
+	case !isImageData(data):
+		return util.ReportInfo("Terminal clipboard does not contain an image")
+	}
+	return func() tea.Msg {
+		return m.newPastedImage(data)
+	}
+}
+
+// isRemoteSession reports whether Crush runs over SSH, where the native
+// clipboard is the remote host's rather than the user's.
+func isRemoteSession() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+var pasteRE = regexp.MustCompile(`paste_(\d+)\.\w+`)
 
 func (m *UI) pasteIdx() int {
 	result := 0
@@ -3189,6 +4011,21 @@ func (m *UI) pasteIdx() int {
 	return result + 1
 }
 
+// contextUsage returns the context usage of the current session including the
+// prompt being typed and its attachments, or nil when no agent is available.
+func (m *UI) contextUsage() *agent.ContextUsage {
+	if m.com.App == nil || m.com.App.AgentCoordinator == nil {
+		return nil
+	}
+	var sess session.Session
+	if m.session != nil {
+		sess = *m.session
+	}
+	usage := agent.NewContextUsage(m.com.App.AgentCoordinator.Model(), sess).
+		WithDraft(m.textarea.Value(), m.attachments.List()...)
+	return &usage
+}
+
 // drawSessionDetails draws the session details in compact mode.
 func (m *UI) drawSessionDetails(scr uv.Screen, area uv.Rectangle) {
 	if m.session == nil {
@@ -3241,7 +4078,7 @@ func (m *UI) drawSessionDetails(scr uv.Screen, area uv.Rectangle) {
 
 func (m *UI) runMCPPrompt(clientID, promptID string, arguments map[string]string) tea.Cmd {
 	load := func() tea.Msg {
-		prompt, err := commands.GetMCPPrompt(m.com.Config(), clientID, promptID, arguments)
+		prompt, err := commands.GetMCPPrompt(context.Background(), m.com.Config(), clientID, promptID, arguments)
 		if err != nil {
 			// TODO: make this better
 			return util.ReportError(err)()
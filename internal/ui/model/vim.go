@@ -0,0 +1,587 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+)
+
+// vimMode is the mode of the prompt editor when vim mode is on.
+type vimMode int
+
+const (
+	vimInsert vimMode = iota
+	vimNormal
+)
+
+// maxVimUndo is how many changes u can undo.
+const maxVimUndo = 100
+
+// vim implements modal editing of the prompt editor. It starts in insert
+// mode, where keys reach the editor as usual, and esc switches to normal
+// mode for motions and operators. Keys with ctrl or alt, and special keys
+// such as enter, are left to the UI in both modes.
+type vim struct {
+	mode vimMode
+	// pending is the operator or prefix typed so far, such as "d" or "g".
+	pending string
+	count   int
+	// opCount is the count typed before a pending operator, as in 2dw.
+	opCount int
+
+	register string
+	linewise bool
+	undo     []vimSnapshot
+}
+
+type vimSnapshot struct {
+	value    string
+	row, col int
+}
+
+// vimBuffer is the text of the editor as lines of runes, with the cursor.
+type vimBuffer struct {
+	lines    [][]rune
+	row, col int
+}
+
+func newVimBuffer(ta *textarea.Model) *vimBuffer {
+	b := &vimBuffer{row: ta.Line(), col: ta.Column()}
+	for line := range strings.SplitSeq(ta.Value(), "\n") {
+		b.lines = append(b.lines, []rune(line))
+	}
+	return b
+}
+
+func (b *vimBuffer) String() string {
+	lines := make([]string, len(b.lines))
+	for i, line := range b.lines {
+		lines[i] = string(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *vimBuffer) line() []rune {
+	return b.lines[b.row]
+}
+
+// clamp keeps the cursor on a character, as in normal mode.
+func (b *vimBuffer) clamp() {
+	b.row = max(0, min(b.row, len(b.lines)-1))
+	b.col = max(0, min(b.col, len(b.line())-1))
+}
+
+// HandleKey handles msg for the editor ta and reports whether it did.
+func (v *vim) HandleKey(ta *textarea.Model, msg tea.KeyPressMsg) bool {
+	k := msg.Key()
+	isEsc := k.Code == tea.KeyEscape && k.Mod == 0
+	if v.mode == vimInsert {
+		if !isEsc {
+			return false
+		}
+		v.setMode(ta, vimNormal)
+		if ta.Column() > 0 {
+			ta.SetCursorColumn(ta.Column() - 1)
+		}
+		return true
+	}
+
+	if isEsc && (v.pending != "" || v.count > 0) {
+		v.pending, v.count = "", 0
+		return true
+	}
+	if k.Text == "" || k.Mod&(tea.ModCtrl|tea.ModAlt|tea.ModMeta|tea.ModSuper) != 0 {
+		return false
+	}
+	v.normal(ta, k.Text)
+	return true
+}
+
+// Reset returns to insert mode, as when the prompt was sent.
+func (v *vim) Reset(ta *textarea.Model) {
+	v.pending, v.count, v.opCount = "", 0, 0
+	v.undo = nil
+	v.setMode(ta, vimInsert)
+}
+
+func (v *vim) setMode(ta *textarea.Model, mode vimMode) {
+	v.mode = mode
+	v.applyCursor(ta)
+}
+
+// applyCursor shows a block cursor in normal mode and a bar in insert mode.
+func (v *vim) applyCursor(ta *textarea.Model) {
+	styles := ta.Styles()
+	styles.Cursor.Shape = tea.CursorBar
+	if v.mode == vimNormal {
+		styles.Cursor.Shape = tea.CursorBlock
+	}
+	ta.SetStyles(styles)
+}
+
+func (v *vim) normal(ta *textarea.Model, key string) {
+	if v.pending == "r" {
+		v.pending = ""
+		b := newVimBuffer(ta)
+		if len(b.line()) > 0 && key != "\n" {
+			v.snapshot(ta)
+			line := b.line()
+			line[b.col] = []rune(key)[0]
+			v.apply(ta, b, true)
+		}
+		return
+	}
+	if key >= "1" && key <= "9" || key == "0" && v.count > 0 {
+		v.count = v.count*10 + int(key[0]-'0')
+		return
+	}
+	count := max(1, v.count) * max(1, v.opCount)
+	v.count = 0
+
+	b := newVimBuffer(ta)
+	cmd := v.pending + key
+	v.pending, v.opCount = "", 0
+	switch cmd {
+	case "g", "r":
+		v.pending = cmd
+		v.count = count
+		if count == 1 {
+			v.count = 0
+		}
+		return
+	case "d", "c", "y":
+		v.pending = cmd
+		v.opCount = count
+		return
+	case "i":
+		v.insert(ta, b)
+		return
+	case "a":
+		b.col = min(b.col+1, len(b.line()))
+		v.insert(ta, b)
+		return
+	case "I":
+		b.col = firstNonBlank(b.line())
+		v.insert(ta, b)
+		return
+	case "A":
+		b.col = len(b.line())
+		v.insert(ta, b)
+		return
+	case "o", "O":
+		row := b.row + 1
+		if cmd == "O" {
+			row = b.row
+		}
+		b.lines = append(b.lines[:row], append([][]rune{{}}, b.lines[row:]...)...)
+		b.row, b.col = row, 0
+		v.insert(ta, b)
+		return
+	case "u":
+		v.popUndo(ta)
+		return
+	case "x", "X", "s":
+		line := b.line()
+		start, end := b.col, min(b.col+count, len(line))
+		if cmd == "X" {
+			start, end = max(0, b.col-count), b.col
+		}
+		if start < end {
+			v.yank(string(line[start:end]), false)
+			v.snapshot(ta)
+			b.lines[b.row] = append(line[:start:start], line[end:]...)
+			b.col = start
+		}
+		if cmd == "s" {
+			v.insert(ta, b)
+			return
+		}
+		b.clamp()
+		v.apply(ta, b, start < end)
+		return
+	case "D", "C":
+		v.changeToEnd(ta, b, cmd == "C")
+		return
+	case "dd", "cc", "yy", "S":
+		v.lines(ta, b, strings.TrimSuffix(cmd, cmd[1:]), count)
+		return
+	case "p", "P":
+		v.paste(ta, b, cmd == "P", count)
+		return
+	default:
+		if len(cmd) == 2 && strings.ContainsAny(cmd[:1], "dcy") {
+			v.operate(ta, b, cmd[0], cmd[1:], count)
+			return
+		}
+		if !b.move(cmd, count) {
+			return
+		}
+	}
+	b.clamp()
+	v.apply(ta, b, false)
+}
+
+// move moves the cursor of b by motion and reports whether it is one.
+func (b *vimBuffer) move(motion string, count int) bool {
+	switch motion {
+	case "h":
+		b.col -= count
+	case "l":
+		b.col = min(b.col+count, len(b.line())-1)
+	case "j":
+		b.row += count
+	case "k":
+		b.row -= count
+	case "w":
+		for range count {
+			b.wordForward()
+		}
+	case "b":
+		for range count {
+			b.wordBackward()
+		}
+	case "e":
+		for range count {
+			b.wordEnd()
+		}
+	case "0":
+		b.col = 0
+	case "^":
+		b.col = firstNonBlank(b.line())
+	case "$":
+		b.col = len(b.line())
+	case "gg":
+		b.row, b.col = 0, 0
+	case "G":
+		b.row, b.col = len(b.lines)-1, 0
+	default:
+		return false
+	}
+	b.row = max(0, min(b.row, len(b.lines)-1))
+	b.col = max(0, min(b.col, len(b.line())))
+	return true
+}
+
+// runeAt returns the rune under the cursor, a newline at the end of lines.
+func (b *vimBuffer) runeAt() rune {
+	if b.col >= len(b.line()) {
+		return '\n'
+	}
+	return b.line()[b.col]
+}
+
+// step moves the cursor one rune forward, or back, across lines, and
+// reports whether it could.
+func (b *vimBuffer) step(forward bool) bool {
+	if forward {
+		if b.col < len(b.line()) {
+			b.col++
+			return true
+		}
+		if b.row+1 < len(b.lines) {
+			b.row, b.col = b.row+1, 0
+			return true
+		}
+		return false
+	}
+	if b.col > 0 {
+		b.col--
+		return true
+	}
+	if b.row > 0 {
+		b.row--
+		b.col = len(b.line())
+		return true
+	}
+	return false
+}
+
+// runeClass sorts runes into blanks (0), punctuation (1) and word runes (2),
+// which words are made of.
+func runeClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 2
+	default:
+		return 1
+	}
+}
+
+func (b *vimBuffer) wordForward() {
+	class := runeClass(b.runeAt())
+	for class != 0 && runeClass(b.runeAt()) == class {
+		if !b.step(true) {
+			return
+		}
+	}
+	for runeClass(b.runeAt()) == 0 {
+		if b.col >= len(b.line()) && b.row == len(b.lines)-1 {
+			return
+		}
+		if len(b.line()) == 0 && b.col == 0 && class != 0 {
+			// An empty line is a word of its own.
+			return
+		}
+		if !b.step(true) {
+			return
+		}
+		class = 1
+	}
+}
+
+func (b *vimBuffer) wordBackward() {
+	if !b.step(false) {
+		return
+	}
+	for runeClass(b.runeAt()) == 0 {
+		if !b.step(false) {
+			return
+		}
+	}
+	class := runeClass(b.runeAt())
+	for b.col > 0 && runeClass(b.line()[b.col-1]) == class {
+		b.col--
+	}
+}
+
+func (b *vimBuffer) wordEnd() {
+	if !b.step(true) {
+		return
+	}
+	for runeClass(b.runeAt()) == 0 {
+		if !b.step(true) {
+			return
+		}
+	}
+	class := runeClass(b.runeAt())
+	for b.col+1 < len(b.line()) && runeClass(b.line()[b.col+1]) == class {
+		b.col++
+	}
+}
+
+func firstNonBlank(line []rune) int {
+	for i, r := range line {
+		if !unicode.IsSpace(r) {
+			return i
+		}
+	}
+	return max(0, len(line)-1)
+}
+
+// operate applies the operator d, c or y to the text from the cursor to
+// where motion moves it, within the line.
+func (v *vim) operate(ta *textarea.Model, b *vimBuffer, op byte, motion string, count int) {
+	target := *b
+	target.lines = b.lines
+	if motion == "w" && op == 'c' {
+		// cw changes to the end of the word, as ce does.
+		motion = "e"
+	}
+	if !target.move(motion, count) {
+		return
+	}
+	start, end := b.col, target.col
+	if target.row != b.row {
+		end = len(b.line())
+		if target.row < b.row {
+			end = 0
+		}
+	}
+	if start > end {
+		start, end = end, start
+	}
+	if motion == "e" {
+		end = min(end+1, len(b.line()))
+	}
+	line := b.line()
+	if start == end {
+		if op == 'c' {
+			v.insert(ta, b)
+		}
+		return
+	}
+	v.yank(string(line[start:end]), false)
+	if op == 'y' {
+		b.col = start
+		b.clamp()
+		v.apply(ta, b, false)
+		return
+	}
+	v.snapshot(ta)
+	b.lines[b.row] = append(line[:start:start], line[end:]...)
+	b.col = start
+	if op == 'c' {
+		v.insert(ta, b)
+		return
+	}
+	b.clamp()
+	v.apply(ta, b, true)
+}
+
+// changeToEnd deletes from the cursor to the end of the line, then enters
+// insert mode when change is set.
+func (v *vim) changeToEnd(ta *textarea.Model, b *vimBuffer, change bool) {
+	line := b.line()
+	if b.col < len(line) {
+		v.yank(string(line[b.col:]), false)
+		v.snapshot(ta)
+		b.lines[b.row] = line[:b.col:b.col]
+	}
+	if change {
+		v.insert(ta, b)
+		return
+	}
+	b.clamp()
+	v.apply(ta, b, true)
+}
+
+// lines applies the operator d, c or y to count whole lines.
+func (v *vim) lines(ta *textarea.Model, b *vimBuffer, op string, count int) {
+	end := min(b.row+count, len(b.lines))
+	text := make([]string, 0, end-b.row)
+	for _, line := range b.lines[b.row:end] {
+		text = append(text, string(line))
+	}
+	v.yank(strings.Join(text, "\n"), true)
+	switch op {
+	case "y":
+		return
+	case "c", "S":
+		v.snapshot(ta)
+		indent := b.line()[:firstNonBlankOrEnd(b.line())]
+		b.lines = append(b.lines[:b.row+1], b.lines[end:]...)
+		b.lines[b.row] = append([]rune(nil), indent...)
+		b.col = len(indent)
+		v.insert(ta, b)
+	default:
+		v.snapshot(ta)
+		b.lines = append(b.lines[:b.row], b.lines[end:]...)
+		if len(b.lines) == 0 {
+			b.lines = [][]rune{{}}
+		}
+		b.row = min(b.row, len(b.lines)-1)
+		b.col = firstNonBlank(b.line())
+		v.apply(ta, b, true)
+	}
+}
+
+func firstNonBlankOrEnd(line []rune) int {
+	for i, r := range line {
+		if !unicode.IsSpace(r) {
+			return i
+		}
+	}
+	return len(line)
+}
+
+// paste puts the register after the cursor, or before it when before is
+// set.
+func (v *vim) paste(ta *textarea.Model, b *vimBuffer, before bool, count int) {
+	if v.register == "" {
+		return
+	}
+	v.snapshot(ta)
+	text := strings.Repeat(v.register+"\n", count)
+	text = strings.TrimSuffix(text, "\n")
+	if v.linewise {
+		var pasted [][]rune
+		for line := range strings.SplitSeq(text, "\n") {
+			pasted = append(pasted, []rune(line))
+		}
+		row := b.row + 1
+		if before {
+			row = b.row
+		}
+		b.lines = append(b.lines[:row], append(pasted, b.lines[row:]...)...)
+		b.row = row
+		b.col = firstNonBlank(b.line())
+		v.apply(ta, b, true)
+		return
+	}
+	text = strings.Repeat(v.register, count)
+	col := b.col
+	if !before && len(b.line()) > 0 {
+		col++
+	}
+	b.col = col
+	v.applyInsert(ta, b, text)
+}
+
+func (v *vim) yank(text string, linewise bool) {
+	v.register, v.linewise = text, linewise
+}
+
+// insert enters insert mode with the cursor of b.
+func (v *vim) insert(ta *textarea.Model, b *vimBuffer) {
+	v.snapshot(ta)
+	v.apply(ta, b, b.String() != ta.Value())
+	v.setMode(ta, vimInsert)
+}
+
+func (v *vim) snapshot(ta *textarea.Model) {
+	s := vimSnapshot{value: ta.Value(), row: ta.Line(), col: ta.Column()}
+	if n := len(v.undo); n > 0 && v.undo[n-1] == s {
+		return
+	}
+	v.undo = append(v.undo, s)
+	if len(v.undo) > maxVimUndo {
+		v.undo = v.undo[1:]
+	}
+}
+
+func (v *vim) popUndo(ta *textarea.Model) {
+	for len(v.undo) > 0 {
+		s := v.undo[len(v.undo)-1]
+		v.undo = v.undo[:len(v.undo)-1]
+		if s.value == ta.Value() {
+			continue
+		}
+		ta.SetValue(s.value)
+		b := newVimBuffer(ta)
+		b.row, b.col = s.row, s.col
+		b.clamp()
+		setCursor(ta, b.row, b.col)
+		return
+	}
+}
+
+// apply writes b back to the editor, its text only when changed.
+func (v *vim) apply(ta *textarea.Model, b *vimBuffer, changed bool) {
+	if changed {
+		ta.SetValue(b.String())
+	}
+	setCursor(ta, b.row, b.col)
+}
+
+// applyInsert inserts text at the cursor of b.
+func (v *vim) applyInsert(ta *textarea.Model, b *vimBuffer, text string) {
+	setCursor(ta, b.row, min(b.col, len(b.line())))
+	ta.InsertString(text)
+	ta.SetCursorColumn(ta.Column() - 1)
+}
+
+// setCursor moves the cursor of the editor to the rune col of line row.
+func setCursor(ta *textarea.Model, row, col int) {
+	ta.MoveToBegin()
+	for ta.Line() < row {
+		before := ta.Line()
+		last := ta.LineInfo()
+		ta.CursorDown()
+		if ta.Line() == before && ta.LineInfo() == last {
+			break
+		}
+	}
+	ta.SetCursorColumn(col)
+}
+
+// vimInsertEscape reports whether msg is the esc that leaves vim insert mode
+// in the editor, rather than one that cancels the agent.
+func (m *UI) vimInsertEscape(msg tea.KeyPressMsg) bool {
+	k := msg.Key()
+	return m.vim != nil && m.vim.mode == vimInsert && m.focus == uiFocusEditor &&
+		k.Code == tea.KeyEscape && k.Mod == 0
+}
@@ -0,0 +1,170 @@
+package model
+
+import (
+	"testing"
+
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// vimEditor returns an editor holding value with the cursor at row and col,
+// and a vim in normal mode.
+func vimEditor(t *testing.T, value string, row, col int) (*textarea.Model, *vim) {
+	t.Helper()
+	ta := textarea.New()
+	ta.SetWidth(80)
+	ta.SetHeight(10)
+	ta.Focus()
+	ta.SetValue(value)
+	setCursor(&ta, row, col)
+	return &ta, &vim{mode: vimNormal}
+}
+
+// typeVim sends keys to v one at a time, with \x1b for esc, and reports
+// whether v handled them all.
+func typeVim(ta *textarea.Model, v *vim, keys string) bool {
+	handled := true
+	for _, r := range keys {
+		msg := tea.KeyPressMsg{Code: r, Text: string(r)}
+		if r == '\x1b' {
+			msg = tea.KeyPressMsg{Code: tea.KeyEscape}
+		}
+		if !v.HandleKey(ta, msg) {
+			handled = false
+			if v.mode == vimInsert {
+				ta.InsertRune(r)
+			}
+		}
+	}
+	return handled
+}
+
+func TestVimMotions(t *testing.T) {
+	t.Parallel()
+
+	const text = "first line here\n  second line\nthird"
+	for _, tt := range []struct {
+		keys      string
+		row, col  int
+		wantRow   int
+		wantCol   int
+		startRow  int
+		startCol  int
+		startText string
+	}{
+		{keys: "l", wantCol: 1},
+		{keys: "3l", wantCol: 3},
+		{keys: "h", startCol: 2, wantCol: 1},
+		{keys: "h", wantCol: 0},
+		{keys: "w", wantCol: 6},
+		{keys: "2w", wantCol: 11},
+		{keys: "e", wantCol: 4},
+		{keys: "b", startCol: 8, wantCol: 6},
+		{keys: "$", wantCol: 14},
+		{keys: "0", startCol: 8, wantCol: 0},
+		{keys: "^", startRow: 1, startCol: 8, wantRow: 1, wantCol: 2},
+		{keys: "j", startCol: 14, wantRow: 1, wantCol: 12},
+		{keys: "2j", wantRow: 2},
+		{keys: "k", startRow: 2, wantRow: 1},
+		{keys: "G", wantRow: 2},
+		{keys: "gg", startRow: 2, startCol: 3, wantRow: 0},
+		{keys: "10l", wantCol: 10},
+		{keys: "w", startText: "foo.bar baz", wantCol: 3},
+		{keys: "w", startText: "é ü", wantCol: 2},
+	} {
+		t.Run(tt.keys, func(t *testing.T) {
+			t.Parallel()
+
+			value := text
+			if tt.startText != "" {
+				value = tt.startText
+			}
+			ta, v := vimEditor(t, value, tt.startRow, tt.startCol)
+			require.True(t, typeVim(ta, v, tt.keys))
+			require.Equal(t, value, ta.Value(), "motions leave the text alone")
+			require.Equal(t, tt.wantRow, ta.Line())
+			require.Equal(t, tt.wantCol, ta.Column())
+			require.Equal(t, vimNormal, v.mode)
+		})
+	}
+}
+
+func TestVimEdits(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name  string
+		value string
+		keys  string
+		want  string
+		// insert is set when the keys end in insert mode.
+		insert bool
+	}{
+		{name: "x", value: "abc", keys: "x", want: "bc"},
+		{name: "count x", value: "abcd", keys: "2x", want: "cd"},
+		{name: "dw", value: "one two three", keys: "dw", want: "two three"},
+		{name: "d2w", value: "one two three", keys: "d2w", want: "three"},
+		{name: "2dw", value: "one two three", keys: "2dw", want: "three"},
+		{name: "de", value: "one two", keys: "de", want: " two"},
+		{name: "D", value: "one two", keys: "wD", want: "one "},
+		{name: "dd", value: "one\ntwo\nthree", keys: "jdd", want: "one\nthree"},
+		{name: "2dd", value: "one\ntwo\nthree", keys: "2dd", want: "three"},
+		{name: "dd last line", value: "one", keys: "dd", want: ""},
+		{name: "cw", value: "one two", keys: "cwuno\x1b", want: "uno two"},
+		{name: "cc keeps indent", value: "  one\ntwo", keys: "ccuno\x1b", want: "  uno\ntwo"},
+		{name: "C", value: "one two", keys: "wCdos\x1b", want: "one dos"},
+		{name: "s", value: "abc", keys: "sX\x1b", want: "Xbc"},
+		{name: "r", value: "abc", keys: "lrX", want: "aXc"},
+		{name: "yy p", value: "one\ntwo", keys: "yyp", want: "one\none\ntwo"},
+		{name: "yy P", value: "one\ntwo", keys: "jyyP", want: "one\ntwo\ntwo"},
+		{name: "yw p", value: "ab cd", keys: "ywp", want: "aab b cd"},
+		{name: "x p swaps", value: "ab", keys: "xp", want: "ba"},
+		{name: "u", value: "one two", keys: "dwdwu", want: "two"},
+		{name: "u twice", value: "one two", keys: "dwdwuu", want: "one two"},
+		{name: "u after insert", value: "one", keys: "Atwo\x1bu", want: "one"},
+		{name: "o", value: "one\nthree", keys: "otwo\x1b", want: "one\ntwo\nthree"},
+		{name: "O", value: "two", keys: "Oone\x1b", want: "one\ntwo"},
+		{name: "I", value: "  two", keys: "$Ione \x1b", want: "  one two"},
+		{name: "a", value: "ac", keys: "ab", want: "abc", insert: true},
+		{name: "esc drops a pending operator", value: "one two", keys: "d\x1bw", want: "one two"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ta, v := vimEditor(t, tt.value, 0, 0)
+			typeVim(ta, v, tt.keys)
+			require.Equal(t, tt.want, ta.Value())
+			require.Equal(t, tt.insert, v.mode == vimInsert)
+		})
+	}
+}
+
+func TestVimModes(t *testing.T) {
+	t.Parallel()
+
+	ta, v := vimEditor(t, "abc", 0, 3)
+	v.mode = vimInsert
+	require.False(t, v.HandleKey(ta, tea.KeyPressMsg{Code: 'x', Text: "x"}), "insert mode leaves keys to the editor")
+
+	require.True(t, v.HandleKey(ta, tea.KeyPressMsg{Code: tea.KeyEscape}))
+	require.Equal(t, vimNormal, v.mode)
+	require.Equal(t, 2, ta.Column(), "esc moves back onto the last character")
+	require.Equal(t, tea.CursorBlock, ta.Styles().Cursor.Shape)
+
+	require.False(t, v.HandleKey(ta, tea.KeyPressMsg{Code: 'c', Mod: tea.ModCtrl}), "ctrl keys are left to the UI")
+	require.False(t, v.HandleKey(ta, tea.KeyPressMsg{Code: tea.KeyEnter}), "enter is left to the UI")
+	require.True(t, v.HandleKey(ta, tea.KeyPressMsg{Code: 'q', Text: "q"}), "unknown keys are swallowed in normal mode")
+	require.Equal(t, "abc", ta.Value())
+
+	require.True(t, v.HandleKey(ta, tea.KeyPressMsg{Code: 'i', Text: "i"}))
+	require.Equal(t, vimInsert, v.mode)
+	require.Equal(t, tea.CursorBar, ta.Styles().Cursor.Shape)
+
+	v.setMode(ta, vimNormal)
+	v.pending, v.count = "d", 3
+	v.Reset(ta)
+	require.Equal(t, vimInsert, v.mode, "sending the prompt returns to insert mode")
+	require.Empty(t, v.pending)
+	require.Zero(t, v.count)
+}
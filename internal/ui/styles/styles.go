@@ -1,6 +1,7 @@
 package styles
 
 import (
+	"fmt"
 	"image/color"
 	"strings"
 
@@ -150,6 +151,8 @@ type Styles struct {
 	EditorPromptYoloIconBlurred lipgloss.Style
 	EditorPromptYoloDotsFocused lipgloss.Style
 	EditorPromptYoloDotsBlurred lipgloss.Style
+	EditorEstimate              lipgloss.Style
+	EditorEstimateWarn          lipgloss.Style
 
 	// Radio
 	RadioOn  lipgloss.Style
@@ -411,6 +414,9 @@ type Styles struct {
 		InfoMessage    lipgloss.Style
 		UpdateMessage  lipgloss.Style
 		SuccessMessage lipgloss.Style
+
+		Context     lipgloss.Style
+		ContextWarn lipgloss.Style
 	}
 
 	// Completions popup styles
@@ -486,56 +492,56 @@ func (s *Styles) DialogHelpStyles() help.Styles {
 	return help.Styles(s.Dialog.Help)
 }
 
-// DefaultStyles returns the default styles for the UI.
+// DefaultStyles returns the styles for the UI in the default theme.
 func DefaultStyles() Styles {
+	return NewStyles(DarkTheme())
+}
+
+// NewStyles returns the styles for the UI in the colors of theme.
+func NewStyles(theme Theme) Styles {
 	var (
-		primary   = charmtone.Charple
-		secondary = charmtone.Dolly
-		tertiary  = charmtone.Bok
-		// accent    = charmtone.Zest
+		primary   = theme.Primary
+		secondary = theme.Secondary
+		tertiary  = theme.Tertiary
 
 		// Backgrounds
-		bgBase        = charmtone.Pepper
-		bgBaseLighter = charmtone.BBQ
-		bgSubtle      = charmtone.Charcoal
-		bgOverlay     = charmtone.Iron
+		bgBase        = theme.BgBase
+		bgBaseLighter = theme.BgBaseLighter
+		bgSubtle      = theme.BgSubtle
+		bgOverlay     = theme.BgOverlay
 
 		// Foregrounds
-		fgBase      = charmtone.Ash
-		fgMuted     = charmtone.Squid
-		fgHalfMuted = charmtone.Smoke
-		fgSubtle    = charmtone.Oyster
-		// fgSelected  = charmtone.Salt
+		fgBase      = theme.FgBase
+		fgMuted     = theme.FgMuted
+		fgHalfMuted = theme.FgHalfMuted
+		fgSubtle    = theme.FgSubtle
+		fgSelected  = theme.FgSelected
 
 		// Borders
-		border      = charmtone.Charcoal
-		borderFocus = charmtone.Charple
+		border      = theme.Border
+		borderFocus = theme.BorderFocus
 
 		// Status
-		error   = charmtone.Sriracha
-		warning = charmtone.Zest
-		info    = charmtone.Malibu
+		error   = theme.Error
+		warning = theme.Warning
+		info    = theme.Info
 
 		// Colors
-		white = charmtone.Butter
+		white = theme.White
 
-		blueLight = charmtone.Sardine
-		blue      = charmtone.Malibu
-		blueDark  = charmtone.Damson
+		blueLight = theme.BlueLight
+		blue      = theme.Blue
+		blueDark  = theme.BlueDark
 
-		// yellow = charmtone.Mustard
-		yellow = charmtone.Mustard
-		// citron = charmtone.Citron
+		yellow    = theme.Yellow
+		highlight = theme.Highlight
 
-		greenLight = charmtone.Bok
-		green      = charmtone.Julep
-		greenDark  = charmtone.Guac
-		// greenLight = charmtone.Bok
+		greenLight = theme.GreenLight
+		green      = theme.Green
+		greenDark  = theme.GreenDark
 
-		red     = charmtone.Coral
-		redDark = charmtone.Sriracha
-		// redLight = charmtone.Salmon
-		// cherry   = charmtone.Cherry
+		red     = theme.Red
+		redDark = theme.RedDark
 	)
 
 	normalBorder := lipgloss.NormalBorder()
@@ -625,7 +631,7 @@ func DefaultStyles() Styles {
 			StylePrimitive: ansi.StylePrimitive{
 				// BlockPrefix: "\n",
 				// BlockSuffix: "\n",
-				Color: stringPtr(charmtone.Smoke.Hex()),
+				Color: stringPtr(hex(fgHalfMuted)),
 			},
 			// Margin: uintPtr(defaultMargin),
 		},
@@ -640,7 +646,7 @@ func DefaultStyles() Styles {
 		Heading: ansi.StyleBlock{
 			StylePrimitive: ansi.StylePrimitive{
 				BlockSuffix: "\n",
-				Color:       stringPtr(charmtone.Malibu.Hex()),
+				Color:       stringPtr(hex(blue)),
 				Bold:        boolPtr(true),
 			},
 		},
@@ -648,8 +654,8 @@ func DefaultStyles() Styles {
 			StylePrimitive: ansi.StylePrimitive{
 				Prefix:          " ",
 				Suffix:          " ",
-				Color:           stringPtr(charmtone.Zest.Hex()),
-				BackgroundColor: stringPtr(charmtone.Charple.Hex()),
+				Color:           stringPtr(hex(warning)),
+				BackgroundColor: stringPtr(hex(primary)),
 				Bold:            boolPtr(true),
 			},
 		},
@@ -676,7 +682,7 @@ func DefaultStyles() Styles {
 		H6: ansi.StyleBlock{
 			StylePrimitive: ansi.StylePrimitive{
 				Prefix: "###### ",
-				Color:  stringPtr(charmtone.Guac.Hex()),
+				Color:  stringPtr(hex(greenDark)),
 				Bold:   boolPtr(false),
 			},
 		},
@@ -690,7 +696,7 @@ func DefaultStyles() Styles {
 			Bold: boolPtr(true),
 		},
 		HorizontalRule: ansi.StylePrimitive{
-			Color:  stringPtr(charmtone.Charcoal.Hex()),
+			Color:  stringPtr(hex(border)),
 			Format: "\n--------\n",
 		},
 		Item: ansi.StylePrimitive{
@@ -709,7 +715,7 @@ func DefaultStyles() Styles {
 			Underline: boolPtr(true),
 		},
 		LinkText: ansi.StylePrimitive{
-			Color: stringPtr(charmtone.Guac.Hex()),
+			Color: stringPtr(hex(greenDark)),
 			Bold:  boolPtr(true),
 		},
 		Image: ansi.StylePrimitive{
@@ -717,40 +723,40 @@ func DefaultStyles() Styles {
 			Underline: boolPtr(true),
 		},
 		ImageText: ansi.StylePrimitive{
-			Color:  stringPtr(charmtone.Squid.Hex()),
+			Color:  stringPtr(hex(fgMuted)),
 			Format: "Image: {{.text}} →",
 		},
 		Code: ansi.StyleBlock{
 			StylePrimitive: ansi.StylePrimitive{
 				Prefix:          " ",
 				Suffix:          " ",
-				Color:           stringPtr(charmtone.Coral.Hex()),
-				BackgroundColor: stringPtr(charmtone.Charcoal.Hex()),
+				Color:           stringPtr(hex(red)),
+				BackgroundColor: stringPtr(hex(bgSubtle)),
 			},
 		},
 		CodeBlock: ansi.StyleCodeBlock{
 			StyleBlock: ansi.StyleBlock{
 				StylePrimitive: ansi.StylePrimitive{
-					Color: stringPtr(charmtone.Charcoal.Hex()),
+					Color: stringPtr(hex(bgSubtle)),
 				},
 				Margin: uintPtr(defaultMargin),
 			},
 			Chroma: &ansi.Chroma{
 				Text: ansi.StylePrimitive{
-					Color: stringPtr(charmtone.Smoke.Hex()),
+					Color: stringPtr(hex(fgHalfMuted)),
 				},
 				Error: ansi.StylePrimitive{
-					Color:           stringPtr(charmtone.Butter.Hex()),
-					BackgroundColor: stringPtr(charmtone.Sriracha.Hex()),
+					Color:           stringPtr(hex(white)),
+					BackgroundColor: stringPtr(hex(redDark)),
 				},
 				Comment: ansi.StylePrimitive{
-					Color: stringPtr(charmtone.Oyster.Hex()),
+					Color: stringPtr(hex(fgSubtle)),
 				},
 				CommentPreproc: ansi.StylePrimitive{
 					Color: stringPtr(charmtone.Bengal.Hex()),
 				},
 				Keyword: ansi.StylePrimitive{
-					Color: stringPtr(charmtone.Malibu.Hex()),
+					Color: stringPtr(hex(blue)),
 				},
 				KeywordReserved: ansi.StylePrimitive{
 					Color: stringPtr(charmtone.Pony.Hex()),
@@ -765,10 +771,10 @@ func DefaultStyles() Styles {
 					Color: stringPtr(charmtone.Salmon.Hex()),
 				},
 				Punctuation: ansi.StylePrimitive{
-					Color: stringPtr(charmtone.Zest.Hex()),
+					Color: stringPtr(hex(warning)),
 				},
 				Name: ansi.StylePrimitive{
-					Color: stringPtr(charmtone.Smoke.Hex()),
+					Color: stringPtr(hex(fgHalfMuted)),
 				},
 				NameBuiltin: ansi.StylePrimitive{
 					Color: stringPtr(charmtone.Cheeky.Hex()),
@@ -780,42 +786,42 @@ func DefaultStyles() Styles {
 					Color: stringPtr(charmtone.Hazy.Hex()),
 				},
 				NameClass: ansi.StylePrimitive{
-					Color:     stringPtr(charmtone.Salt.Hex()),
+					Color:     stringPtr(hex(fgSelected)),
 					Underline: boolPtr(true),
 					Bold:      boolPtr(true),
 				},
 				NameDecorator: ansi.StylePrimitive{
-					Color: stringPtr(charmtone.Citron.Hex()),
+					Color: stringPtr(hex(highlight)),
 				},
 				NameFunction: ansi.StylePrimitive{
-					Color: stringPtr(charmtone.Guac.Hex()),
+					Color: stringPtr(hex(greenDark)),
 				},
 				LiteralNumber: ansi.StylePrimitive{
-					Color: stringPtr(charmtone.Julep.Hex()),
+					Color: stringPtr(hex(green)),
 				},
 				LiteralString: ansi.StylePrimitive{
 					Color: stringPtr(charmtone.Cumin.Hex()),
 				},
 				LiteralStringEscape: ansi.StylePrimitive{
-					Color: stringPtr(charmtone.Bok.Hex()),
+					Color: stringPtr(hex(tertiary)),
 				},
 				GenericDeleted: ansi.StylePrimitive{
-					Color: stringPtr(charmtone.Coral.Hex()),
+					Color: stringPtr(hex(red)),
 				},
 				GenericEmph: ansi.StylePrimitive{
 					Italic: boolPtr(true),
 				},
 				GenericInserted: ansi.StylePrimitive{
-					Color: stringPtr(charmtone.Guac.Hex()),
+					Color: stringPtr(hex(greenDark)),
 				},
 				GenericStrong: ansi.StylePrimitive{
 					Bold: boolPtr(true),
 				},
 				GenericSubheading: ansi.StylePrimitive{
-					Color: stringPtr(charmtone.Squid.Hex()),
+					Color: stringPtr(hex(fgMuted)),
 				},
 				Background: ansi.StylePrimitive{
-					BackgroundColor: stringPtr(charmtone.Charcoal.Hex()),
+					BackgroundColor: stringPtr(hex(bgSubtle)),
 				},
 			},
 		},
@@ -830,8 +836,8 @@ func DefaultStyles() Styles {
 	}
 
 	// PlainMarkdown style - muted colors on subtle background for thinking content.
-	plainBg := stringPtr(bgBaseLighter.Hex())
-	plainFg := stringPtr(fgMuted.Hex())
+	plainBg := stringPtr(hex(bgBaseLighter))
+	plainFg := stringPtr(hex(fgMuted))
 	s.PlainMarkdown = ansi.StyleConfig{
 		Document: ansi.StyleBlock{
 			StylePrimitive: ansi.StylePrimitive{
@@ -1027,23 +1033,23 @@ func DefaultStyles() Styles {
 		},
 		InsertLine: diffview.LineStyle{
 			LineNumber: lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#629657")).
-				Background(lipgloss.Color("#2b322a")),
+				Foreground(theme.DiffInsert).
+				Background(theme.DiffInsertNumberBg),
 			Symbol: lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#629657")).
-				Background(lipgloss.Color("#323931")),
+				Foreground(theme.DiffInsert).
+				Background(theme.DiffInsertBg),
 			Code: lipgloss.NewStyle().
-				Background(lipgloss.Color("#323931")),
+				Background(theme.DiffInsertBg),
 		},
 		DeleteLine: diffview.LineStyle{
 			LineNumber: lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#a45c59")).
-				Background(lipgloss.Color("#312929")),
+				Foreground(theme.DiffDelete).
+				Background(theme.DiffDeleteNumberBg),
 			Symbol: lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#a45c59")).
-				Background(lipgloss.Color("#383030")),
+				Foreground(theme.DiffDelete).
+				Background(theme.DiffDeleteBg),
 			Code: lipgloss.NewStyle().
-				Background(lipgloss.Color("#383030")),
+				Background(theme.DiffDeleteBg),
 		},
 	}
 
@@ -1178,10 +1184,12 @@ func DefaultStyles() Styles {
 	// Editor
 	s.EditorPromptNormalFocused = lipgloss.NewStyle().Foreground(greenDark).SetString("::: ")
 	s.EditorPromptNormalBlurred = s.EditorPromptNormalFocused.Foreground(fgMuted)
-	s.EditorPromptYoloIconFocused = lipgloss.NewStyle().MarginRight(1).Foreground(charmtone.Oyster).Background(charmtone.Citron).Bold(true).SetString(" ! ")
-	s.EditorPromptYoloIconBlurred = s.EditorPromptYoloIconFocused.Foreground(charmtone.Pepper).Background(charmtone.Squid)
-	s.EditorPromptYoloDotsFocused = lipgloss.NewStyle().MarginRight(1).Foreground(charmtone.Zest).SetString(":::")
-	s.EditorPromptYoloDotsBlurred = s.EditorPromptYoloDotsFocused.Foreground(charmtone.Squid)
+	s.EditorPromptYoloIconFocused = lipgloss.NewStyle().MarginRight(1).Foreground(fgSubtle).Background(highlight).Bold(true).SetString(" ! ")
+	s.EditorPromptYoloIconBlurred = s.EditorPromptYoloIconFocused.Foreground(bgBase).Background(fgMuted)
+	s.EditorPromptYoloDotsFocused = lipgloss.NewStyle().MarginRight(1).Foreground(warning).SetString(":::")
+	s.EditorPromptYoloDotsBlurred = s.EditorPromptYoloDotsFocused.Foreground(fgMuted)
+	s.EditorEstimate = lipgloss.NewStyle().Foreground(fgSubtle).PaddingRight(1)
+	s.EditorEstimateWarn = s.EditorEstimate.Foreground(warning)
 
 	s.RadioOn = s.HalfMuted.SetString(RadioOn)
 	s.RadioOff = s.HalfMuted.SetString(RadioOff)
@@ -1195,7 +1203,7 @@ func DefaultStyles() Styles {
 
 	// Section
 	s.Section.Title = s.Subtle
-	s.Section.Line = s.Base.Foreground(charmtone.Charcoal)
+	s.Section.Line = s.Base.Foreground(border)
 
 	// Initialize
 	s.Initialize.Header = s.Base
@@ -1203,14 +1211,14 @@ func DefaultStyles() Styles {
 	s.Initialize.Accent = s.Base.Foreground(greenDark)
 
 	// LSP and MCP status.
-	s.ResourceGroupTitle = lipgloss.NewStyle().Foreground(charmtone.Oyster)
-	s.ResourceOfflineIcon = lipgloss.NewStyle().Foreground(charmtone.Iron).SetString("●")
-	s.ResourceBusyIcon = s.ResourceOfflineIcon.Foreground(charmtone.Citron)
-	s.ResourceErrorIcon = s.ResourceOfflineIcon.Foreground(charmtone.Coral)
-	s.ResourceOnlineIcon = s.ResourceOfflineIcon.Foreground(charmtone.Guac)
-	s.ResourceName = lipgloss.NewStyle().Foreground(charmtone.Squid)
-	s.ResourceStatus = lipgloss.NewStyle().Foreground(charmtone.Oyster)
-	s.ResourceAdditionalText = lipgloss.NewStyle().Foreground(charmtone.Oyster)
+	s.ResourceGroupTitle = lipgloss.NewStyle().Foreground(fgSubtle)
+	s.ResourceOfflineIcon = lipgloss.NewStyle().Foreground(bgOverlay).SetString("●")
+	s.ResourceBusyIcon = s.ResourceOfflineIcon.Foreground(highlight)
+	s.ResourceErrorIcon = s.ResourceOfflineIcon.Foreground(red)
+	s.ResourceOnlineIcon = s.ResourceOfflineIcon.Foreground(greenDark)
+	s.ResourceName = lipgloss.NewStyle().Foreground(fgMuted)
+	s.ResourceStatus = lipgloss.NewStyle().Foreground(fgSubtle)
+	s.ResourceAdditionalText = lipgloss.NewStyle().Foreground(fgSubtle)
 
 	// LSP
 	s.LSP.ErrorDiagnostic = s.Base.Foreground(redDark)
@@ -1263,7 +1271,7 @@ func DefaultStyles() Styles {
 	s.Chat.Message.ThinkingFooterDuration = s.Subtle
 
 	// Text selection.
-	s.TextSelection = lipgloss.NewStyle().Foreground(charmtone.Salt).Background(charmtone.Charple)
+	s.TextSelection = lipgloss.NewStyle().Foreground(fgSelected).Background(primary)
 
 	// Dialog styles
 	s.Dialog.Title = base.Padding(0, 1).Foreground(primary)
@@ -1306,16 +1314,16 @@ func DefaultStyles() Styles {
 	s.Dialog.Sessions.DeletingTitleGradientFromColor = red
 	s.Dialog.Sessions.DeletingTitleGradientToColor = s.Primary
 	s.Dialog.Sessions.DeletingItemBlurred = s.Dialog.NormalItem.Foreground(fgSubtle)
-	s.Dialog.Sessions.DeletingItemFocused = s.Dialog.SelectedItem.Background(red).Foreground(charmtone.Butter)
+	s.Dialog.Sessions.DeletingItemFocused = s.Dialog.SelectedItem.Background(red).Foreground(white)
 
-	s.Dialog.Sessions.RenamingingTitle = s.Dialog.Title.Foreground(charmtone.Zest)
-	s.Dialog.Sessions.RenamingView = s.Dialog.View.BorderForeground(charmtone.Zest)
+	s.Dialog.Sessions.RenamingingTitle = s.Dialog.Title.Foreground(warning)
+	s.Dialog.Sessions.RenamingView = s.Dialog.View.BorderForeground(warning)
 	s.Dialog.Sessions.RenamingingMessage = s.Base.Padding(1)
-	s.Dialog.Sessions.RenamingTitleGradientFromColor = charmtone.Zest
-	s.Dialog.Sessions.RenamingTitleGradientToColor = charmtone.Bok
+	s.Dialog.Sessions.RenamingTitleGradientFromColor = warning
+	s.Dialog.Sessions.RenamingTitleGradientToColor = tertiary
 	s.Dialog.Sessions.RenamingItemBlurred = s.Dialog.NormalItem.Foreground(fgSubtle)
 	s.Dialog.Sessions.RenamingingItemFocused = s.Dialog.SelectedItem.UnsetBackground().UnsetForeground()
-	s.Dialog.Sessions.RenamingPlaceholder = base.Foreground(charmtone.Squid)
+	s.Dialog.Sessions.RenamingPlaceholder = base.Foreground(fgMuted)
 
 	s.Status.Help = lipgloss.NewStyle().Padding(0, 1)
 	s.Status.SuccessIndicator = base.Foreground(bgSubtle).Background(green).Padding(0, 1).Bold(true).SetString("OKAY!")
@@ -1328,6 +1336,8 @@ func DefaultStyles() Styles {
 	s.Status.UpdateMessage = s.Status.SuccessMessage
 	s.Status.WarnMessage = s.Status.SuccessMessage.Foreground(bgOverlay).Background(warning)
 	s.Status.ErrorMessage = s.Status.SuccessMessage.Foreground(white).Background(redDark)
+	s.Status.Context = base.Foreground(fgSubtle).Padding(0, 1)
+	s.Status.ContextWarn = base.Foreground(warning).Padding(0, 1)
 
 	// Completions styles
 	s.Completions.Normal = base.Background(bgSubtle).Foreground(fgBase)
@@ -1355,6 +1365,10 @@ func DefaultStyles() Styles {
 }
 
 // Helper functions for style pointers
+func hex(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02X%02X%02X", r>>8, g>>8, b>>8)
+}
 func boolPtr(b bool) *bool       { return &b }
 func stringPtr(s string) *string { return &s }
 func uintPtr(u uint) *uint       { return &u }
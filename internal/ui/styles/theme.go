@@ -0,0 +1,329 @@
+package styles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/exp/charmtone"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Names of the bundled themes.
+const (
+	ThemeDark  = "dark"
+	ThemeLight = "light"
+)
+
+// DefaultTheme is the theme used when none is selected.
+const DefaultTheme = ThemeDark
+
+// Theme is the palette the styles of the UI are derived from. The accents
+// of syntax highlighting are the same in every theme.
+type Theme struct {
+	Name string `json:"-"`
+
+	Primary   color.Color `json:"primary"`
+	Secondary color.Color `json:"secondary"`
+	Tertiary  color.Color `json:"tertiary"`
+
+	BgBase        color.Color `json:"bg_base"`
+	BgBaseLighter color.Color `json:"bg_base_lighter"`
+	BgSubtle      color.Color `json:"bg_subtle"`
+	BgOverlay     color.Color `json:"bg_overlay"`
+
+	FgBase      color.Color `json:"fg_base"`
+	FgMuted     color.Color `json:"fg_muted"`
+	FgHalfMuted color.Color `json:"fg_half_muted"`
+	FgSubtle    color.Color `json:"fg_subtle"`
+	FgSelected  color.Color `json:"fg_selected"`
+
+	Border      color.Color `json:"border"`
+	BorderFocus color.Color `json:"border_focus"`
+
+	Error   color.Color `json:"error"`
+	Warning color.Color `json:"warning"`
+	Info    color.Color `json:"info"`
+
+	White      color.Color `json:"white"`
+	BlueLight  color.Color `json:"blue_light"`
+	Blue       color.Color `json:"blue"`
+	BlueDark   color.Color `json:"blue_dark"`
+	Yellow     color.Color `json:"yellow"`
+	Highlight  color.Color `json:"highlight"`
+	GreenLight color.Color `json:"green_light"`
+	Green      color.Color `json:"green"`
+	GreenDark  color.Color `json:"green_dark"`
+	Red        color.Color `json:"red"`
+	RedDark    color.Color `json:"red_dark"`
+
+	DiffInsert         color.Color `json:"diff_insert"`
+	DiffInsertBg       color.Color `json:"diff_insert_bg"`
+	DiffInsertNumberBg color.Color `json:"diff_insert_number_bg"`
+	DiffDelete         color.Color `json:"diff_delete"`
+	DiffDeleteBg       color.Color `json:"diff_delete_bg"`
+	DiffDeleteNumberBg color.Color `json:"diff_delete_number_bg"`
+}
+
+// DarkTheme returns the bundled dark theme, the default one.
+func DarkTheme() Theme {
+	return Theme{
+		Name: ThemeDark,
+
+		Primary:   charmtone.Charple,
+		Secondary: charmtone.Dolly,
+		Tertiary:  charmtone.Bok,
+
+		BgBase:        charmtone.Pepper,
+		BgBaseLighter: charmtone.BBQ,
+		BgSubtle:      charmtone.Charcoal,
+		BgOverlay:     charmtone.Iron,
+
+		FgBase:      charmtone.Ash,
+		FgMuted:     charmtone.Squid,
+		FgHalfMuted: charmtone.Smoke,
+		FgSubtle:    charmtone.Oyster,
+		FgSelected:  charmtone.Salt,
+
+		Border:      charmtone.Charcoal,
+		BorderFocus: charmtone.Charple,
+
+		Error:   charmtone.Sriracha,
+		Warning: charmtone.Zest,
+		Info:    charmtone.Malibu,
+
+		White:      charmtone.Butter,
+		BlueLight:  charmtone.Sardine,
+		Blue:       charmtone.Malibu,
+		BlueDark:   charmtone.Damson,
+		Yellow:     charmtone.Mustard,
+		Highlight:  charmtone.Citron,
+		GreenLight: charmtone.Bok,
+		Green:      charmtone.Julep,
+		GreenDark:  charmtone.Guac,
+		Red:        charmtone.Coral,
+		RedDark:    charmtone.Sriracha,
+
+		DiffInsert:         lipgloss.Color("#629657"),
+		DiffInsertBg:       lipgloss.Color("#323931"),
+		DiffInsertNumberBg: lipgloss.Color("#2b322a"),
+		DiffDelete:         lipgloss.Color("#a45c59"),
+		DiffDeleteBg:       lipgloss.Color("#383030"),
+		DiffDeleteNumberBg: lipgloss.Color("#312929"),
+	}
+}
+
+// LightTheme returns the bundled light theme, for light terminals.
+func LightTheme() Theme {
+	return Theme{
+		Name: ThemeLight,
+
+		Primary:   charmtone.Charple,
+		Secondary: charmtone.Macaron,
+		Tertiary:  charmtone.Pickle,
+
+		BgBase:        charmtone.Butter,
+		BgBaseLighter: charmtone.Salt,
+		BgSubtle:      charmtone.Ash,
+		BgOverlay:     charmtone.Smoke,
+
+		FgBase:      charmtone.Pepper,
+		FgMuted:     charmtone.Oyster,
+		FgHalfMuted: charmtone.Iron,
+		FgSubtle:    charmtone.Squid,
+		FgSelected:  charmtone.Pepper,
+
+		Border:      charmtone.Ash,
+		BorderFocus: charmtone.Charple,
+
+		Error:   charmtone.Sriracha,
+		Warning: charmtone.Tang,
+		Info:    charmtone.Damson,
+
+		White:      charmtone.Butter,
+		BlueLight:  charmtone.Malibu,
+		Blue:       charmtone.Damson,
+		BlueDark:   charmtone.Oceania,
+		Yellow:     charmtone.Cumin,
+		Highlight:  charmtone.Mustard,
+		GreenLight: charmtone.Guac,
+		Green:      charmtone.NeueGuac,
+		GreenDark:  charmtone.Pickle,
+		Red:        charmtone.Chili,
+		RedDark:    charmtone.Pom,
+
+		DiffInsert:         lipgloss.Color("#2e7d32"),
+		DiffInsertBg:       lipgloss.Color("#e6f4e4"),
+		DiffInsertNumberBg: lipgloss.Color("#d5ecd2"),
+		DiffDelete:         lipgloss.Color("#b3403a"),
+		DiffDeleteBg:       lipgloss.Color("#fbe9e7"),
+		DiffDeleteNumberBg: lipgloss.Color("#f6d6d3"),
+	}
+}
+
+// themeFile is a user theme as written in TOML or JSON. Colors left out are
+// those of the base theme.
+type themeFile struct {
+	Name   string            `json:"name" toml:"name"`
+	Base   string            `json:"base" toml:"base"`
+	Colors map[string]string `json:"colors" toml:"colors"`
+}
+
+// Registry holds the themes that can be selected: the bundled ones and
+// those loaded from a directory. It is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	themes map[string]Theme
+	order  []string
+}
+
+// NewRegistry returns a registry holding the bundled themes.
+func NewRegistry() *Registry {
+	r := &Registry{themes: make(map[string]Theme)}
+	r.Add(DarkTheme())
+	r.Add(LightTheme())
+	return r
+}
+
+// Add adds theme, replacing the one of the same name.
+func (r *Registry) Add(theme Theme) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.themes[theme.Name]; !ok {
+		r.order = append(r.order, theme.Name)
+	}
+	r.themes[theme.Name] = theme
+}
+
+// Get returns the theme called name.
+func (r *Registry) Get(name string) (Theme, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	theme, ok := r.themes[name]
+	return theme, ok
+}
+
+// Names returns the names of the themes, the bundled ones first.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.order)
+}
+
+// Styles returns the styles of the theme called name, or of the default
+// theme when name is empty.
+func (r *Registry) Styles(name string) (Styles, error) {
+	if name == "" {
+		name = DefaultTheme
+	}
+	theme, ok := r.Get(name)
+	if !ok {
+		return Styles{}, fmt.Errorf("unknown theme %q", name)
+	}
+	return NewStyles(theme), nil
+}
+
+// LoadDir adds the themes of the .toml and .json files in dir. A missing
+// directory holds no themes; a file that fails to load is reported in the
+// error while the others are still added.
+func (r *Registry) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".toml" && ext != ".json") {
+			continue
+		}
+		theme, err := r.loadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.Add(theme)
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Registry) loadFile(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+	var file themeFile
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		err = toml.Unmarshal(data, &file)
+	} else {
+		err = json.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+	}
+
+	base := file.Base
+	if base == "" {
+		base = DefaultTheme
+	}
+	theme, ok := r.Get(base)
+	if !ok {
+		return Theme{}, fmt.Errorf("theme %s: unknown base theme %q", path, base)
+	}
+	theme.Name = file.Name
+	if theme.Name == "" {
+		theme.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := theme.setColors(file.Colors); err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+	}
+	return theme, nil
+}
+
+// setColors sets the colors named by their JSON key to hex values.
+func (t *Theme) setColors(colors map[string]string) error {
+	fields := make(map[string]reflect.Value)
+	v := reflect.ValueOf(t).Elem()
+	for i := range v.NumField() {
+		if key := v.Type().Field(i).Tag.Get("json"); key != "-" {
+			fields[key] = v.Field(i)
+		}
+	}
+	for key, value := range colors {
+		field, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("unknown color %q", key)
+		}
+		c, err := parseHex(value)
+		if err != nil {
+			return fmt.Errorf("color %s: %w", key, err)
+		}
+		field.Set(reflect.ValueOf(c))
+	}
+	return nil
+}
+
+// parseHex parses a color written as #RRGGBB or #RGB.
+func parseHex(s string) (color.Color, error) {
+	h, ok := strings.CutPrefix(strings.TrimSpace(s), "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	n, err := strconv.ParseUint(h, 16, 32)
+	if !ok || len(h) != 6 || err != nil {
+		return nil, fmt.Errorf("invalid color %q: use #RRGGBB", s)
+	}
+	return color.RGBA{R: uint8(n >> 16), G: uint8(n >> 8), B: uint8(n), A: 0xff}, nil
+}
@@ -0,0 +1,51 @@
+package styles
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryLoadDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"solarized.toml": "base = \"light\"\n\n[colors]\nprimary = \"#268bd2\"\nbg_base = \"#fdf6e3\"\n",
+		"neon.json":      `{"name": "Neon", "colors": {"primary": "#f0f"}}`,
+		"broken.toml":    "[colors]\nprimary = \"blue\"\n",
+		"unknown.json":   `{"colors": {"sparkle": "#fff"}}`,
+		"notes.txt":      "not a theme",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	r := NewRegistry()
+	err := r.LoadDir(dir)
+	require.ErrorContains(t, err, `invalid color "blue"`)
+	require.ErrorContains(t, err, `unknown color "sparkle"`)
+	require.Equal(t, []string{ThemeDark, ThemeLight, "Neon", "solarized"}, r.Names())
+
+	solarized, ok := r.Get("solarized")
+	require.True(t, ok)
+	require.Equal(t, color.RGBA{R: 0x26, G: 0x8b, B: 0xd2, A: 0xff}, solarized.Primary)
+	require.Equal(t, color.RGBA{R: 0xfd, G: 0xf6, B: 0xe3, A: 0xff}, solarized.BgBase)
+	require.Equal(t, LightTheme().FgBase, solarized.FgBase, "colors left out come from the base theme")
+
+	neon, ok := r.Get("Neon")
+	require.True(t, ok)
+	require.Equal(t, color.RGBA{R: 0xff, G: 0x00, B: 0xff, A: 0xff}, neon.Primary)
+	require.Equal(t, DarkTheme().BgBase, neon.BgBase)
+
+	s, err := r.Styles("solarized")
+	require.NoError(t, err)
+	require.Equal(t, solarized.BgBase, s.Background)
+	_, err = r.Styles("missing")
+	require.Error(t, err)
+
+	require.NoError(t, NewRegistry().LoadDir(filepath.Join(dir, "missing")))
+}
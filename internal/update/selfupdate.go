@@ -0,0 +1,332 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	checksumsAsset = "checksums.txt"
+	signatureAsset = "checksums.txt.sigstore.json"
+
+	// Releases are signed keylessly with cosign from GitHub Actions, by the
+	// release workflow of crush for tags and the nightly one for main.
+	certificateIdentity = `^https://github\.com/charmbracelet/crush/\.github/workflows/(release\.yml@refs/tags/v|nightly\.yml@refs/heads/main$)`
+	certificateIssuer   = "https://token.actions.githubusercontent.com"
+
+	// maxBinarySize guards against decompression bombs.
+	maxBinarySize = 512 << 20
+)
+
+// Channel is the release channel updates are taken from.
+type Channel string
+
+const (
+	ChannelStable  Channel = "stable"
+	ChannelNightly Channel = "nightly"
+)
+
+// ParseChannel validates a channel name.
+func ParseChannel(s string) (Channel, error) {
+	switch c := Channel(s); c {
+	case ChannelStable, ChannelNightly:
+		return c, nil
+	case "":
+		return ChannelStable, nil
+	default:
+		return "", fmt.Errorf("unknown channel %q: must be %s or %s", s, ChannelStable, ChannelNightly)
+	}
+}
+
+// Source fetches releases and their assets for self-updates.
+type Source interface {
+	Release(ctx context.Context, channel Channel) (*Release, error)
+	Download(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// DefaultSource is the default [Source].
+var DefaultSource Source = &github{}
+
+// VerifyFunc verifies the signature bundle of the checksums file.
+type VerifyFunc func(ctx context.Context, checksums, bundle []byte) error
+
+// Candidate is a release asset the running binary can be replaced with.
+type Candidate struct {
+	Version string
+	Channel Channel
+	URL     string
+	Asset   Asset
+	release *Release
+}
+
+// Find looks up the release archive for the running platform on channel.
+func Find(ctx context.Context, src Source, channel Channel) (*Candidate, error) {
+	release, err := src.Release(ctx, channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s release: %w", channel, err)
+	}
+	suffix := assetSuffix(runtime.GOOS, runtime.GOARCH)
+	for _, asset := range release.Assets {
+		if !strings.HasPrefix(asset.Name, "crush_") || !strings.HasSuffix(asset.Name, suffix) {
+			continue
+		}
+		return &Candidate{
+			Version: strings.TrimSuffix(strings.TrimPrefix(asset.Name, "crush_"), suffix),
+			Channel: channel,
+			URL:     release.HTMLURL,
+			Asset:   asset,
+			release: release,
+		}, nil
+	}
+	return nil, fmt.Errorf("no %s release archive for %s/%s", channel, runtime.GOOS, runtime.GOARCH)
+}
+
+// assetSuffix returns the end of the archive name goreleaser produces for a
+// platform, e.g. "_Linux_x86_64.tar.gz".
+func assetSuffix(goos, goarch string) string {
+	arch := goarch
+	switch goarch {
+	case "amd64":
+		arch = "x86_64"
+	case "386":
+		arch = "i386"
+	case "arm":
+		arch = "armv7"
+	}
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return "_" + strings.ToUpper(goos[:1]) + goos[1:] + "_" + arch + ext
+}
+
+// InstallOptions configures [Install].
+type InstallOptions struct {
+	// Executable is the binary to replace. Defaults to the running one.
+	Executable string
+	// SkipSignature skips the signature check of the checksums file. The
+	// archive checksum is still verified.
+	SkipSignature bool
+	// Verify checks the signature. Defaults to running cosign.
+	Verify VerifyFunc
+}
+
+// Install downloads the candidate, verifies it and replaces the executable
+// with the binary it contains.
+func Install(ctx context.Context, src Source, c *Candidate, opts InstallOptions) error {
+	exe := opts.Executable
+	if exe == "" {
+		var err error
+		if exe, err = os.Executable(); err != nil {
+			return fmt.Errorf("failed to locate executable: %w", err)
+		}
+	}
+	exe, err := filepath.EvalSymlinks(exe)
+	if err != nil {
+		return fmt.Errorf("failed to resolve executable: %w", err)
+	}
+
+	checksums, err := download(ctx, src, c.release, checksumsAsset)
+	if err != nil {
+		return err
+	}
+	if !opts.SkipSignature {
+		bundle, err := download(ctx, src, c.release, signatureAsset)
+		if err != nil {
+			return err
+		}
+		verify := opts.Verify
+		if verify == nil {
+			verify = CosignVerify
+		}
+		if err := verify(ctx, checksums, bundle); err != nil {
+			return fmt.Errorf("signature verification failed: %w", err)
+		}
+	}
+
+	want, err := Checksum(checksums, c.Asset.Name)
+	if err != nil {
+		return err
+	}
+	archive, err := download(ctx, src, c.release, c.Asset.Name)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(archive)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", c.Asset.Name, got, want)
+	}
+
+	binary, err := Extract(c.Asset.Name, archive)
+	if err != nil {
+		return err
+	}
+	return Replace(exe, binary)
+}
+
+func download(ctx context.Context, src Source, release *Release, name string) ([]byte, error) {
+	for _, asset := range release.Assets {
+		if asset.Name != name {
+			continue
+		}
+		body, err := src.Download(ctx, asset.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", name, err)
+		}
+		defer body.Close()
+		data, err := io.ReadAll(io.LimitReader(body, maxBinarySize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", name, err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("release %s has no %s", release.TagName, name)
+}
+
+// Checksum returns the SHA-256 listed for name in a checksums file.
+func Checksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum for %s", name)
+}
+
+// CosignVerify verifies the checksums file against its sigstore bundle with
+// the cosign CLI.
+func CosignVerify(ctx context.Context, checksums, bundle []byte) error {
+	cosign, err := exec.LookPath("cosign")
+	if err != nil {
+		return errors.New("cosign is not installed; install it or skip the signature check")
+	}
+	dir, err := os.MkdirTemp("", "crush-update-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	blob := filepath.Join(dir, checksumsAsset)
+	sig := filepath.Join(dir, signatureAsset)
+	if err := os.WriteFile(blob, checksums, 0o600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(sig, bundle, 0o600); err != nil {
+		return err
+	}
+	out, err := exec.CommandContext(ctx, cosign, "verify-blob",
+		"--bundle", sig,
+		"--certificate-identity-regexp", certificateIdentity,
+		"--certificate-oidc-issuer", certificateIssuer,
+		blob,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Extract returns the crush binary from a release archive.
+func Extract(name string, archive []byte) ([]byte, error) {
+	if strings.HasSuffix(name, ".zip") {
+		return extractZip(archive)
+	}
+	return extractTarGz(archive)
+}
+
+func extractTarGz(archive []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("archive does not contain the crush binary")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeReg && filepath.Base(hdr.Name) == "crush" {
+			return io.ReadAll(io.LimitReader(tr, maxBinarySize))
+		}
+	}
+}
+
+func extractZip(archive []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || filepath.Base(f.Name) != "crush.exe" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		defer rc.Close()
+		return io.ReadAll(io.LimitReader(rc, maxBinarySize))
+	}
+	return nil, errors.New("archive does not contain the crush binary")
+}
+
+// Replace atomically swaps the file at exe for binary. On Windows the
+// running executable cannot be overwritten, so it is moved aside first.
+func Replace(exe string, binary []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".crush-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to stage update: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to stage update: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to stage update: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return fmt.Errorf("failed to replace executable: %w", err)
+		}
+		if err := os.Rename(tmp.Name(), exe); err != nil {
+			_ = os.Rename(old, exe)
+			return fmt.Errorf("failed to replace executable: %w", err)
+		}
+		return nil
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("failed to replace executable: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetSuffix(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "_Linux_x86_64.tar.gz", assetSuffix("linux", "amd64"))
+	require.Equal(t, "_Darwin_arm64.tar.gz", assetSuffix("darwin", "arm64"))
+	require.Equal(t, "_Linux_armv7.tar.gz", assetSuffix("linux", "arm"))
+	require.Equal(t, "_Windows_i386.zip", assetSuffix("windows", "386"))
+}
+
+func TestParseChannel(t *testing.T) {
+	t.Parallel()
+	c, err := ParseChannel("")
+	require.NoError(t, err)
+	require.Equal(t, ChannelStable, c)
+	c, err = ParseChannel("nightly")
+	require.NoError(t, err)
+	require.Equal(t, ChannelNightly, c)
+	_, err = ParseChannel("beta")
+	require.Error(t, err)
+}
+
+func TestCertificateIdentity(t *testing.T) {
+	t.Parallel()
+	re := regexp.MustCompile(certificateIdentity)
+	require.True(t, re.MatchString("https://github.com/charmbracelet/crush/.github/workflows/release.yml@refs/tags/v0.20.0"))
+	require.True(t, re.MatchString("https://github.com/charmbracelet/crush/.github/workflows/nightly.yml@refs/heads/main"))
+	require.False(t, re.MatchString("https://github.com/charmbracelet/glow/.github/workflows/release.yml@refs/tags/v2.0.0"))
+	require.False(t, re.MatchString("https://github.com/charmbracelet/crush/.github/workflows/build.yml@refs/heads/main"))
+	require.False(t, re.MatchString("https://github.com/charmbracelet/crush/.github/workflows/nightly.yml@refs/heads/main-fork"))
+}
+
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+	checksums := []byte("abc123  crush_1.0.0_Linux_x86_64.tar.gz\nDEF456 *crush_1.0.0_Windows_x86_64.zip\n")
+	sum, err := Checksum(checksums, "crush_1.0.0_Linux_x86_64.tar.gz")
+	require.NoError(t, err)
+	require.Equal(t, "abc123", sum)
+	sum, err = Checksum(checksums, "crush_1.0.0_Windows_x86_64.zip")
+	require.NoError(t, err)
+	require.Equal(t, "def456", sum)
+	_, err = Checksum(checksums, "crush_1.0.0_Darwin_arm64.tar.gz")
+	require.Error(t, err)
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+	t.Run("tar.gz", func(t *testing.T) {
+		t.Parallel()
+		binary, err := Extract("crush_1.0.0_Linux_x86_64.tar.gz", tarGz(t, "crush_1.0.0_Linux_x86_64/crush", "binary"))
+		require.NoError(t, err)
+		require.Equal(t, "binary", string(binary))
+	})
+	t.Run("zip", func(t *testing.T) {
+		t.Parallel()
+		binary, err := Extract("crush_1.0.0_Windows_x86_64.zip", zipped(t, "crush_1.0.0_Windows_x86_64/crush.exe", "binary"))
+		require.NoError(t, err)
+		require.Equal(t, "binary", string(binary))
+	})
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+		_, err := Extract("crush_1.0.0_Linux_x86_64.tar.gz", tarGz(t, "README.md", "readme"))
+		require.Error(t, err)
+	})
+}
+
+func TestReplace(t *testing.T) {
+	t.Parallel()
+	exe := filepath.Join(t.TempDir(), "crush")
+	require.NoError(t, os.WriteFile(exe, []byte("old"), 0o755))
+	require.NoError(t, Replace(exe, []byte("new")))
+
+	got, err := os.ReadFile(exe)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(got))
+	entries, err := os.ReadDir(filepath.Dir(exe))
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "staged file should not be left behind")
+}
+
+func TestInstall(t *testing.T) {
+	t.Parallel()
+	name := "crush_1.1.0" + assetSuffix(runtime.GOOS, runtime.GOARCH)
+	var archive []byte
+	if runtime.GOOS == "windows" {
+		archive = zipped(t, "crush/crush.exe", "new")
+	} else {
+		archive = tarGz(t, "crush/crush", "new")
+	}
+	sum := sha256.Sum256(archive)
+
+	newSource := func(checksum string) testSource {
+		return testSource{
+			checksumsAsset: fmt.Sprintf("%s  %s\n", checksum, name),
+			signatureAsset: "{}",
+			name:           string(archive),
+		}
+	}
+	install := func(t *testing.T, src testSource, opts InstallOptions) error {
+		c, err := Find(t.Context(), src, ChannelStable)
+		require.NoError(t, err)
+		require.Equal(t, "1.1.0", c.Version)
+		opts.Executable = filepath.Join(t.TempDir(), "crush")
+		require.NoError(t, os.WriteFile(opts.Executable, []byte("old"), 0o755))
+		if err := Install(t.Context(), src, c, opts); err != nil {
+			return err
+		}
+		got, err := os.ReadFile(opts.Executable)
+		require.NoError(t, err)
+		require.Equal(t, "new", string(got))
+		return nil
+	}
+	verified := func(context.Context, []byte, []byte) error { return nil }
+
+	t.Run("verified", func(t *testing.T) {
+		t.Parallel()
+		require.NoError(t, install(t, newSource(hex.EncodeToString(sum[:])), InstallOptions{Verify: verified}))
+	})
+	t.Run("bad signature", func(t *testing.T) {
+		t.Parallel()
+		err := install(t, newSource(hex.EncodeToString(sum[:])), InstallOptions{
+			Verify: func(context.Context, []byte, []byte) error { return errors.New("bad signature") },
+		})
+		require.ErrorContains(t, err, "signature verification failed")
+	})
+	t.Run("bad checksum", func(t *testing.T) {
+		t.Parallel()
+		err := install(t, newSource("0000"), InstallOptions{SkipSignature: true})
+		require.ErrorContains(t, err, "checksum mismatch")
+	})
+}
+
+// testSource serves a release whose assets are the keys of the map.
+type testSource map[string]string
+
+func (s testSource) Release(context.Context, Channel) (*Release, error) {
+	release := &Release{TagName: "v1.1.0"}
+	for name := range s {
+		release.Assets = append(release.Assets, Asset{Name: name, URL: name})
+	}
+	return release, nil
+}
+
+func (s testSource) Download(_ context.Context, url string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewBufferString(s[url])), nil
+}
+
+func tarGz(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+	_, err := tw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func zipped(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(name)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
@@ -13,7 +13,11 @@ import (
 
 const (
 	githubApiUrl = "https://api.github.com/repos/charmbracelet/crush/releases/latest"
+	githubTagUrl = "https://api.github.com/repos/charmbracelet/crush/releases/tags/"
 	userAgent    = "crush/1.0"
+
+	// downloadTimeout is how long downloading a release asset may take.
+	downloadTimeout = 5 * time.Minute
 )
 
 // Default is the default [Client].
@@ -74,8 +78,15 @@ func Check(ctx context.Context, current string, client Client) (Info, error) {
 
 // Release represents a GitHub release.
 type Release struct {
-	TagName string `json:"tag_name"`
-	HTMLURL string `json:"html_url"`
+	TagName string  `json:"tag_name"`
+	HTMLURL string  `json:"html_url"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
 }
 
 // Client is a client that can get the latest release.
@@ -87,11 +98,45 @@ type github struct{}
 
 // Latest implements [Client].
 func (c *github) Latest(ctx context.Context) (*Release, error) {
+	return c.fetch(ctx, githubApiUrl)
+}
+
+// Release implements [Source].
+func (c *github) Release(ctx context.Context, channel Channel) (*Release, error) {
+	if channel == ChannelNightly {
+		return c.fetch(ctx, githubTagUrl+"nightly")
+	}
+	return c.fetch(ctx, githubApiUrl)
+}
+
+// Download implements [Source].
+func (c *github) Download(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	client := &http.Client{
+		Timeout: downloadTimeout,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("download of %s returned status %d", url, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+func (c *github) fetch(ctx context.Context, url string) (*Release, error) {
 	client := &http.Client{
 		Timeout: 30 * time.Second,
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", githubApiUrl, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,81 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+// commandTools are the built-in tools running shell commands, whose
+// arguments are checked against the denied paths too.
+var commandTools = map[string]bool{
+	tools.BashToolName:    true,
+	tools.ProcessToolName: true,
+}
+
+// deniedTool refuses tool calls on the paths that .crushignore files and
+// the denied paths of the configuration keep away from the agent.
+type deniedTool struct {
+	fantasy.AgentTool
+	param      pathParam
+	workingDir string
+}
+
+// WrapDenied makes the built-in filesystem tools in agentTools refuse the
+// paths [fsext.Denied] denies, in place, and returns the slice. Shell
+// commands are refused when one of their plain arguments names such a path,
+// a best effort since a command can reach files in many other ways.
+// Relative paths are resolved against workingDir as the tools do.
+func WrapDenied(agentTools []fantasy.AgentTool, workingDir string) []fantasy.AgentTool {
+	for i, tool := range agentTools {
+		param, ok := pathParams[tool.Info().Name]
+		if _, wrapped := tool.(*deniedTool); ok && !wrapped {
+			agentTools[i] = &deniedTool{AgentTool: tool, param: param, workingDir: workingDir}
+		}
+	}
+	return agentTools
+}
+
+func (t *deniedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	var params map[string]any
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		// Let the tool report the malformed input.
+		return t.AgentTool.Run(ctx, call)
+	}
+	path, _ := params[t.param.name].(string)
+	dir := filepathext.SmartJoin(t.workingDir, path)
+	if path != "" && fsext.Denied(dir) {
+		return fantasy.NewTextErrorResponse(deniedMessage(path)), nil
+	}
+	if !commandTools[t.Info().Name] {
+		return t.AgentTool.Run(ctx, call)
+	}
+	if path == "" {
+		dir = t.workingDir
+	}
+	command, _ := params["command"].(string)
+	for _, word := range shell.Words(command) {
+		// Options such as --env-file=.env name files too.
+		if _, value, ok := strings.Cut(word, "="); ok && strings.HasPrefix(word, "-") {
+			word = value
+		}
+		if word == "" || strings.ContainsAny(word, "*?[") {
+			continue
+		}
+		if fsext.Denied(filepathext.SmartJoin(dir, word)) {
+			return fantasy.NewTextErrorResponse(deniedMessage(word)), nil
+		}
+	}
+	return t.AgentTool.Run(ctx, call)
+}
+
+func deniedMessage(path string) string {
+	return fmt.Sprintf("access to %s is denied: it is excluded by a %s file or by permissions.denied_paths. Do not try to read, change or send it in any other way.", path, fsext.CrushIgnoreName)
+}
@@ -0,0 +1,94 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/filepathext"
+)
+
+// pathParam describes where a built-in tool takes the path it works on.
+type pathParam struct {
+	name  string
+	write bool
+	// optional means an empty parameter makes the tool work on the
+	// working directory.
+	optional bool
+}
+
+// pathParams lists the built-in tools that touch the filesystem. Shell
+// commands and test runs count as writes to the directory they run in, since
+// they can change anything there.
+var pathParams = map[string]pathParam{
+	tools.ViewToolName:        {name: "file_path"},
+	tools.LSToolName:          {name: "path", optional: true},
+	tools.GlobToolName:        {name: "path", optional: true},
+	tools.GrepToolName:        {name: "path", optional: true},
+	tools.CodeSearchToolName:  {name: "path", optional: true},
+	tools.ReferencesToolName:  {name: "path", optional: true},
+	tools.DiagnosticsToolName: {name: "file_path"},
+	tools.EditToolName:        {name: "file_path", write: true},
+	tools.EditSymbolToolName:  {name: "file_path", write: true},
+	tools.MultiEditToolName:   {name: "file_path", write: true},
+	tools.WriteToolName:       {name: "file_path", write: true},
+	tools.DownloadToolName:    {name: "file_path", write: true},
+	tools.ReplaceAllToolName:  {name: "path", write: true, optional: true},
+	tools.RunTestsToolName:    {name: "path", write: true, optional: true},
+	tools.BashToolName:        {name: "working_dir", write: true, optional: true},
+	tools.ProcessToolName:     {name: "working_dir", write: true, optional: true},
+}
+
+// guardedTool refuses tool calls on paths the manifest does not allow.
+type guardedTool struct {
+	fantasy.AgentTool
+	manifest   *Manifest
+	param      pathParam
+	workingDir string
+}
+
+// WrapTools limits the built-in filesystem tools in agentTools to the roots
+// of m, in place, and returns the slice. Relative paths are resolved against
+// workingDir as the tools do. It does nothing when m is nil.
+func (m *Manifest) WrapTools(agentTools []fantasy.AgentTool, workingDir string) []fantasy.AgentTool {
+	if m == nil {
+		return agentTools
+	}
+	for i, tool := range agentTools {
+		param, ok := pathParams[tool.Info().Name]
+		if _, wrapped := tool.(*guardedTool); ok && !wrapped {
+			agentTools[i] = &guardedTool{AgentTool: tool, manifest: m, param: param, workingDir: workingDir}
+		}
+	}
+	return agentTools
+}
+
+func (t *guardedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	var params map[string]any
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		// Let the tool report the malformed input.
+		return t.AgentTool.Run(ctx, call)
+	}
+	path, _ := params[t.param.name].(string)
+	if path == "" && !t.param.optional {
+		return t.AgentTool.Run(ctx, call)
+	}
+	if err := t.manifest.Check(filepathext.SmartJoin(t.workingDir, path), t.param.write); err != nil {
+		return fantasy.NewTextErrorResponse(t.explain(err)), nil
+	}
+	return t.AgentTool.Run(ctx, call)
+}
+
+func (t *guardedTool) explain(err error) string {
+	switch {
+	case errors.Is(err, ErrOutside):
+		return fmt.Sprintf("%s. Only the roots listed in %s can be used.", err, ManifestName)
+	case errors.Is(err, ErrReadOnly):
+		return fmt.Sprintf("%s, so it cannot be changed.", err)
+	default:
+		return err.Error()
+	}
+}
@@ -0,0 +1,186 @@
+// Package workspace reads the crush.workspace.json manifest, which lists the
+// directories an agent may work with and whether it may change them. With a
+// manifest in place, paths outside its roots are off limits.
+package workspace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ManifestName is the name of the manifest file looked up in the working
+// directory.
+const ManifestName = "crush.workspace.json"
+
+// Access is what the agent may do inside a root.
+type Access string
+
+const (
+	// AccessRead lets the agent read files but not change them.
+	AccessRead Access = "read"
+	// AccessWrite lets the agent read and change files.
+	AccessWrite Access = "write"
+)
+
+var (
+	// ErrOutside is returned for paths that are not inside any root.
+	ErrOutside = errors.New("path is outside the workspace roots")
+	// ErrReadOnly is returned when changing a path in a read-only root.
+	ErrReadOnly = errors.New("path is in a read-only workspace root")
+)
+
+// Root is a directory listed in the manifest.
+type Root struct {
+	// Path is the absolute path of the directory.
+	Path string `json:"path"`
+	// Label is a short name for the root shown to the agent and the user.
+	Label string `json:"label,omitempty"`
+	// Access is what the agent may do inside the root. It defaults to
+	// [AccessRead].
+	Access Access `json:"access,omitempty"`
+}
+
+// Manifest is a parsed crush.workspace.json. A nil Manifest places no
+// restrictions.
+type Manifest struct {
+	// Path is where the manifest was read from.
+	Path string
+
+	roots []Root
+}
+
+// manifestFile is the layout of crush.workspace.json.
+type manifestFile struct {
+	Roots []Root `json:"roots"`
+}
+
+// Load reads the manifest in dir. It returns nil without an error when dir
+// has none.
+func Load(dir string) (*Manifest, error) {
+	path := filepath.Join(dir, ManifestName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, err := Parse(data, dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	m.Path = path
+	return m, nil
+}
+
+// Parse reads a manifest from data. Relative root paths are resolved
+// against dir. Unknown fields are rejected so a typo cannot silently widen
+// access.
+func Parse(data []byte, dir string) (*Manifest, error) {
+	var m manifestFile
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if len(m.Roots) == 0 {
+		return nil, errors.New("manifest lists no roots")
+	}
+
+	seen := make(map[string]bool, len(m.Roots))
+	for i, root := range m.Roots {
+		if root.Path == "" {
+			return nil, fmt.Errorf("root %d has no path", i+1)
+		}
+		path := root.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		path = resolve(filepath.Clean(path))
+		if seen[path] {
+			return nil, fmt.Errorf("root %s is listed twice", root.Path)
+		}
+		seen[path] = true
+
+		switch root.Access {
+		case "":
+			root.Access = AccessRead
+		case AccessRead, AccessWrite:
+		default:
+			return nil, fmt.Errorf("root %s has unknown access %q, use %q or %q", root.Path, root.Access, AccessRead, AccessWrite)
+		}
+		if root.Label == "" {
+			root.Label = filepath.Base(path)
+		}
+		root.Path = path
+		m.Roots[i] = root
+	}
+	return &Manifest{roots: m.Roots}, nil
+}
+
+// Roots returns the roots of m, or nil when m is nil.
+func (m *Manifest) Roots() []Root {
+	if m == nil {
+		return nil
+	}
+	return m.roots
+}
+
+// Root returns the most specific root containing path, so a read-only
+// directory can be carved out of a writable one.
+func (m *Manifest) Root(path string) (Root, bool) {
+	if m == nil {
+		return Root{}, false
+	}
+	path = resolve(filepath.Clean(path))
+	var best Root
+	found := false
+	for _, root := range m.roots {
+		if contains(root.Path, path) && (!found || len(root.Path) > len(best.Path)) {
+			best, found = root, true
+		}
+	}
+	return best, found
+}
+
+// Check reports whether the agent may read path or, when write is set,
+// change it. path must be absolute.
+func (m *Manifest) Check(path string, write bool) error {
+	if m == nil {
+		return nil
+	}
+	root, ok := m.Root(path)
+	if !ok {
+		return fmt.Errorf("%s: %w", path, ErrOutside)
+	}
+	if write && root.Access != AccessWrite {
+		return fmt.Errorf("%s: %w %q", path, ErrReadOnly, root.Label)
+	}
+	return nil
+}
+
+func contains(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// resolve follows symlinks in path so a link inside a root cannot point the
+// agent elsewhere. Parts of path that do not exist yet are kept as is.
+func resolve(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path
+	}
+	return filepath.Join(resolve(parent), filepath.Base(path))
+}
@@ -0,0 +1,198 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// DivergenceKind is how a file of the workspace differs from a clean clone.
+type DivergenceKind string
+
+const (
+	DivergenceModified    DivergenceKind = "modified"
+	DivergenceDeleted     DivergenceKind = "deleted"
+	DivergenceTypeChanged DivergenceKind = "type_changed"
+	DivergenceAdded       DivergenceKind = "added"
+	DivergenceIgnored     DivergenceKind = "ignored"
+)
+
+// Divergence is a file that differs from a clean clone.
+type Divergence struct {
+	Path string         `json:"path"`
+	Kind DivergenceKind `json:"kind"`
+	// Expected is set when Path matches one of the expected patterns.
+	Expected bool `json:"expected,omitempty"`
+	// Hidden is set when git status in the workspace does not show the
+	// change, as with files marked assume-unchanged or skip-worktree, or
+	// excluded in .git/info/exclude.
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+// CleanReport compares a workspace with a clean clone of its HEAD commit.
+type CleanReport struct {
+	Commit      string       `json:"commit"`
+	Divergences []Divergence `json:"divergences"`
+}
+
+// Unexpected returns the divergences no expected pattern matches.
+func (r CleanReport) Unexpected() []Divergence {
+	var unexpected []Divergence
+	for _, d := range r.Divergences {
+		if !d.Expected {
+			unexpected = append(unexpected, d)
+		}
+	}
+	return unexpected
+}
+
+// String lists the divergences, one per line.
+func (r CleanReport) String() string {
+	commit := r.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	if len(r.Divergences) == 0 {
+		return fmt.Sprintf("The workspace matches a clean clone of %s.", commit)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Compared with a clean clone of %s, %d of %d changed files are unexpected:\n", commit, len(r.Unexpected()), len(r.Divergences))
+	for _, d := range r.Divergences {
+		var notes []string
+		if d.Expected {
+			notes = append(notes, "expected")
+		}
+		if d.Hidden {
+			notes = append(notes, "hidden from git status")
+		}
+		fmt.Fprintf(&b, "%-13s %s", d.Kind, d.Path)
+		if len(notes) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(notes, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// VerifyOptions configures [VerifyClean].
+type VerifyOptions struct {
+	// Expected are doublestar patterns, relative to the repository root, of
+	// the files meant to change.
+	Expected []string
+	// Ignored also reports files ignored by the committed .gitignore files,
+	// such as build output.
+	Ignored bool
+}
+
+// VerifyClean compares the repository of dir with a fresh clone at the same
+// commit. Unlike git status in the workspace, the clone has its own index
+// and local excludes, so changes the workspace hides from git are reported
+// too.
+func VerifyClean(ctx context.Context, dir string, opts VerifyOptions) (CleanReport, error) {
+	for _, pattern := range opts.Expected {
+		if !doublestar.ValidatePattern(pattern) {
+			return CleanReport{}, fmt.Errorf("invalid pattern %q", pattern)
+		}
+	}
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return CleanReport{}, err
+	}
+	repo, err := git(ctx, dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return CleanReport{}, fmt.Errorf("verifying needs a git repository: %w", err)
+	}
+	commit, err := git(ctx, repo, nil, "rev-parse", "HEAD")
+	if err != nil {
+		return CleanReport{}, fmt.Errorf("verifying needs at least one commit: %w", err)
+	}
+
+	clone, err := os.MkdirTemp("", "crush-clean-")
+	if err != nil {
+		return CleanReport{}, err
+	}
+	defer os.RemoveAll(clone)
+	// A shared clone borrows the objects of the repository, so no checkout
+	// or copy is needed: the clone's index is compared with the workspace.
+	if _, err := git(ctx, repo, nil, "clone", "--quiet", "--shared", "--no-checkout", repo, clone); err != nil {
+		return CleanReport{}, fmt.Errorf("failed to clone: %w", err)
+	}
+	if _, err := git(ctx, clone, nil, "reset", "--quiet", commit); err != nil {
+		return CleanReport{}, fmt.Errorf("failed to read commit: %w", err)
+	}
+
+	env := []string{"GIT_DIR=" + filepath.Join(clone, ".git"), "GIT_WORK_TREE=" + repo}
+	clean := func(args ...string) ([]string, error) {
+		out, err := gitEnv(ctx, repo, env, nil, append([]string{"-c", "core.excludesFile=" + os.DevNull}, args...)...)
+		return splitNull(out), err
+	}
+
+	report := CleanReport{Commit: commit}
+	diff, err := clean("diff", "--name-status", "--no-renames", "-z")
+	if err != nil {
+		return CleanReport{}, err
+	}
+	for i := 0; i+1 < len(diff); i += 2 {
+		kind := DivergenceModified
+		switch diff[i] {
+		case "D":
+			kind = DivergenceDeleted
+		case "T":
+			kind = DivergenceTypeChanged
+		}
+		report.Divergences = append(report.Divergences, Divergence{Path: diff[i+1], Kind: kind})
+	}
+	added, err := clean("ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return CleanReport{}, err
+	}
+	for _, path := range added {
+		report.Divergences = append(report.Divergences, Divergence{Path: path, Kind: DivergenceAdded})
+	}
+	if opts.Ignored {
+		ignored, err := clean("ls-files", "--others", "--ignored", "--exclude-standard", "--directory", "-z")
+		if err != nil {
+			return CleanReport{}, err
+		}
+		for _, path := range ignored {
+			report.Divergences = append(report.Divergences, Divergence{Path: path, Kind: DivergenceIgnored})
+		}
+	}
+
+	status, err := gitRaw(ctx, repo, nil, "status", "--porcelain", "--no-renames", "--untracked-files=all", "-z")
+	if err != nil {
+		return CleanReport{}, err
+	}
+	var visible []string
+	for _, entry := range splitNull(status) {
+		if len(entry) > 3 {
+			visible = append(visible, entry[3:])
+		}
+	}
+	for i, d := range report.Divergences {
+		report.Divergences[i].Expected = slices.ContainsFunc(opts.Expected, func(pattern string) bool {
+			ok, _ := doublestar.Match(pattern, strings.TrimSuffix(d.Path, "/"))
+			return ok
+		})
+		report.Divergences[i].Hidden = d.Kind != DivergenceIgnored && !slices.Contains(visible, d.Path)
+	}
+	slices.SortFunc(report.Divergences, func(a, b Divergence) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return report, nil
+}
+
+func splitNull(out string) []string {
+	var fields []string
+	for field := range strings.SplitSeq(out, "\x00") {
+		if field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
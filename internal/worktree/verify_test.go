@@ -0,0 +1,62 @@
+package worktree
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyClean(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Parallel()
+
+	repo := t.TempDir()
+	run(t, repo, "init", "--quiet")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, ".gitignore"), []byte("build/\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "pkg"), 0o755))
+	for _, name := range []string{"pkg/main.go", "pkg/gone.go", "pkg/quiet.go"} {
+		require.NoError(t, os.WriteFile(filepath.Join(repo, name), []byte("package main\n"), 0o644))
+	}
+	run(t, repo, "add", ".")
+	run(t, repo, "commit", "--quiet", "-m", "initial")
+
+	report, err := VerifyClean(t.Context(), filepath.Join(repo, "pkg"), VerifyOptions{})
+	require.NoError(t, err)
+	require.Empty(t, report.Divergences)
+	require.Contains(t, report.String(), "matches a clean clone")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "pkg", "main.go"), []byte("package main\n\n// wip\n"), 0o644))
+	require.NoError(t, os.Remove(filepath.Join(repo, "pkg", "gone.go")))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "notes.txt"), []byte("todo"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "build"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "build", "out"), []byte("bin"), 0o644))
+	// Changes git status in the workspace does not show.
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "pkg", "quiet.go"), []byte("package quiet\n"), 0o644))
+	run(t, repo, "update-index", "--assume-unchanged", "pkg/quiet.go")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, ".git", "info", "exclude"), []byte("secret.txt\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "secret.txt"), []byte("hidden"), 0o644))
+
+	report, err = VerifyClean(t.Context(), repo, VerifyOptions{Expected: []string{"pkg/*.go"}})
+	require.NoError(t, err)
+	require.Equal(t, []Divergence{
+		{Path: "notes.txt", Kind: DivergenceAdded},
+		{Path: "pkg/gone.go", Kind: DivergenceDeleted, Expected: true},
+		{Path: "pkg/main.go", Kind: DivergenceModified, Expected: true},
+		{Path: "pkg/quiet.go", Kind: DivergenceModified, Expected: true, Hidden: true},
+		{Path: "secret.txt", Kind: DivergenceAdded, Hidden: true},
+	}, report.Divergences)
+	require.Len(t, report.Unexpected(), 2)
+	require.Contains(t, report.String(), "secret.txt (hidden from git status)")
+
+	report, err = VerifyClean(t.Context(), repo, VerifyOptions{Ignored: true})
+	require.NoError(t, err)
+	require.Contains(t, report.Divergences, Divergence{Path: "build/", Kind: DivergenceIgnored})
+
+	_, err = VerifyClean(t.Context(), repo, VerifyOptions{Expected: []string{"["}})
+	require.Error(t, err)
+}
@@ -0,0 +1,242 @@
+// Package worktree runs the agent against a throwaway git worktree so the
+// real workspace is never touched, and turns what the agent changed into a
+// git-format patch.
+package worktree
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Git identity used for the snapshot and result commits. They only live in
+// the throwaway worktree and the exported patch.
+const (
+	authorName  = "Crush"
+	authorEmail = "crush@charm.land"
+)
+
+// Sandbox is a detached worktree holding a copy of the workspace, including
+// uncommitted changes and untracked files that are not ignored.
+type Sandbox struct {
+	// Dir is the directory inside the worktree that matches the directory
+	// the sandbox was created from.
+	Dir string
+	// BaseCommit is the commit the workspace was at.
+	BaseCommit string
+
+	repo     string
+	root     string
+	snapshot string
+}
+
+// FileChange is a file changed by the agent.
+type FileChange struct {
+	Path      string `json:"path"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+// Create copies the workspace that contains dir into a new worktree.
+func Create(ctx context.Context, dir string) (*Sandbox, error) {
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := git(ctx, dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("patch output needs a git repository: %w", err)
+	}
+	base, err := git(ctx, repo, nil, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("patch output needs at least one commit: %w", err)
+	}
+	rel, err := filepath.Rel(repo, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := os.MkdirTemp("", "crush-worktree-")
+	if err != nil {
+		return nil, err
+	}
+	s := &Sandbox{
+		Dir:        filepath.Join(root, rel),
+		BaseCommit: base,
+		repo:       repo,
+		root:       root,
+	}
+	if _, err := git(ctx, repo, nil, "worktree", "add", "--detach", root, base); err != nil {
+		_ = os.RemoveAll(root)
+		return nil, fmt.Errorf("failed to create worktree: %w", err)
+	}
+	if err := s.copyChanges(ctx); err != nil {
+		_ = s.Remove(context.WithoutCancel(ctx))
+		return nil, err
+	}
+	if s.snapshot, err = s.commit(ctx, "Snapshot of the workspace"); err != nil {
+		_ = s.Remove(context.WithoutCancel(ctx))
+		return nil, err
+	}
+	return s, nil
+}
+
+// copyChanges brings uncommitted and untracked files over from the
+// workspace so the agent sees it as it is.
+func (s *Sandbox) copyChanges(ctx context.Context) error {
+	diff, err := gitRaw(ctx, s.repo, nil, "diff", "--binary", "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to read uncommitted changes: %w", err)
+	}
+	if diff != "" {
+		if _, err := git(ctx, s.root, strings.NewReader(diff), "apply", "--binary", "--whitespace=nowarn"); err != nil {
+			return fmt.Errorf("failed to copy uncommitted changes: %w", err)
+		}
+	}
+
+	untracked, err := gitRaw(ctx, s.repo, nil, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return fmt.Errorf("failed to list untracked files: %w", err)
+	}
+	for name := range strings.SplitSeq(untracked, "\x00") {
+		if name == "" {
+			continue
+		}
+		if err := copyFile(filepath.Join(s.repo, name), filepath.Join(s.root, name)); err != nil {
+			return fmt.Errorf("failed to copy untracked file %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// commit records everything in the worktree and returns the commit hash.
+func (s *Sandbox) commit(ctx context.Context, message string) (string, error) {
+	if _, err := git(ctx, s.root, nil, "add", "--all"); err != nil {
+		return "", err
+	}
+	if _, err := git(ctx, s.root, nil,
+		"-c", "user.name="+authorName,
+		"-c", "user.email="+authorEmail,
+		"-c", "commit.gpgsign=false",
+		"commit", "--quiet", "--allow-empty", "--no-verify", "-m", message,
+	); err != nil {
+		return "", fmt.Errorf("failed to commit: %w", err)
+	}
+	return git(ctx, s.root, nil, "rev-parse", "HEAD")
+}
+
+// Patch commits what the agent changed with message and returns it as a
+// patch that applies with git am on top of the workspace, along with the
+// changed files. The patch is empty when nothing changed.
+func (s *Sandbox) Patch(ctx context.Context, message string) (string, []FileChange, error) {
+	if _, err := git(ctx, s.root, nil, "add", "--all"); err != nil {
+		return "", nil, err
+	}
+	if _, err := git(ctx, s.root, nil, "diff", "--cached", "--quiet"); err == nil {
+		return "", nil, nil
+	}
+	if _, err := s.commit(ctx, message); err != nil {
+		return "", nil, err
+	}
+	patch, err := gitRaw(ctx, s.root, nil, "format-patch", "--stdout", "--binary", s.snapshot+"..HEAD")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to format patch: %w", err)
+	}
+	numstat, err := git(ctx, s.root, nil, "diff", "--numstat", s.snapshot, "HEAD")
+	if err != nil {
+		return "", nil, err
+	}
+	return patch, parseNumstat(numstat), nil
+}
+
+// Remove deletes the worktree.
+func (s *Sandbox) Remove(ctx context.Context) error {
+	_, err := git(ctx, s.repo, nil, "worktree", "remove", "--force", s.root)
+	if rmErr := os.RemoveAll(s.root); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+func parseNumstat(numstat string) []FileChange {
+	var changes []FileChange
+	for line := range strings.SplitSeq(numstat, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		// Binary files report "-" for both counts.
+		additions, _ := strconv.Atoi(fields[0])
+		deletions, _ := strconv.Atoi(fields[1])
+		changes = append(changes, FileChange{Path: fields[2], Additions: additions, Deletions: deletions})
+	}
+	return changes
+}
+
+func copyFile(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// git runs a git command in dir and returns its trimmed output.
+func git(ctx context.Context, dir string, stdin io.Reader, args ...string) (string, error) {
+	out, err := gitRaw(ctx, dir, stdin, args...)
+	return strings.TrimSpace(out), err
+}
+
+// gitRaw is like git but keeps the output as is, which diffs need.
+func gitRaw(ctx context.Context, dir string, stdin io.Reader, args ...string) (string, error) {
+	return gitEnv(ctx, dir, nil, stdin, args...)
+}
+
+// gitEnv is like gitRaw and adds env to the environment of git.
+func gitEnv(ctx context.Context, dir string, env []string, stdin io.Reader, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = stdin
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,89 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return string(out)
+}
+
+func TestSandbox(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Parallel()
+
+	repo := t.TempDir()
+	run(t, repo, "init", "--quiet")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, ".gitignore"), []byte("ignored.txt\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "pkg"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "pkg", "main.go"), []byte("package main\n"), 0o644))
+	run(t, repo, "add", ".")
+	run(t, repo, "commit", "--quiet", "-m", "initial")
+
+	// Uncommitted and untracked changes are part of the sandbox, ignored
+	// files are not.
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "pkg", "main.go"), []byte("package main\n\n// wip\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "pkg", "new.go"), []byte("package main\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "ignored.txt"), []byte("secret"), 0o644))
+
+	s, err := Create(t.Context(), filepath.Join(repo, "pkg"))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, s.Remove(context.Background())) })
+
+	require.Equal(t, "pkg", filepath.Base(s.Dir))
+	got, err := os.ReadFile(filepath.Join(s.Dir, "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package main\n\n// wip\n", string(got))
+	require.FileExists(t, filepath.Join(s.Dir, "new.go"))
+	require.NoFileExists(t, filepath.Join(s.Dir, "..", "ignored.txt"))
+
+	patch, changes, err := s.Patch(t.Context(), "Nothing")
+	require.NoError(t, err)
+	require.Empty(t, patch)
+	require.Empty(t, changes)
+
+	require.NoError(t, os.WriteFile(filepath.Join(s.Dir, "main.go"), []byte("package main\n\n// wip\n\nfunc main() {}\n"), 0o644))
+	patch, changes, err = s.Patch(t.Context(), "Add main")
+	require.NoError(t, err)
+	require.Contains(t, patch, "Subject: [PATCH] Add main")
+	require.Contains(t, patch, "+func main() {}")
+	require.Equal(t, []FileChange{{Path: "pkg/main.go", Additions: 2, Deletions: 0}}, changes)
+
+	// The workspace is untouched.
+	got, err = os.ReadFile(filepath.Join(repo, "pkg", "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package main\n\n// wip\n", string(got))
+
+	// The patch applies on top of the workspace once its own changes are
+	// committed.
+	run(t, repo, "add", ".")
+	run(t, repo, "commit", "--quiet", "-m", "wip")
+	patchFile := filepath.Join(t.TempDir(), "out.patch")
+	require.NoError(t, os.WriteFile(patchFile, []byte(patch), 0o644))
+	run(t, repo, "am", "--quiet", patchFile)
+	got, err = os.ReadFile(filepath.Join(repo, "pkg", "main.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(got), "func main() {}")
+}
+
+func TestCreateOutsideRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Parallel()
+	_, err := Create(t.Context(), t.TempDir())
+	require.Error(t, err)
+}
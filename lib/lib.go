@@ -6,14 +6,32 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/acp"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/agent/prompt"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/artifact"
+	"github.com/charmbracelet/crush/internal/commands"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/connectivity"
 	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/editor"
+	"github.com/charmbracelet/crush/internal/export"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/staging"
+	"github.com/charmbracelet/crush/internal/toolstats"
 	"github.com/charmbracelet/crush/internal/ui/anim"
 	"github.com/charmbracelet/crush/internal/ui/common"
 	ui "github.com/charmbracelet/crush/internal/ui/model"
@@ -31,6 +49,351 @@ type Config = config.Config
 // App is the main Crush application instance.
 type App = app.App
 
+// ContextFile is a context (memory) file merged into the system prompt.
+type ContextFile = prompt.ContextFile
+
+// Attachment is a file sent along with a prompt, such as an image or PDF.
+type Attachment = message.Attachment
+
+// NewAttachmentFromFile reads a file into an [Attachment] for
+// [App.SendMessage].
+func NewAttachmentFromFile(path string) (Attachment, error) {
+	return message.NewAttachmentFromFile(path)
+}
+
+// ContextUsage reports how much of the model's context window a session uses.
+type ContextUsage = agent.ContextUsage
+
+// JobSpec describes a task for [SubmitJob].
+type JobSpec = app.JobSpec
+
+// Job is the state of a submitted job, including the resulting patch once
+// it has finished.
+type Job = app.Job
+
+// JobStatus is the state of a [Job].
+type JobStatus = app.JobStatus
+
+// Job statuses.
+const (
+	JobQueued    = app.JobQueued
+	JobRunning   = app.JobRunning
+	JobSucceeded = app.JobSucceeded
+	JobFailed    = app.JobFailed
+	JobCanceled  = app.JobCanceled
+)
+
+// ErrJobNotFound is returned for unknown job IDs.
+var ErrJobNotFound = app.ErrJobNotFound
+
+// ErrNothingStaged is returned by App.GenerateCommitMessage when no changes
+// are staged.
+var ErrNothingStaged = app.ErrNothingStaged
+
+// SubmitJob queues a task to run in the background of a long-running app
+// and returns its ID right away. Jobs run one at a time and are kept in
+// memory until the app shuts down.
+func SubmitJob(ctx context.Context, appInstance *App, spec JobSpec) (string, error) {
+	return appInstance.SubmitJob(ctx, spec)
+}
+
+// GetJob returns the current state of a job.
+func GetJob(appInstance *App, id string) (Job, error) {
+	return appInstance.Job(id)
+}
+
+// CancelJob stops a queued or running job.
+func CancelJob(appInstance *App, id string) error {
+	return appInstance.CancelJob(id)
+}
+
+// Initialization is the result of [InitializeProject].
+type Initialization = app.Initialization
+
+// InitializeProject has the agent analyze the project and write a starter
+// context file, named by options.initialize_as, describing its build, test
+// commands and conventions. Set Options.InitializeAs on the config before
+// creating the app to write another file, such as CRUSH.md.
+func InitializeProject(ctx context.Context, appInstance *App) (Initialization, error) {
+	return appInstance.InitializeProject(ctx)
+}
+
+// EditorProtocolVersion is the version of the JSON-RPC protocol editor
+// plugins speak on the socket enabled by the editor section of the
+// configuration. It changes whenever a change would break existing clients.
+const EditorProtocolVersion = editor.ProtocolVersion
+
+// Methods and notifications of the editor protocol.
+const (
+	EditorMethodInitialize    = editor.MethodInitialize
+	EditorMethodSessionCreate = editor.MethodSessionCreate
+	EditorMethodSessionList   = editor.MethodSessionList
+	EditorMethodSessionPrompt = editor.MethodSessionPrompt
+	EditorMethodSessionCancel = editor.MethodSessionCancel
+	EditorMethodEditList      = editor.MethodEditList
+	EditorMethodEditApply     = editor.MethodEditApply
+	EditorMethodEditDiscard   = editor.MethodEditDiscard
+
+	EditorNotifyMessage      = editor.NotifyMessage
+	EditorNotifyEditProposed = editor.NotifyEditProposed
+	EditorNotifyEditResolved = editor.NotifyEditResolved
+)
+
+// Messages of the editor protocol.
+type (
+	EditorInitializeParams    = editor.InitializeParams
+	EditorInitializeResult    = editor.InitializeResult
+	EditorCreateSessionParams = editor.CreateSessionParams
+	EditorSessionParams       = editor.SessionParams
+	EditorSessionInfo         = editor.SessionInfo
+	EditorSelection           = editor.Selection
+	EditorPromptParams        = editor.PromptParams
+	EditorPromptResult        = editor.PromptResult
+	EditorEditParams          = editor.EditParams
+	EditorProposedEdit        = editor.ProposedEdit
+	EditorMessageUpdate       = editor.MessageUpdate
+)
+
+// EditorSocketPath returns where the app of the project with this data
+// directory serves editor plugins, unless editor.socket says otherwise.
+func EditorSocketPath(dataDir string) string {
+	return editor.SocketPath(dataDir)
+}
+
+// ServeACP runs the app as an Agent Client Protocol agent, as crush acp does,
+// speaking with the client on r and w until it hangs up.
+func ServeACP(ctx context.Context, r io.Reader, w io.Writer, appInstance *App) error {
+	return acp.Serve(ctx, r, w, appInstance.Config().WorkingDir(), appInstance)
+}
+
+// Mode controls whether the agent may change the workspace.
+type Mode = tools.Mode
+
+// Agent modes.
+const (
+	// ModeBuild lets the agent edit files and run commands.
+	ModeBuild = tools.ModeBuild
+	// ModePlan returns edits as proposed diffs and only runs read-only
+	// commands.
+	ModePlan = tools.ModePlan
+	// ModeReview stages edits until they are applied with [App.ApplyEdit].
+	ModeReview = tools.ModeReview
+)
+
+// StagedEdit is a file edit staged in review mode, listed by
+// [App.PendingEdits].
+type StagedEdit = staging.Edit
+
+// ErrEditConflict is returned by [App.ApplyEdit] when the file changed
+// after the edit was staged.
+var ErrEditConflict = staging.ErrConflict
+
+// Artifact is a file the agent generated with the emit_artifact tool,
+// listed by [App.Artifacts] and copied to the workspace by
+// [App.ExportArtifact].
+type Artifact = artifact.Artifact
+
+// ErrArtifactExists is returned by [App.ExportArtifact] rather than
+// overwrite a file.
+var ErrArtifactExists = artifact.ErrExists
+
+// MCPPrompt is a prompt of an MCP server, listed by [App.MCPPrompts] and
+// filled in by [App.RenderMCPPrompt].
+type MCPPrompt = commands.MCPPrompt
+
+// MCPResource is a resource of an MCP server, listed by [App.MCPResources]
+// and attached with [App.MCPResourceAttachment].
+type MCPResource = mcp.Resource
+
+// SetMode switches the agent between build, plan and review mode. It applies to
+// the next tool call, including in runs already in progress.
+func SetMode(appInstance *App, mode Mode) error {
+	return appInstance.SetMode(mode)
+}
+
+// SetChatOnly turns chat-only mode on or off for a session, so it runs as a
+// plain chat without filesystem or shell tools. Web tools stay available
+// unless disabled in the config.
+func SetChatOnly(ctx context.Context, appInstance *App, sessionID string, chatOnly bool) error {
+	return appInstance.SetChatOnly(ctx, sessionID, chatOnly)
+}
+
+// SetTranslate turns translation on or off for a session, so prompts in
+// other languages reach the model in English and its replies come back in
+// the language of the prompt.
+func SetTranslate(ctx context.Context, appInstance *App, sessionID string, translate bool) error {
+	return appInstance.SetTranslate(ctx, sessionID, translate)
+}
+
+// RenameSession sets the title of a session. Sessions are otherwise titled
+// by the small model after their first exchange.
+func RenameSession(ctx context.Context, appInstance *App, sessionID, title string) error {
+	return appInstance.RenameSession(ctx, sessionID, title)
+}
+
+// Session is a conversation with the agent.
+type Session = session.Session
+
+// SessionFilter narrows the sessions returned by [ListSessions] by tag,
+// project and date range. [ParseSessionFilter] builds one from a query such
+// as "#bug project:api after:7d".
+type SessionFilter = session.Filter
+
+// ParseSessionFilter reads the filter terms of a query and returns the
+// filter and the remaining text.
+func ParseSessionFilter(query string) (SessionFilter, string) {
+	return session.ParseFilter(query, time.Now())
+}
+
+// TagSession replaces the tags of a session; passing none clears them.
+func TagSession(ctx context.Context, appInstance *App, sessionID string, tags ...string) error {
+	return appInstance.TagSession(ctx, sessionID, tags...)
+}
+
+// PinSession pins a session to the top of the session switcher, or unpins
+// it.
+func PinSession(ctx context.Context, appInstance *App, sessionID string, pinned bool) error {
+	return appInstance.PinSession(ctx, sessionID, pinned)
+}
+
+// LabelUsage is the tokens and cost of the turns sent under one label.
+type LabelUsage = session.LabelUsage
+
+// LabelSession sets the label, such as a ticket ID, the next turns of a
+// session are counted under; an empty label stops labeling them.
+func LabelSession(ctx context.Context, appInstance *App, sessionID, label string) error {
+	return appInstance.LabelSession(ctx, sessionID, label)
+}
+
+// UsageByLabel returns the tokens and cost of all turns grouped by label.
+func UsageByLabel(ctx context.Context, appInstance *App) ([]LabelUsage, error) {
+	return appInstance.UsageByLabel(ctx)
+}
+
+// ErrNoSessions is returned by [ResumeLastSession] when the working directory
+// has no sessions yet.
+var ErrNoSessions = app.ErrNoSessions
+
+// ResumeLastSession finds the most recent session of the working directory
+// and has [RunTUI] open it instead of a new session.
+func ResumeLastSession(ctx context.Context, appInstance *App) (Session, error) {
+	return appInstance.ResumeLastSession(ctx)
+}
+
+// ListSessions returns the sessions that pass filter, pinned sessions first,
+// then the most recently updated.
+func ListSessions(ctx context.Context, appInstance *App, filter SessionFilter) ([]Session, error) {
+	return appInstance.ListSessions(ctx, filter)
+}
+
+// RegenerateMode is what [EditAndRegenerate] does with the messages that
+// follow the edited one.
+type RegenerateMode = app.RegenerateMode
+
+const (
+	// RegenerateTruncate deletes the edited message and everything after it.
+	RegenerateTruncate = app.RegenerateTruncate
+	// RegenerateBranch keeps the session and continues in a new one holding
+	// the history before the edited message.
+	RegenerateBranch = app.RegenerateBranch
+)
+
+// EditAndRegenerate replaces the text of an earlier user message and waits
+// for the agent to answer it again. It returns the session the answer was
+// written to, which is new when branching.
+func EditAndRegenerate(ctx context.Context, appInstance *App, messageID, newText string, mode RegenerateMode) (Session, *fantasy.AgentResult, error) {
+	return appInstance.EditAndRegenerate(ctx, messageID, newText, mode)
+}
+
+// QueueMessage sends prompt to a session without waiting for the answer. If
+// the agent is working in the session the prompt is queued until the
+// options.queued_prompts setting lets it through.
+func QueueMessage(appInstance *App, sessionID, prompt string, attachments []Attachment) error {
+	return appInstance.QueueMessage(sessionID, prompt, attachments)
+}
+
+// ErrNotBusy is returned by [Interrupt] when the agent is not working in the
+// session.
+var ErrNotBusy = app.ErrNotBusy
+
+// Interrupt stops the answer or tool the agent is running in a session and
+// has it continue with note, keeping the work done so far.
+func Interrupt(appInstance *App, sessionID, note string) error {
+	return appInstance.Interrupt(sessionID, note)
+}
+
+// ExportFormat is the format of a transcript written by [ExportTranscript].
+type ExportFormat = export.Format
+
+const (
+	// ExportMarkdown exports a transcript as Markdown.
+	ExportMarkdown = export.Markdown
+	// ExportHTML exports a transcript as a standalone HTML page with
+	// highlighted code and the tool outputs collapsed.
+	ExportHTML = export.HTML
+)
+
+// ExportTranscript renders the conversation of a session as a document to
+// share.
+func ExportTranscript(ctx context.Context, appInstance *App, sessionID string, format ExportFormat) ([]byte, error) {
+	return appInstance.ExportTranscript(ctx, sessionID, format)
+}
+
+// ShareSession publishes a redacted transcript of a session to the endpoint
+// of options.share, or writes it as a standalone HTML file when there is
+// none, and returns its link. Callers are expected to have the user
+// confirm first.
+func ShareSession(ctx context.Context, appInstance *App, sessionID string) (string, error) {
+	return appInstance.ShareSession(ctx, sessionID)
+}
+
+// ModelRole is the role a model is selected for with [SetModel].
+type ModelRole = config.SelectedModelType
+
+const (
+	// ModelLarge runs the main agent.
+	ModelLarge ModelRole = config.SelectedModelTypeLarge
+	// ModelSmall runs quick side tasks, such as fetching web pages.
+	ModelSmall ModelRole = config.SelectedModelTypeSmall
+	// ModelTitle titles sessions, falling back to ModelSmall.
+	ModelTitle ModelRole = config.SelectedModelTypeTitle
+	// ModelSummary summarizes long sessions, falling back to the
+	// summary_model option.
+	ModelSummary ModelRole = config.SelectedModelTypeSummary
+	// ModelTask runs sub-agents, falling back to ModelLarge.
+	ModelTask ModelRole = config.SelectedModelTypeTask
+)
+
+// ErrModelNotFound is returned by [SetModel] for a model no configured
+// provider has.
+var ErrModelNotFound = app.ErrModelNotFound
+
+// SetModel selects the model of a provider for role at runtime and saves
+// the choice in the config.
+func SetModel(ctx context.Context, appInstance *App, role ModelRole, providerID, modelID string) error {
+	return appInstance.SetModel(ctx, role, providerID, modelID)
+}
+
+// ConnectivityEvent reports that the providers can no longer be reached,
+// or can again, and how many turns wait for them.
+type ConnectivityEvent = connectivity.Event
+
+// SubscribeConnectivity returns a channel that receives an event every time
+// connectivity is lost or comes back.
+func SubscribeConnectivity(ctx context.Context) <-chan pubsub.Event[ConnectivityEvent] {
+	return connectivity.Subscribe(ctx)
+}
+
+// ToolStat summarizes the executions of a tool: calls, failures and latency
+// percentiles.
+type ToolStat = toolstats.Stats
+
+// ToolStats returns the execution statistics of every tool called in a
+// session, or across all sessions of the app when sessionID is empty.
+func ToolStats(appInstance *App, sessionID string) []ToolStat {
+	return appInstance.ToolStats(sessionID)
+}
+
 // NewConfig creates a new configuration with the given working directory.
 // The data directory will be created as <cwd>/.crush if not specified.
 func NewConfig(cwd, dataDir string, debug bool) (*Config, error) {
@@ -125,9 +488,17 @@ func supportsProgressBar() bool {
 	return isWindowsTerminal || strings.Contains(strings.ToLower(termProg), "ghostty")
 }
 
-// Styles returns the default styles for the TUI.
-func Styles() styles.Styles {
-	return styles.DefaultStyles()
+// Styles returns the styles of the TUI in the theme called name: one of
+// [Themes], or the default theme when name is empty.
+func Styles(name string) (styles.Styles, error) {
+	return common.LoadThemes().Styles(name)
+}
+
+// Themes returns the names of the themes, the bundled dark and light ones
+// first, then the user themes of the themes directory next to the global
+// config.
+func Themes() []string {
+	return common.LoadThemes().Names()
 }
 
 // NewSpinner creates a new spinner for the TUI.